@@ -0,0 +1,25 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+func (s *Server) CheckPorts(ctx context.Context, in *idl.CheckPortsRequest) (*idl.CheckPortsReply, error) {
+	var ports []int
+	for _, port := range in.Ports {
+		ports = append(ports, int(port))
+	}
+
+	reply := new(idl.CheckPortsReply)
+	for _, port := range utils.PortsInUse(ports) {
+		reply.InUse = append(reply.InUse, uint32(port))
+	}
+
+	return reply, nil
+}
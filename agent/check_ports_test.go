@@ -0,0 +1,42 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+func TestCheckPorts(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listening: %+v", err)
+	}
+	defer listener.Close()
+	busy := uint32(listener.Addr().(*net.TCPAddr).Port)
+
+	free, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listening: %+v", err)
+	}
+	freePort := uint32(free.Addr().(*net.TCPAddr).Port)
+	free.Close()
+
+	server := agent.NewServer(agent.Config{})
+	reply, err := server.CheckPorts(context.Background(), &idl.CheckPortsRequest{Ports: []uint32{busy, freePort}})
+	if err != nil {
+		t.Fatalf("CheckPorts() returned error %+v", err)
+	}
+
+	expected := &idl.CheckPortsReply{InUse: []uint32{busy}}
+	if !proto.Equal(reply, expected) {
+		t.Errorf("got reply %v want %v", reply, expected)
+	}
+}
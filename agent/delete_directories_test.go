@@ -20,6 +20,9 @@ import (
 
 func TestDeleteDataDirectories(t *testing.T) {
 	testhelper.SetupTestLogger()
+	defer func() {
+		utils.System = utils.InitializeSystemFunctions()
+	}()
 
 	t.Run("deletes the data directories", func(t *testing.T) {
 		var actualDirectories, actualRequiredPaths []string
@@ -92,6 +95,9 @@ func TestDeleteDataDirectories(t *testing.T) {
 
 func TestDeleteStateDirectory(t *testing.T) {
 	testhelper.SetupTestLogger()
+	defer func() {
+		utils.System = utils.InitializeSystemFunctions()
+	}()
 
 	t.Run("deletes the state directory", func(t *testing.T) {
 		var actualDirectories, actualRequiredPaths []string
@@ -11,10 +11,82 @@ import (
 )
 
 func (s *Server) CheckDiskSpace(ctx context.Context, in *idl.CheckSegmentDiskSpaceRequest) (*idl.CheckDiskSpaceReply, error) {
-	failed, err := disk.CheckUsage(disk.Local, in.Request.Ratio, in.Datadirs...)
+	var failed disk.SpaceFailures
+	var err error
+
+	if in.Request.Estimate {
+		failed, err = estimateDiskSpace(disk.Local, in)
+	} else {
+		failed, err = checkDiskSpace(disk.Local, in)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	return &idl.CheckDiskSpaceReply{Failed: failed}, nil
 }
+
+// checkDiskSpace checks the free space of the filesystems holding the data
+// directories and tablespaces of this host, and the directory the master
+// tablespaces will be copied to.
+func checkDiskSpace(d disk.Disk, in *idl.CheckSegmentDiskSpaceRequest) (disk.SpaceFailures, error) {
+	var paths []string
+	paths = append(paths, in.Datadirs...)
+	paths = append(paths, in.Tablespaces...)
+
+	if dir := in.GetMasterTablespaces().GetDir(); dir != "" {
+		existing, err := disk.ExistingDir(d, dir)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, existing)
+	}
+
+	return disk.CheckUsage(d, in.Request.Ratio, paths...)
+}
+
+// estimateDiskSpace checks that the filesystems of this host have room for
+// the target primaries and mirrors with their tablespaces, and for the copies
+// of the upgraded master and its tablespaces. On the master host it also
+// checks for the backup of the target master.
+func estimateDiskSpace(d disk.Disk, in *idl.CheckSegmentDiskSpaceRequest) (disk.SpaceFailures, error) {
+	var needs []disk.Need
+
+	add := func(paths []string, linked bool) error {
+		for _, path := range paths {
+			n, err := disk.UpgradeNeeds(d, path, linked)
+			if err != nil {
+				return err
+			}
+			needs = append(needs, n...)
+		}
+		return nil
+	}
+
+	if err := add(in.Datadirs, in.LinkMode); err != nil {
+		return nil, err
+	}
+	if err := add(in.Tablespaces, in.LinkMode); err != nil {
+		return nil, err
+	}
+	if err := add(in.MirrorDatadirs, false); err != nil {
+		return nil, err
+	}
+	if err := add(in.MirrorTablespaces, false); err != nil {
+		return nil, err
+	}
+
+	for _, copy := range []*idl.CheckSegmentDiskSpaceRequest_MasterCopy{in.MasterBackup, in.MasterTablespaces, in.TargetMasterBackup} {
+		if copy.GetDir() == "" {
+			continue
+		}
+
+		dir, err := disk.ExistingDir(d, copy.Dir)
+		if err != nil {
+			return nil, err
+		}
+		needs = append(needs, disk.Need{Path: dir, Bytes: copy.Bytes, Files: copy.Files})
+	}
+
+	return disk.CheckNeeds(d, needs...)
+}
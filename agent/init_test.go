@@ -4,9 +4,16 @@
 package agent
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
 )
 
 // TODO: migrate to a shared exectest implementation of the simple
@@ -19,26 +26,64 @@ func FailedMain() {
 	os.Exit(1)
 }
 
-func FailedRsync() {
-	os.Stderr.WriteString("rsync failed cause I said so")
-	os.Exit(2)
+// Runs until it is terminated.
+func SleepingMain() {
+	time.Sleep(30 * time.Second)
+}
+
+// Writes progress like pg_upgrade, leaving the final line unterminated.
+func UpgradeOutputMain() {
+	os.Stdout.WriteString("Performing Upgrade\n")
+	os.Stdout.WriteString("Copying user relation files ")
+	os.Stdout.WriteString("ok\nUpgrade Complete")
+	os.Stderr.WriteString("warning\n")
+}
+
+// Records how many copies of itself are running at once. The working directory
+// is a segment's pg_upgrade directory, so the markers are kept in its parent
+// where the copies for every segment can see them.
+func ConcurrencyTrackingMain() {
+	wd, err := os.Getwd()
+	if err != nil {
+		os.Exit(1)
+	}
+
+	dir := filepath.Dir(wd)
+	marker := filepath.Join(dir, fmt.Sprintf("running-%d", os.Getpid()))
+	if err := ioutil.WriteFile(marker, nil, 0600); err != nil {
+		os.Exit(1)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	running, err := filepath.Glob(filepath.Join(dir, "running-*"))
+	if err != nil {
+		os.Exit(1)
+	}
+
+	count := filepath.Join(dir, fmt.Sprintf("count-%d", os.Getpid()))
+	if err := ioutil.WriteFile(count, []byte(strconv.Itoa(len(running))), 0600); err != nil {
+		os.Exit(1)
+	}
+
+	os.Remove(marker)
 }
 
 func init() {
 	exectest.RegisterMains(
 		Success,
 		FailedMain,
-		FailedRsync,
+		SleepingMain,
+		UpgradeOutputMain,
+		ConcurrencyTrackingMain,
 	)
 }
 
-//
 // Override internals of the agent package
-//
 func SetExecCommand(command exectest.Command) {
 	execCommand = command
 }
 
-func SetRsyncCommand(command exectest.Command) {
-	rsyncCommand = command
+func SetSyncDirectory(sync func(context.Context, string, string, dirsync.Options) (dirsync.Stats, error)) {
+	syncDirectory = sync
 }
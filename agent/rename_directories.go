@@ -28,3 +28,21 @@ func (s *Server) RenameDirectories(ctx context.Context, in *idl.RenameDirectorie
 
 	return &idl.RenameDirectoriesReply{}, mErr.ErrorOrNil()
 }
+
+var CheckArchiveSource = upgrade.CheckArchiveSource
+
+// CheckRenameDirectories reports any directories that RenameDirectories
+// cannot safely rename, for example after an interrupted rename.
+func (s *Server) CheckRenameDirectories(ctx context.Context, in *idl.RenameDirectoriesRequest) (*idl.CheckRenameDirectoriesReply, error) {
+	gplog.Info("agent received request to check renaming of segment data directories")
+
+	reply := &idl.CheckRenameDirectoriesReply{}
+	for _, dir := range in.GetDirs() {
+		err := CheckArchiveSource(dir.GetSource(), dir.GetTarget(), dir.GetRenameTarget())
+		if err != nil {
+			reply.Problems = append(reply.Problems, err.Error())
+		}
+	}
+
+	return reply, nil
+}
@@ -6,6 +6,7 @@ package agent_test
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/greenplum-db/gp-common-go-libs/testhelper"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/greenplum-db/gpupgrade/agent"
 	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/upgrade"
 )
 
 func TestRenameDirectories(t *testing.T) {
@@ -38,3 +40,33 @@ func TestRenameDirectories(t *testing.T) {
 		}
 	})
 }
+
+func TestCheckRenameDirectories(t *testing.T) {
+	testhelper.SetupTestLogger()
+	server := agent.NewServer(agent.Config{})
+
+	defer func() {
+		agent.CheckArchiveSource = upgrade.CheckArchiveSource
+	}()
+
+	t.Run("reports each directory that cannot be renamed", func(t *testing.T) {
+		agent.CheckArchiveSource = func(source, target string, renameTarget bool) error {
+			if source == "/data/bad" {
+				return errors.New("half renamed")
+			}
+			return nil
+		}
+
+		reply, err := server.CheckRenameDirectories(context.Background(), &idl.RenameDirectoriesRequest{
+			Dirs: []*idl.RenameDirectories{{Source: "/data/good"}, {Source: "/data/bad"}},
+		})
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+
+		expected := []string{"half renamed"}
+		if !reflect.DeepEqual(reply.GetProblems(), expected) {
+			t.Errorf("got problems %q want %q", reply.GetProblems(), expected)
+		}
+	})
+}
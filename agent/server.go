@@ -13,11 +13,16 @@ import (
 
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"google.golang.org/grpc"
+	grpcHealth "google.golang.org/grpc/health"
 	"google.golang.org/grpc/reflection"
 
 	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 	"github.com/greenplum-db/gpupgrade/utils/daemon"
+	"github.com/greenplum-db/gpupgrade/utils/handshake"
+	"github.com/greenplum-db/gpupgrade/utils/health"
 	"github.com/greenplum-db/gpupgrade/utils/log"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
 type Server struct {
@@ -25,6 +30,7 @@ type Server struct {
 
 	mu      sync.Mutex
 	server  *grpc.Server
+	health  *grpcHealth.Server
 	lis     net.Listener
 	stopped chan struct{}
 	daemon  bool
@@ -33,6 +39,19 @@ type Server struct {
 type Config struct {
 	Port     int
 	StateDir string
+
+	// TLS holds the certificates used to authenticate the hub. When it is
+	// empty, connections are insecure.
+	TLS certs.Config
+
+	// MetricsAddress and MetricsPort are the address and port that metrics
+	// are served on. Metrics are not served when the port is zero; see
+	// metrics.Listen for the default address.
+	MetricsAddress string
+	MetricsPort    int
+
+	// Version is the version of gpupgrade that the agent reports to the hub.
+	Version string
 }
 
 func NewServer(conf Config) *Server {
@@ -59,12 +78,28 @@ func (s *Server) Start() {
 	// handlers.
 	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		defer log.WritePanics()
-		return handler(ctx, req)
+		health.SendVersion(ctx, s.conf.Version)
+		return metrics.UnaryServerInterceptor(ctx, req, info, handler)
+	}
+	tlsOpts, err := certs.ServerOptions(s.conf.TLS)
+	if err != nil {
+		gplog.Fatal(err, "failed to load TLS certificates")
+	}
+	server := grpc.NewServer(append(tlsOpts,
+		grpc.UnaryInterceptor(interceptor),
+		grpc.StreamInterceptor(metrics.StreamServerInterceptor))...)
+
+	if s.conf.MetricsPort != 0 {
+		metricsServer, err := metrics.Listen(s.conf.MetricsAddress, s.conf.MetricsPort, metrics.Default)
+		if err != nil {
+			gplog.Fatal(err, "failed to serve metrics")
+		}
+		defer metricsServer.Close()
 	}
-	server := grpc.NewServer(grpc.UnaryInterceptor(interceptor))
 
 	s.mu.Lock()
 	s.server = server
+	s.health = health.Register(server)
 	s.lis = lis
 	s.mu.Unlock()
 
@@ -89,6 +124,12 @@ func (s *Server) Start() {
 	s.stopped <- struct{}{}
 }
 
+// Hello reports the agent's version, so the hub can check that it matches its
+// own.
+func (s *Server) Hello(ctx context.Context, in *idl.HelloRequest) (*idl.HelloReply, error) {
+	return handshake.Reply(s.conf.Version), nil
+}
+
 func (s *Server) StopAgent(ctx context.Context, in *idl.StopAgentRequest) (*idl.StopAgentReply, error) {
 	s.Stop()
 	return &idl.StopAgentReply{}, nil
@@ -99,6 +140,9 @@ func (s *Server) Stop() {
 	defer s.mu.Unlock()
 
 	if s.server != nil {
+		// Tell anyone watching that the agent is going away.
+		s.health.Shutdown()
+
 		s.server.Stop()
 		<-s.stopped
 	}
@@ -4,16 +4,24 @@
 package agent_test
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/greenplum-db/gp-common-go-libs/testhelper"
 	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/utils/handshake"
+	"github.com/greenplum-db/gpupgrade/utils/health"
 )
 
 func TestServerStart(t *testing.T) {
@@ -65,6 +73,50 @@ func TestServerStart(t *testing.T) {
 			t.Error("expected stateDir to exist")
 		}
 	})
+
+	t.Run("serves the health service and handshake and reports its version", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, ".gpupgrade")
+		defer os.RemoveAll(stateDir)
+
+		port := testutils.MustGetPort(t)
+		server := agent.NewServer(agent.Config{
+			Port:     port,
+			StateDir: stateDir,
+			Version:  "1.2.3",
+		})
+
+		go server.Start()
+		defer server.Stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		conn, err := grpc.DialContext(ctx, fmt.Sprintf("localhost:%d", port), grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			t.Fatalf("dialing agent: %+v", err)
+		}
+		defer conn.Close()
+
+		status, version, err := health.Check(ctx, grpc_health_v1.NewHealthClient(conn))
+		if err != nil {
+			t.Fatalf("Check() returned error %+v", err)
+		}
+
+		if status != grpc_health_v1.HealthCheckResponse_SERVING {
+			t.Errorf("got status %s want %s", status, grpc_health_v1.HealthCheckResponse_SERVING)
+		}
+		if version != "1.2.3" {
+			t.Errorf("got version %q want %q", version, "1.2.3")
+		}
+
+		reply, err := handshake.Hello(ctx, "the agent", "1.2.3", idl.NewAgentClient(conn).Hello)
+		if err != nil {
+			t.Errorf("Hello() returned error %+v", err)
+		}
+		if !reflect.DeepEqual(reply.GetCapabilities(), handshake.Capabilities) {
+			t.Errorf("got capabilities %q want %q", reply.GetCapabilities(), handshake.Capabilities)
+		}
+	})
 }
 
 func doesPathEventuallyExist(path string) (bool, error) {
@@ -0,0 +1,104 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// lockedSender serializes the Sends of segments that are upgraded
+// concurrently, since a gRPC stream may not be sent to from multiple
+// goroutines at once.
+type lockedSender struct {
+	mu     sync.Mutex
+	sender idl.MessageSender
+}
+
+func (s *lockedSender) Send(msg *idl.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sender.Send(msg)
+}
+
+// sendResult sends the result of a segment's upgrade back to the hub. As with
+// the segment's output, errors are logged and otherwise ignored.
+func sendResult(sender idl.MessageSender, result *idl.SegmentResult) {
+	err := sender.Send(&idl.Message{Contents: &idl.Message_SegmentResult{SegmentResult: result}})
+	if err != nil {
+		gplog.Info("sending result for content %d: %v", result.Content, err)
+	}
+}
+
+// segmentWriter sends everything written to it back to the hub as Chunks
+// tagged with the host and content ID of a segment. Each line is sent as a
+// separate Chunk, so that the hub can merge the output of many segments
+// without interleaving their lines.
+type segmentWriter struct {
+	sender  idl.MessageSender
+	host    string
+	content int32
+	cType   idl.Chunk_Type
+	partial []byte // the last, unterminated line written
+}
+
+func newSegmentWriter(sender idl.MessageSender, host string, content int32, cType idl.Chunk_Type) *segmentWriter {
+	return &segmentWriter{
+		sender:  sender,
+		host:    host,
+		content: content,
+		cType:   cType,
+	}
+}
+
+func (w *segmentWriter) Write(p []byte) (int, error) {
+	buf := append(w.partial, p...)
+
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.send(buf[:i+1])
+		buf = buf[i+1:]
+	}
+
+	w.partial = append([]byte(nil), buf...)
+	return len(p), nil
+}
+
+// Flush sends any unterminated line that remains.
+func (w *segmentWriter) Flush() {
+	if len(w.partial) > 0 {
+		w.send(w.partial)
+		w.partial = nil
+	}
+}
+
+func (w *segmentWriter) send(line []byte) {
+	if w.sender == nil {
+		return
+	}
+
+	// Since the hub may disconnect at any point, errors here are logged and
+	// otherwise ignored so that the upgrade is not interrupted. After the first
+	// send error, no more attempts are made.
+	err := w.sender.Send(&idl.Message{Contents: &idl.Message_Chunk{Chunk: &idl.Chunk{
+		Buffer:  append([]byte(nil), line...),
+		Type:    w.cType,
+		Host:    w.host,
+		Content: w.content,
+	}}})
+
+	if err != nil {
+		gplog.Info("halting output stream for content %d: %v", w.content, err)
+		w.sender = nil
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"io/ioutil"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // the hub compresses SyncDirectory
+	"google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
+)
+
+var syncDirectory = dirsync.Sync
+
+var copiedBytes = metrics.Default.NewCounter("gpupgrade_agent_copied_bytes_total",
+	"Size of the files copied by the agent on each host.", "host")
+
+// CopyDirectory mirrors sourceDir into targetDir, leaving out excludedFiles.
+// Cancelling ctx stops the copy.
+func CopyDirectory(ctx context.Context, sourceDir, targetDir string, excludedFiles []string) error {
+	stats, err := syncDirectory(ctx, sourceDir, targetDir, dirsync.Options{
+		Delete:  true,
+		Exclude: excludedFiles,
+	})
+
+	recordCopiedBytes(stats.Bytes)
+
+	if err != nil {
+		return xerrors.Errorf("copying %q to %q: %w", sourceDir, targetDir, err)
+	}
+
+	return nil
+}
+
+// neededBatchSize is the most paths sent to the hub in one message.
+const neededBatchSize = 1000
+
+// SyncDirectory mirrors the files streamed by the hub into the target
+// directory of the request. Once it has received every entry, it asks the hub
+// for the contents of only the files that differ from those in the target.
+func (s *Server) SyncDirectory(stream idl.Agent_SyncDirectoryServer) error {
+	gplog.Info("got a request to sync a directory from the hub")
+
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	options := msg.GetOptions()
+	if options == nil {
+		return status.Error(codes.InvalidArgument, "the first message must contain the sync options")
+	}
+
+	r := dirsync.NewReceiver(options.TargetDir, dirsync.OptionsFromProto(options))
+
+	entries, needed, err := receiveEntries(stream, r)
+	if err != nil {
+		return err
+	}
+
+	if err := sendNeeded(stream, needed); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if len(needed) == 0 || e.Type != dirsync.Regular || needed[0] != e.Path {
+			if err := r.Apply(e, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		needed = needed[1:]
+
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+
+		if msg.GetContentsOf() != e.Path {
+			return status.Errorf(codes.InvalidArgument, "expected the contents of %q, got %v", e.Path, msg.Contents)
+		}
+
+		contents := &streamReader{stream: stream, hash: sha256.New()}
+		err = r.Apply(e, func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(contents), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// The contents may go unread if the file has changed since it was
+		// found to differ, but they must still be consumed to get to the
+		// next file.
+		if _, err := io.Copy(ioutil.Discard, contents); err != nil {
+			return xerrors.Errorf("receiving %q: %w", e.Path, err)
+		}
+	}
+
+	stats, err := r.Finish()
+	recordCopiedBytes(stats.Bytes)
+	if err != nil {
+		return err
+	}
+
+	return stream.Send(&idl.SyncDirectoryReply{Contents: &idl.SyncDirectoryReply_Stats{
+		Stats: &idl.SyncStats{
+			Files:   stats.Files,
+			Bytes:   stats.Bytes,
+			Deleted: stats.Deleted,
+		},
+	}})
+}
+
+// receiveEntries receives the entries streamed by the hub, along with the
+// paths of those whose contents are needed, in order.
+func receiveEntries(stream idl.Agent_SyncDirectoryServer, r *dirsync.Receiver) ([]dirsync.Entry, []string, error) {
+	var entries []dirsync.Entry
+	var needed []string
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil, nil, status.Error(codes.InvalidArgument, "the stream ended before the last entry")
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if msg.GetEntriesDone() {
+			return entries, needed, nil
+		}
+
+		entry := msg.GetEntry()
+		if entry == nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "expected a file entry, got %T", msg.Contents)
+		}
+
+		e := dirsync.FromProto(entry)
+		if r.Needs(e) {
+			needed = append(needed, e.Path)
+		}
+		entries = append(entries, e)
+	}
+}
+
+// sendNeeded sends the paths of the files whose contents are needed, in
+// batches of neededBatchSize.
+func sendNeeded(stream idl.Agent_SyncDirectoryServer, paths []string) error {
+	for {
+		n := len(paths)
+		if n > neededBatchSize {
+			n = neededBatchSize
+		}
+
+		err := stream.Send(&idl.SyncDirectoryReply{Contents: &idl.SyncDirectoryReply_Needed{
+			Needed: &idl.NeededFiles{Paths: paths[:n], Done: n == len(paths)},
+		}})
+		if err != nil || n == len(paths) {
+			return err
+		}
+
+		paths = paths[n:]
+	}
+}
+
+func recordCopiedBytes(n int64) {
+	host, err := utils.System.Hostname()
+	if err != nil {
+		gplog.Debug("getting hostname for metrics: %+v", err)
+	}
+	copiedBytes.Add(float64(n), host)
+}
+
+// streamReader reads the contents of a file from the data messages that follow
+// its entry, and verifies them against the checksum message that ends them.
+type streamReader struct {
+	stream idl.Agent_SyncDirectoryServer
+	hash   hash.Hash
+	buf    []byte
+	err    error // io.EOF once the checksum has been received
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		msg, err := r.stream.Recv()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			r.err = err
+			continue
+		}
+
+		switch contents := msg.Contents.(type) {
+		case *idl.SyncDirectoryRequest_Data:
+			r.buf = contents.Data
+			r.hash.Write(r.buf)
+
+		case *idl.SyncDirectoryRequest_Checksum:
+			r.err = io.EOF
+			if !bytes.Equal(contents.Checksum, r.hash.Sum(nil)) {
+				r.err = dirsync.ErrChecksum
+			}
+
+		default:
+			r.err = status.Errorf(codes.InvalidArgument, "expected file contents, got %T", msg.Contents)
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
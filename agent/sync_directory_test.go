@@ -0,0 +1,416 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
+)
+
+func writeToFile(filepath string, contents []byte, t *testing.T) {
+	err := ioutil.WriteFile(filepath, contents, 0644)
+
+	if err != nil {
+		t.Fatalf("error writing file '%v'", filepath)
+	}
+}
+
+func TestCopyDirectory(t *testing.T) {
+	// These are "live" integration tests. Plug the real sync back in.
+	agent.SetSyncDirectory(dirsync.Sync)
+	defer agent.SetSyncDirectory(nil)
+
+	t.Run("it copies data from a source directory to a target directory", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "copy-source")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := testutils.GetTempDir(t, "copy-target")
+		defer testutils.MustRemoveAll(t, targetDir)
+
+		writeToFile(filepath.Join(sourceDir, "hi"), []byte("hi"), t)
+
+		if err := agent.CopyDirectory(context.Background(), sourceDir, targetDir, []string{}); err != nil {
+			t.Errorf("CopyDirectory() returned error %+v", err)
+		}
+
+		targetContents, _ := ioutil.ReadFile(filepath.Join(targetDir, "/hi"))
+
+		if !bytes.Equal(targetContents, []byte("hi")) {
+			t.Errorf("target directory file 'hi' contained %v, wanted %v",
+				targetContents,
+				"hi")
+		}
+	})
+
+	t.Run("it reports the bytes copied", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "copy-source")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := testutils.GetTempDir(t, "copy-target")
+		defer testutils.MustRemoveAll(t, targetDir)
+
+		writeToFile(filepath.Join(sourceDir, "hello"), []byte("hello"), t)
+
+		before := copiedBytes(t)
+		if err := agent.CopyDirectory(context.Background(), sourceDir, targetDir, []string{}); err != nil {
+			t.Errorf("CopyDirectory() returned error %+v", err)
+		}
+
+		if copied := copiedBytes(t) - before; copied != 5 {
+			t.Errorf("got %v bytes copied, want 5", copied)
+		}
+	})
+
+	t.Run("it removes files that existed in the target directory before the copy", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "copy-source")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := testutils.GetTempDir(t, "copy-target")
+		defer testutils.MustRemoveAll(t, targetDir)
+
+		writeToFile(filepath.Join(targetDir, "target-file-that-should-get-removed"), []byte("goodbye"), t)
+
+		if err := agent.CopyDirectory(context.Background(), sourceDir, targetDir, []string{}); err != nil {
+			t.Errorf("CopyDirectory() returned error %+v", err)
+		}
+
+		_, statError := os.Stat(filepath.Join(targetDir, "target-file-that-should-get-removed"))
+
+		if !os.IsNotExist(statError) {
+			t.Errorf("target directory file 'target-file-that-should-get-removed' should not exist, but it does")
+		}
+	})
+
+	t.Run("it does not copy files from the source directory when in the exclusion list", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "copy-source")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := testutils.GetTempDir(t, "copy-target")
+		defer testutils.MustRemoveAll(t, targetDir)
+
+		writeToFile(filepath.Join(sourceDir, "source-file-that-should-get-excluded"), []byte("goodbye"), t)
+
+		err := agent.CopyDirectory(context.Background(), sourceDir, targetDir, []string{"source-file-that-should-get-excluded"})
+		if err != nil {
+			t.Errorf("CopyDirectory() returned error %+v", err)
+		}
+
+		_, statError := os.Stat(filepath.Join(targetDir, "source-file-that-should-get-excluded"))
+
+		if !os.IsNotExist(statError) {
+			t.Errorf("target directory file 'source-file-that-should-get-excluded' should not exist, but it does")
+		}
+	})
+
+	t.Run("it preserves files in the target directory when in the exclusion list", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "copy-source")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := testutils.GetTempDir(t, "copy-target")
+		defer testutils.MustRemoveAll(t, targetDir)
+
+		writeToFile(filepath.Join(sourceDir, "source-file-that-should-get-copied"), []byte("new file"), t)
+		writeToFile(filepath.Join(targetDir, "target-file-that-should-get-ignored"), []byte("i'm still here"), t)
+		writeToFile(filepath.Join(targetDir, "another-target-file-that-should-get-ignored"), []byte("i'm still here"), t)
+
+		err := agent.CopyDirectory(context.Background(), sourceDir, targetDir, []string{"target-file-that-should-get-ignored", "another-target-file-that-should-get-ignored"})
+		if err != nil {
+			t.Errorf("CopyDirectory() returned error %+v", err)
+		}
+
+		_, statError := os.Stat(filepath.Join(targetDir, "target-file-that-should-get-ignored"))
+
+		if os.IsNotExist(statError) {
+			t.Error("target directory file 'target-file-that-should-get-ignored' should still exist, but it does not")
+		}
+
+		_, statError = os.Stat(filepath.Join(targetDir, "another-target-file-that-should-get-ignored"))
+
+		if os.IsNotExist(statError) {
+			t.Error("target directory file 'another-target-file-that-should-get-ignored' should still exist, but it does not")
+		}
+
+		_, statError = os.Stat(filepath.Join(targetDir, "source-file-that-should-get-copied"))
+
+		if os.IsNotExist(statError) {
+			t.Error("target directory file 'source-file-that-should-get-copied' should exist, but does not")
+		}
+	})
+
+	t.Run("it names the directories in errors", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "copy-source")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := "/tmp/some/invalid/target/dir"
+
+		writeToFile(filepath.Join(sourceDir, "some-file"), []byte("hi"), t)
+
+		err := agent.CopyDirectory(context.Background(), sourceDir, targetDir, nil)
+		if !xerrors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v, want a not-exist error", err)
+		}
+
+		expected := fmt.Sprintf("copying %q to %q", sourceDir, targetDir)
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("got error %q, want it to contain %q", err, expected)
+		}
+	})
+}
+
+func TestSyncDirectory(t *testing.T) {
+	testhelper.SetupTestLogger()
+
+	server := agent.NewServer(agent.Config{})
+
+	t.Run("it mirrors the streamed files into the target directory", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "sync-source")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := testutils.GetTempDir(t, "sync-target")
+		defer testutils.MustRemoveAll(t, targetDir)
+
+		writeToFile(filepath.Join(sourceDir, "hello"), []byte("hello"), t)
+		writeToFile(filepath.Join(sourceDir, "empty"), nil, t)
+		writeToFile(filepath.Join(targetDir, "extra"), []byte("goodbye"), t)
+
+		stream := syncRequests(t, sourceDir, targetDir, true, nil)
+		if err := server.SyncDirectory(stream); err != nil {
+			t.Fatalf("SyncDirectory() returned error %+v", err)
+		}
+
+		contents, err := ioutil.ReadFile(filepath.Join(targetDir, "hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(contents) != "hello" {
+			t.Errorf("got contents %q want %q", contents, "hello")
+		}
+
+		if _, err := os.Stat(filepath.Join(targetDir, "empty")); err != nil {
+			t.Errorf("expected empty file to be copied: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(targetDir, "extra")); !os.IsNotExist(err) {
+			t.Errorf("expected extraneous file to be deleted, got %v", err)
+		}
+
+		if !reflect.DeepEqual(stream.needed, []string{"empty", "hello"}) {
+			t.Errorf("got needed %q want %q", stream.needed, []string{"empty", "hello"})
+		}
+
+		expected := &idl.SyncStats{Files: 2, Bytes: 5, Deleted: 1}
+		if !proto.Equal(stream.stats, expected) {
+			t.Errorf("got stats %v want %v", stream.stats, expected)
+		}
+	})
+
+	t.Run("it skips the contents of unchanged files", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "sync-source")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := testutils.GetTempDir(t, "sync-target")
+		defer testutils.MustRemoveAll(t, targetDir)
+
+		writeToFile(filepath.Join(sourceDir, "hello"), []byte("hello"), t)
+
+		for i := 0; i < 2; i++ {
+			stream := syncRequests(t, sourceDir, targetDir, false, nil)
+			if err := server.SyncDirectory(stream); err != nil {
+				t.Fatalf("SyncDirectory() returned error %+v", err)
+			}
+
+			if i == 1 && len(stream.needed) != 0 {
+				t.Errorf("got needed %q, want none", stream.needed)
+			}
+
+			if i == 1 && stream.stats.Files != 0 {
+				t.Errorf("got %d files copied, want 0", stream.stats.Files)
+			}
+		}
+	})
+
+	t.Run("it rejects contents that do not match their checksum", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "sync-source")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := testutils.GetTempDir(t, "sync-target")
+		defer testutils.MustRemoveAll(t, targetDir)
+
+		writeToFile(filepath.Join(sourceDir, "hello"), []byte("hello"), t)
+
+		stream := syncRequests(t, sourceDir, targetDir, false, nil)
+		for _, requests := range stream.contents {
+			for _, request := range requests {
+				if data, ok := request.Contents.(*idl.SyncDirectoryRequest_Data); ok {
+					data.Data = []byte("jello")
+				}
+			}
+		}
+
+		err := server.SyncDirectory(stream)
+		if !xerrors.Is(err, dirsync.ErrChecksum) {
+			t.Errorf("got error %#v want %#v", err, dirsync.ErrChecksum)
+		}
+
+		if _, err := os.Stat(filepath.Join(targetDir, "hello")); !os.IsNotExist(err) {
+			t.Errorf("expected the corrupted file not to be written, got %v", err)
+		}
+	})
+
+	t.Run("it requires the options first", func(t *testing.T) {
+		stream := &syncStream{requests: []*idl.SyncDirectoryRequest{
+			{Contents: &idl.SyncDirectoryRequest_Entry{Entry: &idl.FileEntry{Path: "hello"}}},
+		}}
+
+		err := server.SyncDirectory(stream)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("got error %#v want code %v", err, codes.InvalidArgument)
+		}
+	})
+}
+
+// syncRequests returns a stream that syncs sourceDir to targetDir, the way the
+// hub does.
+func syncRequests(t *testing.T, sourceDir, targetDir string, del bool, exclude []string) *syncStream {
+	t.Helper()
+
+	opts := dirsync.Options{Delete: del, Exclude: exclude}
+	stream := &syncStream{
+		requests: []*idl.SyncDirectoryRequest{
+			{Contents: &idl.SyncDirectoryRequest_Options{Options: dirsync.OptionsToProto(targetDir, opts)}},
+		},
+		contents: make(map[string][]*idl.SyncDirectoryRequest),
+	}
+
+	err := dirsync.Walk(context.Background(), []string{sourceDir + "/"}, opts, func(e dirsync.Entry, open dirsync.OpenFunc) error {
+		stream.requests = append(stream.requests, &idl.SyncDirectoryRequest{
+			Contents: &idl.SyncDirectoryRequest_Entry{Entry: dirsync.ToProto(e)},
+		})
+
+		if open == nil {
+			return nil
+		}
+
+		f, err := open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		contents := []*idl.SyncDirectoryRequest{
+			{Contents: &idl.SyncDirectoryRequest_ContentsOf{ContentsOf: e.Path}},
+		}
+		if len(data) > 0 {
+			contents = append(contents, &idl.SyncDirectoryRequest{Contents: &idl.SyncDirectoryRequest_Data{Data: data}})
+		}
+		stream.contents[e.Path] = append(contents, &idl.SyncDirectoryRequest{Contents: &idl.SyncDirectoryRequest_Checksum{Checksum: sum[:]}})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream.requests = append(stream.requests, &idl.SyncDirectoryRequest{
+		Contents: &idl.SyncDirectoryRequest_EntriesDone{EntriesDone: true},
+	})
+
+	return stream
+}
+
+// syncStream is an idl.Agent_SyncDirectoryServer that receives the given
+// requests, followed by the contents of the files that the agent needs, and
+// records the replies.
+type syncStream struct {
+	idl.Agent_SyncDirectoryServer
+
+	requests []*idl.SyncDirectoryRequest
+	contents map[string][]*idl.SyncDirectoryRequest // keyed by path
+
+	needed []string
+	stats  *idl.SyncStats
+}
+
+func (s *syncStream) Recv() (*idl.SyncDirectoryRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+
+	request := s.requests[0]
+	s.requests = s.requests[1:]
+	return request, nil
+}
+
+func (s *syncStream) Send(reply *idl.SyncDirectoryReply) error {
+	if stats := reply.GetStats(); stats != nil {
+		s.stats = stats
+		return nil
+	}
+
+	needed := reply.GetNeeded()
+	s.needed = append(s.needed, needed.Paths...)
+	if needed.Done {
+		for _, path := range s.needed {
+			s.requests = append(s.requests, s.contents[path]...)
+		}
+	}
+
+	return nil
+}
+
+// copiedBytes returns the number of bytes the agent has reported copying on
+// this host.
+func copiedBytes(t *testing.T) float64 {
+	t.Helper()
+
+	host, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := metrics.Default.Write(buf); err != nil {
+		t.Fatalf("writing metrics: %+v", err)
+	}
+
+	prefix := fmt.Sprintf("gpupgrade_agent_copied_bytes_total{host=%q} ", host)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			value, err := strconv.ParseFloat(strings.TrimPrefix(line, prefix), 64)
+			if err != nil {
+				t.Fatalf("parsing %q: %+v", line, err)
+			}
+			return value
+		}
+	}
+
+	return 0
+}
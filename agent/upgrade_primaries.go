@@ -17,12 +17,12 @@ import (
 	"github.com/greenplum-db/gpupgrade/utils"
 )
 
-func (s *Server) UpgradePrimaries(ctx context.Context, request *idl.UpgradePrimariesRequest) (*idl.UpgradePrimariesReply, error) {
+func (s *Server) UpgradePrimaries(request *idl.UpgradePrimariesRequest, stream idl.Agent_UpgradePrimariesServer) error {
 	gplog.Info("agent starting %s", idl.Substep_UPGRADE_PRIMARIES)
 
-	err := UpgradePrimaries(s.conf.StateDir, request)
-
-	return &idl.UpgradePrimariesReply{}, err
+	// The context is cancelled when the hub cancels the request, which stops
+	// any running pg_upgrade processes and copies.
+	return UpgradePrimaries(stream.Context(), s.conf.StateDir, request, stream)
 }
 
 // Allow exec.Command to be mocked out by exectest.NewCommand.
@@ -34,7 +34,11 @@ type Segment struct {
 	WorkDir string // the pg_upgrade working directory, where logs are stored
 }
 
-func UpgradePrimaries(stateDir string, request *idl.UpgradePrimariesRequest) error {
+// UpgradePrimaries upgrades each segment in the request concurrently, running
+// at most request.MaxConcurrency upgrades at once when it is set. The output of
+// each pg_upgrade is sent to sender as it is written, tagged with the host and
+// content ID of the segment.
+func UpgradePrimaries(ctx context.Context, stateDir string, request *idl.UpgradePrimariesRequest, sender idl.MessageSender) error {
 	segments, err := buildSegments(request, stateDir)
 
 	if err != nil {
@@ -50,12 +54,27 @@ func UpgradePrimaries(stateDir string, request *idl.UpgradePrimariesRequest) err
 	// Upgrade each segment concurrently
 	//
 	upgradeResponse := make(chan error, len(segments))
+	sender = &lockedSender{sender: sender}
+
+	limit := len(segments)
+	if request.MaxConcurrency > 0 && int(request.MaxConcurrency) < limit {
+		limit = int(request.MaxConcurrency)
+	}
+	running := make(chan struct{}, limit)
 
 	for _, segment := range segments {
 		segment := segment // capture the range variable
 
 		go func() {
-			upgradeResponse <- upgradeSegment(segment, request, host)
+			select {
+			case running <- struct{}{}:
+				defer func() { <-running }()
+			case <-ctx.Done():
+				upgradeResponse <- xerrors.Errorf("upgrading content %d: %w", segment.Content, ctx.Err())
+				return
+			}
+
+			upgradeResponse <- upgradeSegment(ctx, segment, request, host, sender)
 		}()
 	}
 
@@ -4,12 +4,18 @@
 package agent_test
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"golang.org/x/xerrors"
 
@@ -18,12 +24,19 @@ import (
 	"github.com/greenplum-db/gpupgrade/agent"
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
 )
 
 func ResetCommands() {
 	agent.SetExecCommand(nil)
-	agent.SetRsyncCommand(nil)
+	agent.SetSyncDirectory(nil)
+}
+
+// syncSuccess stands in for a copy that succeeds without touching the disk.
+func syncSuccess(context.Context, string, string, dirsync.Options) (dirsync.Stats, error) {
+	return dirsync.Stats{}, nil
 }
 
 func TestUpgradePrimary(t *testing.T) {
@@ -78,7 +91,7 @@ func TestUpgradePrimary(t *testing.T) {
 
 	t.Run("when pg_upgrade --check fails it returns an error", func(t *testing.T) {
 		agent.SetExecCommand(exectest.NewCommand(agent.FailedMain))
-		agent.SetRsyncCommand(exectest.NewCommand(agent.Success))
+		agent.SetSyncDirectory(syncSuccess)
 
 		defer ResetCommands()
 
@@ -89,7 +102,7 @@ func TestUpgradePrimary(t *testing.T) {
 			CheckOnly:    true,
 			UseLinkMode:  false,
 		}
-		err := agent.UpgradePrimaries(tempDir, request)
+		err := agent.UpgradePrimaries(context.Background(), tempDir, request, &messageRecorder{})
 		if err == nil {
 			t.Fatal("UpgradeSegments() returned no error")
 		}
@@ -105,7 +118,7 @@ func TestUpgradePrimary(t *testing.T) {
 	})
 
 	t.Run("when pg_upgrade with no check fails it returns an error", func(t *testing.T) {
-		agent.SetRsyncCommand(exectest.NewCommand(agent.Success))
+		agent.SetSyncDirectory(syncSuccess)
 		agent.SetExecCommand(exectest.NewCommand(agent.FailedMain))
 		defer ResetCommands()
 
@@ -115,7 +128,7 @@ func TestUpgradePrimary(t *testing.T) {
 			DataDirPairs: pairs,
 			CheckOnly:    false,
 			UseLinkMode:  false}
-		err := agent.UpgradePrimaries(tempDir, request)
+		err := agent.UpgradePrimaries(context.Background(), tempDir, request, &messageRecorder{})
 		if err == nil {
 			t.Fatal("UpgradeSegments() returned no error")
 		}
@@ -130,6 +143,27 @@ func TestUpgradePrimary(t *testing.T) {
 		}
 	})
 
+	t.Run("stops pg_upgrade when the request is cancelled", func(t *testing.T) {
+		agent.SetExecCommand(exectest.NewCommand(agent.SleepingMain))
+		defer ResetCommands()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		request := buildRequest(pairs)
+		request.CheckOnly = true
+
+		start := time.Now()
+		err := agent.UpgradePrimaries(ctx, tempDir, request, &messageRecorder{})
+		if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+			t.Errorf("got error %v, want it to contain %q", err, context.Canceled)
+		}
+
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("pg_upgrade took %s to stop", elapsed)
+		}
+	})
+
 	t.Run("it does not perform a copy of the master backup directory when using check mode", func(t *testing.T) {
 		agent.SetExecCommand(exectest.NewCommand(agent.Success))
 
@@ -138,25 +172,26 @@ func TestUpgradePrimary(t *testing.T) {
 		request := buildRequest(pairs)
 		request.CheckOnly = true
 
-		agent.SetRsyncCommand(
-			exectest.NewCommandWithVerifier(agent.Success, func(commandName string, _ ...string) {
-				if commandName == "rsync" {
-					t.Error("unexpected rsync call")
-				}
-			}))
+		agent.SetSyncDirectory(func(context.Context, string, string, dirsync.Options) (dirsync.Stats, error) {
+			t.Error("unexpected copy")
+			return dirsync.Stats{}, nil
+		})
 
-		_ = agent.UpgradePrimaries(tempDir, request)
+		_ = agent.UpgradePrimaries(context.Background(), tempDir, request, &messageRecorder{})
 	})
 
 	t.Run("it returns errors in parallel if the copy step fails", func(t *testing.T) {
-		agent.SetRsyncCommand(exectest.NewCommand(agent.FailedRsync))
+		agent.SetSyncDirectory(func(context.Context, string, string, dirsync.Options) (dirsync.Stats, error) {
+			return dirsync.Stats{}, xerrors.New("copy failed cause I said so")
+		})
 		agent.SetExecCommand(exectest.NewCommand(agent.Success))
 
 		request := buildRequest(pairs)
-		err = agent.UpgradePrimaries(tempDir, request)
+		recorder := &messageRecorder{}
+		err = agent.UpgradePrimaries(context.Background(), tempDir, request, recorder)
 
-		// We expect each part of the request to return its own ExitError,
-		// containing the expected message from FailedRsync.
+		// We expect each part of the request to return its own error,
+		// containing the expected message from the failed copy.
 		var multiErr *multierror.Error
 		if !xerrors.As(err, &multiErr) {
 			t.Fatalf("got error %#v, want type %T", err, multiErr)
@@ -167,11 +202,184 @@ func TestUpgradePrimary(t *testing.T) {
 		}
 
 		for _, err := range multiErr.Errors {
-			if !strings.Contains(string(err.Error()), "rsync failed cause I said so") {
-				t.Errorf("wanted error message 'rsync failed cause I said so' from rsync, got %q",
+			if !strings.Contains(string(err.Error()), "copy failed cause I said so") {
+				t.Errorf("wanted error message 'copy failed cause I said so' from the copy, got %q",
 					string(err.Error()))
 			}
 		}
+
+		// pg_upgrade never ran, so there is no exit code to report.
+		for _, msg := range recorder.messages {
+			if result := msg.GetSegmentResult(); result != nil {
+				if result.Phase != idl.SegmentResult_RESTORE_BACKUP || result.ExitCode != -1 {
+					t.Errorf("got phase %s and exit code %d, want %s and -1",
+						result.Phase, result.ExitCode, idl.SegmentResult_RESTORE_BACKUP)
+				}
+			}
+		}
+	})
+
+	t.Run("streams the pg_upgrade output of each segment", func(t *testing.T) {
+		agent.SetExecCommand(exectest.NewCommand(agent.UpgradeOutputMain))
+		defer ResetCommands()
+
+		host, err := os.Hostname()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := &idl.UpgradePrimariesRequest{
+			SourceBinDir: "/old/bin",
+			TargetBinDir: "/new/bin",
+			DataDirPairs: pairs,
+			CheckOnly:    true,
+		}
+
+		recorder := &messageRecorder{}
+		err = agent.UpgradePrimaries(context.Background(), tempDir, request, recorder)
+		if err != nil {
+			t.Fatalf("unexpected err %#v", err)
+		}
+
+		output := make(map[int32][]string)
+		for _, msg := range recorder.messages {
+			chunk := msg.GetChunk()
+			if chunk == nil {
+				continue
+			}
+
+			if chunk.Host != host {
+				t.Errorf("got host %q want %q", chunk.Host, host)
+			}
+
+			output[chunk.Content] = append(output[chunk.Content], chunk.Type.String()+": "+string(chunk.Buffer))
+		}
+
+		for _, content := range []int32{1, 7} {
+			actual := output[content]
+			sort.Strings(actual) // stdout and stderr are written concurrently
+
+			expected := []string{
+				"STDERR: warning\n",
+				"STDOUT: Copying user relation files ok\n",
+				"STDOUT: Performing Upgrade\n",
+				"STDOUT: Upgrade Complete",
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("got output %q for content %d, want %q", actual, content, expected)
+			}
+		}
+	})
+
+	t.Run("reports the result of each segment", func(t *testing.T) {
+		agent.SetExecCommand(exectest.NewCommand(agent.FailedMain))
+		defer ResetCommands()
+
+		host, err := os.Hostname()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		request := &idl.UpgradePrimariesRequest{
+			SourceBinDir: "/old/bin",
+			TargetBinDir: "/new/bin",
+			DataDirPairs: pairs,
+			CheckOnly:    true,
+		}
+
+		recorder := &messageRecorder{}
+		err = agent.UpgradePrimaries(context.Background(), tempDir, request, recorder)
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+
+		results := make(map[int32]*idl.SegmentResult)
+		for _, msg := range recorder.messages {
+			if result := msg.GetSegmentResult(); result != nil {
+				results[result.Content] = result
+			}
+		}
+
+		if len(results) != len(pairs) {
+			t.Fatalf("got %d results, want %d", len(results), len(pairs))
+		}
+
+		for _, pair := range pairs {
+			result := results[pair.Content]
+
+			expectedDir := upgrade.SegmentWorkingDirectory(tempDir, int(pair.Content))
+			if result.Host != host || result.Dbid != pair.DBID || result.LogDir != expectedDir {
+				t.Errorf("got result %v, want host %q, dbid %d and log directory %q",
+					result, host, pair.DBID, expectedDir)
+			}
+
+			if result.Phase != idl.SegmentResult_PG_UPGRADE {
+				t.Errorf("got phase %s want %s", result.Phase, idl.SegmentResult_PG_UPGRADE)
+			}
+
+			if result.ExitCode != 1 {
+				t.Errorf("got exit code %d want 1", result.ExitCode)
+			}
+
+			if !strings.Contains(result.Error, "exit status 1") {
+				t.Errorf("got error %q, want it to contain %q", result.Error, "exit status 1")
+			}
+		}
+	})
+
+	t.Run("upgrades at most MaxConcurrency segments at once", func(t *testing.T) {
+		agent.SetExecCommand(exectest.NewCommand(agent.ConcurrencyTrackingMain))
+		defer ResetCommands()
+
+		stateDir := filepath.Join(tempDir, "limited")
+
+		var manyPairs []*idl.DataDirPair
+		for content := int32(0); content < 4; content++ {
+			manyPairs = append(manyPairs, &idl.DataDirPair{
+				SourceDataDir: fmt.Sprintf("/data/old%d", content),
+				TargetDataDir: fmt.Sprintf("/data/new%d", content),
+				Content:       content,
+				DBID:          content + 2,
+			})
+		}
+
+		request := &idl.UpgradePrimariesRequest{
+			SourceBinDir:   "/old/bin",
+			TargetBinDir:   "/new/bin",
+			DataDirPairs:   manyPairs,
+			CheckOnly:      true,
+			MaxConcurrency: 2,
+		}
+
+		err := agent.UpgradePrimaries(context.Background(), stateDir, request, &messageRecorder{})
+		if err != nil {
+			t.Fatalf("unexpected err %#v", err)
+		}
+
+		counts, err := filepath.Glob(filepath.Join(upgrade.SegmentWorkingDirectory(stateDir, 0), "..", "count-*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(counts) != len(manyPairs) {
+			t.Fatalf("got %d upgrades, want %d", len(counts), len(manyPairs))
+		}
+
+		for _, path := range counts {
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			running, err := strconv.Atoi(string(contents))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if running > 2 {
+				t.Errorf("got %d upgrades running at once, want at most 2", running)
+			}
+		}
 	})
 
 	t.Run("it grabs a copy of the master backup directory before running upgrade", func(t *testing.T) {
@@ -187,42 +395,46 @@ func TestUpgradePrimary(t *testing.T) {
 		targetDataDirsUsedChannel := make(chan string, len(targetDataDirs))
 
 		agent.SetExecCommand(exectest.NewCommand(agent.Success))
-		agent.SetRsyncCommand(exectest.NewCommandWithVerifier(agent.Success, func(utility string, arguments ...string) {
-			call := rsyncCall(utility, arguments)
-
-			if call.sourceDir != "/some/master/backup/dir/" {
-				t.Errorf("rsync source directory was %v, want %v",
-					call.sourceDir,
+		agent.SetSyncDirectory(func(_ context.Context, sourceDir, targetDir string, opts dirsync.Options) (dirsync.Stats, error) {
+			if sourceDir != "/some/master/backup/dir" {
+				t.Errorf("copy source directory was %v, want %v",
+					sourceDir,
 					"/some/master/backup/dir")
 			}
 
 			for _, dir := range targetDataDirs {
-				if call.targetDir == dir {
+				if targetDir == dir {
 					targetDataDirsUsedChannel <- dir
 				}
 			}
 
 			expectedExclusions := []string{
-				"--exclude", "internal.auto.conf",
-				"--exclude", "postgresql.conf",
-				"--exclude", "pg_hba.conf",
-				"--exclude", "postmaster.opts",
-				"--exclude", "gp_dbid",
-				"--exclude", "gpssh.conf",
-				"--exclude", "gpperfmon",
+				"internal.auto.conf",
+				"postgresql.conf",
+				"pg_hba.conf",
+				"postmaster.opts",
+				"gp_dbid",
+				"gpssh.conf",
+				"gpperfmon",
 			}
 
-			if !reflect.DeepEqual(call.exclusions, expectedExclusions) {
-				t.Errorf("got %q exclusions in rsync, want %q",
-					call.exclusions,
+			if !reflect.DeepEqual(opts.Exclude, expectedExclusions) {
+				t.Errorf("got %q exclusions in the copy, want %q",
+					opts.Exclude,
 					expectedExclusions)
 			}
-		}))
+
+			if !opts.Delete {
+				t.Error("expected the copy to delete extraneous files")
+			}
+
+			return dirsync.Stats{}, nil
+		})
 
 		request := buildRequest(pairs)
 		request.MasterBackupDir = "/some/master/backup/dir"
 
-		err := agent.UpgradePrimaries(tempDir, request)
+		err := agent.UpgradePrimaries(context.Background(), tempDir, request, &messageRecorder{})
 		if err != nil {
 			t.Error(err)
 		}
@@ -233,7 +445,7 @@ func TestUpgradePrimary(t *testing.T) {
 		// Collect data dirs that were used to restore
 		// a backup of the master data directory
 		//
-		// note: we're using channels because the rsync is happening
+		// note: we're using channels because the copy is happening
 		// on a goroutine, so order cannot be guaranteed
 		//
 		for dir := range targetDataDirsUsedChannel {
@@ -250,42 +462,6 @@ func TestUpgradePrimary(t *testing.T) {
 	})
 }
 
-type rsyncRequest struct {
-	commandName string
-	sourceDir   string
-	targetDir   string
-	archiveFlag string
-	deleteFlag  string
-	exclusions  []string
-}
-
-func rsyncCall(utility string, arguments []string) rsyncRequest {
-	r := rsyncRequest{}
-	r.commandName = utility
-
-	if len(arguments) > 0 {
-		r.archiveFlag = arguments[0]
-	}
-
-	if len(arguments) > 1 {
-		r.deleteFlag = arguments[1]
-	}
-
-	if len(arguments) > 2 {
-		r.sourceDir = arguments[2]
-	}
-
-	if len(arguments) > 3 {
-		r.targetDir = arguments[3]
-	}
-
-	if len(arguments) > 4 {
-		r.exclusions = arguments[4:]
-	}
-
-	return r
-}
-
 func buildRequest(pairs []*idl.DataDirPair) *idl.UpgradePrimariesRequest {
 	return &idl.UpgradePrimariesRequest{
 		SourceBinDir:    "/old/bin",
@@ -296,3 +472,18 @@ func buildRequest(pairs []*idl.DataDirPair) *idl.UpgradePrimariesRequest {
 		MasterBackupDir: "/some/master/backup/dir",
 	}
 }
+
+// messageRecorder is an idl.MessageSender that records the messages it is
+// sent.
+type messageRecorder struct {
+	mu       sync.Mutex
+	messages []*idl.Message
+}
+
+func (m *messageRecorder) Send(msg *idl.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+	return nil
+}
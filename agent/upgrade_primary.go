@@ -4,12 +4,17 @@
 package agent
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
+	"golang.org/x/xerrors"
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/idl"
@@ -17,21 +22,52 @@ import (
 	"github.com/greenplum-db/gpupgrade/utils"
 )
 
-func upgradeSegment(segment Segment, request *idl.UpgradePrimariesRequest, host string) error {
-	err := restoreBackup(request, segment)
+// upgradeSegment upgrades a single segment, and sends a SegmentResult
+// describing how far it got once it has finished.
+func upgradeSegment(ctx context.Context, segment Segment, request *idl.UpgradePrimariesRequest, host string, sender idl.MessageSender) (err error) {
+	result := &idl.SegmentResult{
+		Host:     host,
+		Content:  segment.Content,
+		Dbid:     segment.DBID,
+		LogDir:   segment.WorkDir,
+		ExitCode: -1, // until pg_upgrade has run
+	}
+
+	start := time.Now()
+	defer func() {
+		result.DurationMs = time.Since(start).Milliseconds()
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Phase = idl.SegmentResult_DONE
+		}
+
+		sendResult(sender, result)
+	}()
+
+	result.Phase = idl.SegmentResult_RESTORE_BACKUP
+	err = restoreBackup(ctx, request, segment)
 
 	if err != nil {
 		return errors.Wrapf(err, "failed to restore master data directory backup on host %s for content id %d: %s",
 			host, segment.Content, err)
 	}
 
-	err = RestoreTablespaces(request, segment)
+	result.Phase = idl.SegmentResult_RESTORE_TABLESPACES
+	err = RestoreTablespaces(ctx, request, segment)
 	if err != nil {
 		return errors.Wrapf(err, "restore tablespace on host %s for content id %d: %s",
 			host, segment.Content, err)
 	}
 
-	err = performUpgrade(segment, request)
+	stdout := newSegmentWriter(sender, host, segment.Content, idl.Chunk_STDOUT)
+	stderr := newSegmentWriter(sender, host, segment.Content, idl.Chunk_STDERR)
+
+	result.Phase = idl.SegmentResult_PG_UPGRADE
+	err = performUpgrade(ctx, segment, request, stdout, stderr)
+	stdout.Flush()
+	stderr.Flush()
+	result.ExitCode = exitCode(err)
 
 	if err != nil {
 		failedAction := "upgrade"
@@ -44,7 +80,22 @@ func upgradeSegment(segment Segment, request *idl.UpgradePrimariesRequest, host
 	return nil
 }
 
-func performUpgrade(segment Segment, request *idl.UpgradePrimariesRequest) error {
+// exitCode returns the exit code of the process that returned err, or -1 if
+// it did not exit normally.
+func exitCode(err error) int32 {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if xerrors.As(err, &exitErr) {
+		return int32(exitErr.ExitCode())
+	}
+
+	return -1
+}
+
+func performUpgrade(ctx context.Context, segment Segment, request *idl.UpgradePrimariesRequest, stdout, stderr io.Writer) error {
 	dbid := int(segment.DBID)
 	segmentPair := upgrade.SegmentPair{
 		Source: &upgrade.Segment{BinDir: request.SourceBinDir, DataDir: segment.SourceDataDir, DBID: dbid, Port: int(segment.SourcePort)},
@@ -53,8 +104,10 @@ func performUpgrade(segment Segment, request *idl.UpgradePrimariesRequest) error
 
 	options := []upgrade.Option{
 		upgrade.WithExecCommand(execCommand),
+		upgrade.WithContext(ctx),
 		upgrade.WithWorkDir(segment.WorkDir),
 		upgrade.WithSegmentMode(),
+		upgrade.WithOutputStreams(stdout, stderr),
 	}
 
 	if request.CheckOnly {
@@ -74,23 +127,15 @@ func performUpgrade(segment Segment, request *idl.UpgradePrimariesRequest) error
 	return upgrade.Run(segmentPair, options...)
 }
 
-func restoreBackup(request *idl.UpgradePrimariesRequest, segment Segment) error {
+func restoreBackup(ctx context.Context, request *idl.UpgradePrimariesRequest, segment Segment) error {
 	if request.CheckOnly {
 		return nil
 	}
 
-	return Rsync(request.MasterBackupDir, segment.TargetDataDir, []string{
-		"internal.auto.conf",
-		"postgresql.conf",
-		"pg_hba.conf",
-		"postmaster.opts",
-		"gp_dbid",
-		"gpssh.conf",
-		"gpperfmon",
-	})
+	return CopyDirectory(ctx, request.MasterBackupDir, segment.TargetDataDir, upgrade.SegmentBackupExclusions)
 }
 
-func RestoreTablespaces(request *idl.UpgradePrimariesRequest, segment Segment) error {
+func RestoreTablespaces(ctx context.Context, request *idl.UpgradePrimariesRequest, segment Segment) error {
 	if request.CheckOnly {
 		return nil
 	}
@@ -102,8 +147,8 @@ func RestoreTablespaces(request *idl.UpgradePrimariesRequest, segment Segment) e
 
 		targetDir := greenplum.GetTablespaceLocationForDbId(tablespace, int(segment.DBID))
 		sourceDir := greenplum.GetMasterTablespaceLocation(filepath.Dir(request.TablespacesMappingFilePath), int(oid))
-		if err := Rsync(sourceDir, targetDir, nil); err != nil {
-			return errors.Wrap(err, "rsync master tablespace directory to segment tablespace directory")
+		if err := CopyDirectory(ctx, sourceDir, targetDir, nil); err != nil {
+			return errors.Wrap(err, "copy master tablespace directory to segment tablespace directory")
 		}
 
 		symLinkName := fmt.Sprintf("%s/pg_tblspc/%s", segment.TargetDataDir, strconv.Itoa(int(oid)))
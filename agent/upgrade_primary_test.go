@@ -4,6 +4,7 @@
 package agent_test
 
 import (
+	"context"
 	"os"
 	"reflect"
 	"strings"
@@ -13,8 +14,8 @@ import (
 
 	"github.com/greenplum-db/gpupgrade/agent"
 	"github.com/greenplum-db/gpupgrade/idl"
-	"github.com/greenplum-db/gpupgrade/testutils/exectest"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
 )
 
 func getSampleSegment() agent.Segment {
@@ -62,22 +63,14 @@ func TestRestoreTablespaces(t *testing.T) {
 			},
 		}
 
-		// all the args for multiple invocations of rsync
-		expectedRsyncArgs := []string{
-				"--archive", "--delete",
-				"/tmp/tablespaces/1663/1/", "/tmp/default/1663/2",
-		}
-
-		var actualArgs []string
-		agent.SetRsyncCommand(exectest.NewCommandWithVerifier(agent.Success, func(name string, args ...string) {
-			expected := "rsync"
-			if name != expected {
-				t.Errorf("RestoreTablespaces() invoked %q, want %q", name, expected)
-			}
+		// the directories of each copy
+		expectedCopies := []string{"/tmp/tablespaces/1663/1", "/tmp/default/1663/2"}
 
-			// will use for validation later
-			actualArgs = append(actualArgs, args...)
-		}))
+		var actualCopies []string
+		agent.SetSyncDirectory(func(_ context.Context, sourceDir, targetDir string, _ dirsync.Options) (dirsync.Stats, error) {
+			actualCopies = append(actualCopies, sourceDir, targetDir)
+			return dirsync.Stats{}, nil
+		})
 
 		var actualLstatLinks []string
 		utils.System.Lstat = func(name string) (os.FileInfo, error) {
@@ -101,7 +94,7 @@ func TestRestoreTablespaces(t *testing.T) {
 			return nil
 		}
 
-		defer func() { agent.SetRsyncCommand(nil) }()
+		defer agent.SetSyncDirectory(nil)
 
 		expectedRecreateSymLinkArgs := [][]string{
 			{"/tmp/default/1663/2", "/tmp/newprimary1/pg_tblspc/1663"},
@@ -117,13 +110,13 @@ func TestRestoreTablespaces(t *testing.T) {
 			return nil
 		}
 
-		err := agent.RestoreTablespaces(request, segment)
+		err := agent.RestoreTablespaces(context.Background(), request, segment)
 		if err != nil {
 			t.Errorf("got %+v, want nil", err)
 		}
 
-		if !reflect.DeepEqual(actualArgs, expectedRsyncArgs) {
-			t.Errorf("rsync() invoked with %q, want %q", actualArgs, expectedRsyncArgs)
+		if !reflect.DeepEqual(actualCopies, expectedCopies) {
+			t.Errorf("copied %q, want %q", actualCopies, expectedCopies)
 		}
 
 		if !reflect.DeepEqual(actualRecreateSymLinkArgs, expectedRecreateSymLinkArgs) {
@@ -135,23 +128,25 @@ func TestRestoreTablespaces(t *testing.T) {
 		}()
 	})
 
-	t.Run("restore tablespace fails during rsync", func(t *testing.T) {
+	t.Run("restore tablespace fails during the copy", func(t *testing.T) {
 		request := &idl.UpgradePrimariesRequest{
 			TablespacesMappingFilePath: "/tmp/tablespaces/tablespace_mapping.txt",
 		}
 
 		segment := getSampleSegment()
 
-		agent.SetRsyncCommand(exectest.NewCommand(agent.FailedMain))
-		defer func() { agent.SetRsyncCommand(nil) }()
+		agent.SetSyncDirectory(func(context.Context, string, string, dirsync.Options) (dirsync.Stats, error) {
+			return dirsync.Stats{}, os.ErrPermission
+		})
+		defer agent.SetSyncDirectory(nil)
 
-		err := agent.RestoreTablespaces(request, segment)
+		err := agent.RestoreTablespaces(context.Background(), request, segment)
 
 		if err == nil {
-			t.Error("expected Rsync() to fail")
+			t.Error("expected CopyDirectory() to fail")
 		}
 
-		expectedErrorStr := "rsync master tablespace directory"
+		expectedErrorStr := "copy master tablespace directory"
 		if !strings.Contains(err.Error(), expectedErrorStr) {
 			t.Errorf("got %+v, want %+v", err, expectedErrorStr)
 		}
@@ -171,10 +166,12 @@ func TestRestoreTablespaces(t *testing.T) {
 			utils.System = utils.InitializeSystemFunctions()
 		}()
 
-		agent.SetRsyncCommand(exectest.NewCommand(agent.Success))
-		defer func() { agent.SetRsyncCommand(nil) }()
+		agent.SetSyncDirectory(func(context.Context, string, string, dirsync.Options) (dirsync.Stats, error) {
+			return dirsync.Stats{}, nil
+		})
+		defer agent.SetSyncDirectory(nil)
 
-		err := agent.RestoreTablespaces(request, segment)
+		err := agent.RestoreTablespaces(context.Background(), request, segment)
 		if err == nil {
 			t.Error("expected ReCreateSymLink() to fail")
 		}
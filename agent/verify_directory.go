@@ -0,0 +1,61 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"io"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
+)
+
+// VerifyDirectory compares the target directory of the request against the
+// manifest streamed by the hub, and replies with the files that differ.
+func (s *Server) VerifyDirectory(stream idl.Agent_VerifyDirectoryServer) error {
+	gplog.Info("got a request to verify a directory from the hub")
+
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	options := msg.GetOptions()
+	if options == nil {
+		return status.Error(codes.InvalidArgument, "the first message must contain the sync options")
+	}
+
+	var manifest dirsync.Manifest
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		entry := msg.GetEntry()
+		if entry == nil {
+			return status.Errorf(codes.InvalidArgument, "expected a manifest entry, got %T", msg.Contents)
+		}
+
+		manifest = append(manifest, dirsync.ManifestEntryFromProto(entry))
+	}
+
+	mismatches, err := manifest.Verify(stream.Context(), options.TargetDir, dirsync.OptionsFromProto(options))
+	if err != nil {
+		return err
+	}
+
+	reply := &idl.VerifyDirectoryReply{}
+	for _, m := range mismatches {
+		reply.Mismatches = append(reply.Mismatches, dirsync.MismatchToProto(m))
+	}
+
+	return stream.SendAndClose(reply)
+}
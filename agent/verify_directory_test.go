@@ -0,0 +1,136 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package agent_test
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/agent"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
+)
+
+func TestVerifyDirectory(t *testing.T) {
+	testhelper.SetupTestLogger()
+
+	server := agent.NewServer(agent.Config{})
+
+	t.Run("it replies with the files that differ from the manifest", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "verify-source")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := testutils.GetTempDir(t, "verify-target")
+		defer testutils.MustRemoveAll(t, targetDir)
+
+		writeToFile(filepath.Join(sourceDir, "hello"), []byte("hello"), t)
+		writeToFile(filepath.Join(sourceDir, "goodbye"), []byte("goodbye"), t)
+
+		writeToFile(filepath.Join(targetDir, "hello"), []byte("jello"), t)
+		writeToFile(filepath.Join(targetDir, "extra"), []byte("extra"), t)
+
+		stream := &verifyStream{requests: verifyRequests(t, sourceDir, targetDir, dirsync.Options{Delete: true})}
+		if err := server.VerifyDirectory(stream); err != nil {
+			t.Fatalf("VerifyDirectory() returned error %+v", err)
+		}
+
+		expected := &idl.VerifyDirectoryReply{Mismatches: []*idl.Mismatch{
+			{Path: "extra", Reason: idl.Mismatch_EXTRANEOUS},
+			{Path: "goodbye", Reason: idl.Mismatch_MISSING},
+			{Path: "hello", Reason: idl.Mismatch_CHANGED, Detail: "checksum differs"},
+		}}
+		if !proto.Equal(stream.reply, expected) {
+			t.Errorf("got reply %v want %v", stream.reply, expected)
+		}
+	})
+
+	t.Run("it replies with no mismatches for an intact copy", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "verify-source")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := testutils.GetTempDir(t, "verify-target")
+		defer testutils.MustRemoveAll(t, targetDir)
+
+		writeToFile(filepath.Join(sourceDir, "hello"), []byte("hello"), t)
+		writeToFile(filepath.Join(targetDir, "hello"), []byte("hello"), t)
+
+		stream := &verifyStream{requests: verifyRequests(t, sourceDir, targetDir, dirsync.Options{Delete: true})}
+		if err := server.VerifyDirectory(stream); err != nil {
+			t.Fatalf("VerifyDirectory() returned error %+v", err)
+		}
+
+		if len(stream.reply.Mismatches) != 0 {
+			t.Errorf("got mismatches %v, want none", stream.reply.Mismatches)
+		}
+	})
+
+	t.Run("it requires the options first", func(t *testing.T) {
+		stream := &verifyStream{requests: []*idl.VerifyDirectoryRequest{
+			{Contents: &idl.VerifyDirectoryRequest_Entry{Entry: &idl.ManifestEntry{Path: "hello"}}},
+		}}
+
+		err := server.VerifyDirectory(stream)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("got error %#v want code %v", err, codes.InvalidArgument)
+		}
+	})
+}
+
+// verifyRequests returns the messages that stream the manifest of sourceDir to
+// verify targetDir, the way the hub sends them.
+func verifyRequests(t *testing.T, sourceDir, targetDir string, opts dirsync.Options) []*idl.VerifyDirectoryRequest {
+	t.Helper()
+
+	manifest, err := dirsync.NewManifest(context.Background(), []string{sourceDir + "/"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := []*idl.VerifyDirectoryRequest{
+		{Contents: &idl.VerifyDirectoryRequest_Options{Options: dirsync.OptionsToProto(targetDir, opts)}},
+	}
+	for _, e := range manifest {
+		requests = append(requests, &idl.VerifyDirectoryRequest{
+			Contents: &idl.VerifyDirectoryRequest_Entry{Entry: dirsync.ManifestEntryToProto(e)},
+		})
+	}
+
+	return requests
+}
+
+// verifyStream is an idl.Agent_VerifyDirectoryServer that receives the given
+// requests and records the reply.
+type verifyStream struct {
+	idl.Agent_VerifyDirectoryServer
+
+	requests []*idl.VerifyDirectoryRequest
+	reply    *idl.VerifyDirectoryReply
+}
+
+func (s *verifyStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *verifyStream) Recv() (*idl.VerifyDirectoryRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+
+	request := s.requests[0]
+	s.requests = s.requests[1:]
+	return request, nil
+}
+
+func (s *verifyStream) SendAndClose(reply *idl.VerifyDirectoryReply) error {
+	s.reply = reply
+	return nil
+}
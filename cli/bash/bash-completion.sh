@@ -226,6 +226,56 @@ __handle_word()
     __handle_word
 }
 
+_gpupgrade_check_help()
+{
+    last_command="gpupgrade_check_help"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_gpupgrade_check()
+{
+    last_command="gpupgrade_check"
+    commands=()
+    commands+=("help")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--?")
+    flags+=("-?")
+    local_nonpersistent_flags+=("--?")
+    flags+=("--disk-free-ratio=")
+    local_nonpersistent_flags+=("--disk-free-ratio=")
+    flags+=("--estimate-disk-space")
+    local_nonpersistent_flags+=("--estimate-disk-space")
+    flags+=("--format=")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    must_have_one_noun+=("catalog")
+    must_have_one_noun+=("disk_space")
+    must_have_one_noun+=("ports_free")
+    must_have_one_noun+=("segment_status")
+    must_have_one_noun+=("ssh_reachability")
+    must_have_one_noun+=("version_compatibility")
+    noun_aliases=()
+}
+
 _gpupgrade_config_set()
 {
     last_command="gpupgrade_config_set"
@@ -241,6 +291,7 @@ _gpupgrade_config_set()
     local_nonpersistent_flags+=("--source-bindir=")
     flags+=("--target-bindir=")
     local_nonpersistent_flags+=("--target-bindir=")
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -266,6 +317,7 @@ _gpupgrade_config_show()
     local_nonpersistent_flags+=("--target-bindir")
     flags+=("--target-datadir")
     local_nonpersistent_flags+=("--target-datadir")
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -285,6 +337,7 @@ _gpupgrade_config()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -302,6 +355,7 @@ _gpupgrade_execute_help()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -323,9 +377,14 @@ _gpupgrade_execute()
     flags+=("--?")
     flags+=("-?")
     local_nonpersistent_flags+=("--?")
+    flags+=("--dry-run")
+    local_nonpersistent_flags+=("--dry-run")
+    flags+=("--retry-failed-segments-only")
+    local_nonpersistent_flags+=("--retry-failed-segments-only")
     flags+=("--verbose")
     flags+=("-v")
     local_nonpersistent_flags+=("--verbose")
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -343,6 +402,7 @@ _gpupgrade_finalize_help()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -364,9 +424,12 @@ _gpupgrade_finalize()
     flags+=("--?")
     flags+=("-?")
     local_nonpersistent_flags+=("--?")
+    flags+=("--dry-run")
+    local_nonpersistent_flags+=("--dry-run")
     flags+=("--verbose")
     flags+=("-v")
     local_nonpersistent_flags+=("--verbose")
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -384,6 +447,7 @@ _gpupgrade_help()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -401,6 +465,7 @@ _gpupgrade_initialize_help()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -422,23 +487,52 @@ _gpupgrade_initialize()
     flags+=("--?")
     flags+=("-?")
     local_nonpersistent_flags+=("--?")
+    flags+=("--agent-metrics-port=")
+    local_nonpersistent_flags+=("--agent-metrics-port=")
     flags+=("--agent-port=")
     local_nonpersistent_flags+=("--agent-port=")
     flags+=("--disk-free-ratio=")
     local_nonpersistent_flags+=("--disk-free-ratio=")
+    flags+=("--dry-run")
+    local_nonpersistent_flags+=("--dry-run")
+    flags+=("--estimate-disk-space")
+    local_nonpersistent_flags+=("--estimate-disk-space")
+    flags+=("--max-upgrades=")
+    local_nonpersistent_flags+=("--max-upgrades=")
+    flags+=("--max-upgrades-per-host=")
+    local_nonpersistent_flags+=("--max-upgrades-per-host=")
+    flags+=("--metrics-address=")
+    local_nonpersistent_flags+=("--metrics-address=")
+    flags+=("--metrics-port=")
+    local_nonpersistent_flags+=("--metrics-port=")
     flags+=("--mode=")
     local_nonpersistent_flags+=("--mode=")
+    flags+=("--only-check=")
+    local_nonpersistent_flags+=("--only-check=")
+    flags+=("--skip-check=")
+    local_nonpersistent_flags+=("--skip-check=")
     flags+=("--source-bindir=")
     local_nonpersistent_flags+=("--source-bindir=")
     flags+=("--source-master-port=")
     local_nonpersistent_flags+=("--source-master-port=")
+    flags+=("--ssh-identity-file=")
+    local_nonpersistent_flags+=("--ssh-identity-file=")
+    flags+=("--ssh-option=")
+    local_nonpersistent_flags+=("--ssh-option=")
+    flags+=("--ssh-user=")
+    local_nonpersistent_flags+=("--ssh-user=")
     flags+=("--target-bindir=")
     local_nonpersistent_flags+=("--target-bindir=")
     flags+=("--temp-port-range=")
     local_nonpersistent_flags+=("--temp-port-range=")
+    flags+=("--tls")
+    local_nonpersistent_flags+=("--tls")
+    flags+=("--trusted-shell=")
+    local_nonpersistent_flags+=("--trusted-shell=")
     flags+=("--verbose")
     flags+=("-v")
     local_nonpersistent_flags+=("--verbose")
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_flag+=("--source-bindir=")
@@ -459,6 +553,49 @@ _gpupgrade_kill-services()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--format=")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_gpupgrade_recover_help()
+{
+    last_command="gpupgrade_recover_help"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_gpupgrade_recover()
+{
+    last_command="gpupgrade_recover"
+    commands=()
+    commands+=("help")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--?")
+    flags+=("-?")
+    local_nonpersistent_flags+=("--?")
+    flags+=("--force-retry=")
+    local_nonpersistent_flags+=("--force-retry=")
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -476,6 +613,9 @@ _gpupgrade_restart-services()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--redeploy-agents")
+    local_nonpersistent_flags+=("--redeploy-agents")
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -493,6 +633,7 @@ _gpupgrade_revert_help()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -514,9 +655,52 @@ _gpupgrade_revert()
     flags+=("--?")
     flags+=("-?")
     local_nonpersistent_flags+=("--?")
+    flags+=("--dry-run")
+    local_nonpersistent_flags+=("--dry-run")
     flags+=("--verbose")
     flags+=("-v")
     local_nonpersistent_flags+=("--verbose")
+    flags+=("--format=")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_gpupgrade_status_help()
+{
+    last_command="gpupgrade_status_help"
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--format=")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_gpupgrade_status()
+{
+    last_command="gpupgrade_status"
+    commands=()
+    commands+=("help")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--?")
+    flags+=("-?")
+    local_nonpersistent_flags+=("--?")
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -534,6 +718,7 @@ _gpupgrade_version()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--format=")
 
     must_have_one_flag=()
     must_have_one_noun=()
@@ -544,14 +729,17 @@ _gpupgrade()
 {
     last_command="gpupgrade"
     commands=()
+    commands+=("check")
     commands+=("config")
     commands+=("execute")
     commands+=("finalize")
     commands+=("help")
     commands+=("initialize")
     commands+=("kill-services")
+    commands+=("recover")
     commands+=("restart-services")
     commands+=("revert")
+    commands+=("status")
     commands+=("version")
 
     flags=()
@@ -563,6 +751,7 @@ _gpupgrade()
     flags+=("--?")
     flags+=("-?")
     local_nonpersistent_flags+=("--?")
+    flags+=("--format=")
     flags+=("--version")
     flags+=("-V")
     local_nonpersistent_flags+=("--version")
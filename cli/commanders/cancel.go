@@ -0,0 +1,61 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package commanders
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// Allow signal delivery to be simulated in tests.
+var notifySignals = signal.Notify
+var stopSignals = signal.Stop
+
+// cancelOnInterrupt asks the hub to cancel the running step when the user
+// presses Ctrl-C. Progress continues to be reported until the hub has stopped
+// the step and marked the running substep as failed; a second Ctrl-C exits
+// without waiting. The returned function stops watching for interrupts.
+func cancelOnInterrupt(client idl.CliToHubClient) func() {
+	signals := make(chan os.Signal, 1)
+	notifySignals(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		select {
+		case <-done:
+			return
+		case <-signals:
+		}
+
+		// Restore the default behavior, so that another interrupt exits.
+		stopSignals(signals)
+
+		if !jsonOutput {
+			fmt.Println("\nCancelling. Press Ctrl-C again to exit without waiting for the hub.")
+		}
+
+		if _, err := client.Cancel(context.Background(), &idl.CancelRequest{}); err != nil {
+			gplog.Error("cancelling: %s", err)
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+		stopSignals(signals)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package commanders
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+)
+
+func TestCancelOnInterrupt(t *testing.T) {
+	var signals chan<- os.Signal
+	notifySignals = func(c chan<- os.Signal, _ ...os.Signal) {
+		signals = c
+	}
+	stopSignals = func(chan<- os.Signal) {}
+	defer func() {
+		notifySignals = signal.Notify
+		stopSignals = signal.Stop
+	}()
+
+	t.Run("asks the hub to cancel when interrupted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cancelled := make(chan struct{})
+		client := mock_idl.NewMockCliToHubClient(ctrl)
+		client.EXPECT().Cancel(gomock.Any(), &idl.CancelRequest{}).
+			DoAndReturn(func(context.Context, *idl.CancelRequest, ...grpc.CallOption) (*idl.CancelReply, error) {
+				close(cancelled)
+				return &idl.CancelReply{Cancelled: true}, nil
+			})
+
+		// Keep the cancellation message out of the test output.
+		jsonOutput = true
+		defer func() { jsonOutput = false }()
+
+		stop := cancelOnInterrupt(client)
+		defer stop()
+
+		signals <- os.Interrupt
+
+		select {
+		case <-cancelled:
+		case <-time.After(5 * time.Second):
+			t.Error("Cancel() was not called")
+		}
+	})
+
+	t.Run("does not cancel once stopped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock_idl.NewMockCliToHubClient(ctrl)
+		// No calls to Cancel are expected.
+
+		stop := cancelOnInterrupt(client)
+		stop()
+
+		signals <- os.Interrupt
+	})
+}
@@ -6,18 +6,256 @@ package commanders
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"sort"
 	"strings"
 	"text/tabwriter"
 
+	"github.com/hashicorp/go-multierror"
 	"golang.org/x/xerrors"
 
 	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils"
 	"github.com/greenplum-db/gpupgrade/utils/disk"
 )
 
-func RunChecks(client idl.CliToHubClient, ratio float64) error {
-	return CheckDiskSpace(client, ratio)
+// Check is a named pre-upgrade check. Initialize runs the checks before
+// creating the target cluster, and gpupgrade check runs them on their own. A
+// check fails by returning an error that is ErrCheckFailed; any other error
+// means that the check could not be run.
+type Check struct {
+	Name        string
+	Description string
+	Run         func(client idl.CliToHubClient, opts CheckOptions) error
+}
+
+type CheckOptions struct {
+	DiskFreeRatio float64
+
+	// EstimateDiskSpace estimates the space needed from the size of the data,
+	// instead of using DiskFreeRatio.
+	EstimateDiskSpace bool
+}
+
+// Checks is the registry of pre-upgrade checks, in the order that they run.
+var Checks = []Check{
+	{
+		Name:        "disk_space",
+		Description: "every host has enough free disk space for the upgrade",
+		Run: func(client idl.CliToHubClient, opts CheckOptions) error {
+			return diskSpace(client, opts)
+		},
+	},
+	{
+		Name:        "segment_status",
+		Description: "every segment of the source cluster is up and in its preferred role",
+		Run:         hubCheck(idl.RunCheckRequest_SEGMENT_STATUS),
+	},
+	{
+		Name:        "ports_free",
+		Description: "the target cluster can be given free temporary ports on every host",
+		Run:         hubCheck(idl.RunCheckRequest_PORTS_FREE),
+	},
+	{
+		Name:        "ssh_reachability",
+		Description: "every host can be reached over ssh without a password",
+		Run:         hubCheck(idl.RunCheckRequest_SSH_REACHABILITY),
+	},
+	{
+		Name:        "version_compatibility",
+		Description: "the source cluster can be upgraded to the version of the target binaries",
+		Run:         hubCheck(idl.RunCheckRequest_VERSION_COMPATIBILITY),
+	},
+	{
+		Name:        "catalog",
+		Description: "the source catalog has no objects that block the upgrade",
+		Run:         hubCheck(idl.RunCheckRequest_SOURCE_CATALOG),
+	},
+}
+
+// CheckNames returns the names of the registered checks.
+func CheckNames() []string {
+	names := make([]string, len(Checks))
+	for i, check := range Checks {
+		names[i] = check.Name
+	}
+	return names
+}
+
+// SelectChecks returns the registered checks that are named in only, or all
+// of them when only is empty, less those named in skip.
+func SelectChecks(only, skip []string) ([]Check, error) {
+	known := make(map[string]bool)
+	for _, name := range CheckNames() {
+		known[name] = true
+	}
+
+	for _, name := range append(append([]string{}, only...), skip...) {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown check %q; the checks are %s", name, strings.Join(CheckNames(), ", "))
+		}
+	}
+
+	contains := func(names []string, name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	var checks []Check
+	for _, check := range Checks {
+		if len(only) > 0 && !contains(only, check.Name) {
+			continue
+		}
+		if contains(skip, check.Name) {
+			continue
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// CheckResultsFile is where the results of the last checks are saved, within
+// the state directory.
+const CheckResultsFile = "check_results.txt"
+
+var ErrCheckFailed = xerrors.New("check failed")
+
+// CheckFailedError lists the failures of a check that the hub ran.
+type CheckFailedError struct {
+	Failures    []string
+	Remediation string
+}
+
+func (c CheckFailedError) Error() string {
+	msg := strings.Join(c.Failures, "\n")
+	if c.Remediation != "" {
+		msg += "\n" + c.Remediation
+	}
+	return msg
+}
+
+func (c CheckFailedError) Is(err error) bool {
+	return err == ErrCheckFailed
+}
+
+// ChecksFailedError names the checks that did not pass.
+type ChecksFailedError struct {
+	Failed      []string
+	ResultsFile string
+}
+
+func (c ChecksFailedError) Error() string {
+	return fmt.Sprintf("%d check(s) did not pass: %s. The results are saved in %s.",
+		len(c.Failed), strings.Join(c.Failed, ", "), c.ResultsFile)
+}
+
+// hubCheck returns the Run function of a check that the hub performs.
+func hubCheck(check idl.RunCheckRequest_Check) func(idl.CliToHubClient, CheckOptions) error {
+	return func(client idl.CliToHubClient, _ CheckOptions) error {
+		reply, err := client.RunCheck(context.Background(), &idl.RunCheckRequest{Check: check})
+		if err != nil {
+			return xerrors.Errorf("run check: %w", err)
+		}
+
+		if len(reply.Failures) > 0 {
+			return CheckFailedError{Failures: reply.Failures, Remediation: reply.Remediation}
+		}
+
+		return nil
+	}
+}
+
+// CheckResult is the outcome of running a check.
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+func (r CheckResult) Status() string {
+	switch {
+	case r.Err == nil:
+		return "PASSED"
+	case xerrors.Is(r.Err, ErrCheckFailed):
+		return "FAILED"
+	default:
+		return "ERROR"
+	}
+}
+
+// RunChecks runs each check, then prints a table of their results and saves it
+// to the state directory. A ChecksFailedError is returned when any of them do
+// not pass.
+func RunChecks(client idl.CliToHubClient, checks []Check, opts CheckOptions) (err error) {
+	s := Substep(idl.Substep_RUN_CHECKS)
+
+	var results []CheckResult
+	var failed []string
+	for _, check := range checks {
+		err := check.Run(client, opts)
+		if err != nil {
+			failed = append(failed, check.Name)
+		}
+		results = append(results, CheckResult{Name: check.Name, Err: err})
+	}
+
+	path := filepath.Join(utils.GetStateDir(), CheckResultsFile)
+	if len(failed) > 0 {
+		err = ChecksFailedError{Failed: failed, ResultsFile: path}
+	}
+	s.Finish(&err)
+
+	table := FormatCheckResults(results)
+	if !jsonOutput {
+		fmt.Printf("\n%s\n", table)
+	}
+
+	if werr := ioutil.WriteFile(path, []byte(table), 0644); werr != nil {
+		err = multierror.Append(err, xerrors.Errorf("saving check results: %w", werr)).ErrorOrNil()
+	}
+
+	return err
+}
+
+// FormatCheckResults returns a table of the result of each check. The details
+// of a check that did not pass follow its result, one line per row.
+func FormatCheckResults(results []CheckResult) string {
+	var b strings.Builder
+
+	var t tabwriter.Writer
+	t.Init(&b, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(&t, "Check\tResult\tDetails")
+	for _, result := range results {
+		var details []string
+		if result.Err != nil {
+			details = strings.Split(strings.TrimRight(result.Err.Error(), "\n"), "\n")
+		}
+
+		first := ""
+		if len(details) > 0 {
+			first = details[0]
+		}
+		fmt.Fprintf(&t, "%s\t%s\t%s\n", result.Name, result.Status(), first)
+
+		for i := 1; i < len(details); i++ {
+			fmt.Fprintf(&t, "\t\t%s\n", details[i])
+		}
+	}
+
+	t.Flush()
+
+	// Trim the padding that follows results without details.
+	lines := strings.Split(b.String(), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	return strings.Join(lines, "\n")
 }
 
 type DiskSpaceError struct {
@@ -40,11 +278,29 @@ func (d DiskSpaceError) Error() string {
 	var b strings.Builder
 	b.WriteString("You currently do not have enough disk space to run an upgrade.\n\n")
 
-	// Pretty-print our output with tab-alignment.
+	space, inodes := d.Table(), d.InodeTable()
+	if len(space) > 1 {
+		writeTable(&b, space)
+	}
+
+	if len(inodes) > 1 {
+		if len(space) > 1 {
+			b.WriteString("\n")
+		}
+
+		b.WriteString("These filesystems do not have enough free inodes:\n\n")
+		writeTable(&b, inodes)
+	}
+
+	return b.String()
+}
+
+// writeTable pretty-prints rows with tab-alignment.
+func writeTable(b *strings.Builder, rows [][]string) {
 	var t tabwriter.Writer
-	t.Init(&b, 0, 0, 2, ' ', 0)
+	t.Init(b, 0, 0, 2, ' ', 0)
 
-	for _, row := range d.Table() {
+	for _, row := range rows {
 		for _, col := range row {
 			fmt.Fprintf(&t, "%s\t", col)
 		}
@@ -52,13 +308,21 @@ func (d DiskSpaceError) Error() string {
 	}
 
 	t.Flush()
-	return b.String()
 }
 
+func (d DiskSpaceError) Is(err error) bool {
+	return err == ErrCheckFailed
+}
+
+// Table lists the filesystems that do not have enough space, and by how much.
 func (d DiskSpaceError) Table() [][]string {
 	var rows [][]string
 
 	for id, disk := range d.Failed {
+		if disk.Available >= disk.Required {
+			continue // there are only too few inodes
+		}
+
 		parts := strings.Split(id, ": ")
 		host, fs := parts[0], parts[1]
 
@@ -75,6 +339,32 @@ func (d DiskSpaceError) Table() [][]string {
 	return rows
 }
 
+// InodeTable lists the filesystems that do not have enough free inodes, and by
+// how many.
+func (d DiskSpaceError) InodeTable() [][]string {
+	var rows [][]string
+
+	for id, disk := range d.Failed {
+		if disk.InodesAvailable >= disk.InodesRequired {
+			continue
+		}
+
+		parts := strings.Split(id, ": ")
+		host, fs := parts[0], parts[1]
+
+		rows = append(rows, []string{host, fs,
+			fmt.Sprint(disk.InodesRequired - disk.InodesAvailable),
+			fmt.Sprint(disk.InodesAvailable),
+			fmt.Sprint(disk.InodesRequired),
+		})
+	}
+
+	sort.Sort(tableRows(rows))
+	rows = append([][]string{{"Hostname", "Filesystem", "Shortfall", "Available", "Required"}}, rows...)
+
+	return rows
+}
+
 // tableRows attaches sort.Interface to a slice of string slices.
 type tableRows [][]string
 
@@ -96,11 +386,10 @@ func (t tableRows) Swap(i, j int) {
 	t[i], t[j] = t[j], t[i]
 }
 
-func CheckDiskSpace(client idl.CliToHubClient, ratio float64) (err error) {
-	s := Substep(idl.Substep_CHECK_DISK_SPACE)
-	defer s.Finish(&err)
+func diskSpace(client idl.CliToHubClient, opts CheckOptions) error {
+	request := &idl.CheckDiskSpaceRequest{Ratio: opts.DiskFreeRatio, Estimate: opts.EstimateDiskSpace}
 
-	reply, err := client.CheckDiskSpace(context.Background(), &idl.CheckDiskSpaceRequest{Ratio: ratio})
+	reply, err := client.CheckDiskSpace(context.Background(), request)
 	if err != nil {
 		return xerrors.Errorf("check disk space: %w", err)
 	}
@@ -5,6 +5,8 @@ package commanders_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -14,6 +16,7 @@ import (
 	"github.com/greenplum-db/gpupgrade/cli/commanders"
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
 	"github.com/greenplum-db/gpupgrade/utils/disk"
 
 	"github.com/golang/mock/gomock"
@@ -79,6 +82,59 @@ func TestDiskSpaceError(t *testing.T) {
 	}
 }
 
+func TestDiskSpaceErrorInodes(t *testing.T) {
+	err := &commanders.DiskSpaceError{
+		Failed: disk.SpaceFailures{
+			"sdw1: /": {
+				InodesAvailable: 15,
+				InodesRequired:  20,
+			},
+			"mdw: /": {
+				Available:       1024,
+				Required:        2048,
+				InodesAvailable: 1,
+				InodesRequired:  3,
+			},
+		},
+	}
+
+	t.Run("lists only the filesystems without enough space", func(t *testing.T) {
+		expected := [][]string{
+			{"Hostname", "Filesystem", "Shortfall", "Available", "Required"},
+			{"mdw", "/", commanders.FormatBytes(1024), commanders.FormatBytes(1024), commanders.FormatBytes(2048)},
+		}
+		if rows := err.Table(); !reflect.DeepEqual(rows, expected) {
+			t.Errorf("got table %q, want %q", rows, expected)
+		}
+	})
+
+	t.Run("lists the filesystems without enough inodes", func(t *testing.T) {
+		expected := [][]string{
+			{"Hostname", "Filesystem", "Shortfall", "Available", "Required"},
+			{"mdw", "/", "2", "1", "3"},
+			{"sdw1", "/", "5", "15", "20"},
+		}
+		if rows := err.InodeTable(); !reflect.DeepEqual(rows, expected) {
+			t.Errorf("got table %q, want %q", rows, expected)
+		}
+	})
+
+	t.Run("reports inodes apart from space", func(t *testing.T) {
+		msg := err.Error()
+		if !strings.Contains(msg, "These filesystems do not have enough free inodes:") {
+			t.Errorf("got error %q, want it to report the inode shortfall", msg)
+		}
+
+		inodesOnly := commanders.DiskSpaceError{Failed: disk.SpaceFailures{
+			"sdw1: /": {InodesAvailable: 15, InodesRequired: 20},
+		}}
+		msg = inodesOnly.Error()
+		if strings.Count(msg, "Hostname") != 1 {
+			t.Errorf("got error %q, want only the inode table", msg)
+		}
+	})
+}
+
 func TestDiskSpaceCheck(t *testing.T) {
 	cases := []struct {
 		name    string
@@ -116,13 +172,8 @@ func TestDiskSpaceCheck(t *testing.T) {
 				&idl.CheckDiskSpaceRequest{Ratio: ratio},
 			).Return(&idl.CheckDiskSpaceReply{Failed: c.failed}, c.grpcErr)
 
-			d := bufferStandardDescriptors(t)
-			defer d.Close()
+			err := mustSelectCheck(t, "disk_space").Run(client, commanders.CheckOptions{DiskFreeRatio: ratio})
 
-			err := commanders.CheckDiskSpace(client, ratio)
-			actualOut, _ := d.Collect()
-
-			expectedStatus := idl.Status_FAILED
 			switch {
 			case c.grpcErr != nil:
 				if !xerrors.Is(err, c.grpcErr) {
@@ -138,16 +189,179 @@ func TestDiskSpaceCheck(t *testing.T) {
 				}
 
 			default:
-				expectedStatus = idl.Status_COMPLETE
 				if err != nil {
 					t.Errorf("returned error %#v, expected no error", err)
 				}
 			}
+		})
+	}
+
+	t.Run("asks the hub to estimate the space needed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock_idl.NewMockCliToHubClient(ctrl)
+		client.EXPECT().CheckDiskSpace(
+			gomock.Any(),
+			&idl.CheckDiskSpaceRequest{Estimate: true},
+		).Return(&idl.CheckDiskSpaceReply{}, nil)
+
+		err := mustSelectCheck(t, "disk_space").Run(client, commanders.CheckOptions{EstimateDiskSpace: true})
+		if err != nil {
+			t.Errorf("returned error %#v, expected no error", err)
+		}
+	})
+}
+
+func TestSelectChecks(t *testing.T) {
+	names := func(checks []commanders.Check) []string {
+		var names []string
+		for _, check := range checks {
+			names = append(names, check.Name)
+		}
+		return names
+	}
+
+	cases := []struct {
+		name     string
+		only     []string
+		skip     []string
+		expected []string
+	}{
+		{"selects every check by default", nil, nil, commanders.CheckNames()},
+		{"selects only the named checks, in order", []string{"catalog", "disk_space"}, nil, []string{"disk_space", "catalog"}},
+		{"skips the named checks", nil, []string{"catalog", "ports_free"},
+			[]string{"disk_space", "segment_status", "ssh_reachability", "version_compatibility"}},
+		{"skips from the named checks", []string{"catalog", "disk_space"}, []string{"catalog"}, []string{"disk_space"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			checks, err := commanders.SelectChecks(c.only, c.skip)
+			if err != nil {
+				t.Fatalf("SelectChecks() returned error %+v", err)
+			}
 
-			expected := commanders.Format("Checking disk space...", expectedStatus)
-			if !strings.Contains(string(actualOut), expected) {
-				t.Errorf("Expected string %q to contain %q", actualOut, expected)
+			if actual := names(checks); !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("got checks %q want %q", actual, c.expected)
 			}
 		})
 	}
+
+	t.Run("errors for unknown checks", func(t *testing.T) {
+		for _, args := range [][2][]string{{{"disk_spaces"}, nil}, {nil, {"nonesuch"}}} {
+			_, err := commanders.SelectChecks(args[0], args[1])
+			if err == nil || !strings.Contains(err.Error(), "unknown check") {
+				t.Errorf("got error %v, want an unknown check error", err)
+			}
+		}
+	})
+}
+
+func TestHubChecks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mock_idl.NewMockCliToHubClient(ctrl)
+	client.EXPECT().RunCheck(
+		gomock.Any(),
+		&idl.RunCheckRequest{Check: idl.RunCheckRequest_SOURCE_CATALOG},
+	).Return(&idl.RunCheckReply{Failures: []string{"a", "b"}, Remediation: "fix them"}, nil)
+
+	err := mustSelectCheck(t, "catalog").Run(client, commanders.CheckOptions{})
+	if !xerrors.Is(err, commanders.ErrCheckFailed) {
+		t.Errorf("got error %#v want %#v", err, commanders.ErrCheckFailed)
+	}
+
+	expected := "a\nb\nfix them"
+	if err.Error() != expected {
+		t.Errorf("got error %q want %q", err.Error(), expected)
+	}
+}
+
+func TestRunChecks(t *testing.T) {
+	stateDir := testutils.GetTempDir(t, "")
+	defer testutils.MustRemoveAll(t, stateDir)
+
+	resetEnv := testutils.SetEnv(t, "GPUPGRADE_HOME", stateDir)
+	defer resetEnv()
+
+	passing := commanders.Check{Name: "passing", Run: func(idl.CliToHubClient, commanders.CheckOptions) error {
+		return nil
+	}}
+	failing := commanders.Check{Name: "failing", Run: func(idl.CliToHubClient, commanders.CheckOptions) error {
+		return commanders.CheckFailedError{Failures: []string{"first", "second"}}
+	}}
+	broken := commanders.Check{Name: "broken", Run: func(idl.CliToHubClient, commanders.CheckOptions) error {
+		return errors.New("connection refused")
+	}}
+
+	t.Run("prints and saves the results of each check", func(t *testing.T) {
+		d := bufferStandardDescriptors(t)
+		defer d.Close()
+
+		err := commanders.RunChecks(nil, []commanders.Check{passing, failing, broken}, commanders.CheckOptions{})
+		actualOut, _ := d.Collect()
+
+		path := filepath.Join(stateDir, commanders.CheckResultsFile)
+		var failedErr commanders.ChecksFailedError
+		if !xerrors.As(err, &failedErr) {
+			t.Fatalf("got error %#v, want type %T", err, failedErr)
+		}
+
+		expectedErr := commanders.ChecksFailedError{Failed: []string{"failing", "broken"}, ResultsFile: path}
+		if !reflect.DeepEqual(failedErr, expectedErr) {
+			t.Errorf("got error %+v want %+v", failedErr, expectedErr)
+		}
+
+		expected := `Check    Result  Details
+passing  PASSED
+failing  FAILED  first
+                 second
+broken   ERROR   connection refused
+`
+		if !strings.Contains(string(actualOut), expected) {
+			t.Errorf("got output %q, want it to contain %q", actualOut, expected)
+		}
+
+		if !strings.Contains(string(actualOut), commanders.Format("Running pre-upgrade checks...", idl.Status_FAILED)) {
+			t.Errorf("got output %q, want the substep to fail", actualOut)
+		}
+
+		saved, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(saved) != expected {
+			t.Errorf("saved results %q want %q", saved, expected)
+		}
+	})
+
+	t.Run("succeeds when every check passes", func(t *testing.T) {
+		d := bufferStandardDescriptors(t)
+		defer d.Close()
+
+		err := commanders.RunChecks(nil, []commanders.Check{passing}, commanders.CheckOptions{})
+		actualOut, _ := d.Collect()
+
+		if err != nil {
+			t.Errorf("RunChecks() returned error %+v", err)
+		}
+
+		if !strings.Contains(string(actualOut), commanders.Format("Running pre-upgrade checks...", idl.Status_COMPLETE)) {
+			t.Errorf("got output %q, want the substep to complete", actualOut)
+		}
+	})
+}
+
+func mustSelectCheck(t *testing.T, name string) commanders.Check {
+	t.Helper()
+
+	checks, err := commanders.SelectChecks([]string{name}, nil)
+	if err != nil {
+		t.Fatalf("SelectChecks() returned error %+v", err)
+	}
+
+	return checks[0]
 }
@@ -4,10 +4,12 @@
 package commanders
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 
 	"github.com/greenplum-db/gpupgrade/idl"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 )
 
 // introduce this variable to allow exec.Command to be mocked out in tests
@@ -42,7 +45,12 @@ func CreateStateDir() (err error) {
 	return nil
 }
 
-func CreateInitialClusterConfigs() (err error) {
+// CreateInitialClusterConfigs writes the configuration the hub starts with.
+// When useTLS is set, a CA and the hub's certificate are generated in the state
+// directory, and the hub is configured to use them. The hub and agents serve
+// metrics on metricsAddress and the given ports unless they are zero. The hub
+// runs commands on the other hosts using remoteShell.
+func CreateInitialClusterConfigs(useTLS bool, metricsAddress string, metricsPort, agentMetricsPort int, remoteShell hub.RemoteShell) (err error) {
 	s := Substep(idl.Substep_GENERATING_CONFIG)
 	defer s.Finish(&err)
 
@@ -63,14 +71,41 @@ func CreateInitialClusterConfigs() (err error) {
 		return err
 	}
 
+	// The hub will fill in the rest during initialization. Only set the
+	// fields we need, so that the hub's defaults are not overwritten.
+	initial := make(map[string]interface{})
+	if useTLS {
+		tlsConf, err := certs.GenerateHub(utils.GetStateDir())
+		if err != nil {
+			return fmt.Errorf("generating TLS certificates: %w", err)
+		}
+
+		initial["TLS"] = tlsConf
+	}
+
+	if metricsPort != 0 {
+		initial["MetricsPort"] = metricsPort
+	}
+
+	if agentMetricsPort != 0 {
+		initial["AgentMetricsPort"] = agentMetricsPort
+	}
+
+	if metricsAddress != "" {
+		initial["MetricsAddress"] = metricsAddress
+	}
+
+	if !reflect.DeepEqual(remoteShell, hub.RemoteShell{}) {
+		initial["RemoteShell"] = remoteShell
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	fmt.Fprintf(file, "{}") // the hub will fill this in during initialization
-	return nil
+	return json.NewEncoder(file).Encode(initial)
 }
 
 func StartHub() (err error) {
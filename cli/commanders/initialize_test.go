@@ -16,6 +16,7 @@ import (
 
 	"github.com/greenplum-db/gpupgrade/hub"
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 )
 
 // Streams the above stdout/err constants to the corresponding standard file
@@ -252,7 +253,7 @@ func TestCreateInitialClusterConfigs(t *testing.T) {
 	t.Run("test idempotence", func(t *testing.T) {
 
 		{ // creates initial cluster config files if none exist or fails"
-			err = CreateInitialClusterConfigs()
+			err = CreateInitialClusterConfigs(false, "", 0, 0, hub.RemoteShell{})
 			if err != nil {
 				t.Fatalf("unexpected error %#v", err)
 			}
@@ -263,7 +264,7 @@ func TestCreateInitialClusterConfigs(t *testing.T) {
 		}
 
 		{ // creating cluster config files is idempotent
-			err = CreateInitialClusterConfigs()
+			err = CreateInitialClusterConfigs(false, "", 0, 0, hub.RemoteShell{})
 			if err != nil {
 				t.Fatalf("unexpected error %#v", err)
 			}
@@ -279,10 +280,73 @@ func TestCreateInitialClusterConfigs(t *testing.T) {
 		}
 
 		{ // creating cluster config files succeeds on multiple runs
-			err = CreateInitialClusterConfigs()
+			err = CreateInitialClusterConfigs(false, "", 0, 0, hub.RemoteShell{})
 			if err != nil {
 				t.Fatalf("unexpected error %#v", err)
 			}
 		}
 	})
 }
+
+func TestCreateInitialClusterConfigsWithSettings(t *testing.T) {
+	home, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatalf("failed creating temp dir %#v", err)
+	}
+	defer os.RemoveAll(home)
+
+	oldStateDir, isSet := os.LookupEnv("GPUPGRADE_HOME")
+	defer func() {
+		if isSet {
+			os.Setenv("GPUPGRADE_HOME", oldStateDir)
+		} else {
+			os.Unsetenv("GPUPGRADE_HOME")
+		}
+	}()
+
+	stateDir := filepath.Join(home, ".gpupgrade")
+	if err := os.Setenv("GPUPGRADE_HOME", stateDir); err != nil {
+		t.Fatalf("failed to set GPUPGRADE_HOME %#v", err)
+	}
+
+	if err := CreateStateDir(); err != nil {
+		t.Fatalf("failed to create state dir %#v", err)
+	}
+
+	if err := CreateInitialClusterConfigs(true, "0.0.0.0", 9090, 9091, hub.RemoteShell{User: "gpadmin", Options: []string{"BatchMode=yes"}}); err != nil {
+		t.Fatalf("unexpected error %#v", err)
+	}
+
+	conf := &hub.Config{Port: 7527}
+	if err := hub.LoadConfig(conf, filepath.Join(stateDir, hub.ConfigFileName)); err != nil {
+		t.Fatalf("LoadConfig() returned error %+v", err)
+	}
+
+	expected := certs.HubConfig(stateDir)
+	if conf.TLS != expected {
+		t.Errorf("got TLS config %+v want %+v", conf.TLS, expected)
+	}
+
+	if conf.Port != 7527 {
+		t.Errorf("got port %d, want the default to be kept", conf.Port)
+	}
+
+	if conf.MetricsPort != 9090 || conf.AgentMetricsPort != 9091 {
+		t.Errorf("got metrics ports %d and %d, want 9090 and 9091", conf.MetricsPort, conf.AgentMetricsPort)
+	}
+
+	if conf.MetricsAddress != "0.0.0.0" {
+		t.Errorf("got metrics address %q want %q", conf.MetricsAddress, "0.0.0.0")
+	}
+
+	expectedShell := hub.RemoteShell{User: "gpadmin", Options: []string{"BatchMode=yes"}}
+	if !reflect.DeepEqual(conf.RemoteShell, expectedShell) {
+		t.Errorf("got remote shell %+v want %+v", conf.RemoteShell, expectedShell)
+	}
+
+	for _, path := range []string{expected.CACert, expected.Cert, expected.Key} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %q to exist: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package commanders
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+const (
+	TextFormat = "text"
+	JSONFormat = "json"
+)
+
+// jsonOutput is set by SetFormat. When it is true, all progress is reported as
+// a stream of newline-delimited JSON objects on stdout instead of the usual
+// human-readable text.
+var jsonOutput bool
+
+// SetFormat selects the output format used by all commanders. It returns an
+// error for anything other than TextFormat or JSONFormat.
+func SetFormat(format string) error {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case TextFormat:
+		jsonOutput = false
+	case JSONFormat:
+		jsonOutput = true
+	default:
+		return fmt.Errorf("Invalid format %q. Please specify either %s or %s.", format, TextFormat, JSONFormat)
+	}
+
+	return nil
+}
+
+// IsJSONFormat reports whether output is being written as JSON. Callers should
+// suppress any free-text output of their own when it returns true.
+func IsJSONFormat() bool {
+	return jsonOutput
+}
+
+// Event is the JSON representation of a single idl.Message, or of a substep
+// run directly by the CLI.
+type Event struct {
+	Type     string            `json:"type"`               // "status", "chunk", "segment_result", "segment_results" or "response"
+	Substep  string            `json:"substep,omitempty"`  // status events only
+	Status   string            `json:"status,omitempty"`   // status events only
+	Stream   string            `json:"stream,omitempty"`   // chunk events only; "stdout" or "stderr"
+	Output   string            `json:"output,omitempty"`   // chunk events only
+	Segments []SegmentResult   `json:"segments,omitempty"` // segment_result(s) events only
+	Data     map[string]string `json:"data,omitempty"`     // response events only
+}
+
+// SegmentResult is the JSON representation of an idl.SegmentResult.
+type SegmentResult struct {
+	Host       string `json:"host"`
+	Content    int32  `json:"content"`
+	Dbid       int32  `json:"dbid"`
+	Phase      string `json:"phase"`
+	DurationMs int64  `json:"duration_ms"`
+	ExitCode   int32  `json:"exit_code"`
+	LogDir     string `json:"log_dir,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Skipped    bool   `json:"skipped,omitempty"`
+}
+
+// Summary is the final JSON object written by each command.
+type Summary struct {
+	Type                      string          `json:"type"` // always "summary"
+	Command                   string          `json:"command"`
+	Result                    string          `json:"result"` // "success" or "failure"
+	Error                     string          `json:"error,omitempty"`
+	TargetPort                string          `json:"target_port,omitempty"`
+	TargetMasterDataDirectory string          `json:"target_master_data_directory,omitempty"`
+	Status                    json.RawMessage `json:"status,omitempty"`
+	NextAction                string          `json:"next_action,omitempty"`
+	Reply                     json.RawMessage `json:"reply,omitempty"`
+}
+
+func newEvent(msg *idl.Message) Event {
+	switch x := msg.Contents.(type) {
+	case *idl.Message_Chunk:
+		return Event{
+			Type:   "chunk",
+			Stream: strings.ToLower(x.Chunk.Type.String()),
+			Output: string(x.Chunk.Buffer),
+		}
+
+	case *idl.Message_Status:
+		return statusEvent(x.Status.Step, x.Status.Status)
+
+	case *idl.Message_SegmentResult:
+		return Event{
+			Type:     "segment_result",
+			Segments: segmentResults([]*idl.SegmentResult{x.SegmentResult}),
+		}
+
+	case *idl.Message_SegmentResults:
+		return Event{
+			Type:     "segment_results",
+			Segments: segmentResults(x.SegmentResults.Results),
+		}
+
+	case *idl.Message_Response:
+		return Event{
+			Type: "response",
+			Data: x.Response.Data,
+		}
+
+	default:
+		panic(fmt.Sprintf("unknown message type: %T", x))
+	}
+}
+
+func segmentResults(results []*idl.SegmentResult) []SegmentResult {
+	var converted []SegmentResult
+	for _, r := range results {
+		converted = append(converted, SegmentResult{
+			Host:       r.Host,
+			Content:    r.Content,
+			Dbid:       r.Dbid,
+			Phase:      r.Phase.String(),
+			DurationMs: r.DurationMs,
+			ExitCode:   r.ExitCode,
+			LogDir:     r.LogDir,
+			Error:      r.Error,
+			Skipped:    r.Skipped,
+		})
+	}
+
+	return converted
+}
+
+func statusEvent(substep idl.Substep, status idl.Status) Event {
+	return Event{
+		Type:    "status",
+		Substep: substep.String(),
+		Status:  status.String(),
+	}
+}
+
+// writeJSON writes v to stdout as a single line of JSON.
+func writeJSON(v interface{}) {
+	// Look up os.Stdout on every call so that it may be redirected.
+	err := json.NewEncoder(os.Stdout).Encode(v)
+	if err != nil {
+		gplog.Error("writing JSON output: %v", err)
+	}
+}
+
+// PrintSummary writes the final Summary for the given command when JSON
+// output is enabled; in text mode it does nothing. Any target cluster
+// information found in data is included in the Summary.
+func PrintSummary(command string, data map[string]string, err error) {
+	if !jsonOutput {
+		return
+	}
+
+	summary := newSummary(command, err)
+	summary.TargetPort = data[idl.ResponseKey_target_port.String()]
+	summary.TargetMasterDataDirectory = data[idl.ResponseKey_target_master_data_directory.String()]
+
+	writeJSON(summary)
+}
+
+// printStatusSummary writes the Summary for the status command.
+func printStatusSummary(reply *idl.GetStatusReply) {
+	summary := newSummary("status", nil)
+	summary.NextAction = NextAction(reply)
+
+	status, err := marshalReply(reply)
+	if err != nil {
+		summary = newSummary("status", err)
+	}
+	summary.Status = status
+
+	writeJSON(summary)
+}
+
+// printReplySummary writes a Summary for the given command that includes the
+// hub's reply.
+func printReplySummary(command string, reply proto.Message) {
+	summary := newSummary(command, nil)
+
+	raw, err := marshalReply(reply)
+	if err != nil {
+		summary = newSummary(command, err)
+	}
+	summary.Reply = raw
+
+	writeJSON(summary)
+}
+
+func marshalReply(reply proto.Message) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&buf, reply); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func newSummary(command string, err error) Summary {
+	summary := Summary{
+		Type:    "summary",
+		Command: command,
+		Result:  "success",
+	}
+
+	if err != nil {
+		summary.Result = "failure"
+		summary.Error = err.Error()
+	}
+
+	return summary
+}
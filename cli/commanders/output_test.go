@@ -0,0 +1,255 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package commanders_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/cli/commanders"
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+func TestSetFormat(t *testing.T) {
+	defer resetFormat(t)
+
+	for _, format := range []string{"text", "json", "JSON"} {
+		if err := commanders.SetFormat(format); err != nil {
+			t.Errorf("SetFormat(%q) returned error %+v", format, err)
+		}
+	}
+
+	if !commanders.IsJSONFormat() {
+		t.Error("expected JSON format to be set")
+	}
+
+	if err := commanders.SetFormat("yaml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestUILoopJSON(t *testing.T) {
+	setJSONFormat(t)
+	defer resetFormat(t)
+
+	t.Run("writes one event per message regardless of verbosity", func(t *testing.T) {
+		for _, verbose := range []bool{false, true} {
+			msgs := msgStream{
+				{Contents: &idl.Message_Status{Status: &idl.SubstepStatus{
+					Step:   idl.Substep_UPGRADE_MASTER,
+					Status: idl.Status_RUNNING,
+				}}},
+				{Contents: &idl.Message_Chunk{Chunk: &idl.Chunk{
+					Buffer: []byte("my string"),
+					Type:   idl.Chunk_STDOUT,
+				}}},
+				{Contents: &idl.Message_Chunk{Chunk: &idl.Chunk{
+					Buffer: []byte("my error"),
+					Type:   idl.Chunk_STDERR,
+				}}},
+				{Contents: &idl.Message_Status{Status: &idl.SubstepStatus{
+					Step:   idl.Substep_UPGRADE_MASTER,
+					Status: idl.Status_COMPLETE,
+				}}},
+				{Contents: &idl.Message_SegmentResults{SegmentResults: &idl.SegmentResults{Results: []*idl.SegmentResult{
+					{Host: "sdw1", Content: 0, Dbid: 2, Phase: idl.SegmentResult_DONE, DurationMs: 10, LogDir: "/log/seg0"},
+				}}}},
+				{Contents: &idl.Message_Response{Response: &idl.Response{Data: map[string]string{
+					"key": "value",
+				}}}},
+			}
+
+			d := bufferStandardDescriptors(t)
+
+			data, err := commanders.UILoop(&msgs, verbose)
+			if err != nil {
+				t.Errorf("UILoop() returned %#v", err)
+			}
+
+			stdout, stderr := d.Collect()
+			d.Close()
+
+			if len(stderr) != 0 {
+				t.Errorf("unexpected stderr %#v", string(stderr))
+			}
+
+			expected := []commanders.Event{
+				{Type: "status", Substep: "UPGRADE_MASTER", Status: "RUNNING"},
+				{Type: "chunk", Stream: "stdout", Output: "my string"},
+				{Type: "chunk", Stream: "stderr", Output: "my error"},
+				{Type: "status", Substep: "UPGRADE_MASTER", Status: "COMPLETE"},
+				{Type: "segment_results", Segments: []commanders.SegmentResult{
+					{Host: "sdw1", Content: 0, Dbid: 2, Phase: "DONE", DurationMs: 10, LogDir: "/log/seg0"},
+				}},
+				{Type: "response", Data: map[string]string{"key": "value"}},
+			}
+
+			events := decodeEvents(t, stdout)
+			if !reflect.DeepEqual(events, expected) {
+				t.Errorf("got events %+v want %+v", events, expected)
+			}
+
+			if data["key"] != "value" {
+				t.Errorf("got data %v, want key to be set", data)
+			}
+		}
+	})
+
+	t.Run("writes substep events for substeps run by the CLI", func(t *testing.T) {
+		d := bufferStandardDescriptors(t)
+		defer d.Close()
+
+		var err error
+		s := commanders.Substep(idl.Substep_CREATING_DIRECTORIES)
+		s.Finish(&err)
+
+		err = xerrors.New("error")
+		s = commanders.Substep(idl.Substep_START_HUB)
+		s.Finish(&err)
+
+		stdout, _ := d.Collect()
+
+		expected := []commanders.Event{
+			{Type: "status", Substep: "CREATING_DIRECTORIES", Status: "RUNNING"},
+			{Type: "status", Substep: "CREATING_DIRECTORIES", Status: "COMPLETE"},
+			{Type: "status", Substep: "START_HUB", Status: "RUNNING"},
+			{Type: "status", Substep: "START_HUB", Status: "FAILED"},
+		}
+
+		events := decodeEvents(t, stdout)
+		if !reflect.DeepEqual(events, expected) {
+			t.Errorf("got events %+v want %+v", events, expected)
+		}
+	})
+}
+
+func TestPrintSummary(t *testing.T) {
+	t.Run("writes nothing in text mode", func(t *testing.T) {
+		d := bufferStandardDescriptors(t)
+		defer d.Close()
+
+		commanders.PrintSummary("execute", nil, nil)
+
+		stdout, _ := d.Collect()
+		if len(stdout) != 0 {
+			t.Errorf("unexpected stdout %#v", string(stdout))
+		}
+	})
+
+	setJSONFormat(t)
+	defer resetFormat(t)
+
+	cases := []struct {
+		name     string
+		data     map[string]string
+		err      error
+		expected commanders.Summary
+	}{
+		{
+			name: "includes the target cluster information",
+			data: map[string]string{
+				idl.ResponseKey_target_port.String():                  "6000",
+				idl.ResponseKey_target_master_data_directory.String(): "/data/qddir/demoDataDir-1",
+			},
+			expected: commanders.Summary{
+				Type:                      "summary",
+				Command:                   "execute",
+				Result:                    "success",
+				TargetPort:                "6000",
+				TargetMasterDataDirectory: "/data/qddir/demoDataDir-1",
+			},
+		},
+		{
+			name: "reports failures",
+			err:  xerrors.New("ahhh"),
+			expected: commanders.Summary{
+				Type:    "summary",
+				Command: "execute",
+				Result:  "failure",
+				Error:   "ahhh",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d := bufferStandardDescriptors(t)
+			defer d.Close()
+
+			commanders.PrintSummary("execute", c.data, c.err)
+
+			stdout, _ := d.Collect()
+
+			var summary commanders.Summary
+			if err := json.Unmarshal(stdout, &summary); err != nil {
+				t.Fatalf("decoding summary %q: %+v", stdout, err)
+			}
+
+			if !reflect.DeepEqual(summary, c.expected) {
+				t.Errorf("got summary %+v want %+v", summary, c.expected)
+			}
+		})
+	}
+
+	t.Run("includes the status and next action for the status command", func(t *testing.T) {
+		d := bufferStandardDescriptors(t)
+		defer d.Close()
+
+		commanders.PrintStatus(&idl.GetStatusReply{})
+
+		stdout, _ := d.Collect()
+
+		var summary commanders.Summary
+		if err := json.Unmarshal(stdout, &summary); err != nil {
+			t.Fatalf("decoding summary %q: %+v", stdout, err)
+		}
+
+		if summary.Command != "status" || summary.Result != "success" {
+			t.Errorf("got summary %+v", summary)
+		}
+
+		expected := commanders.NextAction(&idl.GetStatusReply{})
+		if summary.NextAction != expected {
+			t.Errorf("got next action %q want %q", summary.NextAction, expected)
+		}
+	})
+}
+
+func decodeEvents(t *testing.T, output []byte) []commanders.Event {
+	t.Helper()
+
+	var events []commanders.Event
+
+	dec := json.NewDecoder(bytes.NewReader(output))
+	for dec.More() {
+		var event commanders.Event
+		if err := dec.Decode(&event); err != nil {
+			t.Fatalf("decoding events %q: %+v", output, err)
+		}
+
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func setJSONFormat(t *testing.T) {
+	t.Helper()
+
+	if err := commanders.SetFormat(commanders.JSONFormat); err != nil {
+		t.Fatalf("SetFormat() returned error %+v", err)
+	}
+}
+
+func resetFormat(t *testing.T) {
+	t.Helper()
+
+	if err := commanders.SetFormat(commanders.TextFormat); err != nil {
+		t.Fatalf("SetFormat() returned error %+v", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package commanders
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
+)
+
+// Plan prints the actions that the hub would perform for the requested step,
+// without performing any of them.
+func Plan(client idl.CliToHubClient, request *idl.PlanRequest) (err error) {
+	step := request.Step
+	defer func() {
+		if err != nil {
+			PrintSummary(step, nil, err)
+		}
+	}()
+
+	reply, err := client.Plan(context.Background(), request)
+	if err != nil {
+		return xerrors.Errorf("planning %s: %w", step, err)
+	}
+
+	PrintPlan(step, reply.Actions)
+	return nil
+}
+
+// PlanInitialize prints the actions that initialize would perform for the
+// request, without performing any of them. The hub is not started; instead
+// the plan is made by the hub's code from within the CLI.
+func PlanInitialize(request *idl.InitializeRequest, checks []Check, useTLS bool, metricsAddress string, metricsPort, agentMetricsPort int, remoteShell hub.RemoteShell) (err error) {
+	defer func() {
+		if err != nil {
+			PrintSummary("initialize", nil, err)
+		}
+	}()
+
+	stateDir := utils.GetStateDir()
+	conf := &hub.Config{
+		Port:             7527,
+		AgentPort:        upgrade.DefaultAgentPort,
+		MetricsPort:      metricsPort,
+		AgentMetricsPort: agentMetricsPort,
+		MetricsAddress:   metricsAddress,
+		RemoteShell:      remoteShell,
+	}
+	if useTLS {
+		conf.TLS = certs.HubConfig(stateDir)
+	}
+
+	var descriptions []string
+	for _, check := range checks {
+		descriptions = append(descriptions, check.Description)
+	}
+
+	actions, err := hub.PlanInitialize(conf, stateDir, request, descriptions)
+	if err != nil {
+		return xerrors.Errorf("planning initialize: %w", err)
+	}
+
+	PrintPlan("initialize", actions)
+	return nil
+}
+
+// PrintPlan writes the planned actions of a step. In JSON mode they are
+// written as the reply of the step's summary.
+func PrintPlan(step string, actions []*idl.PlannedAction) {
+	if jsonOutput {
+		printReplySummary(step, &idl.PlanReply{Actions: actions})
+		return
+	}
+
+	fmt.Print(FormatPlan(step, actions))
+}
+
+// FormatPlan returns the planned actions of a step as text, grouped by
+// substep. Each action shows the host it takes place on, followed by the
+// command it runs or the contents of the file it writes.
+func FormatPlan(step string, actions []*idl.PlannedAction) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "\nDry run: %s would perform the following actions. Nothing has been changed.\n", step)
+
+	last := idl.Substep_UNKNOWN_SUBSTEP
+	for _, action := range actions {
+		if action.Substep != last {
+			last = action.Substep
+
+			heading := SubstepDescriptions[action.Substep].HelpText
+			if heading == "" {
+				heading = action.Substep.String()
+			}
+			fmt.Fprintf(&b, "\n%s\n", heading)
+		}
+
+		fmt.Fprintf(&b, "  [%s] %s\n", action.Host, action.Description)
+
+		if action.Command != "" {
+			fmt.Fprintf(&b, "      $ %s\n", action.Command)
+		}
+
+		if action.Contents != "" {
+			for _, line := range strings.Split(strings.TrimRight(action.Contents, "\n"), "\n") {
+				fmt.Fprintf(&b, "      | %s\n", line)
+			}
+		}
+	}
+
+	return b.String()
+}
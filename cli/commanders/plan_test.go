@@ -0,0 +1,88 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package commanders_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/cli/commanders"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+)
+
+func TestPlan(t *testing.T) {
+	t.Run("prints the actions planned by the hub", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		actions := []*idl.PlannedAction{
+			{Substep: idl.Substep_SHUTDOWN_SOURCE_CLUSTER, Host: "mdw", Description: "stop the source cluster", Command: "bash -c 'gpstop -a'"},
+		}
+
+		client := mock_idl.NewMockCliToHubClient(ctrl)
+		client.EXPECT().Plan(
+			gomock.Any(),
+			&idl.PlanRequest{Step: "execute"},
+		).Return(&idl.PlanReply{Actions: actions}, nil)
+
+		d := bufferStandardDescriptors(t)
+		defer d.Close()
+
+		err := commanders.Plan(client, &idl.PlanRequest{Step: "execute"})
+		if err != nil {
+			t.Errorf("Plan() returned error %+v", err)
+		}
+
+		stdout, _ := d.Collect()
+		expected := commanders.FormatPlan("execute", actions)
+		if string(stdout) != expected {
+			t.Errorf("got output %q want %q", stdout, expected)
+		}
+	})
+
+	t.Run("returns errors from the hub", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := errors.New("ahhhh")
+		client := mock_idl.NewMockCliToHubClient(ctrl)
+		client.EXPECT().Plan(gomock.Any(), gomock.Any()).Return(nil, expected)
+
+		err := commanders.Plan(client, &idl.PlanRequest{Step: "revert"})
+		if !xerrors.Is(err, expected) {
+			t.Errorf("returned error %#v want %#v", err, expected)
+		}
+	})
+}
+
+func TestFormatPlan(t *testing.T) {
+	actual := commanders.FormatPlan("finalize", []*idl.PlannedAction{
+		{Substep: idl.Substep_UPDATE_DATA_DIRECTORIES, Host: "sdw1", Description: "rename /data/seg0 to /data/seg0_ABC.old"},
+		{Substep: idl.Substep_UPDATE_DATA_DIRECTORIES, Host: "sdw1", Description: "rename /data/seg0_ABC to /data/seg0"},
+		{Substep: idl.Substep_UPGRADE_MIRRORS, Host: "mdw", Description: "write /state/add_mirrors_config", Contents: "0|sdw2|25433|/mirror/seg0\n1|sdw1|25434|/mirror/seg1\n"},
+		{Substep: idl.Substep_UPGRADE_MIRRORS, Host: "mdw", Description: "run gpaddmirrors", Command: "gpaddmirrors -a -i /state/add_mirrors_config"},
+	})
+
+	expected := `
+Dry run: finalize would perform the following actions. Nothing has been changed.
+
+Update data directories
+  [sdw1] rename /data/seg0 to /data/seg0_ABC.old
+  [sdw1] rename /data/seg0_ABC to /data/seg0
+
+Upgrade mirror segments
+  [mdw] write /state/add_mirrors_config
+      | 0|sdw2|25433|/mirror/seg0
+      | 1|sdw1|25434|/mirror/seg1
+  [mdw] run gpaddmirrors
+      $ gpaddmirrors -a -i /state/add_mirrors_config
+`
+	if actual != expected {
+		t.Errorf("got\n%s\nwant\n%s", actual, expected)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package commanders
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// Recover asks the hub to reset any substeps that were interrupted while
+// running, and prints the result. forceRetry names a substep to reset even if
+// it cannot be safely retried; use idl.Substep_UNKNOWN_SUBSTEP for none.
+func Recover(client idl.CliToHubClient, forceRetry idl.Substep) error {
+	reply, err := client.Recover(context.Background(), &idl.RecoverRequest{
+		ForceRetry: forceRetry,
+	})
+	if err != nil {
+		return xerrors.Errorf("recovering: %w", err)
+	}
+
+	if jsonOutput {
+		printReplySummary("recover", reply)
+		return nil
+	}
+
+	writeRecovery(os.Stdout, reply)
+	return nil
+}
+
+func writeRecovery(w io.Writer, reply *idl.RecoverReply) {
+	if len(reply.GetSubsteps()) == 0 {
+		fmt.Fprintln(w, "No interrupted substeps were found.")
+		return
+	}
+
+	for _, substep := range reply.GetSubsteps() {
+		description := substep.Step.String()
+		if text, ok := SubstepDescriptions[substep.Step]; ok {
+			description = text.OutputText
+		}
+
+		command := "gpupgrade " + substep.Section
+		for _, section := range statusSections {
+			if section.Name == substep.Section {
+				command = section.Command
+			}
+		}
+
+		fmt.Fprintln(w)
+		if substep.ResetToFailed {
+			fmt.Fprintf(w, "%q in %s was interrupted, and has been reset.\n", description, substep.Section)
+			fmt.Fprintf(w, "Re-run %q to retry it.\n", command)
+			continue
+		}
+
+		fmt.Fprintf(w, "%q in %s was interrupted, and cannot be safely retried.\n", description, substep.Section)
+		fmt.Fprintln(w, "The following manual cleanup is needed:")
+		for _, step := range substep.ManualSteps {
+			fmt.Fprintf(w, "  - %s\n", step)
+		}
+		fmt.Fprintf(w, "Once done, run \"gpupgrade recover --force-retry %s\" and then re-run %q.\n", substep.Step, command)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package commanders_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/cli/commanders"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+)
+
+func TestRecover(t *testing.T) {
+	t.Run("prints how to continue for each interrupted substep", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock_idl.NewMockCliToHubClient(ctrl)
+		client.EXPECT().Recover(
+			gomock.Any(),
+			&idl.RecoverRequest{ForceRetry: idl.Substep_UNKNOWN_SUBSTEP},
+		).Return(&idl.RecoverReply{Substeps: []*idl.RecoveredSubstep{{
+			Section:       "execute",
+			Step:          idl.Substep_UPGRADE_PRIMARIES,
+			ResetToFailed: true,
+		}, {
+			Section:     "finalize",
+			Step:        idl.Substep_UPDATE_DATA_DIRECTORIES,
+			ManualSteps: []string{"on host sdw1: the target directory is missing"},
+		}}}, nil)
+
+		d := bufferStandardDescriptors(t)
+		defer d.Close()
+
+		err := commanders.Recover(client, idl.Substep_UNKNOWN_SUBSTEP)
+		if err != nil {
+			t.Errorf("Recover() returned error %+v", err)
+		}
+
+		stdout, _ := d.Collect()
+		actual := string(stdout)
+
+		expected := []string{
+			`"Upgrading primary segments..." in execute was interrupted, and has been reset.`,
+			`Re-run "gpupgrade execute" to retry it.`,
+			`"Updating data directories..." in finalize was interrupted, and cannot be safely retried.`,
+			"  - on host sdw1: the target directory is missing\n",
+			`run "gpupgrade recover --force-retry UPDATE_DATA_DIRECTORIES" and then re-run "gpupgrade finalize"`,
+		}
+		for _, e := range expected {
+			if !strings.Contains(actual, e) {
+				t.Errorf("got output %q, want it to contain %q", actual, e)
+			}
+		}
+	})
+
+	t.Run("reports when there is nothing to recover", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := mock_idl.NewMockCliToHubClient(ctrl)
+		client.EXPECT().Recover(gomock.Any(), gomock.Any()).
+			Return(&idl.RecoverReply{}, nil)
+
+		d := bufferStandardDescriptors(t)
+		defer d.Close()
+
+		err := commanders.Recover(client, idl.Substep_UNKNOWN_SUBSTEP)
+		if err != nil {
+			t.Errorf("Recover() returned error %+v", err)
+		}
+
+		stdout, _ := d.Collect()
+		expected := "No interrupted substeps were found.\n"
+		if string(stdout) != expected {
+			t.Errorf("got output %q want %q", stdout, expected)
+		}
+	})
+
+	t.Run("returns errors from the hub", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := errors.New("ahhh")
+		client := mock_idl.NewMockCliToHubClient(ctrl)
+		client.EXPECT().Recover(
+			gomock.Any(),
+			&idl.RecoverRequest{ForceRetry: idl.Substep_INIT_TARGET_CLUSTER},
+		).Return(nil, expected)
+
+		err := commanders.Recover(client, idl.Substep_INIT_TARGET_CLUSTER)
+		if !xerrors.Is(err, expected) {
+			t.Errorf("returned error %#v want %#v", err, expected)
+		}
+	})
+}
@@ -0,0 +1,352 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package commanders
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// statusSection describes a section of the upgrade as recorded by the hub,
+// along with the substeps the hub persists for it, in the order they run.
+type statusSection struct {
+	Name     string
+	Command  string
+	Substeps []idl.Substep
+	Optional map[idl.Substep]bool // substeps that do not run for every cluster
+}
+
+var statusSections = []statusSection{
+	{
+		Name:    "initialize",
+		Command: "gpupgrade initialize",
+		Substeps: []idl.Substep{
+			idl.Substep_GENERATING_CONFIG,
+			idl.Substep_START_AGENTS,
+			idl.Substep_CHECK_TARGET_PORTS,
+			idl.Substep_CREATE_TARGET_CONFIG,
+			idl.Substep_INIT_TARGET_CLUSTER,
+			idl.Substep_SHUTDOWN_TARGET_CLUSTER,
+			idl.Substep_BACKUP_TARGET_MASTER,
+			idl.Substep_CHECK_UPGRADE,
+		},
+	},
+	{
+		Name:    "execute",
+		Command: "gpupgrade execute",
+		Substeps: []idl.Substep{
+			idl.Substep_SHUTDOWN_SOURCE_CLUSTER,
+			idl.Substep_UPGRADE_MASTER,
+			idl.Substep_COPY_MASTER,
+			idl.Substep_UPGRADE_PRIMARIES,
+			idl.Substep_START_TARGET_CLUSTER,
+		},
+	},
+	{
+		Name:    "finalize",
+		Command: "gpupgrade finalize",
+		Substeps: []idl.Substep{
+			idl.Substep_SHUTDOWN_TARGET_CLUSTER,
+			idl.Substep_UPDATE_TARGET_CATALOG_AND_CLUSTER_CONFIG,
+			idl.Substep_UPDATE_DATA_DIRECTORIES,
+			idl.Substep_UPDATE_TARGET_CONF_FILES,
+			idl.Substep_START_TARGET_CLUSTER,
+			idl.Substep_UPGRADE_STANDBY,
+			idl.Substep_UPGRADE_MIRRORS,
+		},
+		Optional: map[idl.Substep]bool{
+			idl.Substep_UPGRADE_STANDBY: true,
+			idl.Substep_UPGRADE_MIRRORS: true,
+		},
+	},
+	{
+		Name:    "revert",
+		Command: "gpupgrade revert",
+		Substeps: []idl.Substep{
+			idl.Substep_DELETE_PRIMARY_DATADIRS,
+			idl.Substep_DELETE_MASTER_DATADIR,
+			idl.Substep_ARCHIVE_LOG_DIRECTORIES,
+			idl.Substep_DELETE_SEGMENT_STATEDIRS,
+		},
+		Optional: map[idl.Substep]bool{
+			idl.Substep_DELETE_PRIMARY_DATADIRS: true,
+			idl.Substep_DELETE_MASTER_DATADIR:   true,
+		},
+	},
+}
+
+// sectionState summarizes the substeps recorded for a single section.
+type sectionState int
+
+const (
+	sectionNotStarted sectionState = iota
+	sectionIncomplete
+	sectionRunning
+	sectionFailed
+	sectionComplete
+)
+
+func Status(client idl.CliToHubClient) error {
+	reply, err := client.GetStatus(context.Background(), &idl.GetStatusRequest{})
+	if err != nil {
+		return xerrors.Errorf("getting status: %w", err)
+	}
+
+	PrintStatus(reply)
+	return nil
+}
+
+// PrintStatus writes the status of every section and substep to stdout,
+// followed by the recommended next action.
+func PrintStatus(reply *idl.GetStatusReply) {
+	if jsonOutput {
+		printStatusSummary(reply)
+		return
+	}
+
+	writeStatus(os.Stdout, reply)
+}
+
+func writeStatus(w io.Writer, reply *idl.GetStatusReply) {
+	records := indexRecords(reply)
+
+	for _, section := range statusSections {
+		fmt.Fprintf(w, "\n%s\n", strings.Title(section.Name))
+
+		listed := make(map[idl.Substep]bool)
+		for _, substep := range section.Substeps {
+			listed[substep] = true
+
+			record, ok := records[section.Name][substep]
+			if !ok && section.Optional[substep] {
+				continue
+			}
+
+			fmt.Fprintln(w, formatRecord(substep, record))
+		}
+
+		// Don't hide anything the hub recorded that we don't know about.
+		for _, record := range recordsFor(reply, section.Name) {
+			if !listed[record.Step] {
+				fmt.Fprintln(w, formatRecord(record.Step, record))
+			}
+		}
+	}
+
+	if health := reply.GetAgentHealth(); health != nil {
+		writeAgentHealth(w, health)
+	}
+
+	fmt.Fprintf(w, `
+NEXT ACTIONS
+------------
+%s
+`, NextAction(reply))
+}
+
+var agentStates = map[idl.AgentHealth_State]string{
+	idl.AgentHealth_NOT_CONNECTED: "[NOT CONNECTED]",
+	idl.AgentHealth_SERVING:       "[SERVING]",
+	idl.AgentHealth_NOT_SERVING:   "[NOT SERVING]",
+	idl.AgentHealth_UNREACHABLE:   "[UNREACHABLE]",
+}
+
+// writeAgentHealth lists what the hub last heard from each agent.
+func writeAgentHealth(w io.Writer, health *idl.GetAgentHealthReply) {
+	version := health.HubVersion
+	if version == "" {
+		version = "unknown"
+	}
+	fmt.Fprintf(w, "\nAgents (hub version %s)\n", version)
+
+	for _, agent := range health.Agents {
+		line := fmt.Sprintf("%-30s%-17s", agent.Hostname, agentStates[agent.State])
+
+		if agent.Version != "" {
+			line += fmt.Sprintf("  version %s", agent.Version)
+		}
+
+		if seen := formatTime(agent.LastSeen); seen != "" {
+			line += fmt.Sprintf("  last seen %s", seen)
+		}
+
+		line = strings.TrimRight(line, " ")
+		if agent.Error != "" {
+			line += fmt.Sprintf("\n    Error: %s", agent.Error)
+		}
+
+		fmt.Fprintln(w, line)
+	}
+}
+
+func formatRecord(substep idl.Substep, record *idl.SubstepRecord) string {
+	description := substep.String()
+	if text, ok := SubstepDescriptions[substep]; ok {
+		description = text.OutputText
+	}
+
+	indicator := "[NOT STARTED]"
+	if i, ok := indicators[record.GetStatus()]; ok {
+		indicator = i
+	}
+
+	line := fmt.Sprintf("%-67s%-13s", description, indicator)
+
+	start := formatTime(record.GetStartTime())
+	if start == "" {
+		return strings.TrimRight(line, " ")
+	}
+
+	line = fmt.Sprintf("%s  %s - %s", line, start, formatTime(record.GetEndTime()))
+
+	if d, err := ptypes.Duration(record.GetDuration()); err == nil && d > 0 {
+		line += fmt.Sprintf("  (%s)", d.Round(time.Second))
+	}
+
+	if record.GetAttempts() > 1 {
+		line += fmt.Sprintf("  %d attempts", record.GetAttempts())
+	}
+
+	if record.GetStatus() == idl.Status_FAILED && record.GetLastError() != "" {
+		line += fmt.Sprintf("\n    Error: %s", record.GetLastError())
+	}
+
+	return line
+}
+
+func formatTime(ts *timestamp.Timestamp) string {
+	if ts == nil {
+		return ""
+	}
+
+	t, err := ptypes.Timestamp(ts)
+	if err != nil {
+		return ""
+	}
+
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// NextAction returns the recommended next command based on the recorded
+// status of each section.
+func NextAction(reply *idl.GetStatusReply) string {
+	records := indexRecords(reply)
+
+	states := make(map[string]sectionState)
+	for _, section := range statusSections {
+		states[section.Name] = section.state(records[section.Name])
+	}
+
+	switch states["revert"] {
+	case sectionComplete:
+		return "The source cluster has been restored to its original state."
+	case sectionRunning:
+		return `Revert is in progress. If the hub is no longer running, re-run "gpupgrade revert".`
+	case sectionFailed, sectionIncomplete:
+		return `Revert did not complete. Re-run "gpupgrade revert".`
+	}
+
+	// Find the latest section of the upgrade that has been started.
+	var last *statusSection
+	for i := range statusSections {
+		section := &statusSections[i]
+		if section.Name == "revert" {
+			continue
+		}
+
+		if states[section.Name] != sectionNotStarted {
+			last = section
+		}
+	}
+
+	if last == nil {
+		return `Run "gpupgrade initialize" to begin the upgrade.`
+	}
+
+	switch states[last.Name] {
+	case sectionRunning:
+		return fmt.Sprintf(`%s is in progress. If the hub is no longer running, manual intervention may be needed before re-running "%s".`,
+			strings.Title(last.Name), last.Command)
+	case sectionFailed:
+		msg := fmt.Sprintf(`%s failed. Address the failure and re-run "%s".`, strings.Title(last.Name), last.Command)
+		if last.Name != "finalize" {
+			msg += "\n" + `To return the cluster to its original state, run "gpupgrade revert".`
+		}
+		return msg
+	case sectionIncomplete:
+		return fmt.Sprintf(`%s did not complete. Re-run "%s".`, strings.Title(last.Name), last.Command)
+	}
+
+	switch last.Name {
+	case "initialize":
+		return `Run "gpupgrade execute" to proceed with the upgrade.`
+	case "execute":
+		return `Run "gpupgrade finalize" to proceed with the upgrade.`
+	default:
+		return "The upgrade is complete."
+	}
+}
+
+func (s statusSection) state(records map[idl.Substep]*idl.SubstepRecord) sectionState {
+	if len(records) == 0 {
+		return sectionNotStarted
+	}
+
+	state := sectionComplete
+	for _, record := range records {
+		switch record.Status {
+		case idl.Status_RUNNING:
+			return sectionRunning
+		case idl.Status_FAILED:
+			state = sectionFailed
+		}
+	}
+
+	if state == sectionFailed {
+		return state
+	}
+
+	for _, substep := range s.Substeps {
+		record, ok := records[substep]
+		if (!ok || record.Status != idl.Status_COMPLETE) && !s.Optional[substep] {
+			return sectionIncomplete
+		}
+	}
+
+	return sectionComplete
+}
+
+// indexRecords maps each section name to its substep records.
+func indexRecords(reply *idl.GetStatusReply) map[string]map[idl.Substep]*idl.SubstepRecord {
+	index := make(map[string]map[idl.Substep]*idl.SubstepRecord)
+
+	for _, section := range reply.GetSections() {
+		index[section.Name] = make(map[idl.Substep]*idl.SubstepRecord)
+		for _, record := range section.Substeps {
+			index[section.Name][record.Step] = record
+		}
+	}
+
+	return index
+}
+
+func recordsFor(reply *idl.GetStatusReply, name string) []*idl.SubstepRecord {
+	for _, section := range reply.GetSections() {
+		if section.Name == name {
+			return section.Substeps
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package commanders_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/greenplum-db/gpupgrade/cli/commanders"
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// section builds an idl.SectionStatus with every given substep set to status.
+func section(name string, status idl.Status, substeps ...idl.Substep) *idl.SectionStatus {
+	s := &idl.SectionStatus{Name: name}
+	for _, substep := range substeps {
+		s.Substeps = append(s.Substeps, &idl.SubstepRecord{Step: substep, Status: status})
+	}
+	return s
+}
+
+var (
+	initializeSubsteps = []idl.Substep{
+		idl.Substep_GENERATING_CONFIG,
+		idl.Substep_START_AGENTS,
+		idl.Substep_CHECK_TARGET_PORTS,
+		idl.Substep_CREATE_TARGET_CONFIG,
+		idl.Substep_INIT_TARGET_CLUSTER,
+		idl.Substep_SHUTDOWN_TARGET_CLUSTER,
+		idl.Substep_BACKUP_TARGET_MASTER,
+		idl.Substep_CHECK_UPGRADE,
+	}
+	executeSubsteps = []idl.Substep{
+		idl.Substep_SHUTDOWN_SOURCE_CLUSTER,
+		idl.Substep_UPGRADE_MASTER,
+		idl.Substep_COPY_MASTER,
+		idl.Substep_UPGRADE_PRIMARIES,
+		idl.Substep_START_TARGET_CLUSTER,
+	}
+	finalizeSubsteps = []idl.Substep{
+		idl.Substep_SHUTDOWN_TARGET_CLUSTER,
+		idl.Substep_UPDATE_TARGET_CATALOG_AND_CLUSTER_CONFIG,
+		idl.Substep_UPDATE_DATA_DIRECTORIES,
+		idl.Substep_UPDATE_TARGET_CONF_FILES,
+		idl.Substep_START_TARGET_CLUSTER,
+	}
+)
+
+func TestNextAction(t *testing.T) {
+	cases := []struct {
+		name     string
+		sections []*idl.SectionStatus
+		expected string
+	}{{
+		"nothing has run",
+		nil,
+		`Run "gpupgrade initialize"`,
+	}, {
+		"initialize stopped before cluster creation",
+		[]*idl.SectionStatus{
+			section("initialize", idl.Status_COMPLETE, idl.Substep_GENERATING_CONFIG, idl.Substep_START_AGENTS),
+		},
+		`Initialize did not complete. Re-run "gpupgrade initialize"`,
+	}, {
+		"initialize completed",
+		[]*idl.SectionStatus{
+			section("initialize", idl.Status_COMPLETE, initializeSubsteps...),
+		},
+		`Run "gpupgrade execute"`,
+	}, {
+		"execute failed",
+		[]*idl.SectionStatus{
+			section("initialize", idl.Status_COMPLETE, initializeSubsteps...),
+			section("execute", idl.Status_FAILED, idl.Substep_UPGRADE_PRIMARIES),
+		},
+		`Execute failed. Address the failure and re-run "gpupgrade execute"`,
+	}, {
+		"execute is running",
+		[]*idl.SectionStatus{
+			section("initialize", idl.Status_COMPLETE, initializeSubsteps...),
+			section("execute", idl.Status_RUNNING, idl.Substep_UPGRADE_MASTER),
+		},
+		"Execute is in progress.",
+	}, {
+		"execute completed",
+		[]*idl.SectionStatus{
+			section("initialize", idl.Status_COMPLETE, initializeSubsteps...),
+			section("execute", idl.Status_COMPLETE, executeSubsteps...),
+		},
+		`Run "gpupgrade finalize"`,
+	}, {
+		"finalize completed without a standby or mirrors",
+		[]*idl.SectionStatus{
+			section("initialize", idl.Status_COMPLETE, initializeSubsteps...),
+			section("execute", idl.Status_COMPLETE, executeSubsteps...),
+			section("finalize", idl.Status_COMPLETE, finalizeSubsteps...),
+		},
+		"The upgrade is complete.",
+	}, {
+		"revert failed",
+		[]*idl.SectionStatus{
+			section("initialize", idl.Status_COMPLETE, initializeSubsteps...),
+			section("revert", idl.Status_FAILED, idl.Substep_ARCHIVE_LOG_DIRECTORIES),
+		},
+		`Re-run "gpupgrade revert"`,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := commanders.NextAction(&idl.GetStatusReply{Sections: c.sections})
+			if !strings.Contains(actual, c.expected) {
+				t.Errorf("got %q, want it to contain %q", actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestPrintStatus(t *testing.T) {
+	d := bufferStandardDescriptors(t)
+	defer d.Close()
+
+	start := time.Date(2020, time.March, 1, 10, 0, 0, 0, time.Local)
+	startTime, err := ptypes.TimestampProto(start)
+	if err != nil {
+		t.Fatalf("converting start time: %+v", err)
+	}
+
+	endTime, err := ptypes.TimestampProto(start.Add(90 * time.Second))
+	if err != nil {
+		t.Fatalf("converting end time: %+v", err)
+	}
+
+	commanders.PrintStatus(&idl.GetStatusReply{Sections: []*idl.SectionStatus{{
+		Name: "initialize",
+		Substeps: []*idl.SubstepRecord{{
+			Step:      idl.Substep_GENERATING_CONFIG,
+			Status:    idl.Status_COMPLETE,
+			StartTime: startTime,
+			EndTime:   endTime,
+			Duration:  ptypes.DurationProto(90 * time.Second),
+		}, {
+			Step:      idl.Substep_START_AGENTS,
+			Status:    idl.Status_FAILED,
+			StartTime: startTime,
+			EndTime:   endTime,
+			Duration:  ptypes.DurationProto(90 * time.Second),
+			Attempts:  2,
+			LastError: "connection refused",
+		}, {
+			Step:      idl.Substep_CREATE_TARGET_CONFIG,
+			Status:    idl.Status_RUNNING,
+			StartTime: startTime,
+			Attempts:  1,
+		}},
+	}}})
+
+	stdout, _ := d.Collect()
+	actual := string(stdout)
+
+	expected := []string{
+		commanders.Format(commanders.SubstepDescriptions[idl.Substep_GENERATING_CONFIG].OutputText, idl.Status_COMPLETE) +
+			"  2020-03-01 10:00:00 - 2020-03-01 10:01:30  (1m30s)\n",
+		commanders.Format(commanders.SubstepDescriptions[idl.Substep_START_AGENTS].OutputText, idl.Status_FAILED) +
+			"  2020-03-01 10:00:00 - 2020-03-01 10:01:30  (1m30s)  2 attempts\n    Error: connection refused\n",
+		commanders.Format(commanders.SubstepDescriptions[idl.Substep_CREATE_TARGET_CONFIG].OutputText, idl.Status_RUNNING) +
+			"  2020-03-01 10:00:00 - \n",
+		"Creating target cluster...",
+		"[NOT STARTED]",
+		"Execute",
+		"Finalize",
+		"Revert",
+		"NEXT ACTIONS",
+		"Initialize is in progress.",
+	}
+	for _, e := range expected {
+		if !strings.Contains(actual, e) {
+			t.Errorf("got output %q, want it to contain %q", actual, e)
+		}
+	}
+
+	// Optional substeps are only shown once they have been recorded.
+	unexpected := commanders.SubstepDescriptions[idl.Substep_UPGRADE_MIRRORS].OutputText
+	if strings.Contains(actual, unexpected) {
+		t.Errorf("got output %q, did not want it to contain %q", actual, unexpected)
+	}
+}
+
+func TestPrintStatusAgentHealth(t *testing.T) {
+	seen := time.Date(2020, time.March, 1, 10, 0, 0, 0, time.Local)
+	lastSeen, err := ptypes.TimestampProto(seen)
+	if err != nil {
+		t.Fatalf("converting last seen time: %+v", err)
+	}
+
+	t.Run("lists the health of each agent", func(t *testing.T) {
+		d := bufferStandardDescriptors(t)
+		defer d.Close()
+
+		commanders.PrintStatus(&idl.GetStatusReply{AgentHealth: &idl.GetAgentHealthReply{
+			HubVersion: "1.2.3",
+			Agents: []*idl.AgentHealth{
+				{Hostname: "sdw1", State: idl.AgentHealth_SERVING, LastSeen: lastSeen, Version: "1.2.3"},
+				{Hostname: "sdw2", State: idl.AgentHealth_UNREACHABLE, LastSeen: lastSeen, Version: "1.2.3", Error: "connection refused"},
+				{Hostname: "sdw3", State: idl.AgentHealth_NOT_CONNECTED},
+			},
+		}})
+
+		stdout, _ := d.Collect()
+		actual := string(stdout)
+
+		expected := []string{
+			"Agents (hub version 1.2.3)\n",
+			fmt.Sprintf("%-30s%-17s", "sdw1", "[SERVING]") + "  version 1.2.3  last seen 2020-03-01 10:00:00\n",
+			fmt.Sprintf("%-30s%-17s", "sdw2", "[UNREACHABLE]") + "  version 1.2.3  last seen 2020-03-01 10:00:00\n    Error: connection refused\n",
+			"sdw3                          [NOT CONNECTED]\n",
+		}
+		for _, e := range expected {
+			if !strings.Contains(actual, e) {
+				t.Errorf("got output %q, want it to contain %q", actual, e)
+			}
+		}
+	})
+
+	t.Run("leaves out the agents when the hub is not running", func(t *testing.T) {
+		d := bufferStandardDescriptors(t)
+		defer d.Close()
+
+		commanders.PrintStatus(&idl.GetStatusReply{})
+
+		stdout, _ := d.Collect()
+		if strings.Contains(string(stdout), "Agents") {
+			t.Errorf("got output %q, did not want it to list the agents", stdout)
+		}
+	})
+}
@@ -8,7 +8,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"github.com/pkg/errors"
@@ -54,6 +57,8 @@ var SubstepDescriptions = map[idl.Substep]substepText{
 	idl.Substep_STOP_HUB_AND_AGENTS:                      substepText{"Stopping hub and agents...", "Stop hub and agents"},
 	idl.Substep_DELETE_MASTER_STATEDIR:                   substepText{"Deleting master state directory...", "Delete master state directory"},
 	idl.Substep_ARCHIVE_LOG_DIRECTORIES:                  substepText{"Archiving log directories...", "Archive log directories"},
+	idl.Substep_RUN_CHECKS:                               substepText{"Running pre-upgrade checks...", "Run pre-upgrade checks"},
+	idl.Substep_CHECK_TARGET_PORTS:                       substepText{"Checking target cluster ports...", "Check target cluster ports"},
 }
 
 var indicators = map[idl.Status]string{
@@ -63,6 +68,8 @@ var indicators = map[idl.Status]string{
 }
 
 func Initialize(client idl.CliToHubClient, request *idl.InitializeRequest, verbose bool) (err error) {
+	defer cancelOnInterrupt(client)()
+
 	stream, err := client.Initialize(context.Background(), request)
 	if err != nil {
 		return errors.Wrap(err, "initializing hub")
@@ -77,6 +84,8 @@ func Initialize(client idl.CliToHubClient, request *idl.InitializeRequest, verbo
 }
 
 func InitializeCreateCluster(client idl.CliToHubClient, verbose bool) (err error) {
+	defer cancelOnInterrupt(client)()
+
 	stream, err := client.InitializeCreateCluster(context.Background(),
 		&idl.InitializeCreateClusterRequest{},
 	)
@@ -92,19 +101,24 @@ func InitializeCreateCluster(client idl.CliToHubClient, verbose bool) (err error
 	return nil
 }
 
-func Execute(client idl.CliToHubClient, verbose bool) error {
-	fmt.Println()
-	fmt.Println("Execute in progress.")
-	fmt.Println()
+func Execute(client idl.CliToHubClient, verbose bool, retryFailedSegmentsOnly bool) (err error) {
+	var dataMap map[string]string
+	defer func() { PrintSummary("execute", dataMap, err) }()
 
-	stream, err := client.Execute(context.Background(), &idl.ExecuteRequest{})
+	printBanner("Execute in progress.")
+
+	defer cancelOnInterrupt(client)()
+
+	stream, err := client.Execute(context.Background(), &idl.ExecuteRequest{
+		RetryFailedSegmentsOnly: retryFailedSegmentsOnly,
+	})
 	if err != nil {
 		// TODO: Change the logging message?
 		gplog.Error("ERROR - Unable to connect to hub")
 		return err
 	}
 
-	dataMap, err := UILoop(stream, verbose)
+	dataMap, err = UILoop(stream, verbose)
 	if err != nil {
 		return xerrors.Errorf("Execute: %w", err)
 	}
@@ -115,6 +129,10 @@ func Execute(client idl.CliToHubClient, verbose bool) error {
 		return xerrors.Errorf("Execute: %w", err)
 	}
 
+	if jsonOutput {
+		return nil
+	}
+
 	message := fmt.Sprintf(`
 Execute completed successfully.
 
@@ -134,10 +152,13 @@ If you are satisfied with the state of the cluster, run "gpupgrade finalize" to
 	return nil
 }
 
-func Finalize(client idl.CliToHubClient, verbose bool) error {
-	fmt.Println()
-	fmt.Println("Finalize in progress.")
-	fmt.Println()
+func Finalize(client idl.CliToHubClient, verbose bool) (err error) {
+	var dataMap map[string]string
+	defer func() { PrintSummary("finalize", dataMap, err) }()
+
+	printBanner("Finalize in progress.")
+
+	defer cancelOnInterrupt(client)()
 
 	stream, err := client.Finalize(context.Background(), &idl.FinalizeRequest{})
 	if err != nil {
@@ -145,7 +166,7 @@ func Finalize(client idl.CliToHubClient, verbose bool) error {
 		return err
 	}
 
-	dataMap, err := UILoop(stream, verbose)
+	dataMap, err = UILoop(stream, verbose)
 	if err != nil {
 		return xerrors.Errorf("Finalize: %w", err)
 	}
@@ -155,6 +176,10 @@ func Finalize(client idl.CliToHubClient, verbose bool) error {
 		return xerrors.Errorf("Finalize: %w", err)
 	}
 
+	if jsonOutput {
+		return nil
+	}
+
 	fmt.Println("")
 	fmt.Println("Finalize completed successfully.")
 	fmt.Println("")
@@ -164,9 +189,7 @@ func Finalize(client idl.CliToHubClient, verbose bool) error {
 }
 
 func Revert(client idl.CliToHubClient, verbose bool) error {
-	fmt.Println()
-	fmt.Println("Revert in progress.")
-	fmt.Println()
+	printBanner("Revert in progress.")
 
 	stream, err := client.Revert(context.Background(), &idl.RevertRequest{})
 	if err != nil {
@@ -180,6 +203,10 @@ func Revert(client idl.CliToHubClient, verbose bool) error {
 		return xerrors.Errorf("Revert: %w", err)
 	}
 
+	if jsonOutput {
+		return nil
+	}
+
 	fmt.Println()
 	// TODO: add more info to this message
 	fmt.Printf("The source cluster is now restored to its original state.\n")
@@ -218,9 +245,14 @@ func UILoop(stream receiver, verbose bool) (map[string]string, error) {
 			break
 		}
 
+		if jsonOutput {
+			// Every message is reported in JSON mode, regardless of verbosity.
+			writeJSON(newEvent(msg))
+		}
+
 		switch x := msg.Contents.(type) {
 		case *idl.Message_Chunk:
-			if !verbose {
+			if !verbose || jsonOutput {
 				continue
 			}
 
@@ -231,6 +263,10 @@ func UILoop(stream receiver, verbose bool) (map[string]string, error) {
 			}
 
 		case *idl.Message_Status:
+			if jsonOutput {
+				continue
+			}
+
 			// Rewrite the current line whenever we get an update for the
 			// current step. (This behavior is switched off in verbose mode,
 			// because it interferes with the output stream.)
@@ -251,6 +287,25 @@ func UILoop(stream receiver, verbose bool) (map[string]string, error) {
 				fmt.Println()
 			}
 
+		case *idl.Message_SegmentResult:
+			// Individual results are summarized by the SegmentResults message
+			// that follows them, so they are only reported in JSON mode.
+
+		case *idl.Message_SegmentResults:
+			if jsonOutput {
+				continue
+			}
+
+			if !verbose && lastStep != idl.Substep_UNKNOWN_SUBSTEP {
+				fmt.Println()
+			}
+			fmt.Println()
+			fmt.Print(FormatSegmentResults(x.SegmentResults.Results))
+
+			// The status line has been terminated, so the next status update
+			// starts a line of its own.
+			lastStep = idl.Substep_UNKNOWN_SUBSTEP
+
 		case *idl.Message_Response:
 			// NOTE: the latest message will clobber earlier keys
 			for k, v := range x.Response.Data {
@@ -262,7 +317,7 @@ func UILoop(stream receiver, verbose bool) (map[string]string, error) {
 		}
 	}
 
-	if !verbose {
+	if !verbose && !jsonOutput {
 		fmt.Println()
 	}
 
@@ -287,6 +342,39 @@ func FormatStatus(status *idl.SubstepStatus) string {
 	return Format(line.OutputText, status.Status)
 }
 
+// FormatSegmentResults returns a table describing the result of upgrading
+// each segment. It's exported for ease of testing.
+func FormatSegmentResults(results []*idl.SegmentResult) string {
+	var b strings.Builder
+
+	var t tabwriter.Writer
+	t.Init(&b, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(&t, "CONTENT\tDBID\tHOST\tSTATUS\tPHASE\tDURATION\tEXIT CODE\tLOG DIRECTORY")
+	for _, r := range results {
+		status := "complete"
+		switch {
+		case r.Error != "":
+			status = "failed"
+		case r.Skipped:
+			status = "skipped"
+		}
+
+		duration := (time.Duration(r.DurationMs) * time.Millisecond).Round(time.Second)
+
+		exitCode := "-"
+		if r.ExitCode >= 0 && !r.Skipped {
+			exitCode = strconv.Itoa(int(r.ExitCode))
+		}
+
+		fmt.Fprintf(&t, "%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Content, r.Dbid, r.Host, status, r.Phase, duration, exitCode, r.LogDir)
+	}
+
+	t.Flush()
+	return b.String()
+}
+
 // Format is also exported for ease of testing (see FormatStatus). Use Substep
 // instead.
 func Format(description string, status idl.Status) string {
@@ -298,13 +386,23 @@ func Format(description string, status idl.Status) string {
 	return fmt.Sprintf("%-67s%-13s", description, indicator)
 }
 
+// substep is a substep run directly by the CLI. See Substep.
+type substep struct {
+	substepText
+	step idl.Substep
+}
+
 // Substep prints out an "in progress" marker for the given substep description,
 // and returns a struct that can be .Finish()d (in a defer statement) to print
 // the final complete/failed state.
-func Substep(step idl.Substep) *substepText {
-	substepText := SubstepDescriptions[step]
-	fmt.Printf("%s\r", Format(substepText.OutputText, idl.Status_RUNNING))
-	return &substepText
+func Substep(step idl.Substep) *substep {
+	s := &substep{
+		substepText: SubstepDescriptions[step],
+		step:        step,
+	}
+
+	s.print(idl.Status_RUNNING)
+	return s
 }
 
 // Finish prints out the final status of the substep; either COMPLETE or FAILED
@@ -318,11 +416,37 @@ func Substep(step idl.Substep) *substepText {
 //        ...
 //    }
 //
-func (s *substepText) Finish(err *error) {
+func (s *substep) Finish(err *error) {
 	status := idl.Status_COMPLETE
 	if *err != nil {
 		status = idl.Status_FAILED
 	}
 
-	fmt.Printf("%s\n", Format(s.OutputText, status))
+	s.print(status)
+}
+
+func (s *substep) print(status idl.Status) {
+	if jsonOutput {
+		writeJSON(statusEvent(s.step, status))
+		return
+	}
+
+	terminator := "\n"
+	if status == idl.Status_RUNNING {
+		terminator = "\r"
+	}
+
+	fmt.Printf("%s%s", Format(s.OutputText, status), terminator)
+}
+
+// printBanner prints a message surrounded by blank lines. Nothing is printed
+// in JSON mode.
+func printBanner(message string) {
+	if jsonOutput {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(message)
+	fmt.Println()
 }
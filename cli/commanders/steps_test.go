@@ -181,6 +181,42 @@ func TestUILoop(t *testing.T) {
 		}
 	})
 
+	t.Run("prints a table of the segment results", func(t *testing.T) {
+		results := []*idl.SegmentResult{
+			{Host: "sdw1", Content: 0, Dbid: 2, Phase: idl.SegmentResult_DONE, DurationMs: 61400, LogDir: "/log/seg0"},
+		}
+
+		msgs := msgStream{
+			{Contents: &idl.Message_Status{Status: &idl.SubstepStatus{
+				Step:   idl.Substep_UPGRADE_PRIMARIES,
+				Status: idl.Status_COMPLETE,
+			}}},
+			{Contents: &idl.Message_SegmentResult{SegmentResult: results[0]}},
+			{Contents: &idl.Message_SegmentResults{SegmentResults: &idl.SegmentResults{Results: results}}},
+			{Contents: &idl.Message_Status{Status: &idl.SubstepStatus{
+				Step:   idl.Substep_START_TARGET_CLUSTER,
+				Status: idl.Status_COMPLETE,
+			}}},
+		}
+
+		expected := commanders.FormatStatus(msgs[0].GetStatus()) + "\n\n"
+		expected += commanders.FormatSegmentResults(results)
+		expected += commanders.FormatStatus(msgs[3].GetStatus()) + "\n"
+
+		d := bufferStandardDescriptors(t)
+		defer d.Close()
+
+		_, err := commanders.UILoop(&msgs, false)
+		if err != nil {
+			t.Errorf("UILoop() returned %#v", err)
+		}
+
+		actualOut, _ := d.Collect()
+		if string(actualOut) != expected {
+			t.Errorf("output %q want %q", actualOut, expected)
+		}
+	})
+
 	t.Run("returns a map of strings that are processed in the stream", func(t *testing.T) {
 		firstMap := make(map[string]string)
 		firstMap["a"] = "b"
@@ -266,6 +302,25 @@ func TestFormatStatus(t *testing.T) {
 	})
 }
 
+func TestFormatSegmentResults(t *testing.T) {
+	actual := commanders.FormatSegmentResults([]*idl.SegmentResult{
+		{Host: "sdw1", Content: 0, Dbid: 2, Phase: idl.SegmentResult_DONE, DurationMs: 61400, LogDir: "/log/seg0"},
+		{Host: "sdw2", Content: 1, Dbid: 3, Phase: idl.SegmentResult_PG_UPGRADE, ExitCode: 1, Error: "exit status 1", LogDir: "/log/seg1"},
+		{Host: "sdw3", Content: 2, Dbid: 4, ExitCode: -1, Error: "connection refused"},
+		{Host: "sdw3", Content: 3, Dbid: 5, Phase: idl.SegmentResult_DONE, Skipped: true},
+	})
+
+	expected := `CONTENT  DBID  HOST  STATUS    PHASE          DURATION  EXIT CODE  LOG DIRECTORY
+0        2     sdw1  complete  DONE           1m1s      0          /log/seg0
+1        3     sdw2  failed    PG_UPGRADE     0s        1          /log/seg1
+2        4     sdw3  failed    UNKNOWN_PHASE  0s        -          
+3        5     sdw3  skipped   DONE           0s        -          
+`
+	if actual != expected {
+		t.Errorf("got\n%s\nwant\n%s", actual, expected)
+	}
+}
+
 func TestSubstep(t *testing.T) {
 	d := bufferStandardDescriptors(t)
 	defer d.Close()
@@ -10,14 +10,19 @@ import (
 	"github.com/greenplum-db/gpupgrade/agent"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 	"github.com/greenplum-db/gpupgrade/utils/daemon"
 	"github.com/greenplum-db/gpupgrade/utils/log"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
 func Agent() *cobra.Command {
 	var port int
+	var metricsPort int
+	var metricsAddress string
 	var statedir string
 	var shouldDaemonize bool
+	var tlsConf certs.Config
 
 	var cmd = &cobra.Command{
 		Use:    "agent",
@@ -34,8 +39,12 @@ func Agent() *cobra.Command {
 			defer log.WritePanics()
 
 			conf := agent.Config{
-				Port:     port,
-				StateDir: statedir,
+				Port:           port,
+				StateDir:       statedir,
+				TLS:            tlsConf,
+				MetricsAddress: metricsAddress,
+				MetricsPort:    metricsPort,
+				Version:        UpgradeVersion,
 			}
 
 			agentServer := agent.NewServer(conf)
@@ -50,7 +59,12 @@ func Agent() *cobra.Command {
 		},
 	}
 	cmd.Flags().IntVar(&port, "port", upgrade.DefaultAgentPort, "the port to listen for commands on")
+	cmd.Flags().IntVar(&metricsPort, "metrics-port", 0, "the port to serve metrics on; metrics are not served by default")
+	cmd.Flags().StringVar(&metricsAddress, "metrics-address", metrics.DefaultAddress, "the address to serve metrics on")
 	cmd.Flags().StringVar(&statedir, "state-directory", utils.GetStateDir(), "Agent state directory")
+	cmd.Flags().StringVar(&tlsConf.CACert, "tls-ca-cert", "", "CA certificate that the hub's certificate must be signed by; enables mutual TLS")
+	cmd.Flags().StringVar(&tlsConf.Cert, "tls-cert", "", "the agent's TLS certificate")
+	cmd.Flags().StringVar(&tlsConf.Key, "tls-key", "", "the agent's TLS private key")
 
 	daemon.MakeDaemonizable(cmd, &shouldDaemonize)
 
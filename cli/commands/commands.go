@@ -10,30 +10,25 @@ package commands
  *    up-to-date but is a useful as an orientation to what is going on here.
  *
  * example> gpupgrade
- * 	   2018/09/28 16:09:39 Please specify one command of: check, config, prepare, status, upgrade, or version
+ * 	   Please specify one command of: initialize, execute, finalize, revert, check, status, recover, config, or version
  *
- * example> gpupgrade check
- *      collects information and validates the target Greenplum installation can be upgraded
+ * example> gpupgrade check disk_space ports_free
+ *      runs the named pre-upgrade checks, or all of them, without upgrading
  *
  *      Usage:
- * 		gpupgrade check [command]
- *
- * 		Available Commands:
- * 			config       gather cluster configuration
- * 			disk-space   check that disk space usage is less than 80% on all segments
- * 			object-count count database objects and numeric objects
- * 			version      validate current version is upgradable
+ * 		gpupgrade check [name...]
  *
  * 		Flags:
- * 			-h, --help   help for check
- *
- * 		Use "gpupgrade check [command] --help" for more information about a command.
+ * 			--disk-free-ratio       ratio of free space needed in order to run upgrade
+ * 			--estimate-disk-space   estimate the space and inodes needed from the size of the data
+ * 			-h, --help              help for check
  */
 
 import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -48,9 +43,13 @@ import (
 	grpcStatus "google.golang.org/grpc/status"
 
 	"github.com/greenplum-db/gpupgrade/cli/commanders"
+	"github.com/greenplum-db/gpupgrade/hub"
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
+	"github.com/greenplum-db/gpupgrade/utils/handshake"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
 var (
@@ -58,6 +57,7 @@ var (
 	ExecuteHelp    string
 	FinalizeHelp   string
 	RevertHelp     string
+	CheckHelp      string
 )
 
 func init() {
@@ -67,7 +67,8 @@ func init() {
 		idl.Substep_START_HUB,
 		idl.Substep_RETRIEVE_SOURCE_CONFIG,
 		idl.Substep_START_AGENTS,
-		idl.Substep_CHECK_DISK_SPACE,
+		idl.Substep_RUN_CHECKS,
+		idl.Substep_CHECK_TARGET_PORTS,
 		idl.Substep_CREATE_TARGET_CONFIG,
 		idl.Substep_INIT_TARGET_CLUSTER,
 		idl.Substep_SHUTDOWN_TARGET_CLUSTER,
@@ -98,14 +99,19 @@ func init() {
 		idl.Substep_DELETE_MASTER_STATEDIR,
 		idl.Substep_ARCHIVE_LOG_DIRECTORIES,
 	})
+	CheckHelp = GenerateCheckHelpString(checkHelp, commanders.Checks)
 }
 
 func BuildRootCommand() *cobra.Command {
 	// TODO: if called without a subcommand, the cli prints a help message with timestamp.  Remove the timestamp.
 	var shouldPrintVersion bool
+	var format string
 
 	root := &cobra.Command{
 		Use: "gpupgrade",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return commanders.SetFormat(format)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			if shouldPrintVersion {
 				printVersion()
@@ -117,6 +123,8 @@ func BuildRootCommand() *cobra.Command {
 	}
 
 	root.Flags().BoolVarP(&shouldPrintVersion, "version", "V", false, "prints version")
+	root.PersistentFlags().StringVar(&format, "format", commanders.TextFormat,
+		fmt.Sprintf("output format; either %s or %s", commanders.TextFormat, commanders.JSONFormat))
 
 	root.AddCommand(config)
 	root.AddCommand(version())
@@ -124,7 +132,10 @@ func BuildRootCommand() *cobra.Command {
 	root.AddCommand(execute())
 	root.AddCommand(finalize())
 	root.AddCommand(revert())
-	root.AddCommand(restartServices)
+	root.AddCommand(status())
+	root.AddCommand(check())
+	root.AddCommand(recoverCmd())
+	root.AddCommand(restartServices())
 	root.AddCommand(killServices)
 	root.AddCommand(Agent())
 	root.AddCommand(Hub())
@@ -164,6 +175,51 @@ func connTimeout() time.Duration {
 // CliToHubClient which wraps the resulting gRPC channel. Any errors result in
 // an os.Exit(1).
 func connectToHub() idl.CliToHubClient {
+	client, err := dialHub()
+	if err != nil {
+		// Print a nicer error message if we can't connect to the hub.
+		if xerrors.Is(err, context.DeadlineExceeded) {
+			gplog.Error("could not connect to the upgrade hub (did you run 'gpupgrade initialize'?)")
+		} else {
+			gplog.Error(err.Error())
+		}
+		os.Exit(1)
+	}
+
+	return client
+}
+
+// dialHub is the error-returning counterpart of connectToHub, for callers that
+// can carry on without a hub. It refuses a hub at a different version than the
+// CLI, unless the CLI's version is unknown, as it is for development builds.
+func dialHub() (idl.CliToHubClient, error) {
+	client, err := dialHubWithoutHandshake()
+	if err != nil {
+		return nil, err
+	}
+
+	if UpgradeVersion == "" {
+		return client, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connTimeout())
+	defer cancel()
+
+	_, err = handshake.Hello(ctx, "the hub", UpgradeVersion, client.Hello)
+	if xerrors.Is(err, handshake.ErrVersionMismatch) {
+		return nil, xerrors.Errorf(`restart the hub at this version with "gpupgrade kill-services" followed by "gpupgrade restart-services": %w`, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// dialHubWithoutHandshake connects to the hub without checking its version.
+// It is only for the few requests, such as stopping the hub, that every
+// version understands.
+func dialHubWithoutHandshake() (idl.CliToHubClient, error) {
 	upgradePort := os.Getenv("GPUPGRADE_HUB_PORT")
 	if upgradePort == "" {
 		upgradePort = "7527"
@@ -175,19 +231,31 @@ func connectToHub() idl.CliToHubClient {
 	ctx, cancel := context.WithTimeout(context.Background(), connTimeout())
 	defer cancel()
 
+	credentials, err := hubCredentials()
+	if err != nil {
+		return nil, err
+	}
+
 	// Attempt a connection.
-	conn, err := grpc.DialContext(ctx, hubAddr, grpc.WithInsecure(), grpc.WithBlock())
+	conn, err := grpc.DialContext(ctx, hubAddr, credentials, grpc.WithBlock())
 	if err != nil {
-		// Print a nicer error message if we can't connect to the hub.
-		if ctx.Err() == context.DeadlineExceeded {
-			gplog.Error("could not connect to the upgrade hub (did you run 'gpupgrade initialize'?)")
-		} else {
-			gplog.Error(err.Error())
-		}
-		os.Exit(1)
+		return nil, err
 	}
 
-	return idl.NewCliToHubClient(conn)
+	return idl.NewCliToHubClient(conn), nil
+}
+
+// hubCredentials returns the dial option matching the TLS configuration that
+// initialize recorded for the hub. Before initialize, the connection is
+// insecure.
+func hubCredentials() (grpc.DialOption, error) {
+	conf := &hub.Config{}
+	err := hub.LoadConfig(conf, filepath.Join(utils.GetStateDir(), hub.ConfigFileName))
+	if err != nil && !xerrors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return certs.DialOption(conf.TLS)
 }
 
 //////////////////////////////////////// CONFIG and its subcommands
@@ -308,16 +376,27 @@ func initialize() *cobra.Command {
 	var sourcePort int
 	var agentPort int
 	var diskFreeRatio float64
+	var estimateDiskSpace bool
 	var stopBeforeClusterCreation bool
 	var verbose bool
 	var ports string
 	var mode string
+	var useTLS bool
+	var metricsPort int
+	var agentMetricsPort int
+	var metricsAddress string
+	var remoteShell hub.RemoteShell
+	var maxUpgradesPerHost int
+	var maxUpgrades int
+	var dryRun bool
+	var skipChecks []string
+	var onlyChecks []string
 
 	subInit := &cobra.Command{
 		Use:   "initialize",
 		Short: "prepare the system for upgrade",
 		Long:  InitializeHelp,
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
 
 			linkMode, err := isLinkMode(mode)
 			if err != nil {
@@ -326,19 +405,20 @@ func initialize() *cobra.Command {
 
 			// if diskFreeRatio is not explicitly set, use defaults
 			if !cmd.Flag("disk-free-ratio").Changed {
-				if linkMode {
-					diskFreeRatio = 0.2
-				} else {
-					diskFreeRatio = 0.6
-				}
+				diskFreeRatio = defaultDiskFreeRatio(linkMode)
 			}
 
-			if diskFreeRatio < 0.0 || diskFreeRatio > 1.0 {
-				// Match Cobra's option-error format.
-				return fmt.Errorf(
-					`invalid argument %g for "--disk-free-ratio" flag: value must be between 0.0 and 1.0`,
-					diskFreeRatio,
-				)
+			if err := validateDiskFreeRatio(diskFreeRatio); err != nil {
+				return err
+			}
+
+			if err := validateEstimateDiskSpace(cmd, estimateDiskSpace); err != nil {
+				return err
+			}
+
+			checks, err := commanders.SelectChecks(onlyChecks, skipChecks)
+			if err != nil {
+				return err
 			}
 
 			ports, err := parsePorts(ports)
@@ -346,20 +426,48 @@ func initialize() *cobra.Command {
 				return err
 			}
 
+			if maxUpgradesPerHost < 0 {
+				// Match Cobra's option-error format.
+				return fmt.Errorf(`invalid argument %d for "--max-upgrades-per-host" flag: value must not be negative`, maxUpgradesPerHost)
+			}
+
+			if maxUpgrades < 0 {
+				return fmt.Errorf(`invalid argument %d for "--max-upgrades" flag: value must not be negative`, maxUpgrades)
+			}
+
 			// If we got here, the args are okay and the user doesn't need a usage
 			// dump on failure.
 			cmd.SilenceUsage = true
 
-			fmt.Println()
-			fmt.Println("Initialize in progress.")
-			fmt.Println()
+			request := &idl.InitializeRequest{
+				AgentPort:          int32(agentPort),
+				SourceBinDir:       sourceBinDir,
+				TargetBinDir:       targetBinDir,
+				SourcePort:         int32(sourcePort),
+				UseLinkMode:        linkMode,
+				Ports:              ports,
+				MaxUpgradesPerHost: int32(maxUpgradesPerHost),
+				MaxUpgrades:        int32(maxUpgrades),
+			}
+
+			if dryRun {
+				return commanders.PlanInitialize(request, checks, useTLS, metricsAddress, metricsPort, agentMetricsPort, remoteShell)
+			}
+
+			defer func() { commanders.PrintSummary("initialize", nil, err) }()
+
+			if !commanders.IsJSONFormat() {
+				fmt.Println()
+				fmt.Println("Initialize in progress.")
+				fmt.Println()
+			}
 
 			err = commanders.CreateStateDir()
 			if err != nil {
 				return errors.Wrap(err, "creating state directory")
 			}
 
-			err = commanders.CreateInitialClusterConfigs()
+			err = commanders.CreateInitialClusterConfigs(useTLS, metricsAddress, metricsPort, agentMetricsPort, remoteShell)
 			if err != nil {
 				return errors.Wrap(err, "creating initial cluster configs")
 			}
@@ -371,20 +479,15 @@ func initialize() *cobra.Command {
 
 			client := connectToHub()
 
-			request := &idl.InitializeRequest{
-				AgentPort:    int32(agentPort),
-				SourceBinDir: sourceBinDir,
-				TargetBinDir: targetBinDir,
-				SourcePort:   int32(sourcePort),
-				UseLinkMode:  linkMode,
-				Ports:        ports,
-			}
 			err = commanders.Initialize(client, request, verbose)
 			if err != nil {
 				return errors.Wrap(err, "initializing hub")
 			}
 
-			err = commanders.RunChecks(client, diskFreeRatio)
+			err = commanders.RunChecks(client, checks, commanders.CheckOptions{
+				DiskFreeRatio:     diskFreeRatio,
+				EstimateDiskSpace: estimateDiskSpace,
+			})
 			if err != nil {
 				return err
 			}
@@ -398,6 +501,10 @@ func initialize() *cobra.Command {
 				return errors.Wrap(err, "initializing cluster")
 			}
 
+			if commanders.IsJSONFormat() {
+				return nil
+			}
+
 			fmt.Println(`
 Initialize completed successfully.
 
@@ -421,14 +528,30 @@ After executing, you will need to finalize.`)
 	subInit.Flags().BoolVar(&stopBeforeClusterCreation, "stop-before-cluster-creation", false, "only run up to pre-init")
 	subInit.Flags().MarkHidden("stop-before-cluster-creation") //nolint
 	subInit.Flags().Float64Var(&diskFreeRatio, "disk-free-ratio", 0.60, "percentage of disk space that must be available (from 0.0 - 1.0)")
+	subInit.Flags().BoolVar(&estimateDiskSpace, "estimate-disk-space", false, "estimate the disk space and inodes needed from the size of the data, instead of using --disk-free-ratio")
 	subInit.Flags().BoolVarP(&verbose, "verbose", "v", false, "print the output stream from all substeps")
 	subInit.Flags().StringVar(&ports, "temp-port-range", "", "set of ports to use when initializing the target cluster")
 	subInit.Flags().StringVar(&mode, "mode", "copy", "performs upgrade in either copy or link mode. Default is copy.")
+	subInit.Flags().BoolVar(&useTLS, "tls", false, "secure connections between gpupgrade processes with mutual TLS")
+	subInit.Flags().IntVar(&metricsPort, "metrics-port", 0, "the port the hub serves metrics on; metrics are not served by default")
+	subInit.Flags().IntVar(&agentMetricsPort, "agent-metrics-port", 0, "the port the agents serve metrics on; metrics are not served by default")
+	subInit.Flags().StringVar(&metricsAddress, "metrics-address", metrics.DefaultAddress, "the address the hub and agents serve metrics on")
+	subInit.Flags().StringVar(&remoteShell.TrustedShell, "trusted-shell", "", `the ssh program used to run commands on the other hosts, as in gpinitsystem's TRUSTED_SHELL, or "local" to run them on this host; ssh by default`)
+	subInit.Flags().StringVar(&remoteShell.User, "ssh-user", "", "the user to run commands on the other hosts as; the current user by default")
+	subInit.Flags().StringVar(&remoteShell.IdentityFile, "ssh-identity-file", "", "the identity file used to run commands on the other hosts")
+	subInit.Flags().StringArrayVar(&remoteShell.Options, "ssh-option", nil, "an ssh option, such as ConnectTimeout=10, used to run commands on the other hosts; may be repeated")
+	subInit.Flags().IntVar(&maxUpgradesPerHost, "max-upgrades-per-host", 0, "the most segments to upgrade at once on each host; no limit by default")
+	subInit.Flags().IntVar(&maxUpgrades, "max-upgrades", 0, "the most segments to upgrade at once across the cluster; no limit by default")
+	subInit.Flags().BoolVar(&dryRun, "dry-run", false, "print the actions initialize would perform, without performing them")
+	subInit.Flags().StringSliceVar(&skipChecks, "skip-check", nil, "the names of pre-upgrade checks not to run")
+	subInit.Flags().StringSliceVar(&onlyChecks, "only-check", nil, "the names of the only pre-upgrade checks to run")
 	return addHelpToCommand(subInit, InitializeHelp)
 }
 
 func execute() *cobra.Command {
 	var verbose bool
+	var retryFailedSegmentsOnly bool
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "execute",
@@ -438,17 +561,27 @@ func execute() *cobra.Command {
 			cmd.SilenceUsage = true
 
 			client := connectToHub()
-			return commanders.Execute(client, verbose)
+			if dryRun {
+				return commanders.Plan(client, &idl.PlanRequest{
+					Step:                    "execute",
+					RetryFailedSegmentsOnly: retryFailedSegmentsOnly,
+				})
+			}
+
+			return commanders.Execute(client, verbose, retryFailedSegmentsOnly)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "print the output stream from all substeps")
+	cmd.Flags().BoolVar(&retryFailedSegmentsOnly, "retry-failed-segments-only", true, "when retrying, only upgrade the primaries that have not already been upgraded")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the actions execute would perform, without performing them")
 
 	return addHelpToCommand(cmd, ExecuteHelp)
 }
 
 func finalize() *cobra.Command {
 	var verbose bool
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "finalize",
@@ -456,7 +589,13 @@ func finalize() *cobra.Command {
 		Long:  FinalizeHelp,
 		Run: func(cmd *cobra.Command, args []string) {
 			client := connectToHub()
-			err := commanders.Finalize(client, verbose)
+
+			var err error
+			if dryRun {
+				err = commanders.Plan(client, &idl.PlanRequest{Step: "finalize"})
+			} else {
+				err = commanders.Finalize(client, verbose)
+			}
 			if err != nil {
 				gplog.Error(err.Error())
 				os.Exit(1)
@@ -465,24 +604,32 @@ func finalize() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "print the output stream from all substeps")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the actions finalize would perform, without performing them")
 
 	return addHelpToCommand(cmd, FinalizeHelp)
 }
 
 func revert() *cobra.Command {
 	var verbose bool
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "revert",
 		Short: "reverts the upgrade and returns the cluster to its original state",
 		Long:  RevertHelp,
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			// If we got here, the args are okay and the user doesn't need a usage
 			// dump on failure.
 			cmd.SilenceUsage = true
 			client := connectToHub()
 
-			err := commanders.Revert(client, verbose)
+			if dryRun {
+				return commanders.Plan(client, &idl.PlanRequest{Step: "revert"})
+			}
+
+			defer func() { commanders.PrintSummary("revert", nil, err) }()
+
+			err = commanders.Revert(client, verbose)
 			if err != nil {
 				gplog.Error(err.Error())
 				return err
@@ -510,6 +657,10 @@ func revert() *cobra.Command {
 				return err
 			}
 
+			if commanders.IsJSONFormat() {
+				return nil
+			}
+
 			fmt.Println("Revert completed successfully.")
 			fmt.Println()
 
@@ -518,10 +669,168 @@ func revert() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "print the output stream from all substeps")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the actions revert would perform, without performing them")
 
 	return addHelpToCommand(cmd, RevertHelp)
 }
 
+func status() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "shows the status of each upgrade step and the recommended next action",
+		Long:  statusHelp,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			// A successful status writes its reply as the summary.
+			defer func() {
+				if err != nil {
+					commanders.PrintSummary("status", nil, err)
+				}
+			}()
+
+			cmd.SilenceUsage = true
+
+			client, err := dialHub()
+			if err == nil {
+				return commanders.Status(client)
+			}
+
+			// The hub is not running, or is at another version, so read the
+			// status file directly.
+			if xerrors.Is(err, handshake.ErrVersionMismatch) {
+				gplog.Warn(err.Error())
+			} else {
+				gplog.Debug("could not connect to the upgrade hub: %v", err)
+			}
+
+			reply, err := hub.ReadStatus(utils.GetStateDir())
+			if xerrors.Is(err, os.ErrNotExist) {
+				if commanders.IsJSONFormat() {
+					commanders.PrintStatus(&idl.GetStatusReply{})
+					return nil
+				}
+
+				fmt.Println(`No upgrade is in progress. Run "gpupgrade initialize" to begin the upgrade.`)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if !commanders.IsJSONFormat() {
+				fmt.Println("The gpupgrade hub is not running; showing the last recorded status.")
+			}
+			commanders.PrintStatus(reply)
+			return nil
+		},
+	}
+
+	return addHelpToCommand(cmd, statusHelp)
+}
+
+func check() *cobra.Command {
+	var diskFreeRatio float64
+	var estimateDiskSpace bool
+
+	cmd := &cobra.Command{
+		Use:       "check [name...]",
+		Short:     "runs the pre-upgrade checks without upgrading",
+		Long:      CheckHelp,
+		ValidArgs: commanders.CheckNames(),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			defer func() { commanders.PrintSummary("check", nil, err) }()
+
+			checks, err := commanders.SelectChecks(args, nil)
+			if err != nil {
+				return err
+			}
+
+			conf := &hub.Config{}
+			err = hub.LoadConfig(conf, filepath.Join(utils.GetStateDir(), hub.ConfigFileName))
+			if xerrors.Is(err, os.ErrNotExist) {
+				return xerrors.New(`"gpupgrade check" can only be run once "gpupgrade initialize" has saved the configuration of the clusters; initialize runs the same checks`)
+			}
+			if err != nil {
+				return err
+			}
+
+			// The default depends on the mode that initialize was given.
+			if !cmd.Flag("disk-free-ratio").Changed {
+				diskFreeRatio = defaultDiskFreeRatio(conf.UseLinkMode)
+			}
+
+			if err := validateDiskFreeRatio(diskFreeRatio); err != nil {
+				return err
+			}
+
+			if err := validateEstimateDiskSpace(cmd, estimateDiskSpace); err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+
+			return commanders.RunChecks(connectToHub(), checks, commanders.CheckOptions{
+				DiskFreeRatio:     diskFreeRatio,
+				EstimateDiskSpace: estimateDiskSpace,
+			})
+		},
+	}
+
+	cmd.Flags().Float64Var(&diskFreeRatio, "disk-free-ratio", 0.60, "percentage of disk space that must be available (from 0.0 - 1.0)")
+	cmd.Flags().BoolVar(&estimateDiskSpace, "estimate-disk-space", false, "estimate the disk space and inodes needed from the size of the data, instead of using --disk-free-ratio")
+
+	return addHelpToCommand(cmd, CheckHelp)
+}
+
+func recoverCmd() *cobra.Command {
+	var forceRetry string
+
+	cmd := &cobra.Command{
+		Use:   "recover",
+		Short: "resets substeps that were interrupted while running so they can be retried",
+		Long:  recoverHelp,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			// A successful recovery writes its reply as the summary.
+			defer func() {
+				if err != nil {
+					commanders.PrintSummary("recover", nil, err)
+				}
+			}()
+
+			substep := idl.Substep_UNKNOWN_SUBSTEP
+			if forceRetry != "" {
+				val, ok := idl.Substep_value[strings.ToUpper(forceRetry)]
+				if !ok || val == int32(idl.Substep_UNKNOWN_SUBSTEP) {
+					// Match Cobra's option-error format.
+					return fmt.Errorf(`invalid argument %q for "--force-retry" flag: unknown substep`, forceRetry)
+				}
+				substep = idl.Substep(val)
+			}
+
+			cmd.SilenceUsage = true
+
+			// The hub is likely to have been interrupted along with the
+			// substep, so make sure it is running.
+			running, err := commanders.IsHubRunning()
+			if err != nil {
+				return xerrors.Errorf("failed to determine if there is a hub running: %w", err)
+			}
+
+			if !running {
+				err = commanders.StartHub()
+				if err != nil {
+					return err
+				}
+			}
+
+			return commanders.Recover(connectToHub(), substep)
+		},
+	}
+
+	cmd.Flags().StringVar(&forceRetry, "force-retry", "", "reset the named substep even if it cannot be safely retried, once any manual cleanup is done")
+
+	return addHelpToCommand(cmd, recoverHelp)
+}
+
 func parsePorts(val string) ([]uint32, error) {
 	var ports []uint32
 
@@ -564,6 +873,35 @@ func parsePorts(val string) ([]uint32, error) {
 	return ports, nil
 }
 
+// defaultDiskFreeRatio returns the ratio of free disk space that is needed
+// when --disk-free-ratio is not given. Link mode needs less.
+func defaultDiskFreeRatio(linkMode bool) float64 {
+	if linkMode {
+		return 0.2
+	}
+	return 0.6
+}
+
+func validateDiskFreeRatio(ratio float64) error {
+	if ratio < 0.0 || ratio > 1.0 {
+		// Match Cobra's option-error format.
+		return fmt.Errorf(
+			`invalid argument %g for "--disk-free-ratio" flag: value must be between 0.0 and 1.0`,
+			ratio,
+		)
+	}
+	return nil
+}
+
+// validateEstimateDiskSpace makes sure that --disk-free-ratio is not given
+// along with --estimate-disk-space, which replaces it.
+func validateEstimateDiskSpace(cmd *cobra.Command, estimate bool) error {
+	if estimate && cmd.Flag("disk-free-ratio").Changed {
+		return errors.New(`the "--disk-free-ratio" and "--estimate-disk-space" flags cannot be used together`)
+	}
+	return nil
+}
+
 // isLinkMode parses the mode flag returning an error if it is not copy or link.
 // It returns true if mode is link.
 func isLinkMode(input string) (bool, error) {
@@ -579,35 +917,44 @@ func isLinkMode(input string) (bool, error) {
 	return false, fmt.Errorf("Invalid input %q. Please specify either %s.", input, strings.Join(choices, " or "))
 }
 
-var restartServices = &cobra.Command{
-	Use:   "restart-services",
-	Short: "restarts hub/agents that are not currently running",
-	Long:  "restarts hub/agents that are not currently running",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		running, err := commanders.IsHubRunning()
-		if err != nil {
-			return xerrors.Errorf("failed to determine if there is a hub running: %w", err)
-		}
+func restartServices() *cobra.Command {
+	var redeployAgents bool
 
-		if !running {
-			err = commanders.StartHub()
+	cmd := &cobra.Command{
+		Use:   "restart-services",
+		Short: "restarts hub/agents that are not currently running",
+		Long:  "restarts hub/agents that are not currently running",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			running, err := commanders.IsHubRunning()
 			if err != nil {
-				return err
+				return xerrors.Errorf("failed to determine if there is a hub running: %w", err)
 			}
-			fmt.Println("Restarted hub")
-		}
 
-		reply, err := connectToHub().RestartAgents(context.Background(), &idl.RestartAgentsRequest{})
-		for _, host := range reply.GetAgentHosts() {
-			fmt.Printf("Restarted agent on: %s\n", host)
-		}
+			if !running {
+				err = commanders.StartHub()
+				if err != nil {
+					return err
+				}
+				fmt.Println("Restarted hub")
+			}
 
-		if err != nil {
-			return xerrors.Errorf("failed to start all agents: %w", err)
-		}
+			reply, err := connectToHub().RestartAgents(context.Background(), &idl.RestartAgentsRequest{
+				RedeployMismatchedAgents: redeployAgents,
+			})
+			for _, host := range reply.GetAgentHosts() {
+				fmt.Printf("Restarted agent on: %s\n", host)
+			}
 
-		return nil
-	},
+			if err != nil {
+				return xerrors.Errorf("failed to start all agents: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&redeployAgents, "redeploy-agents", false, "replace agents running a different version of gpupgrade than the hub with the hub's version")
+	return cmd
 }
 
 var killServices = &cobra.Command{
@@ -637,7 +984,14 @@ var killServices = &cobra.Command{
 }
 
 func stopHubAndAgents() error {
-	_, err := connectToHub().StopServices(context.Background(), &idl.StopServicesRequest{})
+	// A hub at another version must still be stopped, so that it can be
+	// replaced by this one.
+	client, err := dialHubWithoutHandshake()
+	if err != nil {
+		return xerrors.Errorf("connecting to the hub: %w", err)
+	}
+
+	_, err = client.StopServices(context.Background(), &idl.StopServicesRequest{})
 	if err != nil {
 		errCode := grpcStatus.Code(err)
 		errMsg := grpcStatus.Convert(err).Message()
@@ -657,6 +1011,9 @@ Runs through pre-upgrade checks and prepares the cluster for upgrade.
 Initialize will carry out the following sub-steps:
 %s
 
+Pressing Ctrl-C stops the running sub-step and marks it as failed. Run
+"gpupgrade initialize" again to continue from that sub-step.
+
 Usage: gpupgrade initialize <flags>
 
 Required Flags:
@@ -671,6 +1028,16 @@ Optional Flags:
 
       --disk-free-ratio    ratio of free space needed in order to run upgrade, range from 0.0 to 1.0
 
+      --estimate-disk-space
+                           estimates the space needed from the size of the data directories
+                           and tablespaces on each filesystem, instead of using a ratio
+
+      --dry-run            prints the actions initialize would perform, including the commands
+                           it would run and the files it would write, without performing them.
+                           The source cluster is queried, but the hub is not started
+
+      --format [text|json] output format; json writes one JSON object per line
+
   -h, --help               displays help output for initialize
 
       --mode [copy|link]   Upgrade mode to either copy source files to target or use hard links to modify data in place. Default is copy.
@@ -679,6 +1046,43 @@ Optional Flags:
 
       --agent-port         the port gpupgrade agent uses to listen for commands on
 
+      --tls                secures the connections between the gpupgrade CLI, hub and agents with
+                           mutual TLS, using certificates signed by a CA generated in the state directory
+
+      --metrics-port       the port the hub serves Prometheus-style metrics on at /metrics.
+                           Metrics are not served by default
+
+      --agent-metrics-port the port the agents serve Prometheus-style metrics on at /metrics.
+                           Metrics are not served by default
+
+      --metrics-address    the address the hub and agents serve metrics on. Metrics are served
+                           without authentication, even with --tls, so this defaults to the
+                           loopback address 127.0.0.1. Use 0.0.0.0 to serve them on all interfaces
+
+      --trusted-shell      the ssh program used to run commands, such as starting the agents, on
+                           the other hosts. It is also used as gpinitsystem's TRUSTED_SHELL.
+                           Use "local" to run them on this host, for single-host clusters.
+                           Defaults to ssh
+
+      --ssh-user           the user to run commands on the other hosts as
+
+      --ssh-identity-file  the identity file used to run commands on the other hosts
+
+      --ssh-option         an ssh option, such as ConnectTimeout=10, used to run commands on
+                           the other hosts. May be repeated
+
+      --max-upgrades-per-host
+                           the most segments to run pg_upgrade on at once on each host.
+                           There is no limit by default
+
+      --max-upgrades       the most segments to run pg_upgrade on at once across the cluster.
+                           There is no limit by default
+
+      --skip-check         a comma-separated list of pre-upgrade checks not to run.
+                           See "gpupgrade check --help" for the checks
+
+      --only-check         a comma-separated list of the only pre-upgrade checks to run
+
   -v, --verbose            outputs detailed logs for initialize
 `
 	executeHelp = `
@@ -687,13 +1091,29 @@ Upgrades the master and primary segments to the target Greenplum version.
 Execute will carry out the following sub-steps:
 %s
 
+Pressing Ctrl-C stops the running sub-step and marks it as failed. Run
+"gpupgrade execute" again to continue from that sub-step.
+
+When upgrading the primaries fails on some segments, running "gpupgrade
+execute" again only upgrades the segments that failed. Segments are upgraded
+again from scratch if the master is copied again.
+
 Usage: gpupgrade execute
 
 Optional Flags:
 
-  -h, --help      displays help output for execute
+      --dry-run                      prints the actions execute would perform,
+                                     without performing them
+
+      --format                       output format, either text or json
+
+  -h, --help                         displays help output for execute
+
+      --retry-failed-segments-only   when retrying, skip the primaries that were
+                                     already upgraded. Set to false to upgrade
+                                     every primary again. Defaults to true
 
-  -v, --verbose   outputs detailed logs for execute
+  -v, --verbose                      outputs detailed logs for execute
 `
 	finalizeHelp = `
 Upgrades the standby master and mirror segments to the target Greenplum version.
@@ -701,10 +1121,17 @@ Upgrades the standby master and mirror segments to the target Greenplum version.
 Finalize will carry out the following sub-steps:
 %s
 
+Pressing Ctrl-C stops the running sub-step and marks it as failed. Run
+"gpupgrade finalize" again to continue from that sub-step.
+
 Usage: gpupgrade finalize
 
 Optional Flags:
 
+      --dry-run   prints the actions finalize would perform, without performing them
+
+      --format    output format, either text or json
+
   -h, --help      displays help output for finalize
 
   -v, --verbose   outputs detailed logs for finalize
@@ -721,9 +1148,77 @@ Usage: gpupgrade revert
 
 Optional Flags:
 
+      --dry-run   prints the actions revert would perform, without performing them
+
+      --format    output format, either text or json
+
   -h, --help      displays help output for revert
 
   -v, --verbose   outputs detailed logs for revert
+`
+	checkHelp = `
+Runs the pre-upgrade checks, without starting or changing the upgrade. The
+checks that are named are run, or all of them when none are named:
+%s
+A table of the results is printed and saved in the state directory.
+
+Check only works after "gpupgrade initialize" has run. The checks are run by
+the hub, using the configuration of the source and target clusters that
+initialize saves, and initialize itself runs the same checks before it makes
+any changes.
+
+Usage: gpupgrade check [name...] <flags>
+
+Optional Flags:
+
+      --disk-free-ratio    ratio of free space needed in order to run upgrade, range from 0.0 to 1.0.
+                           Defaults to the ratio for the mode given to initialize
+
+      --estimate-disk-space
+                           estimates the space needed from the size of the data directories
+                           and tablespaces on each filesystem, instead of using a ratio
+
+      --format             output format, either text or json
+
+  -h, --help               displays help output for check
+`
+	statusHelp = `
+Shows the status of every substep of initialize, execute, finalize and revert,
+when each started and finished, and the recommended next action.
+
+While the hub is running, the health of each agent is also shown: whether it
+answered the hub's last heartbeat, when it was last seen, and its version.
+
+If the hub is not running, the last status recorded in the state directory is
+shown instead.
+
+Usage: gpupgrade status
+
+Optional Flags:
+
+      --format    output format, either text or json
+
+  -h, --help      displays help output for status
+`
+	recoverHelp = `
+Checks each substep that was interrupted while running, for example because
+the hub was stopped, and determines whether it can be safely retried.
+
+Substeps that can be safely retried are reset, so that they are run again the
+next time their command is run. For the remaining substeps, the manual cleanup
+that is needed before they can be retried is shown.
+
+Usage: gpupgrade recover <flags>
+
+Optional Flags:
+
+      --force-retry   reset the named substep (for example INIT_TARGET_CLUSTER)
+                      even if it cannot be safely retried, once the manual
+                      cleanup has been done
+
+      --format        output format, either text or json
+
+  -h, --help          displays help output for recover
 `
 	GlobalHelp = `
 gpupgrade enables users to do an in-place cluster upgrade to the next major version.
@@ -751,10 +1246,23 @@ Required Commands: gpupgrade is a three-step process
 
   3. finalize     upgrades the standby master and mirror segments to the target Greenplum version
 
+Other Commands:
+
+  check           runs the pre-upgrade checks without upgrading
+
+  status          shows the status of each upgrade step and the recommended next action
+
+  recover         resets substeps that were interrupted so that they can be retried
+
+  revert          returns the cluster to its original state
+
 Optional Flags:
 
   --disk-free-ratio    ratio of free space needed in order to run upgrade, range from 0.0 to 1.0
 
+  --format             output format, either text or json. With json, progress is
+                       written as one JSON object per line, followed by a summary
+
   -h, --help           displays help output for gpupgrade
 
   -v, --verbose        outputs detailed logs for gpupgrade
@@ -774,6 +1282,16 @@ func GenerateHelpString(baseString string, commandList []idl.Substep) string {
 
 }
 
+// GenerateCheckHelpString fills in the base string with the name and
+// description of each check.
+func GenerateCheckHelpString(baseString string, checks []commanders.Check) string {
+	var formattedList string
+	for _, check := range checks {
+		formattedList += fmt.Sprintf(" - %-22s %s\n", check.Name, check.Description)
+	}
+	return fmt.Sprintf(baseString, formattedList)
+}
+
 // Cobra has multiple ways to handle help text, so we want to force all of them to use the same help text
 func addHelpToCommand(cmd *cobra.Command, help string) *cobra.Command {
 	// Add a "-?" flag, which Cobra does not provide by default
@@ -68,6 +68,7 @@ func Hub() *cobra.Command {
 			}
 
 			h := hub.New(conf, grpc.DialContext, stateDir)
+			h.Version = UpgradeVersion
 
 			if shouldDaemonize {
 				h.MakeDaemon()
@@ -11,6 +11,7 @@ import (
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	_ "github.com/lib/pq"
 
+	"github.com/greenplum-db/gpupgrade/cli/commanders"
 	"github.com/greenplum-db/gpupgrade/cli/commands"
 	"github.com/greenplum-db/gpupgrade/utils"
 	"github.com/greenplum-db/gpupgrade/utils/daemon"
@@ -30,8 +31,15 @@ func main() {
 
 	err = root.Execute()
 	if err != nil && err != daemon.ErrSuccessfullyDaemonized {
+		// Keep stdout to the JSON events and summary, which already carry
+		// the error.
+		out := os.Stdout
+		if commanders.IsJSONFormat() {
+			out = os.Stderr
+		}
+
 		// Use v to print the stack trace of an object errors.
-		fmt.Printf("\n%+v\n", err)
+		fmt.Fprintf(out, "\n%+v\n", err)
 		os.Exit(1)
 	}
 }
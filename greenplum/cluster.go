@@ -272,7 +272,12 @@ func (c *Cluster) GetDirForContent(contentID int) string {
 }
 
 func (c *Cluster) Start(stream OutStreams) error {
-	return runStartStopCmd(stream, c.BinDir, fmt.Sprintf("gpstart -a -d %[1]s", c.MasterDataDir()))
+	return runStartStopCmd(stream, c.StartScript())
+}
+
+// StartScript returns the bash script that Start runs.
+func (c *Cluster) StartScript() string {
+	return startStopScript(c.BinDir, fmt.Sprintf("gpstart -a -d %[1]s", c.MasterDataDir()))
 }
 
 func (c *Cluster) Stop(stream OutStreams) error {
@@ -286,11 +291,16 @@ func (c *Cluster) Stop(stream OutStreams) error {
 		return err
 	}
 
-	return runStartStopCmd(stream, c.BinDir, fmt.Sprintf("gpstop -a -d %[1]s", c.MasterDataDir()))
+	return runStartStopCmd(stream, c.StopScript())
+}
+
+// StopScript returns the bash script that Stop runs.
+func (c *Cluster) StopScript() string {
+	return startStopScript(c.BinDir, fmt.Sprintf("gpstop -a -d %[1]s", c.MasterDataDir()))
 }
 
 func (c *Cluster) StartMasterOnly(stream OutStreams) error {
-	return runStartStopCmd(stream, c.BinDir, fmt.Sprintf("gpstart -m -a -d %[1]s", c.MasterDataDir()))
+	return runStartStopCmd(stream, startStopScript(c.BinDir, fmt.Sprintf("gpstart -m -a -d %[1]s", c.MasterDataDir())))
 }
 
 func (c *Cluster) StopMasterOnly(stream OutStreams) error {
@@ -304,15 +314,17 @@ func (c *Cluster) StopMasterOnly(stream OutStreams) error {
 		return err
 	}
 
-	return runStartStopCmd(stream, c.BinDir, fmt.Sprintf("gpstop -m -a -d %[1]s", c.MasterDataDir()))
+	return runStartStopCmd(stream, startStopScript(c.BinDir, fmt.Sprintf("gpstop -m -a -d %[1]s", c.MasterDataDir())))
 }
 
-func runStartStopCmd(stream OutStreams, binDir, command string) error {
-	commandWithEnv := fmt.Sprintf("source %[1]s/../greenplum_path.sh && %[1]s/%[2]s",
+func startStopScript(binDir, command string) string {
+	return fmt.Sprintf("source %[1]s/../greenplum_path.sh && %[1]s/%[2]s",
 		binDir,
 		command)
+}
 
-	cmd := startStopCmd("bash", "-c", commandWithEnv)
+func runStartStopCmd(stream OutStreams, script string) error {
+	cmd := startStopCmd("bash", "-c", script)
 	cmd.Stdout = stream.Stdout()
 	cmd.Stderr = stream.Stderr()
 	return cmd.Run()
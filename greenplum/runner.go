@@ -31,13 +31,19 @@ func NewRunner(c *Cluster, streams OutStreams) Runner {
 	}
 }
 
-func (e *runner) Run(utilityName string, arguments ...string) error {
-	path := filepath.Join(e.binDir, utilityName)
+// UtilityScript returns the bash script that Runner.Run uses to run the
+// utility from binDir with the given arguments.
+func UtilityScript(binDir, utilityName string, arguments ...string) string {
+	path := filepath.Join(binDir, utilityName)
 
 	arguments = append([]string{path}, arguments...)
 	script := shellquote.Join(arguments...)
 
-	withGreenplumPath := fmt.Sprintf("source %s/../greenplum_path.sh && %s", e.binDir, script)
+	return fmt.Sprintf("source %s/../greenplum_path.sh && %s", binDir, script)
+}
+
+func (e *runner) Run(utilityName string, arguments ...string) error {
+	withGreenplumPath := UtilityScript(e.binDir, utilityName, arguments...)
 	gplog.Debug(withGreenplumPath)
 
 	command := exec.Command("bash", "-c", withGreenplumPath)
@@ -7,6 +7,7 @@ import (
 	"encoding/csv"
 	"io"
 	"path/filepath"
+	"sort"
 	"strconv"
 
 	"github.com/greenplum-db/gp-common-go-libs/dbconn"
@@ -67,6 +68,21 @@ func (t *TablespaceInfo) IsUserDefined() bool {
 	return t.UserDefined == 1
 }
 
+// UserDefinedLocations returns the sorted locations of the user defined
+// tablespaces, leaving out pg_default and pg_global which live in the data
+// directory.
+func (s SegmentTablespaces) UserDefinedLocations() []string {
+	var locations []string
+	for _, info := range s {
+		if info.IsUserDefined() {
+			locations = append(locations, info.Location)
+		}
+	}
+
+	sort.Strings(locations)
+	return locations
+}
+
 func GetTablespaceLocationForDbId(t *idl.TablespaceInfo, dbId int) string {
 	return filepath.Join(t.Location, strconv.Itoa(dbId))
 }
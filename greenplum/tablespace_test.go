@@ -222,6 +222,24 @@ func TestNewTablespaces(t *testing.T) {
 	}
 }
 
+func TestUserDefinedLocations(t *testing.T) {
+	tablespaces := SegmentTablespaces{
+		1663:  {Location: "/tmp/primary/gpseg0", UserDefined: 0},
+		1664:  {Location: "/tmp/primary/1664", UserDefined: 0},
+		16390: {Location: "/tmp/tblspc2/16390/2", UserDefined: 1},
+		16386: {Location: "/tmp/tblspc1/16386/2", UserDefined: 1},
+	}
+
+	expected := []string{"/tmp/tblspc1/16386/2", "/tmp/tblspc2/16390/2"}
+	if got := tablespaces.UserDefinedLocations(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("UserDefinedLocations() = %q, want %q", got, expected)
+	}
+
+	if got := (SegmentTablespaces)(nil).UserDefinedLocations(); len(got) != 0 {
+		t.Errorf("UserDefinedLocations() = %q, want none", got)
+	}
+}
+
 // returns a set of sqlmock.Rows that contains the expected
 // response to a tablespace query.
 func MockTablespaceQueryResult() *sqlmock.Rows {
@@ -0,0 +1,59 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"os"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
+)
+
+// DistributeAgentCerts signs a certificate for the agents on the given hosts,
+// and copies it to the same state directory path on each of them, along with
+// the CA certificate.
+func DistributeAgentCerts(ctx context.Context, streams step.OutStreams, executor RemoteExecutor, stateDir string, hosts []string) error {
+	if _, err := certs.GenerateAgent(stateDir, hosts); err != nil {
+		return xerrors.Errorf("generating agent certificate: %w", err)
+	}
+
+	// Local agents share the hub's state directory, which already holds the
+	// files; copying them onto themselves would truncate them.
+	if _, ok := executor.(LocalExecutor); ok {
+		return nil
+	}
+
+	// The agents are not running yet, so the files are written using the
+	// executor. The state directory may not exist yet on a new host.
+	dir := certs.Dir(stateDir)
+	for _, host := range hosts {
+		err := runRemote(ctx, executor, host, "mkdir -p -m 0700 "+dir, nil, streams.Stdout(), streams.Stderr())
+		if err != nil {
+			return xerrors.Errorf("creating certificate directory on host %s: %w", host, err)
+		}
+
+		for _, path := range certs.AgentFiles(stateDir) {
+			if err := copyFileToHost(ctx, streams, executor, host, path); err != nil {
+				return xerrors.Errorf("copying %q to host %s: %w", path, host, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyFileToHost writes a file to the same path on a host, readable only by
+// its owner.
+func copyFileToHost(ctx context.Context, streams step.OutStreams, executor RemoteExecutor, host, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return runRemote(ctx, executor, host, "umask 077 && cat > "+path, f, streams.Stdout(), streams.Stderr())
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
+)
+
+func TestDistributeAgentCerts(t *testing.T) {
+	stateDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stateDir)
+
+	if _, err := certs.GenerateHub(stateDir); err != nil {
+		t.Fatalf("GenerateHub() returned error %+v", err)
+	}
+
+	dir := certs.Dir(stateDir)
+	hosts := []string{"sdw1", "sdw2"}
+
+	var mu sync.Mutex
+	var calls []string
+	SetExecCommand(exectest.NewCommandWithVerifier(Success, func(name string, args ...string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		calls = append(calls, name+" "+strings.Join(args, " "))
+	}))
+	defer ResetExecCommand()
+
+	err = DistributeAgentCerts(context.Background(), utils.DevNull, SSHExecutor{}, stateDir, hosts)
+	if err != nil {
+		t.Fatalf("DistributeAgentCerts() returned error %+v", err)
+	}
+
+	var expected []string
+	for _, host := range hosts {
+		expected = append(expected, "ssh "+host+" mkdir -p -m 0700 "+dir)
+		for _, path := range certs.AgentFiles(stateDir) {
+			expected = append(expected, "ssh "+host+" umask 077 && cat > "+path)
+		}
+	}
+	sort.Strings(expected)
+
+	sort.Strings(calls)
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("got calls %q want %q", calls, expected)
+	}
+
+	for _, path := range certs.AgentFiles(stateDir) {
+		if filepath.Base(path) == "ca.key" {
+			t.Errorf("the CA key must not be copied to the agents: %q", path)
+		}
+	}
+
+	for _, path := range certs.AgentFiles(stateDir) {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %q to exist: %v", path, err)
+		}
+	}
+}
+
+func TestDistributeAgentCertsLocally(t *testing.T) {
+	stateDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stateDir)
+
+	if _, err := certs.GenerateHub(stateDir); err != nil {
+		t.Fatalf("GenerateHub() returned error %+v", err)
+	}
+
+	SetExecCommand(exectest.NewCommandWithVerifier(Success, func(name string, args ...string) {
+		t.Errorf("unexpected command %q %q", name, args)
+	}))
+	defer ResetExecCommand()
+
+	err = DistributeAgentCerts(context.Background(), utils.DevNull, LocalExecutor{}, stateDir, []string{"localhost"})
+	if err != nil {
+		t.Fatalf("DistributeAgentCerts() returned error %+v", err)
+	}
+
+	// The agent shares the hub's state directory, so its files are already in
+	// place and must be left intact.
+	for _, path := range certs.AgentFiles(stateDir) {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Errorf("expected %q to exist: %v", path, err)
+		} else if info.Size() == 0 {
+			t.Errorf("expected %q to be non-empty", path)
+		}
+	}
+}
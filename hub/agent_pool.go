@@ -0,0 +1,232 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils/handshake"
+)
+
+// agentPool holds the hub's connections to its agents. Agents are dialed
+// concurrently and outside of the pool's lock, each with its own DialTimeout,
+// so a slow or missing agent only delays the callers that need it and never
+// blocks Server.Stop().
+type agentPool struct {
+	dialer Dialer
+
+	// ctx is cancelled by close() to abandon any dials still in flight.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	conns   map[string]*Connection
+	pending map[string]*pendingDial
+	closed  bool
+}
+
+// pendingDial is a dial in flight. Callers that need the same host while it is
+// being dialed wait on done rather than dialing it again.
+type pendingDial struct {
+	done chan struct{}
+	conn *Connection
+	err  error
+}
+
+func newAgentPool(dialer Dialer) *agentPool {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &agentPool{
+		dialer:  dialer,
+		ctx:     ctx,
+		cancel:  cancel,
+		conns:   make(map[string]*Connection),
+		pending: make(map[string]*pendingDial),
+	}
+}
+
+// get returns a ready connection to the agent on each of the given hosts,
+// sorted by hostname. Hosts that aren't connected yet are dialed, and broken
+// connections are closed and dialed again. Newly dialed agents must be at
+// version, unless it is empty. The hosts that still could not be reached are
+// returned along with the reason for each.
+func (p *agentPool) get(hosts []string, port int, version string, opts ...grpc.DialOption) ([]*Connection, map[string]error, error) {
+	p.mu.Lock()
+
+	if p.closed {
+		p.mu.Unlock()
+		return nil, nil, ErrHubStopped
+	}
+
+	var conns []*Connection
+	dials := make(map[string]*pendingDial)
+	for _, host := range hosts {
+		if conn, ok := p.conns[host]; ok {
+			state := conn.Conn.GetState()
+			agentConnectionState.SetState(state.String(), connectivityStates, host)
+
+			if state == connectivity.Ready {
+				conns = append(conns, conn)
+				continue
+			}
+
+			gplog.Debug("reconnecting to agent on host %s: connection is %s", host, state)
+			delete(p.conns, host)
+			go closeConn(conn)
+		}
+
+		dial, ok := p.pending[host]
+		if !ok {
+			dial = &pendingDial{done: make(chan struct{})}
+			p.pending[host] = dial
+			go p.dial(dial, host, port, version, opts)
+		}
+		dials[host] = dial
+	}
+
+	p.mu.Unlock()
+
+	unreachable := make(map[string]error)
+	for host, dial := range dials {
+		<-dial.done
+
+		if dial.err != nil {
+			unreachable[host] = dial.err
+			continue
+		}
+
+		conns = append(conns, dial.conn)
+	}
+
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i].Hostname < conns[j].Hostname
+	})
+
+	return conns, unreachable, nil
+}
+
+func (p *agentPool) dial(dial *pendingDial, host string, port int, version string, opts []grpc.DialOption) {
+	defer close(dial.done)
+
+	ctx, cancel := context.WithTimeout(p.ctx, DialTimeout)
+	opts = append(append([]grpc.DialOption(nil), opts...), grpc.WithBlock())
+	conn, err := p.dialer(ctx, host+":"+strconv.Itoa(port), opts...)
+	if err == nil && version != "" {
+		err = greet(ctx, conn, host, version)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.pending, host)
+
+	if xerrors.Is(err, handshake.ErrVersionMismatch) {
+		cancel()
+
+		dial.err = err
+		gplog.Error("refusing agent on host %s: %s", host, err)
+		return
+	}
+
+	if err != nil {
+		cancel()
+
+		dial.err = xerrors.Errorf("grpcDialer failed: %w", err)
+		gplog.Error("connecting to agent on host %s: %s", host, dial.err)
+		return
+	}
+
+	c := &Connection{
+		Conn:          conn,
+		AgentClient:   idl.NewAgentClient(conn),
+		Hostname:      host,
+		CancelContext: cancel,
+	}
+
+	if p.closed {
+		go closeConn(c)
+		dial.err = ErrHubStopped
+		return
+	}
+
+	p.conns[host] = c
+	dial.conn = c
+}
+
+// greet checks that the agent on conn is at version, and closes conn if it is
+// not.
+func greet(ctx context.Context, conn *grpc.ClientConn, host, version string) error {
+	_, err := handshake.Hello(ctx, "the agent on host "+host, version, idl.NewAgentClient(conn).Hello)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	return nil
+}
+
+// snapshot returns the pool's current connections without dialing.
+func (p *agentPool) snapshot() []*Connection {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conns := make([]*Connection, 0, len(p.conns))
+	for _, conn := range p.conns {
+		conns = append(conns, conn)
+	}
+
+	return conns
+}
+
+// close abandons any dials in flight and closes every connection. The pool
+// cannot be used afterwards.
+func (p *agentPool) close() {
+	p.mu.Lock()
+	p.closed = true
+	conns := p.conns
+	p.conns = make(map[string]*Connection)
+	p.mu.Unlock()
+
+	p.cancel()
+
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func(conn *Connection) {
+			defer wg.Done()
+			closeConn(conn)
+		}(conn)
+	}
+	wg.Wait()
+}
+
+// closeConn closes the connection and waits, at most DialTimeout, for it to
+// shut down. Connections that are already shut down are skipped, since they
+// will never change state again.
+func closeConn(conn *Connection) {
+	defer conn.CancelContext()
+
+	state := conn.Conn.GetState()
+	if state == connectivity.Shutdown {
+		return
+	}
+
+	err := conn.Conn.Close()
+	if err != nil {
+		gplog.Info("Error closing hub to agent connection. host: %s, err: %s", conn.Hostname, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DialTimeout)
+	defer cancel()
+	conn.Conn.WaitForStateChange(ctx, state)
+}
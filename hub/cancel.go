@@ -0,0 +1,58 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// Cancel cancels any running initialize, execute or finalize step. The running
+// substep is stopped, along with any processes it started on the hub and the
+// agents, and is marked as failed.
+func (s *Server) Cancel(ctx context.Context, in *idl.CancelRequest) (*idl.CancelReply, error) {
+	s.cancelMu.Lock()
+	defer s.cancelMu.Unlock()
+
+	for _, cancel := range s.cancels {
+		cancel()
+	}
+
+	cancelled := len(s.cancels) > 0
+	if cancelled {
+		gplog.Info("cancelling %d running step(s)", len(s.cancels))
+	}
+
+	return &idl.CancelReply{Cancelled: cancelled}, nil
+}
+
+// cancellableContext returns the context for a step that may be stopped by
+// Cancel. It is deliberately not derived from the stream context; a client
+// that disconnects does not stop the step. The returned function must be
+// called once the step is finished.
+func (s *Server) cancellableContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.cancelMu.Lock()
+	defer s.cancelMu.Unlock()
+
+	if s.cancels == nil {
+		s.cancels = make(map[int]context.CancelFunc)
+	}
+
+	id := s.nextCancelID
+	s.nextCancelID++
+	s.cancels[id] = cancel
+
+	return ctx, func() {
+		s.cancelMu.Lock()
+		defer s.cancelMu.Unlock()
+
+		delete(s.cancels, id)
+		cancel()
+	}
+}
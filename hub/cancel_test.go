@@ -0,0 +1,86 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+func TestCancel(t *testing.T) {
+	testhelper.SetupTestLogger()
+
+	t.Run("cancels the contexts of running steps", func(t *testing.T) {
+		s := New(&Config{}, nil, "")
+
+		ctx1, done1 := s.cancellableContext()
+		defer done1()
+		ctx2, done2 := s.cancellableContext()
+		defer done2()
+
+		reply, err := s.Cancel(context.Background(), &idl.CancelRequest{})
+		if err != nil {
+			t.Fatalf("Cancel() returned error %+v", err)
+		}
+
+		if !reply.Cancelled {
+			t.Error("expected Cancelled to be true")
+		}
+
+		for _, ctx := range []context.Context{ctx1, ctx2} {
+			if ctx.Err() != context.Canceled {
+				t.Errorf("got context error %v want %v", ctx.Err(), context.Canceled)
+			}
+		}
+	})
+
+	t.Run("does nothing when no step is running", func(t *testing.T) {
+		s := New(&Config{}, nil, "")
+
+		_, done := s.cancellableContext()
+		done()
+
+		reply, err := s.Cancel(context.Background(), &idl.CancelRequest{})
+		if err != nil {
+			t.Fatalf("Cancel() returned error %+v", err)
+		}
+
+		if reply.Cancelled {
+			t.Error("expected Cancelled to be false")
+		}
+	})
+
+	t.Run("does not affect steps started afterwards", func(t *testing.T) {
+		s := New(&Config{}, nil, "")
+
+		_, err := s.Cancel(context.Background(), &idl.CancelRequest{})
+		if err != nil {
+			t.Fatalf("Cancel() returned error %+v", err)
+		}
+
+		ctx, done := s.cancellableContext()
+		defer done()
+
+		if ctx.Err() != nil {
+			t.Errorf("got context error %v want nil", ctx.Err())
+		}
+	})
+
+	t.Run("Recover refuses to reset substeps while a step is running", func(t *testing.T) {
+		s := New(&Config{}, nil, "")
+
+		_, done := s.cancellableContext()
+		defer done()
+
+		_, err := s.Recover(context.Background(), &idl.RecoverRequest{})
+		if !xerrors.Is(err, ErrStepRunning) {
+			t.Errorf("got error %#v want %#v", err, ErrStepRunning)
+		}
+	})
+}
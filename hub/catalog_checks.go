@@ -0,0 +1,457 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"github.com/hashicorp/go-multierror"
+	"golang.org/x/xerrors"
+)
+
+// CatalogCheck inspects a database of the source cluster for anything that
+// would keep it from upgrading, beyond what pg_upgrade --check finds. Add a
+// check to CatalogChecks to have the source_catalog check run it.
+type CatalogCheck interface {
+	Name() string
+	Description() string
+
+	// Check returns the objects of the database that fail the check.
+	Check(ctx context.Context, env CheckEnv) ([]CheckFailure, error)
+}
+
+// CheckEnv is what a CatalogCheck runs against.
+type CheckEnv struct {
+	DB       *sql.DB
+	Database string
+	Version  dbconn.GPDBVersion // of the source cluster
+
+	// TargetLibDir is the directory of the target cluster's loadable
+	// libraries.
+	TargetLibDir string
+}
+
+// CheckFailure describes an object that fails a check, and how to fix it.
+type CheckFailure struct {
+	Database string
+	Object   string
+	Problem  string
+
+	// Remediation holds the SQL that resolves the failure. Where the fix
+	// cannot be done in SQL alone, it is described in SQL comments.
+	Remediation string
+}
+
+// CheckResult gathers the failures of one check across every database.
+type CheckResult struct {
+	Name        string
+	Description string
+	Failures    []CheckFailure
+}
+
+func (r CheckResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// SQLCheck is a CatalogCheck whose query returns a row for each failure, with
+// the columns object, problem and remediation.
+type SQLCheck struct {
+	CheckName        string
+	CheckDescription string
+
+	// SourceVersion limits the check to source clusters of the given major
+	// version, such as "5". The check runs for every version when it is
+	// empty.
+	SourceVersion string
+
+	Query string
+}
+
+func (c SQLCheck) Name() string        { return c.CheckName }
+func (c SQLCheck) Description() string { return c.CheckDescription }
+
+func (c SQLCheck) Check(ctx context.Context, env CheckEnv) ([]CheckFailure, error) {
+	if c.SourceVersion != "" && !env.Version.Is(c.SourceVersion) {
+		return nil, nil
+	}
+
+	rows, err := env.DB.QueryContext(ctx, c.Query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failures []CheckFailure
+	for rows.Next() {
+		f := CheckFailure{Database: env.Database}
+		if err := rows.Scan(&f.Object, &f.Problem, &f.Remediation); err != nil {
+			return nil, err
+		}
+		failures = append(failures, f)
+	}
+
+	return failures, rows.Err()
+}
+
+// CatalogChecks are run against every database of the source cluster by the
+// source_catalog check.
+var CatalogChecks = []CatalogCheck{
+	unsupportedTypesCheck,
+	heterogeneousPartitionsCheck,
+	gphdfsExternalTablesCheck,
+	removedCatalogColumnsCheck,
+	missingLibrariesCheck{},
+}
+
+// userSchemas filters out the schemas of the system, for a query on the
+// namespace aliased as n.
+const userSchemas = `n.nspname NOT IN ('pg_catalog', 'information_schema', 'gp_toolkit') AND n.nspname !~ '^pg_toast'`
+
+var unsupportedTypesCheck = SQLCheck{
+	CheckName:        "unsupported_types",
+	CheckDescription: "tables with columns of data types that pg_upgrade cannot upgrade",
+	Query: `
+		SELECT quote_ident(n.nspname) || '.' || quote_ident(c.relname) || '.' || quote_ident(a.attname),
+			'column has the unsupported type ' || pg_catalog.format_type(a.atttypid, a.atttypmod),
+			'ALTER TABLE ' || quote_ident(n.nspname) || '.' || quote_ident(c.relname) ||
+				' ALTER COLUMN ' || quote_ident(a.attname) || ' TYPE text;'
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid
+		WHERE c.relkind = 'r'
+			AND a.attnum > 0
+			AND NOT a.attisdropped
+			AND ` + userSchemas + `
+			AND a.atttypid IN (
+				'pg_catalog.regproc'::pg_catalog.regtype,
+				'pg_catalog.regprocedure'::pg_catalog.regtype,
+				'pg_catalog.regoper'::pg_catalog.regtype,
+				'pg_catalog.regoperator'::pg_catalog.regtype,
+				'pg_catalog.regconfig'::pg_catalog.regtype,
+				'pg_catalog.regdictionary'::pg_catalog.regtype,
+				'pg_catalog.unknown'::pg_catalog.regtype)
+		ORDER BY 1`,
+}
+
+var heterogeneousPartitionsCheck = SQLCheck{
+	CheckName:        "heterogeneous_partitions",
+	CheckDescription: "partitioned tables whose partitions have columns that differ from the root",
+	Query: `
+		SELECT DISTINCT quote_ident(n.nspname) || '.' || quote_ident(r.relname),
+			'partitions have columns that differ from the root partition',
+			'-- Recreate ' || quote_ident(n.nspname) || '.' || quote_ident(r.relname) ||
+				' and reload its data, so that its partitions have the same columns as the root.'
+		FROM pg_catalog.pg_partition p
+		JOIN pg_catalog.pg_partition_rule pr ON pr.paroid = p.oid
+		JOIN pg_catalog.pg_class r ON r.oid = p.parrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = r.relnamespace
+		JOIN pg_catalog.pg_class c ON c.oid = pr.parchildrelid
+		WHERE NOT p.paristemplate
+			AND (r.relnatts <> c.relnatts OR EXISTS (
+				SELECT 1
+				FROM pg_catalog.pg_attribute ra
+				JOIN pg_catalog.pg_attribute ca ON ca.attrelid = c.oid AND ca.attnum = ra.attnum
+				WHERE ra.attrelid = r.oid
+					AND ra.attnum > 0
+					AND (ra.attisdropped <> ca.attisdropped
+						OR ra.atttypid <> ca.atttypid
+						OR ra.attlen <> ca.attlen
+						OR ra.attalign <> ca.attalign)))
+		ORDER BY 1`,
+}
+
+var gphdfsExternalTablesCheck = SQLCheck{
+	CheckName:        "gphdfs_external_tables",
+	CheckDescription: "external tables that use the gphdfs protocol, which Greenplum 6 removes",
+	SourceVersion:    "5",
+	Query: `
+		SELECT quote_ident(n.nspname) || '.' || quote_ident(c.relname),
+			'external table uses the gphdfs protocol',
+			'DROP EXTERNAL TABLE ' || quote_ident(n.nspname) || '.' || quote_ident(c.relname) ||
+				'; -- recreate it with the PXF protocol after the upgrade'
+		FROM pg_catalog.pg_exttable x
+		JOIN pg_catalog.pg_class c ON c.oid = x.reloid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE array_to_string(x.location, ' ') LIKE '%gphdfs://%'
+		ORDER BY 1`,
+}
+
+var removedCatalogColumnsCheck = SQLCheck{
+	CheckName:        "removed_catalog_columns",
+	CheckDescription: "views that depend on catalog columns that Greenplum 6 removes",
+	SourceVersion:    "5",
+	Query: `
+		SELECT DISTINCT quote_ident(n.nspname) || '.' || quote_ident(v.relname),
+			'view depends on pg_catalog.' || c.relname || '.' || a.attname,
+			'DROP VIEW IF EXISTS ' || quote_ident(n.nspname) || '.' || quote_ident(v.relname) ||
+				'; -- recreate it without pg_catalog.' || c.relname || '.' || a.attname || ' after the upgrade'
+		FROM pg_catalog.pg_depend d
+		JOIN pg_catalog.pg_rewrite rw ON rw.oid = d.objid
+		JOIN pg_catalog.pg_class v ON v.oid = rw.ev_class
+		JOIN pg_catalog.pg_namespace n ON n.oid = v.relnamespace
+		JOIN pg_catalog.pg_class c ON c.oid = d.refobjid
+		JOIN pg_catalog.pg_namespace cn ON cn.oid = c.relnamespace
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid AND a.attnum = d.refobjsubid
+		WHERE d.classid = 'pg_catalog.pg_rewrite'::pg_catalog.regclass
+			AND d.refclassid = 'pg_catalog.pg_class'::pg_catalog.regclass
+			AND v.relkind = 'v'
+			AND cn.nspname = 'pg_catalog'
+			AND ` + userSchemas + `
+			AND (c.relname, a.attname) IN (VALUES
+				('pg_authid', 'rolconfig'),
+				('pg_class', 'relfkeys'),
+				('pg_class', 'relrefs'),
+				('pg_class', 'reltoastidxid'),
+				('pg_class', 'relukeys'),
+				('pg_database', 'datconfig'),
+				('pg_proc', 'proiswin'),
+				('pg_roles', 'rolconfig'),
+				('pg_shadow', 'useconfig'),
+				('pg_stat_activity', 'current_query'),
+				('pg_stat_activity', 'procpid'),
+				('pg_tablespace', 'spclocation'),
+				('pg_user', 'useconfig'))
+		ORDER BY 1, 2`,
+}
+
+// missingLibrariesCheck finds the C functions whose libraries are not
+// installed for the target cluster.
+type missingLibrariesCheck struct{}
+
+func (missingLibrariesCheck) Name() string { return "missing_libraries" }
+
+func (missingLibrariesCheck) Description() string {
+	return "functions whose libraries are not installed in the target cluster"
+}
+
+func (missingLibrariesCheck) Check(ctx context.Context, env CheckEnv) ([]CheckFailure, error) {
+	rows, err := env.DB.QueryContext(ctx, `
+		SELECT DISTINCT p.probin, p.oid::pg_catalog.regprocedure::text
+		FROM pg_catalog.pg_proc p
+		JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+		JOIN pg_catalog.pg_language l ON l.oid = p.prolang
+		WHERE l.lanname = 'c'
+			AND `+userSchemas+`
+		ORDER BY 1, 2`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failures []CheckFailure
+	for rows.Next() {
+		var library, function string
+		if err := rows.Scan(&library, &function); err != nil {
+			return nil, err
+		}
+
+		if libraryExists(env.TargetLibDir, library) {
+			continue
+		}
+
+		failures = append(failures, CheckFailure{
+			Database: env.Database,
+			Object:   function,
+			Problem:  fmt.Sprintf("function uses the library %s, which is not installed in %s", library, env.TargetLibDir),
+			Remediation: fmt.Sprintf("DROP FUNCTION %s; -- or install %s for the target cluster on every host",
+				function, library),
+		})
+	}
+
+	return failures, rows.Err()
+}
+
+// libraryExists looks for a library as the server would load it, expanding
+// $libdir and trying the shared library suffix.
+func libraryExists(libDir, library string) bool {
+	path := library
+	switch {
+	case strings.HasPrefix(path, "$libdir/"):
+		path = filepath.Join(libDir, strings.TrimPrefix(path, "$libdir/"))
+	case !strings.Contains(path, "/"):
+		path = filepath.Join(libDir, path)
+	}
+
+	for _, candidate := range []string{path, path + ".so"} {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RunCatalogChecks runs the checks against each database, returning a result
+// for every check in the order given.
+func RunCatalogChecks(ctx context.Context, checks []CatalogCheck, envs []CheckEnv) ([]CheckResult, error) {
+	results := make([]CheckResult, len(checks))
+	for i, check := range checks {
+		results[i] = CheckResult{Name: check.Name(), Description: check.Description()}
+	}
+
+	for _, env := range envs {
+		for i, check := range checks {
+			failures, err := check.Check(ctx, env)
+			if err != nil {
+				return nil, xerrors.Errorf("running check %s on database %s: %w", check.Name(), env.Database, err)
+			}
+			results[i].Failures = append(results[i].Failures, failures...)
+		}
+	}
+
+	return results, nil
+}
+
+// openDatabase connects to a database of the source cluster in utility mode.
+var openDatabase = func(port int, database string) (*sql.DB, error) {
+	return sql.Open("pgx", fmt.Sprintf("postgresql://localhost:%d/%s?gp_session_role=utility&search_path=", port, url.PathEscape(database)))
+}
+
+// pgConfigLibDir returns the directory of a cluster's loadable libraries.
+func pgConfigLibDir(binDir string) (string, error) {
+	cmd := execCommand(filepath.Join(binDir, "pg_config"), "--pkglibdir")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", xerrors.Errorf("finding the library directory of %s: %w", binDir, err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CheckSourceCatalog runs CatalogChecks against every database of the source
+// cluster. When any of them fail, the SQL that resolves the failures is
+// written to the state directory, and its path is returned with the results.
+func (s *Server) CheckSourceCatalog(ctx context.Context) (_ []CheckResult, remediationFile string, err error) {
+	results, err := s.runCatalogChecks(ctx, CatalogChecks)
+	if err != nil {
+		return nil, "", err
+	}
+
+	passed := true
+	for _, result := range results {
+		passed = passed && result.Passed()
+	}
+
+	if passed {
+		return results, "", nil
+	}
+
+	path := filepath.Join(s.StateDir, remediationFileName)
+	if err := ioutil.WriteFile(path, remediationScript(results), 0644); err != nil {
+		return nil, "", xerrors.Errorf("writing remediation statements: %w", err)
+	}
+
+	return results, path, nil
+}
+
+const remediationFileName = "catalog_check_remediation.sql"
+
+func (s *Server) runCatalogChecks(ctx context.Context, checks []CatalogCheck) (_ []CheckResult, err error) {
+	libDir, err := pgConfigLibDir(s.Target.BinDir)
+	if err != nil {
+		return nil, err
+	}
+
+	port := s.Source.MasterPort()
+
+	template1, err := openDatabase(port, "template1")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := template1.Close(); cerr != nil {
+			err = multierror.Append(err, cerr).ErrorOrNil()
+		}
+	}()
+
+	databases, err := connectableDatabases(ctx, template1)
+	if err != nil {
+		return nil, xerrors.Errorf("listing databases: %w", err)
+	}
+
+	var envs []CheckEnv
+	for _, database := range databases {
+		db, err := openDatabase(port, database)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if cerr := db.Close(); cerr != nil {
+				err = multierror.Append(err, cerr).ErrorOrNil()
+			}
+		}()
+
+		envs = append(envs, CheckEnv{
+			DB:           db,
+			Database:     database,
+			Version:      s.Source.Version,
+			TargetLibDir: libDir,
+		})
+	}
+
+	return RunCatalogChecks(ctx, checks, envs)
+}
+
+func connectableDatabases(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT datname FROM pg_catalog.pg_database WHERE datallowconn ORDER BY 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var databases []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		databases = append(databases, name)
+	}
+
+	return databases, rows.Err()
+}
+
+// remediationScript returns a psql script of the statements that resolve the
+// failures, grouped by database.
+func remediationScript(results []CheckResult) []byte {
+	byDatabase := make(map[string][]CheckFailure)
+	for _, result := range results {
+		for _, f := range result.Failures {
+			byDatabase[f.Database] = append(byDatabase[f.Database], f)
+		}
+	}
+
+	var databases []string
+	for database := range byDatabase {
+		databases = append(databases, database)
+	}
+	sort.Strings(databases)
+
+	var b bytes.Buffer
+	b.WriteString("-- Statements that resolve the failures of the gpupgrade catalog checks.\n")
+	b.WriteString("-- Review them before running this script with psql -f.\n")
+
+	for _, database := range databases {
+		fmt.Fprintf(&b, "\n\\connect %s\n", quoteIdent(database))
+		for _, f := range byDatabase[database] {
+			fmt.Fprintf(&b, "\n-- %s: %s\n%s\n", f.Object, f.Problem, f.Remediation)
+		}
+	}
+
+	return b.Bytes()
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,359 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+)
+
+const targetLibDir = "/usr/local/greenplum-db-6/lib/postgresql"
+
+func PgConfigMain() {
+	fmt.Println(targetLibDir)
+}
+
+func init() {
+	exectest.RegisterMains(
+		PgConfigMain,
+	)
+}
+
+func TestSQLCheck(t *testing.T) {
+	check := SQLCheck{
+		CheckName:     "fake",
+		SourceVersion: "5",
+		Query:         "SELECT object, problem, remediation FROM fake",
+	}
+
+	t.Run("returns a failure for each row of the query", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("creating sqlmock: %+v", err)
+		}
+		defer testutils.FinishMock(mock, t)
+
+		mock.ExpectQuery("SELECT object, problem, remediation FROM fake").WillReturnRows(
+			sqlmock.NewRows([]string{"object", "problem", "remediation"}).
+				AddRow("public.t", "is bad", "DROP TABLE public.t;").
+				AddRow("public.u", "is worse", "DROP TABLE public.u;"),
+		)
+
+		env := CheckEnv{DB: db, Database: "postgres", Version: dbconn.NewVersion("5.28.0")}
+		failures, err := check.Check(context.Background(), env)
+		if err != nil {
+			t.Fatalf("Check() returned error %+v", err)
+		}
+
+		expected := []CheckFailure{
+			{Database: "postgres", Object: "public.t", Problem: "is bad", Remediation: "DROP TABLE public.t;"},
+			{Database: "postgres", Object: "public.u", Problem: "is worse", Remediation: "DROP TABLE public.u;"},
+		}
+		if !reflect.DeepEqual(failures, expected) {
+			t.Errorf("got failures %v want %v", failures, expected)
+		}
+	})
+
+	t.Run("skips source clusters of other versions", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("creating sqlmock: %+v", err)
+		}
+		defer testutils.FinishMock(mock, t)
+
+		env := CheckEnv{DB: db, Database: "postgres", Version: dbconn.NewVersion("6.10.0")}
+		failures, err := check.Check(context.Background(), env)
+		if err != nil {
+			t.Fatalf("Check() returned error %+v", err)
+		}
+
+		if len(failures) != 0 {
+			t.Errorf("got failures %v, want none", failures)
+		}
+	})
+
+	t.Run("returns query errors", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("creating sqlmock: %+v", err)
+		}
+		defer testutils.FinishMock(mock, t)
+
+		expected := errors.New("relation does not exist")
+		mock.ExpectQuery(".*").WillReturnError(expected)
+
+		env := CheckEnv{DB: db, Database: "postgres", Version: dbconn.NewVersion("5.28.0")}
+		_, err = check.Check(context.Background(), env)
+		if !xerrors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+	})
+}
+
+func TestMissingLibrariesCheck(t *testing.T) {
+	libDir := testutils.GetTempDir(t, "lib")
+	defer testutils.MustRemoveAll(t, libDir)
+
+	for _, name := range []string{"installed.so", "plain"} {
+		if err := ioutil.WriteFile(filepath.Join(libDir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("creating sqlmock: %+v", err)
+	}
+	defer testutils.FinishMock(mock, t)
+
+	mock.ExpectQuery("SELECT DISTINCT p.probin").WillReturnRows(
+		sqlmock.NewRows([]string{"probin", "function"}).
+			AddRow("$libdir/installed", "public.installed()").
+			AddRow("plain", "public.plain(integer)").
+			AddRow("$libdir/postgis-2.1", "public.st_area(geometry)").
+			AddRow("/opt/lib/custom.so", "public.custom()"),
+	)
+
+	env := CheckEnv{DB: db, Database: "postgres", TargetLibDir: libDir}
+	failures, err := missingLibrariesCheck{}.Check(context.Background(), env)
+	if err != nil {
+		t.Fatalf("Check() returned error %+v", err)
+	}
+
+	expected := []CheckFailure{
+		{
+			Database:    "postgres",
+			Object:      "public.st_area(geometry)",
+			Problem:     fmt.Sprintf("function uses the library $libdir/postgis-2.1, which is not installed in %s", libDir),
+			Remediation: "DROP FUNCTION public.st_area(geometry); -- or install $libdir/postgis-2.1 for the target cluster on every host",
+		},
+		{
+			Database:    "postgres",
+			Object:      "public.custom()",
+			Problem:     fmt.Sprintf("function uses the library /opt/lib/custom.so, which is not installed in %s", libDir),
+			Remediation: "DROP FUNCTION public.custom(); -- or install /opt/lib/custom.so for the target cluster on every host",
+		},
+	}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Errorf("got failures %v want %v", failures, expected)
+	}
+}
+
+// fakeCheck fails every database named in failing.
+type fakeCheck struct {
+	name    string
+	failing map[string]bool
+	err     error
+}
+
+func (f fakeCheck) Name() string        { return f.name }
+func (f fakeCheck) Description() string { return f.name + " things" }
+
+func (f fakeCheck) Check(_ context.Context, env CheckEnv) ([]CheckFailure, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if !f.failing[env.Database] {
+		return nil, nil
+	}
+
+	return []CheckFailure{{
+		Database:    env.Database,
+		Object:      "public." + f.name,
+		Problem:     "is " + f.name,
+		Remediation: "DROP TABLE public." + f.name + ";",
+	}}, nil
+}
+
+func TestRunCatalogChecks(t *testing.T) {
+	envs := []CheckEnv{{Database: "postgres"}, {Database: "template1"}}
+
+	t.Run("gathers the failures of each check across the databases", func(t *testing.T) {
+		checks := []CatalogCheck{
+			fakeCheck{name: "good"},
+			fakeCheck{name: "bad", failing: map[string]bool{"postgres": true, "template1": true}},
+		}
+
+		results, err := RunCatalogChecks(context.Background(), checks, envs)
+		if err != nil {
+			t.Fatalf("RunCatalogChecks() returned error %+v", err)
+		}
+
+		if len(results) != 2 {
+			t.Fatalf("got %d results want 2", len(results))
+		}
+
+		if !results[0].Passed() || results[0].Name != "good" {
+			t.Errorf("got result %+v, want check good to pass", results[0])
+		}
+
+		if results[1].Passed() || len(results[1].Failures) != 2 {
+			t.Errorf("got result %+v, want check bad to fail twice", results[1])
+		}
+	})
+
+	t.Run("returns the error of a check", func(t *testing.T) {
+		expected := errors.New("connection reset")
+		checks := []CatalogCheck{fakeCheck{name: "broken", err: expected}}
+
+		_, err := RunCatalogChecks(context.Background(), checks, envs)
+		if !xerrors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+	})
+}
+
+func TestCheckSourceCatalog(t *testing.T) {
+	source := MustCreateCluster(t, []greenplum.SegConfig{
+		{ContentID: -1, DbID: 1, Port: 15432, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: "p"},
+	})
+	source.Version = dbconn.NewVersion("5.28.0")
+
+	SetExecCommand(exectest.NewCommand(PgConfigMain))
+	defer ResetExecCommand()
+
+	checks := CatalogChecks
+	defer func() { CatalogChecks = checks }()
+
+	// mockDatabases sets up openDatabase to connect to a mock of each of the
+	// databases, which all exist.
+	mockDatabases := func(t *testing.T, databases ...string) func() {
+		t.Helper()
+
+		mocks := make(map[string]sqlmock.Sqlmock)
+		dbs := make(map[string]*sql.DB)
+		for _, name := range append([]string{"template1"}, databases...) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("creating sqlmock: %+v", err)
+			}
+			dbs[name], mocks[name] = db, mock
+		}
+
+		rows := sqlmock.NewRows([]string{"datname"})
+		for _, name := range databases {
+			rows.AddRow(name)
+		}
+		mocks["template1"].ExpectQuery("SELECT datname FROM pg_catalog.pg_database").WillReturnRows(rows)
+		for _, mock := range mocks {
+			mock.ExpectClose()
+		}
+
+		openDatabase = func(port int, database string) (*sql.DB, error) {
+			if port != 15432 {
+				t.Errorf("got port %d want %d", port, 15432)
+			}
+			return dbs[database], nil
+		}
+
+		return func() {
+			openDatabase = nil
+			for _, mock := range mocks {
+				testutils.FinishMock(mock, t)
+			}
+		}
+	}
+
+	t.Run("returns the results of the checks that pass", func(t *testing.T) {
+		defer mockDatabases(t, "postgres")()
+
+		stateDir := testutils.GetTempDir(t, "state")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		CatalogChecks = []CatalogCheck{fakeCheck{name: "good"}}
+		s := New(&Config{Source: source, Target: &greenplum.Cluster{BinDir: "/target/bin"}}, nil, stateDir)
+
+		results, remediationFile, err := s.CheckSourceCatalog(context.Background())
+		if err != nil {
+			t.Errorf("CheckSourceCatalog() returned error %+v", err)
+		}
+
+		expected := []CheckResult{{Name: "good", Description: "good things"}}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("got results %+v want %+v", results, expected)
+		}
+
+		if remediationFile != "" {
+			t.Errorf("got remediation file %q, want none", remediationFile)
+		}
+
+		path := filepath.Join(stateDir, remediationFileName)
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %q not to exist, stat returned %v", path, err)
+		}
+	})
+
+	t.Run("writes the remediation statements of the checks that fail", func(t *testing.T) {
+		defer mockDatabases(t, "postgres", "my db")()
+
+		stateDir := testutils.GetTempDir(t, "state")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		CatalogChecks = []CatalogCheck{
+			fakeCheck{name: "good"},
+			fakeCheck{name: "bad", failing: map[string]bool{"postgres": true, "my db": true}},
+		}
+		s := New(&Config{Source: source, Target: &greenplum.Cluster{BinDir: "/target/bin"}}, nil, stateDir)
+
+		results, remediationFile, err := s.CheckSourceCatalog(context.Background())
+		if err != nil {
+			t.Fatalf("CheckSourceCatalog() returned error %+v", err)
+		}
+
+		if len(results) != 2 || !results[0].Passed() || len(results[1].Failures) != 2 {
+			t.Errorf("got results %+v, want check bad to fail on both databases", results)
+		}
+
+		path := filepath.Join(stateDir, remediationFileName)
+		if remediationFile != path {
+			t.Errorf("got remediation file %q want %q", remediationFile, path)
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, line := range []string{`\connect "my db"`, `\connect "postgres"`, "-- public.bad: is bad", "DROP TABLE public.bad;"} {
+			if !strings.Contains(string(contents), line+"\n") {
+				t.Errorf("remediation script %q does not contain %q", contents, line)
+			}
+		}
+		if strings.Index(string(contents), `\connect "my db"`) > strings.Index(string(contents), `\connect "postgres"`) {
+			t.Errorf("expected the databases of remediation script %q to be in order", contents)
+		}
+	})
+
+	t.Run("returns errors finding the target library directory", func(t *testing.T) {
+		SetExecCommand(exectest.NewCommand(Failure))
+		defer SetExecCommand(exectest.NewCommand(PgConfigMain))
+
+		s := New(&Config{Source: source, Target: &greenplum.Cluster{BinDir: "/target/bin"}}, nil, "")
+
+		_, _, err := s.CheckSourceCatalog(context.Background())
+
+		var exitErr *exec.ExitError
+		if !xerrors.As(err, &exitErr) {
+			t.Errorf("got error %#v, want type %T", err, exitErr)
+		}
+	})
+}
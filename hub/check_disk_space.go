@@ -6,6 +6,7 @@ package hub
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"sync"
 
 	multierror "github.com/hashicorp/go-multierror"
@@ -13,67 +14,201 @@ import (
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils"
 	"github.com/greenplum-db/gpupgrade/utils/disk"
 )
 
 func (s *Server) CheckDiskSpace(ctx context.Context, in *idl.CheckDiskSpaceRequest) (*idl.CheckDiskSpaceReply, error) {
 	reply := new(idl.CheckDiskSpaceReply)
 
+	// A host that isn't checked could run out of space part way through the
+	// upgrade, so every agent is needed.
 	agents, err := s.AgentConns()
 	if err != nil {
 		return reply, err
 	}
 
-	reply.Failed, err = checkDiskSpace(ctx, s.Source, agents, disk.Local, in)
+	if in.Estimate {
+		reply.Failed, err = estimateDiskSpace(ctx, s.Source, s.Tablespaces, agents, disk.Local, in, s.UseLinkMode, s.StateDir)
+		return reply, err
+	}
+
+	reply.Failed, err = checkDiskSpace(ctx, s.Source, s.Tablespaces, agents, disk.Local, in)
 	return reply, err
 }
 
-func checkDiskSpace(ctx context.Context, cluster *greenplum.Cluster, agents []*Connection, d disk.Disk, in *idl.CheckDiskSpaceRequest) (disk.SpaceFailures, error) {
+// checkDiskSpace checks the free space of the filesystems holding the data
+// directories and user tablespaces of the source cluster. Hosts with primaries
+// also check where the master tablespaces will be copied.
+//
+// Tablespaces are only recorded for GPDB 5 sources, whose data directories
+// don't link to their tablespaces from pg_tblspc.
+func checkDiskSpace(ctx context.Context, cluster *greenplum.Cluster, tablespaces greenplum.Tablespaces, agents []*Connection, d disk.Disk, in *idl.CheckDiskSpaceRequest) (disk.SpaceFailures, error) {
+	masterTablespaces := tablespaces.GetMasterTablespaces().UserDefinedLocations()
+
+	checkMaster := func() (disk.SpaceFailures, error) {
+		paths := append([]string{cluster.MasterDataDir()}, masterTablespaces...)
+		return disk.CheckUsage(d, in.Ratio, paths...)
+	}
+
+	segmentRequest := func(segments []greenplum.SegConfig) *idl.CheckSegmentDiskSpaceRequest {
+		req := &idl.CheckSegmentDiskSpaceRequest{
+			Request: in,
+		}
+		for _, s := range segments {
+			req.Datadirs = append(req.Datadirs, s.DataDir)
+			req.Tablespaces = append(req.Tablespaces, tablespaces[s.DbID].UserDefinedLocations()...)
+
+			if s.IsPrimary() && len(masterTablespaces) > 0 {
+				req.MasterTablespaces = &idl.CheckSegmentDiskSpaceRequest_MasterCopy{
+					Dir: utils.GetTablespaceDir(),
+				}
+			}
+		}
+		return req
+	}
+
+	return gatherDiskSpace(ctx, cluster, agents, checkMaster, segmentRequest)
+}
+
+// estimateDiskSpace checks that each filesystem has room for the upgrade, as
+// estimated from the size of the data on it. Besides the target cluster, the
+// master host needs room in the state directory for a backup of the target
+// master, and each primary host for copies of the upgraded master and of the
+// master tablespaces.
+func estimateDiskSpace(ctx context.Context, cluster *greenplum.Cluster, tablespaces greenplum.Tablespaces, agents []*Connection, d disk.Disk, in *idl.CheckDiskSpaceRequest, linkMode bool, stateDir string) (disk.SpaceFailures, error) {
+	masterTablespaces := tablespaces.GetMasterTablespaces().UserDefinedLocations()
+
+	// The copy of the upgraded master holds only its data directory; any
+	// links in pg_tblspc are copied as links.
+	master, err := disk.Measure(d, cluster.MasterDataDir())
+	if err != nil {
+		return nil, xerrors.Errorf("check disk space on master host: %w", err)
+	}
+
+	masterTablespaceSize, err := disk.MeasureAll(d, masterTablespaces...)
+	if err != nil {
+		return nil, xerrors.Errorf("check disk space on master host: %w", err)
+	}
+
+	checkMaster := func() (disk.SpaceFailures, error) {
+		var needs []disk.Need
+		for _, path := range append([]string{cluster.MasterDataDir()}, masterTablespaces...) {
+			n, err := disk.UpgradeNeeds(d, path, linkMode)
+			if err != nil {
+				return nil, err
+			}
+			needs = append(needs, n...)
+		}
+
+		// The backup is taken of the newly created target master, which has
+		// a catalog but no user data yet.
+		needs = append(needs, disk.Need{Path: stateDir, Bytes: master.Other, Files: master.OtherFiles})
+		return disk.CheckNeeds(d, needs...)
+	}
+
+	segmentRequest := func(segments []greenplum.SegConfig) *idl.CheckSegmentDiskSpaceRequest {
+		req := &idl.CheckSegmentDiskSpaceRequest{
+			Request:  in,
+			LinkMode: linkMode,
+		}
+		for _, s := range segments {
+			locations := tablespaces[s.DbID].UserDefinedLocations()
+
+			if s.IsMaster() {
+				req.Datadirs = append(req.Datadirs, s.DataDir)
+				req.Tablespaces = append(req.Tablespaces, locations...)
+				req.TargetMasterBackup = &idl.CheckSegmentDiskSpaceRequest_MasterCopy{
+					Dir:   stateDir,
+					Bytes: master.Other,
+					Files: master.OtherFiles,
+				}
+				continue
+			}
+
+			if !s.IsPrimary() {
+				req.MirrorDatadirs = append(req.MirrorDatadirs, s.DataDir)
+				req.MirrorTablespaces = append(req.MirrorTablespaces, locations...)
+				continue
+			}
+
+			req.Datadirs = append(req.Datadirs, s.DataDir)
+			req.Tablespaces = append(req.Tablespaces, locations...)
+			req.MasterBackup = &idl.CheckSegmentDiskSpaceRequest_MasterCopy{
+				Dir:   filepath.Join(stateDir, executeMasterBackupName),
+				Bytes: master.Total(),
+				Files: master.TotalFiles(),
+			}
+
+			if len(masterTablespaces) > 0 {
+				req.MasterTablespaces = &idl.CheckSegmentDiskSpaceRequest_MasterCopy{
+					Dir:   utils.GetTablespaceDir(),
+					Bytes: masterTablespaceSize.Total(),
+					Files: masterTablespaceSize.TotalFiles(),
+				}
+			}
+		}
+		return req
+	}
+
+	return gatherDiskSpace(ctx, cluster, agents, checkMaster, segmentRequest)
+}
+
+// gatherDiskSpace sends each agent the request made by segmentRequest for the
+// segments on its host. When the master shares its host with other segments,
+// it is included in that agent's request, so that the needs of a filesystem
+// they share are added up; otherwise checkMaster is run on the master host.
+// It returns the failures of every host.
+func gatherDiskSpace(ctx context.Context, cluster *greenplum.Cluster, agents []*Connection, checkMaster func() (disk.SpaceFailures, error), segmentRequest func([]greenplum.SegConfig) *idl.CheckSegmentDiskSpaceRequest) (disk.SpaceFailures, error) {
 	var wg sync.WaitGroup
 	errs := make(chan error, len(agents)+1)
 	failures := make(chan disk.SpaceFailures, len(agents)+1)
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	masterHost := cluster.GetHostForContent(-1)
 
-		failed, err := disk.CheckUsage(d, in.Ratio, cluster.MasterDataDir())
-		if err != nil {
-			errs <- xerrors.Errorf("check disk space on master host: %w", err)
+	masterHasAgent := false
+	for _, agent := range agents {
+		if agent.Hostname == masterHost {
+			masterHasAgent = true
 		}
+	}
 
-		if len(failed) > 0 {
-			masterHost := cluster.GetHostForContent(-1)
-			failures <- prefixWith(masterHost, failed)
-		}
-	}()
+	if !masterHasAgent {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			failed, err := checkMaster()
+			if err != nil {
+				errs <- xerrors.Errorf("check disk space on master host: %w", err)
+			}
+
+			if len(failed) > 0 {
+				failures <- prefixWith(masterHost, failed)
+			}
+		}()
+	}
 
 	for i := range agents {
 		agent := agents[i]
 		wg.Add(1)
 
-		// We want to check disk space for the standby, primaries, and mirrors.
-		excludingMaster := func(seg *greenplum.SegConfig) bool {
-			return seg.IsOnHost(agent.Hostname) && !seg.IsMaster()
+		// We want to check disk space for the standby, primaries, and mirrors,
+		// and for the master when it is on this host.
+		onHost := func(seg *greenplum.SegConfig) bool {
+			return seg.IsOnHost(agent.Hostname)
 		}
 
 		go func() {
 			defer wg.Done()
 
-			segments := cluster.SelectSegments(excludingMaster)
+			segments := cluster.SelectSegments(onHost)
 			if len(segments) == 0 {
 				errs <- greenplum.UnknownHostError{Hostname: agent.Hostname}
 				return
 			}
 
-			req := &idl.CheckSegmentDiskSpaceRequest{
-				Request: in,
-			}
-			for _, s := range segments {
-				req.Datadirs = append(req.Datadirs, s.DataDir)
-			}
-
-			reply, err := agent.AgentClient.CheckDiskSpace(ctx, req)
+			reply, err := agent.AgentClient.CheckDiskSpace(ctx, segmentRequest(segments))
 			if err != nil {
 				errs <- xerrors.Errorf("check disk space on host %s: %w", agent.Hostname, err)
 				return
@@ -7,6 +7,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
@@ -21,12 +24,15 @@ import (
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/utils"
 	"github.com/greenplum-db/gpupgrade/utils/disk"
 )
 
 func TestCheckDiskSpace(t *testing.T) {
 	var d halfFullDisk
 	var c *greenplum.Cluster
+	var tablespaces greenplum.Tablespaces
 	var agents []*Connection
 	var req *idl.CheckDiskSpaceRequest
 	ctx := context.Background()
@@ -38,7 +44,7 @@ func TestCheckDiskSpace(t *testing.T) {
 	check := func(t *testing.T, expected disk.SpaceFailures) {
 		t.Helper()
 
-		actual, err := checkDiskSpace(ctx, c, agents, d, req)
+		actual, err := checkDiskSpace(ctx, c, tablespaces, agents, d, req)
 		if err != nil {
 			t.Errorf("returned error %#v", err)
 		}
@@ -148,6 +154,90 @@ func TestCheckDiskSpace(t *testing.T) {
 		})
 	})
 
+	t.Run("checks the user tablespaces of each segment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		c = MustCreateCluster(t, []greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Hostname: "mdw", DataDir: "/data/master", Role: "p"},
+			{ContentID: 0, DbID: 2, Hostname: "sdw1", DataDir: "/data/primary", Role: "p"},
+			{ContentID: 0, DbID: 3, Hostname: "sdw2", DataDir: "/data/mirror", Role: "m"},
+		})
+		tablespaces = greenplum.Tablespaces{
+			1: {
+				1663:  {Location: "/data/master", UserDefined: 0},
+				16386: {Location: "/tblspc/16386/1", UserDefined: 1},
+			},
+			2: {
+				1663:  {Location: "/data/primary", UserDefined: 0},
+				16386: {Location: "/tblspc/16386/2", UserDefined: 1},
+			},
+			3: {
+				1663:  {Location: "/data/mirror", UserDefined: 0},
+				16386: {Location: "/tblspc/16386/3", UserDefined: 1},
+			},
+		}
+		defer func() { tablespaces = nil }()
+		req = &idl.CheckDiskSpaceRequest{Ratio: 0.25}
+
+		// Hosts with primaries also check where the master tablespaces are
+		// copied to.
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().
+			CheckDiskSpace(ctx, &idl.CheckSegmentDiskSpaceRequest{
+				Request:     req,
+				Datadirs:    []string{"/data/primary"},
+				Tablespaces: []string{"/tblspc/16386/2"},
+				MasterTablespaces: &idl.CheckSegmentDiskSpaceRequest_MasterCopy{
+					Dir: utils.GetTablespaceDir(),
+				},
+			}).
+			Return(&idl.CheckDiskSpaceReply{}, nil)
+
+		sdw2 := mock_idl.NewMockAgentClient(ctrl)
+		sdw2.EXPECT().
+			CheckDiskSpace(ctx, &idl.CheckSegmentDiskSpaceRequest{
+				Request:     req,
+				Datadirs:    []string{"/data/mirror"},
+				Tablespaces: []string{"/tblspc/16386/3"},
+			}).
+			Return(&idl.CheckDiskSpaceReply{}, nil)
+
+		agents = []*Connection{
+			{Hostname: "sdw1", AgentClient: sdw1},
+			{Hostname: "sdw2", AgentClient: sdw2},
+		}
+
+		check(t, disk.SpaceFailures{})
+	})
+
+	t.Run("checks the master with the segments on its host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		c = MustCreateCluster(t, []greenplum.SegConfig{
+			{ContentID: -1, Hostname: "mdw", DataDir: "/data/master", Role: "p"},
+			{ContentID: 0, Hostname: "mdw", DataDir: "/data/primary", Role: "p"},
+		})
+		d.err = errors.New("the master host should not be checked by the hub")
+		defer func() { d.err = nil }()
+		req = &idl.CheckDiskSpaceRequest{Ratio: 0.25}
+
+		mdw := mock_idl.NewMockAgentClient(ctrl)
+		mdw.EXPECT().
+			CheckDiskSpace(ctx, equivalentRequest(&idl.CheckSegmentDiskSpaceRequest{
+				Request:  req,
+				Datadirs: []string{"/data/master", "/data/primary"},
+			})).
+			Return(&idl.CheckDiskSpaceReply{}, nil)
+
+		agents = []*Connection{
+			{Hostname: "mdw", AgentClient: mdw},
+		}
+
+		check(t, disk.SpaceFailures{})
+	})
+
 	t.Run("bubbles up any errors in parallel", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -177,13 +267,230 @@ func TestCheckDiskSpace(t *testing.T) {
 			{Hostname: "sdw2", AgentClient: sdw2}, // invalid hostname
 		}
 
-		_, err := checkDiskSpace(ctx, c, agents, d, req)
+		_, err := checkDiskSpace(ctx, c, nil, agents, d, req)
 
 		expected := []error{d.err, agentErr, greenplum.ErrUnknownHost}
 		checkMultierrorContents(t, err, expected)
 	})
 }
 
+func TestEstimateDiskSpace(t *testing.T) {
+	ctx := context.Background()
+	testhelper.SetupTestLogger()
+
+	// halfFullDisk gives its sizes in KiB, as gosigar does, so 512 MiB are
+	// available.
+	var d halfFullDisk
+
+	// The master has 300 MiB of user relations and 150 MiB of other files.
+	// The files are sparse, so they take no space.
+	masterDir := testutils.GetTempDir(t, "master")
+	defer testutils.MustRemoveAll(t, masterDir)
+
+	mustCreateFile(t, filepath.Join(masterDir, "base", "16384", "16390"), 300*1024*1024)
+	mustCreateFile(t, filepath.Join(masterDir, "global", "1262"), 150*1024*1024)
+
+	stateDir := testutils.GetTempDir(t, "")
+	defer testutils.MustRemoveAll(t, stateDir)
+
+	cluster := MustCreateCluster(t, []greenplum.SegConfig{
+		{ContentID: -1, DbID: 1, Hostname: "mdw", DataDir: masterDir, Role: "p"},
+		{ContentID: -1, DbID: 2, Hostname: "smdw", DataDir: "/data/standby", Role: "m"},
+		{ContentID: 0, DbID: 3, Hostname: "sdw1", DataDir: "/data/primary", Role: "p"},
+	})
+
+	// The master backup holds the five directories and files of the master
+	// other than its user relation.
+	masterBackup := &idl.CheckSegmentDiskSpaceRequest_MasterCopy{
+		Dir:   filepath.Join(stateDir, executeMasterBackupName),
+		Bytes: 450 * 1024 * 1024,
+		Files: 6,
+	}
+
+	req := &idl.CheckDiskSpaceRequest{Estimate: true}
+
+	cases := []struct {
+		name     string
+		linkMode bool
+		expected disk.SpaceFailures
+	}{
+		{
+			// The master needs 450 MiB for its copy and 150 MiB for the backup
+			// of the target master.
+			"copy mode", false,
+			disk.SpaceFailures{
+				"mdw: /": &idl.CheckDiskSpaceReply_DiskUsage{
+					Required:  600 * 1024,
+					Available: 512 * 1024,
+				},
+			},
+		},
+		{
+			// Only the 150 MiB of other files are copied.
+			"link mode", true,
+			disk.SpaceFailures{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			smdw := mock_idl.NewMockAgentClient(ctrl)
+			smdw.EXPECT().
+				CheckDiskSpace(ctx, &idl.CheckSegmentDiskSpaceRequest{
+					Request:        req,
+					MirrorDatadirs: []string{"/data/standby"},
+					LinkMode:       c.linkMode,
+				}).
+				Return(&idl.CheckDiskSpaceReply{}, nil)
+
+			// Primary hosts also need room for the upgraded master.
+			sdw1 := mock_idl.NewMockAgentClient(ctrl)
+			sdw1.EXPECT().
+				CheckDiskSpace(ctx, &idl.CheckSegmentDiskSpaceRequest{
+					Request:      req,
+					Datadirs:     []string{"/data/primary"},
+					LinkMode:     c.linkMode,
+					MasterBackup: masterBackup,
+				}).
+				Return(&idl.CheckDiskSpaceReply{}, nil)
+
+			agents := []*Connection{
+				{Hostname: "smdw", AgentClient: smdw},
+				{Hostname: "sdw1", AgentClient: sdw1},
+			}
+
+			actual, err := estimateDiskSpace(ctx, cluster, nil, agents, d, req, c.linkMode, stateDir)
+			if err != nil {
+				t.Errorf("returned error %#v", err)
+			}
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("returned %v want %v", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("includes the user tablespaces", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// The master tablespace has 100 MiB of user relations, which need
+		// to be copied to the primary hosts.
+		tablespaceDir := testutils.GetTempDir(t, "tablespace")
+		defer testutils.MustRemoveAll(t, tablespaceDir)
+
+		mustCreateFile(t, filepath.Join(tablespaceDir, "16384", "16400"), 100*1024*1024)
+
+		tablespaces := greenplum.Tablespaces{
+			1: {16386: {Location: tablespaceDir, UserDefined: 1}},
+			2: {16386: {Location: "/tblspc/16386/2", UserDefined: 1}},
+			3: {16386: {Location: "/tblspc/16386/3", UserDefined: 1}},
+		}
+
+		smdw := mock_idl.NewMockAgentClient(ctrl)
+		smdw.EXPECT().
+			CheckDiskSpace(ctx, &idl.CheckSegmentDiskSpaceRequest{
+				Request:           req,
+				MirrorDatadirs:    []string{"/data/standby"},
+				MirrorTablespaces: []string{"/tblspc/16386/2"},
+			}).
+			Return(&idl.CheckDiskSpaceReply{}, nil)
+
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().
+			CheckDiskSpace(ctx, &idl.CheckSegmentDiskSpaceRequest{
+				Request:      req,
+				Datadirs:     []string{"/data/primary"},
+				Tablespaces:  []string{"/tblspc/16386/3"},
+				MasterBackup: masterBackup,
+				MasterTablespaces: &idl.CheckSegmentDiskSpaceRequest_MasterCopy{
+					Dir:   utils.GetTablespaceDir(),
+					Bytes: 100 * 1024 * 1024,
+					Files: 3,
+				},
+			}).
+			Return(&idl.CheckDiskSpaceReply{}, nil)
+
+		agents := []*Connection{
+			{Hostname: "smdw", AgentClient: smdw},
+			{Hostname: "sdw1", AgentClient: sdw1},
+		}
+
+		// The master now needs another 100 MiB for its tablespace, or 700 MiB
+		// in all.
+		actual, err := estimateDiskSpace(ctx, cluster, tablespaces, agents, d, req, false, stateDir)
+		if err != nil {
+			t.Errorf("returned error %#v", err)
+		}
+
+		expected := disk.SpaceFailures{
+			"mdw: /": &idl.CheckDiskSpaceReply_DiskUsage{
+				Required:  700 * 1024,
+				Available: 512 * 1024,
+			},
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("returned %v want %v", actual, expected)
+		}
+	})
+	t.Run("sends the needs of the master to the agent on its host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cluster := MustCreateCluster(t, []greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Hostname: "mdw", DataDir: masterDir, Role: "p"},
+			{ContentID: 0, DbID: 2, Hostname: "mdw", DataDir: "/data/primary", Role: "p"},
+		})
+
+		// The master host's agent adds up the needs of the master, its backup
+		// and the primary, instead of the hub checking the master alone.
+		mdw := mock_idl.NewMockAgentClient(ctrl)
+		mdw.EXPECT().
+			CheckDiskSpace(ctx, equivalentRequest(&idl.CheckSegmentDiskSpaceRequest{
+				Request:      req,
+				Datadirs:     []string{masterDir, "/data/primary"},
+				MasterBackup: masterBackup,
+				TargetMasterBackup: &idl.CheckSegmentDiskSpaceRequest_MasterCopy{
+					Dir:   stateDir,
+					Bytes: 150 * 1024 * 1024,
+					Files: 5,
+				},
+			})).
+			Return(&idl.CheckDiskSpaceReply{}, nil)
+
+		agents := []*Connection{
+			{Hostname: "mdw", AgentClient: mdw},
+		}
+
+		actual, err := estimateDiskSpace(ctx, cluster, nil, agents, d, req, false, stateDir)
+		if err != nil {
+			t.Errorf("returned error %#v", err)
+		}
+		if len(actual) != 0 {
+			t.Errorf("returned %v want no failures", actual)
+		}
+	})
+}
+
+// mustCreateFile creates a sparse file of the given size.
+func mustCreateFile(t *testing.T, path string, size int64) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("creating %s: %+v", filepath.Dir(path), err)
+	}
+
+	if err := ioutil.WriteFile(path, nil, 0600); err != nil {
+		t.Fatalf("writing %s: %+v", path, err)
+	}
+
+	if err := os.Truncate(path, size); err != nil {
+		t.Fatalf("extending %s: %+v", path, err)
+	}
+}
+
 // halfFullDisk is a stub implementation of disk.Disk. It has one 1MiB root
 // filesystem with 50% utilization and no reserved space.
 type halfFullDisk struct {
@@ -222,6 +529,29 @@ func (d halfFullDisk) Stat(path string) (*unix.Stat_t, error) {
 	return &unix.Stat_t{Dev: 1}, nil
 }
 
+// Walk walks the real filesystem, so that tests can measure data directories
+// that they create. Directories that don't exist are empty.
+func (d halfFullDisk) Walk(root string, fn filepath.WalkFunc) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	if _, err := os.Lstat(root); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.Walk(root, fn)
+}
+
+// Inodes reports that half of the inodes are free.
+func (d halfFullDisk) Inodes(path string) (disk.InodeUsage, error) {
+	if d.err != nil {
+		return disk.InodeUsage{}, d.err
+	}
+
+	return disk.InodeUsage{Total: d.Size(), Free: d.Size() / 2}, nil
+}
+
 func (_ halfFullDisk) Size() uint64 {
 	return 1024 * 1024
 }
@@ -4,6 +4,7 @@
 package hub
 
 import (
+	"context"
 	"sync"
 
 	"github.com/hashicorp/go-multierror"
@@ -13,30 +14,31 @@ import (
 )
 
 type UpgradeChecker interface {
-	UpgradeMaster(args UpgradeMasterArgs) error
-	UpgradePrimaries(args UpgradePrimaryArgs) error
+	UpgradeMaster(ctx context.Context, args UpgradeMasterArgs) error
+	UpgradePrimaries(ctx context.Context, args UpgradePrimaryArgs) error
 }
 
 type upgradeChecker struct{}
 
-func (upgradeChecker) UpgradeMaster(args UpgradeMasterArgs) error {
-	return UpgradeMaster(args)
+func (upgradeChecker) UpgradeMaster(ctx context.Context, args UpgradeMasterArgs) error {
+	return UpgradeMaster(ctx, args)
 }
 
-func (upgradeChecker) UpgradePrimaries(args UpgradePrimaryArgs) error {
-	return UpgradePrimaries(args)
+func (upgradeChecker) UpgradePrimaries(ctx context.Context, args UpgradePrimaryArgs) error {
+	_, err := UpgradePrimaries(ctx, args)
+	return err
 }
 
 var upgrader UpgradeChecker = upgradeChecker{}
 
-func (s *Server) CheckUpgrade(stream step.OutStreams, conns []*Connection) error {
+func (s *Server) CheckUpgrade(ctx context.Context, stream step.OutStreams, conns []*Connection) error {
 	var wg sync.WaitGroup
 	checkErrs := make(chan error, 2)
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		checkErrs <- upgrader.UpgradeMaster(UpgradeMasterArgs{
+		checkErrs <- upgrader.UpgradeMaster(ctx, UpgradeMasterArgs{
 			Source:      s.Source,
 			Target:      s.Target,
 			StateDir:    s.StateDir,
@@ -56,14 +58,17 @@ func (s *Server) CheckUpgrade(stream step.OutStreams, conns []*Connection) error
 			return
 		}
 
-		checkErrs <- upgrader.UpgradePrimaries(UpgradePrimaryArgs{
-			CheckOnly:       true,
-			MasterBackupDir: "",
-			AgentConns:      conns,
-			DataDirPairMap:  dataDirPairMap,
-			Source:          s.Source,
-			Target:          s.Target,
-			UseLinkMode:     s.UseLinkMode,
+		checkErrs <- upgrader.UpgradePrimaries(ctx, UpgradePrimaryArgs{
+			Stream:             stream,
+			CheckOnly:          true,
+			MasterBackupDir:    "",
+			AgentConns:         conns,
+			DataDirPairMap:     dataDirPairMap,
+			Source:             s.Source,
+			Target:             s.Target,
+			UseLinkMode:        s.UseLinkMode,
+			MaxUpgradesPerHost: s.MaxUpgradesPerHost,
+			MaxUpgrades:        s.MaxUpgrades,
 		})
 	}()
 
@@ -4,6 +4,7 @@
 package hub
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
@@ -17,11 +18,11 @@ type upgraderMock struct {
 	s *Server
 }
 
-func (u upgraderMock) UpgradeMaster(args UpgradeMasterArgs) error {
+func (u upgraderMock) UpgradeMaster(_ context.Context, args UpgradeMasterArgs) error {
 	return UpgradeMasterMock(args, u.s)
 }
 
-func (u upgraderMock) UpgradePrimaries(args UpgradePrimaryArgs) error {
+func (u upgraderMock) UpgradePrimaries(_ context.Context, args UpgradePrimaryArgs) error {
 	return UpgradePrimariesMock(args, u.s)
 }
 
@@ -50,9 +51,11 @@ func TestMasterIsCheckedLinkModeTrue(t *testing.T) {
 	for _, linkMode := range []bool{true, false} {
 		t.Run(fmt.Sprintf("check upgrade correctly passes useLinkMode is %v", linkMode), func(t *testing.T) {
 			conf := &Config{
-				Source:      sourceCluster,
-				Target:      targetCluster,
-				UseLinkMode: linkMode,
+				Source:             sourceCluster,
+				Target:             targetCluster,
+				UseLinkMode:        linkMode,
+				MaxUpgradesPerHost: 2,
+				MaxUpgrades:        3,
 			}
 			s := New(conf, grpc.DialContext, stateDirExpected)
 			testUpgraderMock := upgraderMock{s}
@@ -60,7 +63,7 @@ func TestMasterIsCheckedLinkModeTrue(t *testing.T) {
 			setUpgrader(testUpgraderMock)
 			defer resetUpgrader()
 
-			err := s.CheckUpgrade(nil, connections)
+			err := s.CheckUpgrade(context.Background(), nil, connections)
 
 			if err != nil {
 				t.Errorf("got error: %+v", err) // yes, '%+v'; '%#v' prints opaque multierror
@@ -112,5 +115,9 @@ func UpgradePrimariesMock(result UpgradePrimaryArgs, expected *Server) error {
 	if result.UseLinkMode != expected.UseLinkMode {
 		return fmt.Errorf("got %#v expected %#v", result.UseLinkMode, expected.UseLinkMode)
 	}
+	if result.MaxUpgradesPerHost != expected.MaxUpgradesPerHost || result.MaxUpgrades != expected.MaxUpgrades {
+		return fmt.Errorf("got limits %d and %d expected %d and %d",
+			result.MaxUpgradesPerHost, result.MaxUpgrades, expected.MaxUpgradesPerHost, expected.MaxUpgrades)
+	}
 	return nil
 }
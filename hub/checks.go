@@ -0,0 +1,217 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"github.com/hashicorp/go-multierror"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// ErrNotInitialized is returned by RunCheck until initialize has saved the
+// configuration of the clusters that the checks need.
+var ErrNotInitialized = status.Error(codes.FailedPrecondition,
+	`the checks need the cluster configuration saved by "gpupgrade initialize"; run it first`)
+
+// RunCheck runs one of the pre-upgrade checks that need the hub. Its reply
+// lists the failures of the check; an error means the check could not be run.
+func (s *Server) RunCheck(ctx context.Context, in *idl.RunCheckRequest) (*idl.RunCheckReply, error) {
+	if s.Source == nil || s.Target == nil {
+		return nil, ErrNotInitialized
+	}
+
+	switch in.Check {
+	case idl.RunCheckRequest_SEGMENT_STATUS:
+		failures, err := s.checkSegmentStatus(ctx)
+		return &idl.RunCheckReply{Failures: failures}, err
+
+	case idl.RunCheckRequest_PORTS_FREE:
+		return s.checkPortsFree(ctx)
+
+	case idl.RunCheckRequest_SSH_REACHABILITY:
+		failures := checkSSH(ctx, s.RemoteShell.Executor(), sortedHosts(s.Source.GetHostnames()))
+		return &idl.RunCheckReply{Failures: failures}, nil
+
+	case idl.RunCheckRequest_VERSION_COMPATIBILITY:
+		failures, err := checkVersionCompatibility(s.Source.Version, s.Target.BinDir)
+		return &idl.RunCheckReply{Failures: failures}, err
+
+	case idl.RunCheckRequest_SOURCE_CATALOG:
+		results, remediationFile, err := s.CheckSourceCatalog(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		reply := new(idl.RunCheckReply)
+		for _, result := range results {
+			for _, f := range result.Failures {
+				reply.Failures = append(reply.Failures, fmt.Sprintf("%s: %s: %s: %s", result.Name, f.Database, f.Object, f.Problem))
+			}
+		}
+		if remediationFile != "" {
+			reply.Remediation = fmt.Sprintf("Review and run the statements in %s to resolve the failures.", remediationFile)
+		}
+		return reply, nil
+
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown check %v", in.Check)
+	}
+}
+
+// checkPortsFree finds the ports of the target cluster that are in use, and
+// that initialize cannot replace with free ones.
+func (s *Server) checkPortsFree(ctx context.Context) (*idl.RunCheckReply, error) {
+	conns, err := s.AgentConns()
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, err = s.assignFreeTargetPorts(ctx, conns, func(string, int) {})
+
+	var inUseErr PortsInUseError
+	if !xerrors.As(err, &inUseErr) {
+		return &idl.RunCheckReply{}, err
+	}
+
+	reply := &idl.RunCheckReply{Remediation: "Free the ports, or choose other ports with --temp-port-range."}
+	for _, host := range sortedHostsOf(inUseErr.InUse) {
+		for _, port := range inUseErr.InUse[host] {
+			reply.Failures = append(reply.Failures, fmt.Sprintf("port %d on host %s is in use", port, host))
+		}
+	}
+
+	return reply, nil
+}
+
+// checkSegmentStatus finds the segments of the source cluster that are down,
+// or that are not in their preferred role.
+func (s *Server) checkSegmentStatus(ctx context.Context) (_ []string, err error) {
+	db, err := openDatabase(s.Source.MasterPort(), "template1")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := db.Close(); cerr != nil {
+			err = multierror.Append(err, cerr).ErrorOrNil()
+		}
+	}()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT dbid, content, hostname, status = 'u', role = preferred_role
+		FROM pg_catalog.gp_segment_configuration
+		WHERE status <> 'u' OR role <> preferred_role
+		ORDER BY content, dbid`)
+	if err != nil {
+		return nil, xerrors.Errorf("querying segment status: %w", err)
+	}
+	defer rows.Close()
+
+	var failures []string
+	for rows.Next() {
+		var dbid, content int
+		var hostname string
+		var up, preferred bool
+		if err := rows.Scan(&dbid, &content, &hostname, &up, &preferred); err != nil {
+			return nil, err
+		}
+
+		segment := fmt.Sprintf("segment with dbid %d (content %d) on host %s", dbid, content, hostname)
+		if !up {
+			failures = append(failures, segment+" is down")
+		}
+		if !preferred {
+			failures = append(failures, segment+" is not in its preferred role; rebalance the cluster with gprecoverseg -r")
+		}
+	}
+
+	return failures, rows.Err()
+}
+
+// checkSSH finds the hosts that the executor cannot reach without a password.
+func checkSSH(ctx context.Context, executor RemoteExecutor, hosts []string) []string {
+	// Fail rather than prompt for a password, or wait on an unresponsive host.
+	if ssh, ok := executor.(SSHExecutor); ok {
+		ssh.Options = append([]string{"BatchMode=yes", "ConnectTimeout=10"}, ssh.Options...)
+		executor = ssh
+	}
+
+	failures := make([]string, len(hosts))
+
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		i, host := i, host // capture range variables
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := runRemote(ctx, executor, host, "true", nil, nil, nil)
+			if err != nil {
+				failures[i] = fmt.Sprintf("host %s cannot be reached over ssh: %s", host, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var unreachable []string
+	for _, f := range failures {
+		if f != "" {
+			unreachable = append(unreachable, f)
+		}
+	}
+
+	return unreachable
+}
+
+var gpVersionPattern = regexp.MustCompile(`\(Greenplum Database\) (\d+\.\d+\.\d+)`)
+
+// binaryVersion returns the Greenplum version of the postgres binary in
+// binDir.
+func binaryVersion(binDir string) (dbconn.GPDBVersion, error) {
+	path := filepath.Join(binDir, "postgres")
+
+	output, err := execCommand(path, "--gp-version").Output()
+	if err != nil {
+		return dbconn.GPDBVersion{}, xerrors.Errorf("finding the version of %s: %w", path, err)
+	}
+
+	match := gpVersionPattern.FindStringSubmatch(string(output))
+	if match == nil {
+		return dbconn.GPDBVersion{}, xerrors.Errorf("parsing the version of %s from %q", path, strings.TrimSpace(string(output)))
+	}
+
+	return dbconn.NewVersion(match[1]), nil
+}
+
+// checkVersionCompatibility makes sure the target binaries are either a newer
+// release of the same major version as the source cluster, or the next major
+// version.
+func checkVersionCompatibility(source dbconn.GPDBVersion, targetBinDir string) ([]string, error) {
+	target, err := binaryVersion(targetBinDir)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceMajor, targetMajor := source.SemVer.Major, target.SemVer.Major
+	switch {
+	case targetMajor == sourceMajor+1:
+		return nil, nil
+	case targetMajor == sourceMajor && !target.SemVer.LT(source.SemVer):
+		return nil, nil
+	}
+
+	return []string{fmt.Sprintf("cannot upgrade from Greenplum %s to Greenplum %s in %s",
+		source.SemVer, target.SemVer, targetBinDir)}, nil
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+)
+
+func PostgresGPVersionMain() {
+	fmt.Println("postgres (Greenplum Database) 6.10.0 build commit:1234")
+}
+
+func PostgresGarbageVersionMain() {
+	fmt.Println("postgres (PostgreSQL) 12.4")
+}
+
+func init() {
+	exectest.RegisterMains(
+		PostgresGPVersionMain,
+		PostgresGarbageVersionMain,
+	)
+}
+
+func TestRunCheck(t *testing.T) {
+	t.Run("reports the segments that are down or not in their preferred role", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("creating sqlmock: %+v", err)
+		}
+		defer testutils.FinishMock(mock, t)
+
+		openDatabase = func(port int, database string) (*sql.DB, error) {
+			return db, nil
+		}
+		defer func() { openDatabase = nil }()
+
+		mock.ExpectQuery("SELECT dbid, content, hostname").WillReturnRows(
+			sqlmock.NewRows([]string{"dbid", "content", "hostname", "up", "preferred"}).
+				AddRow(2, 0, "sdw1", false, true).
+				AddRow(5, 1, "sdw2", true, false),
+		)
+		mock.ExpectClose()
+
+		source := MustCreateCluster(t, []greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Port: 15432, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: "p"},
+		})
+		s := New(&Config{Source: source, Target: source}, nil, "")
+
+		reply, err := s.RunCheck(context.Background(), &idl.RunCheckRequest{Check: idl.RunCheckRequest_SEGMENT_STATUS})
+		if err != nil {
+			t.Fatalf("RunCheck() returned error %+v", err)
+		}
+
+		expected := []string{
+			"segment with dbid 2 (content 0) on host sdw1 is down",
+			"segment with dbid 5 (content 1) on host sdw2 is not in its preferred role; rebalance the cluster with gprecoverseg -r",
+		}
+		if !reflect.DeepEqual(reply.Failures, expected) {
+			t.Errorf("got failures %q want %q", reply.Failures, expected)
+		}
+	})
+
+	t.Run("errors for an unknown check", func(t *testing.T) {
+		conf := &Config{Source: &greenplum.Cluster{}, Target: &greenplum.Cluster{}}
+		_, err := New(conf, nil, "").RunCheck(context.Background(), &idl.RunCheckRequest{})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("got error %+v want code %v", err, codes.InvalidArgument)
+		}
+	})
+
+	t.Run("errors before initialize has saved the cluster configuration", func(t *testing.T) {
+		_, err := New(&Config{}, nil, "").RunCheck(context.Background(), &idl.RunCheckRequest{
+			Check: idl.RunCheckRequest_SSH_REACHABILITY,
+		})
+		if err != ErrNotInitialized {
+			t.Errorf("got error %+v want %+v", err, ErrNotInitialized)
+		}
+	})
+}
+
+func TestCheckSSH(t *testing.T) {
+	t.Run("connects to each host without a password", func(t *testing.T) {
+		var hosts []string
+		SetExecCommand(exectest.NewCommandWithVerifier(Success, func(name string, args ...string) {
+			expected := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10"}
+			if name != "ssh" || !reflect.DeepEqual(args[:len(expected)], expected) {
+				t.Errorf("got command %q %q", name, args)
+			}
+			hosts = append(hosts, args[len(expected)])
+		}))
+		defer ResetExecCommand()
+
+		failures := checkSSH(context.Background(), SSHExecutor{}, []string{"sdw1"})
+		if len(failures) != 0 {
+			t.Errorf("got failures %q, want none", failures)
+		}
+
+		if !reflect.DeepEqual(hosts, []string{"sdw1"}) {
+			t.Errorf("got hosts %q want %q", hosts, []string{"sdw1"})
+		}
+	})
+
+	t.Run("connects using the remote shell", func(t *testing.T) {
+		shell := RemoteShell{TrustedShell: "/usr/local/bin/ssh", User: "gpadmin", Options: []string{"Port=2222"}}
+
+		SetExecCommand(exectest.NewCommandWithVerifier(Success, func(name string, args ...string) {
+			expected := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10", "-o", "Port=2222", "gpadmin@sdw1", "true"}
+			if name != "/usr/local/bin/ssh" || !reflect.DeepEqual(args, expected) {
+				t.Errorf("got command %q %q want /usr/local/bin/ssh %q", name, args, expected)
+			}
+		}))
+		defer ResetExecCommand()
+
+		failures := checkSSH(context.Background(), shell.Executor(), []string{"sdw1"})
+		if len(failures) != 0 {
+			t.Errorf("got failures %q, want none", failures)
+		}
+
+		if !reflect.DeepEqual(shell.Options, []string{"Port=2222"}) {
+			t.Errorf("got options %q, want the remote shell to be left unchanged", shell.Options)
+		}
+	})
+
+	t.Run("reports the hosts that cannot be reached", func(t *testing.T) {
+		SetExecCommand(exectest.NewCommand(Failure))
+		defer ResetExecCommand()
+
+		failures := checkSSH(context.Background(), SSHExecutor{}, []string{"sdw1", "sdw2"})
+		if len(failures) != 2 {
+			t.Fatalf("got failures %q, want one for each host", failures)
+		}
+
+		for i, host := range []string{"sdw1", "sdw2"} {
+			if !strings.HasPrefix(failures[i], "host "+host+" cannot be reached over ssh") {
+				t.Errorf("got failure %q, want it to name host %s", failures[i], host)
+			}
+		}
+	})
+}
+
+func TestCheckVersionCompatibility(t *testing.T) {
+	SetExecCommand(exectest.NewCommand(PostgresGPVersionMain))
+	defer ResetExecCommand()
+
+	cases := []struct {
+		source     string
+		compatible bool
+	}{
+		{"5.28.0", true},
+		{"6.9.0", true},
+		{"6.10.0", true},
+		{"6.11.0", false},
+		{"4.3.33", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.source, func(t *testing.T) {
+			failures, err := checkVersionCompatibility(dbconn.NewVersion(c.source), "/target/bin")
+			if err != nil {
+				t.Fatalf("checkVersionCompatibility() returned error %+v", err)
+			}
+
+			if c.compatible && len(failures) != 0 {
+				t.Errorf("got failures %q, want none", failures)
+			}
+
+			if !c.compatible {
+				expected := []string{fmt.Sprintf("cannot upgrade from Greenplum %s to Greenplum 6.10.0 in /target/bin", c.source)}
+				if !reflect.DeepEqual(failures, expected) {
+					t.Errorf("got failures %q want %q", failures, expected)
+				}
+			}
+		})
+	}
+
+	t.Run("errors when the version of the target cannot be parsed", func(t *testing.T) {
+		SetExecCommand(exectest.NewCommand(PostgresGarbageVersionMain))
+		defer SetExecCommand(exectest.NewCommand(PostgresGPVersionMain))
+
+		_, err := checkVersionCompatibility(dbconn.NewVersion("6.10.0"), "/target/bin")
+		if err == nil || !strings.Contains(err.Error(), "PostgreSQL") {
+			t.Errorf("got error %v, want it to contain the version output", err)
+		}
+	})
+}
@@ -4,32 +4,34 @@
 package hub
 
 import (
+	"context"
 	"testing"
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
 )
 
 // Set it to nil so we don't accidentally execute a command for real during tests
 func init() {
 	ResetExecCommand()
-	ResetRsyncExecCommand()
+	ResetSyncDirectory()
 }
 
 func SetExecCommand(cmdFunc exectest.Command) {
 	execCommand = cmdFunc
 }
 
-func SetRsyncExecCommand(cmdFunc exectest.Command) {
-	execCommandRsync = cmdFunc
+func SetSyncDirectory(sync func(context.Context, string, string, dirsync.Options) (dirsync.Stats, error)) {
+	syncDirectory = sync
 }
 
 func ResetExecCommand() {
 	execCommand = nil
 }
 
-func ResetRsyncExecCommand() {
-	execCommandRsync = nil
+func ResetSyncDirectory() {
+	syncDirectory = nil
 }
 
 // failingWriter is an io.Writer for which all calls to Write() return an error.
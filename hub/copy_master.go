@@ -4,7 +4,8 @@
 package hub
 
 import (
-	"bytes"
+	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -12,46 +13,54 @@ import (
 
 	"github.com/hashicorp/go-multierror"
 	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
 
+	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
+var copiedBytes = metrics.Default.NewCounter("gpupgrade_hub_copied_bytes_total",
+	"Size of the files copied from the master to each host.", "host")
+
+// copyChunkSize is the most file contents sent to an agent in one message.
+const copyChunkSize = 64 * 1024
+
 type Result struct {
-	stdout bytes.Buffer
-	stderr bytes.Buffer
-	err    error
+	host  string
+	stats *idl.SyncStats
+	err   error
 }
 
-func Copy(streams step.OutStreams, destinationDir string, sourceDirs, hosts []string) error {
+// Copy mirrors the sources into destinationDir on the host of each agent,
+// streaming them through the agent. As with rsync, a source ending in a slash
+// is copied as its contents, and any other source as itself.
+func Copy(ctx context.Context, streams step.OutStreams, destinationDir string, sourceDirs []string, conns []*Connection) error {
 	/*
 	 * Copy the directories once per host.
 	 */
 	var wg sync.WaitGroup
 
-	results := make(chan *Result, len(hosts))
+	results := make(chan *Result, len(conns))
 
-	for _, hostname := range hosts {
-		hostname := hostname // capture range variable
+	for _, conn := range conns {
+		conn := conn // capture range variable
 
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 
-			dest := fmt.Sprintf("%s:%s", hostname, destinationDir)
-			args := []string{"--archive", "--compress", "--delete", "--stats"}
-			args = append(args, sourceDirs...)
-			args = append(args, dest)
-			cmd := execCommand("rsync", args...)
-
-			result := Result{}
-			cmd.Stdout = &result.stdout
-			cmd.Stderr = &result.stderr
+			result := Result{host: conn.Hostname}
 
-			err := cmd.Run()
+			stats, err := copyToAgent(ctx, conn.AgentClient, destinationDir, sourceDirs)
 			if err != nil {
-				err = xerrors.Errorf("copying source %q to destination %q on host %s: %w", sourceDirs, destinationDir, hostname, err)
+				err = xerrors.Errorf("copying source %q to destination %q on host %s: %w", sourceDirs, destinationDir, conn.Hostname, err)
 				result.err = err
 			}
+			result.stats = stats
+
 			results <- &result
 		}()
 	}
@@ -62,34 +71,199 @@ func Copy(streams step.OutStreams, destinationDir string, sourceDirs, hosts []st
 	var multierr *multierror.Error
 
 	for result := range results {
-		if _, err := io.Copy(streams.Stdout(), &result.stdout); err != nil {
-			multierr = multierror.Append(multierr, err)
+		if result.err != nil {
+			multierr = multierror.Append(multierr, result.err)
+			continue
 		}
 
-		if _, err := io.Copy(streams.Stderr(), &result.stderr); err != nil {
+		copiedBytes.Add(float64(result.stats.Bytes), result.host)
+
+		_, err := fmt.Fprintf(streams.Stdout(), "%s: copied %d files (%d bytes) to %s, deleted %d\n",
+			result.host, result.stats.Files, result.stats.Bytes, destinationDir, result.stats.Deleted)
+		if err != nil {
 			multierr = multierror.Append(multierr, err)
 		}
+	}
 
-		if result.err != nil {
-			multierr = multierror.Append(multierr, result.err)
+	return multierr.ErrorOrNil()
+}
+
+// copyToAgent streams the sources to an agent, which mirrors them into
+// destinationDir and deletes anything else there. The entries of the sources
+// are sent first, and the contents of only the files that the agent needs.
+// The stream is compressed.
+func copyToAgent(ctx context.Context, client idl.AgentClient, destinationDir string, sourceDirs []string) (*idl.SyncStats, error) {
+	opts := dirsync.Options{Delete: true}
+
+	stream, err := client.SyncDirectory(ctx, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	send := func(request *idl.SyncDirectoryRequest) error {
+		err := stream.Send(request)
+		if err == io.EOF {
+			// The agent has ended the stream. Its error is returned from
+			// the next receive.
+			_, err = stream.Recv()
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
 		}
+		return err
 	}
 
-	return multierr.ErrorOrNil()
+	err = send(&idl.SyncDirectoryRequest{Contents: &idl.SyncDirectoryRequest_Options{
+		Options: dirsync.OptionsToProto(destinationDir, opts),
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[string]dirsync.OpenFunc)
+	err = dirsync.Walk(ctx, sourceDirs, opts, func(e dirsync.Entry, open dirsync.OpenFunc) error {
+		if open != nil {
+			contents[e.Path] = open
+		}
+
+		return send(&idl.SyncDirectoryRequest{Contents: &idl.SyncDirectoryRequest_Entry{
+			Entry: dirsync.ToProto(e),
+		}})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = send(&idl.SyncDirectoryRequest{Contents: &idl.SyncDirectoryRequest_EntriesDone{
+		EntriesDone: true,
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	needed, err := receiveNeeded(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range needed {
+		open, ok := contents[path]
+		if !ok {
+			return nil, xerrors.Errorf("agent asked for the contents of %q, which is not a regular file of the sources", path)
+		}
+
+		err := send(&idl.SyncDirectoryRequest{Contents: &idl.SyncDirectoryRequest_ContentsOf{
+			ContentsOf: path,
+		}})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := sendContents(send, open); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	reply, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := reply.GetStats()
+	if stats == nil {
+		return nil, xerrors.Errorf("expected the stats of the copy, got %T", reply.Contents)
+	}
+
+	return stats, nil
+}
+
+// receiveNeeded returns the paths of the files whose contents the agent needs.
+func receiveNeeded(stream idl.Agent_SyncDirectoryClient) ([]string, error) {
+	var paths []string
+
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		needed := reply.GetNeeded()
+		if needed == nil {
+			return nil, xerrors.Errorf("expected the files needed by the agent, got %T", reply.Contents)
+		}
+
+		paths = append(paths, needed.Paths...)
+		if needed.Done {
+			return paths, nil
+		}
+	}
+}
+
+// sendContents sends the contents of a file in chunks, followed by their
+// checksum.
+func sendContents(send func(*idl.SyncDirectoryRequest) error, open dirsync.OpenFunc) error {
+	f, err := open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	buf := make([]byte, copyChunkSize)
+
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			hash.Write(buf[:n])
+
+			if err := send(&idl.SyncDirectoryRequest{Contents: &idl.SyncDirectoryRequest_Data{
+				Data: append([]byte(nil), buf[:n]...),
+			}}); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return send(&idl.SyncDirectoryRequest{Contents: &idl.SyncDirectoryRequest_Checksum{
+		Checksum: hash.Sum(nil),
+	}})
 }
 
-func (s *Server) CopyMasterDataDir(streams step.OutStreams, destination string) error {
-	// Make sure sourceDir ends with a trailing slash so that rsync will
-	// transfer the directory contents and not the directory itself.
-	source := []string{filepath.Clean(s.Target.MasterDataDir()) + string(filepath.Separator)}
-	return Copy(streams, destination, source, s.Target.PrimaryHostnames())
+// CopyMasterDataDir copies the upgraded master to each primary host, and
+// verifies the copies against the checksums of the master's files.
+func (s *Server) CopyMasterDataDir(ctx context.Context, streams step.OutStreams, destination string, conns []*Connection) error {
+	return copyAndVerify(ctx, streams, destination, s.masterDataDirSource(), s.targetPrimaryConns(conns))
 }
 
-func (s *Server) CopyMasterTablespaces(streams step.OutStreams, destinationDir string) error {
+func (s *Server) masterDataDirSource() []string {
+	// Make sure sourceDir ends with a trailing slash so that the directory
+	// contents are copied and not the directory itself.
+	return []string{filepath.Clean(s.Target.MasterDataDir()) + string(filepath.Separator)}
+}
+
+func (s *Server) CopyMasterTablespaces(ctx context.Context, streams step.OutStreams, destinationDir string, conns []*Connection) error {
 	if s.Tablespaces == nil {
 		return nil
 	}
 
+	return copyAndVerify(ctx, streams, destinationDir, s.masterTablespaceSources(), s.targetPrimaryConns(conns))
+}
+
+func (s *Server) masterTablespaceSources() []string {
 	// include tablespace mapping file which is used as a parameter to pg_upgrade
 	sourcePaths := []string{s.TablespacesMappingFilePath}
 
@@ -101,5 +275,31 @@ func (s *Server) CopyMasterTablespaces(streams step.OutStreams, destinationDir s
 		sourcePaths = append(sourcePaths, tablespace.Location)
 	}
 
-	return Copy(streams, destinationDir, sourcePaths, s.Target.PrimaryHostnames())
+	return sourcePaths
+}
+
+func copyAndVerify(ctx context.Context, streams step.OutStreams, destinationDir string, sourceDirs []string, conns []*Connection) error {
+	if err := Copy(ctx, streams, destinationDir, sourceDirs, conns); err != nil {
+		return err
+	}
+
+	return VerifyCopy(ctx, destinationDir, sourceDirs, conns)
+}
+
+// targetPrimaryConns returns the connections to the hosts of the target
+// cluster's primaries.
+func (s *Server) targetPrimaryConns(conns []*Connection) []*Connection {
+	hosts := make(map[string]bool)
+	for _, host := range s.Target.PrimaryHostnames() {
+		hosts[host] = true
+	}
+
+	var primaryConns []*Connection
+	for _, conn := range conns {
+		if hosts[conn.Hostname] {
+			primaryConns = append(primaryConns, conn)
+		}
+	}
+
+	return primaryConns
 }
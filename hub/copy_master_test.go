@@ -4,109 +4,163 @@
 package hub
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"errors"
-	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"reflect"
-	"sort"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
 	multierror "github.com/hashicorp/go-multierror"
 	"golang.org/x/xerrors"
 	"google.golang.org/grpc"
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
-	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
-const (
-	rsyncExitCode     int    = 23 // rsync returns 23 for a partial transfer
-	rsyncErrorMessage string = `rsync: recv_generator: mkdir "/tmp/master_copy/gpseg-1" failed: Permission denied(13)
-*** Skipping any contents from this failed directory ***
-rsync error: some files/attrs were not transferred (see previous errors) (code 23) atmain.c(1052) [sender=3.0.9]
-`
-)
+func TestCopy(t *testing.T) {
+	sourceDir := testutils.GetTempDir(t, "copy-source")
+	defer testutils.MustRemoveAll(t, sourceDir)
 
-func RsyncFailure() {
-	fmt.Fprint(os.Stderr, rsyncErrorMessage)
-	os.Exit(rsyncExitCode)
-}
+	if err := ioutil.WriteFile(filepath.Join(sourceDir, "hello"), []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
 
-func init() {
-	exectest.RegisterMains(
-		RsyncFailure,
-	)
-}
+	t.Run("streams the sources to each host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
-func TestCopy(t *testing.T) {
-	t.Run("copies the directory only once per host", func(t *testing.T) {
+		host1, requests1 := expectSync(ctrl, []string{"hello"}, &idl.SyncStats{}, nil)
+		host2, requests2 := expectSync(ctrl, []string{"hello"}, &idl.SyncStats{}, nil)
+		conns := []*Connection{
+			{AgentClient: host1, Hostname: "host1"},
+			{AgentClient: host2, Hostname: "host2"},
+		}
 
-		sourceDir := []string{"/data/qddir/seg-1/"}
-		targetHosts := []string{"localhost"}
+		err := Copy(context.Background(), utils.DevNull, "foobar/path", []string{sourceDir + "/"}, conns)
+		if err != nil {
+			t.Errorf("copying data directory: %+v", err)
+		}
 
-		// Validate the rsync call and arguments.
-		execCommand = exectest.NewCommandWithVerifier(Success, func(name string, args ...string) {
-			expected := "rsync"
-			if name != expected {
-				t.Errorf("Copy() invoked %q, want %q", name, expected)
-			}
+		checksum := sha256.Sum256([]byte("hello"))
+		expected := []*idl.SyncDirectoryRequest{
+			{Contents: &idl.SyncDirectoryRequest_Options{Options: &idl.SyncOptions{TargetDir: "foobar/path", Delete: true}}},
+			{Contents: &idl.SyncDirectoryRequest_Entry{Entry: &idl.FileEntry{Path: ".", Type: idl.FileEntry_DIRECTORY}}},
+			{Contents: &idl.SyncDirectoryRequest_Entry{Entry: &idl.FileEntry{Path: "hello", Type: idl.FileEntry_REGULAR, Size: 5}}},
+			{Contents: &idl.SyncDirectoryRequest_EntriesDone{EntriesDone: true}},
+			{Contents: &idl.SyncDirectoryRequest_ContentsOf{ContentsOf: "hello"}},
+			{Contents: &idl.SyncDirectoryRequest_Data{Data: []byte("hello")}},
+			{Contents: &idl.SyncDirectoryRequest_Checksum{Checksum: checksum[:]}},
+		}
 
-			expectedArgs := []string{
-				"--archive", "--compress", "--delete", "--stats",
-				"/data/qddir/seg-1/", "localhost:foobar/path",
-			}
-			if !reflect.DeepEqual(args, expectedArgs) {
-				t.Errorf("rsync invoked with %q, want %q", args, expectedArgs)
-			}
-		})
+		for _, requests := range [][]*idl.SyncDirectoryRequest{*requests1, *requests2} {
+			verifyRequests(t, requests, expected)
+		}
+	})
+
+	t.Run("only sends the contents of the files that the agent needs", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
-		err := Copy(utils.DevNull, "foobar/path", sourceDir, targetHosts)
+		client, requests := expectSync(ctrl, nil, &idl.SyncStats{}, nil)
+		conns := []*Connection{{AgentClient: client, Hostname: "sdw1"}}
+
+		err := Copy(context.Background(), utils.DevNull, "foobar/path", []string{sourceDir + "/"}, conns)
 		if err != nil {
 			t.Errorf("copying data directory: %+v", err)
 		}
+
+		expected := []*idl.SyncDirectoryRequest{
+			{Contents: &idl.SyncDirectoryRequest_Options{Options: &idl.SyncOptions{TargetDir: "foobar/path", Delete: true}}},
+			{Contents: &idl.SyncDirectoryRequest_Entry{Entry: &idl.FileEntry{Path: ".", Type: idl.FileEntry_DIRECTORY}}},
+			{Contents: &idl.SyncDirectoryRequest_Entry{Entry: &idl.FileEntry{Path: "hello", Type: idl.FileEntry_REGULAR, Size: 5}}},
+			{Contents: &idl.SyncDirectoryRequest_EntriesDone{EntriesDone: true}},
+		}
+		verifyRequests(t, *requests, expected)
 	})
 
-	t.Run("copies the data directory to each host", func(t *testing.T) {
-		// The verifier function can be called in parallel, so use a channel to
-		// communicate which hosts were actually used.
-		primaryHosts := []string{"host1", "host2"}
-		hosts := make(chan string, len(primaryHosts))
-		sourceDir := []string{"/data/qddir/seg-1"}
+	t.Run("refuses to send files that are not in the sources", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
-		expectedArgs := []string{
-			"--archive", "--compress", "--delete", "--stats",
-			"/data/qddir/seg-1", "foobar/path",
+		stream := mock_idl.NewMockAgent_SyncDirectoryClient(ctrl)
+		stream.EXPECT().Send(gomock.Any()).Return(nil).AnyTimes()
+		stream.EXPECT().Recv().Return(&idl.SyncDirectoryReply{Contents: &idl.SyncDirectoryReply_Needed{
+			Needed: &idl.NeededFiles{Paths: []string{"/etc/passwd"}, Done: true},
+		}}, nil)
+
+		client := mock_idl.NewMockAgentClient(ctrl)
+		client.EXPECT().SyncDirectory(gomock.Any(), gomock.Any()).Return(stream, nil)
+
+		conns := []*Connection{{AgentClient: client, Hostname: "sdw1"}}
+		err := Copy(context.Background(), utils.DevNull, "foobar/path", []string{sourceDir + "/"}, conns)
+		if err == nil || !strings.Contains(err.Error(), "/etc/passwd") {
+			t.Errorf("got error %v, want it to name /etc/passwd", err)
 		}
-		execCommandVerifier(t, hosts, expectedArgs)
+	})
+
+	t.Run("counts the bytes copied to each host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client, _ := expectSync(ctrl, nil, &idl.SyncStats{Bytes: 1024}, nil)
+		conns := []*Connection{{AgentClient: client, Hostname: "copied-host"}}
+
+		before := metricValue(t, `gpupgrade_hub_copied_bytes_total{host="copied-host"}`)
 
-		err := Copy(utils.DevNull, "foobar/path", sourceDir, primaryHosts)
+		err := Copy(context.Background(), utils.DevNull, "foobar/path", []string{sourceDir}, conns)
 		if err != nil {
 			t.Errorf("copying directory: %+v", err)
 		}
 
-		close(hosts)
+		after := metricValue(t, `gpupgrade_hub_copied_bytes_total{host="copied-host"}`)
+		if after-before != 1024 {
+			t.Errorf("got %v bytes copied, want 1024", after-before)
+		}
+	})
+
+	t.Run("writes a summary of the copy to each host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client, _ := expectSync(ctrl, nil, &idl.SyncStats{Files: 2, Bytes: 1024, Deleted: 1}, nil)
+		conns := []*Connection{{AgentClient: client, Hostname: "sdw1"}}
 
-		// Collect the hostnames for validation.
-		var actualHosts []string
-		for host := range hosts {
-			actualHosts = append(actualHosts, host)
+		buffer := new(bufferedStreams)
+		err := Copy(context.Background(), buffer, "foobar/path", []string{sourceDir}, conns)
+		if err != nil {
+			t.Errorf("copying directory: %+v", err)
 		}
-		sort.Strings(actualHosts) // receive order not guaranteed
 
-		expectedHosts := []string{"host1", "host2"}
-		if !reflect.DeepEqual(actualHosts, expectedHosts) {
-			t.Errorf("copied to hosts %q, want %q", actualHosts, expectedHosts)
+		expected := "sdw1: copied 2 files (1024 bytes) to foobar/path, deleted 1\n"
+		if buffer.stdout.String() != expected {
+			t.Errorf("got stdout %q want %q", buffer.stdout.String(), expected)
 		}
 	})
 
-	t.Run("returns errors when writing stdout and stderr buffers to the stream", func(t *testing.T) {
-		execCommand = exectest.NewCommand(StreamingMain)
+	t.Run("returns errors when writing to the stream", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client, _ := expectSync(ctrl, nil, &idl.SyncStats{}, nil)
+		conns := []*Connection{{AgentClient: client, Hostname: "localhost"}}
 		streams := failingStreams{errors.New("e")}
 
-		err := Copy(streams, "", nil, []string{"localhost"})
+		err := Copy(context.Background(), streams, "", []string{sourceDir}, conns)
 
 		// Make sure the errors are correctly propagated up.
 		var merr *multierror.Error
@@ -120,44 +174,73 @@ func TestCopy(t *testing.T) {
 		}
 	})
 
-	t.Run("serializes rsync failures to the log stream", func(t *testing.T) {
-		execCommand = exectest.NewCommand(RsyncFailure)
-		buffer := new(bufferedStreams)
-		hosts := []string{"mdw", "sdw1", "sdw2"}
+	t.Run("returns the errors from each host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
-		err := Copy(buffer, "foobar/path", nil, hosts)
+		expected := errors.New("permission denied")
+
+		var conns []*Connection
+		for _, host := range []string{"mdw", "sdw1", "sdw2"} {
+			client, _ := expectSync(ctrl, nil, nil, expected)
+			conns = append(conns, &Connection{AgentClient: client, Hostname: host})
+		}
+
+		buffer := new(bufferedStreams)
+		err := Copy(context.Background(), buffer, "foobar/path", []string{sourceDir}, conns)
 
-		// Make sure the errors are correctly propagated up.
 		var merr *multierror.Error
 		if !xerrors.As(err, &merr) {
 			t.Fatalf("returned %#v, want error type %T", err, merr)
 		}
-		var exitErr *exec.ExitError
+
+		if len(merr.Errors) != len(conns) {
+			t.Errorf("got %d errors want %d", len(merr.Errors), len(conns))
+		}
+
 		for _, err := range merr.Errors {
-			if !xerrors.As(err, &exitErr) || exitErr.ExitCode() != rsyncExitCode {
-				t.Errorf("returned error %#v, want exit code %d", err, rsyncExitCode)
+			if !xerrors.Is(err, expected) {
+				t.Errorf("returned error %#v, want %#v", err, expected)
 			}
 		}
 
-		stdout := buffer.stdout.String()
-		if len(stdout) != 0 {
-			t.Errorf("got stdout %q, expected no output", stdout)
+		if buffer.stdout.Len() != 0 {
+			t.Errorf("got stdout %q, expected no output", buffer.stdout.String())
 		}
+	})
+
+	t.Run("returns the agent's error when it ends the stream early", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
-		// Make sure we have as many copies of the stderr string as there are
-		// hosts. They should be serialized sanely, even though we may execute
-		// in parallel.
-		stderr := buffer.stderr.String()
-		expected := strings.Repeat(rsyncErrorMessage, len(hosts))
-		if stderr != expected {
-			t.Errorf("got stderr:\n%v\nwant:\n%v", stderr, expected)
+		expected := errors.New("no space left on device")
+
+		stream := mock_idl.NewMockAgent_SyncDirectoryClient(ctrl)
+		stream.EXPECT().Send(gomock.Any()).Return(io.EOF)
+		stream.EXPECT().Recv().Return(nil, expected)
+
+		client := mock_idl.NewMockAgentClient(ctrl)
+		client.EXPECT().SyncDirectory(gomock.Any(), gomock.Any()).Return(stream, nil)
+
+		conns := []*Connection{{AgentClient: client, Hostname: "sdw1"}}
+		err := Copy(context.Background(), utils.DevNull, "foobar/path", []string{sourceDir}, conns)
+
+		var merr *multierror.Error
+		if !xerrors.As(err, &merr) {
+			t.Fatalf("returned %#v, want error type %T", err, merr)
+		}
+		if !xerrors.Is(merr.Errors[0], expected) {
+			t.Errorf("returned error %#v, want %#v", merr.Errors[0], expected)
 		}
 	})
 }
 
 func TestCopyMasterDataDir(t *testing.T) {
+	masterDir := testutils.GetTempDir(t, "master")
+	defer testutils.MustRemoveAll(t, masterDir)
+
 	targetCluster := MustCreateCluster(t, []greenplum.SegConfig{
-		{ContentID: -1, DbID: 1, Port: 15432, Hostname: "localhost", DataDir: "/data/qddir/seg-1", Role: "p"},
+		{ContentID: -1, DbID: 1, Port: 15432, Hostname: "localhost", DataDir: masterDir, Role: "p"},
 		{ContentID: 0, DbID: 2, Port: 25432, Hostname: "host1", DataDir: "/data/dbfast1/seg1", Role: "p"},
 		{ContentID: 1, DbID: 3, Port: 25433, Hostname: "host2", DataDir: "/data/dbfast2/seg2", Role: "p"},
 	})
@@ -168,30 +251,85 @@ func TestCopyMasterDataDir(t *testing.T) {
 	hub := New(conf, grpc.DialContext, ".gpupgrade")
 
 	t.Run("copies the master data directory to each primary host", func(t *testing.T) {
-		// The verifier function can be called in parallel, so use a channel to
-		// communicate which hosts were actually used.
-		hosts := make(chan string, len(targetCluster.PrimaryHostnames()))
-
-		expectedArgs := []string{
-			"--archive", "--compress", "--delete", "--stats",
-			"/data/qddir/seg-1/", "foobar/path",
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// The master's host has no primaries, so nothing is copied to it.
+		host1, requests1 := expectSync(ctrl, nil, &idl.SyncStats{}, nil)
+		host2, requests2 := expectSync(ctrl, nil, &idl.SyncStats{}, nil)
+		verify1 := expectVerify(ctrl, host1, &idl.VerifyDirectoryReply{}, nil)
+		verify2 := expectVerify(ctrl, host2, &idl.VerifyDirectoryReply{}, nil)
+		conns := []*Connection{
+			{AgentClient: mock_idl.NewMockAgentClient(ctrl), Hostname: "localhost"},
+			{AgentClient: host1, Hostname: "host1"},
+			{AgentClient: host2, Hostname: "host2"},
 		}
 
-		execCommandVerifier(t, hosts, expectedArgs)
-
-		err := hub.CopyMasterDataDir(utils.DevNull, "foobar/path")
+		err := hub.CopyMasterDataDir(context.Background(), utils.DevNull, "foobar/path", conns)
 		if err != nil {
 			t.Errorf("copying master data directory: %+v", err)
 		}
 
-		close(hosts)
+		for _, requests := range [][]*idl.SyncDirectoryRequest{*requests1, *requests2} {
+			expected := []string{"."}
+			if paths := entryPaths(requests); !reflect.DeepEqual(paths, expected) {
+				t.Errorf("got paths %q want %q", paths, expected)
+			}
+		}
 
-		expectedHosts := []string{"host1", "host2"}
-		verifyHosts(hosts, expectedHosts, t)
+		for _, requests := range [][]*idl.VerifyDirectoryRequest{*verify1, *verify2} {
+			expected := []string{"."}
+			if paths := manifestPaths(requests); !reflect.DeepEqual(paths, expected) {
+				t.Errorf("got manifest paths %q want %q", paths, expected)
+			}
+		}
+	})
+
+	t.Run("fails when a copy differs from the master", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		host1, _ := expectSync(ctrl, nil, &idl.SyncStats{}, nil)
+		host2, _ := expectSync(ctrl, nil, &idl.SyncStats{}, nil)
+		expectVerify(ctrl, host1, &idl.VerifyDirectoryReply{}, nil)
+		expectVerify(ctrl, host2, &idl.VerifyDirectoryReply{Mismatches: []*idl.Mismatch{
+			{Path: "global/pg_control", Reason: idl.Mismatch_CHANGED, Detail: "checksum differs"},
+		}}, nil)
+		conns := []*Connection{
+			{AgentClient: host1, Hostname: "host1"},
+			{AgentClient: host2, Hostname: "host2"},
+		}
+
+		err := hub.CopyMasterDataDir(context.Background(), utils.DevNull, "foobar/path", conns)
+
+		var mismatchErr CopyMismatchError
+		if !xerrors.As(err, &mismatchErr) {
+			t.Fatalf("got error %#v, want type %T", err, mismatchErr)
+		}
+
+		expected := map[string][]dirsync.Mismatch{
+			"host2": {{Path: "global/pg_control", Reason: dirsync.Changed, Detail: "checksum differs"}},
+		}
+		if !reflect.DeepEqual(mismatchErr.Mismatches, expected) {
+			t.Errorf("got mismatches %v want %v", mismatchErr.Mismatches, expected)
+		}
 	})
 }
 
 func TestCopyMasterTablespaces(t *testing.T) {
+	dir := testutils.GetTempDir(t, "tablespaces")
+	defer testutils.MustRemoveAll(t, dir)
+
+	mappingFile := filepath.Join(dir, "mapping.txt")
+	if err := ioutil.WriteFile(mappingFile, []byte("mapping"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tablespaceDir := filepath.Join(dir, "tblspc2")
+	if err := os.Mkdir(tablespaceDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
 	targetCluster := MustCreateCluster(t, []greenplum.SegConfig{
 		{ContentID: -1, DbID: 1, Port: 15432, Hostname: "localhost", DataDir: "/data/qddir/seg-1", Role: "p"},
 		{ContentID: 0, DbID: 2, Port: 25432, Hostname: "host1", DataDir: "/data/dbfast1/seg1", Role: "p"},
@@ -199,117 +337,179 @@ func TestCopyMasterTablespaces(t *testing.T) {
 	})
 
 	t.Run("copies tablespace mapping file and master tablespace directory to each primary host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
 		conf := &Config{
 			Target: targetCluster,
 			Tablespaces: greenplum.Tablespaces{
 				1: greenplum.SegmentTablespaces{
 					1663: greenplum.TablespaceInfo{
-						Location: "/tmp/tblspc1",
+						Location:    "/tmp/tblspc1",
 						UserDefined: 0},
 					1664: greenplum.TablespaceInfo{
-						Location: "/tmp/tblspc2",
+						Location:    tablespaceDir,
 						UserDefined: 1},
 				},
 				2: greenplum.SegmentTablespaces{
 					1663: greenplum.TablespaceInfo{
-						Location: "/tmp/primary1/tblspc1",
+						Location:    "/tmp/primary1/tblspc1",
 						UserDefined: 0},
 					1664: greenplum.TablespaceInfo{
-						Location: "/tmp/primary1/tblspc2",
+						Location:    "/tmp/primary1/tblspc2",
 						UserDefined: 1},
 				},
 				3: greenplum.SegmentTablespaces{
 					1663: greenplum.TablespaceInfo{
-						Location: "/tmp/primary2/tblspc1",
+						Location:    "/tmp/primary2/tblspc1",
 						UserDefined: 0},
 					1664: greenplum.TablespaceInfo{
-						Location: "/tmp/primary2/tblspc2",
+						Location:    "/tmp/primary2/tblspc2",
 						UserDefined: 1},
 				},
 			},
-			TablespacesMappingFilePath: "/tmp/mapping.txt",
+			TablespacesMappingFilePath: mappingFile,
 		}
 		hub := New(conf, grpc.DialContext, ".gpupgrade")
 
-		// The verifier function can be called in parallel, so use a channel to
-		// communicate which hosts were actually used.
-		hosts := make(chan string, len(targetCluster.PrimaryHostnames()))
-
-		expectedArgs := []string{
-			"--archive", "--compress", "--delete", "--stats",
-			"/tmp/mapping.txt", "/tmp/tblspc2", "foobar/path",
+		host1, requests1 := expectSync(ctrl, nil, &idl.SyncStats{}, nil)
+		host2, requests2 := expectSync(ctrl, nil, &idl.SyncStats{}, nil)
+		verify1 := expectVerify(ctrl, host1, &idl.VerifyDirectoryReply{}, nil)
+		verify2 := expectVerify(ctrl, host2, &idl.VerifyDirectoryReply{}, nil)
+		conns := []*Connection{
+			{AgentClient: host1, Hostname: "host1"},
+			{AgentClient: host2, Hostname: "host2"},
 		}
-		execCommandVerifier(t, hosts, expectedArgs)
 
-		err := hub.CopyMasterTablespaces(utils.DevNull, "foobar/path")
+		err := hub.CopyMasterTablespaces(context.Background(), utils.DevNull, "foobar/path", conns)
 		if err != nil {
 			t.Errorf("copying master tablespace directories and mapping file: %+v", err)
 		}
 
-		close(hosts)
+		for _, requests := range [][]*idl.SyncDirectoryRequest{*requests1, *requests2} {
+			expected := []string{"mapping.txt", "tblspc2"}
+			if paths := entryPaths(requests); !reflect.DeepEqual(paths, expected) {
+				t.Errorf("got paths %q want %q", paths, expected)
+			}
+		}
 
-		expectedHosts := []string{"host1", "host2"}
-		verifyHosts(hosts, expectedHosts, t)
+		for _, requests := range [][]*idl.VerifyDirectoryRequest{*verify1, *verify2} {
+			expected := []string{"mapping.txt", "tblspc2"}
+			if paths := manifestPaths(requests); !reflect.DeepEqual(paths, expected) {
+				t.Errorf("got manifest paths %q want %q", paths, expected)
+			}
+		}
 	})
 
 	t.Run("CopyMasterTablespaces returns nil if there is no tablespaces", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
 		conf := &Config{
 			Target: targetCluster,
 		}
 		hub := New(conf, grpc.DialContext, ".gpupgrade")
-		// The verifier function can be called in parallel, so use a channel to
-		// communicate which hosts were actually used.
-
-		hosts := make(chan string, len(targetCluster.PrimaryHostnames()))
 
-		var expectedArgs []string
-		execCommandVerifier(t, hosts, expectedArgs)
+		// Any call to the agent fails the test.
+		conns := []*Connection{
+			{AgentClient: mock_idl.NewMockAgentClient(ctrl), Hostname: "host1"},
+		}
 
-		err := hub.CopyMasterTablespaces(utils.DevNull, "foobar/path")
+		err := hub.CopyMasterTablespaces(context.Background(), utils.DevNull, "foobar/path", conns)
 		if err != nil {
 			t.Errorf("got %+v, want nil", err)
 		}
+	})
+}
 
-		close(hosts)
+// expectSync returns an agent client that expects a single directory sync, and
+// the requests that it is sent. The agent needs the contents of the given
+// paths, and ends the sync with the given stats, or fails it with err.
+func expectSync(ctrl *gomock.Controller, needed []string, stats *idl.SyncStats, err error) (*mock_idl.MockAgentClient, *[]*idl.SyncDirectoryRequest) {
+	requests := new([]*idl.SyncDirectoryRequest)
+
+	stream := mock_idl.NewMockAgent_SyncDirectoryClient(ctrl)
+	stream.EXPECT().Send(gomock.Any()).DoAndReturn(func(request *idl.SyncDirectoryRequest) error {
+		*requests = append(*requests, request)
+		return nil
+	}).AnyTimes()
+
+	if err != nil {
+		stream.EXPECT().Recv().Return(nil, err)
+	} else {
+		gomock.InOrder(
+			stream.EXPECT().Recv().Return(&idl.SyncDirectoryReply{Contents: &idl.SyncDirectoryReply_Needed{
+				Needed: &idl.NeededFiles{Paths: needed, Done: true},
+			}}, nil),
+			stream.EXPECT().Recv().Return(&idl.SyncDirectoryReply{Contents: &idl.SyncDirectoryReply_Stats{
+				Stats: stats,
+			}}, nil),
+		)
+		stream.EXPECT().CloseSend().Return(nil)
+	}
 
-		if expectedArgs != nil {
-			t.Errorf("Rsync() should not be invoked")
-		}
-	})
+	client := mock_idl.NewMockAgentClient(ctrl)
+	client.EXPECT().SyncDirectory(gomock.Any(), gomock.Any()).Return(stream, nil)
+
+	return client, requests
 }
 
-func verifyHosts(hosts chan string, expectedHosts []string, t *testing.T) {
-	// Collect the hostnames for validation.
-	var actualHosts []string
-	for host := range hosts {
-		actualHosts = append(actualHosts, host)
+// verifyRequests compares the requests, ignoring the attributes of the files
+// that depend on the system.
+func verifyRequests(t *testing.T, actual, expected []*idl.SyncDirectoryRequest) {
+	t.Helper()
+
+	var stripped []*idl.SyncDirectoryRequest
+	for _, request := range actual {
+		if entry := request.GetEntry(); entry != nil {
+			entry = proto.Clone(entry).(*idl.FileEntry)
+			entry.Mode, entry.ModTime, entry.Uid, entry.Gid = 0, 0, 0, 0
+			request = &idl.SyncDirectoryRequest{Contents: &idl.SyncDirectoryRequest_Entry{Entry: entry}}
+		}
+		stripped = append(stripped, request)
+	}
+
+	if len(stripped) != len(expected) {
+		t.Fatalf("got %d requests want %d", len(stripped), len(expected))
 	}
-	sort.Strings(actualHosts) // receive order not guaranteed
 
-	if !reflect.DeepEqual(actualHosts, expectedHosts) {
-		t.Errorf("copied to hosts %q, want %q", actualHosts, expectedHosts)
+	for i := range expected {
+		if !proto.Equal(stripped[i], expected[i]) {
+			t.Errorf("got request %d %v want %v", i, stripped[i], expected[i])
+		}
 	}
 }
 
-// Validate the rsync call and arguments.
-func execCommandVerifier(t *testing.T, hosts chan string, expectedArgs []string) {
-	execCommand = exectest.NewCommandWithVerifier(Success, func(name string, args ...string) {
-		expected := "rsync"
-		if name != expected {
-			t.Errorf("invoked %q, want %q", name, expected)
+// entryPaths returns the paths of the files that the requests sync.
+func entryPaths(requests []*idl.SyncDirectoryRequest) []string {
+	var paths []string
+	for _, request := range requests {
+		if entry := request.GetEntry(); entry != nil {
+			paths = append(paths, entry.Path)
 		}
+	}
+	return paths
+}
+
+// metricValue returns the current value of the given series from the default
+// metrics registry, or zero if it has not been reported.
+func metricValue(t *testing.T, series string) float64 {
+	t.Helper()
 
-		// The last argument is host:/destination/directory. Remove the
-		// host (saving it for later verification) to make comparison
-		// easier.
-		parts := strings.SplitN(args[len(args)-1], ":", 2)
-		host, dest := parts[0], parts[1]
-		args[len(args)-1] = dest
+	buf := new(bytes.Buffer)
+	if err := metrics.Default.Write(buf); err != nil {
+		t.Fatalf("writing metrics: %+v", err)
+	}
 
-		if !reflect.DeepEqual(args, expectedArgs) {
-			t.Errorf("rsync invoked with %q, want %q", args, expectedArgs)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, series+" ") {
+			value, err := strconv.ParseFloat(strings.TrimPrefix(line, series+" "), 64)
+			if err != nil {
+				t.Fatalf("parsing %q: %+v", line, err)
+			}
+			return value
 		}
+	}
 
-		hosts <- host
-	})
+	return 0
 }
@@ -29,19 +29,8 @@ func deleteDataDirectories(agentConns []*Connection, cluster *greenplum.Cluster,
 	for _, conn := range agentConns {
 		conn := conn
 
-		filterFunc := func(seg *greenplum.SegConfig) bool {
-			if seg.Hostname != conn.Hostname {
-				return false
-			}
-
-			if primaries {
-				return seg.IsPrimary()
-			}
-			return seg.Role == greenplum.MirrorRole
-		}
-
-		segments := cluster.SelectSegments(filterFunc)
-		if len(segments) == 0 {
+		datadirs := dataDirectoriesOnHost(cluster, conn.Hostname, primaries)
+		if len(datadirs) == 0 {
 			// This can happen if there are no segments matching the filter on a host
 			continue
 		}
@@ -50,12 +39,7 @@ func deleteDataDirectories(agentConns []*Connection, cluster *greenplum.Cluster,
 		go func(c *Connection) {
 			defer wg.Done()
 
-			req := new(idl.DeleteDataDirectoriesRequest)
-			for _, seg := range segments {
-				datadir := seg.DataDir
-				req.Datadirs = append(req.Datadirs, datadir)
-			}
-
+			req := &idl.DeleteDataDirectoriesRequest{Datadirs: datadirs}
 			_, err := c.AgentClient.DeleteDataDirectories(context.Background(), req)
 			if err != nil {
 				gplog.Error("Error deleting data directories on host %s: %s",
@@ -75,3 +59,25 @@ func deleteDataDirectories(agentConns []*Connection, cluster *greenplum.Cluster,
 
 	return mErr.ErrorOrNil()
 }
+
+// dataDirectoriesOnHost returns the data directories of the cluster's primaries
+// or mirrors on the given host.
+func dataDirectoriesOnHost(cluster *greenplum.Cluster, hostname string, primaries bool) []string {
+	filterFunc := func(seg *greenplum.SegConfig) bool {
+		if seg.Hostname != hostname {
+			return false
+		}
+
+		if primaries {
+			return seg.IsPrimary()
+		}
+		return seg.Role == greenplum.MirrorRole
+	}
+
+	var datadirs []string
+	for _, seg := range cluster.SelectSegments(filterFunc) {
+		datadirs = append(datadirs, seg.DataDir)
+	}
+
+	return datadirs
+}
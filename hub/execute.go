@@ -4,9 +4,11 @@
 package hub
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"github.com/hashicorp/go-multierror"
@@ -23,7 +25,10 @@ const executeMasterBackupName = "upgraded-master.bak"
 func (s *Server) Execute(request *idl.ExecuteRequest, stream idl.CliToHub_ExecuteServer) (err error) {
 	upgradedMasterBackupDir := filepath.Join(s.StateDir, executeMasterBackupName)
 
-	st, err := step.Begin(s.StateDir, "execute", stream)
+	ctx, done := s.cancellableContext()
+	defer done()
+
+	st, err := step.Begin(ctx, s.StateDir, "execute", stream)
 	if err != nil {
 		return err
 	}
@@ -38,7 +43,7 @@ func (s *Server) Execute(request *idl.ExecuteRequest, stream idl.CliToHub_Execut
 		}
 	}()
 
-	st.Run(idl.Substep_SHUTDOWN_SOURCE_CLUSTER, func(streams step.OutStreams) error {
+	st.Run(idl.Substep_SHUTDOWN_SOURCE_CLUSTER, func(_ context.Context, streams step.OutStreams) error {
 		err := s.Source.Stop(streams)
 
 		if err != nil {
@@ -48,9 +53,9 @@ func (s *Server) Execute(request *idl.ExecuteRequest, stream idl.CliToHub_Execut
 		return nil
 	})
 
-	st.Run(idl.Substep_UPGRADE_MASTER, func(streams step.OutStreams) error {
+	st.Run(idl.Substep_UPGRADE_MASTER, func(ctx context.Context, streams step.OutStreams) error {
 		stateDir := s.StateDir
-		return UpgradeMaster(UpgradeMasterArgs{
+		return UpgradeMaster(ctx, UpgradeMasterArgs{
 			Source:      s.Source,
 			Target:      s.Target,
 			StateDir:    stateDir,
@@ -60,13 +65,28 @@ func (s *Server) Execute(request *idl.ExecuteRequest, stream idl.CliToHub_Execut
 		})
 	})
 
-	st.Run(idl.Substep_COPY_MASTER, func(streams step.OutStreams) error {
-		err := s.CopyMasterDataDir(streams, upgradedMasterBackupDir)
+	segmentStore := step.NewSegmentStore(filepath.Join(s.StateDir, step.SegmentStatusFileName))
+
+	st.Run(idl.Substep_COPY_MASTER, func(ctx context.Context, streams step.OutStreams) error {
+		// The primaries are restored from the master copy before they are
+		// upgraded, so any primaries upgraded from an earlier copy must be
+		// upgraded again.
+		err := segmentStore.Reset(idl.Substep_UPGRADE_PRIMARIES)
+		if err != nil {
+			return err
+		}
+
+		agentConns, err := s.AgentConns()
+		if err != nil {
+			return errors.Wrap(err, "failed to connect to gpupgrade agent")
+		}
+
+		err = s.CopyMasterDataDir(ctx, streams, upgradedMasterBackupDir, agentConns)
 		if err != nil {
 			return err
 		}
 
-		err = s.CopyMasterTablespaces(streams, utils.GetTablespaceDir() + string(os.PathSeparator))
+		err = s.CopyMasterTablespaces(ctx, streams, utils.GetTablespaceDir() + string(os.PathSeparator), agentConns)
 		if err != nil {
 			return err
 		}
@@ -74,7 +94,8 @@ func (s *Server) Execute(request *idl.ExecuteRequest, stream idl.CliToHub_Execut
 		return nil
 	})
 
-	st.Run(idl.Substep_UPGRADE_PRIMARIES, func(_ step.OutStreams) error {
+	var segmentResults []*idl.SegmentResult
+	st.Run(idl.Substep_UPGRADE_PRIMARIES, func(ctx context.Context, streams step.OutStreams) error {
 		agentConns, err := s.AgentConns()
 
 		if err != nil {
@@ -87,7 +108,13 @@ func (s *Server) Execute(request *idl.ExecuteRequest, stream idl.CliToHub_Execut
 			return errors.Wrap(err, "failed to get source and target primary data directories")
 		}
 
-		return UpgradePrimaries(UpgradePrimaryArgs{
+		completed, err := completedPrimaries(segmentStore, request.RetryFailedSegmentsOnly)
+		if err != nil {
+			return err
+		}
+
+		segmentResults, err = UpgradePrimaries(ctx, UpgradePrimaryArgs{
+			Stream:                 streams,
 			CheckOnly:              false,
 			MasterBackupDir:        upgradedMasterBackupDir,
 			AgentConns:             agentConns,
@@ -96,10 +123,37 @@ func (s *Server) Execute(request *idl.ExecuteRequest, stream idl.CliToHub_Execut
 			Target:                 s.Target,
 			UseLinkMode:            s.UseLinkMode,
 			TablespacesMappingFile: s.TablespacesMappingFilePath,
+			Completed:              completed,
+			MaxUpgradesPerHost:     s.MaxUpgradesPerHost,
+			MaxUpgrades:            s.MaxUpgrades,
 		})
+
+		if merr := markUpgradedPrimaries(segmentStore, segmentResults); merr != nil {
+			err = multierror.Append(err, merr).ErrorOrNil()
+		}
+
+		if len(segmentResults) > 0 {
+			path := filepath.Join(s.StateDir, SegmentResultsFileName)
+			if serr := SaveSegmentResults(path, segmentResults); serr != nil {
+				err = multierror.Append(err, serr).ErrorOrNil()
+			}
+		}
+
+		return err
 	})
 
-	st.Run(idl.Substep_START_TARGET_CLUSTER, func(streams step.OutStreams) error {
+	if len(segmentResults) > 0 {
+		// The results are informational, so failing to send them should not
+		// stop the upgrade.
+		msg := &idl.Message{Contents: &idl.Message_SegmentResults{
+			SegmentResults: &idl.SegmentResults{Results: segmentResults},
+		}}
+		if serr := stream.Send(msg); serr != nil {
+			gplog.Error("sending segment results: %v", serr)
+		}
+	}
+
+	st.Run(idl.Substep_START_TARGET_CLUSTER, func(_ context.Context, streams step.OutStreams) error {
 		err := s.Target.Start(streams)
 
 		if err != nil {
@@ -116,3 +170,46 @@ func (s *Server) Execute(request *idl.ExecuteRequest, stream idl.CliToHub_Execut
 
 	return st.Err()
 }
+
+// completedPrimaries returns the primaries that were upgraded by an earlier
+// attempt, which can be skipped when only failed segments are being retried.
+// Otherwise the record of them is cleared so that every primary is upgraded.
+func completedPrimaries(store *step.SegmentStore, retryFailedOnly bool) (map[int32]bool, error) {
+	if !retryFailedOnly {
+		return nil, store.Reset(idl.Substep_UPGRADE_PRIMARIES)
+	}
+
+	completed, err := store.Completed(idl.Substep_UPGRADE_PRIMARIES)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(completed) > 0 {
+		gplog.Info("skipping primaries that were already upgraded: %v", sortedContents(completed))
+	}
+
+	return completed, nil
+}
+
+// markUpgradedPrimaries records the primaries that were upgraded successfully,
+// so that they can be skipped if the substep is retried.
+func markUpgradedPrimaries(store *step.SegmentStore, results []*idl.SegmentResult) error {
+	var upgraded []int32
+	for _, result := range results {
+		if result.Phase == idl.SegmentResult_DONE && !result.Skipped {
+			upgraded = append(upgraded, result.Content)
+		}
+	}
+
+	return store.MarkCompleted(idl.Substep_UPGRADE_PRIMARIES, upgraded...)
+}
+
+func sortedContents(contents map[int32]bool) []int32 {
+	var sorted []int32
+	for content := range contents {
+		sorted = append(sorted, content)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+)
+
+func TestCompletedPrimaries(t *testing.T) {
+	testhelper.SetupTestLogger()
+
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatalf("creating temporary directory: %+v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := step.NewSegmentStore(filepath.Join(dir, step.SegmentStatusFileName))
+
+	err = markUpgradedPrimaries(store, []*idl.SegmentResult{
+		{Content: 0, Phase: idl.SegmentResult_DONE},
+		{Content: 1, Phase: idl.SegmentResult_PG_UPGRADE, Error: "exit status 1"},
+		{Content: 2, Phase: idl.SegmentResult_DONE},
+	})
+	if err != nil {
+		t.Fatalf("markUpgradedPrimaries() returned error %+v", err)
+	}
+
+	t.Run("returns the successfully upgraded primaries when retrying failed segments only", func(t *testing.T) {
+		completed, err := completedPrimaries(store, true)
+		if err != nil {
+			t.Fatalf("completedPrimaries() returned error %+v", err)
+		}
+
+		expected := map[int32]bool{0: true, 2: true}
+		if !reflect.DeepEqual(completed, expected) {
+			t.Errorf("got %v want %v", completed, expected)
+		}
+	})
+
+	t.Run("clears the upgraded primaries when retrying every segment", func(t *testing.T) {
+		completed, err := completedPrimaries(store, false)
+		if err != nil {
+			t.Fatalf("completedPrimaries() returned error %+v", err)
+		}
+
+		if len(completed) != 0 {
+			t.Errorf("got %v, want no completed primaries", completed)
+		}
+
+		completed, err = completedPrimaries(store, true)
+		if err != nil {
+			t.Fatalf("completedPrimaries() returned error %+v", err)
+		}
+
+		if len(completed) != 0 {
+			t.Errorf("got %v, want the record to have been cleared", completed)
+		}
+	})
+}
@@ -41,12 +41,15 @@ func FillClusterConfigsSubStep(config *Config, conn *sql.DB, _ step.OutStreams,
 	config.Source = source
 	config.Target = &greenplum.Cluster{BinDir: request.TargetBinDir}
 	config.UseLinkMode = request.UseLinkMode
+	config.MaxUpgradesPerHost = int(request.MaxUpgradesPerHost)
+	config.MaxUpgrades = int(request.MaxUpgrades)
 
 	var ports []int
 	for _, p := range request.Ports {
 		ports = append(ports, int(p))
 	}
 
+	config.TargetPorts = append([]int(nil), ports...) // AssignDatadirsAndPorts sorts ports in place
 	config.TargetInitializeConfig, err = AssignDatadirsAndPorts(config.Source, ports, config.UpgradeID)
 	if err != nil {
 		return err
@@ -71,9 +74,13 @@ func FillClusterConfigsSubStep(config *Config, conn *sql.DB, _ step.OutStreams,
 	return nil
 }
 
+// defaultTargetPort is the first port that the target cluster uses when no
+// ports are given.
+const defaultTargetPort = 50432
+
 func AssignDatadirsAndPorts(source *greenplum.Cluster, ports []int, upgradeID upgrade.ID) (InitializeConfig, error) {
 	if len(ports) == 0 {
-		port := 50432
+		port := defaultTargetPort
 		numberOfSegments := len(source.Mirrors) + len(source.Primaries) + 2 // +2 for master/standby
 		if (numberOfSegments + port) > 65535 {
 			numberOfSegments = 65535 - port
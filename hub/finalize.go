@@ -4,6 +4,7 @@
 package hub
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
@@ -16,7 +17,10 @@ import (
 )
 
 func (s *Server) Finalize(_ *idl.FinalizeRequest, stream idl.CliToHub_FinalizeServer) (err error) {
-	st, err := step.Begin(s.StateDir, "finalize", stream)
+	ctx, done := s.cancellableContext()
+	defer done()
+
+	st, err := step.Begin(ctx, s.StateDir, "finalize", stream)
 	if err != nil {
 		return err
 	}
@@ -31,7 +35,7 @@ func (s *Server) Finalize(_ *idl.FinalizeRequest, stream idl.CliToHub_FinalizeSe
 		}
 	}()
 
-	st.Run(idl.Substep_SHUTDOWN_TARGET_CLUSTER, func(streams step.OutStreams) error {
+	st.Run(idl.Substep_SHUTDOWN_TARGET_CLUSTER, func(_ context.Context, streams step.OutStreams) error {
 		err := s.Target.Stop(streams)
 
 		if err != nil {
@@ -41,15 +45,15 @@ func (s *Server) Finalize(_ *idl.FinalizeRequest, stream idl.CliToHub_FinalizeSe
 		return nil
 	})
 
-	st.Run(idl.Substep_UPDATE_TARGET_CATALOG_AND_CLUSTER_CONFIG, func(streams step.OutStreams) error {
+	st.Run(idl.Substep_UPDATE_TARGET_CATALOG_AND_CLUSTER_CONFIG, func(_ context.Context, streams step.OutStreams) error {
 		return s.UpdateCatalogAndClusterConfig(streams)
 	})
 
-	st.Run(idl.Substep_UPDATE_DATA_DIRECTORIES, func(_ step.OutStreams) error {
+	st.Run(idl.Substep_UPDATE_DATA_DIRECTORIES, func(_ context.Context, _ step.OutStreams) error {
 		return s.UpdateDataDirectories()
 	})
 
-	st.Run(idl.Substep_UPDATE_TARGET_CONF_FILES, func(streams step.OutStreams) error {
+	st.Run(idl.Substep_UPDATE_TARGET_CONF_FILES, func(_ context.Context, streams step.OutStreams) error {
 		return UpdateConfFiles(streams,
 			s.Target.MasterDataDir(),
 			s.TargetInitializeConfig.Master.Port,
@@ -57,7 +61,7 @@ func (s *Server) Finalize(_ *idl.FinalizeRequest, stream idl.CliToHub_FinalizeSe
 		)
 	})
 
-	st.Run(idl.Substep_START_TARGET_CLUSTER, func(streams step.OutStreams) error {
+	st.Run(idl.Substep_START_TARGET_CLUSTER, func(_ context.Context, streams step.OutStreams) error {
 		err := s.Target.Start(streams)
 
 		if err != nil {
@@ -70,7 +74,7 @@ func (s *Server) Finalize(_ *idl.FinalizeRequest, stream idl.CliToHub_FinalizeSe
 	// todo: we don't currently have a way to output nothing to the UI when there is no standby.
 	// If we did, this check would actually be in `UpgradeStandby`
 	if s.Source.HasStandby() {
-		st.Run(idl.Substep_UPGRADE_STANDBY, func(streams step.OutStreams) error {
+		st.Run(idl.Substep_UPGRADE_STANDBY, func(_ context.Context, streams step.OutStreams) error {
 			// TODO: once the temporary standby upgrade is fixed, switch to
 			// using the TargetInitializeConfig's temporary assignments, and
 			// move this upgrade step back to before the target shutdown.
@@ -86,7 +90,7 @@ func (s *Server) Finalize(_ *idl.FinalizeRequest, stream idl.CliToHub_FinalizeSe
 	// todo: we don't currently have a way to output nothing to the UI when there are no mirrors.
 	// If we did, this check would actually be in `UpgradeMirrors`
 	if s.Source.HasMirrors() {
-		st.Run(idl.Substep_UPGRADE_MIRRORS, func(streams step.OutStreams) error {
+		st.Run(idl.Substep_UPGRADE_MIRRORS, func(_ context.Context, streams step.OutStreams) error {
 			// TODO: once the temporary mirror upgrade is fixed, switch to using
 			// the TargetInitializeConfig's temporary assignments, and move this
 			// upgrade step back to before the target shutdown.
@@ -0,0 +1,124 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils/health"
+)
+
+// HeartbeatInterval is how often the hub checks the health of the agents it
+// is connected to.
+var HeartbeatInterval = 10 * time.Second
+
+func (s *Server) GetAgentHealth(ctx context.Context, in *idl.GetAgentHealthRequest) (*idl.GetAgentHealthReply, error) {
+	reply := &idl.GetAgentHealthReply{HubVersion: s.Version}
+
+	// Before initialize there is no source cluster, and so no agents.
+	if s.Source == nil {
+		return reply, nil
+	}
+
+	hosts := AgentHosts(s.Source)
+	sort.Strings(hosts)
+
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	for _, host := range hosts {
+		agent, ok := s.agentHealth[host]
+		if !ok {
+			agent = &idl.AgentHealth{Hostname: host, State: idl.AgentHealth_NOT_CONNECTED}
+		}
+
+		reply.Agents = append(reply.Agents, proto.Clone(agent).(*idl.AgentHealth))
+	}
+
+	return reply, nil
+}
+
+// heartbeat checks the health of the agents every interval, until ctx is
+// cancelled.
+func (s *Server) heartbeat(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.CheckAgentHealth(ctx)
+		}
+	}
+}
+
+// CheckAgentHealth checks the health of every agent that the hub is connected
+// to, and records the result for GetAgentHealth. It doesn't dial agents that
+// the hub isn't yet connected to.
+func (s *Server) CheckAgentHealth(ctx context.Context) {
+	conns := s.agents.snapshot()
+
+	done := make(chan struct{}, len(conns))
+	for _, conn := range conns {
+		go func(conn *Connection) {
+			defer func() { done <- struct{}{} }()
+			s.checkAgent(ctx, conn)
+		}(conn)
+	}
+
+	for range conns {
+		<-done
+	}
+}
+
+func (s *Server) checkAgent(ctx context.Context, conn *Connection) {
+	if conn.Conn == nil {
+		return
+	}
+
+	agentConnectionState.SetState(conn.Conn.GetState().String(), connectivityStates, conn.Hostname)
+
+	ctx, cancel := context.WithTimeout(ctx, DialTimeout)
+	defer cancel()
+
+	status, version, err := health.Check(ctx, grpc_health_v1.NewHealthClient(conn.Conn))
+
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	if s.agentHealth == nil {
+		s.agentHealth = make(map[string]*idl.AgentHealth)
+	}
+
+	agent, ok := s.agentHealth[conn.Hostname]
+	if !ok {
+		agent = &idl.AgentHealth{Hostname: conn.Hostname}
+		s.agentHealth[conn.Hostname] = agent
+	}
+
+	if err != nil {
+		gplog.Debug("heartbeat to agent on host %s failed: %+v", conn.Hostname, err)
+
+		agent.State = idl.AgentHealth_UNREACHABLE
+		agent.Error = err.Error()
+		return
+	}
+
+	agent.State = idl.AgentHealth_NOT_SERVING
+	if status == grpc_health_v1.HealthCheckResponse_SERVING {
+		agent.State = idl.AgentHealth_SERVING
+	}
+	agent.LastSeen = protoTime(time.Now())
+	agent.Version = version
+	agent.Error = ""
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils/mock_agent"
+)
+
+func TestAgentHealth(t *testing.T) {
+	testhelper.SetupTestLogger()
+	ctx := context.Background()
+
+	source := hub.MustCreateCluster(t, []greenplum.SegConfig{
+		{ContentID: -1, DbID: 1, Port: 15432, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: "p"},
+		{ContentID: 0, DbID: 2, Port: 25432, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1", Role: "p"},
+		{ContentID: 1, DbID: 3, Port: 25433, Hostname: "sdw2", DataDir: "/data/dbfast2/seg2", Role: "p"},
+	})
+
+	agentServer, dialer, agentPort := mock_agent.NewMockAgentServer()
+	defer agentServer.Stop()
+
+	newHub := func() *hub.Server {
+		h := hub.New(&hub.Config{Source: source, AgentPort: agentPort}, dialer, "")
+		h.Version = mock_agent.Version // the hub refuses agents at other versions
+		return h
+	}
+
+	// states returns the state of each agent in the reply, by hostname.
+	states := func(reply *idl.GetAgentHealthReply) map[string]idl.AgentHealth_State {
+		result := make(map[string]idl.AgentHealth_State)
+		for _, agent := range reply.Agents {
+			result[agent.Hostname] = agent.State
+		}
+		return result
+	}
+
+	t.Run("reports no agents before initialize", func(t *testing.T) {
+		h := hub.New(&hub.Config{}, dialer, "")
+		h.Version = "1.2.3"
+
+		reply, err := h.GetAgentHealth(ctx, &idl.GetAgentHealthRequest{})
+		if err != nil {
+			t.Fatalf("GetAgentHealth() returned error %+v", err)
+		}
+
+		expected := &idl.GetAgentHealthReply{HubVersion: "1.2.3"}
+		if !reflect.DeepEqual(reply, expected) {
+			t.Errorf("got %v want %v", reply, expected)
+		}
+	})
+
+	t.Run("reports agents that the hub has not connected to", func(t *testing.T) {
+		h := newHub()
+		h.CheckAgentHealth(ctx)
+
+		reply, err := h.GetAgentHealth(ctx, &idl.GetAgentHealthRequest{})
+		if err != nil {
+			t.Fatalf("GetAgentHealth() returned error %+v", err)
+		}
+
+		expected := &idl.GetAgentHealthReply{
+			HubVersion: mock_agent.Version,
+			Agents: []*idl.AgentHealth{
+				{Hostname: "sdw1", State: idl.AgentHealth_NOT_CONNECTED},
+				{Hostname: "sdw2", State: idl.AgentHealth_NOT_CONNECTED},
+			},
+		}
+		if !reflect.DeepEqual(reply, expected) {
+			t.Errorf("got %v want %v", reply, expected)
+		}
+	})
+
+	t.Run("records the state and version of each connected agent", func(t *testing.T) {
+		h := newHub()
+		defer h.Stop(true)
+
+		agentConns, err := h.AgentConns()
+		if err != nil {
+			t.Fatalf("AgentConns() returned error %+v", err)
+		}
+		ensureAgentConnsReachState(t, agentConns, connectivity.Ready)
+
+		h.CheckAgentHealth(ctx)
+
+		reply, err := h.GetAgentHealth(ctx, &idl.GetAgentHealthRequest{})
+		if err != nil {
+			t.Fatalf("GetAgentHealth() returned error %+v", err)
+		}
+
+		for _, agent := range reply.Agents {
+			if agent.State != idl.AgentHealth_SERVING {
+				t.Errorf("got state %s for host %s want %s", agent.State, agent.Hostname, idl.AgentHealth_SERVING)
+			}
+			if agent.Version != mock_agent.Version {
+				t.Errorf("got version %q for host %s want %q", agent.Version, agent.Hostname, mock_agent.Version)
+			}
+			if agent.LastSeen == nil {
+				t.Errorf("expected host %s to have been seen", agent.Hostname)
+			}
+		}
+	})
+
+	t.Run("reports agents that have stopped responding", func(t *testing.T) {
+		agentServer, dialer, agentPort := mock_agent.NewMockAgentServer()
+		defer agentServer.Stop()
+
+		h := hub.New(&hub.Config{Source: source, AgentPort: agentPort}, dialer, "")
+		defer h.Stop(true)
+
+		agentConns, err := h.AgentConns()
+		if err != nil {
+			t.Fatalf("AgentConns() returned error %+v", err)
+		}
+		ensureAgentConnsReachState(t, agentConns, connectivity.Ready)
+
+		h.CheckAgentHealth(ctx)
+		agentServer.Stop()
+		h.CheckAgentHealth(ctx)
+
+		reply, err := h.GetAgentHealth(ctx, &idl.GetAgentHealthRequest{})
+		if err != nil {
+			t.Fatalf("GetAgentHealth() returned error %+v", err)
+		}
+
+		expected := map[string]idl.AgentHealth_State{
+			"sdw1": idl.AgentHealth_UNREACHABLE,
+			"sdw2": idl.AgentHealth_UNREACHABLE,
+		}
+		if actual := states(reply); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got states %v want %v", actual, expected)
+		}
+
+		for _, agent := range reply.Agents {
+			if agent.Error == "" {
+				t.Errorf("expected an error for host %s", agent.Hostname)
+			}
+
+			// The agent was last seen before it stopped.
+			if agent.LastSeen == nil || agent.Version != mock_agent.Version {
+				t.Errorf("expected host %s to keep its last heartbeat, got %v", agent.Hostname, agent)
+			}
+		}
+	})
+}
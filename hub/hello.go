@@ -0,0 +1,113 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/hashicorp/go-multierror"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
+	"github.com/greenplum-db/gpupgrade/utils/handshake"
+)
+
+// Hello reports the hub's version, so the CLI can check that it matches its
+// own.
+func (s *Server) Hello(ctx context.Context, in *idl.HelloRequest) (*idl.HelloReply, error) {
+	return handshake.Reply(s.Version), nil
+}
+
+// mismatchedHosts returns the hosts, sorted, whose agents were refused for
+// being at a different version than the hub.
+func mismatchedHosts(unreachable UnreachableHostsError) []string {
+	var hosts []string
+	for host, err := range unreachable.Hosts {
+		if xerrors.Is(err, handshake.ErrVersionMismatch) {
+			hosts = append(hosts, host)
+		}
+	}
+
+	sort.Strings(hosts)
+	return hosts
+}
+
+// stopAgentOnHost stops an agent that the hub does not keep a connection to,
+// such as one at a different version.
+func (s *Server) stopAgentOnHost(ctx context.Context, host string) error {
+	credentials, err := certs.DialOption(s.TLS)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DialTimeout)
+	defer cancel()
+
+	conn, err := s.agents.dialer(ctx, host+":"+strconv.Itoa(s.AgentPort), credentials, grpc.WithBlock())
+	if err != nil {
+		return xerrors.Errorf("connecting to agent on host %s: %w", host, err)
+	}
+	defer conn.Close()
+
+	return stopAgent(ctx, idl.NewAgentClient(conn), host)
+}
+
+// redeployAgents replaces the agents whose version differs from the hub's.
+// Each is stopped, and the hub's gpupgrade executable is copied over the one
+// on its host, ready for RestartAgents to start it again.
+func (s *Server) redeployAgents(ctx context.Context) error {
+	_, unreachable, err := s.ReachableAgentConns()
+	if err != nil {
+		return err
+	}
+
+	agentPath, err := getAgentPath()
+	if err != nil {
+		return err
+	}
+
+	executor := s.RemoteShell.Executor()
+
+	var merr *multierror.Error
+	for _, host := range mismatchedHosts(unreachable) {
+		gplog.Info("redeploying agent on %s", host)
+
+		if err := s.stopAgentOnHost(ctx, host); err != nil {
+			merr = multierror.Append(merr, err)
+			continue
+		}
+
+		if err := copyAgent(ctx, executor, host, agentPath); err != nil {
+			merr = multierror.Append(merr, xerrors.Errorf("copying gpupgrade to host %s: %w", host, err))
+		}
+	}
+
+	return merr.ErrorOrNil()
+}
+
+// copyAgent replaces the gpupgrade executable at agentPath on host with the
+// hub's. Local agents already run the hub's executable.
+func copyAgent(ctx context.Context, executor RemoteExecutor, host, agentPath string) error {
+	if _, ok := executor.(LocalExecutor); ok {
+		return nil
+	}
+
+	f, err := os.Open(agentPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Write to a temporary file first, so a failed copy doesn't leave a
+	// truncated executable behind.
+	script := fmt.Sprintf("cat > %[1]s.new && chmod 755 %[1]s.new && mv %[1]s.new %[1]s", agentPath)
+	return runRemote(ctx, executor, host, script, f, nil, nil)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+)
+
+func TestCopyAgent(t *testing.T) {
+	f, err := ioutil.TempFile("", "gpupgrade")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Close()
+
+	t.Run("replaces the executable on the host", func(t *testing.T) {
+		SetExecCommand(exectest.NewCommandWithVerifier(Success, func(name string, args ...string) {
+			path := f.Name()
+			expected := []string{"sdw1", "cat > " + path + ".new && chmod 755 " + path + ".new && mv " + path + ".new " + path}
+			if name != "ssh" || !reflect.DeepEqual(args, expected) {
+				t.Errorf("got %q %q want ssh %q", name, args, expected)
+			}
+		}))
+		defer ResetExecCommand()
+
+		err := copyAgent(context.Background(), SSHExecutor{}, "sdw1", f.Name())
+		if err != nil {
+			t.Errorf("copyAgent() returned error %+v", err)
+		}
+	})
+
+	t.Run("leaves local agents alone", func(t *testing.T) {
+		SetExecCommand(exectest.NewCommandWithVerifier(Success, func(name string, args ...string) {
+			t.Errorf("unexpected command %q %q", name, args)
+		}))
+		defer ResetExecCommand()
+
+		err := copyAgent(context.Background(), LocalExecutor{}, "localhost", f.Name())
+		if err != nil {
+			t.Errorf("copyAgent() returned error %+v", err)
+		}
+	})
+}
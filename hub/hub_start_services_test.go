@@ -5,6 +5,7 @@ package hub_test
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net"
 	"os"
@@ -25,6 +26,7 @@ import (
 	"github.com/greenplum-db/gpupgrade/hub"
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 )
 
 func gpupgrade_agent() {
@@ -68,7 +70,7 @@ func TestRestartAgent(t *testing.T) {
 			return listener.Dial()
 		}
 
-		restartedHosts, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir)
+		restartedHosts, err := hub.RestartAgents(ctx, dialer, hub.SSHExecutor{}, hostnames, port, "", 0, stateDir, certs.Config{})
 		if err != nil {
 			t.Errorf("returned %#v", err)
 		}
@@ -88,7 +90,7 @@ func TestRestartAgent(t *testing.T) {
 			return listener.Dial()
 		}
 
-		restartedHosts, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir)
+		restartedHosts, err := hub.RestartAgents(ctx, dialer, hub.SSHExecutor{}, hostnames, port, "", 0, stateDir, certs.Config{})
 		if err != nil {
 			t.Errorf("returned %#v", err)
 		}
@@ -111,7 +113,7 @@ func TestRestartAgent(t *testing.T) {
 			return nil, immediateFailure{}
 		}
 
-		restartedHosts, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir)
+		restartedHosts, err := hub.RestartAgents(ctx, dialer, hub.SSHExecutor{}, hostnames, port, "", 0, stateDir, certs.Config{})
 		if err == nil {
 			t.Errorf("expected restart agents to fail")
 		}
@@ -122,10 +124,15 @@ func TestRestartAgent(t *testing.T) {
 			}
 
 			var exitErr *exec.ExitError
+			var remoteErr *hub.RemoteError
 			for _, err := range merr.WrappedErrors() {
 				if !xerrors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
 					t.Errorf("expected exit code: 1 but got: %#v", err)
 				}
+
+				if !xerrors.As(err, &remoteErr) || remoteErr.Stderr != "could not find state-directory" {
+					t.Errorf("expected the agent's stderr but got: %#v", err)
+				}
 			}
 		}
 
@@ -159,11 +166,88 @@ func TestRestartAgent(t *testing.T) {
 			return listener.Dial()
 		}
 
-		_, err := hub.RestartAgents(ctx, dialer, hostnames, port, stateDir)
+		_, err := hub.RestartAgents(ctx, dialer, hub.SSHExecutor{}, hostnames, port, "", 0, stateDir, certs.Config{})
 		if err != nil {
 			t.Errorf("unexpected errr %#v", err)
 		}
 	})
+
+	t.Run("starts agents using the given executor", func(t *testing.T) {
+		execCmd := exectest.NewCommandWithVerifier(gpupgrade_agent, func(name string, args ...string) {
+			cmd := fmt.Sprintf("bash -c \"%s/gpupgrade agent --daemonize --port %d --state-directory %s\"", mustGetExecutablePath(t), port, stateDir)
+			expected := []string{"-c", cmd}
+			if name != "bash" || !reflect.DeepEqual(args, expected) {
+				t.Errorf("got %q %q want bash %q", name, args, expected)
+			}
+		})
+		hub.SetExecCommand(execCmd)
+		defer hub.ResetExecCommand()
+
+		dialer := func(ctx context.Context, address string) (net.Conn, error) {
+			return nil, immediateFailure{}
+		}
+
+		_, err := hub.RestartAgents(ctx, dialer, hub.LocalExecutor{}, []string{"localhost"}, port, "", 0, stateDir, certs.Config{})
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+	})
+
+	t.Run("starts agents with their certificates when TLS is enabled", func(t *testing.T) {
+		tlsStateDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tlsStateDir)
+
+		tlsConf, err := certs.GenerateHub(tlsStateDir)
+		if err != nil {
+			t.Fatalf("GenerateHub() returned error %+v", err)
+		}
+
+		agentConf := certs.AgentConfig(tlsStateDir)
+		execCmd := exectest.NewCommandWithVerifier(gpupgrade_agent, func(name string, args ...string) {
+			cmd := fmt.Sprintf("bash -c \"%s/gpupgrade agent --daemonize --port %d --state-directory %s --tls-ca-cert %s --tls-cert %s --tls-key %s\"",
+				mustGetExecutablePath(t), port, tlsStateDir, agentConf.CACert, agentConf.Cert, agentConf.Key)
+			expected := []string{"host1", cmd}
+			if !reflect.DeepEqual(args, expected) {
+				t.Errorf("got %q want %q", args, expected)
+			}
+		})
+		hub.SetExecCommand(execCmd)
+		defer hub.ResetExecCommand()
+
+		dialer := func(ctx context.Context, address string) (net.Conn, error) {
+			return nil, immediateFailure{}
+		}
+
+		_, err = hub.RestartAgents(ctx, dialer, hub.SSHExecutor{}, []string{"host1"}, port, "", 0, tlsStateDir, tlsConf)
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+	})
+
+	t.Run("starts agents serving metrics when a metrics port is given", func(t *testing.T) {
+		execCmd := exectest.NewCommandWithVerifier(gpupgrade_agent, func(name string, args ...string) {
+			cmd := fmt.Sprintf("bash -c \"%s/gpupgrade agent --daemonize --port %d --state-directory %s --metrics-port 9091 --metrics-address 0.0.0.0\"",
+				mustGetExecutablePath(t), port, stateDir)
+			expected := []string{"host1", cmd}
+			if !reflect.DeepEqual(args, expected) {
+				t.Errorf("got %q want %q", args, expected)
+			}
+		})
+		hub.SetExecCommand(execCmd)
+		defer hub.ResetExecCommand()
+
+		dialer := func(ctx context.Context, address string) (net.Conn, error) {
+			return nil, immediateFailure{}
+		}
+
+		_, err := hub.RestartAgents(ctx, dialer, hub.SSHExecutor{}, []string{"host1"}, port, "0.0.0.0", 9091, stateDir, certs.Config{})
+		if err != nil {
+			t.Errorf("unexpected error %#v", err)
+		}
+	})
 }
 
 func mustGetExecutablePath(t *testing.T) string {
@@ -4,6 +4,7 @@
 package hub
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"path"
@@ -17,6 +18,7 @@ import (
 	"github.com/greenplum-db/gpupgrade/db"
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/utils"
 )
 
 func (s *Server) GenerateInitsystemConfig() error {
@@ -25,7 +27,11 @@ func (s *Server) GenerateInitsystemConfig() error {
 }
 
 func (s *Server) initsystemConfPath() string {
-	return filepath.Join(s.StateDir, "gpinitsystem_config")
+	return initsystemConfPath(s.StateDir)
+}
+
+func initsystemConfPath(stateDir string) string {
+	return filepath.Join(stateDir, "gpinitsystem_config")
 }
 
 func (s *Server) writeConf(sourceDBConn *dbconn.DBConn) error {
@@ -35,26 +41,38 @@ func (s *Server) writeConf(sourceDBConn *dbconn.DBConn) error {
 	}
 	defer sourceDBConn.Close()
 
-	gpinitsystemConfig, err := CreateInitialInitsystemConfig(s.TargetInitializeConfig.Master.DataDir)
+	gpinitsystemConfig, err := initsystemConfig(s.TargetInitializeConfig, s.RemoteShell.GpinitsystemShell(), sourceDBConn)
 	if err != nil {
 		return err
 	}
 
+	return WriteInitsystemFile(gpinitsystemConfig, s.initsystemConfPath())
+}
+
+// initsystemConfig returns the lines of the gpinitsystem_config used to create
+// the target cluster, which gpinitsystem reaches using trustedShell.
+// sourceDBConn must already be connected.
+func initsystemConfig(target InitializeConfig, trustedShell string, sourceDBConn *dbconn.DBConn) ([]string, error) {
+	gpinitsystemConfig, err := CreateInitialInitsystemConfig(target.Master.DataDir, trustedShell)
+	if err != nil {
+		return nil, err
+	}
+
 	gpinitsystemConfig, err = GetCheckpointSegmentsAndEncoding(gpinitsystemConfig, sourceDBConn)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	gpinitsystemConfig, err = WriteSegmentArray(gpinitsystemConfig, s.TargetInitializeConfig)
+	gpinitsystemConfig, err = WriteSegmentArray(gpinitsystemConfig, target)
 	if err != nil {
-		return xerrors.Errorf("generating segment array: %w", err)
+		return nil, xerrors.Errorf("generating segment array: %w", err)
 	}
 
-	return WriteInitsystemFile(gpinitsystemConfig, s.initsystemConfPath())
+	return gpinitsystemConfig, nil
 }
 
-func (s *Server) CreateTargetCluster(stream step.OutStreams) error {
-	err := s.InitTargetCluster(stream)
+func (s *Server) CreateTargetCluster(ctx context.Context, stream step.OutStreams) error {
+	err := s.InitTargetCluster(ctx, stream)
 	if err != nil {
 		return err
 	}
@@ -74,8 +92,8 @@ func (s *Server) CreateTargetCluster(stream step.OutStreams) error {
 	return nil
 }
 
-func (s *Server) InitTargetCluster(stream step.OutStreams) error {
-	return RunInitsystemForTargetCluster(stream, s.Target, s.initsystemConfPath())
+func (s *Server) InitTargetCluster(ctx context.Context, stream step.OutStreams) error {
+	return RunInitsystemForTargetCluster(ctx, stream, s.Target, s.initsystemConfPath())
 }
 
 func GetCheckpointSegmentsAndEncoding(gpinitsystemConfig []string, dbConnector *dbconn.DBConn) ([]string, error) {
@@ -93,7 +111,7 @@ func GetCheckpointSegmentsAndEncoding(gpinitsystemConfig []string, dbConnector *
 	return gpinitsystemConfig, nil
 }
 
-func CreateInitialInitsystemConfig(targetMasterDataDir string) ([]string, error) {
+func CreateInitialInitsystemConfig(targetMasterDataDir, trustedShell string) ([]string, error) {
 	gpinitsystemConfig := []string{`ARRAY_NAME="gp_upgrade cluster"`}
 
 	segPrefix, err := GetMasterSegPrefix(targetMasterDataDir)
@@ -101,7 +119,7 @@ func CreateInitialInitsystemConfig(targetMasterDataDir string) ([]string, error)
 		return gpinitsystemConfig, errors.Wrap(err, "Could not get master segment prefix")
 	}
 
-	gpinitsystemConfig = append(gpinitsystemConfig, "SEG_PREFIX="+segPrefix, "TRUSTED_SHELL=ssh")
+	gpinitsystemConfig = append(gpinitsystemConfig, "SEG_PREFIX="+segPrefix, "TRUSTED_SHELL="+trustedShell)
 
 	return gpinitsystemConfig, nil
 }
@@ -150,7 +168,21 @@ func WriteSegmentArray(config []string, targetInitializeConfig InitializeConfig)
 	return config, nil
 }
 
-func RunInitsystemForTargetCluster(stream step.OutStreams, target *greenplum.Cluster, gpinitsystemFilepath string) error {
+func RunInitsystemForTargetCluster(ctx context.Context, stream step.OutStreams, target *greenplum.Cluster, gpinitsystemFilepath string) error {
+	cmd := execCommand("bash", "-c", initsystemScript(target, gpinitsystemFilepath))
+
+	cmd.Stdout = stream.Stdout()
+	cmd.Stderr = stream.Stderr()
+
+	err := utils.RunContext(ctx, cmd)
+	if err != nil {
+		return xerrors.Errorf("gpinitsystem: %w", err)
+	}
+
+	return nil
+}
+
+func initsystemScript(target *greenplum.Cluster, gpinitsystemFilepath string) string {
 	gphome := filepath.Dir(path.Clean(target.BinDir)) //works around https://github.com/golang/go/issues/4837 in go10.4
 
 	args := "-a -I " + gpinitsystemFilepath
@@ -160,21 +192,10 @@ func RunInitsystemForTargetCluster(stream step.OutStreams, target *greenplum.Clu
 		args += " --ignore-warnings"
 	}
 
-	script := fmt.Sprintf("source %[1]s/greenplum_path.sh && %[1]s/bin/gpinitsystem %[2]s",
+	return fmt.Sprintf("source %[1]s/greenplum_path.sh && %[1]s/bin/gpinitsystem %[2]s",
 		gphome,
 		args,
 	)
-	cmd := execCommand("bash", "-c", script)
-
-	cmd.Stdout = stream.Stdout()
-	cmd.Stderr = stream.Stderr()
-
-	err := cmd.Run()
-	if err != nil {
-		return xerrors.Errorf("gpinitsystem: %w", err)
-	}
-
-	return nil
 }
 
 func GetMasterSegPrefix(datadir string) (string, error) {
@@ -20,7 +20,10 @@ import (
 const connectionString = "postgresql://localhost:%d/template1?gp_session_role=utility&search_path="
 
 func (s *Server) Initialize(in *idl.InitializeRequest, stream idl.CliToHub_InitializeServer) (err error) {
-	st, err := step.Begin(s.StateDir, "initialize", stream)
+	ctx, done := s.cancellableContext()
+	defer done()
+
+	st, err := step.Begin(ctx, s.StateDir, "initialize", stream)
 	if err != nil {
 		return err
 	}
@@ -35,7 +38,7 @@ func (s *Server) Initialize(in *idl.InitializeRequest, stream idl.CliToHub_Initi
 		}
 	}()
 
-	st.Run(idl.Substep_GENERATING_CONFIG, func(stream step.OutStreams) error {
+	st.Run(idl.Substep_GENERATING_CONFIG, func(_ context.Context, stream step.OutStreams) error {
 		conn, err := sql.Open("pgx", fmt.Sprintf(connectionString, in.SourcePort))
 		if err != nil {
 			return err
@@ -49,8 +52,16 @@ func (s *Server) Initialize(in *idl.InitializeRequest, stream idl.CliToHub_Initi
 		return FillClusterConfigsSubStep(s.Config, conn, stream, in, s.SaveConfig)
 	})
 
-	st.Run(idl.Substep_START_AGENTS, func(_ step.OutStreams) error {
-		_, err := RestartAgents(context.Background(), nil, AgentHosts(s.Source), s.AgentPort, s.StateDir)
+	st.Run(idl.Substep_START_AGENTS, func(ctx context.Context, streams step.OutStreams) error {
+		hosts := AgentHosts(s.Source)
+		executor := s.RemoteShell.Executor()
+		if s.TLS.Enabled() {
+			if err := DistributeAgentCerts(ctx, streams, executor, s.StateDir, hosts); err != nil {
+				return err
+			}
+		}
+
+		_, err := RestartAgents(ctx, nil, executor, hosts, s.AgentPort, s.MetricsAddress, s.AgentMetricsPort, s.StateDir, s.TLS)
 		return err
 	})
 
@@ -58,7 +69,10 @@ func (s *Server) Initialize(in *idl.InitializeRequest, stream idl.CliToHub_Initi
 }
 
 func (s *Server) InitializeCreateCluster(in *idl.InitializeCreateClusterRequest, stream idl.CliToHub_InitializeCreateClusterServer) (err error) {
-	st, err := step.Begin(s.StateDir, "initialize", stream)
+	ctx, done := s.cancellableContext()
+	defer done()
+
+	st, err := step.Begin(ctx, s.StateDir, "initialize", stream)
 	if err != nil {
 		return err
 	}
@@ -73,15 +87,24 @@ func (s *Server) InitializeCreateCluster(in *idl.InitializeCreateClusterRequest,
 		}
 	}()
 
-	st.Run(idl.Substep_CREATE_TARGET_CONFIG, func(_ step.OutStreams) error {
+	st.Run(idl.Substep_CHECK_TARGET_PORTS, func(ctx context.Context, streams step.OutStreams) error {
+		conns, err := s.AgentConns()
+		if err != nil {
+			return err
+		}
+
+		return s.CheckTargetPorts(ctx, streams, conns)
+	})
+
+	st.Run(idl.Substep_CREATE_TARGET_CONFIG, func(_ context.Context, _ step.OutStreams) error {
 		return s.GenerateInitsystemConfig()
 	})
 
-	st.Run(idl.Substep_INIT_TARGET_CLUSTER, func(stream step.OutStreams) error {
-		return s.CreateTargetCluster(stream)
+	st.Run(idl.Substep_INIT_TARGET_CLUSTER, func(ctx context.Context, stream step.OutStreams) error {
+		return s.CreateTargetCluster(ctx, stream)
 	})
 
-	st.Run(idl.Substep_SHUTDOWN_TARGET_CLUSTER, func(stream step.OutStreams) error {
+	st.Run(idl.Substep_SHUTDOWN_TARGET_CLUSTER, func(_ context.Context, stream step.OutStreams) error {
 		err := s.Target.Stop(stream)
 
 		if err != nil {
@@ -91,20 +114,20 @@ func (s *Server) InitializeCreateCluster(in *idl.InitializeCreateClusterRequest,
 		return nil
 	})
 
-	st.Run(idl.Substep_BACKUP_TARGET_MASTER, func(stream step.OutStreams) error {
+	st.Run(idl.Substep_BACKUP_TARGET_MASTER, func(ctx context.Context, _ step.OutStreams) error {
 		sourceDir := s.Target.MasterDataDir()
 		targetDir := filepath.Join(s.StateDir, originalMasterBackupName)
-		return RsyncMasterDataDir(stream, sourceDir, targetDir)
+		return SyncMasterDataDir(ctx, sourceDir, targetDir)
 	})
 
-	st.AlwaysRun(idl.Substep_CHECK_UPGRADE, func(stream step.OutStreams) error {
+	st.AlwaysRun(idl.Substep_CHECK_UPGRADE, func(ctx context.Context, stream step.OutStreams) error {
 		conns, err := s.AgentConns()
 
 		if err != nil {
 			return err
 		}
 
-		return s.CheckUpgrade(stream, conns)
+		return s.CheckUpgrade(ctx, stream, conns)
 	})
 
 	return st.Err()
@@ -0,0 +1,500 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/db"
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
+)
+
+// Plan returns the actions that a step would perform, without performing any
+// of them.
+func (s *Server) Plan(_ context.Context, in *idl.PlanRequest) (*idl.PlanReply, error) {
+	p := new(plan)
+
+	var err error
+	switch in.Step {
+	case "execute":
+		err = s.planExecute(p, in.RetryFailedSegmentsOnly)
+	case "finalize":
+		err = s.planFinalize(p)
+	case "revert":
+		err = s.planRevert(p)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "%q is not a step that can be planned", in.Step)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &idl.PlanReply{Actions: p.actions}, nil
+}
+
+// PlanInitialize returns the actions that initialize would perform for the
+// request. Since the hub is not yet running, it is called directly by the CLI.
+// The source cluster is queried to lay out the target cluster, but nothing is
+// changed on any host. The temporary target data directories are named with a
+// new upgrade ID, so they differ from those that initialize creates. checks
+// describes the pre-upgrade checks that the CLI runs.
+func PlanInitialize(conf *Config, stateDir string, request *idl.InitializeRequest, checks []string) ([]*idl.PlannedAction, error) {
+	conf.AgentPort = int(request.AgentPort)
+	conf.UpgradeID = upgrade.NewID()
+	conf.UseLinkMode = request.UseLinkMode
+	conf.MaxUpgradesPerHost = int(request.MaxUpgradesPerHost)
+	conf.MaxUpgrades = int(request.MaxUpgrades)
+
+	source, err := greenplum.ClusterFromDB(db.NewDBConn("localhost", int(request.SourcePort), "template1"), request.SourceBinDir)
+	if err != nil {
+		return nil, xerrors.Errorf("retrieving source configuration: %w", err)
+	}
+	conf.Source = source
+
+	var ports []int
+	for _, port := range request.Ports {
+		ports = append(ports, int(port))
+	}
+
+	conf.TargetInitializeConfig, err = AssignDatadirsAndPorts(source, ports, conf.UpgradeID)
+	if err != nil {
+		return nil, err
+	}
+
+	segs := append([]greenplum.SegConfig{conf.TargetInitializeConfig.Master}, conf.TargetInitializeConfig.Primaries...)
+	conf.Target, err = greenplum.NewCluster(segs)
+	if err != nil {
+		return nil, err
+	}
+	conf.Target.BinDir = request.TargetBinDir
+
+	conn := db.NewDBConn("localhost", int(request.SourcePort), "template1")
+	if err := conn.Connect(1); err != nil {
+		return nil, xerrors.Errorf("connecting to the source cluster: %w", err)
+	}
+	defer conn.Close()
+
+	gpinitsystemConfig, err := initsystemConfig(conf.TargetInitializeConfig, conf.RemoteShell.GpinitsystemShell(), conn)
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(plan)
+	if err := New(conf, nil, stateDir).planInitialize(p, request, gpinitsystemConfig, checks); err != nil {
+		return nil, err
+	}
+
+	return p.actions, nil
+}
+
+// planInitialize adds the actions of initialize. The configuration must
+// already describe the source cluster and the layout of the target cluster.
+func (s *Server) planInitialize(p *plan, request *idl.InitializeRequest, gpinitsystemConfig []string, checks []string) error {
+	master := s.Source.MasterHostname()
+
+	p.note(idl.Substep_CREATING_DIRECTORIES, master, "create the state directory %s", s.StateDir)
+	p.note(idl.Substep_GENERATING_CONFIG, master, "write the initial configuration to %s", filepath.Join(s.StateDir, ConfigFileName))
+	p.note(idl.Substep_START_HUB, master, "start the hub on port %d", s.Port)
+	p.note(idl.Substep_GENERATING_CONFIG, master, "retrieve and check the source cluster configuration from port %d", request.SourcePort)
+
+	executor := s.RemoteShell.Executor()
+	_, local := executor.(LocalExecutor)
+
+	hosts := sortedHosts(AgentHosts(s.Source))
+	for _, host := range hosts {
+		if s.TLS.Enabled() && !local {
+			dir := certs.Dir(s.StateDir)
+			p.remote(idl.Substep_START_AGENTS, master, fmt.Sprintf("create the certificate directory on %s", host),
+				executor, host, "mkdir -p -m 0700 "+dir)
+			for _, path := range certs.AgentFiles(s.StateDir) {
+				p.remote(idl.Substep_START_AGENTS, master, fmt.Sprintf("copy %s to %s", path, host),
+					executor, host, "umask 077 && cat > "+path)
+			}
+		}
+
+		agentCmd, err := agentStartCommand(s.AgentPort, s.MetricsAddress, s.AgentMetricsPort, s.StateDir, s.TLS)
+		if err != nil {
+			return err
+		}
+		p.remote(idl.Substep_START_AGENTS, master, fmt.Sprintf("start an agent on %s, unless one is running", host),
+			executor, host, agentCmd)
+	}
+
+	for _, check := range checks {
+		p.note(idl.Substep_RUN_CHECKS, master, "check that %s", check)
+	}
+
+	p.note(idl.Substep_CHECK_TARGET_PORTS, master, "check that the ports of the target cluster are free on every host, and replace those in use")
+
+	confPath := initsystemConfPath(s.StateDir)
+	p.write(idl.Substep_CREATE_TARGET_CONFIG, master, confPath, strings.Join(gpinitsystemConfig, "\n"))
+	p.run(idl.Substep_INIT_TARGET_CLUSTER, master, "create the target cluster",
+		"bash", "-c", initsystemScript(s.Target, confPath))
+	p.run(idl.Substep_SHUTDOWN_TARGET_CLUSTER, master, "stop the target cluster",
+		"bash", "-c", s.Target.StopScript())
+	p.sync(idl.Substep_BACKUP_TARGET_MASTER, master, "back up the target master",
+		[]string{s.Target.MasterDataDir() + "/"}, filepath.Join(s.StateDir, originalMasterBackupName), masterSyncOptions)
+
+	path, args := upgrade.Command(masterPair(s.Source, s.Target), masterOptions(true, s.UseLinkMode, nil)...)
+	p.run(idl.Substep_CHECK_UPGRADE, master, "check the master", path, args...)
+
+	return s.planPrimaryUpgrades(p, idl.Substep_CHECK_UPGRADE, true, "", nil)
+}
+
+// planExecute adds the actions of execute. When retryFailedOnly is set, the
+// primaries already upgraded by an earlier attempt are skipped, as Execute
+// skips them.
+func (s *Server) planExecute(p *plan, retryFailedOnly bool) error {
+	master := s.Source.MasterHostname()
+
+	p.run(idl.Substep_SHUTDOWN_SOURCE_CLUSTER, master, "stop the source cluster",
+		"bash", "-c", s.Source.StopScript())
+
+	p.sync(idl.Substep_UPGRADE_MASTER, master, "restore the target master from its backup",
+		[]string{filepath.Join(s.StateDir, originalMasterBackupName) + "/"}, s.Target.MasterDataDir(), masterSyncOptions)
+	path, args := upgrade.Command(masterPair(s.Source, s.Target), masterOptions(false, s.UseLinkMode, nil)...)
+	p.run(idl.Substep_UPGRADE_MASTER, master, "upgrade the master", path, args...)
+
+	upgradedMasterBackupDir := filepath.Join(s.StateDir, executeMasterBackupName)
+	for _, host := range sortedHosts(s.Target.PrimaryHostnames()) {
+		p.sync(idl.Substep_COPY_MASTER, master, fmt.Sprintf("copy the upgraded master to %s", host),
+			s.masterDataDirSource(), host+":"+upgradedMasterBackupDir, dirsync.Options{Delete: true})
+		p.note(idl.Substep_COPY_MASTER, host, "verify %s against the checksums of %s", upgradedMasterBackupDir, s.Target.MasterDataDir())
+
+		if s.Tablespaces != nil {
+			tablespaceDir := utils.GetTablespaceDir() + string(os.PathSeparator)
+			p.sync(idl.Substep_COPY_MASTER, master, fmt.Sprintf("copy the master tablespaces to %s", host),
+				s.masterTablespaceSources(), host+":"+tablespaceDir, dirsync.Options{Delete: true})
+			p.note(idl.Substep_COPY_MASTER, host, "verify %s against the checksums of the master tablespaces", tablespaceDir)
+		}
+	}
+
+	var completed map[int32]bool
+	if retryFailedOnly {
+		var err error
+		segmentStore := step.NewSegmentStore(filepath.Join(s.StateDir, step.SegmentStatusFileName))
+		completed, err = segmentStore.Completed(idl.Substep_UPGRADE_PRIMARIES)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.planPrimaryUpgrades(p, idl.Substep_UPGRADE_PRIMARIES, false, upgradedMasterBackupDir, completed); err != nil {
+		return err
+	}
+
+	p.run(idl.Substep_START_TARGET_CLUSTER, master, "start the target cluster",
+		"bash", "-c", s.Target.StartScript())
+
+	return nil
+}
+
+// planPrimaryUpgrades adds the commands that the agents run to upgrade, or
+// only check, their primaries. The primaries in completed are skipped, and
+// the limits on how many run at once are noted, as in UpgradePrimaries.
+func (s *Server) planPrimaryUpgrades(p *plan, substep idl.Substep, checkOnly bool, masterBackupDir string, completed map[int32]bool) error {
+	pairs, err := s.GetDataDirPairs()
+	if err != nil {
+		return xerrors.Errorf("getting source and target primary data directories: %w", err)
+	}
+
+	verb := "upgrade"
+	if checkOnly {
+		verb = "check"
+	}
+
+	if limits := concurrencyLimits(s.MaxUpgradesPerHost, s.MaxUpgrades); limits != "" {
+		p.note(substep, s.Source.MasterHostname(), "%s the primaries %s", verb, limits)
+	}
+
+	var hosts []string
+	for host := range pairs {
+		hosts = append(hosts, host)
+	}
+
+	for _, host := range sortedHosts(hosts) {
+		remaining, skipped := excludeCompleted(host, pairs[host], completed)
+		for _, result := range skipped {
+			p.note(substep, host, "skip content %d, which was upgraded by an earlier attempt", result.Content)
+		}
+
+		for _, pair := range remaining {
+			if !checkOnly {
+				p.sync(substep, host, fmt.Sprintf("restore content %d from the upgraded master", pair.Content),
+					[]string{masterBackupDir + "/"}, pair.TargetDataDir, dirsync.Options{Delete: true, Exclude: upgrade.SegmentBackupExclusions})
+				s.planTablespaceRestores(p, substep, host, pair)
+			}
+
+			dbid := int(pair.DBID)
+			segmentPair := upgrade.SegmentPair{
+				Source: &upgrade.Segment{BinDir: s.Source.BinDir, DataDir: pair.SourceDataDir, DBID: dbid, Port: int(pair.SourcePort)},
+				Target: &upgrade.Segment{BinDir: s.Target.BinDir, DataDir: pair.TargetDataDir, DBID: dbid, Port: int(pair.TargetPort)},
+			}
+
+			options := []upgrade.Option{upgrade.WithSegmentMode()}
+			if checkOnly {
+				options = append(options, upgrade.WithCheckOnly())
+			} else {
+				options = append(options, upgrade.WithTablespaceFile(s.TablespacesMappingFilePath))
+			}
+
+			if s.UseLinkMode {
+				options = append(options, upgrade.WithLinkMode())
+			}
+
+			path, args := upgrade.Command(segmentPair, options...)
+			p.run(substep, host, fmt.Sprintf("%s content %d", verb, pair.Content), path, args...)
+		}
+	}
+
+	return nil
+}
+
+// concurrencyLimits describes the limits on the primaries that are upgraded at
+// once, or returns an empty string when there are none.
+func concurrencyLimits(perHost, cluster int) string {
+	var limits []string
+	if perHost > 0 {
+		limits = append(limits, fmt.Sprintf("at most %d at once on each host", perHost))
+	}
+	if cluster > 0 {
+		limits = append(limits, fmt.Sprintf("at most %d at once across the cluster", cluster))
+	}
+
+	return strings.Join(limits, " and ")
+}
+
+func (s *Server) planTablespaceRestores(p *plan, substep idl.Substep, host string, pair *idl.DataDirPair) {
+	var oids []int
+	for oid, tablespace := range pair.Tablespaces {
+		if tablespace.GetUserDefined() {
+			oids = append(oids, int(oid))
+		}
+	}
+	sort.Ints(oids)
+
+	for _, oid := range oids {
+		targetDir := greenplum.GetTablespaceLocationForDbId(pair.Tablespaces[int32(oid)], int(pair.DBID))
+		sourceDir := greenplum.GetMasterTablespaceLocation(filepath.Dir(s.TablespacesMappingFilePath), oid)
+
+		p.sync(substep, host, fmt.Sprintf("restore tablespace %d of content %d", oid, pair.Content),
+			[]string{sourceDir + "/"}, targetDir, dirsync.Options{Delete: true})
+	}
+}
+
+func (s *Server) planFinalize(p *plan) error {
+	master := s.Source.MasterHostname()
+
+	p.run(idl.Substep_SHUTDOWN_TARGET_CLUSTER, master, "stop the target cluster",
+		"bash", "-c", s.Target.StopScript())
+	p.note(idl.Substep_UPDATE_TARGET_CATALOG_AND_CLUSTER_CONFIG, master,
+		"start the target master alone, and update its catalog to use the source data directories and ports")
+
+	sourceDir := s.Source.MasterDataDir()
+	targetDir := s.TargetInitializeConfig.Master.DataDir
+	p.note(idl.Substep_UPDATE_DATA_DIRECTORIES, master, "rename %s to %s", sourceDir, targetDir+upgrade.OldSuffix)
+	p.note(idl.Substep_UPDATE_DATA_DIRECTORIES, master, "rename %s to %s", targetDir, sourceDir)
+
+	hosts := sortedHosts(AgentHosts(s.Source))
+	if s.UseLinkMode {
+		for _, host := range hosts {
+			datadirs := dataDirectoriesOnHost(s.Source, host, false)
+			if len(datadirs) > 0 {
+				p.note(idl.Substep_UPDATE_DATA_DIRECTORIES, host,
+					"delete the source mirror and standby data directories %s", strings.Join(datadirs, ", "))
+			}
+		}
+	}
+
+	renames := getRenameMap(s.Source, s.TargetInitializeConfig, s.UseLinkMode)
+	for _, host := range hosts {
+		for _, rename := range renames[host] {
+			p.note(idl.Substep_UPDATE_DATA_DIRECTORIES, host, "rename %s to %s", rename.Source, rename.Target+upgrade.OldSuffix)
+			if rename.RenameTarget {
+				p.note(idl.Substep_UPDATE_DATA_DIRECTORIES, host, "rename %s to %s", rename.Target, rename.Source)
+			}
+		}
+	}
+
+	// By now the upgraded master has taken over the source master's data
+	// directory.
+	p.run(idl.Substep_UPDATE_TARGET_CONF_FILES, master, "update gpperfmon.conf",
+		"sed", gpperfmonConfArgs(sourceDir)...)
+	p.run(idl.Substep_UPDATE_TARGET_CONF_FILES, master, "update postgresql.conf",
+		"sed", postgresqlConfArgs(sourceDir, s.TargetInitializeConfig.Master.Port, s.Source.MasterPort())...)
+
+	finalTarget := &greenplum.Cluster{BinDir: s.Target.BinDir, Primaries: s.Source.Primaries}
+	p.run(idl.Substep_START_TARGET_CLUSTER, master, "start the target cluster",
+		"bash", "-c", finalTarget.StartScript())
+
+	if s.Source.HasStandby() {
+		standby := s.Source.Mirrors[-1]
+		err := UpgradeStandby(planRunner{p, idl.Substep_UPGRADE_STANDBY, master, s.Target.BinDir}, StandbyConfig{
+			Port:          standby.Port,
+			Hostname:      standby.Hostname,
+			DataDirectory: standby.DataDir,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.Source.HasMirrors() {
+		mirrors := s.Source.SelectSegments(func(seg *greenplum.SegConfig) bool {
+			return seg.IsMirror()
+		})
+
+		var contents bytes.Buffer
+		if err := writeGpAddmirrorsConfig(mirrors, &contents); err != nil {
+			return err
+		}
+
+		path := addMirrorsConfigPath(s.StateDir)
+		p.write(idl.Substep_UPGRADE_MIRRORS, master, path, contents.String())
+
+		if err := runAddMirrors(planRunner{p, idl.Substep_UPGRADE_MIRRORS, master, s.Target.BinDir}, path); err != nil {
+			return err
+		}
+
+		p.note(idl.Substep_UPGRADE_MIRRORS, master, "wait up to %s for the mirrors to synchronize", defaultFTSTimeout)
+	}
+
+	return nil
+}
+
+func (s *Server) planRevert(p *plan) error {
+	master := s.Source.MasterHostname()
+	hosts := sortedHosts(AgentHosts(s.Source))
+
+	if len(s.Target.Primaries) > 0 {
+		for _, host := range hosts {
+			datadirs := dataDirectoriesOnHost(s.Target, host, true)
+			if len(datadirs) > 0 {
+				p.note(idl.Substep_DELETE_PRIMARY_DATADIRS, host,
+					"delete the target primary data directories %s", strings.Join(datadirs, ", "))
+			}
+		}
+
+		p.note(idl.Substep_DELETE_MASTER_DATADIR, s.Target.MasterHostname(),
+			"delete the target master data directory %s", s.Target.MasterDataDir())
+	}
+
+	oldDir, err := utils.GetLogDir()
+	if err != nil {
+		return err
+	}
+	newDir := filepath.Join(filepath.Dir(oldDir), utils.GetArchiveDirectoryName())
+
+	p.note(idl.Substep_ARCHIVE_LOG_DIRECTORIES, master, "rename the log directory %s to %s", oldDir, newDir)
+	for _, host := range hosts {
+		if host != s.Target.MasterHostname() {
+			p.note(idl.Substep_ARCHIVE_LOG_DIRECTORIES, host, "rename the log directory %s to %s", oldDir, newDir)
+		}
+	}
+
+	for _, host := range hosts {
+		if host != master {
+			p.note(idl.Substep_DELETE_SEGMENT_STATEDIRS, host, "delete the state directory %s", s.StateDir)
+		}
+	}
+
+	p.note(idl.Substep_STOP_HUB_AND_AGENTS, master, "stop the hub and agents")
+	p.note(idl.Substep_DELETE_MASTER_STATEDIR, master, "delete the state directory %s", s.StateDir)
+
+	return nil
+}
+
+// plan collects the actions that a step would perform.
+type plan struct {
+	actions []*idl.PlannedAction
+}
+
+// note adds an action that does not run a command, such as one carried out by
+// an agent.
+func (p *plan) note(substep idl.Substep, host, format string, a ...interface{}) {
+	p.actions = append(p.actions, &idl.PlannedAction{
+		Substep:     substep,
+		Host:        host,
+		Description: fmt.Sprintf(format, a...),
+	})
+}
+
+// run adds an action that runs a command on host.
+func (p *plan) run(substep idl.Substep, host, description, name string, args ...string) {
+	p.actions = append(p.actions, &idl.PlannedAction{
+		Substep:     substep,
+		Host:        host,
+		Description: description,
+		Command:     shellquote.Join(append([]string{name}, args...)...),
+	})
+}
+
+// remote adds an action that runs script on target using executor.
+func (p *plan) remote(substep idl.Substep, host, description string, executor RemoteExecutor, target, script string) {
+	args := executor.Args(target, script)
+	p.run(substep, host, description, args[0], args[1:]...)
+}
+
+// sync adds an action that mirrors the sources into target, in the manner of
+// dirsync.
+func (p *plan) sync(substep idl.Substep, host, description string, sources []string, target string, opts dirsync.Options) {
+	details := fmt.Sprintf("sync %s into %s", strings.Join(sources, " "), target)
+	if len(opts.Exclude) > 0 {
+		details += ", excluding " + strings.Join(opts.Exclude, " ")
+	}
+	if opts.Delete {
+		details += ", deleting anything else"
+	}
+
+	p.note(substep, host, "%s: %s", description, details)
+}
+
+// write adds an action that writes a file on host.
+func (p *plan) write(substep idl.Substep, host, path, contents string) {
+	p.actions = append(p.actions, &idl.PlannedAction{
+		Substep:     substep,
+		Host:        host,
+		Description: fmt.Sprintf("write %s", path),
+		Contents:    contents,
+	})
+}
+
+// planRunner is a greenplum.Runner that adds the utilities it is asked to run
+// to a plan, instead of running them.
+type planRunner struct {
+	plan    *plan
+	substep idl.Substep
+	host    string
+	binDir  string
+}
+
+func (r planRunner) Run(utilityName string, arguments ...string) error {
+	r.plan.run(r.substep, r.host, fmt.Sprintf("run %s", utilityName),
+		"bash", "-c", greenplum.UtilityScript(r.binDir, utilityName, arguments...))
+	return nil
+}
+
+func sortedHosts(hosts []string) []string {
+	sorted := append([]string(nil), hosts...)
+	sort.Strings(sorted)
+	return sorted
+}
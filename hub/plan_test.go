@@ -0,0 +1,280 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
+)
+
+func TestPlan(t *testing.T) {
+	testhelper.SetupTestLogger()
+
+	source := MustCreateCluster(t, []greenplum.SegConfig{
+		{ContentID: -1, DbID: 1, Port: 15432, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: "p"},
+		{ContentID: -1, DbID: 2, Port: 16432, Hostname: "smdw", DataDir: "/data/standby", Role: "m"},
+		{ContentID: 0, DbID: 3, Port: 25432, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1", Role: "p"},
+		{ContentID: 0, DbID: 4, Port: 25433, Hostname: "sdw2", DataDir: "/data/dbfast_mirror1/seg1", Role: "m"},
+		{ContentID: 1, DbID: 5, Port: 25434, Hostname: "sdw2", DataDir: "/data/dbfast2/seg2", Role: "p"},
+		{ContentID: 1, DbID: 6, Port: 25435, Hostname: "sdw1", DataDir: "/data/dbfast_mirror2/seg2", Role: "m"},
+	})
+	source.BinDir = "/source/bin"
+
+	target := MustCreateCluster(t, []greenplum.SegConfig{
+		{ContentID: -1, DbID: 1, Port: 50432, Hostname: "mdw", DataDir: "/data/qddir/seg-1_ABC-1", Role: "p"},
+		{ContentID: 0, DbID: 3, Port: 50434, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1_ABC", Role: "p"},
+		{ContentID: 1, DbID: 5, Port: 50435, Hostname: "sdw2", DataDir: "/data/dbfast2/seg2_ABC", Role: "p"},
+	})
+	target.BinDir = "/target/bin"
+
+	targetInit := InitializeConfig{
+		Master:  target.Primaries[-1],
+		Standby: greenplum.SegConfig{ContentID: -1, DbID: 2, Port: 50433, Hostname: "smdw", DataDir: "/data/standby_ABC", Role: "m"},
+		Primaries: []greenplum.SegConfig{
+			target.Primaries[0],
+			target.Primaries[1],
+		},
+		Mirrors: []greenplum.SegConfig{
+			{ContentID: 0, DbID: 4, Port: 50436, Hostname: "sdw2", DataDir: "/data/dbfast_mirror1/seg1_ABC", Role: "m"},
+			{ContentID: 1, DbID: 6, Port: 50437, Hostname: "sdw1", DataDir: "/data/dbfast_mirror2/seg2_ABC", Role: "m"},
+		},
+	}
+
+	newServer := func() *Server {
+		conf := &Config{
+			Source:                 source,
+			Target:                 target,
+			TargetInitializeConfig: targetInit,
+			Port:                   7527,
+			AgentPort:              6416,
+		}
+		return New(conf, nil, "/state")
+	}
+
+	// Nothing may be run while planning.
+	execCommand = nil
+	syncDirectory = nil
+	defer func() {
+		execCommand = exec.Command
+		syncDirectory = dirsync.Sync
+	}()
+
+	t.Run("execute upgrades each primary on its own host", func(t *testing.T) {
+		p := new(plan)
+		if err := newServer().planExecute(p, false); err != nil {
+			t.Fatalf("planExecute() returned error %+v", err)
+		}
+
+		expected := []string{
+			"sdw1: restore content 0 from the upgraded master",
+			"sdw1: upgrade content 0",
+			"sdw2: restore content 1 from the upgraded master",
+			"sdw2: upgrade content 1",
+		}
+		if actual := describe(p.actions, idl.Substep_UPGRADE_PRIMARIES); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got %q want %q", actual, expected)
+		}
+
+		upgradeContent0 := p.actions[indexOf(t, p.actions, "upgrade content 0")].Command
+		for _, arg := range []string{"/target/bin/pg_upgrade", "--mode segment", "--old-datadir /data/dbfast1/seg1", "--new-datadir /data/dbfast1/seg1_ABC"} {
+			if !strings.Contains(upgradeContent0, arg) {
+				t.Errorf("command %q does not contain %q", upgradeContent0, arg)
+			}
+		}
+
+		restore := p.actions[indexOf(t, p.actions, "restore content 1 from the upgraded master")].Description
+		expectedRestore := "sync /state/upgraded-master.bak/ into /data/dbfast2/seg2_ABC, excluding " +
+			strings.Join(upgrade.SegmentBackupExclusions, " ") + ", deleting anything else"
+		if !strings.HasSuffix(restore, expectedRestore) {
+			t.Errorf("got description %q, want it to end with %q", restore, expectedRestore)
+		}
+
+		expected = []string{
+			"mdw: copy the upgraded master to sdw1",
+			"sdw1: verify /state/upgraded-master.bak against the checksums of /data/qddir/seg-1_ABC-1",
+			"mdw: copy the upgraded master to sdw2",
+			"sdw2: verify /state/upgraded-master.bak against the checksums of /data/qddir/seg-1_ABC-1",
+		}
+		if actual := describe(p.actions, idl.Substep_COPY_MASTER); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got %q want %q", actual, expected)
+		}
+	})
+
+	t.Run("execute skips the primaries already upgraded and notes the limits", func(t *testing.T) {
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		store := step.NewSegmentStore(filepath.Join(stateDir, step.SegmentStatusFileName))
+		if err := store.MarkCompleted(idl.Substep_UPGRADE_PRIMARIES, 0); err != nil {
+			t.Fatalf("MarkCompleted() returned error %+v", err)
+		}
+
+		s := newServer()
+		s.StateDir = stateDir
+		s.MaxUpgradesPerHost = 1
+		s.MaxUpgrades = 2
+
+		p := new(plan)
+		if err := s.planExecute(p, true); err != nil {
+			t.Fatalf("planExecute() returned error %+v", err)
+		}
+
+		expected := []string{
+			"mdw: upgrade the primaries at most 1 at once on each host and at most 2 at once across the cluster",
+			"sdw1: skip content 0, which was upgraded by an earlier attempt",
+			"sdw2: restore content 1 from the upgraded master",
+			"sdw2: upgrade content 1",
+		}
+		if actual := describe(p.actions, idl.Substep_UPGRADE_PRIMARIES); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got %q want %q", actual, expected)
+		}
+
+		// Without retrying only the failed segments, every primary is upgraded.
+		p = new(plan)
+		if err := s.planExecute(p, false); err != nil {
+			t.Fatalf("planExecute() returned error %+v", err)
+		}
+
+		if actual := describe(p.actions, idl.Substep_UPGRADE_PRIMARIES); len(actual) != 5 {
+			t.Errorf("got %q, want the limits and both primaries", actual)
+		}
+	})
+
+	t.Run("finalize renames the data directories and writes the gpaddmirrors configuration", func(t *testing.T) {
+		p := new(plan)
+		if err := newServer().planFinalize(p); err != nil {
+			t.Fatalf("planFinalize() returned error %+v", err)
+		}
+
+		expected := []string{
+			"mdw: rename /data/qddir/seg-1 to /data/qddir/seg-1_ABC-1.old",
+			"mdw: rename /data/qddir/seg-1_ABC-1 to /data/qddir/seg-1",
+			"sdw1: rename /data/dbfast1/seg1 to /data/dbfast1/seg1_ABC.old",
+			"sdw1: rename /data/dbfast1/seg1_ABC to /data/dbfast1/seg1",
+			"sdw1: rename /data/dbfast_mirror2/seg2 to /data/dbfast_mirror2/seg2_ABC.old",
+			"sdw2: rename /data/dbfast2/seg2 to /data/dbfast2/seg2_ABC.old",
+			"sdw2: rename /data/dbfast2/seg2_ABC to /data/dbfast2/seg2",
+			"sdw2: rename /data/dbfast_mirror1/seg1 to /data/dbfast_mirror1/seg1_ABC.old",
+			"smdw: rename /data/standby to /data/standby_ABC.old",
+		}
+		if actual := describe(p.actions, idl.Substep_UPDATE_DATA_DIRECTORIES); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got %q want %q", actual, expected)
+		}
+
+		config := p.actions[indexOf(t, p.actions, "write /state/add_mirrors_config")].Contents
+		expectedConfig := "0|sdw2|25433|/data/dbfast_mirror1/seg1\n1|sdw1|25435|/data/dbfast_mirror2/seg2\n"
+		if config != expectedConfig {
+			t.Errorf("got add_mirrors_config %q want %q", config, expectedConfig)
+		}
+
+		addMirrors := p.actions[indexOf(t, p.actions, "run gpaddmirrors")].Command
+		if !strings.Contains(addMirrors, "gpaddmirrors -a -i /state/add_mirrors_config") {
+			t.Errorf("got command %q, want it to run gpaddmirrors with the configuration", addMirrors)
+		}
+	})
+
+	t.Run("revert deletes the target primary data directories on each host", func(t *testing.T) {
+		p := new(plan)
+		if err := newServer().planRevert(p); err != nil {
+			t.Fatalf("planRevert() returned error %+v", err)
+		}
+
+		expected := []string{
+			"sdw1: delete the target primary data directories /data/dbfast1/seg1_ABC",
+			"sdw2: delete the target primary data directories /data/dbfast2/seg2_ABC",
+		}
+		if actual := describe(p.actions, idl.Substep_DELETE_PRIMARY_DATADIRS); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got %q want %q", actual, expected)
+		}
+	})
+
+	t.Run("initialize runs the checks, writes the gpinitsystem configuration and checks each segment", func(t *testing.T) {
+		p := new(plan)
+		request := &idl.InitializeRequest{SourcePort: 15432}
+		gpinitsystemConfig := []string{`ARRAY_NAME="gp_upgrade cluster"`, "SEG_PREFIX=seg"}
+
+		checks := []string{"every host has enough free disk space"}
+
+		if err := newServer().planInitialize(p, request, gpinitsystemConfig, checks); err != nil {
+			t.Fatalf("planInitialize() returned error %+v", err)
+		}
+
+		expectedChecks := []string{"mdw: check that every host has enough free disk space"}
+		if actual := describe(p.actions, idl.Substep_RUN_CHECKS); !reflect.DeepEqual(actual, expectedChecks) {
+			t.Errorf("got %q want %q", actual, expectedChecks)
+		}
+
+		config := p.actions[indexOf(t, p.actions, "write /state/gpinitsystem_config")].Contents
+		expectedConfig := "ARRAY_NAME=\"gp_upgrade cluster\"\nSEG_PREFIX=seg"
+		if config != expectedConfig {
+			t.Errorf("got gpinitsystem_config %q want %q", config, expectedConfig)
+		}
+
+		expected := []string{
+			"mdw: check the master",
+			"sdw1: check content 0",
+			"sdw2: check content 1",
+		}
+		if actual := describe(p.actions, idl.Substep_CHECK_UPGRADE); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("got %q want %q", actual, expected)
+		}
+
+		for _, action := range p.actions {
+			if action.Substep == idl.Substep_CHECK_UPGRADE && !strings.Contains(action.Command, "--check") {
+				t.Errorf("command %q does not contain --check", action.Command)
+			}
+		}
+	})
+
+	t.Run("errors for a step that cannot be planned", func(t *testing.T) {
+		_, err := newServer().Plan(context.Background(), &idl.PlanRequest{Step: "status"})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("got error %+v want code %v", err, codes.InvalidArgument)
+		}
+	})
+}
+
+// describe returns the host and description of each action in the substep.
+// The details that follow the description of a sync are left out.
+func describe(actions []*idl.PlannedAction, substep idl.Substep) []string {
+	var described []string
+	for _, action := range actions {
+		if action.Substep == substep {
+			described = append(described, action.Host+": "+summary(action))
+		}
+	}
+	return described
+}
+
+func indexOf(t *testing.T, actions []*idl.PlannedAction, description string) int {
+	t.Helper()
+
+	for i, action := range actions {
+		if summary(action) == description {
+			return i
+		}
+	}
+
+	t.Fatalf("no action %q in the plan", description)
+	return -1
+}
+
+func summary(action *idl.PlannedAction) string {
+	return strings.SplitN(action.Description, ": ", 2)[0]
+}
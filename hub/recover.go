@@ -0,0 +1,240 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gp-common-go-libs/operating"
+	"github.com/hashicorp/go-multierror"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/upgrade"
+)
+
+var CheckArchiveSource = upgrade.CheckArchiveSource
+
+var ErrStepRunning = errors.New(`a step is still running; wait for it to finish, or stop it with "gpupgrade cancel", before recovering`)
+
+// recoveryChecks returns the manual cleanup, if any, that must be done before a
+// substep that was interrupted while RUNNING can be retried. Substeps that are
+// not listed are idempotent, and can always be safely retried.
+var recoveryChecks = map[idl.Substep]func(*Server) ([]string, error){
+	idl.Substep_INIT_TARGET_CLUSTER:     (*Server).initTargetClusterCleanup,
+	idl.Substep_UPGRADE_MASTER:          (*Server).upgradeMasterCleanup,
+	idl.Substep_UPGRADE_PRIMARIES:       (*Server).upgradePrimariesCleanup,
+	idl.Substep_UPDATE_DATA_DIRECTORIES: (*Server).updateDataDirectoriesCleanup,
+	idl.Substep_UPGRADE_STANDBY:         (*Server).upgradeStandbyCleanup,
+	idl.Substep_UPGRADE_MIRRORS:         (*Server).upgradeMirrorsCleanup,
+}
+
+// Recover finds the substeps that were left RUNNING when the hub was
+// interrupted. Those that can be safely retried, and the one named by
+// ForceRetry if any, are reset to FAILED so that they are run again by the
+// next invocation of their section. The manual cleanup needed for the rest is
+// returned instead.
+//
+// Substeps that this hub is still running are also RUNNING, so Recover refuses
+// to do anything while any step is running. The lock is held throughout, so
+// that no step can start while substeps are being reset.
+func (s *Server) Recover(ctx context.Context, in *idl.RecoverRequest) (*idl.RecoverReply, error) {
+	s.cancelMu.Lock()
+	defer s.cancelMu.Unlock()
+
+	if len(s.cancels) > 0 {
+		return nil, ErrStepRunning
+	}
+
+	status, err := ReadStatus(s.StateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	store := step.NewFileStore(filepath.Join(s.StateDir, step.StatusFileName))
+	reply := &idl.RecoverReply{}
+	forced := false
+
+	for _, section := range status.Sections {
+		for _, record := range section.Substeps {
+			if record.Status != idl.Status_RUNNING {
+				continue
+			}
+
+			recovered := &idl.RecoveredSubstep{
+				Section: section.Name,
+				Step:    record.Step,
+			}
+			reply.Substeps = append(reply.Substeps, recovered)
+
+			if check, ok := recoveryChecks[record.Step]; ok {
+				recovered.ManualSteps, err = check(s)
+				if err != nil {
+					return nil, xerrors.Errorf("checking substep %s: %w", record.Step, err)
+				}
+			}
+
+			force := record.Step == in.ForceRetry
+			forced = forced || force
+
+			if len(recovered.ManualSteps) > 0 && !force {
+				continue
+			}
+
+			if err := resetSubstep(store, section.Name, record.Step, force); err != nil {
+				return nil, err
+			}
+			recovered.ResetToFailed = true
+		}
+	}
+
+	if in.ForceRetry != idl.Substep_UNKNOWN_SUBSTEP && !forced {
+		return nil, xerrors.Errorf("substep %s was not interrupted while running", in.ForceRetry)
+	}
+
+	return reply, nil
+}
+
+// resetSubstep marks an interrupted substep as FAILED, and records that it was
+// reset in the log of its section.
+func resetSubstep(store step.Store, section string, substep idl.Substep, forced bool) (err error) {
+	err = store.Fail(section, substep, xerrors.New("interrupted while running; reset by gpupgrade recover"))
+	if err != nil {
+		return xerrors.Errorf("resetting substep %s: %w", substep, err)
+	}
+
+	how := "it can be safely retried"
+	if forced {
+		how = "a retry was forced"
+	}
+
+	message := fmt.Sprintf("%s substep %s was interrupted while running, and has been reset to FAILED since %s.",
+		section, substep, how)
+	gplog.Info(message)
+
+	log, err := step.OpenLog(section)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := log.Close(); cErr != nil {
+			err = multierror.Append(err, cErr).ErrorOrNil()
+		}
+	}()
+
+	_, err = fmt.Fprintf(log, "\n%s %s\n", operating.System.Now().Format(time.RFC3339), message)
+	return err
+}
+
+func (s *Server) initTargetClusterCleanup() ([]string, error) {
+	steps := []string{
+		`gpinitsystem may have partially created the target cluster. Either run "gpupgrade revert" to start over, ` +
+			"or stop any running target cluster processes and delete the following target data directories:",
+		fmt.Sprintf("delete %s on host %s", s.TargetInitializeConfig.Master.DataDir, s.TargetInitializeConfig.Master.Hostname),
+	}
+
+	for _, seg := range s.TargetInitializeConfig.Primaries {
+		steps = append(steps, fmt.Sprintf("delete %s on host %s", seg.DataDir, seg.Hostname))
+	}
+
+	return steps, nil
+}
+
+// upgradeMasterCleanup only requires manual steps in link mode, where
+// pg_upgrade may have already modified the source master. In copy mode the
+// source is untouched, and the target master is restored before retrying.
+func (s *Server) upgradeMasterCleanup() ([]string, error) {
+	if !s.UseLinkMode {
+		return nil, nil
+	}
+
+	return linkModeCleanup([]greenplum.SegConfig{s.Source.Primaries[-1]}), nil
+}
+
+// upgradePrimariesCleanup only requires manual steps in link mode, where
+// pg_upgrade may have already modified the source primaries.
+func (s *Server) upgradePrimariesCleanup() ([]string, error) {
+	if !s.UseLinkMode {
+		return nil, nil
+	}
+
+	primaries := s.Source.SelectSegments(func(seg *greenplum.SegConfig) bool {
+		return seg.IsPrimary()
+	})
+
+	return linkModeCleanup(primaries), nil
+}
+
+func linkModeCleanup(segs []greenplum.SegConfig) []string {
+	steps := []string{
+		"pg_upgrade was run in link mode, and may have already modified the source cluster. " +
+			"A source data directory containing global/pg_control.old can no longer be started by the source cluster, " +
+			`and must be restored from a backup before running "gpupgrade revert". Check the following source data directories:`,
+	}
+
+	for _, seg := range segs {
+		steps = append(steps, fmt.Sprintf("check %s on host %s", seg.DataDir, seg.Hostname))
+	}
+
+	return steps
+}
+
+// updateDataDirectoriesCleanup looks for data directories that were left in a
+// state that ArchiveSource cannot recover from.
+func (s *Server) updateDataDirectoriesCleanup() ([]string, error) {
+	var steps []string
+
+	source := s.Source.MasterDataDir()
+	target := s.TargetInitializeConfig.Master.DataDir
+	if err := CheckArchiveSource(source, target, true); err != nil {
+		steps = append(steps, fmt.Sprintf("on host %s: %s", s.Source.MasterHostname(), err))
+	}
+
+	agentConns, err := s.AgentConns()
+	if err != nil {
+		return nil, xerrors.Errorf("connecting to gpupgrade agents: %w", err)
+	}
+
+	renameMap := getRenameMap(s.Source, s.TargetInitializeConfig, s.UseLinkMode)
+	problems, err := CheckRenameSegmentDataDirs(agentConns, renameMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(steps, problems...), nil
+}
+
+func (s *Server) upgradeStandbyCleanup() ([]string, error) {
+	standby := s.Source.Mirrors[-1]
+
+	return []string{
+		fmt.Sprintf("gpinitstandby may have partially added the standby. Using the target cluster installation in %s, "+
+			`remove any standby with "gpinitstandby -r", and delete %s on host %s.`,
+			s.Target.BinDir, standby.DataDir, standby.Hostname),
+	}, nil
+}
+
+func (s *Server) upgradeMirrorsCleanup() ([]string, error) {
+	steps := []string{
+		"gpaddmirrors may have partially added the mirrors. Using the target cluster installation in " + s.Target.BinDir +
+			", check gp_segment_configuration and delete the data directories of any mirrors that were not added:",
+	}
+
+	mirrors := s.Source.SelectSegments(func(seg *greenplum.SegConfig) bool {
+		return seg.IsMirror()
+	})
+	for _, seg := range mirrors {
+		steps = append(steps, fmt.Sprintf("delete %s on host %s if mirror content %d is not in gp_segment_configuration",
+			seg.DataDir, seg.Hostname, seg.ContentID))
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,276 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+func TestRecover(t *testing.T) {
+	testhelper.SetupTestLogger()
+
+	stateDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stateDir)
+
+	// Keep the audit logs out of the real log directory.
+	utils.System.CurrentUser = func() (*user.User, error) {
+		return &user.User{HomeDir: stateDir}, nil
+	}
+	defer func() { utils.System.CurrentUser = user.Current }()
+
+	logDir, err := utils.GetLogDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(logDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &hub.Config{
+		TargetInitializeConfig: hub.InitializeConfig{
+			Master: greenplum.SegConfig{ContentID: -1, Hostname: "mdw", DataDir: "/data/qddir/seg.ABC.-1"},
+			Primaries: []greenplum.SegConfig{
+				{ContentID: 0, Hostname: "sdw1", DataDir: "/data/dbfast1/seg.ABC.0"},
+			},
+		},
+	}
+	h := hub.New(conf, nil, stateDir)
+
+	statusPath := filepath.Join(stateDir, step.StatusFileName)
+	store := step.NewFileStore(statusPath)
+
+	setStatus := func(t *testing.T, section string, statuses map[idl.Substep]idl.Status) {
+		t.Helper()
+
+		if err := ioutil.WriteFile(statusPath, []byte("{}"), 0600); err != nil {
+			t.Fatalf("clearing status file: %v", err)
+		}
+
+		for substep, status := range statuses {
+			if err := store.Write(section, substep, status); err != nil {
+				t.Fatalf("Write(): %+v", err)
+			}
+		}
+	}
+
+	readStatus := func(t *testing.T, section string, substep idl.Substep) step.Entry {
+		t.Helper()
+
+		entries, err := store.ReadAll()
+		if err != nil {
+			t.Fatalf("ReadAll(): %+v", err)
+		}
+
+		return entries[section][substep]
+	}
+
+	t.Run("resets interrupted substeps that can be safely retried", func(t *testing.T) {
+		setStatus(t, "execute", map[idl.Substep]idl.Status{
+			idl.Substep_SHUTDOWN_SOURCE_CLUSTER: idl.Status_COMPLETE,
+			idl.Substep_UPGRADE_PRIMARIES:       idl.Status_RUNNING,
+		})
+
+		reply, err := h.Recover(context.Background(), &idl.RecoverRequest{})
+		if err != nil {
+			t.Fatalf("Recover() returned error %+v", err)
+		}
+
+		expected := []*idl.RecoveredSubstep{{
+			Section:       "execute",
+			Step:          idl.Substep_UPGRADE_PRIMARIES,
+			ResetToFailed: true,
+		}}
+		if !reflect.DeepEqual(reply.Substeps, expected) {
+			t.Errorf("got %v want %v", reply.Substeps, expected)
+		}
+
+		entry := readStatus(t, "execute", idl.Substep_UPGRADE_PRIMARIES)
+		if entry.Status.Status != idl.Status_FAILED {
+			t.Errorf("got status %v want %v", entry.Status.Status, idl.Status_FAILED)
+		}
+		if !strings.Contains(entry.LastError, "gpupgrade recover") {
+			t.Errorf("got last error %q, want it to mention gpupgrade recover", entry.LastError)
+		}
+
+		if completed := readStatus(t, "execute", idl.Substep_SHUTDOWN_SOURCE_CLUSTER); completed.Status.Status != idl.Status_COMPLETE {
+			t.Errorf("got status %v want %v", completed.Status.Status, idl.Status_COMPLETE)
+		}
+
+		// An audit record is kept in the section's log.
+		logs, err := filepath.Glob(filepath.Join(logDir, "execute_*.log"))
+		if err != nil || len(logs) != 1 {
+			t.Fatalf("got logs %q (err %v), want one execute log", logs, err)
+		}
+
+		contents, err := ioutil.ReadFile(logs[0])
+		if err != nil {
+			t.Fatalf("reading log: %+v", err)
+		}
+
+		if !strings.Contains(string(contents), "UPGRADE_PRIMARIES was interrupted while running, and has been reset to FAILED") {
+			t.Errorf("log %q does not contain an audit record", contents)
+		}
+	})
+
+	t.Run("returns the manual cleanup for substeps that cannot be safely retried", func(t *testing.T) {
+		setStatus(t, "initialize", map[idl.Substep]idl.Status{
+			idl.Substep_INIT_TARGET_CLUSTER: idl.Status_RUNNING,
+		})
+
+		reply, err := h.Recover(context.Background(), &idl.RecoverRequest{})
+		if err != nil {
+			t.Fatalf("Recover() returned error %+v", err)
+		}
+
+		if len(reply.Substeps) != 1 {
+			t.Fatalf("got %d substeps want 1", len(reply.Substeps))
+		}
+
+		recovered := reply.Substeps[0]
+		if recovered.ResetToFailed {
+			t.Error("expected substep to not be reset")
+		}
+
+		manual := strings.Join(recovered.ManualSteps, "\n")
+		for _, dir := range []string{"/data/qddir/seg.ABC.-1", "/data/dbfast1/seg.ABC.0"} {
+			if !strings.Contains(manual, dir) {
+				t.Errorf("got manual steps %q, want them to contain %q", manual, dir)
+			}
+		}
+
+		if entry := readStatus(t, "initialize", idl.Substep_INIT_TARGET_CLUSTER); entry.Status.Status != idl.Status_RUNNING {
+			t.Errorf("got status %v want %v", entry.Status.Status, idl.Status_RUNNING)
+		}
+	})
+
+	t.Run("resets a substep that cannot be safely retried when forced", func(t *testing.T) {
+		setStatus(t, "initialize", map[idl.Substep]idl.Status{
+			idl.Substep_INIT_TARGET_CLUSTER: idl.Status_RUNNING,
+		})
+
+		reply, err := h.Recover(context.Background(), &idl.RecoverRequest{
+			ForceRetry: idl.Substep_INIT_TARGET_CLUSTER,
+		})
+		if err != nil {
+			t.Fatalf("Recover() returned error %+v", err)
+		}
+
+		if len(reply.Substeps) != 1 || !reply.Substeps[0].ResetToFailed {
+			t.Errorf("got %v, want INIT_TARGET_CLUSTER to be reset", reply.Substeps)
+		}
+
+		if entry := readStatus(t, "initialize", idl.Substep_INIT_TARGET_CLUSTER); entry.Status.Status != idl.Status_FAILED {
+			t.Errorf("got status %v want %v", entry.Status.Status, idl.Status_FAILED)
+		}
+	})
+
+	t.Run("returns the manual cleanup for pg_upgrade substeps in link mode", func(t *testing.T) {
+		linkConf := *conf
+		linkConf.UseLinkMode = true
+		linkConf.Source = hub.MustCreateCluster(t, []greenplum.SegConfig{
+			{ContentID: -1, DbID: 1, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: "p"},
+			{ContentID: 0, DbID: 2, Hostname: "sdw1", DataDir: "/data/dbfast1/seg0", Role: "p"},
+		})
+		linkHub := hub.New(&linkConf, nil, stateDir)
+
+		cases := []struct {
+			section string
+			substep idl.Substep
+			dir     string
+		}{
+			{"execute", idl.Substep_UPGRADE_MASTER, "/data/qddir/seg-1"},
+			{"execute", idl.Substep_UPGRADE_PRIMARIES, "/data/dbfast1/seg0"},
+		}
+
+		for _, c := range cases {
+			setStatus(t, c.section, map[idl.Substep]idl.Status{
+				c.substep: idl.Status_RUNNING,
+			})
+
+			reply, err := linkHub.Recover(context.Background(), &idl.RecoverRequest{})
+			if err != nil {
+				t.Fatalf("Recover() returned error %+v", err)
+			}
+
+			if len(reply.Substeps) != 1 || reply.Substeps[0].ResetToFailed {
+				t.Fatalf("got %v, want %s to not be reset", reply.Substeps, c.substep)
+			}
+
+			manual := strings.Join(reply.Substeps[0].ManualSteps, "\n")
+			if !strings.Contains(manual, c.dir) {
+				t.Errorf("got manual steps %q, want them to contain %q", manual, c.dir)
+			}
+
+			if entry := readStatus(t, c.section, c.substep); entry.Status.Status != idl.Status_RUNNING {
+				t.Errorf("got status %v want %v", entry.Status.Status, idl.Status_RUNNING)
+			}
+		}
+	})
+
+	t.Run("errors when forcing a substep that was not interrupted", func(t *testing.T) {
+		setStatus(t, "initialize", map[idl.Substep]idl.Status{
+			idl.Substep_INIT_TARGET_CLUSTER: idl.Status_COMPLETE,
+		})
+
+		_, err := h.Recover(context.Background(), &idl.RecoverRequest{
+			ForceRetry: idl.Substep_INIT_TARGET_CLUSTER,
+		})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestCheckRenameSegmentDataDirs(t *testing.T) {
+	testhelper.SetupTestLogger()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dirs := []*idl.RenameDirectories{{Source: "/data/dbfast1/seg1", Target: "/data/dbfast1/seg.ABC.1"}}
+
+	client1 := mock_idl.NewMockAgentClient(ctrl)
+	client1.EXPECT().CheckRenameDirectories(
+		gomock.Any(),
+		&idl.RenameDirectoriesRequest{Dirs: dirs},
+	).Return(&idl.CheckRenameDirectoriesReply{Problems: []string{"half renamed"}}, nil)
+
+	client2 := mock_idl.NewMockAgentClient(ctrl)
+	// client2 has no directories, so it must not be called.
+
+	agentConns := []*hub.Connection{
+		{nil, client1, "sdw1", nil},
+		{nil, client2, "sdw2", nil},
+	}
+
+	problems, err := hub.CheckRenameSegmentDataDirs(agentConns, hub.RenameMap{"sdw1": dirs})
+	if err != nil {
+		t.Errorf("unexpected error %+v", err)
+	}
+
+	expected := []string{"on host sdw1: half renamed"}
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("got problems %q want %q", problems, expected)
+	}
+}
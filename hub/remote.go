@@ -0,0 +1,153 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+// LocalShell is the RemoteShell.TrustedShell that runs commands on the hub's
+// own host, for clusters that live entirely on it.
+const LocalShell = "local"
+
+// RemoteShell configures how the hub runs commands on the cluster's other
+// hosts, such as starting the agents. The zero value runs them over ssh.
+type RemoteShell struct {
+	// TrustedShell is the ssh-compatible program used to reach other hosts,
+	// as in the TRUSTED_SHELL setting of gpinitsystem, or LocalShell. It
+	// defaults to ssh.
+	TrustedShell string
+
+	// User, IdentityFile and Options are passed to the trusted shell as the
+	// remote user, the -i identity file and -o options respectively.
+	User         string
+	IdentityFile string
+	Options      []string
+}
+
+// Executor returns the RemoteExecutor that the configuration describes.
+func (r RemoteShell) Executor() RemoteExecutor {
+	if r.TrustedShell == LocalShell {
+		return LocalExecutor{}
+	}
+
+	return SSHExecutor{
+		Program:      r.TrustedShell,
+		User:         r.User,
+		IdentityFile: r.IdentityFile,
+		Options:      r.Options,
+	}
+}
+
+// GpinitsystemShell returns the TRUSTED_SHELL for gpinitsystem, which always
+// reaches the segment hosts over ssh, even when they are all local.
+func (r RemoteShell) GpinitsystemShell() string {
+	if r.TrustedShell == "" || r.TrustedShell == LocalShell {
+		return "ssh"
+	}
+
+	return r.TrustedShell
+}
+
+// RemoteExecutor builds the commands that run shell scripts on the cluster's
+// hosts. Use runRemote to run them.
+type RemoteExecutor interface {
+	// Args returns the command line that runs script on host.
+	Args(host, script string) []string
+}
+
+// SSHExecutor runs commands on other hosts over ssh.
+type SSHExecutor struct {
+	Program      string // defaults to ssh
+	User         string
+	IdentityFile string
+	Options      []string // each is passed with -o
+}
+
+func (e SSHExecutor) Args(host, script string) []string {
+	program := e.Program
+	if program == "" {
+		program = "ssh"
+	}
+
+	args := []string{program}
+	for _, option := range e.Options {
+		args = append(args, "-o", option)
+	}
+
+	if e.IdentityFile != "" {
+		args = append(args, "-i", e.IdentityFile)
+	}
+
+	if e.User != "" {
+		host = e.User + "@" + host
+	}
+
+	return append(args, host, script)
+}
+
+// LocalExecutor runs commands on the hub's host no matter which host they are
+// meant for. It suits single-host clusters, and tests.
+type LocalExecutor struct{}
+
+func (LocalExecutor) Args(_, script string) []string {
+	return []string{"bash", "-c", script}
+}
+
+// RemoteError is returned when a command run by a RemoteExecutor fails. It
+// wraps the error from running the command, and includes what the command
+// wrote to stderr.
+type RemoteError struct {
+	Host   string
+	Script string
+	Stderr string
+	Err    error
+}
+
+func (r *RemoteError) Error() string {
+	msg := fmt.Sprintf("running %q on host %s: %v", r.Script, r.Host, r.Err)
+	if r.Stderr != "" {
+		msg += ": " + r.Stderr
+	}
+
+	return msg
+}
+
+func (r *RemoteError) Unwrap() error {
+	return r.Err
+}
+
+// runRemote runs script on host using the executor, with stdin, stdout and
+// stderr connected to the given streams, any of which may be nil. Failures are
+// returned as a *RemoteError.
+func runRemote(ctx context.Context, executor RemoteExecutor, host, script string, stdin io.Reader, stdout, stderr io.Writer) error {
+	args := executor.Args(host, script)
+	cmd := execCommand(args[0], args[1:]...)
+
+	var errBuf bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &errBuf
+	if stderr != nil {
+		cmd.Stderr = io.MultiWriter(&errBuf, stderr)
+	}
+
+	err := utils.RunContext(ctx, cmd)
+	if err != nil {
+		return &RemoteError{
+			Host:   host,
+			Script: script,
+			Stderr: strings.TrimSpace(errBuf.String()),
+			Err:    err,
+		}
+	}
+
+	return nil
+}
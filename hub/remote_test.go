@@ -0,0 +1,144 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/testutils/exectest"
+)
+
+const RemoteFailureStderr = "ssh: connect to host sdw1 port 22: Connection refused"
+
+func RemoteFailureMain() {
+	os.Stderr.WriteString(RemoteFailureStderr + "\n")
+	os.Exit(255)
+}
+
+func init() {
+	exectest.RegisterMains(
+		RemoteFailureMain,
+	)
+}
+
+func TestRemoteShell(t *testing.T) {
+	cases := []struct {
+		name     string
+		shell    RemoteShell
+		expected []string
+	}{
+		{
+			"uses ssh by default",
+			RemoteShell{},
+			[]string{"ssh", "sdw1", "true"},
+		},
+		{
+			"passes the user, identity file and options to ssh",
+			RemoteShell{
+				User:         "gpadmin",
+				IdentityFile: "/home/gpadmin/.ssh/id_rsa",
+				Options:      []string{"BatchMode=yes", "ConnectTimeout=10"},
+			},
+			[]string{"ssh", "-o", "BatchMode=yes", "-o", "ConnectTimeout=10", "-i", "/home/gpadmin/.ssh/id_rsa", "gpadmin@sdw1", "true"},
+		},
+		{
+			"uses the trusted shell in place of ssh",
+			RemoteShell{TrustedShell: "/usr/local/bin/ssh"},
+			[]string{"/usr/local/bin/ssh", "sdw1", "true"},
+		},
+		{
+			"runs commands locally for the local shell",
+			RemoteShell{TrustedShell: LocalShell, User: "ignored"},
+			[]string{"bash", "-c", "true"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			args := c.shell.Executor().Args("sdw1", "true")
+			if !reflect.DeepEqual(args, c.expected) {
+				t.Errorf("got args %q want %q", args, c.expected)
+			}
+		})
+	}
+
+	t.Run("gpinitsystem always uses an ssh program", func(t *testing.T) {
+		cases := []struct {
+			shell    RemoteShell
+			expected string
+		}{
+			{RemoteShell{}, "ssh"},
+			{RemoteShell{TrustedShell: LocalShell}, "ssh"},
+			{RemoteShell{TrustedShell: "/usr/local/bin/ssh"}, "/usr/local/bin/ssh"},
+		}
+
+		for _, c := range cases {
+			if shell := c.shell.GpinitsystemShell(); shell != c.expected {
+				t.Errorf("GpinitsystemShell() for %+v returned %q want %q", c.shell, shell, c.expected)
+			}
+		}
+	})
+}
+
+func TestRunRemote(t *testing.T) {
+	t.Run("runs the executor's command with the given streams", func(t *testing.T) {
+		SetExecCommand(exectest.NewCommandWithVerifier(StreamingMain, func(name string, args ...string) {
+			expected := []string{"-i", "id_rsa", "sdw1", "echo hi"}
+			if name != "ssh" || !reflect.DeepEqual(args, expected) {
+				t.Errorf("got command %q %q want ssh %q", name, args, expected)
+			}
+		}))
+		defer ResetExecCommand()
+
+		var stdout, stderr bytes.Buffer
+		err := runRemote(context.Background(), SSHExecutor{IdentityFile: "id_rsa"}, "sdw1", "echo hi", nil, &stdout, &stderr)
+		if err != nil {
+			t.Errorf("runRemote() returned error %+v", err)
+		}
+
+		if stdout.String() != StreamingMainStdout {
+			t.Errorf("got stdout %q want %q", stdout.String(), StreamingMainStdout)
+		}
+		if stderr.String() != StreamingMainStderr {
+			t.Errorf("got stderr %q want %q", stderr.String(), StreamingMainStderr)
+		}
+	})
+
+	t.Run("returns a RemoteError with the remote stderr", func(t *testing.T) {
+		SetExecCommand(exectest.NewCommand(RemoteFailureMain))
+		defer ResetExecCommand()
+
+		err := runRemote(context.Background(), SSHExecutor{}, "sdw1", "true", nil, nil, nil)
+
+		var remoteErr *RemoteError
+		if !xerrors.As(err, &remoteErr) {
+			t.Fatalf("got error %#v want type %T", err, remoteErr)
+		}
+
+		if remoteErr.Host != "sdw1" || remoteErr.Script != "true" {
+			t.Errorf("got host %q and script %q want %q and %q", remoteErr.Host, remoteErr.Script, "sdw1", "true")
+		}
+
+		if remoteErr.Stderr != RemoteFailureStderr {
+			t.Errorf("got stderr %q want %q", remoteErr.Stderr, RemoteFailureStderr)
+		}
+
+		var exitErr *exec.ExitError
+		if !xerrors.As(err, &exitErr) || exitErr.ExitCode() != 255 {
+			t.Errorf("got error %#v want exit code 255", err)
+		}
+
+		if !strings.Contains(err.Error(), RemoteFailureStderr) {
+			t.Errorf("expected error %q to contain %q", err.Error(), RemoteFailureStderr)
+		}
+	})
+}
@@ -5,6 +5,8 @@ package hub
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
@@ -21,7 +23,14 @@ var ArchiveSource = upgrade.ArchiveSource
 type RenameMap = map[string][]*idl.RenameDirectories
 
 func (s *Server) UpdateDataDirectories() error {
-	return UpdateDataDirectories(s.Config, s.agentConns)
+	// The data directories of every host must be updated for the target
+	// cluster to start, so every agent is needed.
+	agentConns, err := s.AgentConns()
+	if err != nil {
+		return err
+	}
+
+	return UpdateDataDirectories(s.Config, agentConns)
 }
 
 func UpdateDataDirectories(conf *Config, agentConns []*Connection) error {
@@ -117,3 +126,55 @@ func RenameSegmentDataDirs(agentConns []*Connection, renames RenameMap) error {
 
 	return mErr.ErrorOrNil()
 }
+
+// CheckRenameSegmentDataDirs asks each agent whether its segment data
+// directories can be safely renamed, and returns the problems reported.
+func CheckRenameSegmentDataDirs(agentConns []*Connection, renames RenameMap) ([]string, error) {
+	wg := sync.WaitGroup{}
+	errs := make(chan error, len(agentConns))
+	problems := make(chan []string, len(agentConns))
+
+	for _, conn := range agentConns {
+		conn := conn
+
+		if len(renames[conn.Hostname]) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req := &idl.RenameDirectoriesRequest{Dirs: renames[conn.Hostname]}
+			reply, err := conn.AgentClient.CheckRenameDirectories(context.Background(), req)
+			if err != nil {
+				gplog.Error("checking segment data directories on host %s: %s", conn.Hostname, err.Error())
+				errs <- err
+				return
+			}
+
+			var hostProblems []string
+			for _, p := range reply.GetProblems() {
+				hostProblems = append(hostProblems, fmt.Sprintf("on host %s: %s", conn.Hostname, p))
+			}
+			problems <- hostProblems
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	close(problems)
+
+	var mErr *multierror.Error
+	for err := range errs {
+		mErr = multierror.Append(mErr, err)
+	}
+
+	var found []string
+	for p := range problems {
+		found = append(found, p...)
+	}
+
+	sort.Strings(found)
+	return found, mErr.ErrorOrNil()
+}
@@ -4,8 +4,11 @@
 package hub
 
 import (
+	"context"
 	"path/filepath"
 
+	"github.com/hashicorp/go-multierror"
+
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/upgrade"
@@ -13,17 +16,36 @@ import (
 )
 
 func (s *Server) Revert(_ *idl.RevertRequest, stream idl.CliToHub_RevertServer) (err error) {
-	st, err := step.Begin(s.StateDir, "revert", stream)
+	// Revert cannot be cancelled, since stopping it part way through would
+	// leave neither cluster usable.
+	st, err := step.Begin(context.Background(), s.StateDir, "revert", stream)
 	if err != nil {
 		return err
 	}
 
+	// Revert cleans up as much as it can. Each substep acts on the hosts whose
+	// agents can be reached, and only then fails if any of the hosts it
+	// needed could not be, so that revert can be re-run once they are back.
 	if len(s.Config.Target.Primaries) > 0 {
-		st.Run(idl.Substep_DELETE_PRIMARY_DATADIRS, func(_ step.OutStreams) error {
-			return DeletePrimaryDataDirectories(s.agentConns, s.Config.Target)
+		st.Run(idl.Substep_DELETE_PRIMARY_DATADIRS, func(_ context.Context, _ step.OutStreams) error {
+			conns, unreachable, err := s.ReachableAgentConns()
+			if err != nil {
+				return err
+			}
+
+			err = DeletePrimaryDataDirectories(conns, s.Config.Target)
+			err = withUnreachable(err, unreachable.Among(s.Config.Target.PrimaryHostnames()))
+			if err != nil {
+				return err
+			}
+
+			// The upgraded primaries are gone, so none can be skipped by a
+			// later execute.
+			store := step.NewSegmentStore(filepath.Join(s.StateDir, step.SegmentStatusFileName))
+			return store.Reset(idl.Substep_UPGRADE_PRIMARIES)
 		})
 
-		st.Run(idl.Substep_DELETE_MASTER_DATADIR, func(streams step.OutStreams) error {
+		st.Run(idl.Substep_DELETE_MASTER_DATADIR, func(_ context.Context, streams step.OutStreams) error {
 			datadir := s.Config.Target.MasterDataDir()
 			hostname := s.Config.Target.MasterHostname()
 
@@ -31,7 +53,7 @@ func (s *Server) Revert(_ *idl.RevertRequest, stream idl.CliToHub_RevertServer)
 		})
 	}
 
-	st.Run(idl.Substep_ARCHIVE_LOG_DIRECTORIES, func(_ step.OutStreams) error {
+	st.Run(idl.Substep_ARCHIVE_LOG_DIRECTORIES, func(_ context.Context, _ step.OutStreams) error {
 		// Archive log directory on master
 		oldDir, err := utils.GetLogDir()
 		if err != nil {
@@ -42,12 +64,48 @@ func (s *Server) Revert(_ *idl.RevertRequest, stream idl.CliToHub_RevertServer)
 			return err
 		}
 
-		return ArchiveSegmentLogDirectories(s.agentConns, s.Config.Target.MasterHostname(), newDir, oldDir)
+		conns, unreachable, err := s.ReachableAgentConns()
+		if err != nil {
+			return err
+		}
+
+		masterHost := s.Config.Target.MasterHostname()
+		err = ArchiveSegmentLogDirectories(conns, masterHost, newDir, oldDir)
+		return withUnreachable(err, unreachable.Among(hostsExcept(AgentHosts(s.Source), masterHost)))
 	})
 
-	st.Run(idl.Substep_DELETE_SEGMENT_STATEDIRS, func(_ step.OutStreams) error {
-		return DeleteStateDirectories(s.agentConns, s.Source.MasterHostname())
+	st.Run(idl.Substep_DELETE_SEGMENT_STATEDIRS, func(_ context.Context, _ step.OutStreams) error {
+		conns, unreachable, err := s.ReachableAgentConns()
+		if err != nil {
+			return err
+		}
+
+		masterHost := s.Source.MasterHostname()
+		err = DeleteStateDirectories(conns, masterHost)
+		return withUnreachable(err, unreachable.Among(hostsExcept(AgentHosts(s.Source), masterHost)))
 	})
 
 	return st.Err()
 }
+
+// withUnreachable adds the error for any unreachable hosts to the error of a
+// fan-out that ran on the rest.
+func withUnreachable(err error, unreachable error) error {
+	if unreachable == nil {
+		return err
+	}
+
+	return multierror.Append(err, unreachable)
+}
+
+// hostsExcept returns hostnames without the excluded host.
+func hostsExcept(hostnames []string, exclude string) []string {
+	var hosts []string
+	for _, host := range hostnames {
+		if host != exclude {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/google/renameio"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// SegmentResultsFileName is the name of the file in the state directory that
+// records the per-segment results of the most recent primaries upgrade.
+const SegmentResultsFileName = "segment_results.json"
+
+var segmentResultsMarshaler = jsonpb.Marshaler{EmitDefaults: true, Indent: "  "}
+
+// SaveSegmentResults atomically writes the results to path as JSON.
+func SaveSegmentResults(path string, results []*idl.SegmentResult) error {
+	buf := new(bytes.Buffer)
+	err := segmentResultsMarshaler.Marshal(buf, &idl.SegmentResults{Results: results})
+	if err != nil {
+		return xerrors.Errorf("encoding segment results: %w", err)
+	}
+
+	buf.WriteByte('\n')
+
+	err = renameio.WriteFile(path, buf.Bytes(), 0644)
+	if err != nil {
+		return xerrors.Errorf("saving segment results: %w", err)
+	}
+
+	return nil
+}
+
+// LoadSegmentResults reads the results written by SaveSegmentResults.
+func LoadSegmentResults(path string) ([]*idl.SegmentResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	results := &idl.SegmentResults{}
+	if err := jsonpb.Unmarshal(file, results); err != nil {
+		return nil, xerrors.Errorf("decoding segment results: %w", err)
+	}
+
+	return results.Results, nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+func TestSegmentResults(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatalf("creating temporary directory: %+v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, hub.SegmentResultsFileName)
+
+	results := []*idl.SegmentResult{
+		{Host: "sdw1", Content: 0, Dbid: 2, Phase: idl.SegmentResult_DONE, DurationMs: 1500, LogDir: "/log/seg0"},
+		{Host: "sdw2", Content: 1, Dbid: 3, Phase: idl.SegmentResult_PG_UPGRADE, ExitCode: 1, Error: "exit status 1"},
+	}
+
+	t.Run("saves results as JSON", func(t *testing.T) {
+		if err := hub.SaveSegmentResults(path, results); err != nil {
+			t.Fatalf("SaveSegmentResults() returned error %+v", err)
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading results: %+v", err)
+		}
+
+		for _, expected := range []string{`"phase": "PG_UPGRADE"`, `"logDir": "/log/seg0"`} {
+			if !strings.Contains(string(contents), expected) {
+				t.Errorf("got %s, want it to contain %s", contents, expected)
+			}
+		}
+	})
+
+	t.Run("loads saved results", func(t *testing.T) {
+		loaded, err := hub.LoadSegmentResults(path)
+		if err != nil {
+			t.Fatalf("LoadSegmentResults() returned error %+v", err)
+		}
+
+		if !proto.Equal(&idl.SegmentResults{Results: loaded}, &idl.SegmentResults{Results: results}) {
+			t.Errorf("got results %v want %v", loaded, results)
+		}
+	})
+}
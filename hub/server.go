@@ -4,6 +4,7 @@
 package hub
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,6 +25,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
+	grpcHealth "google.golang.org/grpc/health"
 	"google.golang.org/grpc/reflection"
 	grpcStatus "google.golang.org/grpc/status"
 
@@ -30,12 +33,26 @@ import (
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 	"github.com/greenplum-db/gpupgrade/utils/daemon"
+	"github.com/greenplum-db/gpupgrade/utils/health"
 	"github.com/greenplum-db/gpupgrade/utils/log"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
 var DialTimeout = 3 * time.Second
 
+var agentConnectionState = metrics.Default.NewGauge("gpupgrade_agent_connection_state",
+	"The state of the hub's connection to each agent; the series for the current state is 1.", "host", "state")
+
+var connectivityStates = []string{
+	connectivity.Idle.String(),
+	connectivity.Connecting.String(),
+	connectivity.Ready.String(),
+	connectivity.TransientFailure.String(),
+	connectivity.Shutdown.String(),
+}
+
 // Returned from Server.Start() if Server.Stop() has already been called.
 var ErrHubStopped = errors.New("hub is stopped")
 
@@ -46,13 +63,26 @@ type Server struct {
 
 	StateDir string
 
-	agentConns []*Connection
-	grpcDialer Dialer
+	// Version is the version of gpupgrade that the hub reports.
+	Version string
+
+	agents *agentPool
 
 	mu     sync.Mutex
 	server *grpc.Server
+	health *grpcHealth.Server
 	lis    net.Listener
 
+	// what the heartbeats last heard from each agent, by hostname; see
+	// CheckAgentHealth()
+	healthMu    sync.Mutex
+	agentHealth map[string]*idl.AgentHealth
+
+	// cancels the contexts of running steps; see Cancel()
+	cancelMu     sync.Mutex
+	cancels      map[int]context.CancelFunc
+	nextCancelID int
+
 	// This is used both as a channel to communicate from Start() to
 	// Stop() to indicate to Stop() that it can finally terminate
 	// and also as a flag to communicate from Stop() to Start() that
@@ -74,10 +104,10 @@ type Connection struct {
 
 func New(conf *Config, grpcDialer Dialer, stateDir string) *Server {
 	h := &Server{
-		Config:     conf,
-		StateDir:   stateDir,
-		stopped:    make(chan struct{}, 1),
-		grpcDialer: grpcDialer,
+		Config:   conf,
+		StateDir: stateDir,
+		stopped:  make(chan struct{}, 1),
+		agents:   newAgentPool(grpcDialer),
 	}
 
 	return h
@@ -99,9 +129,26 @@ func (s *Server) Start() error {
 	// handlers.
 	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		defer log.WritePanics()
-		return handler(ctx, req)
+		health.SendVersion(ctx, s.Version)
+		return metrics.UnaryServerInterceptor(ctx, req, info, handler)
+	}
+	tlsOpts, err := certs.ServerOptions(s.TLS)
+	if err != nil {
+		lis.Close()
+		return err
+	}
+	server := grpc.NewServer(append(tlsOpts,
+		grpc.UnaryInterceptor(interceptor),
+		grpc.StreamInterceptor(metrics.StreamServerInterceptor))...)
+
+	if s.MetricsPort != 0 {
+		metricsServer, err := metrics.Listen(s.MetricsAddress, s.MetricsPort, metrics.Default)
+		if err != nil {
+			lis.Close()
+			return err
+		}
+		defer metricsServer.Close()
 	}
-	server := grpc.NewServer(grpc.UnaryInterceptor(interceptor))
 
 	s.mu.Lock()
 	if s.stopped == nil {
@@ -110,6 +157,7 @@ func (s *Server) Start() error {
 		return ErrHubStopped
 	}
 	s.server = server
+	s.health = health.Register(server)
 	s.lis = lis
 	s.mu.Unlock()
 
@@ -121,6 +169,10 @@ func (s *Server) Start() error {
 		daemon.Daemonize()
 	}
 
+	heartbeatCtx, cancelHeartbeat := context.WithCancel(context.Background())
+	defer cancelHeartbeat()
+	go s.heartbeat(heartbeatCtx, HeartbeatInterval)
+
 	err = server.Serve(lis)
 	if err != nil {
 		err = errors.Wrap(err, "failed to serve")
@@ -142,37 +194,45 @@ func (s *Server) StopServices(ctx context.Context, in *idl.StopServicesRequest)
 	return &idl.StopServicesReply{}, nil
 }
 
-// TODO: add unit tests for this; this is currently tricky due to h.AgentConns()
-//    mutating global state
+// StopAgents stops the agents on every host that can be reached, including
+// those refused for being at a different version. It does not stop early for
+// unreachable agents; those are reported once the rest have been stopped.
 func (s *Server) StopAgents() error {
-	// FIXME: s.AgentConns() fails fast if a single agent isn't available
-	//    we need to connect to all available agents so we can stop just those
-	_, err := s.AgentConns()
+	conns, unreachable, err := s.ReachableAgentConns()
 	if err != nil {
 		return err
 	}
 
+	mismatched := mismatchedHosts(unreachable)
+	for _, host := range mismatched {
+		delete(unreachable.Hosts, host)
+	}
+
 	var wg sync.WaitGroup
-	errs := make(chan error, len(s.agentConns))
+	errs := make(chan error, len(conns)+len(mismatched))
 
-	for _, conn := range s.agentConns {
+	for _, conn := range conns {
 		conn := conn
 
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 
-			_, err := conn.AgentClient.StopAgent(context.Background(), &idl.StopAgentRequest{})
-			if err == nil { // no error means the agent did not terminate as expected
-				errs <- xerrors.Errorf("failed to stop agent on host: %s", conn.Hostname)
-				return
+			if err := stopAgent(context.Background(), conn.AgentClient, conn.Hostname); err != nil {
+				errs <- err
 			}
+		}()
+	}
+
+	for _, host := range mismatched {
+		host := host
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 
-			// XXX: "transport is closing" is not documented but is needed to uniquely interpret codes.Unavailable
-			// https://github.com/grpc/grpc/blob/v1.24.0/doc/statuscodes.md
-			errStatus := grpcStatus.Convert(err)
-			if errStatus.Code() != codes.Unavailable || errStatus.Message() != "transport is closing" {
-				errs <- xerrors.Errorf("failed to stop agent on host %s : %w", conn.Hostname, err)
+			if err := s.stopAgentOnHost(context.Background(), host); err != nil {
+				errs <- err
 			}
 		}()
 	}
@@ -185,19 +245,41 @@ func (s *Server) StopAgents() error {
 		multiErr = multierror.Append(multiErr, err)
 	}
 
+	if err := unreachable.ErrorOrNil(); err != nil {
+		multiErr = multierror.Append(multiErr, err)
+	}
+
 	return multiErr.ErrorOrNil()
 }
 
+// stopAgent asks an agent to stop, and checks that it did.
+func stopAgent(ctx context.Context, client idl.AgentClient, host string) error {
+	_, err := client.StopAgent(ctx, &idl.StopAgentRequest{})
+	if err == nil { // no error means the agent did not terminate as expected
+		return xerrors.Errorf("failed to stop agent on host: %s", host)
+	}
+
+	// XXX: "transport is closing" is not documented but is needed to uniquely interpret codes.Unavailable
+	// https://github.com/grpc/grpc/blob/v1.24.0/doc/statuscodes.md
+	errStatus := grpcStatus.Convert(err)
+	if errStatus.Code() != codes.Unavailable || errStatus.Message() != "transport is closing" {
+		return xerrors.Errorf("failed to stop agent on host %s : %w", host, err)
+	}
+
+	return nil
+}
+
 func (s *Server) Stop(closeAgentConns bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// StopServices calls Stop(false) because it has already closed the agentConns
+	// StopServices calls Stop(false) because it has already stopped the agents
 	if closeAgentConns {
-		s.closeAgentConns()
+		s.agents.close()
 	}
 
 	if s.server != nil {
+		s.health.Shutdown()
 		s.server.Stop()
 		<-s.stopped // block until it is OK to stop
 	}
@@ -208,15 +290,60 @@ func (s *Server) Stop(closeAgentConns bool) {
 }
 
 func (s *Server) RestartAgents(ctx context.Context, in *idl.RestartAgentsRequest) (*idl.RestartAgentsReply, error) {
-	restartedHosts, err := RestartAgents(ctx, nil, AgentHosts(s.Source), s.AgentPort, s.StateDir)
+	if in.RedeployMismatchedAgents {
+		// The redeployed agents are left stopped, to be started below.
+		if err := s.redeployAgents(ctx); err != nil {
+			return &idl.RestartAgentsReply{}, err
+		}
+	}
+
+	restartedHosts, err := RestartAgents(ctx, nil, s.RemoteShell.Executor(), AgentHosts(s.Source), s.AgentPort, s.MetricsAddress, s.AgentMetricsPort, s.StateDir, s.TLS)
 	return &idl.RestartAgentsReply{AgentHosts: restartedHosts}, err
 }
 
+// agentStartCommand returns the command that RestartAgents runs on each host
+// to start an agent.
+func agentStartCommand(port int, metricsAddress string, metricsPort int, stateDir string, tlsConf certs.Config) (string, error) {
+	agentPath, err := getAgentPath()
+	if err != nil {
+		return "", err
+	}
+
+	agentArgs := fmt.Sprintf("--daemonize --port %d --state-directory %s", port, stateDir)
+	if metricsPort != 0 {
+		agentArgs += fmt.Sprintf(" --metrics-port %d", metricsPort)
+	}
+	if metricsPort != 0 && metricsAddress != "" {
+		agentArgs += fmt.Sprintf(" --metrics-address %s", metricsAddress)
+	}
+	if tlsConf.Enabled() {
+		agentConf := certs.AgentConfig(stateDir)
+		agentArgs += fmt.Sprintf(" --tls-ca-cert %s --tls-cert %s --tls-key %s",
+			agentConf.CACert, agentConf.Cert, agentConf.Key)
+	}
+
+	return fmt.Sprintf("bash -c \"%s agent %s\"", agentPath, agentArgs), nil
+}
+
+// RestartAgents starts an agent, using executor, on each host that does not
+// already have one running. When tlsConf is enabled, the agents are dialed
+// using it, and are started using the certificates distributed by
+// DistributeAgentCerts. Agents serve metrics on metricsAddress and metricsPort
+// unless the port is zero.
 func RestartAgents(ctx context.Context,
 	dialer func(context.Context, string) (net.Conn, error),
+	executor RemoteExecutor,
 	hostnames []string,
 	port int,
-	stateDir string) ([]string, error) {
+	metricsAddress string,
+	metricsPort int,
+	stateDir string,
+	tlsConf certs.Config) ([]string, error) {
+
+	credentials, err := certs.DialOption(tlsConf)
+	if err != nil {
+		return nil, err
+	}
 
 	var wg sync.WaitGroup
 	restartedHosts := make(chan string, len(hostnames))
@@ -231,7 +358,7 @@ func RestartAgents(ctx context.Context,
 			timeoutCtx, cancelFunc := context.WithTimeout(ctx, 3*time.Second)
 			opts := []grpc.DialOption{
 				grpc.WithBlock(),
-				grpc.WithInsecure(),
+				credentials,
 				grpc.FailOnNonTempDialError(true),
 			}
 			if dialer != nil {
@@ -250,20 +377,19 @@ func RestartAgents(ctx context.Context,
 			gplog.Debug("failed to dial agent on %s: %+v", host, err)
 			gplog.Info("starting agent on %s", host)
 
-			agentPath, err := getAgentPath()
+			agentCmd, err := agentStartCommand(port, metricsAddress, metricsPort, stateDir, tlsConf)
 			if err != nil {
 				errs <- err
 				return
 			}
-			cmd := execCommand("ssh", host,
-				fmt.Sprintf("bash -c \"%s agent --daemonize --port %d --state-directory %s\"", agentPath, port, stateDir))
-			stdout, err := cmd.Output()
+			var stdout bytes.Buffer
+			err = runRemote(ctx, executor, host, agentCmd, nil, &stdout, nil)
 			if err != nil {
 				errs <- err
 				return
 			}
 
-			gplog.Debug(string(stdout))
+			gplog.Debug(stdout.String())
 			restartedHosts <- host
 		}(host)
 	}
@@ -285,77 +411,110 @@ func RestartAgents(ctx context.Context,
 	return hosts, multiErr.ErrorOrNil()
 }
 
+// AgentConns returns connections to the agents on every host. It fails with
+// an UnreachableHostsError unless every agent can be reached; fan-outs that
+// can tolerate some unreachable agents use ReachableAgentConns instead.
 func (s *Server) AgentConns() ([]*Connection, error) {
-	// Lock the mutex to protect against races with Server.Stop().
-	// XXX This is a *ridiculously* broad lock. Have fun waiting for the dial
-	// timeout when calling Stop() and AgentConns() at the same time, for
-	// instance. We should not lock around a network operation, but it seems
-	// like the AgentConns concept is not long for this world anyway.
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	conns, unreachable, err := s.ReachableAgentConns()
+	if err != nil {
+		return nil, err
+	}
 
-	if s.agentConns != nil {
-		err := EnsureConnsAreReady(s.agentConns)
-		if err != nil {
-			gplog.Error("ensureConnsAreReady failed: %s", err)
-			return nil, err
-		}
+	if err := unreachable.ErrorOrNil(); err != nil {
+		gplog.Error(err.Error())
+		return nil, err
+	}
 
-		return s.agentConns, nil
+	return conns, nil
+}
+
+// ReachableAgentConns connects to the agent on every host that it can, and
+// returns those connections along with the hosts whose agents could not be
+// reached. Agents at a different version than the hub are refused, and
+// reported as unreachable with a handshake.VersionMismatchError. Connections
+// are saved for future calls; hosts that could not be reached, and
+// connections that have broken, are dialed again on each call. The returned
+// error is only set when the TLS configuration cannot be loaded, or with
+// ErrHubStopped once the hub has stopped.
+func (s *Server) ReachableAgentConns() ([]*Connection, UnreachableHostsError, error) {
+	credentials, err := certs.DialOption(s.TLS)
+	if err != nil {
+		return nil, UnreachableHostsError{}, err
 	}
 
-	hostnames := AgentHosts(s.Source)
-	for _, host := range hostnames {
-		ctx, cancelFunc := context.WithTimeout(context.Background(), DialTimeout)
-		conn, err := s.grpcDialer(ctx,
-			host+":"+strconv.Itoa(s.AgentPort),
-			grpc.WithInsecure(), grpc.WithBlock())
-		if err != nil {
-			err = xerrors.Errorf("grpcDialer failed: %w", err)
-			gplog.Error(err.Error())
-			cancelFunc()
-			return nil, err
-		}
-		s.agentConns = append(s.agentConns, &Connection{
-			Conn:          conn,
-			AgentClient:   idl.NewAgentClient(conn),
-			Hostname:      host,
-			CancelContext: cancelFunc,
-		})
+	conns, unreachable, err := s.agents.get(AgentHosts(s.Source), s.AgentPort, s.Version, credentials)
+	if err != nil {
+		return nil, UnreachableHostsError{}, err
 	}
 
-	return s.agentConns, nil
+	return conns, UnreachableHostsError{Hosts: unreachable}, nil
 }
 
-func EnsureConnsAreReady(agentConns []*Connection) error {
-	hostnames := []string{}
-	for _, conn := range agentConns {
-		if conn.Conn.GetState() != connectivity.Ready {
-			hostnames = append(hostnames, conn.Hostname)
+// ErrUnreachableHosts is matched by an UnreachableHostsError.
+var ErrUnreachableHosts = errors.New("agents could not be reached")
+
+// UnreachableHostsError lists the hosts whose agents could not be reached,
+// with the reason for each.
+type UnreachableHostsError struct {
+	Hosts map[string]error
+}
+
+func (u UnreachableHostsError) Error() string {
+	hosts := make([]string, 0, len(u.Hosts))
+	for host := range u.Hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for i, host := range hosts {
+		hosts[i] = fmt.Sprintf("%s (%s)", host, u.Hosts[host])
+	}
+
+	msg := fmt.Sprintf("the connections to the following hosts were not ready: %s", strings.Join(hosts, ", "))
+	if len(mismatchedHosts(u)) > 0 {
+		msg += `. To replace the mismatched agents with this version of gpupgrade, run "gpupgrade restart-services --redeploy-agents"`
+	}
+
+	return msg
+}
+
+// Is matches ErrUnreachableHosts, and any error that a host was unreachable
+// with.
+func (u UnreachableHostsError) Is(err error) bool {
+	if err == ErrUnreachableHosts {
+		return true
+	}
+
+	for _, hostErr := range u.Hosts {
+		if xerrors.Is(hostErr, err) {
+			return true
 		}
 	}
 
-	if len(hostnames) > 0 {
-		return fmt.Errorf("the connections to the following hosts were not ready: %s", strings.Join(hostnames, ","))
+	return false
+}
+
+// ErrorOrNil returns the error, or nil when every host was reachable.
+func (u UnreachableHostsError) ErrorOrNil() error {
+	if len(u.Hosts) == 0 {
+		return nil
 	}
 
-	return nil
+	return u
 }
 
-// Closes all h.agentConns. Callers must hold the Server's mutex.
-// TODO: this function assumes that all h.agentConns are _not_ in a terminal
-//   state(e.g. already closed).  If so, conn.Conn.WaitForStateChange() can block
-//   indefinitely.
-func (s *Server) closeAgentConns() {
-	for _, conn := range s.agentConns {
-		defer conn.CancelContext()
-		currState := conn.Conn.GetState()
-		err := conn.Conn.Close()
-		if err != nil {
-			gplog.Info(fmt.Sprintf("Error closing hub to agent connection. host: %s, err: %s", conn.Hostname, err.Error()))
+// Among returns an error listing those of the given hosts that are
+// unreachable, or nil if they all are reachable. Fan-outs that tolerate
+// unreachable agents use it to report only the hosts they needed.
+func (u UnreachableHostsError) Among(hostnames []string) error {
+	among := UnreachableHostsError{Hosts: make(map[string]error)}
+	for _, host := range hostnames {
+		if err, ok := u.Hosts[host]; ok {
+			among.Hosts[host] = err
 		}
-		conn.Conn.WaitForStateChange(context.Background(), currState)
 	}
+
+	return among.ErrorOrNil()
 }
 
 type InitializeConfig struct {
@@ -375,6 +534,10 @@ type Config struct {
 	// target cluster's master, standby, primaries and mirrors.
 	TargetInitializeConfig InitializeConfig
 
+	// TargetPorts are the ports that the target cluster may use, from
+	// --temp-port-range. When it is empty, the ports from 50432 up are used.
+	TargetPorts []int
+
 	Port        int
 	AgentPort   int
 	UseLinkMode bool
@@ -384,6 +547,28 @@ type Config struct {
 	// dbid and tablespace oid
 	Tablespaces                greenplum.Tablespaces
 	TablespacesMappingFilePath string
+
+	// TLS holds the certificates used by the hub, and by the CLI to connect to
+	// it. When it is empty all connections are insecure.
+	TLS certs.Config
+
+	// MetricsPort and AgentMetricsPort are the ports that the hub and agents
+	// serve metrics on. Metrics are not served when they are zero.
+	// MetricsAddress is the address that both serve them on; see
+	// metrics.Listen for the default.
+	MetricsPort      int
+	AgentMetricsPort int
+	MetricsAddress   string
+
+	// MaxUpgradesPerHost and MaxUpgrades bound the number of segments that
+	// pg_upgrade runs on at once, on each host and across the cluster
+	// respectively. There is no bound when they are zero.
+	MaxUpgradesPerHost int
+	MaxUpgrades        int
+
+	// RemoteShell configures how commands such as starting the agents are run
+	// on the other hosts.
+	RemoteShell RemoteShell
 }
 
 func (c *Config) Load(r io.Reader) error {
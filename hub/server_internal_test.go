@@ -5,12 +5,17 @@ package hub
 
 import (
 	"bytes"
+	"errors"
+	"os"
 	"reflect"
 	"testing"
 
+	"golang.org/x/xerrors"
+
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/testutils"
 	"github.com/greenplum-db/gpupgrade/upgrade"
+	"github.com/greenplum-db/gpupgrade/utils/certs"
 )
 
 func TestConfig(t *testing.T) {
@@ -27,16 +32,29 @@ func TestConfig(t *testing.T) {
 			source,
 			target,
 			targetInitializeConfig,
-			12345,           // Port
-			54321,           // AgentPort
-			false,           // UseLinkMode
-			upgrade.NewID(), // UpgradeID
+			[]int{50432, 50433}, // TargetPorts
+			12345,               // Port
+			54321,               // AgentPort
+			false,               // UseLinkMode
+			upgrade.NewID(),     // UpgradeID
 			map[int]greenplum.SegmentTablespaces{
 				1: {1663: {
 					Location:    "/tmp/master/my_tablespace/1663",
 					UserDefined: 1,
 				}}}, // Tablespaces
 			greenplum.TablespacesMappingFile, // TablespacesMappingFilePath
+			certs.HubConfig("/state/dir"),    // TLS
+			9090,                             // MetricsPort
+			9091,                             // AgentMetricsPort
+			"0.0.0.0",                        // MetricsAddress
+			4,                                // MaxUpgradesPerHost
+			16,                               // MaxUpgrades
+			RemoteShell{
+				TrustedShell: "/usr/bin/ssh",
+				User:         "gpadmin",
+				IdentityFile: "/home/gpadmin/.ssh/id_rsa",
+				Options:      []string{"StrictHostKeyChecking=no"},
+			},
 		}
 
 		buf := new(bytes.Buffer)
@@ -63,3 +81,51 @@ func TestConfig(t *testing.T) {
 		}
 	})
 }
+
+func TestUnreachableHostsError(t *testing.T) {
+	refused := errors.New("connection refused")
+	unreachable := UnreachableHostsError{Hosts: map[string]error{
+		"sdw2": refused,
+		"sdw1": xerrors.Errorf("grpcDialer failed: %w", refused),
+	}}
+
+	t.Run("lists every unreachable host in order", func(t *testing.T) {
+		expected := "the connections to the following hosts were not ready: " +
+			"sdw1 (grpcDialer failed: connection refused), sdw2 (connection refused)"
+		if unreachable.Error() != expected {
+			t.Errorf("got %q want %q", unreachable.Error(), expected)
+		}
+	})
+
+	t.Run("matches ErrUnreachableHosts and the errors of its hosts", func(t *testing.T) {
+		for _, target := range []error{ErrUnreachableHosts, refused} {
+			if !xerrors.Is(unreachable, target) {
+				t.Errorf("expected %#v to match %#v", unreachable, target)
+			}
+		}
+
+		if xerrors.Is(unreachable, os.ErrNotExist) {
+			t.Errorf("did not expect %#v to match %#v", unreachable, os.ErrNotExist)
+		}
+	})
+
+	t.Run("is nil when every host is reachable", func(t *testing.T) {
+		if err := (UnreachableHostsError{}).ErrorOrNil(); err != nil {
+			t.Errorf("got error %#v want nil", err)
+		}
+	})
+
+	t.Run("reports only the unreachable hosts among those given", func(t *testing.T) {
+		err := unreachable.Among([]string{"sdw2", "sdw3"})
+
+		expected := UnreachableHostsError{Hosts: map[string]error{"sdw2": refused}}
+		if !reflect.DeepEqual(err, expected) {
+			t.Errorf("got %#v want %#v", err, expected)
+		}
+
+		err = unreachable.Among([]string{"sdw3"})
+		if err != nil {
+			t.Errorf("got error %#v want nil", err)
+		}
+	})
+}
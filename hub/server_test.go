@@ -5,11 +5,15 @@ package hub_test
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,12 +23,17 @@ import (
 	"golang.org/x/xerrors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/testutils"
 	"github.com/greenplum-db/gpupgrade/testutils/mock_agent"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/handshake"
+	"github.com/greenplum-db/gpupgrade/utils/health"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
 const timeout = 1 * time.Second
@@ -94,6 +103,89 @@ func TestHubStart(t *testing.T) {
 		}
 	})
 
+	t.Run("serves metrics when a metrics port is configured", func(t *testing.T) {
+		metricsConf := *conf
+		metricsConf.Port = testutils.MustGetPort(t)
+		metricsConf.MetricsPort = testutils.MustGetPort(t)
+
+		h := hub.New(&metricsConf, grpc.DialContext, "")
+		go func() {
+			_ = h.Start()
+		}()
+		defer h.Stop(true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		conn, err := grpc.DialContext(ctx, fmt.Sprintf("localhost:%d", metricsConf.Port), grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			t.Fatalf("dialing hub: %+v", err)
+		}
+		defer conn.Close()
+
+		_, err = idl.NewCliToHubClient(conn).Cancel(ctx, &idl.CancelRequest{})
+		if err != nil {
+			t.Fatalf("Cancel() returned error %+v", err)
+		}
+
+		resp, err := http.Get(fmt.Sprintf("http://localhost:%d%s", metricsConf.MetricsPort, metrics.Path))
+		if err != nil {
+			t.Fatalf("scraping metrics: %+v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("reading metrics: %+v", err)
+		}
+
+		expected := `gpupgrade_grpc_request_duration_seconds_count{method="/idl.CliToHub/Cancel",code="OK"} `
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", expected, body)
+		}
+	})
+
+	t.Run("serves the health service and handshake and reports its version", func(t *testing.T) {
+		healthConf := *conf
+		healthConf.Port = testutils.MustGetPort(t)
+
+		h := hub.New(&healthConf, grpc.DialContext, "")
+		h.Version = "1.2.3"
+		go func() {
+			_ = h.Start()
+		}()
+		defer h.Stop(true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		conn, err := grpc.DialContext(ctx, fmt.Sprintf("localhost:%d", healthConf.Port), grpc.WithInsecure(), grpc.WithBlock())
+		if err != nil {
+			t.Fatalf("dialing hub: %+v", err)
+		}
+		defer conn.Close()
+
+		status, version, err := health.Check(ctx, grpc_health_v1.NewHealthClient(conn))
+		if err != nil {
+			t.Fatalf("Check() returned error %+v", err)
+		}
+
+		if status != grpc_health_v1.HealthCheckResponse_SERVING {
+			t.Errorf("got status %s want %s", status, grpc_health_v1.HealthCheckResponse_SERVING)
+		}
+		if version != "1.2.3" {
+			t.Errorf("got version %q want %q", version, "1.2.3")
+		}
+
+		reply, err := handshake.Hello(ctx, "the hub", "1.2.3", idl.NewCliToHubClient(conn).Hello)
+		if err != nil {
+			t.Errorf("Hello() returned error %+v", err)
+		}
+		if !reflect.DeepEqual(reply.GetCapabilities(), handshake.Capabilities) {
+			t.Errorf("got capabilities %q want %q", reply.GetCapabilities(), handshake.Capabilities)
+		}
+	})
+
 	// This is inherently testing a race. It will give false successes instead
 	// of false failures, so DO NOT ignore transient failures in this test!
 	t.Run("will return from Start() if Stop is called concurrently", func(t *testing.T) {
@@ -250,8 +342,11 @@ func TestAgentConns(t *testing.T) {
 		}
 	})
 
-	// XXX This test takes 1.5 seconds because of EnsureConnsAreReady(...)
 	t.Run("returns an error if any connections have non-ready states", func(t *testing.T) {
+		// Don't wait long for the broken connections to be redialed.
+		defer func(timeout time.Duration) { hub.DialTimeout = timeout }(hub.DialTimeout)
+		hub.DialTimeout = 100 * time.Millisecond
+
 		h := hub.New(conf, dialer, "")
 
 		agentConns, err := h.AgentConns()
@@ -285,6 +380,343 @@ func TestAgentConns(t *testing.T) {
 	})
 }
 
+func TestReachableAgentConns(t *testing.T) {
+	testhelper.SetupTestLogger()
+
+	source := hub.MustCreateCluster(t, []greenplum.SegConfig{
+		{ContentID: -1, DbID: 1, Port: 15432, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: "p"},
+		{ContentID: 0, DbID: 2, Port: 25432, Hostname: "sdw1", DataDir: "/data/dbfast1/seg1", Role: "p"},
+		{ContentID: 1, DbID: 3, Port: 25433, Hostname: "sdw2", DataDir: "/data/dbfast2/seg2", Role: "p"},
+		{ContentID: 2, DbID: 4, Port: 25434, Hostname: "sdw3", DataDir: "/data/dbfast3/seg3", Role: "p"},
+	})
+
+	agentServer, dialer, agentPort := mock_agent.NewMockAgentServer()
+	defer agentServer.Stop()
+
+	refused := errors.New("connection refused")
+
+	// newHub returns a hub that cannot dial the agents on the down hosts.
+	newHub := func(down map[string]bool) *hub.Server {
+		partialDialer := func(ctx context.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+			host := strings.Split(target, ":")[0]
+			if down[host] {
+				return nil, refused
+			}
+			return dialer(ctx, target, opts...)
+		}
+
+		return hub.New(&hub.Config{Source: source, AgentPort: agentPort}, partialDialer, "")
+	}
+
+	hostnames := func(conns []*hub.Connection) []string {
+		var hosts []string
+		for _, conn := range conns {
+			hosts = append(hosts, conn.Hostname)
+		}
+		sort.Strings(hosts)
+		return hosts
+	}
+
+	t.Run("connects to the reachable agents and reports the rest", func(t *testing.T) {
+		h := newHub(map[string]bool{"sdw2": true})
+		defer h.Stop(true)
+
+		conns, unreachable, err := h.ReachableAgentConns()
+		if err != nil {
+			t.Fatalf("ReachableAgentConns() returned error %+v", err)
+		}
+
+		expected := []string{"sdw1", "sdw3"}
+		if hosts := hostnames(conns); !reflect.DeepEqual(hosts, expected) {
+			t.Errorf("got connections to %v want %v", hosts, expected)
+		}
+
+		if len(unreachable.Hosts) != 1 || !xerrors.Is(unreachable.Hosts["sdw2"], refused) {
+			t.Errorf("got unreachable hosts %v, want sdw2 to be refused", unreachable.Hosts)
+		}
+
+		// AgentConns needs every agent.
+		_, err = h.AgentConns()
+		if !xerrors.Is(err, hub.ErrUnreachableHosts) {
+			t.Errorf("AgentConns() returned error %#v want %#v", err, hub.ErrUnreachableHosts)
+		}
+	})
+
+	t.Run("dials the unreachable hosts again on later calls", func(t *testing.T) {
+		down := map[string]bool{"sdw2": true}
+		h := newHub(down)
+		defer h.Stop(true)
+
+		_, _, err := h.ReachableAgentConns()
+		if err != nil {
+			t.Fatalf("ReachableAgentConns() returned error %+v", err)
+		}
+
+		delete(down, "sdw2")
+
+		conns, err := h.AgentConns()
+		if err != nil {
+			t.Fatalf("AgentConns() returned error %+v", err)
+		}
+
+		expected := []string{"sdw1", "sdw2", "sdw3"}
+		if hosts := hostnames(conns); !reflect.DeepEqual(hosts, expected) {
+			t.Errorf("got connections to %v want %v", hosts, expected)
+		}
+	})
+
+	t.Run("dials the agents concurrently", func(t *testing.T) {
+		// Each dial waits for the others to start, so dialing the hosts one
+		// at a time would never finish.
+		var wg sync.WaitGroup
+		wg.Add(3)
+		concurrentDialer := func(ctx context.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+			wg.Done()
+			wg.Wait()
+			return dialer(ctx, target, opts...)
+		}
+
+		h := hub.New(&hub.Config{Source: source, AgentPort: agentPort}, concurrentDialer, "")
+		defer h.Stop(true)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := h.AgentConns()
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("AgentConns() returned error %+v", err)
+			}
+		case <-time.After(2 * hub.DialTimeout):
+			t.Fatal("timed out waiting for the agents to be dialed")
+		}
+	})
+
+	t.Run("reconnects broken connections", func(t *testing.T) {
+		h := newHub(map[string]bool{})
+		defer h.Stop(true)
+
+		conns, err := h.AgentConns()
+		if err != nil {
+			t.Fatalf("AgentConns() returned error %+v", err)
+		}
+
+		broken := conns[0]
+		if err := broken.Conn.Close(); err != nil {
+			t.Fatalf("closing connection: %+v", err)
+		}
+
+		conns, err = h.AgentConns()
+		if err != nil {
+			t.Fatalf("AgentConns() returned error %+v", err)
+		}
+
+		expected := []string{"sdw1", "sdw2", "sdw3"}
+		if hosts := hostnames(conns); !reflect.DeepEqual(hosts, expected) {
+			t.Errorf("got connections to %v want %v", hosts, expected)
+		}
+
+		for _, conn := range conns {
+			if conn == broken {
+				t.Errorf("got broken connection to %s", conn.Hostname)
+			}
+		}
+	})
+
+	t.Run("stops without waiting for dials in flight", func(t *testing.T) {
+		dialing := make(chan struct{}, 3)
+		hangingDialer := func(ctx context.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+			dialing <- struct{}{}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		// Make sure a hung dial would outlast the test.
+		defer func(timeout time.Duration) { hub.DialTimeout = timeout }(hub.DialTimeout)
+		hub.DialTimeout = time.Minute
+
+		h := hub.New(&hub.Config{Source: source, AgentPort: agentPort}, hangingDialer, "")
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := h.AgentConns()
+			done <- err
+		}()
+
+		<-dialing
+
+		stopped := make(chan struct{})
+		go func() {
+			h.Stop(true)
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(timeout):
+			t.Fatal("timed out waiting for the hub to stop")
+		}
+
+		select {
+		case err := <-done:
+			if !xerrors.Is(err, context.Canceled) {
+				t.Errorf("AgentConns() returned error %#v want %#v", err, context.Canceled)
+			}
+		case <-time.After(timeout):
+			t.Fatal("timed out waiting for AgentConns() to return")
+		}
+
+		_, err := h.AgentConns()
+		if !xerrors.Is(err, hub.ErrHubStopped) {
+			t.Errorf("AgentConns() returned error %#v want %#v", err, hub.ErrHubStopped)
+		}
+	})
+
+	t.Run("stops after connections have already been closed", func(t *testing.T) {
+		h := newHub(map[string]bool{})
+
+		conns, err := h.AgentConns()
+		if err != nil {
+			t.Fatalf("AgentConns() returned error %+v", err)
+		}
+
+		for _, conn := range conns {
+			if err := conn.Conn.Close(); err != nil {
+				t.Fatalf("closing connection: %+v", err)
+			}
+		}
+
+		stopped := make(chan struct{})
+		go func() {
+			h.Stop(true)
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(timeout):
+			t.Fatal("timed out waiting for the hub to stop")
+		}
+	})
+
+	t.Run("refuses agents at a different version", func(t *testing.T) {
+		h := newHub(map[string]bool{})
+		h.Version = "other"
+		defer h.Stop(true)
+
+		conns, unreachable, err := h.ReachableAgentConns()
+		if err != nil {
+			t.Fatalf("ReachableAgentConns() returned error %+v", err)
+		}
+
+		if len(conns) != 0 {
+			t.Errorf("got connections to %v want none", hostnames(conns))
+		}
+
+		for _, host := range []string{"sdw1", "sdw2", "sdw3"} {
+			if !xerrors.Is(unreachable.Hosts[host], handshake.ErrVersionMismatch) {
+				t.Errorf("got error %#v for host %s want %#v", unreachable.Hosts[host], host, handshake.ErrVersionMismatch)
+			}
+		}
+
+		_, err = h.AgentConns()
+		if !xerrors.Is(err, handshake.ErrVersionMismatch) {
+			t.Errorf("AgentConns() returned error %#v want %#v", err, handshake.ErrVersionMismatch)
+		}
+
+		expected := "gpupgrade restart-services --redeploy-agents"
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error %q to contain %q", err.Error(), expected)
+		}
+	})
+
+	t.Run("connects to agents at the same version", func(t *testing.T) {
+		h := newHub(map[string]bool{})
+		h.Version = mock_agent.Version
+		defer h.Stop(true)
+
+		conns, err := h.AgentConns()
+		if err != nil {
+			t.Fatalf("AgentConns() returned error %+v", err)
+		}
+
+		expected := []string{"sdw1", "sdw2", "sdw3"}
+		if hosts := hostnames(conns); !reflect.DeepEqual(hosts, expected) {
+			t.Errorf("got connections to %v want %v", hosts, expected)
+		}
+	})
+
+	t.Run("stops agents at a different version", func(t *testing.T) {
+		h := newHub(map[string]bool{})
+		h.Version = "other"
+		defer h.Stop(true)
+
+		err := h.StopAgents()
+
+		var multiErr *multierror.Error
+		if !xerrors.As(err, &multiErr) {
+			t.Fatalf("got error %#v want type %T", err, multiErr)
+		}
+
+		// As above, the mock agents report that they failed to stop, which
+		// shows they were asked to.
+		var stopped []string
+		for _, err := range multiErr.Errors {
+			stopped = append(stopped, err.Error())
+		}
+		sort.Strings(stopped)
+
+		expected := []string{
+			"failed to stop agent on host: sdw1",
+			"failed to stop agent on host: sdw2",
+			"failed to stop agent on host: sdw3",
+		}
+		if !reflect.DeepEqual(stopped, expected) {
+			t.Errorf("got errors %q want %q", stopped, expected)
+		}
+	})
+
+	t.Run("stops the reachable agents and reports the rest", func(t *testing.T) {
+		h := newHub(map[string]bool{"sdw2": true})
+		defer h.Stop(true)
+
+		err := h.StopAgents()
+
+		var multiErr *multierror.Error
+		if !xerrors.As(err, &multiErr) {
+			t.Fatalf("got error %#v want type %T", err, multiErr)
+		}
+
+		// The mock agents don't actually stop, which StopAgents reports as a
+		// failure; that shows the reachable agents were asked to stop.
+		var unreachable []error
+		var stopped []string
+		for _, err := range multiErr.Errors {
+			if xerrors.Is(err, hub.ErrUnreachableHosts) {
+				unreachable = append(unreachable, err)
+				continue
+			}
+			stopped = append(stopped, err.Error())
+		}
+		sort.Strings(stopped)
+
+		expected := []string{
+			"failed to stop agent on host: sdw1",
+			"failed to stop agent on host: sdw3",
+		}
+		if !reflect.DeepEqual(stopped, expected) {
+			t.Errorf("got errors %q want %q", stopped, expected)
+		}
+
+		if len(unreachable) != 1 || !xerrors.Is(unreachable[0], refused) {
+			t.Errorf("got unreachable errors %v, want sdw2 to be refused", unreachable)
+		}
+	})
+}
+
 func ensureAgentConnsReachState(t *testing.T, agentConns []*hub.Connection, state connectivity.State) {
 	t.Helper()
 
@@ -0,0 +1,99 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+)
+
+func (s *Server) GetStatus(ctx context.Context, in *idl.GetStatusRequest) (*idl.GetStatusReply, error) {
+	reply, err := ReadStatus(s.StateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	reply.AgentHealth, err = s.GetAgentHealth(ctx, &idl.GetAgentHealthRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// ReadStatus builds a GetStatusReply directly from the status file in the
+// given state directory. It is used by the hub to answer GetStatus, and by the
+// CLI when the hub is not running. Sections are sorted by name, and substeps
+// within each section by their idl.Substep value.
+func ReadStatus(stateDir string) (*idl.GetStatusReply, error) {
+	store := step.NewFileStore(filepath.Join(stateDir, step.StatusFileName))
+
+	sections, err := store.ReadAll()
+	if err != nil {
+		return nil, xerrors.Errorf("reading status: %w", err)
+	}
+
+	reply := &idl.GetStatusReply{}
+	for name, entries := range sections {
+		section := &idl.SectionStatus{Name: name}
+
+		for substep, entry := range entries {
+			section.Substeps = append(section.Substeps, &idl.SubstepRecord{
+				Step:      substep,
+				Status:    entry.Status.Status,
+				StartTime: protoTime(entry.StartTime),
+				EndTime:   protoTime(entry.EndTime),
+				Duration:  protoDuration(entry.Duration.Duration),
+				Attempts:  int32(entry.Attempts),
+				LastError: entry.LastError,
+			})
+		}
+
+		sort.Slice(section.Substeps, func(i, j int) bool {
+			return section.Substeps[i].Step < section.Substeps[j].Step
+		})
+
+		reply.Sections = append(reply.Sections, section)
+	}
+
+	sort.Slice(reply.Sections, func(i, j int) bool {
+		return reply.Sections[i].Name < reply.Sections[j].Name
+	})
+
+	return reply, nil
+}
+
+// protoTime converts t to a protobuf Timestamp, leaving unset times nil.
+func protoTime(t time.Time) *timestamp.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		// Only times outside of the years 0001-9999 are rejected.
+		return nil
+	}
+
+	return ts
+}
+
+// protoDuration converts d to a protobuf Duration, leaving unset durations nil.
+func protoDuration(d time.Duration) *duration.Duration {
+	if d == 0 {
+		return nil
+	}
+
+	return ptypes.DurationProto(d)
+}
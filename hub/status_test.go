@@ -0,0 +1,134 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/greenplum-db/gp-common-go-libs/operating"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/hub"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+)
+
+func TestReadStatus(t *testing.T) {
+	stateDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(stateDir); err != nil {
+			t.Errorf("removing temp directory: %v", err)
+		}
+	}()
+
+	t.Run("returns ErrNotExist when there is no status file", func(t *testing.T) {
+		_, err := hub.ReadStatus(stateDir)
+		if !xerrors.Is(err, os.ErrNotExist) {
+			t.Errorf("returned error %#v want ErrNotExist", err)
+		}
+	})
+
+	t.Run("returns every recorded section and substep in order", func(t *testing.T) {
+		path, err := step.GetStatusFile(stateDir)
+		if err != nil {
+			t.Fatalf("GetStatusFile() returned error %+v", err)
+		}
+
+		now := time.Date(2020, time.March, 1, 10, 0, 0, 0, time.UTC)
+		operating.System.Now = func() time.Time { return now }
+		defer func() { operating.System.Now = time.Now }()
+
+		store := step.NewFileStore(path)
+		writes := []struct {
+			section string
+			substep idl.Substep
+			status  idl.Status
+		}{
+			{"initialize", idl.Substep_START_AGENTS, idl.Status_COMPLETE},
+			{"initialize", idl.Substep_GENERATING_CONFIG, idl.Status_RUNNING},
+			{"execute", idl.Substep_UPGRADE_MASTER, idl.Status_FAILED},
+		}
+		for _, w := range writes {
+			if err := store.Write(w.section, w.substep, w.status); err != nil {
+				t.Fatalf("Write() returned error %+v", err)
+			}
+		}
+
+		if err := store.Fail("execute", idl.Substep_UPGRADE_MASTER, xerrors.New("pg_upgrade failed")); err != nil {
+			t.Fatalf("Fail() returned error %+v", err)
+		}
+
+		reply, err := hub.ReadStatus(stateDir)
+		if err != nil {
+			t.Fatalf("ReadStatus() returned error %+v", err)
+		}
+
+		if len(reply.Sections) != 2 {
+			t.Fatalf("got %d sections want 2", len(reply.Sections))
+		}
+
+		execute, initialize := reply.Sections[0], reply.Sections[1]
+		if execute.Name != "execute" || initialize.Name != "initialize" {
+			t.Errorf("got sections %q, %q want %q, %q", execute.Name, initialize.Name, "execute", "initialize")
+		}
+
+		if len(initialize.Substeps) != 2 {
+			t.Fatalf("got %d initialize substeps want 2", len(initialize.Substeps))
+		}
+
+		running := initialize.Substeps[0]
+		if running.Step != idl.Substep_GENERATING_CONFIG || running.Status != idl.Status_RUNNING {
+			t.Errorf("got %v %v want %v %v", running.Step, running.Status,
+				idl.Substep_GENERATING_CONFIG, idl.Status_RUNNING)
+		}
+
+		start, err := ptypes.Timestamp(running.StartTime)
+		if err != nil {
+			t.Fatalf("converting start time: %+v", err)
+		}
+		if !start.Equal(now) {
+			t.Errorf("got start time %v want %v", start, now)
+		}
+		if running.EndTime != nil {
+			t.Errorf("got end time %v want nil", running.EndTime)
+		}
+
+		failed := execute.Substeps[0]
+		if failed.Step != idl.Substep_UPGRADE_MASTER || failed.Status != idl.Status_FAILED {
+			t.Errorf("got %v %v want %v %v", failed.Step, failed.Status,
+				idl.Substep_UPGRADE_MASTER, idl.Status_FAILED)
+		}
+		if failed.Attempts != 1 || failed.LastError != "pg_upgrade failed" {
+			t.Errorf("got %d attempts and error %q want 1 attempt and error %q",
+				failed.Attempts, failed.LastError, "pg_upgrade failed")
+		}
+	})
+
+	t.Run("GetStatus reads from the hub's state directory", func(t *testing.T) {
+		h := hub.New(&hub.Config{}, nil, stateDir)
+		h.Version = "1.2.3"
+
+		reply, err := h.GetStatus(context.Background(), &idl.GetStatusRequest{})
+		if err != nil {
+			t.Fatalf("GetStatus() returned error %+v", err)
+		}
+
+		if len(reply.Sections) != 2 {
+			t.Errorf("got %d sections want 2", len(reply.Sections))
+		}
+
+		if reply.AgentHealth.GetHubVersion() != "1.2.3" {
+			t.Errorf("got agent health %v, want it to include the hub version", reply.AgentHealth)
+		}
+	})
+
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/utils"
+)
+
+var ErrPortsInUse = xerrors.New("target ports are in use")
+
+// PortsInUseError lists, for each host, the ports of the target cluster that
+// are in use and could not be replaced with free ones.
+type PortsInUseError struct {
+	InUse map[string][]int // keyed by host
+}
+
+func (p PortsInUseError) Error() string {
+	var b strings.Builder
+	b.WriteString("there are not enough free ports for the target cluster. These ports are in use:")
+	for _, host := range sortedHostsOf(p.InUse) {
+		var ports []string
+		for _, port := range p.InUse[host] {
+			ports = append(ports, fmt.Sprint(port))
+		}
+		fmt.Fprintf(&b, "\n  %s: %s", host, strings.Join(ports, ", "))
+	}
+	b.WriteString("\nFree them, or choose other ports with --temp-port-range.")
+
+	return b.String()
+}
+
+func (p PortsInUseError) Is(err error) bool {
+	return err == ErrPortsInUse
+}
+
+// maxPortAssignments bounds the number of times that the ports of the target
+// cluster are reassigned, in case the replacements are also found in use.
+const maxPortAssignments = 5
+
+// CheckTargetPorts makes sure that the ports assigned to the target cluster
+// are free on every host, before gpinitsystem tries to use them. Each port
+// that is in use is reported, and replaced with a free one from the ports of
+// --temp-port-range, or from all ports from 50432 up when none were given.
+// The new assignments are saved in the configuration.
+func (s *Server) CheckTargetPorts(ctx context.Context, streams step.OutStreams, conns []*Connection) error {
+	config, changed, err := s.assignFreeTargetPorts(ctx, conns, func(host string, port int) {
+		fmt.Fprintf(streams.Stdout(), "port %d is in use on host %s\n", port, host)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		return nil
+	}
+
+	fmt.Fprintln(streams.Stdout(), "assigned free ports to the target cluster")
+	s.TargetInitializeConfig = config
+	return s.SaveConfig()
+}
+
+// assignFreeTargetPorts returns the layout of the target cluster with each
+// port that is in use replaced by a free one, and whether any were replaced.
+// report is called for every port found in use. A PortsInUseError is returned
+// when there are not enough free ports.
+func (s *Server) assignFreeTargetPorts(ctx context.Context, conns []*Connection, report func(host string, port int)) (InitializeConfig, bool, error) {
+	config := s.TargetInitializeConfig
+	excluded := make(map[int]bool)
+
+	for attempt := 1; ; attempt++ {
+		inUse, err := targetPortsInUse(ctx, config, s.Source.MasterHostname(), conns)
+		if err != nil {
+			return InitializeConfig{}, false, err
+		}
+
+		if len(inUse) == 0 {
+			return config, attempt > 1, nil
+		}
+
+		for _, host := range sortedHostsOf(inUse) {
+			for _, port := range inUse[host] {
+				report(host, port)
+				excluded[port] = true
+			}
+		}
+
+		candidates := candidatePorts(s.TargetPorts, excluded)
+		if attempt == maxPortAssignments || len(candidates) == 0 {
+			return InitializeConfig{}, false, PortsInUseError{InUse: inUse}
+		}
+
+		config, err = AssignDatadirsAndPorts(s.Source, candidates, s.UpgradeID)
+		if err != nil {
+			return InitializeConfig{}, false, PortsInUseError{InUse: inUse}
+		}
+	}
+}
+
+// candidatePorts returns the ports that the target cluster may use, less those
+// that are excluded.
+func candidatePorts(ports []int, excluded map[int]bool) []int {
+	if len(ports) == 0 {
+		for port := defaultTargetPort; port <= 65535; port++ {
+			ports = append(ports, port)
+		}
+	}
+
+	var candidates []int
+	for _, port := range ports {
+		if !excluded[port] {
+			candidates = append(candidates, port)
+		}
+	}
+
+	return candidates
+}
+
+// targetPortsInUse returns the ports of the target cluster that are in use,
+// keyed by host. The agents check the ports of their hosts. The hub checks the
+// ports of the master host itself when no agent runs there.
+func targetPortsInUse(ctx context.Context, target InitializeConfig, masterHost string, conns []*Connection) (map[string][]int, error) {
+	segments := []greenplum.SegConfig{target.Master}
+	if target.Standby.Hostname != "" {
+		segments = append(segments, target.Standby)
+	}
+	segments = append(segments, target.Primaries...)
+	segments = append(segments, target.Mirrors...)
+
+	portsByHost := make(map[string][]int)
+	for _, seg := range segments {
+		portsByHost[seg.Hostname] = append(portsByHost[seg.Hostname], seg.Port)
+	}
+
+	agents := make(map[string]idl.AgentClient)
+	for _, conn := range conns {
+		agents[conn.Hostname] = conn.AgentClient
+	}
+
+	for host := range portsByHost {
+		if _, ok := agents[host]; !ok && host != masterHost {
+			return nil, xerrors.Errorf("no agent is connected on host %s", host)
+		}
+	}
+
+	type result struct {
+		host  string
+		inUse []int
+		err   error
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan result, len(portsByHost))
+
+	for host, ports := range portsByHost {
+		host, ports := host, ports // capture range variables
+
+		client, ok := agents[host]
+		if !ok {
+			results <- result{host: host, inUse: utils.PortsInUse(ports)}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			request := &idl.CheckPortsRequest{}
+			for _, port := range ports {
+				request.Ports = append(request.Ports, uint32(port))
+			}
+
+			reply, err := client.CheckPorts(ctx, request)
+			if err != nil {
+				err = xerrors.Errorf("checking ports on host %s: %w", host, err)
+				results <- result{host: host, err: err}
+				return
+			}
+
+			var inUse []int
+			for _, port := range reply.InUse {
+				inUse = append(inUse, int(port))
+			}
+			results <- result{host: host, inUse: inUse}
+		}()
+	}
+
+	wg.Wait()
+	close(results)
+
+	var merr *multierror.Error
+	inUse := make(map[string][]int)
+	for result := range results {
+		if result.err != nil {
+			merr = multierror.Append(merr, result.err)
+			continue
+		}
+
+		if len(result.inUse) > 0 {
+			inUse[result.host] = result.inUse
+		}
+	}
+
+	return inUse, merr.ErrorOrNil()
+}
+
+// sortedHostsOf returns the hosts of the ports that are in use, in order.
+func sortedHostsOf(inUse map[string][]int) []string {
+	var hosts []string
+	for host := range inUse {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+)
+
+func TestCheckTargetPorts(t *testing.T) {
+	ctx := context.Background()
+
+	source := MustCreateCluster(t, []greenplum.SegConfig{
+		{ContentID: -1, DbID: 1, Port: 15432, Hostname: "mdw", DataDir: "/data/qddir/seg-1", Role: "p"},
+		{ContentID: 0, DbID: 2, Port: 25432, Hostname: "mdw", DataDir: "/data/dbfast1/seg1", Role: "p"},
+		{ContentID: 1, DbID: 3, Port: 25433, Hostname: "sdw1", DataDir: "/data/dbfast2/seg2", Role: "p"},
+	})
+
+	// newServer returns a hub with the ports of the target cluster assigned
+	// from the given ports, as initialize does.
+	newServer := func(t *testing.T, ports []int) *Server {
+		t.Helper()
+
+		target, err := AssignDatadirsAndPorts(source, append([]int(nil), ports...), 0)
+		if err != nil {
+			t.Fatalf("AssignDatadirsAndPorts() returned error %+v", err)
+		}
+
+		return New(&Config{
+			Source:                 source,
+			TargetInitializeConfig: target,
+			TargetPorts:            ports,
+		}, nil, "")
+	}
+
+	request := func(ports ...uint32) *idl.CheckPortsRequest {
+		return &idl.CheckPortsRequest{Ports: ports}
+	}
+
+	t.Run("leaves the ports unchanged when they are free", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mdw := mock_idl.NewMockAgentClient(ctrl)
+		mdw.EXPECT().CheckPorts(ctx, request(50432, 50433)).Return(&idl.CheckPortsReply{}, nil)
+
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().CheckPorts(ctx, request(50433)).Return(&idl.CheckPortsReply{}, nil)
+
+		s := newServer(t, nil)
+		expected := s.TargetInitializeConfig
+
+		streams := new(bufferedStreams)
+		err := s.CheckTargetPorts(ctx, streams, []*Connection{
+			{Hostname: "mdw", AgentClient: mdw},
+			{Hostname: "sdw1", AgentClient: sdw1},
+		})
+		if err != nil {
+			t.Fatalf("CheckTargetPorts() returned error %+v", err)
+		}
+
+		if !reflect.DeepEqual(s.TargetInitializeConfig, expected) {
+			t.Errorf("got target config %+v want %+v", s.TargetInitializeConfig, expected)
+		}
+
+		if streams.stdout.Len() != 0 {
+			t.Errorf("got stdout %q, want nothing", streams.stdout.String())
+		}
+	})
+
+	t.Run("replaces the ports that are in use and saves the configuration", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mdw := mock_idl.NewMockAgentClient(ctrl)
+		gomock.InOrder(
+			mdw.EXPECT().CheckPorts(ctx, request(50432, 50433)).Return(&idl.CheckPortsReply{}, nil),
+			mdw.EXPECT().CheckPorts(ctx, request(50432, 50434)).Return(&idl.CheckPortsReply{}, nil),
+		)
+
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		gomock.InOrder(
+			sdw1.EXPECT().CheckPorts(ctx, request(50433)).Return(&idl.CheckPortsReply{InUse: []uint32{50433}}, nil),
+			sdw1.EXPECT().CheckPorts(ctx, request(50434)).Return(&idl.CheckPortsReply{}, nil),
+		)
+
+		stateDir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, stateDir)
+
+		s := newServer(t, nil)
+		s.StateDir = stateDir
+
+		streams := new(bufferedStreams)
+		err := s.CheckTargetPorts(ctx, streams, []*Connection{
+			{Hostname: "mdw", AgentClient: mdw},
+			{Hostname: "sdw1", AgentClient: sdw1},
+		})
+		if err != nil {
+			t.Fatalf("CheckTargetPorts() returned error %+v", err)
+		}
+
+		if port := s.TargetInitializeConfig.Master.Port; port != 50432 {
+			t.Errorf("got master port %d want %d", port, 50432)
+		}
+		for _, seg := range s.TargetInitializeConfig.Primaries {
+			if seg.Port != 50434 {
+				t.Errorf("got port %d for content %d want %d", seg.Port, seg.ContentID, 50434)
+			}
+		}
+
+		output := streams.stdout.String()
+		if !strings.Contains(output, "port 50433 is in use on host sdw1") {
+			t.Errorf("got stdout %q, want it to report the port in use", output)
+		}
+
+		if _, err := os.Stat(filepath.Join(stateDir, ConfigFileName)); err != nil {
+			t.Errorf("expected the configuration to be saved: %+v", err)
+		}
+	})
+
+	t.Run("reports the ports in use when there are not enough free ones", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mdw := mock_idl.NewMockAgentClient(ctrl)
+		mdw.EXPECT().CheckPorts(ctx, request(50432, 50433)).Return(&idl.CheckPortsReply{}, nil)
+
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().CheckPorts(ctx, request(50433)).Return(&idl.CheckPortsReply{InUse: []uint32{50433}}, nil)
+
+		s := newServer(t, []int{50432, 50433})
+		expected := s.TargetInitializeConfig
+
+		err := s.CheckTargetPorts(ctx, new(bufferedStreams), []*Connection{
+			{Hostname: "mdw", AgentClient: mdw},
+			{Hostname: "sdw1", AgentClient: sdw1},
+		})
+		if !xerrors.Is(err, ErrPortsInUse) {
+			t.Fatalf("got error %#v want %#v", err, ErrPortsInUse)
+		}
+
+		var inUseErr PortsInUseError
+		if !xerrors.As(err, &inUseErr) {
+			t.Fatalf("got error %#v want type %T", err, inUseErr)
+		}
+
+		expectedInUse := map[string][]int{"sdw1": {50433}}
+		if !reflect.DeepEqual(inUseErr.InUse, expectedInUse) {
+			t.Errorf("got ports in use %v want %v", inUseErr.InUse, expectedInUse)
+		}
+
+		if !reflect.DeepEqual(s.TargetInitializeConfig, expected) {
+			t.Errorf("target config was changed to %+v", s.TargetInitializeConfig)
+		}
+	})
+
+	t.Run("returns errors from the agents", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mdw := mock_idl.NewMockAgentClient(ctrl)
+		mdw.EXPECT().CheckPorts(ctx, gomock.Any()).Return(&idl.CheckPortsReply{}, nil)
+
+		expected := errors.New("permission denied")
+		sdw1 := mock_idl.NewMockAgentClient(ctrl)
+		sdw1.EXPECT().CheckPorts(ctx, gomock.Any()).Return(nil, expected)
+
+		err := newServer(t, nil).CheckTargetPorts(ctx, new(bufferedStreams), []*Connection{
+			{Hostname: "mdw", AgentClient: mdw},
+			{Hostname: "sdw1", AgentClient: sdw1},
+		})
+		checkMultierrorContents(t, err, []error{expected})
+	})
+
+	t.Run("errors when a segment host has no agent", func(t *testing.T) {
+		err := newServer(t, nil).CheckTargetPorts(ctx, new(bufferedStreams), nil)
+
+		expected := "no agent is connected on host sdw1"
+		if err == nil || err.Error() != expected {
+			t.Errorf("got error %v want %q", err, expected)
+		}
+	})
+}
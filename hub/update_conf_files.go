@@ -23,24 +23,34 @@ func UpdateConfFiles(streams step.OutStreams, masterDataDir string, oldPort, new
 }
 
 func UpdateGpperfmonConf(streams step.OutStreams, masterDataDir string) error {
+	cmd := execCommand("sed", gpperfmonConfArgs(masterDataDir)...)
+
+	cmd.Stdout, cmd.Stderr = streams.Stdout(), streams.Stderr()
+	return cmd.Run()
+}
+
+func gpperfmonConfArgs(masterDataDir string) []string {
 	logDir := filepath.Join(masterDataDir, "gpperfmon", "logs")
 
 	pattern := `^log_location = .*$`
 	replacement := fmt.Sprintf("log_location = %s", logDir)
 
 	// TODO: allow arbitrary whitespace around the = sign?
-	cmd := execCommand(
-		"sed",
+	return []string{
 		"-i.bak", // in-place substitution with .bak backup extension
 		fmt.Sprintf(`s|%s|%s|`, pattern, replacement),
 		filepath.Join(masterDataDir, "gpperfmon", "conf", "gpperfmon.conf"),
-	)
+	}
+}
+
+func UpdatePostgresqlConf(streams step.OutStreams, dataDir string, oldPort, newPort int) error {
+	cmd := execCommand("sed", postgresqlConfArgs(dataDir, oldPort, newPort)...)
 
 	cmd.Stdout, cmd.Stderr = streams.Stdout(), streams.Stderr()
 	return cmd.Run()
 }
 
-func UpdatePostgresqlConf(streams step.OutStreams, dataDir string, oldPort, newPort int) error {
+func postgresqlConfArgs(dataDir string, oldPort, newPort int) []string {
 	// NOTE: any additions of forward slashes (/) here require an update to the
 	// sed script below
 	pattern := fmt.Sprintf(`(^port[ \t]*=[ \t]*)%d([^0-9]|$)`, oldPort)
@@ -48,14 +58,10 @@ func UpdatePostgresqlConf(streams step.OutStreams, dataDir string, oldPort, newP
 
 	path := filepath.Join(dataDir, "postgresql.conf")
 
-	cmd := execCommand(
-		"sed",
+	return []string{
 		"-E",     // use POSIX extended regexes
 		"-i.bak", // in-place substitution with .bak backup extension
 		fmt.Sprintf(`s/%s/%s/`, pattern, replacement),
 		path,
-	)
-
-	cmd.Stdout, cmd.Stderr = streams.Stdout(), streams.Stderr()
-	return cmd.Run()
+	}
 }
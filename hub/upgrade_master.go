@@ -4,7 +4,7 @@
 package hub
 
 import (
-	"os"
+	"context"
 	"os/exec"
 	"path/filepath"
 
@@ -14,11 +14,13 @@ import (
 	"github.com/greenplum-db/gpupgrade/step"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
 )
 
 // Allow exec.Command to be mocked out by exectest.NewCommand.
 var execCommand = exec.Command
-var execCommandRsync = exec.Command
+
+var syncDirectory = dirsync.Sync
 
 const originalMasterBackupName = "master.bak"
 
@@ -34,7 +36,7 @@ type UpgradeMasterArgs struct {
 // XXX this makes more sense as a Server method, but it's so difficult to stub a
 // Server that the parameters have been split out for testing. Revisit if/when the
 // Server monolith is broken up.
-func UpgradeMaster(args UpgradeMasterArgs) error {
+func UpgradeMaster(ctx context.Context, args UpgradeMasterArgs) error {
 	wd := upgrade.MasterWorkingDirectory(args.StateDir)
 	err := utils.System.MkdirAll(wd, 0700)
 	if err != nil {
@@ -42,30 +44,39 @@ func UpgradeMaster(args UpgradeMasterArgs) error {
 	}
 
 	sourceDir := filepath.Join(args.StateDir, originalMasterBackupName)
-	err = RsyncMasterDataDir(args.Stream, sourceDir, args.Target.MasterDataDir())
+	err = SyncMasterDataDir(ctx, sourceDir, args.Target.MasterDataDir())
 	if err != nil {
 		return err
 	}
 
-	pair := upgrade.SegmentPair{
-		Source: masterSegmentFromCluster(args.Source),
-		Target: masterSegmentFromCluster(args.Target),
-	}
-
 	options := []upgrade.Option{
 		upgrade.WithExecCommand(execCommand),
+		upgrade.WithContext(ctx),
 		upgrade.WithWorkDir(wd),
 		upgrade.WithOutputStreams(args.Stream.Stdout(), args.Stream.Stderr()),
 	}
-	if args.CheckOnly {
+
+	return upgrade.Run(masterPair(args.Source, args.Target), masterOptions(args.CheckOnly, args.UseLinkMode, options)...)
+}
+
+func masterPair(source, target *greenplum.Cluster) upgrade.SegmentPair {
+	return upgrade.SegmentPair{
+		Source: masterSegmentFromCluster(source),
+		Target: masterSegmentFromCluster(target),
+	}
+}
+
+// masterOptions adds the options that depend on the kind of master upgrade.
+func masterOptions(checkOnly, useLinkMode bool, options []upgrade.Option) []upgrade.Option {
+	if checkOnly {
 		options = append(options, upgrade.WithCheckOnly())
 	}
 
-	if args.UseLinkMode {
+	if useLinkMode {
 		options = append(options, upgrade.WithLinkMode())
 	}
 
-	return upgrade.Run(pair, options...)
+	return options
 }
 
 func masterSegmentFromCluster(cluster *greenplum.Cluster) *upgrade.Segment {
@@ -77,16 +88,18 @@ func masterSegmentFromCluster(cluster *greenplum.Cluster) *upgrade.Segment {
 	}
 }
 
-func RsyncMasterDataDir(stream step.OutStreams, sourceDir, targetDir string) error {
-	sourceDirRsync := filepath.Clean(sourceDir) + string(os.PathSeparator)
-	cmd := execCommandRsync("rsync", "--archive", "--delete", "--exclude=pg_log/*", sourceDirRsync, targetDir)
-
-	cmd.Stdout = stream.Stdout()
-	cmd.Stderr = stream.Stderr()
+// masterSyncOptions leaves the master's logs out of the copy, and keeps those
+// already in the target.
+var masterSyncOptions = dirsync.Options{
+	Delete:  true,
+	Exclude: []string{"pg_log/*"},
+}
 
-	err := cmd.Run()
+// SyncMasterDataDir mirrors the contents of sourceDir into targetDir.
+func SyncMasterDataDir(ctx context.Context, sourceDir, targetDir string) error {
+	_, err := syncDirectory(ctx, sourceDir, targetDir, masterSyncOptions)
 	if err != nil {
-		return xerrors.Errorf("rsync %q to %q: %w", sourceDirRsync, targetDir, err)
+		return xerrors.Errorf("copying %q to %q: %w", sourceDir, targetDir, err)
 	}
 	return nil
 }
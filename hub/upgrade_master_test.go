@@ -5,12 +5,14 @@ package hub
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"testing"
@@ -18,9 +20,11 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
+	"github.com/greenplum-db/gpupgrade/testutils"
 	"github.com/greenplum-db/gpupgrade/testutils/exectest"
 	"github.com/greenplum-db/gpupgrade/upgrade"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
 )
 
 func Success() {}
@@ -149,10 +153,10 @@ func TestUpgradeMaster(t *testing.T) {
 		SetExecCommand(exectest.NewCommand(Success))
 		defer ResetExecCommand()
 
-		SetRsyncExecCommand(exectest.NewCommand(Success))
-		defer ResetRsyncExecCommand()
+		SetSyncDirectory(syncSuccess)
+		defer ResetSyncDirectory()
 
-		err := UpgradeMaster(UpgradeMasterArgs{
+		err := UpgradeMaster(context.Background(), UpgradeMasterArgs{
 			Source:      source,
 			Target:      target,
 			StateDir:    tempDir,
@@ -174,12 +178,12 @@ func TestUpgradeMaster(t *testing.T) {
 		SetExecCommand(exectest.NewCommand(StreamingMain))
 		defer ResetExecCommand()
 
-		SetRsyncExecCommand(exectest.NewCommand(Success))
-		defer ResetRsyncExecCommand()
+		SetSyncDirectory(syncSuccess)
+		defer ResetSyncDirectory()
 
 		stream := new(bufferedStreams)
 
-		err := UpgradeMaster(UpgradeMasterArgs{
+		err := UpgradeMaster(context.Background(), UpgradeMasterArgs{
 			Source:      source,
 			Target:      target,
 			StateDir:    tempDir,
@@ -207,11 +211,11 @@ func TestUpgradeMaster(t *testing.T) {
 		SetExecCommand(exectest.NewCommand(BlindlyWritingMain))
 		defer ResetExecCommand()
 
-		SetRsyncExecCommand(exectest.NewCommand(Success))
-		defer ResetRsyncExecCommand()
+		SetSyncDirectory(syncSuccess)
+		defer ResetSyncDirectory()
 
 		expectedErr := errors.New("write failed!")
-		err := UpgradeMaster(UpgradeMasterArgs{
+		err := UpgradeMaster(context.Background(), UpgradeMasterArgs{
 			Source:      source,
 			Target:      target,
 			StateDir:    tempDir,
@@ -224,16 +228,18 @@ func TestUpgradeMaster(t *testing.T) {
 		}
 	})
 
-	t.Run("rsync during upgrade master errors out", func(t *testing.T) {
+	t.Run("copy during upgrade master errors out", func(t *testing.T) {
 		SetExecCommand(exectest.NewCommand(StreamingMain))
 		defer ResetExecCommand()
 
-		SetRsyncExecCommand(exectest.NewCommand(Failure))
-		defer ResetRsyncExecCommand()
+		SetSyncDirectory(func(context.Context, string, string, dirsync.Options) (dirsync.Stats, error) {
+			return dirsync.Stats{}, os.ErrPermission
+		})
+		defer ResetSyncDirectory()
 
 		stream := new(bufferedStreams)
 
-		err := UpgradeMaster(UpgradeMasterArgs{
+		err := UpgradeMaster(context.Background(), UpgradeMasterArgs{
 			Source:      source,
 			Target:      target,
 			StateDir:    tempDir,
@@ -248,29 +254,69 @@ func TestUpgradeMaster(t *testing.T) {
 	})
 }
 
-func TestRsyncMasterDir(t *testing.T) {
-	t.Run("rsync streams stdout and stderr to the client", func(t *testing.T) {
-		SetRsyncExecCommand(exectest.NewCommand(StreamingMain))
-		defer ResetRsyncExecCommand()
+func TestSyncMasterDataDir(t *testing.T) {
+	SetSyncDirectory(dirsync.Sync)
+	defer ResetSyncDirectory()
 
-		stream := new(bufferedStreams)
-		err := RsyncMasterDataDir(stream, "", "")
+	t.Run("mirrors the master data directory except for its logs", func(t *testing.T) {
+		sourceDir := testutils.GetTempDir(t, "master")
+		defer testutils.MustRemoveAll(t, sourceDir)
+
+		targetDir := testutils.GetTempDir(t, "master-backup")
+		defer testutils.MustRemoveAll(t, targetDir)
+
+		for _, dir := range []string{sourceDir, targetDir} {
+			if err := os.Mkdir(filepath.Join(dir, "pg_log"), 0700); err != nil {
+				t.Fatal(err)
+			}
+		}
 
+		files := map[string]string{
+			filepath.Join(sourceDir, "postgresql.conf"):   "port=5432",
+			filepath.Join(sourceDir, "pg_log", "new.csv"): "source log",
+			filepath.Join(targetDir, "pg_log", "old.csv"): "target log",
+			filepath.Join(targetDir, "postmaster.pid"):    "1234",
+		}
+		for path, contents := range files {
+			if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		err := SyncMasterDataDir(context.Background(), sourceDir, targetDir)
 		if err != nil {
 			t.Errorf("returned: %+v", err)
 		}
 
-		stdout := stream.stdout.String()
-		if stdout != StreamingMainStdout {
-			t.Errorf("got stdout %q, want %q", stdout, StreamingMainStdout)
+		for _, path := range []string{"postgresql.conf", "pg_log/old.csv"} {
+			if _, err := os.Stat(filepath.Join(targetDir, path)); err != nil {
+				t.Errorf("expected %q to exist: %v", path, err)
+			}
 		}
 
-		stderr := stream.stderr.String()
-		if stderr != StreamingMainStderr {
-			t.Errorf("got stderr %q, want %q", stderr, StreamingMainStderr)
+		for _, path := range []string{"pg_log/new.csv", "postmaster.pid"} {
+			if _, err := os.Stat(filepath.Join(targetDir, path)); !os.IsNotExist(err) {
+				t.Errorf("expected %q not to exist, got %v", path, err)
+			}
+		}
+	})
+
+	t.Run("names the directories in errors", func(t *testing.T) {
+		err := SyncMasterDataDir(context.Background(), "/does/not/exist", "/tmp/some/target")
+		if !xerrors.Is(err, os.ErrNotExist) {
+			t.Errorf("returned %#v, want a not-exist error", err)
+		}
+
+		expected := `copying "/does/not/exist" to "/tmp/some/target"`
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("got error %q, want it to contain %q", err, expected)
 		}
 	})
+}
 
+// syncSuccess stands in for a copy that succeeds without touching the disk.
+func syncSuccess(context.Context, string, string, dirsync.Options) (dirsync.Stats, error) {
+	return dirsync.Stats{}, nil
 }
 
 // bufferedStreams is an implementation of OutStreams that just writes to
@@ -29,6 +29,10 @@ func writeGpAddmirrorsConfig(mirrors []greenplum.SegConfig, out io.Writer) error
 	return nil
 }
 
+func addMirrorsConfigPath(stateDir string) string {
+	return filepath.Join(stateDir, "add_mirrors_config")
+}
+
 func runAddMirrors(r greenplum.Runner, filepath string) error {
 	return r.Run("gpaddmirrors",
 		"-a",
@@ -102,7 +106,7 @@ func UpgradeMirrors(stateDir string, masterPort int, mirrors []greenplum.SegConf
 }
 
 func doUpgrade(db *sql.DB, stateDir string, mirrors []greenplum.SegConfig, targetRunner greenplum.Runner) (err error) {
-	path := filepath.Join(stateDir, "add_mirrors_config")
+	path := addMirrorsConfigPath(stateDir)
 	// calling Close() on a file twice results in an error
 	// only call Close() in the defer if we haven't yet tried to close it.
 	fileClosed := false
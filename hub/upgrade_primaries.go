@@ -6,6 +6,7 @@ package hub
 import (
 	"context"
 	"fmt"
+	"io"
 	"sort"
 	"sync"
 
@@ -14,9 +15,11 @@ import (
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
 )
 
 type UpgradePrimaryArgs struct {
+	Stream                 step.OutStreams // receives the output streamed by the agents
 	CheckOnly              bool
 	MasterBackupDir        string
 	AgentConns             []*Connection
@@ -25,33 +28,105 @@ type UpgradePrimaryArgs struct {
 	Target                 *greenplum.Cluster
 	UseLinkMode            bool
 	TablespacesMappingFile string
+	Completed              map[int32]bool // content IDs of segments to skip, since they are already upgraded
+	MaxUpgradesPerHost     int            // zero means no limit
+	MaxUpgrades            int            // across the cluster; zero means no limit
 }
 
-func UpgradePrimaries(args UpgradePrimaryArgs) error {
+// UpgradePrimaries upgrades the primaries on each agent. The output of each
+// segment's upgrade is written to args.Stream as the agents send it. Cancelling
+// ctx stops the upgrades on the agents.
+//
+// Each agent upgrades at most args.MaxUpgradesPerHost segments at once, and at
+// most args.MaxUpgrades segments are upgraded at once across the cluster. To
+// enforce the cluster-wide limit, each segment is sent to its agent in a
+// separate request; otherwise each agent is sent all of its segments at once.
+//
+// A result is returned for every segment in args.DataDirPairMap, sorted by
+// content ID, even when the upgrade fails. Segments whose agent failed before
+// reporting a result are given one that records the agent's error. Segments in
+// args.Completed are not sent to the agents, and their results are marked as
+// skipped.
+func UpgradePrimaries(ctx context.Context, args UpgradePrimaryArgs) ([]*idl.SegmentResult, error) {
 	wg := sync.WaitGroup{}
 
-	agentErrs := make(chan error, len(args.AgentConns))
+	var mu sync.Mutex
+	var results []*idl.SegmentResult
+
+	type batch struct {
+		conn  *Connection
+		pairs []*idl.DataDirPair
+		host  slots
+	}
+
+	var batches []batch
 	for _, conn := range args.AgentConns {
+		pairs, skipped := excludeCompleted(conn.Hostname, args.DataDirPairMap[conn.Hostname], args.Completed)
+		results = append(results, skipped...)
+
+		if len(args.Completed) > 0 && len(pairs) == 0 {
+			// Every segment on this host has already been upgraded.
+			continue
+		}
+
+		if args.MaxUpgrades <= 0 {
+			batches = append(batches, batch{conn: conn, pairs: pairs})
+			continue
+		}
+
+		host := newSlots(args.MaxUpgradesPerHost)
+		for _, pair := range pairs {
+			batches = append(batches, batch{conn: conn, pairs: []*idl.DataDirPair{pair}, host: host})
+		}
+	}
+
+	cluster := newSlots(args.MaxUpgrades)
+
+	agentErrs := make(chan error, len(batches))
+	for _, b := range batches {
 		wg.Add(1)
 
-		go func(conn *Connection) {
+		go func(b batch) {
 			defer wg.Done()
 
-			_, err := conn.AgentClient.UpgradePrimaries(context.Background(), &idl.UpgradePrimariesRequest{
-				SourceBinDir:               args.Source.BinDir,
-				TargetBinDir:               args.Target.BinDir,
-				TargetVersion:              args.Target.Version.SemVer.String(),
-				DataDirPairs:               args.DataDirPairMap[conn.Hostname],
-				CheckOnly:                  args.CheckOnly,
-				UseLinkMode:                args.UseLinkMode,
-				MasterBackupDir:            args.MasterBackupDir,
-				TablespacesMappingFilePath: args.TablespacesMappingFile,
-			})
+			var hostResults []*idl.SegmentResult
+			err := b.host.acquire(ctx)
+			if err == nil {
+				defer b.host.release()
+				err = cluster.acquire(ctx)
+			}
+
+			if err == nil {
+				defer cluster.release()
+
+				var stream idl.Agent_UpgradePrimariesClient
+				stream, err = b.conn.AgentClient.UpgradePrimaries(ctx, &idl.UpgradePrimariesRequest{
+					SourceBinDir:               args.Source.BinDir,
+					TargetBinDir:               args.Target.BinDir,
+					TargetVersion:              args.Target.Version.SemVer.String(),
+					DataDirPairs:               b.pairs,
+					CheckOnly:                  args.CheckOnly,
+					UseLinkMode:                args.UseLinkMode,
+					MasterBackupDir:            args.MasterBackupDir,
+					TablespacesMappingFilePath: args.TablespacesMappingFile,
+					MaxConcurrency:             int32(args.MaxUpgradesPerHost),
+				})
+
+				if err == nil {
+					hostResults, err = receiveAgentMessages(stream, args.Stream)
+				}
+			}
+
+			hostResults = fillMissingResults(b.conn.Hostname, b.pairs, hostResults, err)
+
+			mu.Lock()
+			results = append(results, hostResults...)
+			mu.Unlock()
 
 			if err != nil {
-				agentErrs <- errors.Wrapf(err, "failed to upgrade primary segment on host %s", conn.Hostname)
+				agentErrs <- errors.Wrapf(err, "failed to upgrade primary segment on host %s", b.conn.Hostname)
 			}
-		}(conn)
+		}(b)
 	}
 
 	wg.Wait()
@@ -63,7 +138,137 @@ func UpgradePrimaries(args UpgradePrimaryArgs) error {
 		err = multierror.Append(err, agentErr)
 	}
 
-	return err
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Content < results[j].Content
+	})
+
+	return results, err
+}
+
+// slots bounds the number of operations that run at once. A nil slots places
+// no bound on them.
+type slots chan struct{}
+
+func newSlots(limit int) slots {
+	if limit <= 0 {
+		return nil
+	}
+
+	return make(slots, limit)
+}
+
+// acquire waits for a free slot, or for ctx to be cancelled.
+func (s slots) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s slots) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+// receiveAgentMessages writes the output streamed by an agent to streams until
+// the agent finishes, prefixing each line with the host and content ID of the
+// segment that produced it. The segment results sent by the agent are
+// returned along with the agent's error, if any.
+func receiveAgentMessages(stream idl.Agent_UpgradePrimariesClient, streams step.OutStreams) ([]*idl.SegmentResult, error) {
+	var results []*idl.SegmentResult
+	var writeErr error
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return results, writeErr
+		}
+		if err != nil {
+			return results, err
+		}
+
+		if result := msg.GetSegmentResult(); result != nil {
+			results = append(results, result)
+			continue
+		}
+
+		chunk := msg.GetChunk()
+		if chunk == nil || writeErr != nil {
+			// Keep receiving after a write error, so that the agent is not
+			// blocked sending its output.
+			continue
+		}
+
+		w := streams.Stdout()
+		if chunk.Type == idl.Chunk_STDERR {
+			w = streams.Stderr()
+		}
+
+		_, writeErr = fmt.Fprintf(w, "[%s content %d] %s", chunk.Host, chunk.Content, chunk.Buffer)
+	}
+}
+
+// excludeCompleted splits a host's pairs into those that still need to be
+// upgraded and results for those that were already upgraded.
+func excludeCompleted(host string, pairs []*idl.DataDirPair, completed map[int32]bool) ([]*idl.DataDirPair, []*idl.SegmentResult) {
+	var remaining []*idl.DataDirPair
+	var skipped []*idl.SegmentResult
+
+	for _, pair := range pairs {
+		if !completed[pair.Content] {
+			remaining = append(remaining, pair)
+			continue
+		}
+
+		skipped = append(skipped, &idl.SegmentResult{
+			Host:    host,
+			Content: pair.Content,
+			Dbid:    pair.DBID,
+			Phase:   idl.SegmentResult_DONE,
+			Skipped: true,
+		})
+	}
+
+	return remaining, skipped
+}
+
+// fillMissingResults adds a result for each of the host's segments that the
+// agent did not report on, such as when the agent could not be reached. The
+// error recorded for those segments is agentErr.
+func fillMissingResults(host string, pairs []*idl.DataDirPair, results []*idl.SegmentResult, agentErr error) []*idl.SegmentResult {
+	reported := make(map[int32]bool)
+	for _, result := range results {
+		reported[result.Content] = true
+	}
+
+	reason := "no result was reported by the agent"
+	if agentErr != nil {
+		reason = agentErr.Error()
+	}
+
+	for _, pair := range pairs {
+		if reported[pair.Content] {
+			continue
+		}
+
+		results = append(results, &idl.SegmentResult{
+			Host:     host,
+			Content:  pair.Content,
+			Dbid:     pair.DBID,
+			Phase:    idl.SegmentResult_UNKNOWN_PHASE,
+			ExitCode: -1,
+			Error:    reason,
+		})
+	}
+
+	return results
 }
 
 // ErrInvalidCluster is returned by GetDataDirPairs if the source and target
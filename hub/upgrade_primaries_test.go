@@ -4,18 +4,26 @@
 package hub_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"io"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
 	"github.com/greenplum-db/gp-common-go-libs/dbconn"
 	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
 
 	"github.com/greenplum-db/gpupgrade/greenplum"
 	"github.com/greenplum-db/gpupgrade/hub"
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/utils"
 )
 
 func TestUpgradePrimaries(t *testing.T) {
@@ -83,7 +91,7 @@ func TestUpgradePrimaries(t *testing.T) {
 				MasterBackupDir:            "",
 				TablespacesMappingFilePath: "/tmp/tablespaces_mapping.txt",
 			},
-		).Return(&idl.UpgradePrimariesReply{}, nil)
+		).Return(finishedStream(ctrl), nil)
 
 		client2 := mock_idl.NewMockAgentClient(ctrl)
 		client2.EXPECT().UpgradePrimaries(
@@ -98,14 +106,15 @@ func TestUpgradePrimaries(t *testing.T) {
 				MasterBackupDir:            "",
 				TablespacesMappingFilePath: "/tmp/tablespaces_mapping.txt",
 			},
-		).Return(&idl.UpgradePrimariesReply{}, nil)
+		).Return(finishedStream(ctrl), nil)
 
 		agentConns := []*hub.Connection{
 			{nil, client1, "sdw1", nil},
 			{nil, client2, "sdw2", nil},
 		}
 
-		err := hub.UpgradePrimaries(hub.UpgradePrimaryArgs{
+		_, err := hub.UpgradePrimaries(context.Background(), hub.UpgradePrimaryArgs{
+			Stream:                 utils.DevNull,
 			CheckOnly:              false,
 			MasterBackupDir:        "",
 			AgentConns:             agentConns,
@@ -136,7 +145,7 @@ func TestUpgradePrimaries(t *testing.T) {
 				UseLinkMode:     false,
 				MasterBackupDir: "",
 			},
-		).Return(&idl.UpgradePrimariesReply{}, nil)
+		).Return(finishedStream(ctrl), nil)
 
 		expected := errors.New("permission denied")
 		failedClient := mock_idl.NewMockAgentClient(ctrl)
@@ -152,14 +161,15 @@ func TestUpgradePrimaries(t *testing.T) {
 				MasterBackupDir:            "",
 				TablespacesMappingFilePath: "",
 			},
-		).Return(&idl.UpgradePrimariesReply{}, expected)
+		).Return(nil, expected)
 
 		agentConns := []*hub.Connection{
 			{nil, client1, "sdw1", nil},
 			{nil, failedClient, "sdw2", nil},
 		}
 
-		err := hub.UpgradePrimaries(hub.UpgradePrimaryArgs{
+		_, err := hub.UpgradePrimaries(context.Background(), hub.UpgradePrimaryArgs{
+			Stream:                 utils.DevNull,
 			CheckOnly:              false,
 			MasterBackupDir:        "",
 			AgentConns:             agentConns,
@@ -180,6 +190,293 @@ func TestUpgradePrimaries(t *testing.T) {
 			t.Errorf("error %q did not contain expected contents '%q'", err.Error(), expected.Error())
 		}
 	})
+
+	t.Run("writes the output streamed by the agents", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := errors.New("pg_upgrade failed")
+
+		stream := mock_idl.NewMockAgent_UpgradePrimariesClient(ctrl)
+		gomock.InOrder(
+			stream.EXPECT().Recv().Return(&idl.Message{Contents: &idl.Message_Chunk{Chunk: &idl.Chunk{
+				Buffer: []byte("Performing Upgrade\n"), Type: idl.Chunk_STDOUT, Host: "sdw1", Content: 0,
+			}}}, nil),
+			stream.EXPECT().Recv().Return(&idl.Message{Contents: &idl.Message_Chunk{Chunk: &idl.Chunk{
+				Buffer: []byte("could not connect\n"), Type: idl.Chunk_STDERR, Host: "sdw1", Content: 0,
+			}}}, nil),
+			stream.EXPECT().Recv().Return(nil, expected),
+		)
+
+		client := mock_idl.NewMockAgentClient(ctrl)
+		client.EXPECT().UpgradePrimaries(gomock.Any(), gomock.Any()).Return(stream, nil)
+
+		streams := &bufferedStreams{}
+		_, err := hub.UpgradePrimaries(context.Background(), hub.UpgradePrimaryArgs{
+			Stream:         streams,
+			AgentConns:     []*hub.Connection{{nil, client, "sdw1", nil}},
+			DataDirPairMap: pairs,
+			Source:         source,
+			Target:         target,
+		})
+		if err == nil || !strings.Contains(err.Error(), expected.Error()) {
+			t.Errorf("got error %v, want it to contain %q", err, expected)
+		}
+
+		if streams.stdout.String() != "[sdw1 content 0] Performing Upgrade\n" {
+			t.Errorf("got stdout %q", streams.stdout.String())
+		}
+
+		if streams.stderr.String() != "[sdw1 content 0] could not connect\n" {
+			t.Errorf("got stderr %q", streams.stderr.String())
+		}
+	})
+
+	t.Run("returns a result for each segment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		reported := &idl.SegmentResult{
+			Host:       "sdw1",
+			Content:    0,
+			Dbid:       2,
+			Phase:      idl.SegmentResult_DONE,
+			DurationMs: 1500,
+			LogDir:     "/home/gpadmin/.gpupgrade/pg_upgrade/seg0",
+		}
+
+		stream := mock_idl.NewMockAgent_UpgradePrimariesClient(ctrl)
+		gomock.InOrder(
+			stream.EXPECT().Recv().Return(&idl.Message{Contents: &idl.Message_SegmentResult{SegmentResult: reported}}, nil),
+			stream.EXPECT().Recv().Return(nil, io.EOF),
+		)
+
+		client1 := mock_idl.NewMockAgentClient(ctrl)
+		client1.EXPECT().UpgradePrimaries(gomock.Any(), gomock.Any()).Return(stream, nil)
+
+		expected := errors.New("connection refused")
+		client2 := mock_idl.NewMockAgentClient(ctrl)
+		client2.EXPECT().UpgradePrimaries(gomock.Any(), gomock.Any()).Return(nil, expected)
+
+		results, err := hub.UpgradePrimaries(context.Background(), hub.UpgradePrimaryArgs{
+			Stream:         utils.DevNull,
+			AgentConns:     []*hub.Connection{{nil, client2, "sdw2", nil}, {nil, client1, "sdw1", nil}},
+			DataDirPairMap: pairs,
+			Source:         source,
+			Target:         target,
+		})
+		if err == nil || !strings.Contains(err.Error(), expected.Error()) {
+			t.Errorf("got error %v, want it to contain %q", err, expected)
+		}
+
+		missing := &idl.SegmentResult{
+			Host:     "sdw2",
+			Content:  1,
+			Dbid:     3,
+			Phase:    idl.SegmentResult_UNKNOWN_PHASE,
+			ExitCode: -1,
+			Error:    expected.Error(),
+		}
+
+		if len(results) != 2 || !proto.Equal(results[0], reported) || !proto.Equal(results[1], missing) {
+			t.Errorf("got results %v, want %v", results, []*idl.SegmentResult{reported, missing})
+		}
+	})
+
+	t.Run("skips segments that were already upgraded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// sdw1 has only completed segments, so it should not be contacted.
+		client1 := mock_idl.NewMockAgentClient(ctrl)
+
+		client2 := mock_idl.NewMockAgentClient(ctrl)
+		client2.EXPECT().UpgradePrimaries(
+			gomock.Any(),
+			&idl.UpgradePrimariesRequest{
+				SourceBinDir:  "/usr/local/greenplum-db",
+				TargetBinDir:  "/usr/local/greenplum-db-new",
+				TargetVersion: dbconn.NewVersion("6.0.0").VersionString,
+				DataDirPairs:  pairs["sdw2"],
+			},
+		).Return(finishedStream(ctrl), nil)
+
+		results, err := hub.UpgradePrimaries(context.Background(), hub.UpgradePrimaryArgs{
+			Stream:         utils.DevNull,
+			AgentConns:     []*hub.Connection{{nil, client1, "sdw1", nil}, {nil, client2, "sdw2", nil}},
+			DataDirPairMap: pairs,
+			Source:         source,
+			Target:         target,
+			Completed:      map[int32]bool{0: true},
+		})
+		if err != nil {
+			t.Errorf("got unexpected error: %+v", err)
+		}
+
+		skipped := &idl.SegmentResult{
+			Host:    "sdw1",
+			Content: 0,
+			Dbid:    2,
+			Phase:   idl.SegmentResult_DONE,
+			Skipped: true,
+		}
+
+		if len(results) != 2 || !proto.Equal(results[0], skipped) || results[1].Content != 1 {
+			t.Errorf("got results %v, want the first to be %v", results, skipped)
+		}
+	})
+
+	t.Run("bounds the upgrades running on each host and across the cluster", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cluster := &concurrencyTracker{}
+		manyPairs := make(map[string][]*idl.DataDirPair)
+		var conns []*hub.Connection
+
+		for i, host := range []string{"sdw1", "sdw2", "sdw3"} {
+			for j := 0; j < 3; j++ {
+				content := int32(i*3 + j)
+				manyPairs[host] = append(manyPairs[host], &idl.DataDirPair{Content: content, DBID: content + 2})
+			}
+
+			perHost := &concurrencyTracker{}
+			client := mock_idl.NewMockAgentClient(ctrl)
+			client.EXPECT().UpgradePrimaries(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, req *idl.UpgradePrimariesRequest, _ ...grpc.CallOption) (idl.Agent_UpgradePrimariesClient, error) {
+					if len(req.DataDirPairs) != 1 || req.MaxConcurrency != 1 {
+						t.Errorf("got %d pairs with MaxConcurrency %d, want 1 pair with MaxConcurrency 1",
+							len(req.DataDirPairs), req.MaxConcurrency)
+					}
+
+					cluster.start()
+					perHost.start()
+					return &trackedStream{done: func() {
+						perHost.stop()
+						cluster.stop()
+					}}, nil
+				}).Times(3)
+
+			conns = append(conns, &hub.Connection{nil, client, host, nil})
+			defer func(host string) {
+				if perHost.max > 1 {
+					t.Errorf("got %d upgrades running at once on %s, want at most 1", perHost.max, host)
+				}
+			}(host)
+		}
+
+		results, err := hub.UpgradePrimaries(context.Background(), hub.UpgradePrimaryArgs{
+			Stream:             utils.DevNull,
+			AgentConns:         conns,
+			DataDirPairMap:     manyPairs,
+			Source:             source,
+			Target:             target,
+			MaxUpgradesPerHost: 1,
+			MaxUpgrades:        2,
+		})
+		if err != nil {
+			t.Errorf("got unexpected error: %+v", err)
+		}
+
+		if len(results) != 9 {
+			t.Errorf("got %d results, want 9", len(results))
+		}
+
+		if cluster.max > 2 {
+			t.Errorf("got %d upgrades running at once across the cluster, want at most 2", cluster.max)
+		}
+	})
+
+	t.Run("cancels the agent requests when the context is cancelled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		client := mock_idl.NewMockAgentClient(ctrl)
+		client.EXPECT().UpgradePrimaries(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ *idl.UpgradePrimariesRequest, _ ...grpc.CallOption) (idl.Agent_UpgradePrimariesClient, error) {
+				cancel()
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(5 * time.Second):
+					return nil, errors.New("request context was not cancelled")
+				}
+			})
+
+		_, err := hub.UpgradePrimaries(ctx, hub.UpgradePrimaryArgs{
+			Stream:         utils.DevNull,
+			AgentConns:     []*hub.Connection{{nil, client, "sdw1", nil}},
+			DataDirPairMap: pairs,
+			Source:         source,
+			Target:         target,
+		})
+		if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+			t.Errorf("got error %v, want it to contain %q", err, context.Canceled)
+		}
+	})
+}
+
+// finishedStream returns a mock UpgradePrimaries stream that completes without
+// sending any output.
+func finishedStream(ctrl *gomock.Controller) idl.Agent_UpgradePrimariesClient {
+	stream := mock_idl.NewMockAgent_UpgradePrimariesClient(ctrl)
+	stream.EXPECT().Recv().Return(nil, io.EOF)
+
+	return stream
+}
+
+// concurrencyTracker records the most operations that were running at once.
+type concurrencyTracker struct {
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (c *concurrencyTracker) start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.running++
+	if c.running > c.max {
+		c.max = c.running
+	}
+}
+
+func (c *concurrencyTracker) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.running--
+}
+
+// trackedStream is an UpgradePrimaries stream that finishes after a short
+// delay, calling done just before it does.
+type trackedStream struct {
+	idl.Agent_UpgradePrimariesClient
+	done func()
+}
+
+func (s *trackedStream) Recv() (*idl.Message, error) {
+	time.Sleep(20 * time.Millisecond)
+	s.done()
+	return nil, io.EOF
+}
+
+// bufferedStreams is an implementation of OutStreams that just writes to
+// bytes.Buffers.
+type bufferedStreams struct {
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+}
+
+func (b *bufferedStreams) Stdout() io.Writer {
+	return &b.stdout
+}
+
+func (b *bufferedStreams) Stderr() io.Writer {
+	return &b.stderr
 }
 
 func TestGetDataDirPairs(t *testing.T) {
@@ -0,0 +1,165 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
+)
+
+var ErrCopyMismatch = xerrors.New("copies differ from their source")
+
+// CopyMismatchError lists, for each host, the files of its copy that differ
+// from the source.
+type CopyMismatchError struct {
+	DestinationDir string
+	Mismatches     map[string][]dirsync.Mismatch // keyed by host
+}
+
+func (c CopyMismatchError) Error() string {
+	var hosts []string
+	for host := range c.Mismatches {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "the copies in %q differ from their source on %d host(s):", c.DestinationDir, len(hosts))
+	for _, host := range hosts {
+		fmt.Fprintf(&b, "\n  %s:", host)
+		for _, m := range c.Mismatches[host] {
+			fmt.Fprintf(&b, "\n    %s", m)
+		}
+	}
+
+	return b.String()
+}
+
+func (c CopyMismatchError) Is(err error) bool {
+	return err == ErrCopyMismatch
+}
+
+// VerifyCopy checks the copies that Copy made of the sources on the host of
+// each agent against a manifest of the source checksums. A CopyMismatchError
+// is returned when any of the copies differ.
+func VerifyCopy(ctx context.Context, destinationDir string, sourceDirs []string, conns []*Connection) error {
+	opts := dirsync.Options{Delete: true}
+
+	manifest, err := dirsync.NewManifest(ctx, sourceDirs, opts)
+	if err != nil {
+		return xerrors.Errorf("creating manifest of %q: %w", sourceDirs, err)
+	}
+
+	type result struct {
+		host       string
+		mismatches []dirsync.Mismatch
+		err        error
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan result, len(conns))
+
+	for _, conn := range conns {
+		conn := conn // capture range variable
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			mismatches, err := verifyOnAgent(ctx, conn.AgentClient, destinationDir, manifest, opts)
+			if err != nil {
+				err = xerrors.Errorf("verifying copy of %q in %q on host %s: %w", sourceDirs, destinationDir, conn.Hostname, err)
+			}
+
+			results <- result{host: conn.Hostname, mismatches: mismatches, err: err}
+		}()
+	}
+
+	wg.Wait()
+	close(results)
+
+	var multierr *multierror.Error
+	mismatches := make(map[string][]dirsync.Mismatch)
+
+	for result := range results {
+		if result.err != nil {
+			multierr = multierror.Append(multierr, result.err)
+			continue
+		}
+
+		if len(result.mismatches) > 0 {
+			mismatches[result.host] = result.mismatches
+		}
+	}
+
+	if err := multierr.ErrorOrNil(); err != nil {
+		return err
+	}
+
+	if len(mismatches) > 0 {
+		return CopyMismatchError{DestinationDir: destinationDir, Mismatches: mismatches}
+	}
+
+	return nil
+}
+
+// verifyOnAgent streams the manifest to an agent, which compares it against
+// destinationDir and returns the files that differ.
+func verifyOnAgent(ctx context.Context, client idl.AgentClient, destinationDir string, manifest dirsync.Manifest, opts dirsync.Options) ([]dirsync.Mismatch, error) {
+	stream, err := client.VerifyDirectory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	send := func(request *idl.VerifyDirectoryRequest) error {
+		err := stream.Send(request)
+		if err == io.EOF {
+			// The agent has ended the stream. Its error is returned from
+			// the reply.
+			_, err = stream.CloseAndRecv()
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+		return err
+	}
+
+	err = send(&idl.VerifyDirectoryRequest{Contents: &idl.VerifyDirectoryRequest_Options{
+		Options: dirsync.OptionsToProto(destinationDir, opts),
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range manifest {
+		err := send(&idl.VerifyDirectoryRequest{Contents: &idl.VerifyDirectoryRequest_Entry{
+			Entry: dirsync.ManifestEntryToProto(e),
+		}})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []dirsync.Mismatch
+	for _, m := range reply.GetMismatches() {
+		mismatches = append(mismatches, dirsync.MismatchFromProto(m))
+	}
+
+	return mismatches, nil
+}
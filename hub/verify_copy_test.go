@@ -0,0 +1,206 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package hub
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	multierror "github.com/hashicorp/go-multierror"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+)
+
+func TestVerifyCopy(t *testing.T) {
+	sourceDir := testutils.GetTempDir(t, "verify-source")
+	defer testutils.MustRemoveAll(t, sourceDir)
+
+	if err := ioutil.WriteFile(filepath.Join(sourceDir, "hello"), []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("streams the manifest of the sources to each host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		host1 := mock_idl.NewMockAgentClient(ctrl)
+		host2 := mock_idl.NewMockAgentClient(ctrl)
+		requests1 := expectVerify(ctrl, host1, &idl.VerifyDirectoryReply{}, nil)
+		requests2 := expectVerify(ctrl, host2, &idl.VerifyDirectoryReply{}, nil)
+		conns := []*Connection{
+			{AgentClient: host1, Hostname: "host1"},
+			{AgentClient: host2, Hostname: "host2"},
+		}
+
+		err := VerifyCopy(context.Background(), "foobar/path", []string{sourceDir + "/"}, conns)
+		if err != nil {
+			t.Errorf("verifying copy: %+v", err)
+		}
+
+		checksum := sha256.Sum256([]byte("hello"))
+		expected := []*idl.VerifyDirectoryRequest{
+			{Contents: &idl.VerifyDirectoryRequest_Options{Options: &idl.SyncOptions{TargetDir: "foobar/path", Delete: true}}},
+			{Contents: &idl.VerifyDirectoryRequest_Entry{Entry: &idl.ManifestEntry{Path: ".", Type: idl.FileEntry_DIRECTORY}}},
+			{Contents: &idl.VerifyDirectoryRequest_Entry{Entry: &idl.ManifestEntry{Path: "hello", Type: idl.FileEntry_REGULAR, Size: 5, Checksum: checksum[:]}}},
+		}
+
+		for _, requests := range [][]*idl.VerifyDirectoryRequest{*requests1, *requests2} {
+			if len(requests) != len(expected) {
+				t.Fatalf("got %d requests want %d", len(requests), len(expected))
+			}
+
+			for i := range expected {
+				if !proto.Equal(requests[i], expected[i]) {
+					t.Errorf("got request %d %v want %v", i, requests[i], expected[i])
+				}
+			}
+		}
+	})
+
+	t.Run("returns the mismatched files of each host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		host1 := mock_idl.NewMockAgentClient(ctrl)
+		host2 := mock_idl.NewMockAgentClient(ctrl)
+		host3 := mock_idl.NewMockAgentClient(ctrl)
+		expectVerify(ctrl, host1, &idl.VerifyDirectoryReply{Mismatches: []*idl.Mismatch{
+			{Path: "hello", Reason: idl.Mismatch_MISSING},
+		}}, nil)
+		expectVerify(ctrl, host2, &idl.VerifyDirectoryReply{}, nil)
+		expectVerify(ctrl, host3, &idl.VerifyDirectoryReply{Mismatches: []*idl.Mismatch{
+			{Path: "extra", Reason: idl.Mismatch_EXTRANEOUS},
+			{Path: "hello", Reason: idl.Mismatch_CHANGED, Detail: "checksum differs"},
+		}}, nil)
+		conns := []*Connection{
+			{AgentClient: host1, Hostname: "sdw1"},
+			{AgentClient: host2, Hostname: "sdw2"},
+			{AgentClient: host3, Hostname: "sdw3"},
+		}
+
+		err := VerifyCopy(context.Background(), "foobar/path", []string{sourceDir + "/"}, conns)
+		if !xerrors.Is(err, ErrCopyMismatch) {
+			t.Fatalf("got error %#v want %#v", err, ErrCopyMismatch)
+		}
+
+		expected := `the copies in "foobar/path" differ from their source on 2 host(s):
+  sdw1:
+    hello: missing
+  sdw3:
+    extra: extraneous
+    hello: changed (checksum differs)`
+		if err.Error() != expected {
+			t.Errorf("got error %q want %q", err.Error(), expected)
+		}
+	})
+
+	t.Run("returns the errors from each host", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := errors.New("permission denied")
+
+		var conns []*Connection
+		for _, host := range []string{"sdw1", "sdw2"} {
+			client := mock_idl.NewMockAgentClient(ctrl)
+			expectVerify(ctrl, client, nil, expected)
+			conns = append(conns, &Connection{AgentClient: client, Hostname: host})
+		}
+
+		err := VerifyCopy(context.Background(), "foobar/path", []string{sourceDir + "/"}, conns)
+
+		var merr *multierror.Error
+		if !xerrors.As(err, &merr) {
+			t.Fatalf("returned %#v, want error type %T", err, merr)
+		}
+
+		if len(merr.Errors) != len(conns) {
+			t.Errorf("got %d errors want %d", len(merr.Errors), len(conns))
+		}
+
+		for _, err := range merr.Errors {
+			if !xerrors.Is(err, expected) {
+				t.Errorf("returned error %#v, want %#v", err, expected)
+			}
+		}
+	})
+
+	t.Run("returns the agent's error when it ends the stream early", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		expected := errors.New("no such directory")
+
+		stream := mock_idl.NewMockAgent_VerifyDirectoryClient(ctrl)
+		stream.EXPECT().Send(gomock.Any()).Return(io.EOF)
+		stream.EXPECT().CloseAndRecv().Return(nil, expected)
+
+		client := mock_idl.NewMockAgentClient(ctrl)
+		client.EXPECT().VerifyDirectory(gomock.Any()).Return(stream, nil)
+
+		conns := []*Connection{{AgentClient: client, Hostname: "sdw1"}}
+		err := VerifyCopy(context.Background(), "foobar/path", []string{sourceDir + "/"}, conns)
+
+		var merr *multierror.Error
+		if !xerrors.As(err, &merr) {
+			t.Fatalf("returned %#v, want error type %T", err, merr)
+		}
+		if !xerrors.Is(merr.Errors[0], expected) {
+			t.Errorf("returned error %#v, want %#v", merr.Errors[0], expected)
+		}
+	})
+
+	t.Run("fails to create the manifest of missing sources", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// Any call to the agent fails the test.
+		conns := []*Connection{{AgentClient: mock_idl.NewMockAgentClient(ctrl), Hostname: "sdw1"}}
+
+		err := VerifyCopy(context.Background(), "foobar/path", []string{filepath.Join(sourceDir, "missing")}, conns)
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
+
+// expectVerify sets up the agent client to expect a single verification, and
+// returns the requests that it is sent. The verification ends with the given
+// reply and error.
+func expectVerify(ctrl *gomock.Controller, client *mock_idl.MockAgentClient, reply *idl.VerifyDirectoryReply, err error) *[]*idl.VerifyDirectoryRequest {
+	requests := new([]*idl.VerifyDirectoryRequest)
+
+	stream := mock_idl.NewMockAgent_VerifyDirectoryClient(ctrl)
+	stream.EXPECT().Send(gomock.Any()).DoAndReturn(func(request *idl.VerifyDirectoryRequest) error {
+		*requests = append(*requests, request)
+		return nil
+	}).AnyTimes()
+	stream.EXPECT().CloseAndRecv().Return(reply, err)
+
+	client.EXPECT().VerifyDirectory(gomock.Any()).Return(stream, nil)
+
+	return requests
+}
+
+// manifestPaths returns the paths of the files in the manifest that the
+// requests send.
+func manifestPaths(requests []*idl.VerifyDirectoryRequest) []string {
+	var paths []string
+	for _, request := range requests {
+		if entry := request.GetEntry(); entry != nil {
+			paths = append(paths, entry.Path)
+		}
+	}
+	return paths
+}
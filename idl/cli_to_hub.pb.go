@@ -7,6 +7,8 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	duration "github.com/golang/protobuf/ptypes/duration"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -55,6 +57,8 @@ const (
 	Substep_STOP_HUB_AND_AGENTS                      Substep = 25
 	Substep_DELETE_MASTER_STATEDIR                   Substep = 26
 	Substep_ARCHIVE_LOG_DIRECTORIES                  Substep = 27
+	Substep_CHECK_TARGET_PORTS                       Substep = 28
+	Substep_RUN_CHECKS                               Substep = 29
 )
 
 var Substep_name = map[int32]string{
@@ -86,6 +90,8 @@ var Substep_name = map[int32]string{
 	25: "STOP_HUB_AND_AGENTS",
 	26: "DELETE_MASTER_STATEDIR",
 	27: "ARCHIVE_LOG_DIRECTORIES",
+	28: "CHECK_TARGET_PORTS",
+	29: "RUN_CHECKS",
 }
 
 var Substep_value = map[string]int32{
@@ -117,6 +123,8 @@ var Substep_value = map[string]int32{
 	"STOP_HUB_AND_AGENTS":                      25,
 	"DELETE_MASTER_STATEDIR":                   26,
 	"ARCHIVE_LOG_DIRECTORIES":                  27,
+	"CHECK_TARGET_PORTS":                       28,
+	"RUN_CHECKS":                               29,
 }
 
 func (x Substep) String() string {
@@ -183,6 +191,43 @@ func (ResponseKey) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_631e66a01873be02, []int{2}
 }
 
+type RunCheckRequest_Check int32
+
+const (
+	RunCheckRequest_UNKNOWN_CHECK         RunCheckRequest_Check = 0
+	RunCheckRequest_SEGMENT_STATUS        RunCheckRequest_Check = 1
+	RunCheckRequest_PORTS_FREE            RunCheckRequest_Check = 2
+	RunCheckRequest_SSH_REACHABILITY      RunCheckRequest_Check = 3
+	RunCheckRequest_VERSION_COMPATIBILITY RunCheckRequest_Check = 4
+	RunCheckRequest_SOURCE_CATALOG        RunCheckRequest_Check = 5
+)
+
+var RunCheckRequest_Check_name = map[int32]string{
+	0: "UNKNOWN_CHECK",
+	1: "SEGMENT_STATUS",
+	2: "PORTS_FREE",
+	3: "SSH_REACHABILITY",
+	4: "VERSION_COMPATIBILITY",
+	5: "SOURCE_CATALOG",
+}
+
+var RunCheckRequest_Check_value = map[string]int32{
+	"UNKNOWN_CHECK":         0,
+	"SEGMENT_STATUS":        1,
+	"PORTS_FREE":            2,
+	"SSH_REACHABILITY":      3,
+	"VERSION_COMPATIBILITY": 4,
+	"SOURCE_CATALOG":        5,
+}
+
+func (x RunCheckRequest_Check) String() string {
+	return proto.EnumName(RunCheckRequest_Check_name, int32(x))
+}
+
+func (RunCheckRequest_Check) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{15, 0}
+}
+
 type Chunk_Type int32
 
 const (
@@ -208,7 +253,72 @@ func (x Chunk_Type) String() string {
 }
 
 func (Chunk_Type) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{14, 0}
+	return fileDescriptor_631e66a01873be02, []int{21, 0}
+}
+
+type SegmentResult_Phase int32
+
+const (
+	SegmentResult_UNKNOWN_PHASE       SegmentResult_Phase = 0
+	SegmentResult_RESTORE_BACKUP      SegmentResult_Phase = 1
+	SegmentResult_RESTORE_TABLESPACES SegmentResult_Phase = 2
+	SegmentResult_PG_UPGRADE          SegmentResult_Phase = 3
+	SegmentResult_DONE                SegmentResult_Phase = 4
+)
+
+var SegmentResult_Phase_name = map[int32]string{
+	0: "UNKNOWN_PHASE",
+	1: "RESTORE_BACKUP",
+	2: "RESTORE_TABLESPACES",
+	3: "PG_UPGRADE",
+	4: "DONE",
+}
+
+var SegmentResult_Phase_value = map[string]int32{
+	"UNKNOWN_PHASE":       0,
+	"RESTORE_BACKUP":      1,
+	"RESTORE_TABLESPACES": 2,
+	"PG_UPGRADE":          3,
+	"DONE":                4,
+}
+
+func (x SegmentResult_Phase) String() string {
+	return proto.EnumName(SegmentResult_Phase_name, int32(x))
+}
+
+func (SegmentResult_Phase) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{23, 0}
+}
+
+type AgentHealth_State int32
+
+const (
+	AgentHealth_NOT_CONNECTED AgentHealth_State = 0
+	AgentHealth_SERVING       AgentHealth_State = 1
+	AgentHealth_NOT_SERVING   AgentHealth_State = 2
+	AgentHealth_UNREACHABLE   AgentHealth_State = 3
+)
+
+var AgentHealth_State_name = map[int32]string{
+	0: "NOT_CONNECTED",
+	1: "SERVING",
+	2: "NOT_SERVING",
+	3: "UNREACHABLE",
+}
+
+var AgentHealth_State_value = map[string]int32{
+	"NOT_CONNECTED": 0,
+	"SERVING":       1,
+	"NOT_SERVING":   2,
+	"UNREACHABLE":   3,
+}
+
+func (x AgentHealth_State) String() string {
+	return proto.EnumName(AgentHealth_State_name, int32(x))
+}
+
+func (AgentHealth_State) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{34, 0}
 }
 
 type InitializeRequest struct {
@@ -218,6 +328,8 @@ type InitializeRequest struct {
 	SourcePort           int32    `protobuf:"varint,4,opt,name=sourcePort,proto3" json:"sourcePort,omitempty"`
 	UseLinkMode          bool     `protobuf:"varint,5,opt,name=useLinkMode,proto3" json:"useLinkMode,omitempty"`
 	Ports                []uint32 `protobuf:"varint,6,rep,packed,name=ports,proto3" json:"ports,omitempty"`
+	MaxUpgradesPerHost   int32    `protobuf:"varint,7,opt,name=maxUpgradesPerHost,proto3" json:"maxUpgradesPerHost,omitempty"`
+	MaxUpgrades          int32    `protobuf:"varint,8,opt,name=maxUpgrades,proto3" json:"maxUpgrades,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -290,6 +402,20 @@ func (m *InitializeRequest) GetPorts() []uint32 {
 	return nil
 }
 
+func (m *InitializeRequest) GetMaxUpgradesPerHost() int32 {
+	if m != nil {
+		return m.MaxUpgradesPerHost
+	}
+	return 0
+}
+
+func (m *InitializeRequest) GetMaxUpgrades() int32 {
+	if m != nil {
+		return m.MaxUpgrades
+	}
+	return 0
+}
+
 type InitializeCreateClusterRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -322,9 +448,10 @@ func (m *InitializeCreateClusterRequest) XXX_DiscardUnknown() {
 var xxx_messageInfo_InitializeCreateClusterRequest proto.InternalMessageInfo
 
 type ExecuteRequest struct {
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	RetryFailedSegmentsOnly bool     `protobuf:"varint,1,opt,name=retryFailedSegmentsOnly,proto3" json:"retryFailedSegmentsOnly,omitempty"`
+	XXX_NoUnkeyedLiteral    struct{} `json:"-"`
+	XXX_unrecognized        []byte   `json:"-"`
+	XXX_sizecache           int32    `json:"-"`
 }
 
 func (m *ExecuteRequest) Reset()         { *m = ExecuteRequest{} }
@@ -352,6 +479,13 @@ func (m *ExecuteRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_ExecuteRequest proto.InternalMessageInfo
 
+func (m *ExecuteRequest) GetRetryFailedSegmentsOnly() bool {
+	if m != nil {
+		return m.RetryFailedSegmentsOnly
+	}
+	return false
+}
+
 type FinalizeRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -414,17 +548,272 @@ func (m *RevertRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_RevertRequest proto.InternalMessageInfo
 
-type RestartAgentsRequest struct {
+type PlanRequest struct {
+	Step                    string   `protobuf:"bytes,1,opt,name=step,proto3" json:"step,omitempty"`
+	RetryFailedSegmentsOnly bool     `protobuf:"varint,2,opt,name=retryFailedSegmentsOnly,proto3" json:"retryFailedSegmentsOnly,omitempty"`
+	XXX_NoUnkeyedLiteral    struct{} `json:"-"`
+	XXX_unrecognized        []byte   `json:"-"`
+	XXX_sizecache           int32    `json:"-"`
+}
+
+func (m *PlanRequest) Reset()         { *m = PlanRequest{} }
+func (m *PlanRequest) String() string { return proto.CompactTextString(m) }
+func (*PlanRequest) ProtoMessage()    {}
+func (*PlanRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{5}
+}
+
+func (m *PlanRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PlanRequest.Unmarshal(m, b)
+}
+func (m *PlanRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PlanRequest.Marshal(b, m, deterministic)
+}
+func (m *PlanRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlanRequest.Merge(m, src)
+}
+func (m *PlanRequest) XXX_Size() int {
+	return xxx_messageInfo_PlanRequest.Size(m)
+}
+func (m *PlanRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlanRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlanRequest proto.InternalMessageInfo
+
+func (m *PlanRequest) GetStep() string {
+	if m != nil {
+		return m.Step
+	}
+	return ""
+}
+
+func (m *PlanRequest) GetRetryFailedSegmentsOnly() bool {
+	if m != nil {
+		return m.RetryFailedSegmentsOnly
+	}
+	return false
+}
+
+type PlanReply struct {
+	Actions              []*PlannedAction `protobuf:"bytes,1,rep,name=actions,proto3" json:"actions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *PlanReply) Reset()         { *m = PlanReply{} }
+func (m *PlanReply) String() string { return proto.CompactTextString(m) }
+func (*PlanReply) ProtoMessage()    {}
+func (*PlanReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{6}
+}
+
+func (m *PlanReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PlanReply.Unmarshal(m, b)
+}
+func (m *PlanReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PlanReply.Marshal(b, m, deterministic)
+}
+func (m *PlanReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlanReply.Merge(m, src)
+}
+func (m *PlanReply) XXX_Size() int {
+	return xxx_messageInfo_PlanReply.Size(m)
+}
+func (m *PlanReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlanReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlanReply proto.InternalMessageInfo
+
+func (m *PlanReply) GetActions() []*PlannedAction {
+	if m != nil {
+		return m.Actions
+	}
+	return nil
+}
+
+// PlannedAction describes one operation that a step would perform.
+type PlannedAction struct {
+	Substep              Substep  `protobuf:"varint,1,opt,name=substep,proto3,enum=idl.Substep" json:"substep,omitempty"`
+	Host                 string   `protobuf:"bytes,2,opt,name=host,proto3" json:"host,omitempty"`
+	Description          string   `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Command              string   `protobuf:"bytes,4,opt,name=command,proto3" json:"command,omitempty"`
+	Contents             string   `protobuf:"bytes,5,opt,name=contents,proto3" json:"contents,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PlannedAction) Reset()         { *m = PlannedAction{} }
+func (m *PlannedAction) String() string { return proto.CompactTextString(m) }
+func (*PlannedAction) ProtoMessage()    {}
+func (*PlannedAction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{7}
+}
+
+func (m *PlannedAction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PlannedAction.Unmarshal(m, b)
+}
+func (m *PlannedAction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PlannedAction.Marshal(b, m, deterministic)
+}
+func (m *PlannedAction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlannedAction.Merge(m, src)
+}
+func (m *PlannedAction) XXX_Size() int {
+	return xxx_messageInfo_PlannedAction.Size(m)
+}
+func (m *PlannedAction) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlannedAction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlannedAction proto.InternalMessageInfo
+
+func (m *PlannedAction) GetSubstep() Substep {
+	if m != nil {
+		return m.Substep
+	}
+	return Substep_UNKNOWN_SUBSTEP
+}
+
+func (m *PlannedAction) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *PlannedAction) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *PlannedAction) GetCommand() string {
+	if m != nil {
+		return m.Command
+	}
+	return ""
+}
+
+func (m *PlannedAction) GetContents() string {
+	if m != nil {
+		return m.Contents
+	}
+	return ""
+}
+
+// HelloRequest and HelloReply let the CLI, hub and agents check that they
+// were built from the same version before relying on any other message.
+type HelloRequest struct {
+	Version              string   `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Capabilities         []string `protobuf:"bytes,2,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HelloRequest) Reset()         { *m = HelloRequest{} }
+func (m *HelloRequest) String() string { return proto.CompactTextString(m) }
+func (*HelloRequest) ProtoMessage()    {}
+func (*HelloRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{8}
+}
+
+func (m *HelloRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HelloRequest.Unmarshal(m, b)
+}
+func (m *HelloRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HelloRequest.Marshal(b, m, deterministic)
+}
+func (m *HelloRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HelloRequest.Merge(m, src)
+}
+func (m *HelloRequest) XXX_Size() int {
+	return xxx_messageInfo_HelloRequest.Size(m)
+}
+func (m *HelloRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HelloRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HelloRequest proto.InternalMessageInfo
+
+func (m *HelloRequest) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *HelloRequest) GetCapabilities() []string {
+	if m != nil {
+		return m.Capabilities
+	}
+	return nil
+}
+
+type HelloReply struct {
+	Version              string   `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Capabilities         []string `protobuf:"bytes,2,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
+func (m *HelloReply) Reset()         { *m = HelloReply{} }
+func (m *HelloReply) String() string { return proto.CompactTextString(m) }
+func (*HelloReply) ProtoMessage()    {}
+func (*HelloReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{9}
+}
+
+func (m *HelloReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HelloReply.Unmarshal(m, b)
+}
+func (m *HelloReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HelloReply.Marshal(b, m, deterministic)
+}
+func (m *HelloReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HelloReply.Merge(m, src)
+}
+func (m *HelloReply) XXX_Size() int {
+	return xxx_messageInfo_HelloReply.Size(m)
+}
+func (m *HelloReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_HelloReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HelloReply proto.InternalMessageInfo
+
+func (m *HelloReply) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *HelloReply) GetCapabilities() []string {
+	if m != nil {
+		return m.Capabilities
+	}
+	return nil
+}
+
+type RestartAgentsRequest struct {
+	RedeployMismatchedAgents bool     `protobuf:"varint,1,opt,name=redeployMismatchedAgents,proto3" json:"redeployMismatchedAgents,omitempty"`
+	XXX_NoUnkeyedLiteral     struct{} `json:"-"`
+	XXX_unrecognized         []byte   `json:"-"`
+	XXX_sizecache            int32    `json:"-"`
+}
+
 func (m *RestartAgentsRequest) Reset()         { *m = RestartAgentsRequest{} }
 func (m *RestartAgentsRequest) String() string { return proto.CompactTextString(m) }
 func (*RestartAgentsRequest) ProtoMessage()    {}
 func (*RestartAgentsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{5}
+	return fileDescriptor_631e66a01873be02, []int{10}
 }
 
 func (m *RestartAgentsRequest) XXX_Unmarshal(b []byte) error {
@@ -445,6 +834,13 @@ func (m *RestartAgentsRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_RestartAgentsRequest proto.InternalMessageInfo
 
+func (m *RestartAgentsRequest) GetRedeployMismatchedAgents() bool {
+	if m != nil {
+		return m.RedeployMismatchedAgents
+	}
+	return false
+}
+
 type RestartAgentsReply struct {
 	AgentHosts           []string `protobuf:"bytes,1,rep,name=agentHosts,proto3" json:"agentHosts,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -456,7 +852,7 @@ func (m *RestartAgentsReply) Reset()         { *m = RestartAgentsReply{} }
 func (m *RestartAgentsReply) String() string { return proto.CompactTextString(m) }
 func (*RestartAgentsReply) ProtoMessage()    {}
 func (*RestartAgentsReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{6}
+	return fileDescriptor_631e66a01873be02, []int{11}
 }
 
 func (m *RestartAgentsReply) XXX_Unmarshal(b []byte) error {
@@ -494,7 +890,7 @@ func (m *StopServicesRequest) Reset()         { *m = StopServicesRequest{} }
 func (m *StopServicesRequest) String() string { return proto.CompactTextString(m) }
 func (*StopServicesRequest) ProtoMessage()    {}
 func (*StopServicesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{7}
+	return fileDescriptor_631e66a01873be02, []int{12}
 }
 
 func (m *StopServicesRequest) XXX_Unmarshal(b []byte) error {
@@ -525,7 +921,7 @@ func (m *StopServicesReply) Reset()         { *m = StopServicesReply{} }
 func (m *StopServicesReply) String() string { return proto.CompactTextString(m) }
 func (*StopServicesReply) ProtoMessage()    {}
 func (*StopServicesReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{8}
+	return fileDescriptor_631e66a01873be02, []int{13}
 }
 
 func (m *StopServicesReply) XXX_Unmarshal(b []byte) error {
@@ -558,7 +954,7 @@ func (m *SubstepStatus) Reset()         { *m = SubstepStatus{} }
 func (m *SubstepStatus) String() string { return proto.CompactTextString(m) }
 func (*SubstepStatus) ProtoMessage()    {}
 func (*SubstepStatus) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{9}
+	return fileDescriptor_631e66a01873be02, []int{14}
 }
 
 func (m *SubstepStatus) XXX_Unmarshal(b []byte) error {
@@ -593,8 +989,97 @@ func (m *SubstepStatus) GetStatus() Status {
 	return Status_UNKNOWN_STATUS
 }
 
+type RunCheckRequest struct {
+	Check                RunCheckRequest_Check `protobuf:"varint,1,opt,name=check,proto3,enum=idl.RunCheckRequest_Check" json:"check,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *RunCheckRequest) Reset()         { *m = RunCheckRequest{} }
+func (m *RunCheckRequest) String() string { return proto.CompactTextString(m) }
+func (*RunCheckRequest) ProtoMessage()    {}
+func (*RunCheckRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{15}
+}
+
+func (m *RunCheckRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RunCheckRequest.Unmarshal(m, b)
+}
+func (m *RunCheckRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RunCheckRequest.Marshal(b, m, deterministic)
+}
+func (m *RunCheckRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RunCheckRequest.Merge(m, src)
+}
+func (m *RunCheckRequest) XXX_Size() int {
+	return xxx_messageInfo_RunCheckRequest.Size(m)
+}
+func (m *RunCheckRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RunCheckRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RunCheckRequest proto.InternalMessageInfo
+
+func (m *RunCheckRequest) GetCheck() RunCheckRequest_Check {
+	if m != nil {
+		return m.Check
+	}
+	return RunCheckRequest_UNKNOWN_CHECK
+}
+
+type RunCheckReply struct {
+	Failures             []string `protobuf:"bytes,1,rep,name=failures,proto3" json:"failures,omitempty"`
+	Remediation          string   `protobuf:"bytes,2,opt,name=remediation,proto3" json:"remediation,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RunCheckReply) Reset()         { *m = RunCheckReply{} }
+func (m *RunCheckReply) String() string { return proto.CompactTextString(m) }
+func (*RunCheckReply) ProtoMessage()    {}
+func (*RunCheckReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{16}
+}
+
+func (m *RunCheckReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RunCheckReply.Unmarshal(m, b)
+}
+func (m *RunCheckReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RunCheckReply.Marshal(b, m, deterministic)
+}
+func (m *RunCheckReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RunCheckReply.Merge(m, src)
+}
+func (m *RunCheckReply) XXX_Size() int {
+	return xxx_messageInfo_RunCheckReply.Size(m)
+}
+func (m *RunCheckReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RunCheckReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RunCheckReply proto.InternalMessageInfo
+
+func (m *RunCheckReply) GetFailures() []string {
+	if m != nil {
+		return m.Failures
+	}
+	return nil
+}
+
+func (m *RunCheckReply) GetRemediation() string {
+	if m != nil {
+		return m.Remediation
+	}
+	return ""
+}
+
 type CheckDiskSpaceRequest struct {
-	Ratio                float64  `protobuf:"fixed64,1,opt,name=ratio,proto3" json:"ratio,omitempty"`
+	Ratio float64 `protobuf:"fixed64,1,opt,name=ratio,proto3" json:"ratio,omitempty"`
+	// estimate the space that the upgrade needs from the size of the data,
+	// instead of requiring ratio of each filesystem to be free
+	Estimate             bool     `protobuf:"varint,2,opt,name=estimate,proto3" json:"estimate,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -604,7 +1089,7 @@ func (m *CheckDiskSpaceRequest) Reset()         { *m = CheckDiskSpaceRequest{} }
 func (m *CheckDiskSpaceRequest) String() string { return proto.CompactTextString(m) }
 func (*CheckDiskSpaceRequest) ProtoMessage()    {}
 func (*CheckDiskSpaceRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{10}
+	return fileDescriptor_631e66a01873be02, []int{17}
 }
 
 func (m *CheckDiskSpaceRequest) XXX_Unmarshal(b []byte) error {
@@ -632,6 +1117,13 @@ func (m *CheckDiskSpaceRequest) GetRatio() float64 {
 	return 0
 }
 
+func (m *CheckDiskSpaceRequest) GetEstimate() bool {
+	if m != nil {
+		return m.Estimate
+	}
+	return false
+}
+
 type CheckDiskSpaceReply struct {
 	Failed               map[string]*CheckDiskSpaceReply_DiskUsage `protobuf:"bytes,1,rep,name=failed,proto3" json:"failed,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	XXX_NoUnkeyedLiteral struct{}                                  `json:"-"`
@@ -643,7 +1135,7 @@ func (m *CheckDiskSpaceReply) Reset()         { *m = CheckDiskSpaceReply{} }
 func (m *CheckDiskSpaceReply) String() string { return proto.CompactTextString(m) }
 func (*CheckDiskSpaceReply) ProtoMessage()    {}
 func (*CheckDiskSpaceReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{11}
+	return fileDescriptor_631e66a01873be02, []int{18}
 }
 
 func (m *CheckDiskSpaceReply) XXX_Unmarshal(b []byte) error {
@@ -674,6 +1166,8 @@ func (m *CheckDiskSpaceReply) GetFailed() map[string]*CheckDiskSpaceReply_DiskUs
 type CheckDiskSpaceReply_DiskUsage struct {
 	Available            uint64   `protobuf:"varint,1,opt,name=available,proto3" json:"available,omitempty"`
 	Required             uint64   `protobuf:"varint,2,opt,name=required,proto3" json:"required,omitempty"`
+	InodesAvailable      uint64   `protobuf:"varint,3,opt,name=inodesAvailable,proto3" json:"inodesAvailable,omitempty"`
+	InodesRequired       uint64   `protobuf:"varint,4,opt,name=inodesRequired,proto3" json:"inodesRequired,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -683,7 +1177,7 @@ func (m *CheckDiskSpaceReply_DiskUsage) Reset()         { *m = CheckDiskSpaceRep
 func (m *CheckDiskSpaceReply_DiskUsage) String() string { return proto.CompactTextString(m) }
 func (*CheckDiskSpaceReply_DiskUsage) ProtoMessage()    {}
 func (*CheckDiskSpaceReply_DiskUsage) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{11, 0}
+	return fileDescriptor_631e66a01873be02, []int{18, 0}
 }
 
 func (m *CheckDiskSpaceReply_DiskUsage) XXX_Unmarshal(b []byte) error {
@@ -718,17 +1212,31 @@ func (m *CheckDiskSpaceReply_DiskUsage) GetRequired() uint64 {
 	return 0
 }
 
-type PrepareInitClusterRequest struct {
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *CheckDiskSpaceReply_DiskUsage) GetInodesAvailable() uint64 {
+	if m != nil {
+		return m.InodesAvailable
+	}
+	return 0
+}
+
+func (m *CheckDiskSpaceReply_DiskUsage) GetInodesRequired() uint64 {
+	if m != nil {
+		return m.InodesRequired
+	}
+	return 0
+}
+
+type PrepareInitClusterRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *PrepareInitClusterRequest) Reset()         { *m = PrepareInitClusterRequest{} }
 func (m *PrepareInitClusterRequest) String() string { return proto.CompactTextString(m) }
 func (*PrepareInitClusterRequest) ProtoMessage()    {}
 func (*PrepareInitClusterRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{12}
+	return fileDescriptor_631e66a01873be02, []int{19}
 }
 
 func (m *PrepareInitClusterRequest) XXX_Unmarshal(b []byte) error {
@@ -759,7 +1267,7 @@ func (m *PrepareInitClusterReply) Reset()         { *m = PrepareInitClusterReply
 func (m *PrepareInitClusterReply) String() string { return proto.CompactTextString(m) }
 func (*PrepareInitClusterReply) ProtoMessage()    {}
 func (*PrepareInitClusterReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{13}
+	return fileDescriptor_631e66a01873be02, []int{20}
 }
 
 func (m *PrepareInitClusterReply) XXX_Unmarshal(b []byte) error {
@@ -781,18 +1289,22 @@ func (m *PrepareInitClusterReply) XXX_DiscardUnknown() {
 var xxx_messageInfo_PrepareInitClusterReply proto.InternalMessageInfo
 
 type Chunk struct {
-	Buffer               []byte     `protobuf:"bytes,1,opt,name=buffer,proto3" json:"buffer,omitempty"`
-	Type                 Chunk_Type `protobuf:"varint,2,opt,name=type,proto3,enum=idl.Chunk_Type" json:"type,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
-	XXX_unrecognized     []byte     `json:"-"`
-	XXX_sizecache        int32      `json:"-"`
+	Buffer []byte     `protobuf:"bytes,1,opt,name=buffer,proto3" json:"buffer,omitempty"`
+	Type   Chunk_Type `protobuf:"varint,2,opt,name=type,proto3,enum=idl.Chunk_Type" json:"type,omitempty"`
+	// Output streamed from an agent is tagged with the host and the content ID
+	// of the segment that produced it. Host is empty for other output.
+	Host                 string   `protobuf:"bytes,3,opt,name=host,proto3" json:"host,omitempty"`
+	Content              int32    `protobuf:"varint,4,opt,name=content,proto3" json:"content,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Chunk) Reset()         { *m = Chunk{} }
 func (m *Chunk) String() string { return proto.CompactTextString(m) }
 func (*Chunk) ProtoMessage()    {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{14}
+	return fileDescriptor_631e66a01873be02, []int{21}
 }
 
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
@@ -827,11 +1339,27 @@ func (m *Chunk) GetType() Chunk_Type {
 	return Chunk_UNKNOWN
 }
 
+func (m *Chunk) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *Chunk) GetContent() int32 {
+	if m != nil {
+		return m.Content
+	}
+	return 0
+}
+
 type Message struct {
 	// Types that are valid to be assigned to Contents:
 	//	*Message_Chunk
 	//	*Message_Status
 	//	*Message_Response
+	//	*Message_SegmentResult
+	//	*Message_SegmentResults
 	Contents             isMessage_Contents `protobuf_oneof:"contents"`
 	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
 	XXX_unrecognized     []byte             `json:"-"`
@@ -842,7 +1370,7 @@ func (m *Message) Reset()         { *m = Message{} }
 func (m *Message) String() string { return proto.CompactTextString(m) }
 func (*Message) ProtoMessage()    {}
 func (*Message) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{15}
+	return fileDescriptor_631e66a01873be02, []int{22}
 }
 
 func (m *Message) XXX_Unmarshal(b []byte) error {
@@ -879,12 +1407,24 @@ type Message_Response struct {
 	Response *Response `protobuf:"bytes,3,opt,name=response,proto3,oneof"`
 }
 
+type Message_SegmentResult struct {
+	SegmentResult *SegmentResult `protobuf:"bytes,4,opt,name=segmentResult,proto3,oneof"`
+}
+
+type Message_SegmentResults struct {
+	SegmentResults *SegmentResults `protobuf:"bytes,5,opt,name=segmentResults,proto3,oneof"`
+}
+
 func (*Message_Chunk) isMessage_Contents() {}
 
 func (*Message_Status) isMessage_Contents() {}
 
 func (*Message_Response) isMessage_Contents() {}
 
+func (*Message_SegmentResult) isMessage_Contents() {}
+
+func (*Message_SegmentResults) isMessage_Contents() {}
+
 func (m *Message) GetContents() isMessage_Contents {
 	if m != nil {
 		return m.Contents
@@ -913,13 +1453,172 @@ func (m *Message) GetResponse() *Response {
 	return nil
 }
 
+func (m *Message) GetSegmentResult() *SegmentResult {
+	if x, ok := m.GetContents().(*Message_SegmentResult); ok {
+		return x.SegmentResult
+	}
+	return nil
+}
+
+func (m *Message) GetSegmentResults() *SegmentResults {
+	if x, ok := m.GetContents().(*Message_SegmentResults); ok {
+		return x.SegmentResults
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*Message) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
 		(*Message_Chunk)(nil),
 		(*Message_Status)(nil),
 		(*Message_Response)(nil),
+		(*Message_SegmentResult)(nil),
+		(*Message_SegmentResults)(nil),
+	}
+}
+
+// SegmentResult describes how far the upgrade of a single primary segment got.
+type SegmentResult struct {
+	Host                 string              `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Content              int32               `protobuf:"varint,2,opt,name=content,proto3" json:"content,omitempty"`
+	Dbid                 int32               `protobuf:"varint,3,opt,name=dbid,proto3" json:"dbid,omitempty"`
+	Phase                SegmentResult_Phase `protobuf:"varint,4,opt,name=phase,proto3,enum=idl.SegmentResult_Phase" json:"phase,omitempty"`
+	DurationMs           int64               `protobuf:"varint,5,opt,name=durationMs,proto3" json:"durationMs,omitempty"`
+	ExitCode             int32               `protobuf:"varint,6,opt,name=exitCode,proto3" json:"exitCode,omitempty"`
+	LogDir               string              `protobuf:"bytes,7,opt,name=logDir,proto3" json:"logDir,omitempty"`
+	Error                string              `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	Skipped              bool                `protobuf:"varint,9,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *SegmentResult) Reset()         { *m = SegmentResult{} }
+func (m *SegmentResult) String() string { return proto.CompactTextString(m) }
+func (*SegmentResult) ProtoMessage()    {}
+func (*SegmentResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{23}
+}
+
+func (m *SegmentResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SegmentResult.Unmarshal(m, b)
+}
+func (m *SegmentResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SegmentResult.Marshal(b, m, deterministic)
+}
+func (m *SegmentResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SegmentResult.Merge(m, src)
+}
+func (m *SegmentResult) XXX_Size() int {
+	return xxx_messageInfo_SegmentResult.Size(m)
+}
+func (m *SegmentResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_SegmentResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SegmentResult proto.InternalMessageInfo
+
+func (m *SegmentResult) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *SegmentResult) GetContent() int32 {
+	if m != nil {
+		return m.Content
+	}
+	return 0
+}
+
+func (m *SegmentResult) GetDbid() int32 {
+	if m != nil {
+		return m.Dbid
+	}
+	return 0
+}
+
+func (m *SegmentResult) GetPhase() SegmentResult_Phase {
+	if m != nil {
+		return m.Phase
+	}
+	return SegmentResult_UNKNOWN_PHASE
+}
+
+func (m *SegmentResult) GetDurationMs() int64 {
+	if m != nil {
+		return m.DurationMs
+	}
+	return 0
+}
+
+func (m *SegmentResult) GetExitCode() int32 {
+	if m != nil {
+		return m.ExitCode
+	}
+	return 0
+}
+
+func (m *SegmentResult) GetLogDir() string {
+	if m != nil {
+		return m.LogDir
+	}
+	return ""
+}
+
+func (m *SegmentResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *SegmentResult) GetSkipped() bool {
+	if m != nil {
+		return m.Skipped
+	}
+	return false
+}
+
+type SegmentResults struct {
+	Results              []*SegmentResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *SegmentResults) Reset()         { *m = SegmentResults{} }
+func (m *SegmentResults) String() string { return proto.CompactTextString(m) }
+func (*SegmentResults) ProtoMessage()    {}
+func (*SegmentResults) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{24}
+}
+
+func (m *SegmentResults) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SegmentResults.Unmarshal(m, b)
+}
+func (m *SegmentResults) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SegmentResults.Marshal(b, m, deterministic)
+}
+func (m *SegmentResults) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SegmentResults.Merge(m, src)
+}
+func (m *SegmentResults) XXX_Size() int {
+	return xxx_messageInfo_SegmentResults.Size(m)
+}
+func (m *SegmentResults) XXX_DiscardUnknown() {
+	xxx_messageInfo_SegmentResults.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SegmentResults proto.InternalMessageInfo
+
+func (m *SegmentResults) GetResults() []*SegmentResult {
+	if m != nil {
+		return m.Results
 	}
+	return nil
 }
 
 type Response struct {
@@ -933,7 +1632,7 @@ func (m *Response) Reset()         { *m = Response{} }
 func (m *Response) String() string { return proto.CompactTextString(m) }
 func (*Response) ProtoMessage()    {}
 func (*Response) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{16}
+	return fileDescriptor_631e66a01873be02, []int{25}
 }
 
 func (m *Response) XXX_Unmarshal(b []byte) error {
@@ -973,7 +1672,7 @@ func (m *SetConfigRequest) Reset()         { *m = SetConfigRequest{} }
 func (m *SetConfigRequest) String() string { return proto.CompactTextString(m) }
 func (*SetConfigRequest) ProtoMessage()    {}
 func (*SetConfigRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{17}
+	return fileDescriptor_631e66a01873be02, []int{26}
 }
 
 func (m *SetConfigRequest) XXX_Unmarshal(b []byte) error {
@@ -994,125 +1693,700 @@ func (m *SetConfigRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_SetConfigRequest proto.InternalMessageInfo
 
-func (m *SetConfigRequest) GetName() string {
+func (m *SetConfigRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *SetConfigRequest) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type SetConfigReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetConfigReply) Reset()         { *m = SetConfigReply{} }
+func (m *SetConfigReply) String() string { return proto.CompactTextString(m) }
+func (*SetConfigReply) ProtoMessage()    {}
+func (*SetConfigReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{27}
+}
+
+func (m *SetConfigReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetConfigReply.Unmarshal(m, b)
+}
+func (m *SetConfigReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetConfigReply.Marshal(b, m, deterministic)
+}
+func (m *SetConfigReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetConfigReply.Merge(m, src)
+}
+func (m *SetConfigReply) XXX_Size() int {
+	return xxx_messageInfo_SetConfigReply.Size(m)
+}
+func (m *SetConfigReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetConfigReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetConfigReply proto.InternalMessageInfo
+
+type GetConfigRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetConfigRequest) Reset()         { *m = GetConfigRequest{} }
+func (m *GetConfigRequest) String() string { return proto.CompactTextString(m) }
+func (*GetConfigRequest) ProtoMessage()    {}
+func (*GetConfigRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{28}
+}
+
+func (m *GetConfigRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetConfigRequest.Unmarshal(m, b)
+}
+func (m *GetConfigRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetConfigRequest.Marshal(b, m, deterministic)
+}
+func (m *GetConfigRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetConfigRequest.Merge(m, src)
+}
+func (m *GetConfigRequest) XXX_Size() int {
+	return xxx_messageInfo_GetConfigRequest.Size(m)
+}
+func (m *GetConfigRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetConfigRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetConfigRequest proto.InternalMessageInfo
+
+func (m *GetConfigRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type GetConfigReply struct {
+	Value                string   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetConfigReply) Reset()         { *m = GetConfigReply{} }
+func (m *GetConfigReply) String() string { return proto.CompactTextString(m) }
+func (*GetConfigReply) ProtoMessage()    {}
+func (*GetConfigReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{29}
+}
+
+func (m *GetConfigReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetConfigReply.Unmarshal(m, b)
+}
+func (m *GetConfigReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetConfigReply.Marshal(b, m, deterministic)
+}
+func (m *GetConfigReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetConfigReply.Merge(m, src)
+}
+func (m *GetConfigReply) XXX_Size() int {
+	return xxx_messageInfo_GetConfigReply.Size(m)
+}
+func (m *GetConfigReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetConfigReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetConfigReply proto.InternalMessageInfo
+
+func (m *GetConfigReply) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type GetStatusRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetStatusRequest) Reset()         { *m = GetStatusRequest{} }
+func (m *GetStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*GetStatusRequest) ProtoMessage()    {}
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{30}
+}
+
+func (m *GetStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetStatusRequest.Unmarshal(m, b)
+}
+func (m *GetStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetStatusRequest.Marshal(b, m, deterministic)
+}
+func (m *GetStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetStatusRequest.Merge(m, src)
+}
+func (m *GetStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_GetStatusRequest.Size(m)
+}
+func (m *GetStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetStatusRequest proto.InternalMessageInfo
+
+type GetStatusReply struct {
+	Sections             []*SectionStatus     `protobuf:"bytes,1,rep,name=sections,proto3" json:"sections,omitempty"`
+	AgentHealth          *GetAgentHealthReply `protobuf:"bytes,2,opt,name=agentHealth,proto3" json:"agentHealth,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *GetStatusReply) Reset()         { *m = GetStatusReply{} }
+func (m *GetStatusReply) String() string { return proto.CompactTextString(m) }
+func (*GetStatusReply) ProtoMessage()    {}
+func (*GetStatusReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{31}
+}
+
+func (m *GetStatusReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetStatusReply.Unmarshal(m, b)
+}
+func (m *GetStatusReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetStatusReply.Marshal(b, m, deterministic)
+}
+func (m *GetStatusReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetStatusReply.Merge(m, src)
+}
+func (m *GetStatusReply) XXX_Size() int {
+	return xxx_messageInfo_GetStatusReply.Size(m)
+}
+func (m *GetStatusReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetStatusReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetStatusReply proto.InternalMessageInfo
+
+func (m *GetStatusReply) GetSections() []*SectionStatus {
+	if m != nil {
+		return m.Sections
+	}
+	return nil
+}
+
+func (m *GetStatusReply) GetAgentHealth() *GetAgentHealthReply {
+	if m != nil {
+		return m.AgentHealth
+	}
+	return nil
+}
+
+type GetAgentHealthRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAgentHealthRequest) Reset()         { *m = GetAgentHealthRequest{} }
+func (m *GetAgentHealthRequest) String() string { return proto.CompactTextString(m) }
+func (*GetAgentHealthRequest) ProtoMessage()    {}
+func (*GetAgentHealthRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{32}
+}
+
+func (m *GetAgentHealthRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAgentHealthRequest.Unmarshal(m, b)
+}
+func (m *GetAgentHealthRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAgentHealthRequest.Marshal(b, m, deterministic)
+}
+func (m *GetAgentHealthRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAgentHealthRequest.Merge(m, src)
+}
+func (m *GetAgentHealthRequest) XXX_Size() int {
+	return xxx_messageInfo_GetAgentHealthRequest.Size(m)
+}
+func (m *GetAgentHealthRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAgentHealthRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAgentHealthRequest proto.InternalMessageInfo
+
+type GetAgentHealthReply struct {
+	HubVersion           string         `protobuf:"bytes,1,opt,name=hubVersion,proto3" json:"hubVersion,omitempty"`
+	Agents               []*AgentHealth `protobuf:"bytes,2,rep,name=agents,proto3" json:"agents,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *GetAgentHealthReply) Reset()         { *m = GetAgentHealthReply{} }
+func (m *GetAgentHealthReply) String() string { return proto.CompactTextString(m) }
+func (*GetAgentHealthReply) ProtoMessage()    {}
+func (*GetAgentHealthReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{33}
+}
+
+func (m *GetAgentHealthReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAgentHealthReply.Unmarshal(m, b)
+}
+func (m *GetAgentHealthReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAgentHealthReply.Marshal(b, m, deterministic)
+}
+func (m *GetAgentHealthReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAgentHealthReply.Merge(m, src)
+}
+func (m *GetAgentHealthReply) XXX_Size() int {
+	return xxx_messageInfo_GetAgentHealthReply.Size(m)
+}
+func (m *GetAgentHealthReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAgentHealthReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAgentHealthReply proto.InternalMessageInfo
+
+func (m *GetAgentHealthReply) GetHubVersion() string {
+	if m != nil {
+		return m.HubVersion
+	}
+	return ""
+}
+
+func (m *GetAgentHealthReply) GetAgents() []*AgentHealth {
+	if m != nil {
+		return m.Agents
+	}
+	return nil
+}
+
+// AgentHealth is what the hub last heard from an agent through its heartbeats.
+type AgentHealth struct {
+	Hostname             string               `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	State                AgentHealth_State    `protobuf:"varint,2,opt,name=state,proto3,enum=idl.AgentHealth_State" json:"state,omitempty"`
+	LastSeen             *timestamp.Timestamp `protobuf:"bytes,3,opt,name=lastSeen,proto3" json:"lastSeen,omitempty"`
+	Version              string               `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	Error                string               `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *AgentHealth) Reset()         { *m = AgentHealth{} }
+func (m *AgentHealth) String() string { return proto.CompactTextString(m) }
+func (*AgentHealth) ProtoMessage()    {}
+func (*AgentHealth) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{34}
+}
+
+func (m *AgentHealth) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AgentHealth.Unmarshal(m, b)
+}
+func (m *AgentHealth) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AgentHealth.Marshal(b, m, deterministic)
+}
+func (m *AgentHealth) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AgentHealth.Merge(m, src)
+}
+func (m *AgentHealth) XXX_Size() int {
+	return xxx_messageInfo_AgentHealth.Size(m)
+}
+func (m *AgentHealth) XXX_DiscardUnknown() {
+	xxx_messageInfo_AgentHealth.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AgentHealth proto.InternalMessageInfo
+
+func (m *AgentHealth) GetHostname() string {
+	if m != nil {
+		return m.Hostname
+	}
+	return ""
+}
+
+func (m *AgentHealth) GetState() AgentHealth_State {
+	if m != nil {
+		return m.State
+	}
+	return AgentHealth_NOT_CONNECTED
+}
+
+func (m *AgentHealth) GetLastSeen() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastSeen
+	}
+	return nil
+}
+
+func (m *AgentHealth) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *AgentHealth) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type SectionStatus struct {
+	Name                 string           `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Substeps             []*SubstepRecord `protobuf:"bytes,2,rep,name=substeps,proto3" json:"substeps,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *SectionStatus) Reset()         { *m = SectionStatus{} }
+func (m *SectionStatus) String() string { return proto.CompactTextString(m) }
+func (*SectionStatus) ProtoMessage()    {}
+func (*SectionStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{35}
+}
+
+func (m *SectionStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SectionStatus.Unmarshal(m, b)
+}
+func (m *SectionStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SectionStatus.Marshal(b, m, deterministic)
+}
+func (m *SectionStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SectionStatus.Merge(m, src)
+}
+func (m *SectionStatus) XXX_Size() int {
+	return xxx_messageInfo_SectionStatus.Size(m)
+}
+func (m *SectionStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_SectionStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SectionStatus proto.InternalMessageInfo
+
+func (m *SectionStatus) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *SectionStatus) GetSubsteps() []*SubstepRecord {
+	if m != nil {
+		return m.Substeps
+	}
+	return nil
+}
+
+type RecoverRequest struct {
+	// Reset this substep even when it cannot be safely retried, once any
+	// manual cleanup has been done.
+	ForceRetry           Substep  `protobuf:"varint,1,opt,name=forceRetry,proto3,enum=idl.Substep" json:"forceRetry,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RecoverRequest) Reset()         { *m = RecoverRequest{} }
+func (m *RecoverRequest) String() string { return proto.CompactTextString(m) }
+func (*RecoverRequest) ProtoMessage()    {}
+func (*RecoverRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{36}
+}
+
+func (m *RecoverRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecoverRequest.Unmarshal(m, b)
+}
+func (m *RecoverRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecoverRequest.Marshal(b, m, deterministic)
+}
+func (m *RecoverRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecoverRequest.Merge(m, src)
+}
+func (m *RecoverRequest) XXX_Size() int {
+	return xxx_messageInfo_RecoverRequest.Size(m)
+}
+func (m *RecoverRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecoverRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecoverRequest proto.InternalMessageInfo
+
+func (m *RecoverRequest) GetForceRetry() Substep {
+	if m != nil {
+		return m.ForceRetry
+	}
+	return Substep_UNKNOWN_SUBSTEP
+}
+
+type RecoverReply struct {
+	Substeps             []*RecoveredSubstep `protobuf:"bytes,1,rep,name=substeps,proto3" json:"substeps,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *RecoverReply) Reset()         { *m = RecoverReply{} }
+func (m *RecoverReply) String() string { return proto.CompactTextString(m) }
+func (*RecoverReply) ProtoMessage()    {}
+func (*RecoverReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{37}
+}
+
+func (m *RecoverReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecoverReply.Unmarshal(m, b)
+}
+func (m *RecoverReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecoverReply.Marshal(b, m, deterministic)
+}
+func (m *RecoverReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecoverReply.Merge(m, src)
+}
+func (m *RecoverReply) XXX_Size() int {
+	return xxx_messageInfo_RecoverReply.Size(m)
+}
+func (m *RecoverReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecoverReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecoverReply proto.InternalMessageInfo
+
+func (m *RecoverReply) GetSubsteps() []*RecoveredSubstep {
+	if m != nil {
+		return m.Substeps
+	}
+	return nil
+}
+
+type RecoveredSubstep struct {
+	Section              string   `protobuf:"bytes,1,opt,name=section,proto3" json:"section,omitempty"`
+	Step                 Substep  `protobuf:"varint,2,opt,name=step,proto3,enum=idl.Substep" json:"step,omitempty"`
+	ResetToFailed        bool     `protobuf:"varint,3,opt,name=resetToFailed,proto3" json:"resetToFailed,omitempty"`
+	ManualSteps          []string `protobuf:"bytes,4,rep,name=manualSteps,proto3" json:"manualSteps,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RecoveredSubstep) Reset()         { *m = RecoveredSubstep{} }
+func (m *RecoveredSubstep) String() string { return proto.CompactTextString(m) }
+func (*RecoveredSubstep) ProtoMessage()    {}
+func (*RecoveredSubstep) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{38}
+}
+
+func (m *RecoveredSubstep) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecoveredSubstep.Unmarshal(m, b)
+}
+func (m *RecoveredSubstep) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecoveredSubstep.Marshal(b, m, deterministic)
+}
+func (m *RecoveredSubstep) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecoveredSubstep.Merge(m, src)
+}
+func (m *RecoveredSubstep) XXX_Size() int {
+	return xxx_messageInfo_RecoveredSubstep.Size(m)
+}
+func (m *RecoveredSubstep) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecoveredSubstep.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecoveredSubstep proto.InternalMessageInfo
+
+func (m *RecoveredSubstep) GetSection() string {
+	if m != nil {
+		return m.Section
+	}
+	return ""
+}
+
+func (m *RecoveredSubstep) GetStep() Substep {
+	if m != nil {
+		return m.Step
+	}
+	return Substep_UNKNOWN_SUBSTEP
+}
+
+func (m *RecoveredSubstep) GetResetToFailed() bool {
 	if m != nil {
-		return m.Name
+		return m.ResetToFailed
 	}
-	return ""
+	return false
 }
 
-func (m *SetConfigRequest) GetValue() string {
+func (m *RecoveredSubstep) GetManualSteps() []string {
 	if m != nil {
-		return m.Value
+		return m.ManualSteps
 	}
-	return ""
+	return nil
 }
 
-type SetConfigReply struct {
+type CancelRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *SetConfigReply) Reset()         { *m = SetConfigReply{} }
-func (m *SetConfigReply) String() string { return proto.CompactTextString(m) }
-func (*SetConfigReply) ProtoMessage()    {}
-func (*SetConfigReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{18}
+func (m *CancelRequest) Reset()         { *m = CancelRequest{} }
+func (m *CancelRequest) String() string { return proto.CompactTextString(m) }
+func (*CancelRequest) ProtoMessage()    {}
+func (*CancelRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{39}
 }
 
-func (m *SetConfigReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SetConfigReply.Unmarshal(m, b)
+func (m *CancelRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelRequest.Unmarshal(m, b)
 }
-func (m *SetConfigReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SetConfigReply.Marshal(b, m, deterministic)
+func (m *CancelRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelRequest.Marshal(b, m, deterministic)
 }
-func (m *SetConfigReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SetConfigReply.Merge(m, src)
+func (m *CancelRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelRequest.Merge(m, src)
 }
-func (m *SetConfigReply) XXX_Size() int {
-	return xxx_messageInfo_SetConfigReply.Size(m)
+func (m *CancelRequest) XXX_Size() int {
+	return xxx_messageInfo_CancelRequest.Size(m)
 }
-func (m *SetConfigReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_SetConfigReply.DiscardUnknown(m)
+func (m *CancelRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_SetConfigReply proto.InternalMessageInfo
+var xxx_messageInfo_CancelRequest proto.InternalMessageInfo
 
-type GetConfigRequest struct {
-	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+type CancelReply struct {
+	Cancelled            bool     `protobuf:"varint,1,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GetConfigRequest) Reset()         { *m = GetConfigRequest{} }
-func (m *GetConfigRequest) String() string { return proto.CompactTextString(m) }
-func (*GetConfigRequest) ProtoMessage()    {}
-func (*GetConfigRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{19}
+func (m *CancelReply) Reset()         { *m = CancelReply{} }
+func (m *CancelReply) String() string { return proto.CompactTextString(m) }
+func (*CancelReply) ProtoMessage()    {}
+func (*CancelReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{40}
 }
 
-func (m *GetConfigRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetConfigRequest.Unmarshal(m, b)
+func (m *CancelReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CancelReply.Unmarshal(m, b)
 }
-func (m *GetConfigRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetConfigRequest.Marshal(b, m, deterministic)
+func (m *CancelReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CancelReply.Marshal(b, m, deterministic)
 }
-func (m *GetConfigRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetConfigRequest.Merge(m, src)
+func (m *CancelReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CancelReply.Merge(m, src)
 }
-func (m *GetConfigRequest) XXX_Size() int {
-	return xxx_messageInfo_GetConfigRequest.Size(m)
+func (m *CancelReply) XXX_Size() int {
+	return xxx_messageInfo_CancelReply.Size(m)
 }
-func (m *GetConfigRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetConfigRequest.DiscardUnknown(m)
+func (m *CancelReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_CancelReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetConfigRequest proto.InternalMessageInfo
+var xxx_messageInfo_CancelReply proto.InternalMessageInfo
 
-func (m *GetConfigRequest) GetName() string {
+func (m *CancelReply) GetCancelled() bool {
 	if m != nil {
-		return m.Name
+		return m.Cancelled
 	}
-	return ""
+	return false
 }
 
-type GetConfigReply struct {
-	Value                string   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+type SubstepRecord struct {
+	Step                 Substep              `protobuf:"varint,1,opt,name=step,proto3,enum=idl.Substep" json:"step,omitempty"`
+	Status               Status               `protobuf:"varint,2,opt,name=status,proto3,enum=idl.Status" json:"status,omitempty"`
+	StartTime            *timestamp.Timestamp `protobuf:"bytes,3,opt,name=startTime,proto3" json:"startTime,omitempty"`
+	EndTime              *timestamp.Timestamp `protobuf:"bytes,4,opt,name=endTime,proto3" json:"endTime,omitempty"`
+	Duration             *duration.Duration   `protobuf:"bytes,5,opt,name=duration,proto3" json:"duration,omitempty"`
+	Attempts             int32                `protobuf:"varint,6,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	LastError            string               `protobuf:"bytes,7,opt,name=lastError,proto3" json:"lastError,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
-func (m *GetConfigReply) Reset()         { *m = GetConfigReply{} }
-func (m *GetConfigReply) String() string { return proto.CompactTextString(m) }
-func (*GetConfigReply) ProtoMessage()    {}
-func (*GetConfigReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_631e66a01873be02, []int{20}
+func (m *SubstepRecord) Reset()         { *m = SubstepRecord{} }
+func (m *SubstepRecord) String() string { return proto.CompactTextString(m) }
+func (*SubstepRecord) ProtoMessage()    {}
+func (*SubstepRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_631e66a01873be02, []int{41}
 }
 
-func (m *GetConfigReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetConfigReply.Unmarshal(m, b)
+func (m *SubstepRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubstepRecord.Unmarshal(m, b)
 }
-func (m *GetConfigReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetConfigReply.Marshal(b, m, deterministic)
+func (m *SubstepRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubstepRecord.Marshal(b, m, deterministic)
 }
-func (m *GetConfigReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetConfigReply.Merge(m, src)
+func (m *SubstepRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubstepRecord.Merge(m, src)
 }
-func (m *GetConfigReply) XXX_Size() int {
-	return xxx_messageInfo_GetConfigReply.Size(m)
+func (m *SubstepRecord) XXX_Size() int {
+	return xxx_messageInfo_SubstepRecord.Size(m)
 }
-func (m *GetConfigReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetConfigReply.DiscardUnknown(m)
+func (m *SubstepRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubstepRecord.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetConfigReply proto.InternalMessageInfo
+var xxx_messageInfo_SubstepRecord proto.InternalMessageInfo
 
-func (m *GetConfigReply) GetValue() string {
+func (m *SubstepRecord) GetStep() Substep {
 	if m != nil {
-		return m.Value
+		return m.Step
+	}
+	return Substep_UNKNOWN_SUBSTEP
+}
+
+func (m *SubstepRecord) GetStatus() Status {
+	if m != nil {
+		return m.Status
+	}
+	return Status_UNKNOWN_STATUS
+}
+
+func (m *SubstepRecord) GetStartTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartTime
+	}
+	return nil
+}
+
+func (m *SubstepRecord) GetEndTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.EndTime
+	}
+	return nil
+}
+
+func (m *SubstepRecord) GetDuration() *duration.Duration {
+	if m != nil {
+		return m.Duration
+	}
+	return nil
+}
+
+func (m *SubstepRecord) GetAttempts() int32 {
+	if m != nil {
+		return m.Attempts
+	}
+	return 0
+}
+
+func (m *SubstepRecord) GetLastError() string {
+	if m != nil {
+		return m.LastError
 	}
 	return ""
 }
@@ -1121,17 +2395,27 @@ func init() {
 	proto.RegisterEnum("idl.Substep", Substep_name, Substep_value)
 	proto.RegisterEnum("idl.Status", Status_name, Status_value)
 	proto.RegisterEnum("idl.ResponseKey", ResponseKey_name, ResponseKey_value)
+	proto.RegisterEnum("idl.RunCheckRequest_Check", RunCheckRequest_Check_name, RunCheckRequest_Check_value)
 	proto.RegisterEnum("idl.Chunk_Type", Chunk_Type_name, Chunk_Type_value)
+	proto.RegisterEnum("idl.SegmentResult_Phase", SegmentResult_Phase_name, SegmentResult_Phase_value)
+	proto.RegisterEnum("idl.AgentHealth_State", AgentHealth_State_name, AgentHealth_State_value)
 	proto.RegisterType((*InitializeRequest)(nil), "idl.InitializeRequest")
 	proto.RegisterType((*InitializeCreateClusterRequest)(nil), "idl.InitializeCreateClusterRequest")
 	proto.RegisterType((*ExecuteRequest)(nil), "idl.ExecuteRequest")
 	proto.RegisterType((*FinalizeRequest)(nil), "idl.FinalizeRequest")
 	proto.RegisterType((*RevertRequest)(nil), "idl.RevertRequest")
+	proto.RegisterType((*PlanRequest)(nil), "idl.PlanRequest")
+	proto.RegisterType((*PlanReply)(nil), "idl.PlanReply")
+	proto.RegisterType((*PlannedAction)(nil), "idl.PlannedAction")
+	proto.RegisterType((*HelloRequest)(nil), "idl.HelloRequest")
+	proto.RegisterType((*HelloReply)(nil), "idl.HelloReply")
 	proto.RegisterType((*RestartAgentsRequest)(nil), "idl.RestartAgentsRequest")
 	proto.RegisterType((*RestartAgentsReply)(nil), "idl.RestartAgentsReply")
 	proto.RegisterType((*StopServicesRequest)(nil), "idl.StopServicesRequest")
 	proto.RegisterType((*StopServicesReply)(nil), "idl.StopServicesReply")
 	proto.RegisterType((*SubstepStatus)(nil), "idl.SubstepStatus")
+	proto.RegisterType((*RunCheckRequest)(nil), "idl.RunCheckRequest")
+	proto.RegisterType((*RunCheckReply)(nil), "idl.RunCheckReply")
 	proto.RegisterType((*CheckDiskSpaceRequest)(nil), "idl.CheckDiskSpaceRequest")
 	proto.RegisterType((*CheckDiskSpaceReply)(nil), "idl.CheckDiskSpaceReply")
 	proto.RegisterMapType((map[string]*CheckDiskSpaceReply_DiskUsage)(nil), "idl.CheckDiskSpaceReply.FailedEntry")
@@ -1140,103 +2424,193 @@ func init() {
 	proto.RegisterType((*PrepareInitClusterReply)(nil), "idl.PrepareInitClusterReply")
 	proto.RegisterType((*Chunk)(nil), "idl.Chunk")
 	proto.RegisterType((*Message)(nil), "idl.Message")
+	proto.RegisterType((*SegmentResult)(nil), "idl.SegmentResult")
+	proto.RegisterType((*SegmentResults)(nil), "idl.SegmentResults")
 	proto.RegisterType((*Response)(nil), "idl.Response")
 	proto.RegisterMapType((map[string]string)(nil), "idl.Response.DataEntry")
 	proto.RegisterType((*SetConfigRequest)(nil), "idl.SetConfigRequest")
 	proto.RegisterType((*SetConfigReply)(nil), "idl.SetConfigReply")
 	proto.RegisterType((*GetConfigRequest)(nil), "idl.GetConfigRequest")
 	proto.RegisterType((*GetConfigReply)(nil), "idl.GetConfigReply")
+	proto.RegisterType((*GetStatusRequest)(nil), "idl.GetStatusRequest")
+	proto.RegisterType((*GetStatusReply)(nil), "idl.GetStatusReply")
+	proto.RegisterType((*GetAgentHealthRequest)(nil), "idl.GetAgentHealthRequest")
+	proto.RegisterType((*GetAgentHealthReply)(nil), "idl.GetAgentHealthReply")
+	proto.RegisterType((*AgentHealth)(nil), "idl.AgentHealth")
+	proto.RegisterType((*SectionStatus)(nil), "idl.SectionStatus")
+	proto.RegisterType((*RecoverRequest)(nil), "idl.RecoverRequest")
+	proto.RegisterType((*RecoverReply)(nil), "idl.RecoverReply")
+	proto.RegisterType((*RecoveredSubstep)(nil), "idl.RecoveredSubstep")
+	proto.RegisterType((*CancelRequest)(nil), "idl.CancelRequest")
+	proto.RegisterType((*CancelReply)(nil), "idl.CancelReply")
+	proto.RegisterType((*SubstepRecord)(nil), "idl.SubstepRecord")
 }
 
 func init() { proto.RegisterFile("cli_to_hub.proto", fileDescriptor_631e66a01873be02) }
 
 var fileDescriptor_631e66a01873be02 = []byte{
-	// 1358 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x56, 0x4d, 0x6f, 0xdb, 0x46,
-	0x13, 0x16, 0x6d, 0x49, 0x96, 0x46, 0xfe, 0x58, 0xaf, 0x64, 0x5b, 0x56, 0x82, 0x40, 0x60, 0x5e,
-	0x04, 0x46, 0x92, 0xd7, 0x08, 0xd4, 0xa2, 0x4d, 0x8a, 0x1c, 0x4a, 0x93, 0x6b, 0x8a, 0xb0, 0x4d,
-	0x09, 0x4b, 0x2a, 0x45, 0x0e, 0x05, 0x41, 0xcb, 0x6b, 0x87, 0xb0, 0x22, 0x2a, 0xe4, 0xd2, 0xa8,
-	0xfa, 0x3b, 0xfa, 0xb7, 0x7a, 0xe8, 0x8f, 0xe9, 0xa1, 0xb7, 0x62, 0x97, 0xa4, 0x4c, 0x29, 0x0a,
-	0xd0, 0x1b, 0x77, 0xe6, 0x99, 0x67, 0x76, 0x3e, 0x38, 0x3b, 0x80, 0xc6, 0x93, 0xc0, 0xe3, 0xa1,
-	0xf7, 0x29, 0xb9, 0x3e, 0x9d, 0x45, 0x21, 0x0f, 0xf1, 0x66, 0x70, 0x33, 0x51, 0xff, 0x52, 0x60,
-	0xdf, 0x9a, 0x06, 0x3c, 0xf0, 0x27, 0xc1, 0xef, 0x8c, 0xb2, 0x2f, 0x09, 0x8b, 0x39, 0x7e, 0x0a,
-	0x75, 0xff, 0x8e, 0x4d, 0xf9, 0x30, 0x8c, 0x78, 0x5b, 0xe9, 0x2a, 0x27, 0x15, 0xfa, 0x28, 0xc0,
-	0x2a, 0x6c, 0xc7, 0x61, 0x12, 0x8d, 0xd9, 0x59, 0x30, 0x35, 0x82, 0xa8, 0xbd, 0xd1, 0x55, 0x4e,
-	0xea, 0x74, 0x49, 0x26, 0x30, 0xdc, 0x8f, 0xee, 0x18, 0xcf, 0x30, 0x9b, 0x29, 0xa6, 0x28, 0xc3,
-	0xcf, 0x00, 0x52, 0x1b, 0xe9, 0xa6, 0x2c, 0xdd, 0x14, 0x24, 0xb8, 0x0b, 0x8d, 0x24, 0x66, 0x97,
-	0xc1, 0xf4, 0xfe, 0x2a, 0xbc, 0x61, 0xed, 0x4a, 0x57, 0x39, 0xa9, 0xd1, 0xa2, 0x08, 0xb7, 0xa0,
-	0x32, 0x0b, 0x23, 0x1e, 0xb7, 0xab, 0xdd, 0xcd, 0x93, 0x1d, 0x9a, 0x1e, 0xd4, 0x2e, 0x3c, 0x7b,
-	0x0c, 0x49, 0x8f, 0x98, 0xcf, 0x99, 0x3e, 0x49, 0x62, 0xce, 0xa2, 0x2c, 0x3e, 0x15, 0xc1, 0x2e,
-	0xf9, 0x8d, 0x8d, 0x13, 0x9e, 0x47, 0xac, 0xee, 0xc3, 0xde, 0x79, 0x30, 0x2d, 0x26, 0x41, 0xdd,
-	0x83, 0x1d, 0xca, 0x1e, 0x58, 0xc4, 0x73, 0xc1, 0x21, 0xb4, 0x28, 0x8b, 0xb9, 0x1f, 0x71, 0x4d,
-	0xe4, 0x22, 0xce, 0xe5, 0xdf, 0x03, 0x5e, 0x91, 0xcf, 0x26, 0x73, 0x11, 0x9d, 0x4c, 0x59, 0x3f,
-	0x8c, 0x79, 0xdc, 0x56, 0xba, 0x9b, 0x27, 0x75, 0x5a, 0x90, 0xa8, 0x07, 0xd0, 0x74, 0x78, 0x38,
-	0x73, 0x58, 0xf4, 0x10, 0x8c, 0xd9, 0x82, 0xac, 0x09, 0xfb, 0xcb, 0xe2, 0xd9, 0x64, 0xae, 0x7e,
-	0x80, 0x1d, 0x27, 0xb9, 0x8e, 0x39, 0x9b, 0x39, 0xdc, 0xe7, 0x49, 0x8c, 0xbb, 0x50, 0x16, 0x27,
-	0x59, 0x9b, 0xdd, 0xde, 0xf6, 0x69, 0x70, 0x33, 0x39, 0xcd, 0x10, 0x54, 0x6a, 0xf0, 0x73, 0xa8,
-	0xc6, 0x12, 0x2b, 0xcb, 0xb3, 0xdb, 0x6b, 0xa4, 0x18, 0x29, 0xa2, 0x99, 0x4a, 0xfd, 0x3f, 0x1c,
-	0xe8, 0x9f, 0xd8, 0xf8, 0xde, 0x08, 0xe2, 0x7b, 0x67, 0xe6, 0x8f, 0x17, 0x0d, 0xd0, 0x82, 0x4a,
-	0xe4, 0xf3, 0x20, 0x94, 0x0e, 0x14, 0x9a, 0x1e, 0xd4, 0x7f, 0x14, 0x68, 0xae, 0xe2, 0x45, 0xa8,
-	0xef, 0xa1, 0x7a, 0xeb, 0x07, 0x13, 0x76, 0x23, 0xc3, 0x6c, 0xf4, 0xfe, 0x27, 0x7d, 0xad, 0x41,
-	0x9e, 0x9e, 0x4b, 0x18, 0x99, 0xf2, 0x68, 0x4e, 0x33, 0x9b, 0x0e, 0x81, 0xba, 0x40, 0x8d, 0x62,
-	0xff, 0x8e, 0xc9, 0xce, 0x7b, 0xf0, 0x83, 0x89, 0x7f, 0x3d, 0x61, 0xd2, 0x79, 0x99, 0x3e, 0x0a,
-	0x70, 0x07, 0x6a, 0x11, 0xfb, 0x92, 0x04, 0x11, 0xbb, 0x91, 0x61, 0x95, 0xe9, 0xe2, 0xdc, 0xf9,
-	0x15, 0x1a, 0x05, 0x76, 0x8c, 0x60, 0xf3, 0x9e, 0xcd, 0x25, 0x45, 0x9d, 0x8a, 0x4f, 0xfc, 0x16,
-	0x2a, 0x0f, 0xfe, 0x24, 0x61, 0xd2, 0xb2, 0xd1, 0x53, 0xbf, 0x79, 0xc9, 0xc5, 0x6d, 0x68, 0x6a,
-	0xf0, 0xd3, 0xc6, 0x5b, 0x45, 0x7d, 0x02, 0xc7, 0xc3, 0x88, 0xcd, 0xfc, 0x88, 0x89, 0xde, 0x5a,
-	0xe9, 0xa7, 0x63, 0x38, 0x5a, 0xa7, 0x14, 0xa5, 0xfb, 0x02, 0x15, 0xfd, 0x53, 0x32, 0xbd, 0xc7,
-	0x87, 0x50, 0xbd, 0x4e, 0x6e, 0x6f, 0x59, 0x24, 0xef, 0xb4, 0x4d, 0xb3, 0x13, 0x7e, 0x0e, 0x65,
-	0x3e, 0x9f, 0xb1, 0xac, 0x4c, 0x7b, 0xd9, 0xad, 0x92, 0xe9, 0xfd, 0xa9, 0x3b, 0x9f, 0x31, 0x2a,
-	0x95, 0xea, 0x2b, 0x28, 0x8b, 0x13, 0x6e, 0xc0, 0xd6, 0xc8, 0xbe, 0xb0, 0x07, 0xbf, 0xd8, 0xa8,
-	0x84, 0x01, 0xaa, 0x8e, 0x6b, 0x0c, 0x46, 0x2e, 0x52, 0xb2, 0x6f, 0x42, 0x29, 0xda, 0x50, 0xff,
-	0x50, 0x60, 0xeb, 0x8a, 0xc5, 0x32, 0x9f, 0x2a, 0x54, 0xc6, 0x82, 0x4c, 0x3a, 0x6d, 0xf4, 0xe0,
-	0x91, 0xbe, 0x5f, 0xa2, 0xa9, 0x0a, 0xbf, 0x5e, 0x6a, 0x95, 0x46, 0x0f, 0x17, 0xdb, 0x29, 0xed,
-	0x98, 0x7e, 0x29, 0xef, 0x19, 0xfc, 0x4a, 0xd4, 0x20, 0x9e, 0x85, 0xd3, 0x98, 0xc9, 0xbf, 0xba,
-	0xd1, 0xdb, 0x91, 0x78, 0x9a, 0x09, 0xfb, 0x25, 0xba, 0x00, 0x9c, 0x01, 0xd4, 0xc6, 0xe1, 0x94,
-	0x8b, 0xbf, 0x42, 0x9d, 0x41, 0x2d, 0xc7, 0xe0, 0x57, 0x50, 0xbe, 0xf1, 0xb9, 0x9f, 0xf5, 0xcb,
-	0xd1, 0x12, 0xc1, 0xa9, 0xe1, 0x73, 0x3f, 0x6d, 0x11, 0x09, 0xea, 0xfc, 0x08, 0xf5, 0x85, 0x68,
-	0x4d, 0x5d, 0x5b, 0xc5, 0xba, 0xd6, 0x8b, 0x35, 0x7b, 0x0f, 0xc8, 0x61, 0x5c, 0x0f, 0xa7, 0xb7,
-	0xc1, 0x5d, 0xde, 0xd9, 0x18, 0xca, 0x53, 0xff, 0x33, 0xcb, 0x08, 0xe4, 0xf7, 0x7a, 0x06, 0x31,
-	0x24, 0x0a, 0xd6, 0xa2, 0x96, 0x2f, 0x00, 0x99, 0xff, 0x81, 0x4f, 0x7d, 0x01, 0xbb, 0xe6, 0x92,
-	0xe5, 0xa3, 0x07, 0xa5, 0xe0, 0xe1, 0xe5, 0x9f, 0x15, 0xd8, 0xca, 0xd2, 0x8c, 0x9b, 0xb0, 0x97,
-	0x55, 0xd6, 0x73, 0x46, 0x67, 0x8e, 0x4b, 0x86, 0xa8, 0x84, 0xdb, 0xd0, 0xd2, 0x29, 0xd1, 0x5c,
-	0xcb, 0x36, 0x3d, 0xc3, 0xa2, 0x44, 0x77, 0x07, 0xd4, 0x22, 0x0e, 0x52, 0xf0, 0x01, 0xec, 0x9b,
-	0xc4, 0x26, 0x34, 0xd5, 0xe9, 0x03, 0xfb, 0xdc, 0x32, 0xd1, 0x06, 0xde, 0x81, 0xba, 0xe3, 0x6a,
-	0xd4, 0xf5, 0xfa, 0xa3, 0x33, 0xb4, 0x89, 0x3b, 0x70, 0x48, 0x89, 0x4b, 0x2d, 0xf2, 0x81, 0x78,
-	0xce, 0x60, 0x44, 0x75, 0x92, 0x43, 0xcb, 0x18, 0xc1, 0x76, 0x0a, 0xd5, 0x4c, 0x62, 0xbb, 0x0e,
-	0xaa, 0xe0, 0x16, 0x20, 0xbd, 0x4f, 0xf4, 0x0b, 0xcf, 0xb0, 0x9c, 0x0b, 0xcf, 0x19, 0x6a, 0x3a,
-	0x41, 0xd5, 0xc5, 0x1d, 0x88, 0xe7, 0x6a, 0xd4, 0x24, 0x6e, 0xce, 0xb0, 0x85, 0x8f, 0xa0, 0x69,
-	0xd9, 0x96, 0xbb, 0x90, 0x5f, 0x8e, 0x1c, 0x97, 0x50, 0x54, 0xc3, 0x4f, 0xe0, 0xc8, 0xe9, 0x8f,
-	0x5c, 0x43, 0x04, 0xb3, 0xa2, 0xac, 0x0b, 0xbe, 0x33, 0x4d, 0xbf, 0x18, 0x0d, 0x73, 0xd5, 0x95,
-	0x26, 0x35, 0x80, 0xf7, 0x61, 0x27, 0xf5, 0x3f, 0x1a, 0x9a, 0x54, 0x33, 0x08, 0x6a, 0x2c, 0x31,
-	0xe5, 0x01, 0x64, 0x4c, 0xdb, 0x18, 0xc3, 0x6e, 0x86, 0xcc, 0x39, 0x76, 0xf0, 0x1e, 0x34, 0xf4,
-	0xc1, 0xf0, 0x63, 0x2e, 0xd8, 0x15, 0x89, 0xca, 0x41, 0x43, 0x6a, 0x5d, 0x69, 0x32, 0x7f, 0x7b,
-	0xe2, 0x16, 0x69, 0xf4, 0x2b, 0xf7, 0x43, 0xf8, 0x35, 0x9c, 0x8c, 0x86, 0x46, 0x31, 0x5e, 0xcd,
-	0xd5, 0x2e, 0x07, 0xa6, 0xa7, 0xd9, 0x46, 0x0e, 0xcb, 0x73, 0xb0, 0x2f, 0x2e, 0x98, 0xa1, 0x0d,
-	0xcd, 0xd5, 0x96, 0x8a, 0x84, 0xf1, 0x53, 0x68, 0xaf, 0x50, 0x0d, 0xec, 0x73, 0xef, 0xdc, 0xba,
-	0x24, 0x0e, 0x6a, 0xca, 0x8a, 0x67, 0x37, 0x73, 0x5c, 0xcd, 0x36, 0xce, 0x3e, 0xa2, 0x56, 0x51,
-	0x78, 0x65, 0x51, 0x3a, 0xa0, 0x0e, 0x3a, 0x10, 0x4e, 0x0c, 0x72, 0x49, 0xdc, 0x3c, 0x84, 0x8f,
-	0xd2, 0x99, 0x61, 0x51, 0x07, 0x1d, 0xe2, 0x63, 0x38, 0xc8, 0x94, 0x69, 0xcc, 0xb9, 0x0e, 0x1d,
-	0x09, 0xff, 0x99, 0xca, 0x21, 0xe6, 0x15, 0xb1, 0x5d, 0xe1, 0xc8, 0x25, 0xd2, 0xb0, 0x2d, 0xca,
-	0xe7, 0xb8, 0x83, 0xa1, 0x68, 0x15, 0x19, 0x5b, 0xd6, 0x07, 0xc7, 0xa2, 0x6b, 0x96, 0x19, 0x73,
-	0x2b, 0xd4, 0x11, 0x57, 0xd1, 0xa8, 0xde, 0xb7, 0x3e, 0x10, 0x4f, 0xe4, 0xa4, 0x18, 0xef, 0x93,
-	0x97, 0x3a, 0x54, 0xb3, 0xf7, 0x49, 0x94, 0x26, 0xef, 0x66, 0x57, 0x73, 0x47, 0x0e, 0x2a, 0x89,
-	0xd9, 0x45, 0x47, 0xb6, 0x6d, 0xd9, 0x26, 0x52, 0xf0, 0x36, 0xd4, 0xf4, 0xc1, 0xd5, 0x50, 0x78,
-	0x41, 0x1b, 0x62, 0x7a, 0x9d, 0x6b, 0xd6, 0x25, 0x31, 0xd0, 0xe6, 0xcb, 0x9f, 0xa1, 0x91, 0x4f,
-	0x82, 0x0b, 0x36, 0x17, 0x05, 0x4d, 0x97, 0x06, 0x4f, 0x3c, 0xee, 0xa8, 0x84, 0xbb, 0xf0, 0x34,
-	0x13, 0x7c, 0xf6, 0xc5, 0x98, 0xf5, 0xc4, 0x8c, 0xf0, 0x6e, 0x82, 0x88, 0x8d, 0x79, 0x18, 0xcd,
-	0x91, 0xd2, 0xfb, 0xbb, 0x0c, 0x35, 0x7d, 0x12, 0xb8, 0x61, 0x3f, 0xb9, 0xc6, 0x7d, 0xd8, 0x5d,
-	0x9e, 0xf1, 0xb8, 0xb3, 0x76, 0xf0, 0xcb, 0xbf, 0xb9, 0xd3, 0xfe, 0xd6, 0xa3, 0xa0, 0x96, 0xf0,
-	0x0f, 0x00, 0x8f, 0x6b, 0x05, 0x3e, 0x94, 0xc8, 0xaf, 0x56, 0xa7, 0x4e, 0xfa, 0x16, 0x67, 0xe3,
-	0x57, 0x2d, 0xbd, 0x51, 0xf0, 0x10, 0x8e, 0xbe, 0xb1, 0x8e, 0xe0, 0xe7, 0x2b, 0x24, 0xeb, 0x96,
-	0x95, 0x35, 0x8c, 0x6f, 0x60, 0x2b, 0x5b, 0x5f, 0x70, 0x53, 0x2a, 0x97, 0x97, 0x99, 0x35, 0x16,
-	0x3d, 0xa8, 0xe5, 0xeb, 0x0d, 0x6e, 0x49, 0xed, 0xca, 0xb6, 0xb3, 0xc6, 0xe6, 0x14, 0xaa, 0xe9,
-	0xfe, 0x83, 0x71, 0x36, 0x9f, 0x0b, 0xcb, 0xd0, 0x1a, 0xfc, 0x3b, 0xa8, 0x2f, 0xe6, 0x25, 0x3e,
-	0x48, 0xdf, 0x90, 0x95, 0x69, 0xd9, 0x69, 0xae, 0x8a, 0xd3, 0xd4, 0xbe, 0x83, 0xba, 0xb9, 0x62,
-	0x6a, 0xae, 0x37, 0x35, 0x57, 0x4d, 0x89, 0xd8, 0xd2, 0x0a, 0xcb, 0x17, 0x3e, 0xce, 0x1f, 0x93,
-	0xaf, 0x16, 0xb5, 0xce, 0xd1, 0x3a, 0x55, 0x4a, 0x73, 0x06, 0xdb, 0xc5, 0xb5, 0x0b, 0xb7, 0xb3,
-	0x75, 0xe9, 0xab, 0x05, 0xad, 0x73, 0xb8, 0x46, 0x23, 0x39, 0xae, 0xab, 0x72, 0xaf, 0xfe, 0xee,
-	0xdf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x46, 0x07, 0x51, 0x0a, 0x6b, 0x0b, 0x00, 0x00,
+	// 2575 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0xdb, 0x6e, 0xe3, 0xc6,
+	0x19, 0xd6, 0xf9, 0xf0, 0xcb, 0x92, 0xe9, 0xf1, 0x49, 0x56, 0xb6, 0x5b, 0x83, 0x09, 0x16, 0xc6,
+	0xee, 0x56, 0xd9, 0x3a, 0x69, 0xba, 0x59, 0xa4, 0x45, 0x69, 0x89, 0x96, 0x94, 0xb5, 0x25, 0x61,
+	0x48, 0xb9, 0x58, 0x14, 0x85, 0x40, 0x4b, 0x63, 0x9b, 0x30, 0x45, 0x2a, 0x24, 0x65, 0x44, 0x45,
+	0xef, 0x7a, 0xd7, 0x9b, 0xde, 0xb5, 0x97, 0xbd, 0xc8, 0x43, 0xf4, 0x19, 0x0a, 0xf4, 0x41, 0xfa,
+	0x00, 0xed, 0x7d, 0x31, 0x27, 0x8a, 0x94, 0xe5, 0x34, 0x40, 0x7b, 0xc7, 0xf9, 0xfe, 0x03, 0xff,
+	0x99, 0xf9, 0x8f, 0x03, 0xca, 0xc4, 0xb1, 0xc7, 0xa1, 0x37, 0xbe, 0x5b, 0x5c, 0x37, 0xe7, 0xbe,
+	0x17, 0x7a, 0x28, 0x6b, 0x4f, 0x9d, 0xc6, 0xf3, 0x5b, 0xcf, 0xbb, 0x75, 0xc8, 0xa7, 0x0c, 0xba,
+	0x5e, 0xdc, 0x7c, 0x3a, 0x5d, 0xf8, 0x56, 0x68, 0x7b, 0x2e, 0x67, 0x6a, 0xfc, 0x78, 0x9d, 0x1e,
+	0xda, 0x33, 0x12, 0x84, 0xd6, 0x6c, 0xce, 0x19, 0xd4, 0xef, 0x32, 0xb0, 0xd3, 0x73, 0xed, 0xd0,
+	0xb6, 0x1c, 0xfb, 0x77, 0x04, 0x93, 0x6f, 0x16, 0x24, 0x08, 0xd1, 0x33, 0x28, 0x5b, 0xb7, 0xc4,
+	0x0d, 0x87, 0x9e, 0x1f, 0xd6, 0xd3, 0xc7, 0xe9, 0x93, 0x3c, 0x5e, 0x01, 0x48, 0x85, 0xad, 0xc0,
+	0x5b, 0xf8, 0x13, 0x72, 0x66, 0xbb, 0x6d, 0xdb, 0xaf, 0x67, 0x8e, 0xd3, 0x27, 0x65, 0x9c, 0xc0,
+	0x28, 0x4f, 0x68, 0xf9, 0xb7, 0x24, 0x14, 0x3c, 0x59, 0xce, 0x13, 0xc7, 0xd0, 0x73, 0x00, 0x2e,
+	0xc3, 0x7e, 0x93, 0x63, 0xbf, 0x89, 0x21, 0xe8, 0x18, 0x2a, 0x8b, 0x80, 0x5c, 0xd8, 0xee, 0xfd,
+	0xa5, 0x37, 0x25, 0xf5, 0xfc, 0x71, 0xfa, 0xa4, 0x84, 0xe3, 0x10, 0xda, 0x83, 0xfc, 0xdc, 0xf3,
+	0xc3, 0xa0, 0x5e, 0x38, 0xce, 0x9e, 0x54, 0x31, 0x5f, 0xa0, 0x26, 0xa0, 0x99, 0xf5, 0xed, 0x68,
+	0x7e, 0xeb, 0x5b, 0x53, 0x12, 0x0c, 0x89, 0xdf, 0xf5, 0x82, 0xb0, 0x5e, 0x64, 0xfa, 0x37, 0x50,
+	0xe8, 0x7f, 0x62, 0x68, 0xbd, 0xc4, 0x18, 0xe3, 0x90, 0x7a, 0x0c, 0xcf, 0x57, 0x87, 0xd4, 0xf2,
+	0x89, 0x15, 0x92, 0x96, 0xb3, 0x08, 0x42, 0xe2, 0x8b, 0x13, 0x53, 0xbf, 0x86, 0x9a, 0xfe, 0x2d,
+	0x99, 0x2c, 0xc2, 0xe8, 0x0c, 0xdf, 0xc2, 0xa1, 0x4f, 0x42, 0x7f, 0x79, 0x6e, 0xd9, 0x0e, 0x99,
+	0x1a, 0xe4, 0x76, 0x46, 0xdc, 0x30, 0x18, 0xb8, 0xce, 0x92, 0x9d, 0x68, 0x09, 0x3f, 0x45, 0x56,
+	0x77, 0x60, 0xfb, 0xdc, 0x76, 0xe3, 0x17, 0xa2, 0x6e, 0x43, 0x15, 0x93, 0x07, 0xe2, 0x87, 0x12,
+	0xf8, 0x0d, 0x54, 0x86, 0x8e, 0xe5, 0xca, 0x9f, 0x21, 0xc8, 0x05, 0x21, 0x99, 0x33, 0xcd, 0x65,
+	0xcc, 0xbe, 0xbf, 0xcf, 0x80, 0xcc, 0xf7, 0x1b, 0xf0, 0x25, 0x94, 0xb9, 0xf2, 0xb9, 0xb3, 0x44,
+	0xaf, 0xa1, 0x68, 0x4d, 0xa8, 0x4b, 0x05, 0xf5, 0xf4, 0x71, 0xf6, 0xa4, 0x72, 0x8a, 0x9a, 0xf6,
+	0xd4, 0x69, 0x52, 0x06, 0x97, 0x4c, 0x35, 0x46, 0xc2, 0x92, 0x45, 0xfd, 0x2e, 0x0d, 0xd5, 0x04,
+	0x09, 0xbd, 0x80, 0x62, 0xb0, 0xb8, 0x8e, 0xac, 0xab, 0x9d, 0x6e, 0x31, 0x79, 0x83, 0x63, 0x58,
+	0x12, 0xe9, 0x16, 0xee, 0xe8, 0x3d, 0x71, 0x6f, 0xca, 0xdd, 0x89, 0x9b, 0x99, 0x92, 0x60, 0xe2,
+	0xdb, 0x73, 0xaa, 0x4a, 0x38, 0x51, 0x1c, 0x42, 0x75, 0x28, 0x4e, 0xbc, 0xd9, 0xcc, 0x72, 0xa7,
+	0xcc, 0x81, 0xca, 0x58, 0x2e, 0x51, 0x03, 0x4a, 0x13, 0xcf, 0x0d, 0xe9, 0xa6, 0x98, 0xeb, 0x94,
+	0x71, 0xb4, 0x56, 0x2f, 0x60, 0xab, 0x4b, 0x1c, 0xc7, 0x93, 0xc7, 0x57, 0x87, 0xe2, 0x03, 0xf1,
+	0x03, 0xfa, 0x0f, 0x7e, 0x82, 0x72, 0x49, 0xfd, 0x78, 0x62, 0xcd, 0xad, 0x6b, 0xdb, 0xb1, 0x43,
+	0x9b, 0x04, 0xf5, 0xcc, 0x71, 0x96, 0xfa, 0x71, 0x1c, 0x53, 0xbf, 0x06, 0x10, 0xda, 0xe8, 0x79,
+	0xfd, 0x6f, 0xba, 0x30, 0xec, 0x61, 0x1a, 0xa0, 0x7e, 0xa8, 0xd1, 0x78, 0x0b, 0xa4, 0x85, 0xef,
+	0xa0, 0xee, 0x93, 0x29, 0x99, 0x3b, 0xde, 0xf2, 0xd2, 0x0e, 0x66, 0x56, 0x38, 0xb9, 0x23, 0x53,
+	0xce, 0x22, 0xdc, 0xe9, 0x49, 0xba, 0xfa, 0x39, 0xa0, 0x35, 0x9d, 0xd4, 0xce, 0xe7, 0x00, 0x2c,
+	0xa4, 0x69, 0x08, 0xf0, 0xab, 0x2d, 0xe3, 0x18, 0xa2, 0xee, 0xc3, 0xae, 0x11, 0x7a, 0x73, 0x83,
+	0xf8, 0x0f, 0xf6, 0x84, 0x48, 0x43, 0xd4, 0x5d, 0xd8, 0x49, 0xc2, 0x73, 0x67, 0xa9, 0x5e, 0x41,
+	0x55, 0xdc, 0xa7, 0x11, 0x5a, 0xe1, 0x22, 0x40, 0xc7, 0x90, 0x7b, 0xf2, 0xc6, 0x19, 0x05, 0x7d,
+	0x0c, 0x85, 0x80, 0xf1, 0xb2, 0x0b, 0xaf, 0x9d, 0x56, 0x38, 0x0f, 0x83, 0xb0, 0x20, 0xa9, 0x7f,
+	0x4f, 0xc3, 0x36, 0x5e, 0xb8, 0xad, 0x3b, 0x32, 0xb9, 0x97, 0x27, 0xf1, 0x06, 0xf2, 0x13, 0xba,
+	0x16, 0xba, 0x1b, 0x4c, 0x6e, 0x8d, 0xa9, 0xc9, 0x17, 0x9c, 0x51, 0xfd, 0x43, 0x1a, 0xf2, 0x0c,
+	0x40, 0x3b, 0x50, 0x1d, 0xf5, 0xdf, 0xf7, 0x07, 0xbf, 0xee, 0x8f, 0x5b, 0x5d, 0xbd, 0xf5, 0x5e,
+	0x49, 0x21, 0x04, 0x35, 0x43, 0xef, 0x5c, 0xea, 0x7d, 0x73, 0x6c, 0x98, 0x9a, 0x39, 0x32, 0x94,
+	0x34, 0xaa, 0x01, 0x0c, 0x07, 0xd8, 0x34, 0xc6, 0xe7, 0x58, 0xd7, 0x95, 0x0c, 0xda, 0x03, 0xc5,
+	0x30, 0xba, 0x63, 0xac, 0x6b, 0xad, 0xae, 0x76, 0xd6, 0xbb, 0xe8, 0x99, 0x1f, 0x94, 0x2c, 0x3a,
+	0x82, 0xfd, 0x2b, 0x1d, 0x1b, 0xbd, 0x41, 0x7f, 0xdc, 0x1a, 0x5c, 0x0e, 0x35, 0xb3, 0x27, 0x48,
+	0x39, 0xa6, 0x74, 0x30, 0xc2, 0x2d, 0x7d, 0xdc, 0xd2, 0x4c, 0xed, 0x62, 0xd0, 0x51, 0xf2, 0xea,
+	0x25, 0x54, 0x57, 0x56, 0xd2, 0x0b, 0x68, 0x40, 0xe9, 0xc6, 0xb2, 0x9d, 0x85, 0x4f, 0xe4, 0xf1,
+	0x47, 0x6b, 0xea, 0xf8, 0x3e, 0x99, 0x91, 0xa9, 0xcd, 0x92, 0xb9, 0x88, 0x89, 0x38, 0xa4, 0xf6,
+	0x60, 0x9f, 0xe9, 0x6a, 0xdb, 0xc1, 0xbd, 0x31, 0xb7, 0x26, 0x51, 0xde, 0xd9, 0x83, 0x3c, 0x2b,
+	0x01, 0xec, 0x7c, 0xd2, 0x98, 0x2f, 0xe8, 0xcf, 0x48, 0x10, 0xda, 0x33, 0x2b, 0x24, 0x22, 0xfa,
+	0xa3, 0xb5, 0xfa, 0x8f, 0x0c, 0xec, 0xae, 0xeb, 0xa2, 0x06, 0x7e, 0x05, 0x85, 0x1b, 0x96, 0x1c,
+	0x44, 0xe0, 0x7f, 0xc2, 0x8e, 0x7a, 0x03, 0x67, 0x93, 0xe7, 0x10, 0xdd, 0x0d, 0xfd, 0x25, 0x16,
+	0x32, 0x8d, 0xbf, 0xa4, 0xa1, 0x4c, 0xd9, 0x46, 0x81, 0x75, 0x4b, 0x58, 0x45, 0x79, 0xb0, 0x6c,
+	0xc7, 0xba, 0x76, 0x08, 0xb3, 0x2c, 0x87, 0x57, 0x00, 0xb5, 0xce, 0x27, 0xdf, 0x2c, 0x6c, 0x9f,
+	0x4c, 0x99, 0x75, 0x39, 0x1c, 0xad, 0xd1, 0x09, 0x6c, 0xdb, 0xae, 0x37, 0x25, 0x81, 0x16, 0xc9,
+	0x67, 0x19, 0xcb, 0x3a, 0x8c, 0x5e, 0x40, 0x8d, 0x43, 0x58, 0xea, 0xca, 0x31, 0xc6, 0x35, 0xb4,
+	0xf1, 0x5b, 0xa8, 0xc4, 0x0c, 0x46, 0x0a, 0x64, 0xef, 0xc9, 0x52, 0x04, 0x2b, 0xfd, 0x44, 0x6f,
+	0x21, 0xff, 0x60, 0x39, 0x0b, 0x7e, 0x52, 0x95, 0x53, 0xf5, 0xc9, 0x7d, 0x47, 0xfb, 0xc3, 0x5c,
+	0xe0, 0x5d, 0xe6, 0x6d, 0x5a, 0xfd, 0x08, 0x8e, 0x86, 0x3e, 0x99, 0x5b, 0x3e, 0xa1, 0x35, 0x63,
+	0xad, 0x4e, 0x1c, 0xc1, 0xe1, 0x26, 0x22, 0x0d, 0xa2, 0xbf, 0x32, 0x37, 0x5d, 0xb8, 0xf7, 0xe8,
+	0x00, 0x0a, 0xd7, 0x8b, 0x9b, 0x1b, 0xe2, 0x33, 0xa3, 0xb6, 0xb0, 0x58, 0xa1, 0x8f, 0x21, 0x17,
+	0x2e, 0xe7, 0x44, 0x44, 0xcc, 0xb6, 0x30, 0x6b, 0xe1, 0xde, 0x37, 0xcd, 0xe5, 0x9c, 0x60, 0x46,
+	0x8c, 0xf2, 0x68, 0x36, 0x96, 0x47, 0x59, 0x96, 0x64, 0xb9, 0x4f, 0x94, 0x59, 0xb9, 0x54, 0x5f,
+	0x41, 0x8e, 0xca, 0xa2, 0x0a, 0x14, 0x45, 0x64, 0x28, 0x29, 0x04, 0x50, 0x30, 0xcc, 0xf6, 0x60,
+	0x64, 0x2a, 0x69, 0xf1, 0xad, 0x63, 0xac, 0x64, 0xd4, 0x3f, 0x65, 0xa0, 0x78, 0x49, 0x02, 0x76,
+	0xa1, 0x2a, 0x0d, 0xc3, 0x85, 0xcb, 0xc3, 0xb0, 0x72, 0x0a, 0x2b, 0x63, 0xba, 0x29, 0xcc, 0x49,
+	0xe8, 0x75, 0x22, 0xc6, 0x65, 0xe5, 0x48, 0x64, 0x8a, 0x6e, 0x4a, 0x06, 0x3b, 0x7a, 0x45, 0x9d,
+	0x20, 0x98, 0x7b, 0x6e, 0xc0, 0x6f, 0xb8, 0x72, 0x5a, 0xe5, 0xb1, 0x2d, 0xc0, 0x6e, 0x0a, 0x47,
+	0x0c, 0xe8, 0x1d, 0x54, 0x03, 0x5e, 0xb2, 0x30, 0x09, 0x16, 0x0e, 0xdf, 0x57, 0xf4, 0x87, 0x38,
+	0xa5, 0x9b, 0xc2, 0x49, 0x56, 0xf4, 0x0b, 0xa8, 0x25, 0x00, 0x5e, 0x1f, 0x2a, 0xa7, 0xbb, 0x8f,
+	0x85, 0xa9, 0x7d, 0x6b, 0xcc, 0x67, 0xb0, 0x2a, 0x2c, 0xea, 0x3f, 0x33, 0x50, 0x4d, 0x08, 0x44,
+	0xc7, 0x9f, 0xde, 0x7c, 0xfc, 0x99, 0xc4, 0xf1, 0x53, 0xee, 0xe9, 0xb5, 0x3d, 0x65, 0xfb, 0xcd,
+	0x63, 0xf6, 0x8d, 0x9a, 0x90, 0x9f, 0xdf, 0x59, 0x01, 0x61, 0x5b, 0xaa, 0x9d, 0xd6, 0x1f, 0x5b,
+	0xd5, 0x1c, 0x52, 0x3a, 0xe6, 0x6c, 0x34, 0x91, 0xcb, 0xae, 0xef, 0x92, 0x6f, 0x25, 0x8b, 0x63,
+	0x08, 0x0b, 0xfd, 0x6f, 0xed, 0xb0, 0x45, 0x7b, 0xa8, 0x02, 0xfb, 0x4f, 0xb4, 0xa6, 0x9e, 0xe6,
+	0x78, 0xb7, 0xb4, 0x41, 0x2b, 0x32, 0x7b, 0xc5, 0x8a, 0x26, 0x11, 0xe2, 0xfb, 0x9e, 0xcf, 0x9a,
+	0xa1, 0x32, 0xe6, 0x0b, 0xba, 0x8f, 0xe0, 0xde, 0x9e, 0xcf, 0xc9, 0xb4, 0x5e, 0x66, 0x39, 0x44,
+	0x2e, 0x55, 0x0b, 0xf2, 0xcc, 0xa6, 0x78, 0x86, 0x1d, 0x76, 0x35, 0x43, 0xe7, 0x19, 0x16, 0xeb,
+	0x86, 0x39, 0xc0, 0xfa, 0xf8, 0x4c, 0x6b, 0xbd, 0x1f, 0x0d, 0x95, 0x34, 0x3a, 0x84, 0x5d, 0x89,
+	0x99, 0xda, 0xd9, 0x85, 0x6e, 0x0c, 0xb5, 0x96, 0x6e, 0x28, 0x19, 0x96, 0x7a, 0x3b, 0xe3, 0xd1,
+	0xb0, 0x83, 0xb5, 0xb6, 0xae, 0x64, 0x51, 0x09, 0x72, 0xed, 0x41, 0x5f, 0x57, 0x72, 0xea, 0x2f,
+	0xa1, 0x96, 0xbc, 0x1a, 0xda, 0x99, 0xf8, 0xe2, 0x02, 0xe3, 0x9d, 0x49, 0x82, 0x0b, 0x4b, 0x16,
+	0x75, 0x0e, 0x25, 0xe9, 0x49, 0xe8, 0x15, 0xe4, 0xa6, 0x56, 0x68, 0x09, 0xb1, 0xc3, 0x84, 0x9b,
+	0x35, 0xdb, 0x56, 0x68, 0xf1, 0x54, 0xc6, 0x98, 0x1a, 0x3f, 0x87, 0x72, 0x04, 0x6d, 0x48, 0x16,
+	0x7b, 0xf1, 0x64, 0x51, 0x8e, 0x27, 0x82, 0xaf, 0x40, 0x31, 0x48, 0xd8, 0xf2, 0xdc, 0x1b, 0xfb,
+	0x36, 0xd6, 0xa8, 0xb9, 0xd6, 0x8c, 0x48, 0xf7, 0xa0, 0xdf, 0x9b, 0x35, 0xa8, 0x0a, 0xd4, 0x62,
+	0xd2, 0x34, 0x41, 0xbc, 0x00, 0xa5, 0xf3, 0x03, 0xf4, 0xa9, 0x2f, 0xa0, 0xd6, 0x49, 0x48, 0xae,
+	0xfe, 0x90, 0x8e, 0xff, 0x01, 0x31, 0x7d, 0xa2, 0xe4, 0x8a, 0xfc, 0xf4, 0x7b, 0xa8, 0xc5, 0x30,
+	0x2a, 0xdb, 0x84, 0x52, 0x40, 0x36, 0x34, 0x80, 0x06, 0x07, 0x05, 0x6b, 0xc4, 0x83, 0xde, 0x41,
+	0x85, 0x77, 0x11, 0xc4, 0x72, 0xc2, 0x3b, 0x11, 0xf9, 0xdc, 0x89, 0x3b, 0x24, 0xd4, 0x56, 0x24,
+	0xa6, 0x1e, 0xc7, 0x99, 0xd5, 0x43, 0xd8, 0x5f, 0xe7, 0xe1, 0x66, 0x8d, 0x61, 0x77, 0x83, 0x30,
+	0x75, 0xfd, 0xbb, 0xc5, 0xf5, 0x55, 0xa2, 0xdd, 0x8a, 0x21, 0xe8, 0x04, 0x0a, 0x16, 0xef, 0x91,
+	0x32, 0xcc, 0x72, 0x85, 0x99, 0x11, 0x57, 0x23, 0xe8, 0xea, 0x1f, 0x33, 0x50, 0x89, 0xe1, 0x34,
+	0x68, 0x68, 0xe8, 0xc6, 0xce, 0x36, 0x5a, 0xa3, 0xd7, 0x90, 0xa7, 0x29, 0x4b, 0xe6, 0xe1, 0x83,
+	0x75, 0xa5, 0xac, 0x8b, 0x21, 0x98, 0x33, 0xa1, 0x2f, 0xa0, 0xe4, 0x58, 0x41, 0x68, 0x10, 0xe2,
+	0x8a, 0xb4, 0xd6, 0x68, 0xf2, 0xa9, 0xac, 0x29, 0xa7, 0xb2, 0xa6, 0x29, 0xa7, 0x32, 0x1c, 0xf1,
+	0xc6, 0xfb, 0xc8, 0x5c, 0xb2, 0x8f, 0x8c, 0x82, 0x33, 0x1f, 0x0b, 0x4e, 0xb5, 0x07, 0x79, 0xf6,
+	0x5f, 0x1a, 0x82, 0xfd, 0x81, 0x39, 0x6e, 0x0d, 0xfa, 0x7d, 0xbd, 0x65, 0xea, 0x6d, 0x25, 0x45,
+	0xb3, 0xbb, 0xa1, 0xe3, 0xab, 0x5e, 0xbf, 0xa3, 0xa4, 0xd1, 0x36, 0x54, 0x28, 0x5d, 0x02, 0x19,
+	0x0a, 0x8c, 0xfa, 0xa2, 0xb9, 0xb9, 0xd0, 0x95, 0xac, 0x6a, 0xd0, 0xa4, 0x16, 0xbb, 0xdd, 0x8d,
+	0x5e, 0x4b, 0xfd, 0x82, 0x67, 0x72, 0x79, 0xba, 0x89, 0xf4, 0x8e, 0xc9, 0xc4, 0xf3, 0xa7, 0x38,
+	0xe2, 0xa1, 0xf1, 0x4b, 0xb1, 0x87, 0xa8, 0x16, 0xa2, 0xd7, 0x00, 0x37, 0x9e, 0x4f, 0x6b, 0x69,
+	0xe8, 0x2f, 0x37, 0xb6, 0x8a, 0x31, 0xba, 0xaa, 0xc1, 0x56, 0x24, 0x4f, 0xef, 0xfe, 0xa7, 0xb1,
+	0xff, 0x73, 0xbf, 0xdc, 0x17, 0x71, 0xcc, 0x98, 0xc8, 0x54, 0x2a, 0x59, 0x99, 0xf0, 0xe7, 0x34,
+	0x28, 0xeb, 0x64, 0x96, 0xd4, 0xf8, 0x66, 0x65, 0xbf, 0x2e, 0x96, 0x51, 0x13, 0x9b, 0x79, 0xb2,
+	0x89, 0xfd, 0x04, 0xaa, 0x3e, 0x09, 0x48, 0x68, 0x7a, 0xbc, 0xa1, 0x60, 0x17, 0x5c, 0xc2, 0x49,
+	0x90, 0xcf, 0x97, 0xee, 0xc2, 0x72, 0x0c, 0x66, 0x6c, 0x8e, 0xf5, 0x7a, 0x71, 0x88, 0x8e, 0x77,
+	0x2d, 0xcb, 0x9d, 0x10, 0x47, 0xfa, 0xfb, 0x2b, 0xa8, 0x48, 0x80, 0xee, 0xf5, 0x19, 0x94, 0x27,
+	0x6c, 0xc9, 0x9b, 0x31, 0xfa, 0x8f, 0x15, 0xa0, 0xfe, 0x2d, 0x13, 0xb5, 0xdf, 0xfc, 0xd4, 0xff,
+	0x4f, 0xed, 0x37, 0x7a, 0x0b, 0x65, 0x36, 0x36, 0x50, 0xf7, 0xfc, 0x01, 0xbe, 0xbb, 0x62, 0x46,
+	0x9f, 0x43, 0x91, 0xb8, 0x53, 0x26, 0x97, 0xfb, 0xaf, 0x72, 0x92, 0x15, 0xfd, 0x0c, 0x4a, 0xb2,
+	0x6e, 0x89, 0x92, 0x7c, 0xf4, 0x48, 0xac, 0x2d, 0x18, 0x70, 0xc4, 0x4a, 0x63, 0xd5, 0x0a, 0x43,
+	0x32, 0x9b, 0x87, 0x81, 0x2c, 0x70, 0x72, 0x4d, 0x4f, 0x8e, 0x46, 0x94, 0xce, 0xe2, 0x85, 0xd7,
+	0xb8, 0x15, 0xf0, 0xf2, 0xdf, 0x79, 0x28, 0x4a, 0x3f, 0xd8, 0x85, 0x6d, 0x59, 0xb9, 0x8c, 0xd1,
+	0x99, 0x61, 0xea, 0x43, 0x25, 0x85, 0xea, 0xb0, 0xd7, 0xc2, 0xba, 0x66, 0xf6, 0xfa, 0x9d, 0x71,
+	0xbb, 0x87, 0xf5, 0x96, 0x39, 0xc0, 0x3d, 0x9d, 0xce, 0x08, 0xfb, 0xb0, 0xd3, 0xd1, 0xfb, 0x3a,
+	0xe6, 0xb4, 0xd6, 0xa0, 0x7f, 0xde, 0xa3, 0xb1, 0x54, 0x85, 0xb2, 0x61, 0x6a, 0xd8, 0x1c, 0x77,
+	0x47, 0x67, 0x4a, 0x16, 0x35, 0xe0, 0x00, 0xeb, 0x26, 0xee, 0xe9, 0x57, 0xfa, 0x58, 0x4e, 0x04,
+	0x9c, 0x35, 0x87, 0x14, 0xd8, 0xe2, 0xac, 0x5a, 0x47, 0xef, 0x9b, 0x86, 0x92, 0xa7, 0x73, 0x06,
+	0x1b, 0x4b, 0xc6, 0xed, 0x9e, 0xf1, 0x7e, 0xcc, 0x6a, 0xa2, 0x52, 0x88, 0x6c, 0xa0, 0xa5, 0x12,
+	0x77, 0x74, 0x53, 0x6a, 0x28, 0xd2, 0x2a, 0xda, 0xeb, 0xf7, 0xcc, 0x08, 0xbf, 0x18, 0x19, 0xa6,
+	0x8e, 0x95, 0x12, 0xfa, 0x08, 0x0e, 0x8d, 0xee, 0xc8, 0x6c, 0xd3, 0xcd, 0xac, 0x11, 0xcb, 0x54,
+	0x1f, 0xaf, 0xc3, 0x92, 0x74, 0xa9, 0x31, 0x0a, 0xd0, 0xcc, 0xc1, 0xff, 0x2f, 0xeb, 0x6f, 0x25,
+	0xa1, 0x49, 0x6e, 0x40, 0x68, 0xda, 0xa2, 0x95, 0x5d, 0x70, 0x4a, 0x1d, 0x55, 0x9a, 0x4c, 0x5a,
+	0x83, 0xe1, 0x07, 0x09, 0xd4, 0xe8, 0x41, 0x49, 0xa6, 0x21, 0xee, 0x5d, 0x6a, 0xec, 0xfc, 0xb6,
+	0xa9, 0x15, 0x7c, 0xf7, 0x6b, 0xf6, 0x29, 0xe8, 0x35, 0x9c, 0x8c, 0x86, 0xed, 0xf8, 0x7e, 0xf9,
+	0x0c, 0x35, 0xd6, 0xfa, 0x6d, 0xc9, 0x26, 0xcf, 0x60, 0x87, 0x1a, 0x28, 0xb8, 0xdb, 0x9a, 0xa9,
+	0x25, 0x2e, 0x09, 0xa1, 0x67, 0x50, 0x5f, 0x53, 0x35, 0xe8, 0x9f, 0x8f, 0xcf, 0x7b, 0x17, 0xba,
+	0xa1, 0xec, 0xb2, 0x1b, 0x17, 0x96, 0x19, 0xa6, 0xd6, 0x6f, 0x9f, 0x7d, 0x50, 0xf6, 0xe2, 0xe0,
+	0x65, 0x0f, 0xe3, 0x01, 0x36, 0x94, 0x7d, 0xfa, 0x93, 0xb6, 0x7e, 0xa1, 0x9b, 0x72, 0x0b, 0x1f,
+	0xd8, 0xcf, 0xda, 0x3d, 0x6c, 0x28, 0x07, 0x74, 0x0e, 0x14, 0x44, 0xbe, 0x67, 0x49, 0x53, 0x0e,
+	0xe9, 0xff, 0x05, 0x29, 0x3e, 0x63, 0xea, 0x4c, 0xb0, 0x4e, 0xaf, 0xcf, 0x30, 0x07, 0x43, 0xea,
+	0x2a, 0x6c, 0x6f, 0xc2, 0x0f, 0x8e, 0xa8, 0xd7, 0x24, 0x35, 0x4a, 0x29, 0xa5, 0x41, 0x4d, 0xd1,
+	0x70, 0xab, 0xdb, 0xbb, 0xd2, 0xc7, 0xf4, 0x4c, 0xe2, 0xfb, 0xfd, 0x08, 0x1d, 0x00, 0xe2, 0x17,
+	0x28, 0xb6, 0xcb, 0xa6, 0x58, 0xe5, 0x19, 0xed, 0xaa, 0xf0, 0x48, 0xcc, 0xbc, 0x86, 0xf2, 0xa3,
+	0x97, 0x2d, 0x28, 0x44, 0x99, 0xbd, 0x16, 0x79, 0x3d, 0x1f, 0x7f, 0x59, 0xb5, 0xc0, 0xa3, 0x7e,
+	0x9f, 0x57, 0x8b, 0x2d, 0x28, 0xd1, 0xe9, 0x96, 0x5a, 0xa3, 0x64, 0xe8, 0x34, 0x70, 0xae, 0xf5,
+	0x2e, 0xf4, 0xb6, 0x92, 0x7d, 0xf9, 0x2b, 0xa8, 0xc8, 0x9e, 0xe9, 0x3d, 0x59, 0xd2, 0x8b, 0xe7,
+	0xaf, 0x7b, 0x63, 0xfa, 0x0a, 0xa7, 0xa4, 0xd0, 0x31, 0x3c, 0x13, 0xc0, 0xcc, 0xa2, 0x53, 0xce,
+	0x98, 0x76, 0x53, 0xe3, 0xa9, 0xed, 0x93, 0x49, 0xe8, 0xf9, 0x4b, 0x25, 0x7d, 0xfa, 0xaf, 0x22,
+	0x94, 0x5a, 0x8e, 0x6d, 0x7a, 0xdd, 0xc5, 0x35, 0xfa, 0x09, 0xe4, 0xd9, 0x2b, 0x0a, 0xda, 0x61,
+	0xa9, 0x28, 0xfe, 0x3e, 0xd3, 0xd8, 0x8e, 0x43, 0xb4, 0x15, 0x4a, 0xa1, 0x2e, 0xd4, 0x92, 0x13,
+	0x19, 0x6a, 0x6c, 0x1c, 0xd3, 0xb8, 0x82, 0xfa, 0x53, 0x23, 0x9c, 0x9a, 0x42, 0x5f, 0x00, 0xac,
+	0x1e, 0xf7, 0x10, 0xaf, 0xe6, 0x8f, 0x9e, 0x44, 0x1b, 0x3c, 0x89, 0x8a, 0xe9, 0x47, 0x4d, 0xbd,
+	0x49, 0xa3, 0x21, 0x1c, 0x3e, 0xf1, 0x28, 0x88, 0x3e, 0x5e, 0x53, 0xb2, 0xe9, 0xc9, 0x70, 0x83,
+	0xc6, 0x37, 0x50, 0x14, 0x8f, 0x88, 0x88, 0xcf, 0x22, 0xc9, 0x27, 0xc5, 0x0d, 0x12, 0xa7, 0x50,
+	0x92, 0x4f, 0x85, 0x68, 0x8f, 0x51, 0xd7, 0x5e, 0x0e, 0x37, 0xc8, 0x34, 0xa1, 0xc0, 0xdf, 0x12,
+	0x11, 0x12, 0x05, 0x33, 0xf6, 0xb0, 0xb8, 0x81, 0xff, 0x4b, 0x28, 0x47, 0x8d, 0x28, 0xda, 0x17,
+	0xbd, 0x5f, 0xb2, 0x0d, 0x6d, 0xec, 0xae, 0xc3, 0xfc, 0x68, 0xbf, 0x84, 0x72, 0x67, 0x4d, 0xb4,
+	0xb3, 0x59, 0xb4, 0xb3, 0x2e, 0xaa, 0xd3, 0x17, 0xcf, 0xd8, 0xa3, 0x15, 0x3a, 0x92, 0x5d, 0xfa,
+	0xa3, 0xc7, 0xb1, 0xc6, 0xe1, 0x26, 0x12, 0x57, 0x73, 0x06, 0x5b, 0xf1, 0xe7, 0x2a, 0x24, 0xa6,
+	0xa9, 0xc7, 0x0f, 0x5b, 0x8d, 0x83, 0x0d, 0x94, 0xf8, 0x2e, 0x44, 0xc0, 0x44, 0xbb, 0x48, 0xf4,
+	0xcd, 0x8d, 0xdd, 0x75, 0x98, 0x8b, 0x7e, 0x06, 0x45, 0xd1, 0x70, 0x88, 0x1b, 0x4d, 0xb6, 0x40,
+	0x8d, 0x9d, 0x24, 0xc8, 0x85, 0xde, 0x40, 0x81, 0x17, 0x7f, 0x71, 0x41, 0x89, 0xd6, 0xa0, 0xa1,
+	0x24, 0x30, 0x2e, 0xf1, 0x12, 0x72, 0xf4, 0xd1, 0x15, 0x29, 0xd1, 0xd3, 0xac, 0xe4, 0xae, 0xc5,
+	0x10, 0xe9, 0xee, 0x25, 0xf9, 0x0e, 0x25, 0x5c, 0x66, 0xed, 0xf1, 0xac, 0x81, 0xd6, 0xd0, 0x28,
+	0xe0, 0x92, 0x2d, 0xb8, 0x08, 0xb8, 0x8d, 0x0d, 0x7b, 0xe3, 0xc9, 0x86, 0x5f, 0x4d, 0x5d, 0x17,
+	0x58, 0x31, 0xff, 0xec, 0x3f, 0x03, 0x00, 0xe9, 0x3c, 0x25, 0x39, 0xd4, 0x18, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1251,6 +2625,7 @@ const _ = grpc.SupportPackageIsVersion4
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type CliToHubClient interface {
+	Hello(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloReply, error)
 	CheckDiskSpace(ctx context.Context, in *CheckDiskSpaceRequest, opts ...grpc.CallOption) (*CheckDiskSpaceReply, error)
 	Initialize(ctx context.Context, in *InitializeRequest, opts ...grpc.CallOption) (CliToHub_InitializeClient, error)
 	InitializeCreateCluster(ctx context.Context, in *InitializeCreateClusterRequest, opts ...grpc.CallOption) (CliToHub_InitializeCreateClusterClient, error)
@@ -1261,6 +2636,12 @@ type CliToHubClient interface {
 	GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigReply, error)
 	RestartAgents(ctx context.Context, in *RestartAgentsRequest, opts ...grpc.CallOption) (*RestartAgentsReply, error)
 	StopServices(ctx context.Context, in *StopServicesRequest, opts ...grpc.CallOption) (*StopServicesReply, error)
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusReply, error)
+	Recover(ctx context.Context, in *RecoverRequest, opts ...grpc.CallOption) (*RecoverReply, error)
+	Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelReply, error)
+	Plan(ctx context.Context, in *PlanRequest, opts ...grpc.CallOption) (*PlanReply, error)
+	RunCheck(ctx context.Context, in *RunCheckRequest, opts ...grpc.CallOption) (*RunCheckReply, error)
+	GetAgentHealth(ctx context.Context, in *GetAgentHealthRequest, opts ...grpc.CallOption) (*GetAgentHealthReply, error)
 }
 
 type cliToHubClient struct {
@@ -1271,6 +2652,15 @@ func NewCliToHubClient(cc *grpc.ClientConn) CliToHubClient {
 	return &cliToHubClient{cc}
 }
 
+func (c *cliToHubClient) Hello(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloReply, error) {
+	out := new(HelloReply)
+	err := c.cc.Invoke(ctx, "/idl.CliToHub/Hello", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cliToHubClient) CheckDiskSpace(ctx context.Context, in *CheckDiskSpaceRequest, opts ...grpc.CallOption) (*CheckDiskSpaceReply, error) {
 	out := new(CheckDiskSpaceReply)
 	err := c.cc.Invoke(ctx, "/idl.CliToHub/CheckDiskSpace", in, out, opts...)
@@ -1476,8 +2866,63 @@ func (c *cliToHubClient) StopServices(ctx context.Context, in *StopServicesReque
 	return out, nil
 }
 
+func (c *cliToHubClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusReply, error) {
+	out := new(GetStatusReply)
+	err := c.cc.Invoke(ctx, "/idl.CliToHub/GetStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cliToHubClient) Recover(ctx context.Context, in *RecoverRequest, opts ...grpc.CallOption) (*RecoverReply, error) {
+	out := new(RecoverReply)
+	err := c.cc.Invoke(ctx, "/idl.CliToHub/Recover", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cliToHubClient) Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelReply, error) {
+	out := new(CancelReply)
+	err := c.cc.Invoke(ctx, "/idl.CliToHub/Cancel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cliToHubClient) Plan(ctx context.Context, in *PlanRequest, opts ...grpc.CallOption) (*PlanReply, error) {
+	out := new(PlanReply)
+	err := c.cc.Invoke(ctx, "/idl.CliToHub/Plan", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cliToHubClient) RunCheck(ctx context.Context, in *RunCheckRequest, opts ...grpc.CallOption) (*RunCheckReply, error) {
+	out := new(RunCheckReply)
+	err := c.cc.Invoke(ctx, "/idl.CliToHub/RunCheck", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cliToHubClient) GetAgentHealth(ctx context.Context, in *GetAgentHealthRequest, opts ...grpc.CallOption) (*GetAgentHealthReply, error) {
+	out := new(GetAgentHealthReply)
+	err := c.cc.Invoke(ctx, "/idl.CliToHub/GetAgentHealth", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CliToHubServer is the server API for CliToHub service.
 type CliToHubServer interface {
+	Hello(context.Context, *HelloRequest) (*HelloReply, error)
 	CheckDiskSpace(context.Context, *CheckDiskSpaceRequest) (*CheckDiskSpaceReply, error)
 	Initialize(*InitializeRequest, CliToHub_InitializeServer) error
 	InitializeCreateCluster(*InitializeCreateClusterRequest, CliToHub_InitializeCreateClusterServer) error
@@ -1488,12 +2933,21 @@ type CliToHubServer interface {
 	GetConfig(context.Context, *GetConfigRequest) (*GetConfigReply, error)
 	RestartAgents(context.Context, *RestartAgentsRequest) (*RestartAgentsReply, error)
 	StopServices(context.Context, *StopServicesRequest) (*StopServicesReply, error)
+	GetStatus(context.Context, *GetStatusRequest) (*GetStatusReply, error)
+	Recover(context.Context, *RecoverRequest) (*RecoverReply, error)
+	Cancel(context.Context, *CancelRequest) (*CancelReply, error)
+	Plan(context.Context, *PlanRequest) (*PlanReply, error)
+	RunCheck(context.Context, *RunCheckRequest) (*RunCheckReply, error)
+	GetAgentHealth(context.Context, *GetAgentHealthRequest) (*GetAgentHealthReply, error)
 }
 
 // UnimplementedCliToHubServer can be embedded to have forward compatible implementations.
 type UnimplementedCliToHubServer struct {
 }
 
+func (*UnimplementedCliToHubServer) Hello(ctx context.Context, req *HelloRequest) (*HelloReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hello not implemented")
+}
 func (*UnimplementedCliToHubServer) CheckDiskSpace(ctx context.Context, req *CheckDiskSpaceRequest) (*CheckDiskSpaceReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CheckDiskSpace not implemented")
 }
@@ -1524,11 +2978,47 @@ func (*UnimplementedCliToHubServer) RestartAgents(ctx context.Context, req *Rest
 func (*UnimplementedCliToHubServer) StopServices(ctx context.Context, req *StopServicesRequest) (*StopServicesReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StopServices not implemented")
 }
+func (*UnimplementedCliToHubServer) GetStatus(ctx context.Context, req *GetStatusRequest) (*GetStatusReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (*UnimplementedCliToHubServer) Recover(ctx context.Context, req *RecoverRequest) (*RecoverReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Recover not implemented")
+}
+func (*UnimplementedCliToHubServer) Cancel(ctx context.Context, req *CancelRequest) (*CancelReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Cancel not implemented")
+}
+func (*UnimplementedCliToHubServer) Plan(ctx context.Context, req *PlanRequest) (*PlanReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Plan not implemented")
+}
+func (*UnimplementedCliToHubServer) RunCheck(ctx context.Context, req *RunCheckRequest) (*RunCheckReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunCheck not implemented")
+}
+func (*UnimplementedCliToHubServer) GetAgentHealth(ctx context.Context, req *GetAgentHealthRequest) (*GetAgentHealthReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAgentHealth not implemented")
+}
 
 func RegisterCliToHubServer(s *grpc.Server, srv CliToHubServer) {
 	s.RegisterService(&_CliToHub_serviceDesc, srv)
 }
 
+func _CliToHub_Hello_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HelloRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CliToHubServer).Hello(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.CliToHub/Hello",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CliToHubServer).Hello(ctx, req.(*HelloRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CliToHub_CheckDiskSpace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CheckDiskSpaceRequest)
 	if err := dec(in); err != nil {
@@ -1724,10 +3214,122 @@ func _CliToHub_StopServices_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CliToHub_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CliToHubServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.CliToHub/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CliToHubServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CliToHub_Recover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecoverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CliToHubServer).Recover(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.CliToHub/Recover",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CliToHubServer).Recover(ctx, req.(*RecoverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CliToHub_Cancel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CliToHubServer).Cancel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.CliToHub/Cancel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CliToHubServer).Cancel(ctx, req.(*CancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CliToHub_Plan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CliToHubServer).Plan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.CliToHub/Plan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CliToHubServer).Plan(ctx, req.(*PlanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CliToHub_RunCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CliToHubServer).RunCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.CliToHub/RunCheck",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CliToHubServer).RunCheck(ctx, req.(*RunCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CliToHub_GetAgentHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAgentHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CliToHubServer).GetAgentHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.CliToHub/GetAgentHealth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CliToHubServer).GetAgentHealth(ctx, req.(*GetAgentHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _CliToHub_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "idl.CliToHub",
 	HandlerType: (*CliToHubServer)(nil),
 	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Hello",
+			Handler:    _CliToHub_Hello_Handler,
+		},
 		{
 			MethodName: "CheckDiskSpace",
 			Handler:    _CliToHub_CheckDiskSpace_Handler,
@@ -1748,6 +3350,30 @@ var _CliToHub_serviceDesc = grpc.ServiceDesc{
 			MethodName: "StopServices",
 			Handler:    _CliToHub_StopServices_Handler,
 		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _CliToHub_GetStatus_Handler,
+		},
+		{
+			MethodName: "Recover",
+			Handler:    _CliToHub_Recover_Handler,
+		},
+		{
+			MethodName: "Cancel",
+			Handler:    _CliToHub_Cancel_Handler,
+		},
+		{
+			MethodName: "Plan",
+			Handler:    _CliToHub_Plan_Handler,
+		},
+		{
+			MethodName: "RunCheck",
+			Handler:    _CliToHub_RunCheck_Handler,
+		},
+		{
+			MethodName: "GetAgentHealth",
+			Handler:    _CliToHub_GetAgentHealth_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
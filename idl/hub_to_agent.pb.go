@@ -24,6 +24,65 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type FileEntry_Type int32
+
+const (
+	FileEntry_REGULAR   FileEntry_Type = 0
+	FileEntry_DIRECTORY FileEntry_Type = 1
+	FileEntry_SYMLINK   FileEntry_Type = 2
+	FileEntry_HARDLINK  FileEntry_Type = 3
+)
+
+var FileEntry_Type_name = map[int32]string{
+	0: "REGULAR",
+	1: "DIRECTORY",
+	2: "SYMLINK",
+	3: "HARDLINK",
+}
+
+var FileEntry_Type_value = map[string]int32{
+	"REGULAR":   0,
+	"DIRECTORY": 1,
+	"SYMLINK":   2,
+	"HARDLINK":  3,
+}
+
+func (x FileEntry_Type) String() string {
+	return proto.EnumName(FileEntry_Type_name, int32(x))
+}
+
+func (FileEntry_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{20, 0}
+}
+
+type Mismatch_Reason int32
+
+const (
+	Mismatch_MISSING    Mismatch_Reason = 0
+	Mismatch_CHANGED    Mismatch_Reason = 1
+	Mismatch_EXTRANEOUS Mismatch_Reason = 2
+)
+
+var Mismatch_Reason_name = map[int32]string{
+	0: "MISSING",
+	1: "CHANGED",
+	2: "EXTRANEOUS",
+}
+
+var Mismatch_Reason_value = map[string]int32{
+	"MISSING":    0,
+	"CHANGED":    1,
+	"EXTRANEOUS": 2,
+}
+
+func (x Mismatch_Reason) String() string {
+	return proto.EnumName(Mismatch_Reason_name, int32(x))
+}
+
+func (Mismatch_Reason) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{27, 0}
+}
+
 type TablespaceInfo struct {
 	Name                 string   `protobuf:"bytes,3,opt,name=Name,proto3" json:"Name,omitempty"`
 	Location             string   `protobuf:"bytes,4,opt,name=Location,proto3" json:"Location,omitempty"`
@@ -88,6 +147,7 @@ type UpgradePrimariesRequest struct {
 	UseLinkMode                bool           `protobuf:"varint,6,opt,name=UseLinkMode,proto3" json:"UseLinkMode,omitempty"`
 	MasterBackupDir            string         `protobuf:"bytes,7,opt,name=MasterBackupDir,proto3" json:"MasterBackupDir,omitempty"`
 	TablespacesMappingFilePath string         `protobuf:"bytes,8,opt,name=TablespacesMappingFilePath,proto3" json:"TablespacesMappingFilePath,omitempty"`
+	MaxConcurrency             int32          `protobuf:"varint,9,opt,name=MaxConcurrency,proto3" json:"MaxConcurrency,omitempty"`
 	XXX_NoUnkeyedLiteral       struct{}       `json:"-"`
 	XXX_unrecognized           []byte         `json:"-"`
 	XXX_sizecache              int32          `json:"-"`
@@ -174,6 +234,13 @@ func (m *UpgradePrimariesRequest) GetTablespacesMappingFilePath() string {
 	return ""
 }
 
+func (m *UpgradePrimariesRequest) GetMaxConcurrency() int32 {
+	if m != nil {
+		return m.MaxConcurrency
+	}
+	return 0
+}
+
 type DataDirPair struct {
 	SourceDataDir        string                    `protobuf:"bytes,1,opt,name=SourceDataDir,proto3" json:"SourceDataDir,omitempty"`
 	TargetDataDir        string                    `protobuf:"bytes,2,opt,name=TargetDataDir,proto3" json:"TargetDataDir,omitempty"`
@@ -261,37 +328,6 @@ func (m *DataDirPair) GetTablespaces() map[int32]*TablespaceInfo {
 	return nil
 }
 
-type UpgradePrimariesReply struct {
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
-}
-
-func (m *UpgradePrimariesReply) Reset()         { *m = UpgradePrimariesReply{} }
-func (m *UpgradePrimariesReply) String() string { return proto.CompactTextString(m) }
-func (*UpgradePrimariesReply) ProtoMessage()    {}
-func (*UpgradePrimariesReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{3}
-}
-
-func (m *UpgradePrimariesReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_UpgradePrimariesReply.Unmarshal(m, b)
-}
-func (m *UpgradePrimariesReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_UpgradePrimariesReply.Marshal(b, m, deterministic)
-}
-func (m *UpgradePrimariesReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_UpgradePrimariesReply.Merge(m, src)
-}
-func (m *UpgradePrimariesReply) XXX_Size() int {
-	return xxx_messageInfo_UpgradePrimariesReply.Size(m)
-}
-func (m *UpgradePrimariesReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_UpgradePrimariesReply.DiscardUnknown(m)
-}
-
-var xxx_messageInfo_UpgradePrimariesReply proto.InternalMessageInfo
-
 type DeleteDataDirectoriesRequest struct {
 	Datadirs             []string `protobuf:"bytes,1,rep,name=datadirs,proto3" json:"datadirs,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -303,7 +339,7 @@ func (m *DeleteDataDirectoriesRequest) Reset()         { *m = DeleteDataDirector
 func (m *DeleteDataDirectoriesRequest) String() string { return proto.CompactTextString(m) }
 func (*DeleteDataDirectoriesRequest) ProtoMessage()    {}
 func (*DeleteDataDirectoriesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{4}
+	return fileDescriptor_9e73bb06acc917d8, []int{3}
 }
 
 func (m *DeleteDataDirectoriesRequest) XXX_Unmarshal(b []byte) error {
@@ -341,7 +377,7 @@ func (m *DeleteDataDirectoriesReply) Reset()         { *m = DeleteDataDirectorie
 func (m *DeleteDataDirectoriesReply) String() string { return proto.CompactTextString(m) }
 func (*DeleteDataDirectoriesReply) ProtoMessage()    {}
 func (*DeleteDataDirectoriesReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{5}
+	return fileDescriptor_9e73bb06acc917d8, []int{4}
 }
 
 func (m *DeleteDataDirectoriesReply) XXX_Unmarshal(b []byte) error {
@@ -372,7 +408,7 @@ func (m *DeleteStateDirectoryRequest) Reset()         { *m = DeleteStateDirector
 func (m *DeleteStateDirectoryRequest) String() string { return proto.CompactTextString(m) }
 func (*DeleteStateDirectoryRequest) ProtoMessage()    {}
 func (*DeleteStateDirectoryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{6}
+	return fileDescriptor_9e73bb06acc917d8, []int{5}
 }
 
 func (m *DeleteStateDirectoryRequest) XXX_Unmarshal(b []byte) error {
@@ -403,7 +439,7 @@ func (m *DeleteStateDirectoryReply) Reset()         { *m = DeleteStateDirectoryR
 func (m *DeleteStateDirectoryReply) String() string { return proto.CompactTextString(m) }
 func (*DeleteStateDirectoryReply) ProtoMessage()    {}
 func (*DeleteStateDirectoryReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{7}
+	return fileDescriptor_9e73bb06acc917d8, []int{6}
 }
 
 func (m *DeleteStateDirectoryReply) XXX_Unmarshal(b []byte) error {
@@ -436,7 +472,7 @@ func (m *ArchiveLogDirectoryRequest) Reset()         { *m = ArchiveLogDirectoryR
 func (m *ArchiveLogDirectoryRequest) String() string { return proto.CompactTextString(m) }
 func (*ArchiveLogDirectoryRequest) ProtoMessage()    {}
 func (*ArchiveLogDirectoryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{8}
+	return fileDescriptor_9e73bb06acc917d8, []int{7}
 }
 
 func (m *ArchiveLogDirectoryRequest) XXX_Unmarshal(b []byte) error {
@@ -481,7 +517,7 @@ func (m *ArchiveLogDirectoryReply) Reset()         { *m = ArchiveLogDirectoryRep
 func (m *ArchiveLogDirectoryReply) String() string { return proto.CompactTextString(m) }
 func (*ArchiveLogDirectoryReply) ProtoMessage()    {}
 func (*ArchiveLogDirectoryReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{9}
+	return fileDescriptor_9e73bb06acc917d8, []int{8}
 }
 
 func (m *ArchiveLogDirectoryReply) XXX_Unmarshal(b []byte) error {
@@ -515,7 +551,7 @@ func (m *RenameDirectories) Reset()         { *m = RenameDirectories{} }
 func (m *RenameDirectories) String() string { return proto.CompactTextString(m) }
 func (*RenameDirectories) ProtoMessage()    {}
 func (*RenameDirectories) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{10}
+	return fileDescriptor_9e73bb06acc917d8, []int{9}
 }
 
 func (m *RenameDirectories) XXX_Unmarshal(b []byte) error {
@@ -568,7 +604,7 @@ func (m *RenameDirectoriesRequest) Reset()         { *m = RenameDirectoriesReque
 func (m *RenameDirectoriesRequest) String() string { return proto.CompactTextString(m) }
 func (*RenameDirectoriesRequest) ProtoMessage()    {}
 func (*RenameDirectoriesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{11}
+	return fileDescriptor_9e73bb06acc917d8, []int{10}
 }
 
 func (m *RenameDirectoriesRequest) XXX_Unmarshal(b []byte) error {
@@ -606,7 +642,7 @@ func (m *RenameDirectoriesReply) Reset()         { *m = RenameDirectoriesReply{}
 func (m *RenameDirectoriesReply) String() string { return proto.CompactTextString(m) }
 func (*RenameDirectoriesReply) ProtoMessage()    {}
 func (*RenameDirectoriesReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{12}
+	return fileDescriptor_9e73bb06acc917d8, []int{11}
 }
 
 func (m *RenameDirectoriesReply) XXX_Unmarshal(b []byte) error {
@@ -627,6 +663,123 @@ func (m *RenameDirectoriesReply) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_RenameDirectoriesReply proto.InternalMessageInfo
 
+type CheckRenameDirectoriesReply struct {
+	Problems             []string `protobuf:"bytes,1,rep,name=Problems,proto3" json:"Problems,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckRenameDirectoriesReply) Reset()         { *m = CheckRenameDirectoriesReply{} }
+func (m *CheckRenameDirectoriesReply) String() string { return proto.CompactTextString(m) }
+func (*CheckRenameDirectoriesReply) ProtoMessage()    {}
+func (*CheckRenameDirectoriesReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{12}
+}
+
+func (m *CheckRenameDirectoriesReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckRenameDirectoriesReply.Unmarshal(m, b)
+}
+func (m *CheckRenameDirectoriesReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckRenameDirectoriesReply.Marshal(b, m, deterministic)
+}
+func (m *CheckRenameDirectoriesReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckRenameDirectoriesReply.Merge(m, src)
+}
+func (m *CheckRenameDirectoriesReply) XXX_Size() int {
+	return xxx_messageInfo_CheckRenameDirectoriesReply.Size(m)
+}
+func (m *CheckRenameDirectoriesReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckRenameDirectoriesReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckRenameDirectoriesReply proto.InternalMessageInfo
+
+func (m *CheckRenameDirectoriesReply) GetProblems() []string {
+	if m != nil {
+		return m.Problems
+	}
+	return nil
+}
+
+type CheckPortsRequest struct {
+	Ports                []uint32 `protobuf:"varint,1,rep,packed,name=Ports,proto3" json:"Ports,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckPortsRequest) Reset()         { *m = CheckPortsRequest{} }
+func (m *CheckPortsRequest) String() string { return proto.CompactTextString(m) }
+func (*CheckPortsRequest) ProtoMessage()    {}
+func (*CheckPortsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{13}
+}
+
+func (m *CheckPortsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckPortsRequest.Unmarshal(m, b)
+}
+func (m *CheckPortsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckPortsRequest.Marshal(b, m, deterministic)
+}
+func (m *CheckPortsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckPortsRequest.Merge(m, src)
+}
+func (m *CheckPortsRequest) XXX_Size() int {
+	return xxx_messageInfo_CheckPortsRequest.Size(m)
+}
+func (m *CheckPortsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckPortsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckPortsRequest proto.InternalMessageInfo
+
+func (m *CheckPortsRequest) GetPorts() []uint32 {
+	if m != nil {
+		return m.Ports
+	}
+	return nil
+}
+
+type CheckPortsReply struct {
+	InUse                []uint32 `protobuf:"varint,1,rep,packed,name=InUse,proto3" json:"InUse,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckPortsReply) Reset()         { *m = CheckPortsReply{} }
+func (m *CheckPortsReply) String() string { return proto.CompactTextString(m) }
+func (*CheckPortsReply) ProtoMessage()    {}
+func (*CheckPortsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{14}
+}
+
+func (m *CheckPortsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckPortsReply.Unmarshal(m, b)
+}
+func (m *CheckPortsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckPortsReply.Marshal(b, m, deterministic)
+}
+func (m *CheckPortsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckPortsReply.Merge(m, src)
+}
+func (m *CheckPortsReply) XXX_Size() int {
+	return xxx_messageInfo_CheckPortsReply.Size(m)
+}
+func (m *CheckPortsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckPortsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckPortsReply proto.InternalMessageInfo
+
+func (m *CheckPortsReply) GetInUse() []uint32 {
+	if m != nil {
+		return m.InUse
+	}
+	return nil
+}
+
 type StopAgentRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -637,7 +790,7 @@ func (m *StopAgentRequest) Reset()         { *m = StopAgentRequest{} }
 func (m *StopAgentRequest) String() string { return proto.CompactTextString(m) }
 func (*StopAgentRequest) ProtoMessage()    {}
 func (*StopAgentRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{13}
+	return fileDescriptor_9e73bb06acc917d8, []int{15}
 }
 
 func (m *StopAgentRequest) XXX_Unmarshal(b []byte) error {
@@ -668,7 +821,7 @@ func (m *StopAgentReply) Reset()         { *m = StopAgentReply{} }
 func (m *StopAgentReply) String() string { return proto.CompactTextString(m) }
 func (*StopAgentReply) ProtoMessage()    {}
 func (*StopAgentReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{14}
+	return fileDescriptor_9e73bb06acc917d8, []int{16}
 }
 
 func (m *StopAgentReply) XXX_Unmarshal(b []byte) error {
@@ -690,18 +843,30 @@ func (m *StopAgentReply) XXX_DiscardUnknown() {
 var xxx_messageInfo_StopAgentReply proto.InternalMessageInfo
 
 type CheckSegmentDiskSpaceRequest struct {
-	Request              *CheckDiskSpaceRequest `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
-	Datadirs             []string               `protobuf:"bytes,2,rep,name=datadirs,proto3" json:"datadirs,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
-	XXX_unrecognized     []byte                 `json:"-"`
-	XXX_sizecache        int32                  `json:"-"`
+	Request     *CheckDiskSpaceRequest `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Datadirs    []string               `protobuf:"bytes,2,rep,name=datadirs,proto3" json:"datadirs,omitempty"`
+	Tablespaces []string               `protobuf:"bytes,6,rep,name=tablespaces,proto3" json:"tablespaces,omitempty"`
+	// The fields below are used to estimate the space needed. The datadirs
+	// and tablespaces are then only those of the primaries.
+	MirrorDatadirs    []string                                 `protobuf:"bytes,3,rep,name=mirrorDatadirs,proto3" json:"mirrorDatadirs,omitempty"`
+	MirrorTablespaces []string                                 `protobuf:"bytes,7,rep,name=mirrorTablespaces,proto3" json:"mirrorTablespaces,omitempty"`
+	LinkMode          bool                                     `protobuf:"varint,4,opt,name=linkMode,proto3" json:"linkMode,omitempty"`
+	MasterBackup      *CheckSegmentDiskSpaceRequest_MasterCopy `protobuf:"bytes,5,opt,name=masterBackup,proto3" json:"masterBackup,omitempty"`
+	MasterTablespaces *CheckSegmentDiskSpaceRequest_MasterCopy `protobuf:"bytes,8,opt,name=masterTablespaces,proto3" json:"masterTablespaces,omitempty"`
+	// TargetMasterBackup is the backup of the target master that is taken in
+	// the state directory. It is only set for the master host, whose
+	// datadirs and tablespaces then include those of the master.
+	TargetMasterBackup   *CheckSegmentDiskSpaceRequest_MasterCopy `protobuf:"bytes,9,opt,name=targetMasterBackup,proto3" json:"targetMasterBackup,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                                 `json:"-"`
+	XXX_unrecognized     []byte                                   `json:"-"`
+	XXX_sizecache        int32                                    `json:"-"`
 }
 
 func (m *CheckSegmentDiskSpaceRequest) Reset()         { *m = CheckSegmentDiskSpaceRequest{} }
 func (m *CheckSegmentDiskSpaceRequest) String() string { return proto.CompactTextString(m) }
 func (*CheckSegmentDiskSpaceRequest) ProtoMessage()    {}
 func (*CheckSegmentDiskSpaceRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e73bb06acc917d8, []int{15}
+	return fileDescriptor_9e73bb06acc917d8, []int{17}
 }
 
 func (m *CheckSegmentDiskSpaceRequest) XXX_Unmarshal(b []byte) error {
@@ -736,199 +901,1244 @@ func (m *CheckSegmentDiskSpaceRequest) GetDatadirs() []string {
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*TablespaceInfo)(nil), "idl.TablespaceInfo")
-	proto.RegisterType((*UpgradePrimariesRequest)(nil), "idl.UpgradePrimariesRequest")
-	proto.RegisterType((*DataDirPair)(nil), "idl.DataDirPair")
-	proto.RegisterMapType((map[int32]*TablespaceInfo)(nil), "idl.DataDirPair.TablespacesEntry")
-	proto.RegisterType((*UpgradePrimariesReply)(nil), "idl.UpgradePrimariesReply")
-	proto.RegisterType((*DeleteDataDirectoriesRequest)(nil), "idl.DeleteDataDirectoriesRequest")
-	proto.RegisterType((*DeleteDataDirectoriesReply)(nil), "idl.DeleteDataDirectoriesReply")
-	proto.RegisterType((*DeleteStateDirectoryRequest)(nil), "idl.DeleteStateDirectoryRequest")
-	proto.RegisterType((*DeleteStateDirectoryReply)(nil), "idl.DeleteStateDirectoryReply")
-	proto.RegisterType((*ArchiveLogDirectoryRequest)(nil), "idl.ArchiveLogDirectoryRequest")
-	proto.RegisterType((*ArchiveLogDirectoryReply)(nil), "idl.ArchiveLogDirectoryReply")
-	proto.RegisterType((*RenameDirectories)(nil), "idl.RenameDirectories")
-	proto.RegisterType((*RenameDirectoriesRequest)(nil), "idl.RenameDirectoriesRequest")
-	proto.RegisterType((*RenameDirectoriesReply)(nil), "idl.RenameDirectoriesReply")
-	proto.RegisterType((*StopAgentRequest)(nil), "idl.StopAgentRequest")
-	proto.RegisterType((*StopAgentReply)(nil), "idl.StopAgentReply")
-	proto.RegisterType((*CheckSegmentDiskSpaceRequest)(nil), "idl.CheckSegmentDiskSpaceRequest")
+func (m *CheckSegmentDiskSpaceRequest) GetTablespaces() []string {
+	if m != nil {
+		return m.Tablespaces
+	}
+	return nil
 }
 
-func init() { proto.RegisterFile("hub_to_agent.proto", fileDescriptor_9e73bb06acc917d8) }
-
-var fileDescriptor_9e73bb06acc917d8 = []byte{
-	// 805 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x56, 0xcf, 0x4e, 0xfb, 0x46,
-	0x10, 0x6e, 0xfe, 0x91, 0x30, 0xa1, 0x34, 0x5d, 0x0a, 0xb8, 0x4b, 0xa0, 0xc1, 0xea, 0x21, 0xed,
-	0x21, 0x87, 0x94, 0x43, 0xcb, 0xa1, 0x12, 0xe0, 0x22, 0x21, 0x05, 0x48, 0x1d, 0x68, 0xa5, 0x4a,
-	0x15, 0xda, 0xd8, 0x4b, 0xb2, 0x8a, 0x63, 0xbb, 0xeb, 0x0d, 0x55, 0x5e, 0xb1, 0x6f, 0xd1, 0xd3,
-	0xef, 0x35, 0x7e, 0xda, 0x5d, 0x9b, 0xd8, 0xc6, 0xce, 0x6d, 0xe7, 0x9b, 0x6f, 0xbe, 0x9d, 0x99,
-	0x9d, 0xb1, 0x0c, 0x68, 0xbe, 0x9a, 0xbe, 0x88, 0xe0, 0x85, 0xcc, 0xa8, 0x2f, 0x06, 0x21, 0x0f,
-	0x44, 0x80, 0x6a, 0xcc, 0xf5, 0x70, 0xc7, 0xf1, 0x98, 0x74, 0xcc, 0x57, 0x53, 0x0d, 0x9b, 0x53,
-	0xd8, 0x7f, 0x22, 0x53, 0x8f, 0x46, 0x21, 0x71, 0xe8, 0x9d, 0xff, 0x1a, 0x20, 0x04, 0xf5, 0x07,
-	0xb2, 0xa4, 0x46, 0xad, 0x57, 0xe9, 0xef, 0xda, 0xea, 0x8c, 0x30, 0xb4, 0x46, 0x81, 0x43, 0x04,
-	0x0b, 0x7c, 0xa3, 0xae, 0xf0, 0x77, 0x1b, 0xf5, 0xa0, 0xfd, 0x1c, 0x51, 0x6e, 0xd1, 0x57, 0xe6,
-	0x53, 0xd7, 0x68, 0xf4, 0x2a, 0xfd, 0x96, 0x9d, 0x86, 0xcc, 0x4f, 0x55, 0x38, 0x7e, 0x0e, 0x67,
-	0x9c, 0xb8, 0x74, 0xcc, 0xd9, 0x92, 0x70, 0x46, 0x23, 0x9b, 0xfe, 0xb3, 0xa2, 0x91, 0x40, 0x26,
-	0xec, 0x4d, 0x82, 0x15, 0x77, 0xe8, 0x35, 0xf3, 0x2d, 0xc6, 0x8d, 0x8a, 0x52, 0xcf, 0x60, 0x92,
-	0xf3, 0x44, 0xf8, 0x8c, 0x8a, 0x98, 0x53, 0xd5, 0x9c, 0x34, 0x86, 0xbe, 0x87, 0x2f, 0xb5, 0xfd,
-	0x07, 0xe5, 0x91, 0x4c, 0x53, 0xa7, 0x9f, 0x05, 0xd1, 0x05, 0xec, 0x59, 0x44, 0x10, 0x8b, 0xf1,
-	0x31, 0x61, 0x3c, 0x32, 0xea, 0xbd, 0x5a, 0xbf, 0x3d, 0xec, 0x0c, 0x98, 0xeb, 0x0d, 0x52, 0x0e,
-	0x3b, 0xc3, 0x42, 0x5d, 0xd8, 0xbd, 0x99, 0x53, 0x67, 0xf1, 0xe8, 0x7b, 0xeb, 0xb8, 0xbe, 0x0d,
-	0x10, 0xd7, 0x3f, 0x62, 0xfe, 0xe2, 0x3e, 0x70, 0xa9, 0xb1, 0xf3, 0x5e, 0x7f, 0x02, 0xa1, 0x3e,
-	0x7c, 0x75, 0x4f, 0x22, 0x41, 0xf9, 0x35, 0x71, 0x16, 0xab, 0x50, 0x96, 0xd0, 0x54, 0xd9, 0xe5,
-	0x61, 0xf4, 0x2b, 0xe0, 0xcd, 0x6b, 0x44, 0xf7, 0x24, 0x0c, 0x99, 0x3f, 0xbb, 0x65, 0x1e, 0x1d,
-	0x13, 0x31, 0x37, 0x5a, 0x2a, 0x68, 0x0b, 0xc3, 0xfc, 0xbf, 0x0a, 0xed, 0x54, 0xea, 0xb2, 0x2b,
-	0xba, 0x93, 0x31, 0x18, 0xb7, 0x37, 0x0b, 0x6e, 0x7a, 0x97, 0xb0, 0xaa, 0xe9, 0xde, 0x25, 0xac,
-	0x33, 0x00, 0x1d, 0x36, 0x0e, 0xb8, 0x50, 0xed, 0x6d, 0xd8, 0x29, 0x44, 0xfa, 0x75, 0x80, 0xf2,
-	0xd7, 0xb5, 0x7f, 0x83, 0x20, 0x03, 0x9a, 0x37, 0x81, 0x2f, 0xa8, 0x2f, 0x54, 0x0f, 0x1b, 0x76,
-	0x62, 0xca, 0x89, 0xb3, 0xae, 0xef, 0x2c, 0xd5, 0xba, 0x86, 0xad, 0xce, 0xe8, 0x06, 0xda, 0xa9,
-	0x3a, 0x8d, 0xa6, 0x7a, 0xa8, 0xf3, 0xfc, 0x43, 0x0d, 0x52, 0x9c, 0xdf, 0x7c, 0xc1, 0xd7, 0x76,
-	0x3a, 0x0a, 0x4f, 0xa0, 0x93, 0x27, 0xa0, 0x0e, 0xd4, 0x16, 0x74, 0xad, 0x1a, 0xd1, 0xb0, 0xe5,
-	0x11, 0xfd, 0x00, 0x8d, 0x37, 0xe2, 0xad, 0xa8, 0x2a, 0xbb, 0x3d, 0x3c, 0x50, 0x97, 0x64, 0x97,
-	0xc2, 0xd6, 0x8c, 0xcb, 0xea, 0xcf, 0x15, 0xf3, 0x18, 0x0e, 0x3f, 0x0e, 0x73, 0xe8, 0xad, 0xcd,
-	0x4b, 0xe8, 0x5a, 0xd4, 0xa3, 0x22, 0xe9, 0x2b, 0x75, 0x44, 0x90, 0x1e, 0x75, 0x0c, 0x2d, 0x97,
-	0x08, 0xe2, 0xca, 0xc1, 0xab, 0xf4, 0x6a, 0x72, 0x89, 0x12, 0xdb, 0xec, 0x02, 0x2e, 0x89, 0x95,
-	0xca, 0xa7, 0x70, 0xa2, 0xbd, 0x13, 0x41, 0x04, 0x4d, 0xdc, 0xeb, 0x58, 0xd8, 0x3c, 0x81, 0x6f,
-	0x8b, 0xdd, 0x32, 0x76, 0x04, 0xf8, 0x8a, 0x3b, 0x73, 0xf6, 0x46, 0x47, 0xc1, 0x2c, 0x1f, 0x8a,
-	0x8e, 0x60, 0xe7, 0xd1, 0x73, 0x37, 0x93, 0x11, 0x5b, 0x12, 0x7f, 0xa0, 0xff, 0x6e, 0x66, 0x21,
-	0xb6, 0x4c, 0x0c, 0x46, 0xa1, 0x9a, 0xbc, 0x69, 0x06, 0x5f, 0xdb, 0xd4, 0x27, 0x4b, 0x9a, 0xca,
-	0x5f, 0x0a, 0xe9, 0x19, 0x49, 0x2e, 0xd0, 0x96, 0xc4, 0xf5, 0x6c, 0x24, 0x17, 0x68, 0x4b, 0xee,
-	0xba, 0x16, 0x89, 0xbd, 0x35, 0xb5, 0x4e, 0x19, 0xcc, 0xbc, 0x05, 0xe3, 0xc3, 0x45, 0x49, 0x41,
-	0x3f, 0x42, 0xdd, 0x4a, 0x1a, 0xdc, 0x1e, 0x1e, 0xa9, 0xb7, 0xfc, 0x48, 0x56, 0x1c, 0xd3, 0x80,
-	0xa3, 0x02, 0x1d, 0x59, 0x0a, 0x82, 0xce, 0x44, 0x04, 0xe1, 0x95, 0xfc, 0x7e, 0x26, 0x5d, 0xee,
-	0xc0, 0x7e, 0x0a, 0x93, 0xac, 0x10, 0xba, 0xea, 0x33, 0x30, 0xa1, 0xb3, 0x25, 0xf5, 0x85, 0xc5,
-	0xa2, 0xc5, 0x44, 0x4e, 0x4c, 0x92, 0xcb, 0x05, 0x34, 0xb9, 0x3e, 0xaa, 0xe2, 0xdb, 0x43, 0xac,
-	0xd2, 0x51, 0x31, 0x79, 0xb2, 0x9d, 0x50, 0x33, 0x63, 0x52, 0xcd, 0x8e, 0xc9, 0xf0, 0xbf, 0x3a,
-	0x34, 0x54, 0x02, 0xe8, 0x11, 0xf6, 0xb3, 0x3a, 0xe8, 0x7c, 0x23, 0x5e, 0x92, 0x10, 0x36, 0x0a,
-	0xef, 0x97, 0xa5, 0x7c, 0x81, 0x1e, 0xa0, 0x93, 0x1f, 0x6b, 0xd4, 0x55, 0xfc, 0x92, 0x4f, 0x37,
-	0xc6, 0x25, 0x5e, 0xad, 0xf7, 0x7b, 0xd1, 0x34, 0x9c, 0x96, 0xbc, 0x47, 0xac, 0x78, 0x52, 0xe6,
-	0xd6, 0x92, 0xbf, 0xc0, 0xee, 0xfb, 0x0b, 0xa0, 0x43, 0xc5, 0xcd, 0xbf, 0x12, 0x3e, 0xc8, 0xc3,
-	0x3a, 0xf4, 0x6f, 0x38, 0x2c, 0xdc, 0xaf, 0xb8, 0x6b, 0xdb, 0xf6, 0x16, 0x7f, 0xb7, 0x8d, 0xa2,
-	0xe5, 0xff, 0x82, 0x6f, 0x8a, 0x36, 0x10, 0xf5, 0x52, 0xa1, 0x85, 0xbb, 0x8b, 0xcf, 0xb6, 0x30,
-	0xb4, 0xf6, 0x9f, 0x70, 0x50, 0xb0, 0x72, 0x48, 0x67, 0x55, 0xbe, 0xda, 0xf8, 0xb4, 0x9c, 0xa0,
-	0x84, 0xa7, 0x3b, 0xea, 0x0f, 0xe0, 0xa7, 0xcf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xae, 0x64, 0x17,
-	0xd9, 0x2e, 0x08, 0x00, 0x00,
+func (m *CheckSegmentDiskSpaceRequest) GetMirrorDatadirs() []string {
+	if m != nil {
+		return m.MirrorDatadirs
+	}
+	return nil
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// AgentClient is the client API for Agent service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type AgentClient interface {
-	CheckDiskSpace(ctx context.Context, in *CheckSegmentDiskSpaceRequest, opts ...grpc.CallOption) (*CheckDiskSpaceReply, error)
-	UpgradePrimaries(ctx context.Context, in *UpgradePrimariesRequest, opts ...grpc.CallOption) (*UpgradePrimariesReply, error)
-	RenameDirectories(ctx context.Context, in *RenameDirectoriesRequest, opts ...grpc.CallOption) (*RenameDirectoriesReply, error)
-	StopAgent(ctx context.Context, in *StopAgentRequest, opts ...grpc.CallOption) (*StopAgentReply, error)
-	DeleteDataDirectories(ctx context.Context, in *DeleteDataDirectoriesRequest, opts ...grpc.CallOption) (*DeleteDataDirectoriesReply, error)
-	DeleteStateDirectory(ctx context.Context, in *DeleteStateDirectoryRequest, opts ...grpc.CallOption) (*DeleteStateDirectoryReply, error)
-	ArchiveLogDirectory(ctx context.Context, in *ArchiveLogDirectoryRequest, opts ...grpc.CallOption) (*ArchiveLogDirectoryReply, error)
+func (m *CheckSegmentDiskSpaceRequest) GetMirrorTablespaces() []string {
+	if m != nil {
+		return m.MirrorTablespaces
+	}
+	return nil
 }
 
-type agentClient struct {
-	cc *grpc.ClientConn
+func (m *CheckSegmentDiskSpaceRequest) GetLinkMode() bool {
+	if m != nil {
+		return m.LinkMode
+	}
+	return false
 }
 
-func NewAgentClient(cc *grpc.ClientConn) AgentClient {
-	return &agentClient{cc}
+func (m *CheckSegmentDiskSpaceRequest) GetMasterBackup() *CheckSegmentDiskSpaceRequest_MasterCopy {
+	if m != nil {
+		return m.MasterBackup
+	}
+	return nil
 }
 
-func (c *agentClient) CheckDiskSpace(ctx context.Context, in *CheckSegmentDiskSpaceRequest, opts ...grpc.CallOption) (*CheckDiskSpaceReply, error) {
-	out := new(CheckDiskSpaceReply)
-	err := c.cc.Invoke(ctx, "/idl.Agent/CheckDiskSpace", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *CheckSegmentDiskSpaceRequest) GetMasterTablespaces() *CheckSegmentDiskSpaceRequest_MasterCopy {
+	if m != nil {
+		return m.MasterTablespaces
 	}
-	return out, nil
+	return nil
 }
 
-func (c *agentClient) UpgradePrimaries(ctx context.Context, in *UpgradePrimariesRequest, opts ...grpc.CallOption) (*UpgradePrimariesReply, error) {
-	out := new(UpgradePrimariesReply)
-	err := c.cc.Invoke(ctx, "/idl.Agent/UpgradePrimaries", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *CheckSegmentDiskSpaceRequest) GetTargetMasterBackup() *CheckSegmentDiskSpaceRequest_MasterCopy {
+	if m != nil {
+		return m.TargetMasterBackup
 	}
-	return out, nil
+	return nil
 }
 
-func (c *agentClient) RenameDirectories(ctx context.Context, in *RenameDirectoriesRequest, opts ...grpc.CallOption) (*RenameDirectoriesReply, error) {
-	out := new(RenameDirectoriesReply)
-	err := c.cc.Invoke(ctx, "/idl.Agent/RenameDirectories", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+// MasterCopy is a copy of the upgraded master, or of its tablespaces, that
+// is made on each host with primaries. Its size is in bytes.
+type CheckSegmentDiskSpaceRequest_MasterCopy struct {
+	Dir                  string   `protobuf:"bytes,1,opt,name=dir,proto3" json:"dir,omitempty"`
+	Bytes                uint64   `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Files                uint64   `protobuf:"varint,3,opt,name=files,proto3" json:"files,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (c *agentClient) StopAgent(ctx context.Context, in *StopAgentRequest, opts ...grpc.CallOption) (*StopAgentReply, error) {
-	out := new(StopAgentReply)
-	err := c.cc.Invoke(ctx, "/idl.Agent/StopAgent", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+func (m *CheckSegmentDiskSpaceRequest_MasterCopy) Reset() {
+	*m = CheckSegmentDiskSpaceRequest_MasterCopy{}
+}
+func (m *CheckSegmentDiskSpaceRequest_MasterCopy) String() string { return proto.CompactTextString(m) }
+func (*CheckSegmentDiskSpaceRequest_MasterCopy) ProtoMessage()    {}
+func (*CheckSegmentDiskSpaceRequest_MasterCopy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{17, 0}
 }
 
-func (c *agentClient) DeleteDataDirectories(ctx context.Context, in *DeleteDataDirectoriesRequest, opts ...grpc.CallOption) (*DeleteDataDirectoriesReply, error) {
-	out := new(DeleteDataDirectoriesReply)
-	err := c.cc.Invoke(ctx, "/idl.Agent/DeleteDataDirectories", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *CheckSegmentDiskSpaceRequest_MasterCopy) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckSegmentDiskSpaceRequest_MasterCopy.Unmarshal(m, b)
+}
+func (m *CheckSegmentDiskSpaceRequest_MasterCopy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckSegmentDiskSpaceRequest_MasterCopy.Marshal(b, m, deterministic)
+}
+func (m *CheckSegmentDiskSpaceRequest_MasterCopy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckSegmentDiskSpaceRequest_MasterCopy.Merge(m, src)
+}
+func (m *CheckSegmentDiskSpaceRequest_MasterCopy) XXX_Size() int {
+	return xxx_messageInfo_CheckSegmentDiskSpaceRequest_MasterCopy.Size(m)
+}
+func (m *CheckSegmentDiskSpaceRequest_MasterCopy) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckSegmentDiskSpaceRequest_MasterCopy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckSegmentDiskSpaceRequest_MasterCopy proto.InternalMessageInfo
+
+func (m *CheckSegmentDiskSpaceRequest_MasterCopy) GetDir() string {
+	if m != nil {
+		return m.Dir
 	}
-	return out, nil
+	return ""
 }
 
-func (c *agentClient) DeleteStateDirectory(ctx context.Context, in *DeleteStateDirectoryRequest, opts ...grpc.CallOption) (*DeleteStateDirectoryReply, error) {
-	out := new(DeleteStateDirectoryReply)
-	err := c.cc.Invoke(ctx, "/idl.Agent/DeleteStateDirectory", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *CheckSegmentDiskSpaceRequest_MasterCopy) GetBytes() uint64 {
+	if m != nil {
+		return m.Bytes
 	}
-	return out, nil
+	return 0
 }
 
-func (c *agentClient) ArchiveLogDirectory(ctx context.Context, in *ArchiveLogDirectoryRequest, opts ...grpc.CallOption) (*ArchiveLogDirectoryReply, error) {
-	out := new(ArchiveLogDirectoryReply)
-	err := c.cc.Invoke(ctx, "/idl.Agent/ArchiveLogDirectory", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *CheckSegmentDiskSpaceRequest_MasterCopy) GetFiles() uint64 {
+	if m != nil {
+		return m.Files
 	}
-	return out, nil
+	return 0
 }
 
-// AgentServer is the server API for Agent service.
-type AgentServer interface {
-	CheckDiskSpace(context.Context, *CheckSegmentDiskSpaceRequest) (*CheckDiskSpaceReply, error)
-	UpgradePrimaries(context.Context, *UpgradePrimariesRequest) (*UpgradePrimariesReply, error)
-	RenameDirectories(context.Context, *RenameDirectoriesRequest) (*RenameDirectoriesReply, error)
-	StopAgent(context.Context, *StopAgentRequest) (*StopAgentReply, error)
-	DeleteDataDirectories(context.Context, *DeleteDataDirectoriesRequest) (*DeleteDataDirectoriesReply, error)
-	DeleteStateDirectory(context.Context, *DeleteStateDirectoryRequest) (*DeleteStateDirectoryReply, error)
-	ArchiveLogDirectory(context.Context, *ArchiveLogDirectoryRequest) (*ArchiveLogDirectoryReply, error)
+// SyncDirectoryRequest is streamed to mirror files onto an agent's host. The
+// options come first, followed by an entry for each file and then entriesDone.
+// The agent replies with the REGULAR files whose contents it needs, and only
+// those are then sent, in the same order. Each starts with a contentsOf
+// message naming the file, followed by zero or more data messages, and ends
+// with the SHA-256 checksum of the data.
+type SyncDirectoryRequest struct {
+	// Types that are valid to be assigned to Contents:
+	//	*SyncDirectoryRequest_Options
+	//	*SyncDirectoryRequest_Entry
+	//	*SyncDirectoryRequest_Data
+	//	*SyncDirectoryRequest_Checksum
+	//	*SyncDirectoryRequest_EntriesDone
+	//	*SyncDirectoryRequest_ContentsOf
+	Contents             isSyncDirectoryRequest_Contents `protobuf_oneof:"contents"`
+	XXX_NoUnkeyedLiteral struct{}                        `json:"-"`
+	XXX_unrecognized     []byte                          `json:"-"`
+	XXX_sizecache        int32                           `json:"-"`
 }
 
-// UnimplementedAgentServer can be embedded to have forward compatible implementations.
-type UnimplementedAgentServer struct {
+func (m *SyncDirectoryRequest) Reset()         { *m = SyncDirectoryRequest{} }
+func (m *SyncDirectoryRequest) String() string { return proto.CompactTextString(m) }
+func (*SyncDirectoryRequest) ProtoMessage()    {}
+func (*SyncDirectoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{18}
 }
 
-func (*UnimplementedAgentServer) CheckDiskSpace(ctx context.Context, req *CheckSegmentDiskSpaceRequest) (*CheckDiskSpaceReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method CheckDiskSpace not implemented")
+func (m *SyncDirectoryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncDirectoryRequest.Unmarshal(m, b)
 }
-func (*UnimplementedAgentServer) UpgradePrimaries(ctx context.Context, req *UpgradePrimariesRequest) (*UpgradePrimariesReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method UpgradePrimaries not implemented")
+func (m *SyncDirectoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncDirectoryRequest.Marshal(b, m, deterministic)
+}
+func (m *SyncDirectoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncDirectoryRequest.Merge(m, src)
+}
+func (m *SyncDirectoryRequest) XXX_Size() int {
+	return xxx_messageInfo_SyncDirectoryRequest.Size(m)
+}
+func (m *SyncDirectoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncDirectoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncDirectoryRequest proto.InternalMessageInfo
+
+type isSyncDirectoryRequest_Contents interface {
+	isSyncDirectoryRequest_Contents()
+}
+
+type SyncDirectoryRequest_Options struct {
+	Options *SyncOptions `protobuf:"bytes,1,opt,name=options,proto3,oneof"`
+}
+
+type SyncDirectoryRequest_Entry struct {
+	Entry *FileEntry `protobuf:"bytes,2,opt,name=entry,proto3,oneof"`
+}
+
+type SyncDirectoryRequest_Data struct {
+	Data []byte `protobuf:"bytes,3,opt,name=data,proto3,oneof"`
+}
+
+type SyncDirectoryRequest_Checksum struct {
+	Checksum []byte `protobuf:"bytes,4,opt,name=checksum,proto3,oneof"`
+}
+
+type SyncDirectoryRequest_EntriesDone struct {
+	EntriesDone bool `protobuf:"varint,5,opt,name=entriesDone,proto3,oneof"`
+}
+
+type SyncDirectoryRequest_ContentsOf struct {
+	ContentsOf string `protobuf:"bytes,6,opt,name=contentsOf,proto3,oneof"`
+}
+
+func (*SyncDirectoryRequest_Options) isSyncDirectoryRequest_Contents() {}
+
+func (*SyncDirectoryRequest_Entry) isSyncDirectoryRequest_Contents() {}
+
+func (*SyncDirectoryRequest_Data) isSyncDirectoryRequest_Contents() {}
+
+func (*SyncDirectoryRequest_Checksum) isSyncDirectoryRequest_Contents() {}
+
+func (*SyncDirectoryRequest_EntriesDone) isSyncDirectoryRequest_Contents() {}
+
+func (*SyncDirectoryRequest_ContentsOf) isSyncDirectoryRequest_Contents() {}
+
+func (m *SyncDirectoryRequest) GetContents() isSyncDirectoryRequest_Contents {
+	if m != nil {
+		return m.Contents
+	}
+	return nil
+}
+
+func (m *SyncDirectoryRequest) GetOptions() *SyncOptions {
+	if x, ok := m.GetContents().(*SyncDirectoryRequest_Options); ok {
+		return x.Options
+	}
+	return nil
+}
+
+func (m *SyncDirectoryRequest) GetEntry() *FileEntry {
+	if x, ok := m.GetContents().(*SyncDirectoryRequest_Entry); ok {
+		return x.Entry
+	}
+	return nil
+}
+
+func (m *SyncDirectoryRequest) GetData() []byte {
+	if x, ok := m.GetContents().(*SyncDirectoryRequest_Data); ok {
+		return x.Data
+	}
+	return nil
+}
+
+func (m *SyncDirectoryRequest) GetChecksum() []byte {
+	if x, ok := m.GetContents().(*SyncDirectoryRequest_Checksum); ok {
+		return x.Checksum
+	}
+	return nil
+}
+
+func (m *SyncDirectoryRequest) GetEntriesDone() bool {
+	if x, ok := m.GetContents().(*SyncDirectoryRequest_EntriesDone); ok {
+		return x.EntriesDone
+	}
+	return false
+}
+
+func (m *SyncDirectoryRequest) GetContentsOf() string {
+	if x, ok := m.GetContents().(*SyncDirectoryRequest_ContentsOf); ok {
+		return x.ContentsOf
+	}
+	return ""
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*SyncDirectoryRequest) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*SyncDirectoryRequest_Options)(nil),
+		(*SyncDirectoryRequest_Entry)(nil),
+		(*SyncDirectoryRequest_Data)(nil),
+		(*SyncDirectoryRequest_Checksum)(nil),
+		(*SyncDirectoryRequest_EntriesDone)(nil),
+		(*SyncDirectoryRequest_ContentsOf)(nil),
+	}
+}
+
+type SyncOptions struct {
+	TargetDir            string   `protobuf:"bytes,1,opt,name=targetDir,proto3" json:"targetDir,omitempty"`
+	Delete               bool     `protobuf:"varint,2,opt,name=delete,proto3" json:"delete,omitempty"`
+	Exclude              []string `protobuf:"bytes,3,rep,name=exclude,proto3" json:"exclude,omitempty"`
+	Checksum             bool     `protobuf:"varint,4,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SyncOptions) Reset()         { *m = SyncOptions{} }
+func (m *SyncOptions) String() string { return proto.CompactTextString(m) }
+func (*SyncOptions) ProtoMessage()    {}
+func (*SyncOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{19}
+}
+
+func (m *SyncOptions) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncOptions.Unmarshal(m, b)
+}
+func (m *SyncOptions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncOptions.Marshal(b, m, deterministic)
+}
+func (m *SyncOptions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncOptions.Merge(m, src)
+}
+func (m *SyncOptions) XXX_Size() int {
+	return xxx_messageInfo_SyncOptions.Size(m)
+}
+func (m *SyncOptions) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncOptions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncOptions proto.InternalMessageInfo
+
+func (m *SyncOptions) GetTargetDir() string {
+	if m != nil {
+		return m.TargetDir
+	}
+	return ""
+}
+
+func (m *SyncOptions) GetDelete() bool {
+	if m != nil {
+		return m.Delete
+	}
+	return false
+}
+
+func (m *SyncOptions) GetExclude() []string {
+	if m != nil {
+		return m.Exclude
+	}
+	return nil
+}
+
+func (m *SyncOptions) GetChecksum() bool {
+	if m != nil {
+		return m.Checksum
+	}
+	return false
+}
+
+type FileEntry struct {
+	Path                 string         `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Type                 FileEntry_Type `protobuf:"varint,2,opt,name=type,proto3,enum=idl.FileEntry_Type" json:"type,omitempty"`
+	Mode                 uint32         `protobuf:"varint,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	ModTime              int64          `protobuf:"varint,4,opt,name=modTime,proto3" json:"modTime,omitempty"`
+	Size                 int64          `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	Link                 string         `protobuf:"bytes,6,opt,name=link,proto3" json:"link,omitempty"`
+	Uid                  uint32         `protobuf:"varint,7,opt,name=uid,proto3" json:"uid,omitempty"`
+	Gid                  uint32         `protobuf:"varint,8,opt,name=gid,proto3" json:"gid,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *FileEntry) Reset()         { *m = FileEntry{} }
+func (m *FileEntry) String() string { return proto.CompactTextString(m) }
+func (*FileEntry) ProtoMessage()    {}
+func (*FileEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{20}
+}
+
+func (m *FileEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FileEntry.Unmarshal(m, b)
+}
+func (m *FileEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FileEntry.Marshal(b, m, deterministic)
+}
+func (m *FileEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FileEntry.Merge(m, src)
+}
+func (m *FileEntry) XXX_Size() int {
+	return xxx_messageInfo_FileEntry.Size(m)
+}
+func (m *FileEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_FileEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FileEntry proto.InternalMessageInfo
+
+func (m *FileEntry) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *FileEntry) GetType() FileEntry_Type {
+	if m != nil {
+		return m.Type
+	}
+	return FileEntry_REGULAR
+}
+
+func (m *FileEntry) GetMode() uint32 {
+	if m != nil {
+		return m.Mode
+	}
+	return 0
+}
+
+func (m *FileEntry) GetModTime() int64 {
+	if m != nil {
+		return m.ModTime
+	}
+	return 0
+}
+
+func (m *FileEntry) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *FileEntry) GetLink() string {
+	if m != nil {
+		return m.Link
+	}
+	return ""
+}
+
+func (m *FileEntry) GetUid() uint32 {
+	if m != nil {
+		return m.Uid
+	}
+	return 0
+}
+
+func (m *FileEntry) GetGid() uint32 {
+	if m != nil {
+		return m.Gid
+	}
+	return 0
+}
+
+// SyncDirectoryReply is streamed back to the hub. Once it has every entry, the
+// agent sends the paths of the files it needs, in the order of their entries,
+// over one or more needed messages, the last of which is done. The stats
+// follow once the target directory is up to date.
+type SyncDirectoryReply struct {
+	// Types that are valid to be assigned to Contents:
+	//	*SyncDirectoryReply_Needed
+	//	*SyncDirectoryReply_Stats
+	Contents             isSyncDirectoryReply_Contents `protobuf_oneof:"contents"`
+	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
+	XXX_unrecognized     []byte                        `json:"-"`
+	XXX_sizecache        int32                         `json:"-"`
+}
+
+func (m *SyncDirectoryReply) Reset()         { *m = SyncDirectoryReply{} }
+func (m *SyncDirectoryReply) String() string { return proto.CompactTextString(m) }
+func (*SyncDirectoryReply) ProtoMessage()    {}
+func (*SyncDirectoryReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{21}
+}
+
+func (m *SyncDirectoryReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncDirectoryReply.Unmarshal(m, b)
+}
+func (m *SyncDirectoryReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncDirectoryReply.Marshal(b, m, deterministic)
+}
+func (m *SyncDirectoryReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncDirectoryReply.Merge(m, src)
+}
+func (m *SyncDirectoryReply) XXX_Size() int {
+	return xxx_messageInfo_SyncDirectoryReply.Size(m)
+}
+func (m *SyncDirectoryReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncDirectoryReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncDirectoryReply proto.InternalMessageInfo
+
+type isSyncDirectoryReply_Contents interface {
+	isSyncDirectoryReply_Contents()
+}
+
+type SyncDirectoryReply_Needed struct {
+	Needed *NeededFiles `protobuf:"bytes,1,opt,name=needed,proto3,oneof"`
+}
+
+type SyncDirectoryReply_Stats struct {
+	Stats *SyncStats `protobuf:"bytes,2,opt,name=stats,proto3,oneof"`
+}
+
+func (*SyncDirectoryReply_Needed) isSyncDirectoryReply_Contents() {}
+
+func (*SyncDirectoryReply_Stats) isSyncDirectoryReply_Contents() {}
+
+func (m *SyncDirectoryReply) GetContents() isSyncDirectoryReply_Contents {
+	if m != nil {
+		return m.Contents
+	}
+	return nil
+}
+
+func (m *SyncDirectoryReply) GetNeeded() *NeededFiles {
+	if x, ok := m.GetContents().(*SyncDirectoryReply_Needed); ok {
+		return x.Needed
+	}
+	return nil
+}
+
+func (m *SyncDirectoryReply) GetStats() *SyncStats {
+	if x, ok := m.GetContents().(*SyncDirectoryReply_Stats); ok {
+		return x.Stats
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*SyncDirectoryReply) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*SyncDirectoryReply_Needed)(nil),
+		(*SyncDirectoryReply_Stats)(nil),
+	}
+}
+
+type NeededFiles struct {
+	Paths                []string `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	Done                 bool     `protobuf:"varint,2,opt,name=done,proto3" json:"done,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NeededFiles) Reset()         { *m = NeededFiles{} }
+func (m *NeededFiles) String() string { return proto.CompactTextString(m) }
+func (*NeededFiles) ProtoMessage()    {}
+func (*NeededFiles) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{22}
+}
+
+func (m *NeededFiles) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NeededFiles.Unmarshal(m, b)
+}
+func (m *NeededFiles) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NeededFiles.Marshal(b, m, deterministic)
+}
+func (m *NeededFiles) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NeededFiles.Merge(m, src)
+}
+func (m *NeededFiles) XXX_Size() int {
+	return xxx_messageInfo_NeededFiles.Size(m)
+}
+func (m *NeededFiles) XXX_DiscardUnknown() {
+	xxx_messageInfo_NeededFiles.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NeededFiles proto.InternalMessageInfo
+
+func (m *NeededFiles) GetPaths() []string {
+	if m != nil {
+		return m.Paths
+	}
+	return nil
+}
+
+func (m *NeededFiles) GetDone() bool {
+	if m != nil {
+		return m.Done
+	}
+	return false
+}
+
+type SyncStats struct {
+	Files                int64    `protobuf:"varint,1,opt,name=files,proto3" json:"files,omitempty"`
+	Bytes                int64    `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Deleted              int64    `protobuf:"varint,3,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SyncStats) Reset()         { *m = SyncStats{} }
+func (m *SyncStats) String() string { return proto.CompactTextString(m) }
+func (*SyncStats) ProtoMessage()    {}
+func (*SyncStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{23}
+}
+
+func (m *SyncStats) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncStats.Unmarshal(m, b)
+}
+func (m *SyncStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncStats.Marshal(b, m, deterministic)
+}
+func (m *SyncStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncStats.Merge(m, src)
+}
+func (m *SyncStats) XXX_Size() int {
+	return xxx_messageInfo_SyncStats.Size(m)
+}
+func (m *SyncStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncStats proto.InternalMessageInfo
+
+func (m *SyncStats) GetFiles() int64 {
+	if m != nil {
+		return m.Files
+	}
+	return 0
+}
+
+func (m *SyncStats) GetBytes() int64 {
+	if m != nil {
+		return m.Bytes
+	}
+	return 0
+}
+
+func (m *SyncStats) GetDeleted() int64 {
+	if m != nil {
+		return m.Deleted
+	}
+	return 0
+}
+
+// VerifyDirectoryRequest is streamed to check a copy made by SyncDirectory
+// against its source. The options come first, followed by the manifest of the
+// source.
+type VerifyDirectoryRequest struct {
+	// Types that are valid to be assigned to Contents:
+	//	*VerifyDirectoryRequest_Options
+	//	*VerifyDirectoryRequest_Entry
+	Contents             isVerifyDirectoryRequest_Contents `protobuf_oneof:"contents"`
+	XXX_NoUnkeyedLiteral struct{}                          `json:"-"`
+	XXX_unrecognized     []byte                            `json:"-"`
+	XXX_sizecache        int32                             `json:"-"`
+}
+
+func (m *VerifyDirectoryRequest) Reset()         { *m = VerifyDirectoryRequest{} }
+func (m *VerifyDirectoryRequest) String() string { return proto.CompactTextString(m) }
+func (*VerifyDirectoryRequest) ProtoMessage()    {}
+func (*VerifyDirectoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{24}
+}
+
+func (m *VerifyDirectoryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyDirectoryRequest.Unmarshal(m, b)
+}
+func (m *VerifyDirectoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyDirectoryRequest.Marshal(b, m, deterministic)
+}
+func (m *VerifyDirectoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyDirectoryRequest.Merge(m, src)
+}
+func (m *VerifyDirectoryRequest) XXX_Size() int {
+	return xxx_messageInfo_VerifyDirectoryRequest.Size(m)
+}
+func (m *VerifyDirectoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyDirectoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyDirectoryRequest proto.InternalMessageInfo
+
+type isVerifyDirectoryRequest_Contents interface {
+	isVerifyDirectoryRequest_Contents()
+}
+
+type VerifyDirectoryRequest_Options struct {
+	Options *SyncOptions `protobuf:"bytes,1,opt,name=options,proto3,oneof"`
+}
+
+type VerifyDirectoryRequest_Entry struct {
+	Entry *ManifestEntry `protobuf:"bytes,2,opt,name=entry,proto3,oneof"`
+}
+
+func (*VerifyDirectoryRequest_Options) isVerifyDirectoryRequest_Contents() {}
+
+func (*VerifyDirectoryRequest_Entry) isVerifyDirectoryRequest_Contents() {}
+
+func (m *VerifyDirectoryRequest) GetContents() isVerifyDirectoryRequest_Contents {
+	if m != nil {
+		return m.Contents
+	}
+	return nil
+}
+
+func (m *VerifyDirectoryRequest) GetOptions() *SyncOptions {
+	if x, ok := m.GetContents().(*VerifyDirectoryRequest_Options); ok {
+		return x.Options
+	}
+	return nil
+}
+
+func (m *VerifyDirectoryRequest) GetEntry() *ManifestEntry {
+	if x, ok := m.GetContents().(*VerifyDirectoryRequest_Entry); ok {
+		return x.Entry
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*VerifyDirectoryRequest) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*VerifyDirectoryRequest_Options)(nil),
+		(*VerifyDirectoryRequest_Entry)(nil),
+	}
+}
+
+type ManifestEntry struct {
+	Path                 string         `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Type                 FileEntry_Type `protobuf:"varint,2,opt,name=type,proto3,enum=idl.FileEntry_Type" json:"type,omitempty"`
+	Size                 int64          `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	Link                 string         `protobuf:"bytes,4,opt,name=link,proto3" json:"link,omitempty"`
+	Checksum             []byte         `protobuf:"bytes,5,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *ManifestEntry) Reset()         { *m = ManifestEntry{} }
+func (m *ManifestEntry) String() string { return proto.CompactTextString(m) }
+func (*ManifestEntry) ProtoMessage()    {}
+func (*ManifestEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{25}
+}
+
+func (m *ManifestEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ManifestEntry.Unmarshal(m, b)
+}
+func (m *ManifestEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ManifestEntry.Marshal(b, m, deterministic)
+}
+func (m *ManifestEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ManifestEntry.Merge(m, src)
+}
+func (m *ManifestEntry) XXX_Size() int {
+	return xxx_messageInfo_ManifestEntry.Size(m)
+}
+func (m *ManifestEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_ManifestEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ManifestEntry proto.InternalMessageInfo
+
+func (m *ManifestEntry) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *ManifestEntry) GetType() FileEntry_Type {
+	if m != nil {
+		return m.Type
+	}
+	return FileEntry_REGULAR
+}
+
+func (m *ManifestEntry) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+func (m *ManifestEntry) GetLink() string {
+	if m != nil {
+		return m.Link
+	}
+	return ""
+}
+
+func (m *ManifestEntry) GetChecksum() []byte {
+	if m != nil {
+		return m.Checksum
+	}
+	return nil
+}
+
+type VerifyDirectoryReply struct {
+	Mismatches           []*Mismatch `protobuf:"bytes,1,rep,name=mismatches,proto3" json:"mismatches,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *VerifyDirectoryReply) Reset()         { *m = VerifyDirectoryReply{} }
+func (m *VerifyDirectoryReply) String() string { return proto.CompactTextString(m) }
+func (*VerifyDirectoryReply) ProtoMessage()    {}
+func (*VerifyDirectoryReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{26}
+}
+
+func (m *VerifyDirectoryReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyDirectoryReply.Unmarshal(m, b)
+}
+func (m *VerifyDirectoryReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyDirectoryReply.Marshal(b, m, deterministic)
+}
+func (m *VerifyDirectoryReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyDirectoryReply.Merge(m, src)
+}
+func (m *VerifyDirectoryReply) XXX_Size() int {
+	return xxx_messageInfo_VerifyDirectoryReply.Size(m)
+}
+func (m *VerifyDirectoryReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyDirectoryReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyDirectoryReply proto.InternalMessageInfo
+
+func (m *VerifyDirectoryReply) GetMismatches() []*Mismatch {
+	if m != nil {
+		return m.Mismatches
+	}
+	return nil
+}
+
+type Mismatch struct {
+	Path                 string          `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Reason               Mismatch_Reason `protobuf:"varint,2,opt,name=reason,proto3,enum=idl.Mismatch_Reason" json:"reason,omitempty"`
+	Detail               string          `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *Mismatch) Reset()         { *m = Mismatch{} }
+func (m *Mismatch) String() string { return proto.CompactTextString(m) }
+func (*Mismatch) ProtoMessage()    {}
+func (*Mismatch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e73bb06acc917d8, []int{27}
+}
+
+func (m *Mismatch) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Mismatch.Unmarshal(m, b)
+}
+func (m *Mismatch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Mismatch.Marshal(b, m, deterministic)
+}
+func (m *Mismatch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Mismatch.Merge(m, src)
+}
+func (m *Mismatch) XXX_Size() int {
+	return xxx_messageInfo_Mismatch.Size(m)
+}
+func (m *Mismatch) XXX_DiscardUnknown() {
+	xxx_messageInfo_Mismatch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Mismatch proto.InternalMessageInfo
+
+func (m *Mismatch) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *Mismatch) GetReason() Mismatch_Reason {
+	if m != nil {
+		return m.Reason
+	}
+	return Mismatch_MISSING
+}
+
+func (m *Mismatch) GetDetail() string {
+	if m != nil {
+		return m.Detail
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterEnum("idl.FileEntry_Type", FileEntry_Type_name, FileEntry_Type_value)
+	proto.RegisterEnum("idl.Mismatch_Reason", Mismatch_Reason_name, Mismatch_Reason_value)
+	proto.RegisterType((*TablespaceInfo)(nil), "idl.TablespaceInfo")
+	proto.RegisterType((*UpgradePrimariesRequest)(nil), "idl.UpgradePrimariesRequest")
+	proto.RegisterType((*DataDirPair)(nil), "idl.DataDirPair")
+	proto.RegisterMapType((map[int32]*TablespaceInfo)(nil), "idl.DataDirPair.TablespacesEntry")
+	proto.RegisterType((*DeleteDataDirectoriesRequest)(nil), "idl.DeleteDataDirectoriesRequest")
+	proto.RegisterType((*DeleteDataDirectoriesReply)(nil), "idl.DeleteDataDirectoriesReply")
+	proto.RegisterType((*DeleteStateDirectoryRequest)(nil), "idl.DeleteStateDirectoryRequest")
+	proto.RegisterType((*DeleteStateDirectoryReply)(nil), "idl.DeleteStateDirectoryReply")
+	proto.RegisterType((*ArchiveLogDirectoryRequest)(nil), "idl.ArchiveLogDirectoryRequest")
+	proto.RegisterType((*ArchiveLogDirectoryReply)(nil), "idl.ArchiveLogDirectoryReply")
+	proto.RegisterType((*RenameDirectories)(nil), "idl.RenameDirectories")
+	proto.RegisterType((*RenameDirectoriesRequest)(nil), "idl.RenameDirectoriesRequest")
+	proto.RegisterType((*RenameDirectoriesReply)(nil), "idl.RenameDirectoriesReply")
+	proto.RegisterType((*CheckRenameDirectoriesReply)(nil), "idl.CheckRenameDirectoriesReply")
+	proto.RegisterType((*CheckPortsRequest)(nil), "idl.CheckPortsRequest")
+	proto.RegisterType((*CheckPortsReply)(nil), "idl.CheckPortsReply")
+	proto.RegisterType((*StopAgentRequest)(nil), "idl.StopAgentRequest")
+	proto.RegisterType((*StopAgentReply)(nil), "idl.StopAgentReply")
+	proto.RegisterType((*CheckSegmentDiskSpaceRequest)(nil), "idl.CheckSegmentDiskSpaceRequest")
+	proto.RegisterType((*CheckSegmentDiskSpaceRequest_MasterCopy)(nil), "idl.CheckSegmentDiskSpaceRequest.MasterCopy")
+	proto.RegisterType((*SyncDirectoryRequest)(nil), "idl.SyncDirectoryRequest")
+	proto.RegisterType((*SyncOptions)(nil), "idl.SyncOptions")
+	proto.RegisterType((*FileEntry)(nil), "idl.FileEntry")
+	proto.RegisterType((*SyncDirectoryReply)(nil), "idl.SyncDirectoryReply")
+	proto.RegisterType((*NeededFiles)(nil), "idl.NeededFiles")
+	proto.RegisterType((*SyncStats)(nil), "idl.SyncStats")
+	proto.RegisterType((*VerifyDirectoryRequest)(nil), "idl.VerifyDirectoryRequest")
+	proto.RegisterType((*ManifestEntry)(nil), "idl.ManifestEntry")
+	proto.RegisterType((*VerifyDirectoryReply)(nil), "idl.VerifyDirectoryReply")
+	proto.RegisterType((*Mismatch)(nil), "idl.Mismatch")
+}
+
+func init() { proto.RegisterFile("hub_to_agent.proto", fileDescriptor_9e73bb06acc917d8) }
+
+var fileDescriptor_9e73bb06acc917d8 = []byte{
+	// 1657 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x58, 0x5f, 0x6f, 0xdb, 0x46,
+	0x0c, 0xf7, 0xdf, 0xd8, 0xa6, 0xe3, 0xc4, 0xb9, 0xba, 0xa9, 0xaa, 0xa4, 0x9d, 0x2b, 0x0c, 0x6d,
+	0x5a, 0xa4, 0x41, 0x91, 0x15, 0xd8, 0x5a, 0x6c, 0x03, 0x92, 0x38, 0xad, 0xbd, 0xc5, 0x49, 0x7a,
+	0x4e, 0xba, 0xb5, 0xdb, 0x50, 0x28, 0xd6, 0xc5, 0x11, 0x22, 0x4b, 0x9e, 0x24, 0x77, 0xf5, 0x1e,
+	0x06, 0xec, 0x13, 0x6c, 0x8f, 0x7b, 0xde, 0xe7, 0xda, 0xcb, 0xbe, 0xc2, 0x3e, 0xc1, 0x40, 0x9e,
+	0x64, 0x4b, 0xb6, 0x9c, 0x02, 0xed, 0xdb, 0x91, 0xfc, 0x1d, 0x75, 0xe4, 0xfd, 0xc8, 0xa3, 0x0d,
+	0xec, 0x62, 0x78, 0xf6, 0xc6, 0x77, 0xde, 0xe8, 0x3d, 0x61, 0xfb, 0x5b, 0x03, 0xd7, 0xf1, 0x1d,
+	0x96, 0x35, 0x0d, 0x4b, 0xad, 0x76, 0x2d, 0x13, 0x0d, 0x17, 0xc3, 0x33, 0xa9, 0xd6, 0xce, 0x60,
+	0xe9, 0x44, 0x3f, 0xb3, 0x84, 0x37, 0xd0, 0xbb, 0xa2, 0x65, 0x9f, 0x3b, 0x8c, 0x41, 0xee, 0x50,
+	0xef, 0x0b, 0x25, 0x5b, 0x4f, 0x6f, 0x94, 0x38, 0xad, 0x99, 0x0a, 0xc5, 0x03, 0xa7, 0xab, 0xfb,
+	0xa6, 0x63, 0x2b, 0x39, 0xd2, 0x8f, 0x65, 0x56, 0x87, 0xf2, 0xa9, 0x27, 0xdc, 0x86, 0x38, 0x37,
+	0x6d, 0x61, 0x28, 0xf9, 0x7a, 0x7a, 0xa3, 0xc8, 0xa3, 0x2a, 0xed, 0x8f, 0x2c, 0xdc, 0x38, 0x1d,
+	0xf4, 0x5c, 0xdd, 0x10, 0xc7, 0xae, 0xd9, 0xd7, 0x5d, 0x53, 0x78, 0x5c, 0xfc, 0x3c, 0x14, 0x9e,
+	0xcf, 0x34, 0x58, 0xec, 0x38, 0x43, 0xb7, 0x2b, 0x76, 0x4d, 0xbb, 0x61, 0xba, 0x4a, 0x9a, 0xbc,
+	0xc7, 0x74, 0x88, 0x39, 0xd1, 0xdd, 0x9e, 0xf0, 0x03, 0x4c, 0x46, 0x62, 0xa2, 0x3a, 0xf6, 0x29,
+	0x54, 0xa4, 0xfc, 0x52, 0xb8, 0x1e, 0x1e, 0x53, 0x1e, 0x3f, 0xae, 0x64, 0x8f, 0x61, 0xb1, 0xa1,
+	0xfb, 0x7a, 0xc3, 0x74, 0x8f, 0x75, 0xd3, 0xf5, 0x94, 0x5c, 0x3d, 0xbb, 0x51, 0xde, 0xae, 0x6e,
+	0x99, 0x86, 0xb5, 0x15, 0x31, 0xf0, 0x18, 0x8a, 0xad, 0x43, 0x69, 0xef, 0x42, 0x74, 0x2f, 0x8f,
+	0x6c, 0x6b, 0x14, 0xc4, 0x37, 0x51, 0x04, 0xf1, 0x1f, 0x98, 0xf6, 0x65, 0xdb, 0x31, 0x84, 0xb2,
+	0x30, 0x8e, 0x3f, 0x54, 0xb1, 0x0d, 0x58, 0x6e, 0xeb, 0x9e, 0x2f, 0xdc, 0x5d, 0xbd, 0x7b, 0x39,
+	0x1c, 0x60, 0x08, 0x05, 0x3a, 0xdd, 0xb4, 0x9a, 0x7d, 0x0d, 0xea, 0xe4, 0x36, 0xbc, 0xb6, 0x3e,
+	0x18, 0x98, 0x76, 0xef, 0x99, 0x69, 0x89, 0x63, 0xdd, 0xbf, 0x50, 0x8a, 0xb4, 0xe9, 0x0a, 0x04,
+	0xbb, 0x0b, 0x4b, 0x6d, 0xfd, 0xdd, 0x9e, 0x63, 0x77, 0x87, 0xae, 0x2b, 0xec, 0xee, 0x48, 0x29,
+	0xd5, 0xd3, 0x1b, 0x79, 0x3e, 0xa5, 0xd5, 0xfe, 0xcd, 0x40, 0x39, 0x12, 0x22, 0x66, 0x4f, 0x66,
+	0x3c, 0x50, 0x06, 0xd7, 0x10, 0x57, 0x4e, 0x72, 0x1c, 0xa2, 0x32, 0xd1, 0x1c, 0x87, 0xa8, 0xdb,
+	0x00, 0x72, 0xdb, 0xb1, 0xe3, 0xfa, 0x74, 0x0d, 0x79, 0x1e, 0xd1, 0xa0, 0x5d, 0x6e, 0x20, 0x7b,
+	0x4e, 0xda, 0x27, 0x1a, 0xa6, 0x40, 0x61, 0xcf, 0xb1, 0x7d, 0x61, 0xfb, 0x94, 0xeb, 0x3c, 0x0f,
+	0x45, 0x64, 0x66, 0x63, 0xb7, 0xd5, 0xa0, 0x14, 0xe7, 0x39, 0xad, 0xd9, 0x1e, 0x94, 0x23, 0xf9,
+	0x50, 0x0a, 0x74, 0xa1, 0x77, 0xa6, 0x2f, 0x74, 0x2b, 0x82, 0xd9, 0xb7, 0x7d, 0x77, 0xc4, 0xa3,
+	0xbb, 0xd4, 0x0e, 0x54, 0xa7, 0x01, 0xac, 0x0a, 0xd9, 0x4b, 0x31, 0xa2, 0x44, 0xe4, 0x39, 0x2e,
+	0xd9, 0x7d, 0xc8, 0xbf, 0xd5, 0xad, 0xa1, 0xa0, 0xb0, 0xcb, 0xdb, 0xd7, 0xe8, 0x23, 0xf1, 0xe2,
+	0xe1, 0x12, 0xf1, 0x34, 0xf3, 0x45, 0x5a, 0x7b, 0x0a, 0xeb, 0x0d, 0x61, 0x09, 0x3f, 0x4c, 0x9f,
+	0xe8, 0xfa, 0x4e, 0x94, 0xf9, 0x2a, 0x14, 0x0d, 0xdd, 0xd7, 0x0d, 0xe4, 0x61, 0xba, 0x9e, 0xc5,
+	0x9a, 0x0a, 0x65, 0x6d, 0x1d, 0xd4, 0x39, 0x7b, 0x07, 0xd6, 0x48, 0xbb, 0x05, 0x6b, 0xd2, 0xda,
+	0xf1, 0x75, 0x5f, 0x84, 0xe6, 0x51, 0xe0, 0x58, 0x5b, 0x83, 0x9b, 0xc9, 0x66, 0xdc, 0x7b, 0x00,
+	0xea, 0x8e, 0xdb, 0xbd, 0x30, 0xdf, 0x8a, 0x03, 0xa7, 0x37, 0xbd, 0x95, 0xad, 0xc2, 0xc2, 0x91,
+	0x65, 0x4c, 0x08, 0x10, 0x48, 0xa8, 0x3f, 0x14, 0xbf, 0x4c, 0xae, 0x3c, 0x90, 0x34, 0x15, 0x94,
+	0x44, 0x6f, 0xf8, 0xa5, 0x1e, 0xac, 0x70, 0x61, 0xeb, 0x7d, 0x11, 0x39, 0x3f, 0x3a, 0x92, 0x54,
+	0x08, 0x3f, 0x20, 0x25, 0xd4, 0x4b, 0x0a, 0x84, 0x1f, 0x90, 0x12, 0x96, 0xbe, 0x74, 0x12, 0x58,
+	0xb3, 0x54, 0x5d, 0x31, 0x9d, 0xf6, 0x0c, 0x94, 0x99, 0x0f, 0x85, 0x01, 0x3d, 0x80, 0x5c, 0x23,
+	0x4c, 0x70, 0x79, 0x7b, 0x95, 0xae, 0x6c, 0x16, 0x4c, 0x18, 0x4d, 0x81, 0xd5, 0x59, 0x13, 0x85,
+	0xf2, 0x04, 0xd6, 0xa8, 0xde, 0x93, 0xcd, 0x78, 0x93, 0xc7, 0xae, 0x73, 0x66, 0x89, 0xfe, 0xf8,
+	0x26, 0x43, 0x59, 0xbb, 0x0f, 0x2b, 0xb4, 0x15, 0xa9, 0x3d, 0x3e, 0x55, 0x0d, 0xf2, 0x24, 0x13,
+	0xba, 0xc2, 0xa5, 0xa0, 0xdd, 0x83, 0xe5, 0x28, 0x14, 0x3d, 0xd7, 0x20, 0xdf, 0xb2, 0x4f, 0x3d,
+	0x11, 0x02, 0x49, 0xd0, 0x18, 0x54, 0x3b, 0xbe, 0x33, 0xd8, 0xc1, 0xee, 0x1e, 0x5e, 0x7a, 0x15,
+	0x96, 0x22, 0x3a, 0x3c, 0xf4, 0x3f, 0x39, 0x58, 0x27, 0x7f, 0x1d, 0xd1, 0xeb, 0x0b, 0xdb, 0x6f,
+	0x98, 0xde, 0x65, 0x07, 0x89, 0x1a, 0x9e, 0xe2, 0x31, 0x14, 0x5c, 0xb9, 0xa4, 0xcb, 0x28, 0x6f,
+	0xab, 0x94, 0x1e, 0xda, 0x33, 0x0d, 0xe6, 0x21, 0x34, 0x46, 0xdb, 0x4c, 0x9c, 0xb6, 0xd8, 0x0a,
+	0xfd, 0x48, 0x31, 0x2e, 0x90, 0x39, 0xaa, 0xc2, 0x06, 0xd5, 0x37, 0x5d, 0xd7, 0x71, 0x1b, 0xa1,
+	0x8f, 0x2c, 0x81, 0xa6, 0xb4, 0x6c, 0x13, 0x56, 0xa4, 0x66, 0xba, 0xb8, 0x4b, 0x7c, 0xd6, 0x80,
+	0x67, 0xb2, 0xc2, 0xfe, 0x9b, 0x23, 0x86, 0x8c, 0x65, 0x76, 0x0c, 0x8b, 0xfd, 0x48, 0x97, 0xa5,
+	0x9e, 0x52, 0xde, 0xde, 0x9c, 0x84, 0x3a, 0x27, 0x3d, 0x5b, 0xb2, 0x37, 0xef, 0x39, 0x83, 0x11,
+	0x8f, 0x79, 0x60, 0xaf, 0x61, 0x45, 0xca, 0xd1, 0xb3, 0x15, 0x3f, 0xc0, 0xed, 0xac, 0x1b, 0xf6,
+	0x23, 0x30, 0x9f, 0x58, 0x1d, 0x7d, 0x19, 0x94, 0xd2, 0x07, 0x38, 0x4f, 0xf0, 0xa3, 0x7e, 0x03,
+	0x30, 0x41, 0x60, 0x87, 0x33, 0xc6, 0x95, 0x8e, 0x4b, 0xa4, 0xdb, 0xd9, 0xc8, 0x17, 0x1e, 0x15,
+	0x61, 0x8e, 0x4b, 0x01, 0xb5, 0xe7, 0xa6, 0x25, 0x3c, 0x2a, 0xbe, 0x1c, 0x97, 0x82, 0xf6, 0x5f,
+	0x1a, 0x6a, 0x9d, 0x91, 0xdd, 0x9d, 0xe9, 0x21, 0x9b, 0x50, 0x70, 0x06, 0x38, 0x19, 0x78, 0x01,
+	0xad, 0xe4, 0xf3, 0x8a, 0xd8, 0x23, 0xa9, 0x6f, 0xa6, 0x78, 0x08, 0x61, 0x77, 0x21, 0x2f, 0xb0,
+	0xdf, 0x06, 0x4d, 0x75, 0x89, 0xb0, 0xf8, 0x9e, 0x51, 0x17, 0x6e, 0xa6, 0xb8, 0x34, 0xb3, 0x1a,
+	0xe4, 0x90, 0x66, 0x74, 0x86, 0xc5, 0x66, 0x8a, 0x93, 0xc4, 0xd6, 0xa1, 0xd8, 0xc5, 0x7c, 0x78,
+	0xc3, 0xbe, 0x92, 0x0b, 0x2c, 0x63, 0x0d, 0xd3, 0xa0, 0x8c, 0x9b, 0x4d, 0xe1, 0x35, 0x1c, 0x5b,
+	0xc8, 0x97, 0xbb, 0x99, 0xe2, 0x51, 0x25, 0xab, 0x03, 0x74, 0xe5, 0xf3, 0xe2, 0x1d, 0x9d, 0xd3,
+	0xcb, 0x52, 0x6a, 0xa6, 0x78, 0x44, 0xb7, 0x0b, 0x50, 0x0c, 0x25, 0x6d, 0x04, 0xe5, 0x48, 0x1c,
+	0x38, 0x18, 0xc8, 0x2c, 0x4f, 0x3a, 0xe6, 0x44, 0x81, 0x3d, 0xcd, 0xa0, 0x3e, 0x4c, 0xb1, 0x15,
+	0x79, 0x20, 0xe1, 0x03, 0x27, 0xde, 0x75, 0xad, 0xa1, 0x21, 0x02, 0xf2, 0x87, 0x22, 0xf2, 0x38,
+	0x16, 0x4e, 0x71, 0x12, 0x8c, 0xf6, 0x7b, 0x06, 0x4a, 0xe3, 0xbc, 0xe0, 0x53, 0x38, 0xc0, 0x91,
+	0x40, 0x7e, 0x94, 0xd6, 0xec, 0x1e, 0xe4, 0xfc, 0xd1, 0x40, 0x7e, 0x6d, 0x29, 0x78, 0x9e, 0xc6,
+	0x3b, 0xb6, 0x4e, 0x46, 0x03, 0xc1, 0x09, 0x80, 0x9b, 0xfb, 0x8e, 0x21, 0x27, 0xbc, 0x0a, 0xa7,
+	0x35, 0x1e, 0xaa, 0xef, 0x18, 0x27, 0x66, 0x5f, 0x56, 0x50, 0x96, 0x87, 0x22, 0xa2, 0x3d, 0xf3,
+	0x57, 0x99, 0xbe, 0x2c, 0xa7, 0x35, 0xea, 0xb0, 0xc0, 0x64, 0xbe, 0x38, 0xad, 0x91, 0x4e, 0x43,
+	0xd3, 0xa0, 0xc9, 0xa6, 0xc2, 0x71, 0x89, 0x9a, 0x9e, 0x69, 0x50, 0x69, 0x54, 0x38, 0x2e, 0xb5,
+	0xaf, 0x20, 0x87, 0xe7, 0x60, 0x65, 0x28, 0xf0, 0xfd, 0xe7, 0xa7, 0x07, 0x3b, 0xbc, 0x9a, 0x62,
+	0x15, 0x28, 0x35, 0x5a, 0x7c, 0x7f, 0xef, 0xe4, 0x88, 0xbf, 0xaa, 0xa6, 0xd1, 0xd6, 0x79, 0xd5,
+	0x3e, 0x68, 0x1d, 0x7e, 0x5b, 0xcd, 0xb0, 0x45, 0x28, 0x36, 0x77, 0x78, 0x83, 0xa4, 0xac, 0xe6,
+	0x03, 0x9b, 0xa2, 0x1c, 0x36, 0xc9, 0x07, 0xb0, 0x60, 0x0b, 0x61, 0x08, 0x23, 0xc6, 0xb7, 0x43,
+	0x52, 0x61, 0xfc, 0xc8, 0xb7, 0x00, 0x81, 0x74, 0xf3, 0x7c, 0xdd, 0xf7, 0x62, 0x74, 0x43, 0x9f,
+	0xf8, 0x56, 0x22, 0x50, 0x9a, 0x63, 0x97, 0xfe, 0x39, 0x94, 0x23, 0xce, 0xb0, 0x1c, 0x30, 0xdd,
+	0x61, 0xab, 0x97, 0x02, 0x66, 0xc4, 0x40, 0x92, 0xc9, 0xab, 0xa6, 0xb5, 0xf6, 0x02, 0x4a, 0x63,
+	0xd7, 0x93, 0x2a, 0x4a, 0x53, 0x1e, 0xa5, 0x10, 0xaf, 0xb8, 0x6c, 0x58, 0x71, 0x0a, 0x14, 0x24,
+	0x57, 0x0c, 0xba, 0xa3, 0x2c, 0x0f, 0x45, 0xed, 0x37, 0x58, 0x7d, 0x29, 0x5c, 0xf3, 0x7c, 0xf4,
+	0x91, 0x65, 0xf7, 0x20, 0x5e, 0x76, 0x8c, 0xb0, 0x6d, 0xdd, 0x36, 0xcf, 0x85, 0xe7, 0xc7, 0x4b,
+	0x2f, 0x96, 0x8b, 0x3f, 0xd3, 0x50, 0x89, 0xc1, 0x3e, 0x9a, 0x89, 0xc4, 0xad, 0x6c, 0x02, 0xb7,
+	0x72, 0x11, 0x6e, 0x45, 0x0b, 0x03, 0x79, 0xb8, 0x18, 0x29, 0x8c, 0x7d, 0xa8, 0xcd, 0xa4, 0x04,
+	0x69, 0xf1, 0x10, 0xa0, 0x6f, 0x7a, 0x7d, 0xdd, 0xef, 0x5e, 0x88, 0x70, 0x00, 0xa8, 0xc8, 0x38,
+	0x03, 0x35, 0x8f, 0x00, 0xb4, 0xbf, 0xd2, 0x50, 0x0c, 0x0d, 0x89, 0x41, 0x6d, 0xc2, 0x82, 0x2b,
+	0x74, 0xcf, 0xb1, 0x83, 0xb0, 0x6a, 0x31, 0x5f, 0x5b, 0x9c, 0x6c, 0x3c, 0xc0, 0xc8, 0xe2, 0xf7,
+	0x75, 0xd3, 0x0a, 0x7e, 0x88, 0x04, 0x92, 0xb6, 0x0d, 0x0b, 0x12, 0x89, 0x3c, 0x6f, 0xb7, 0x3a,
+	0x9d, 0xd6, 0xe1, 0xf3, 0x6a, 0x0a, 0x85, 0xbd, 0xe6, 0xce, 0xe1, 0xf3, 0xfd, 0x46, 0x35, 0xcd,
+	0x96, 0x00, 0xf6, 0xbf, 0x3f, 0xe1, 0x3b, 0x87, 0xfb, 0x47, 0xa7, 0x9d, 0x6a, 0x66, 0xfb, 0xef,
+	0x02, 0xe4, 0xe9, 0x61, 0x67, 0x0f, 0x21, 0xdf, 0x14, 0x96, 0xe5, 0xb0, 0x15, 0xfa, 0x38, 0xad,
+	0x03, 0x02, 0xa8, 0xcb, 0x51, 0x15, 0x0e, 0x00, 0x29, 0x76, 0x04, 0x4b, 0xf1, 0xd7, 0x9c, 0xdd,
+	0x79, 0xef, 0x1b, 0xa2, 0x2a, 0x89, 0x53, 0x80, 0x74, 0xb8, 0x0b, 0xd5, 0xe9, 0x1f, 0x72, 0x6c,
+	0x9d, 0xf0, 0x73, 0x7e, 0xdf, 0xa9, 0x8b, 0x32, 0x4b, 0xc2, 0xf3, 0xf4, 0x9e, 0xd0, 0x52, 0x8f,
+	0xd2, 0xec, 0x45, 0xd2, 0x5c, 0x78, 0x6b, 0xce, 0x64, 0x16, 0x78, 0x59, 0x9b, 0x67, 0x96, 0xc7,
+	0xfa, 0x01, 0x56, 0x93, 0xe7, 0xb3, 0xf7, 0xf9, 0xad, 0x4f, 0x62, 0x9d, 0xeb, 0xfc, 0x09, 0x94,
+	0xc6, 0x93, 0x15, 0xbb, 0x2e, 0x8b, 0x6a, 0x6a, 0xfa, 0x52, 0xaf, 0x4d, 0xab, 0xe5, 0xd6, 0x9f,
+	0xe0, 0x7a, 0xe2, 0x18, 0x1f, 0x5c, 0xc3, 0x55, 0x3f, 0x0f, 0xd4, 0x4f, 0xae, 0x82, 0x48, 0xf7,
+	0xaf, 0xa1, 0x96, 0x34, 0xe8, 0xb3, 0x7a, 0x64, 0x6b, 0xe2, 0x4f, 0x04, 0xf5, 0xf6, 0x15, 0x08,
+	0xe9, 0xfb, 0x3b, 0xb8, 0x96, 0x30, 0xd9, 0x33, 0x79, 0xaa, 0xf9, 0xbf, 0x20, 0xd4, 0x5b, 0xf3,
+	0x01, 0xd2, 0x71, 0x0b, 0x2a, 0xb1, 0x1e, 0xce, 0x6e, 0x8e, 0xfb, 0xd4, 0x8c, 0xb3, 0x1b, 0x49,
+	0x26, 0x72, 0xb3, 0x91, 0x7e, 0x94, 0x66, 0x6d, 0x58, 0x9e, 0xaa, 0x7c, 0x26, 0x89, 0x92, 0xdc,
+	0x22, 0xd5, 0x9b, 0xc9, 0xc6, 0xc0, 0x21, 0xfb, 0x12, 0x60, 0x32, 0x7f, 0xb3, 0xd5, 0x09, 0x35,
+	0xa2, 0xb3, 0xbb, 0x5a, 0x9b, 0xd1, 0xd3, 0xfe, 0xb3, 0x05, 0xfa, 0x3f, 0xe5, 0xb3, 0xff, 0x07,
+	0x00, 0xa4, 0xb2, 0xa1, 0x34, 0x7c, 0x11, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// AgentClient is the client API for Agent service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AgentClient interface {
+	Hello(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloReply, error)
+	CheckDiskSpace(ctx context.Context, in *CheckSegmentDiskSpaceRequest, opts ...grpc.CallOption) (*CheckDiskSpaceReply, error)
+	UpgradePrimaries(ctx context.Context, in *UpgradePrimariesRequest, opts ...grpc.CallOption) (Agent_UpgradePrimariesClient, error)
+	RenameDirectories(ctx context.Context, in *RenameDirectoriesRequest, opts ...grpc.CallOption) (*RenameDirectoriesReply, error)
+	CheckRenameDirectories(ctx context.Context, in *RenameDirectoriesRequest, opts ...grpc.CallOption) (*CheckRenameDirectoriesReply, error)
+	StopAgent(ctx context.Context, in *StopAgentRequest, opts ...grpc.CallOption) (*StopAgentReply, error)
+	DeleteDataDirectories(ctx context.Context, in *DeleteDataDirectoriesRequest, opts ...grpc.CallOption) (*DeleteDataDirectoriesReply, error)
+	DeleteStateDirectory(ctx context.Context, in *DeleteStateDirectoryRequest, opts ...grpc.CallOption) (*DeleteStateDirectoryReply, error)
+	ArchiveLogDirectory(ctx context.Context, in *ArchiveLogDirectoryRequest, opts ...grpc.CallOption) (*ArchiveLogDirectoryReply, error)
+	SyncDirectory(ctx context.Context, opts ...grpc.CallOption) (Agent_SyncDirectoryClient, error)
+	VerifyDirectory(ctx context.Context, opts ...grpc.CallOption) (Agent_VerifyDirectoryClient, error)
+	CheckPorts(ctx context.Context, in *CheckPortsRequest, opts ...grpc.CallOption) (*CheckPortsReply, error)
+}
+
+type agentClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAgentClient(cc *grpc.ClientConn) AgentClient {
+	return &agentClient{cc}
+}
+
+func (c *agentClient) Hello(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloReply, error) {
+	out := new(HelloReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/Hello", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) CheckDiskSpace(ctx context.Context, in *CheckSegmentDiskSpaceRequest, opts ...grpc.CallOption) (*CheckDiskSpaceReply, error) {
+	out := new(CheckDiskSpaceReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/CheckDiskSpace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) UpgradePrimaries(ctx context.Context, in *UpgradePrimariesRequest, opts ...grpc.CallOption) (Agent_UpgradePrimariesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Agent_serviceDesc.Streams[0], "/idl.Agent/UpgradePrimaries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentUpgradePrimariesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Agent_UpgradePrimariesClient interface {
+	Recv() (*Message, error)
+	grpc.ClientStream
+}
+
+type agentUpgradePrimariesClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentUpgradePrimariesClient) Recv() (*Message, error) {
+	m := new(Message)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentClient) RenameDirectories(ctx context.Context, in *RenameDirectoriesRequest, opts ...grpc.CallOption) (*RenameDirectoriesReply, error) {
+	out := new(RenameDirectoriesReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/RenameDirectories", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) CheckRenameDirectories(ctx context.Context, in *RenameDirectoriesRequest, opts ...grpc.CallOption) (*CheckRenameDirectoriesReply, error) {
+	out := new(CheckRenameDirectoriesReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/CheckRenameDirectories", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) StopAgent(ctx context.Context, in *StopAgentRequest, opts ...grpc.CallOption) (*StopAgentReply, error) {
+	out := new(StopAgentReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/StopAgent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) DeleteDataDirectories(ctx context.Context, in *DeleteDataDirectoriesRequest, opts ...grpc.CallOption) (*DeleteDataDirectoriesReply, error) {
+	out := new(DeleteDataDirectoriesReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/DeleteDataDirectories", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) DeleteStateDirectory(ctx context.Context, in *DeleteStateDirectoryRequest, opts ...grpc.CallOption) (*DeleteStateDirectoryReply, error) {
+	out := new(DeleteStateDirectoryReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/DeleteStateDirectory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) ArchiveLogDirectory(ctx context.Context, in *ArchiveLogDirectoryRequest, opts ...grpc.CallOption) (*ArchiveLogDirectoryReply, error) {
+	out := new(ArchiveLogDirectoryReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/ArchiveLogDirectory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) SyncDirectory(ctx context.Context, opts ...grpc.CallOption) (Agent_SyncDirectoryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Agent_serviceDesc.Streams[1], "/idl.Agent/SyncDirectory", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentSyncDirectoryClient{stream}
+	return x, nil
+}
+
+type Agent_SyncDirectoryClient interface {
+	Send(*SyncDirectoryRequest) error
+	Recv() (*SyncDirectoryReply, error)
+	grpc.ClientStream
+}
+
+type agentSyncDirectoryClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentSyncDirectoryClient) Send(m *SyncDirectoryRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *agentSyncDirectoryClient) Recv() (*SyncDirectoryReply, error) {
+	m := new(SyncDirectoryReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentClient) VerifyDirectory(ctx context.Context, opts ...grpc.CallOption) (Agent_VerifyDirectoryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Agent_serviceDesc.Streams[2], "/idl.Agent/VerifyDirectory", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentVerifyDirectoryClient{stream}
+	return x, nil
+}
+
+type Agent_VerifyDirectoryClient interface {
+	Send(*VerifyDirectoryRequest) error
+	CloseAndRecv() (*VerifyDirectoryReply, error)
+	grpc.ClientStream
+}
+
+type agentVerifyDirectoryClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentVerifyDirectoryClient) Send(m *VerifyDirectoryRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *agentVerifyDirectoryClient) CloseAndRecv() (*VerifyDirectoryReply, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(VerifyDirectoryReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentClient) CheckPorts(ctx context.Context, in *CheckPortsRequest, opts ...grpc.CallOption) (*CheckPortsReply, error) {
+	out := new(CheckPortsReply)
+	err := c.cc.Invoke(ctx, "/idl.Agent/CheckPorts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AgentServer is the server API for Agent service.
+type AgentServer interface {
+	Hello(context.Context, *HelloRequest) (*HelloReply, error)
+	CheckDiskSpace(context.Context, *CheckSegmentDiskSpaceRequest) (*CheckDiskSpaceReply, error)
+	UpgradePrimaries(*UpgradePrimariesRequest, Agent_UpgradePrimariesServer) error
+	RenameDirectories(context.Context, *RenameDirectoriesRequest) (*RenameDirectoriesReply, error)
+	CheckRenameDirectories(context.Context, *RenameDirectoriesRequest) (*CheckRenameDirectoriesReply, error)
+	StopAgent(context.Context, *StopAgentRequest) (*StopAgentReply, error)
+	DeleteDataDirectories(context.Context, *DeleteDataDirectoriesRequest) (*DeleteDataDirectoriesReply, error)
+	DeleteStateDirectory(context.Context, *DeleteStateDirectoryRequest) (*DeleteStateDirectoryReply, error)
+	ArchiveLogDirectory(context.Context, *ArchiveLogDirectoryRequest) (*ArchiveLogDirectoryReply, error)
+	SyncDirectory(Agent_SyncDirectoryServer) error
+	VerifyDirectory(Agent_VerifyDirectoryServer) error
+	CheckPorts(context.Context, *CheckPortsRequest) (*CheckPortsReply, error)
+}
+
+// UnimplementedAgentServer can be embedded to have forward compatible implementations.
+type UnimplementedAgentServer struct {
+}
+
+func (*UnimplementedAgentServer) Hello(ctx context.Context, req *HelloRequest) (*HelloReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hello not implemented")
+}
+func (*UnimplementedAgentServer) CheckDiskSpace(ctx context.Context, req *CheckSegmentDiskSpaceRequest) (*CheckDiskSpaceReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckDiskSpace not implemented")
+}
+func (*UnimplementedAgentServer) UpgradePrimaries(req *UpgradePrimariesRequest, srv Agent_UpgradePrimariesServer) error {
+	return status.Errorf(codes.Unimplemented, "method UpgradePrimaries not implemented")
 }
 func (*UnimplementedAgentServer) RenameDirectories(ctx context.Context, req *RenameDirectoriesRequest) (*RenameDirectoriesReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RenameDirectories not implemented")
 }
+func (*UnimplementedAgentServer) CheckRenameDirectories(ctx context.Context, req *RenameDirectoriesRequest) (*CheckRenameDirectoriesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckRenameDirectories not implemented")
+}
 func (*UnimplementedAgentServer) StopAgent(ctx context.Context, req *StopAgentRequest) (*StopAgentReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StopAgent not implemented")
 }
@@ -941,11 +2151,38 @@ func (*UnimplementedAgentServer) DeleteStateDirectory(ctx context.Context, req *
 func (*UnimplementedAgentServer) ArchiveLogDirectory(ctx context.Context, req *ArchiveLogDirectoryRequest) (*ArchiveLogDirectoryReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ArchiveLogDirectory not implemented")
 }
+func (*UnimplementedAgentServer) SyncDirectory(srv Agent_SyncDirectoryServer) error {
+	return status.Errorf(codes.Unimplemented, "method SyncDirectory not implemented")
+}
+func (*UnimplementedAgentServer) VerifyDirectory(srv Agent_VerifyDirectoryServer) error {
+	return status.Errorf(codes.Unimplemented, "method VerifyDirectory not implemented")
+}
+func (*UnimplementedAgentServer) CheckPorts(ctx context.Context, req *CheckPortsRequest) (*CheckPortsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckPorts not implemented")
+}
 
 func RegisterAgentServer(s *grpc.Server, srv AgentServer) {
 	s.RegisterService(&_Agent_serviceDesc, srv)
 }
 
+func _Agent_Hello_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HelloRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).Hello(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.Agent/Hello",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).Hello(ctx, req.(*HelloRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Agent_CheckDiskSpace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CheckSegmentDiskSpaceRequest)
 	if err := dec(in); err != nil {
@@ -964,38 +2201,59 @@ func _Agent_CheckDiskSpace_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Agent_UpgradePrimaries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UpgradePrimariesRequest)
+func _Agent_UpgradePrimaries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(UpgradePrimariesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServer).UpgradePrimaries(m, &agentUpgradePrimariesServer{stream})
+}
+
+type Agent_UpgradePrimariesServer interface {
+	Send(*Message) error
+	grpc.ServerStream
+}
+
+type agentUpgradePrimariesServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentUpgradePrimariesServer) Send(m *Message) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Agent_RenameDirectories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameDirectoriesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AgentServer).UpgradePrimaries(ctx, in)
+		return srv.(AgentServer).RenameDirectories(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/idl.Agent/UpgradePrimaries",
+		FullMethod: "/idl.Agent/RenameDirectories",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AgentServer).UpgradePrimaries(ctx, req.(*UpgradePrimariesRequest))
+		return srv.(AgentServer).RenameDirectories(ctx, req.(*RenameDirectoriesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Agent_RenameDirectories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _Agent_CheckRenameDirectories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RenameDirectoriesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AgentServer).RenameDirectories(ctx, in)
+		return srv.(AgentServer).CheckRenameDirectories(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/idl.Agent/RenameDirectories",
+		FullMethod: "/idl.Agent/CheckRenameDirectories",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AgentServer).RenameDirectories(ctx, req.(*RenameDirectoriesRequest))
+		return srv.(AgentServer).CheckRenameDirectories(ctx, req.(*RenameDirectoriesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -1072,22 +2330,96 @@ func _Agent_ArchiveLogDirectory_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Agent_SyncDirectory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AgentServer).SyncDirectory(&agentSyncDirectoryServer{stream})
+}
+
+type Agent_SyncDirectoryServer interface {
+	Send(*SyncDirectoryReply) error
+	Recv() (*SyncDirectoryRequest, error)
+	grpc.ServerStream
+}
+
+type agentSyncDirectoryServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentSyncDirectoryServer) Send(m *SyncDirectoryReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *agentSyncDirectoryServer) Recv() (*SyncDirectoryRequest, error) {
+	m := new(SyncDirectoryRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Agent_VerifyDirectory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AgentServer).VerifyDirectory(&agentVerifyDirectoryServer{stream})
+}
+
+type Agent_VerifyDirectoryServer interface {
+	SendAndClose(*VerifyDirectoryReply) error
+	Recv() (*VerifyDirectoryRequest, error)
+	grpc.ServerStream
+}
+
+type agentVerifyDirectoryServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentVerifyDirectoryServer) SendAndClose(m *VerifyDirectoryReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *agentVerifyDirectoryServer) Recv() (*VerifyDirectoryRequest, error) {
+	m := new(VerifyDirectoryRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Agent_CheckPorts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckPortsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).CheckPorts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/idl.Agent/CheckPorts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).CheckPorts(ctx, req.(*CheckPortsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Agent_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "idl.Agent",
 	HandlerType: (*AgentServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "CheckDiskSpace",
-			Handler:    _Agent_CheckDiskSpace_Handler,
+			MethodName: "Hello",
+			Handler:    _Agent_Hello_Handler,
 		},
 		{
-			MethodName: "UpgradePrimaries",
-			Handler:    _Agent_UpgradePrimaries_Handler,
+			MethodName: "CheckDiskSpace",
+			Handler:    _Agent_CheckDiskSpace_Handler,
 		},
 		{
 			MethodName: "RenameDirectories",
 			Handler:    _Agent_RenameDirectories_Handler,
 		},
+		{
+			MethodName: "CheckRenameDirectories",
+			Handler:    _Agent_CheckRenameDirectories_Handler,
+		},
 		{
 			MethodName: "StopAgent",
 			Handler:    _Agent_StopAgent_Handler,
@@ -1104,7 +2436,28 @@ var _Agent_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ArchiveLogDirectory",
 			Handler:    _Agent_ArchiveLogDirectory_Handler,
 		},
+		{
+			MethodName: "CheckPorts",
+			Handler:    _Agent_CheckPorts_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UpgradePrimaries",
+			Handler:       _Agent_UpgradePrimaries_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SyncDirectory",
+			Handler:       _Agent_SyncDirectory_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "VerifyDirectory",
+			Handler:       _Agent_VerifyDirectory_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "hub_to_agent.proto",
 }
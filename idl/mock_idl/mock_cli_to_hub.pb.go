@@ -36,6 +36,26 @@ func (m *MockCliToHubClient) EXPECT() *MockCliToHubClientMockRecorder {
 	return m.recorder
 }
 
+// Cancel mocks base method
+func (m *MockCliToHubClient) Cancel(arg0 context.Context, arg1 *idl.CancelRequest, arg2 ...grpc.CallOption) (*idl.CancelReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Cancel", varargs...)
+	ret0, _ := ret[0].(*idl.CancelReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cancel indicates an expected call of Cancel
+func (mr *MockCliToHubClientMockRecorder) Cancel(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockCliToHubClient)(nil).Cancel), varargs...)
+}
+
 // CheckDiskSpace mocks base method
 func (m *MockCliToHubClient) CheckDiskSpace(arg0 context.Context, arg1 *idl.CheckDiskSpaceRequest, arg2 ...grpc.CallOption) (*idl.CheckDiskSpaceReply, error) {
 	m.ctrl.T.Helper()
@@ -96,6 +116,26 @@ func (mr *MockCliToHubClientMockRecorder) Finalize(arg0, arg1 interface{}, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finalize", reflect.TypeOf((*MockCliToHubClient)(nil).Finalize), varargs...)
 }
 
+// GetAgentHealth mocks base method
+func (m *MockCliToHubClient) GetAgentHealth(arg0 context.Context, arg1 *idl.GetAgentHealthRequest, arg2 ...grpc.CallOption) (*idl.GetAgentHealthReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetAgentHealth", varargs...)
+	ret0, _ := ret[0].(*idl.GetAgentHealthReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAgentHealth indicates an expected call of GetAgentHealth
+func (mr *MockCliToHubClientMockRecorder) GetAgentHealth(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAgentHealth", reflect.TypeOf((*MockCliToHubClient)(nil).GetAgentHealth), varargs...)
+}
+
 // GetConfig mocks base method
 func (m *MockCliToHubClient) GetConfig(arg0 context.Context, arg1 *idl.GetConfigRequest, arg2 ...grpc.CallOption) (*idl.GetConfigReply, error) {
 	m.ctrl.T.Helper()
@@ -116,6 +156,46 @@ func (mr *MockCliToHubClientMockRecorder) GetConfig(arg0, arg1 interface{}, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockCliToHubClient)(nil).GetConfig), varargs...)
 }
 
+// GetStatus mocks base method
+func (m *MockCliToHubClient) GetStatus(arg0 context.Context, arg1 *idl.GetStatusRequest, arg2 ...grpc.CallOption) (*idl.GetStatusReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetStatus", varargs...)
+	ret0, _ := ret[0].(*idl.GetStatusReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatus indicates an expected call of GetStatus
+func (mr *MockCliToHubClientMockRecorder) GetStatus(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatus", reflect.TypeOf((*MockCliToHubClient)(nil).GetStatus), varargs...)
+}
+
+// Hello mocks base method
+func (m *MockCliToHubClient) Hello(arg0 context.Context, arg1 *idl.HelloRequest, arg2 ...grpc.CallOption) (*idl.HelloReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Hello", varargs...)
+	ret0, _ := ret[0].(*idl.HelloReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Hello indicates an expected call of Hello
+func (mr *MockCliToHubClientMockRecorder) Hello(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hello", reflect.TypeOf((*MockCliToHubClient)(nil).Hello), varargs...)
+}
+
 // Initialize mocks base method
 func (m *MockCliToHubClient) Initialize(arg0 context.Context, arg1 *idl.InitializeRequest, arg2 ...grpc.CallOption) (idl.CliToHub_InitializeClient, error) {
 	m.ctrl.T.Helper()
@@ -156,6 +236,46 @@ func (mr *MockCliToHubClientMockRecorder) InitializeCreateCluster(arg0, arg1 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitializeCreateCluster", reflect.TypeOf((*MockCliToHubClient)(nil).InitializeCreateCluster), varargs...)
 }
 
+// Plan mocks base method
+func (m *MockCliToHubClient) Plan(arg0 context.Context, arg1 *idl.PlanRequest, arg2 ...grpc.CallOption) (*idl.PlanReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Plan", varargs...)
+	ret0, _ := ret[0].(*idl.PlanReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Plan indicates an expected call of Plan
+func (mr *MockCliToHubClientMockRecorder) Plan(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Plan", reflect.TypeOf((*MockCliToHubClient)(nil).Plan), varargs...)
+}
+
+// Recover mocks base method
+func (m *MockCliToHubClient) Recover(arg0 context.Context, arg1 *idl.RecoverRequest, arg2 ...grpc.CallOption) (*idl.RecoverReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Recover", varargs...)
+	ret0, _ := ret[0].(*idl.RecoverReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recover indicates an expected call of Recover
+func (mr *MockCliToHubClientMockRecorder) Recover(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recover", reflect.TypeOf((*MockCliToHubClient)(nil).Recover), varargs...)
+}
+
 // RestartAgents mocks base method
 func (m *MockCliToHubClient) RestartAgents(arg0 context.Context, arg1 *idl.RestartAgentsRequest, arg2 ...grpc.CallOption) (*idl.RestartAgentsReply, error) {
 	m.ctrl.T.Helper()
@@ -196,6 +316,26 @@ func (mr *MockCliToHubClientMockRecorder) Revert(arg0, arg1 interface{}, arg2 ..
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revert", reflect.TypeOf((*MockCliToHubClient)(nil).Revert), varargs...)
 }
 
+// RunCheck mocks base method
+func (m *MockCliToHubClient) RunCheck(arg0 context.Context, arg1 *idl.RunCheckRequest, arg2 ...grpc.CallOption) (*idl.RunCheckReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RunCheck", varargs...)
+	ret0, _ := ret[0].(*idl.RunCheckReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunCheck indicates an expected call of RunCheck
+func (mr *MockCliToHubClientMockRecorder) RunCheck(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCheck", reflect.TypeOf((*MockCliToHubClient)(nil).RunCheck), varargs...)
+}
+
 // SetConfig mocks base method
 func (m *MockCliToHubClient) SetConfig(arg0 context.Context, arg1 *idl.SetConfigRequest, arg2 ...grpc.CallOption) (*idl.SetConfigReply, error) {
 	m.ctrl.T.Helper()
@@ -259,6 +399,21 @@ func (m *MockCliToHubServer) EXPECT() *MockCliToHubServerMockRecorder {
 	return m.recorder
 }
 
+// Cancel mocks base method
+func (m *MockCliToHubServer) Cancel(arg0 context.Context, arg1 *idl.CancelRequest) (*idl.CancelReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", arg0, arg1)
+	ret0, _ := ret[0].(*idl.CancelReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cancel indicates an expected call of Cancel
+func (mr *MockCliToHubServerMockRecorder) Cancel(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockCliToHubServer)(nil).Cancel), arg0, arg1)
+}
+
 // CheckDiskSpace mocks base method
 func (m *MockCliToHubServer) CheckDiskSpace(arg0 context.Context, arg1 *idl.CheckDiskSpaceRequest) (*idl.CheckDiskSpaceReply, error) {
 	m.ctrl.T.Helper()
@@ -302,6 +457,21 @@ func (mr *MockCliToHubServerMockRecorder) Finalize(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finalize", reflect.TypeOf((*MockCliToHubServer)(nil).Finalize), arg0, arg1)
 }
 
+// GetAgentHealth mocks base method
+func (m *MockCliToHubServer) GetAgentHealth(arg0 context.Context, arg1 *idl.GetAgentHealthRequest) (*idl.GetAgentHealthReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAgentHealth", arg0, arg1)
+	ret0, _ := ret[0].(*idl.GetAgentHealthReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAgentHealth indicates an expected call of GetAgentHealth
+func (mr *MockCliToHubServerMockRecorder) GetAgentHealth(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAgentHealth", reflect.TypeOf((*MockCliToHubServer)(nil).GetAgentHealth), arg0, arg1)
+}
+
 // GetConfig mocks base method
 func (m *MockCliToHubServer) GetConfig(arg0 context.Context, arg1 *idl.GetConfigRequest) (*idl.GetConfigReply, error) {
 	m.ctrl.T.Helper()
@@ -317,6 +487,36 @@ func (mr *MockCliToHubServerMockRecorder) GetConfig(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockCliToHubServer)(nil).GetConfig), arg0, arg1)
 }
 
+// GetStatus mocks base method
+func (m *MockCliToHubServer) GetStatus(arg0 context.Context, arg1 *idl.GetStatusRequest) (*idl.GetStatusReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatus", arg0, arg1)
+	ret0, _ := ret[0].(*idl.GetStatusReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatus indicates an expected call of GetStatus
+func (mr *MockCliToHubServerMockRecorder) GetStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatus", reflect.TypeOf((*MockCliToHubServer)(nil).GetStatus), arg0, arg1)
+}
+
+// Hello mocks base method
+func (m *MockCliToHubServer) Hello(arg0 context.Context, arg1 *idl.HelloRequest) (*idl.HelloReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Hello", arg0, arg1)
+	ret0, _ := ret[0].(*idl.HelloReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Hello indicates an expected call of Hello
+func (mr *MockCliToHubServerMockRecorder) Hello(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hello", reflect.TypeOf((*MockCliToHubServer)(nil).Hello), arg0, arg1)
+}
+
 // Initialize mocks base method
 func (m *MockCliToHubServer) Initialize(arg0 *idl.InitializeRequest, arg1 idl.CliToHub_InitializeServer) error {
 	m.ctrl.T.Helper()
@@ -345,6 +545,36 @@ func (mr *MockCliToHubServerMockRecorder) InitializeCreateCluster(arg0, arg1 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitializeCreateCluster", reflect.TypeOf((*MockCliToHubServer)(nil).InitializeCreateCluster), arg0, arg1)
 }
 
+// Plan mocks base method
+func (m *MockCliToHubServer) Plan(arg0 context.Context, arg1 *idl.PlanRequest) (*idl.PlanReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Plan", arg0, arg1)
+	ret0, _ := ret[0].(*idl.PlanReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Plan indicates an expected call of Plan
+func (mr *MockCliToHubServerMockRecorder) Plan(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Plan", reflect.TypeOf((*MockCliToHubServer)(nil).Plan), arg0, arg1)
+}
+
+// Recover mocks base method
+func (m *MockCliToHubServer) Recover(arg0 context.Context, arg1 *idl.RecoverRequest) (*idl.RecoverReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recover", arg0, arg1)
+	ret0, _ := ret[0].(*idl.RecoverReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recover indicates an expected call of Recover
+func (mr *MockCliToHubServerMockRecorder) Recover(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recover", reflect.TypeOf((*MockCliToHubServer)(nil).Recover), arg0, arg1)
+}
+
 // RestartAgents mocks base method
 func (m *MockCliToHubServer) RestartAgents(arg0 context.Context, arg1 *idl.RestartAgentsRequest) (*idl.RestartAgentsReply, error) {
 	m.ctrl.T.Helper()
@@ -374,6 +604,21 @@ func (mr *MockCliToHubServerMockRecorder) Revert(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revert", reflect.TypeOf((*MockCliToHubServer)(nil).Revert), arg0, arg1)
 }
 
+// RunCheck mocks base method
+func (m *MockCliToHubServer) RunCheck(arg0 context.Context, arg1 *idl.RunCheckRequest) (*idl.RunCheckReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunCheck", arg0, arg1)
+	ret0, _ := ret[0].(*idl.RunCheckReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunCheck indicates an expected call of RunCheck
+func (mr *MockCliToHubServerMockRecorder) RunCheck(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunCheck", reflect.TypeOf((*MockCliToHubServer)(nil).RunCheck), arg0, arg1)
+}
+
 // SetConfig mocks base method
 func (m *MockCliToHubServer) SetConfig(arg0 context.Context, arg1 *idl.SetConfigRequest) (*idl.SetConfigReply, error) {
 	m.ctrl.T.Helper()
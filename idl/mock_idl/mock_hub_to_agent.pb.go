@@ -9,9 +9,115 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	idl "github.com/greenplum-db/gpupgrade/idl"
 	grpc "google.golang.org/grpc"
+	metadata "google.golang.org/grpc/metadata"
 	reflect "reflect"
 )
 
+// MockisSyncDirectoryRequest_Contents is a mock of isSyncDirectoryRequest_Contents interface
+type MockisSyncDirectoryRequest_Contents struct {
+	ctrl     *gomock.Controller
+	recorder *MockisSyncDirectoryRequest_ContentsMockRecorder
+}
+
+// MockisSyncDirectoryRequest_ContentsMockRecorder is the mock recorder for MockisSyncDirectoryRequest_Contents
+type MockisSyncDirectoryRequest_ContentsMockRecorder struct {
+	mock *MockisSyncDirectoryRequest_Contents
+}
+
+// NewMockisSyncDirectoryRequest_Contents creates a new mock instance
+func NewMockisSyncDirectoryRequest_Contents(ctrl *gomock.Controller) *MockisSyncDirectoryRequest_Contents {
+	mock := &MockisSyncDirectoryRequest_Contents{ctrl: ctrl}
+	mock.recorder = &MockisSyncDirectoryRequest_ContentsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockisSyncDirectoryRequest_Contents) EXPECT() *MockisSyncDirectoryRequest_ContentsMockRecorder {
+	return m.recorder
+}
+
+// isSyncDirectoryRequest_Contents mocks base method
+func (m *MockisSyncDirectoryRequest_Contents) isSyncDirectoryRequest_Contents() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "isSyncDirectoryRequest_Contents")
+}
+
+// isSyncDirectoryRequest_Contents indicates an expected call of isSyncDirectoryRequest_Contents
+func (mr *MockisSyncDirectoryRequest_ContentsMockRecorder) isSyncDirectoryRequest_Contents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "isSyncDirectoryRequest_Contents", reflect.TypeOf((*MockisSyncDirectoryRequest_Contents)(nil).isSyncDirectoryRequest_Contents))
+}
+
+// MockisSyncDirectoryReply_Contents is a mock of isSyncDirectoryReply_Contents interface
+type MockisSyncDirectoryReply_Contents struct {
+	ctrl     *gomock.Controller
+	recorder *MockisSyncDirectoryReply_ContentsMockRecorder
+}
+
+// MockisSyncDirectoryReply_ContentsMockRecorder is the mock recorder for MockisSyncDirectoryReply_Contents
+type MockisSyncDirectoryReply_ContentsMockRecorder struct {
+	mock *MockisSyncDirectoryReply_Contents
+}
+
+// NewMockisSyncDirectoryReply_Contents creates a new mock instance
+func NewMockisSyncDirectoryReply_Contents(ctrl *gomock.Controller) *MockisSyncDirectoryReply_Contents {
+	mock := &MockisSyncDirectoryReply_Contents{ctrl: ctrl}
+	mock.recorder = &MockisSyncDirectoryReply_ContentsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockisSyncDirectoryReply_Contents) EXPECT() *MockisSyncDirectoryReply_ContentsMockRecorder {
+	return m.recorder
+}
+
+// isSyncDirectoryReply_Contents mocks base method
+func (m *MockisSyncDirectoryReply_Contents) isSyncDirectoryReply_Contents() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "isSyncDirectoryReply_Contents")
+}
+
+// isSyncDirectoryReply_Contents indicates an expected call of isSyncDirectoryReply_Contents
+func (mr *MockisSyncDirectoryReply_ContentsMockRecorder) isSyncDirectoryReply_Contents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "isSyncDirectoryReply_Contents", reflect.TypeOf((*MockisSyncDirectoryReply_Contents)(nil).isSyncDirectoryReply_Contents))
+}
+
+// MockisVerifyDirectoryRequest_Contents is a mock of isVerifyDirectoryRequest_Contents interface
+type MockisVerifyDirectoryRequest_Contents struct {
+	ctrl     *gomock.Controller
+	recorder *MockisVerifyDirectoryRequest_ContentsMockRecorder
+}
+
+// MockisVerifyDirectoryRequest_ContentsMockRecorder is the mock recorder for MockisVerifyDirectoryRequest_Contents
+type MockisVerifyDirectoryRequest_ContentsMockRecorder struct {
+	mock *MockisVerifyDirectoryRequest_Contents
+}
+
+// NewMockisVerifyDirectoryRequest_Contents creates a new mock instance
+func NewMockisVerifyDirectoryRequest_Contents(ctrl *gomock.Controller) *MockisVerifyDirectoryRequest_Contents {
+	mock := &MockisVerifyDirectoryRequest_Contents{ctrl: ctrl}
+	mock.recorder = &MockisVerifyDirectoryRequest_ContentsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockisVerifyDirectoryRequest_Contents) EXPECT() *MockisVerifyDirectoryRequest_ContentsMockRecorder {
+	return m.recorder
+}
+
+// isVerifyDirectoryRequest_Contents mocks base method
+func (m *MockisVerifyDirectoryRequest_Contents) isVerifyDirectoryRequest_Contents() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "isVerifyDirectoryRequest_Contents")
+}
+
+// isVerifyDirectoryRequest_Contents indicates an expected call of isVerifyDirectoryRequest_Contents
+func (mr *MockisVerifyDirectoryRequest_ContentsMockRecorder) isVerifyDirectoryRequest_Contents() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "isVerifyDirectoryRequest_Contents", reflect.TypeOf((*MockisVerifyDirectoryRequest_Contents)(nil).isVerifyDirectoryRequest_Contents))
+}
+
 // MockAgentClient is a mock of AgentClient interface
 type MockAgentClient struct {
 	ctrl     *gomock.Controller
@@ -35,6 +141,26 @@ func (m *MockAgentClient) EXPECT() *MockAgentClientMockRecorder {
 	return m.recorder
 }
 
+// Hello mocks base method
+func (m *MockAgentClient) Hello(ctx context.Context, in *idl.HelloRequest, opts ...grpc.CallOption) (*idl.HelloReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Hello", varargs...)
+	ret0, _ := ret[0].(*idl.HelloReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Hello indicates an expected call of Hello
+func (mr *MockAgentClientMockRecorder) Hello(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hello", reflect.TypeOf((*MockAgentClient)(nil).Hello), varargs...)
+}
+
 // CheckDiskSpace mocks base method
 func (m *MockAgentClient) CheckDiskSpace(ctx context.Context, in *idl.CheckSegmentDiskSpaceRequest, opts ...grpc.CallOption) (*idl.CheckDiskSpaceReply, error) {
 	m.ctrl.T.Helper()
@@ -56,14 +182,14 @@ func (mr *MockAgentClientMockRecorder) CheckDiskSpace(ctx, in interface{}, opts
 }
 
 // UpgradePrimaries mocks base method
-func (m *MockAgentClient) UpgradePrimaries(ctx context.Context, in *idl.UpgradePrimariesRequest, opts ...grpc.CallOption) (*idl.UpgradePrimariesReply, error) {
+func (m *MockAgentClient) UpgradePrimaries(ctx context.Context, in *idl.UpgradePrimariesRequest, opts ...grpc.CallOption) (idl.Agent_UpgradePrimariesClient, error) {
 	m.ctrl.T.Helper()
 	varargs := []interface{}{ctx, in}
 	for _, a := range opts {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "UpgradePrimaries", varargs...)
-	ret0, _ := ret[0].(*idl.UpgradePrimariesReply)
+	ret0, _ := ret[0].(idl.Agent_UpgradePrimariesClient)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -95,6 +221,26 @@ func (mr *MockAgentClientMockRecorder) RenameDirectories(ctx, in interface{}, op
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameDirectories", reflect.TypeOf((*MockAgentClient)(nil).RenameDirectories), varargs...)
 }
 
+// CheckRenameDirectories mocks base method
+func (m *MockAgentClient) CheckRenameDirectories(ctx context.Context, in *idl.RenameDirectoriesRequest, opts ...grpc.CallOption) (*idl.CheckRenameDirectoriesReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CheckRenameDirectories", varargs...)
+	ret0, _ := ret[0].(*idl.CheckRenameDirectoriesReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckRenameDirectories indicates an expected call of CheckRenameDirectories
+func (mr *MockAgentClientMockRecorder) CheckRenameDirectories(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRenameDirectories", reflect.TypeOf((*MockAgentClient)(nil).CheckRenameDirectories), varargs...)
+}
+
 // StopAgent mocks base method
 func (m *MockAgentClient) StopAgent(ctx context.Context, in *idl.StopAgentRequest, opts ...grpc.CallOption) (*idl.StopAgentReply, error) {
 	m.ctrl.T.Helper()
@@ -175,6 +321,463 @@ func (mr *MockAgentClientMockRecorder) ArchiveLogDirectory(ctx, in interface{},
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveLogDirectory", reflect.TypeOf((*MockAgentClient)(nil).ArchiveLogDirectory), varargs...)
 }
 
+// SyncDirectory mocks base method
+func (m *MockAgentClient) SyncDirectory(ctx context.Context, opts ...grpc.CallOption) (idl.Agent_SyncDirectoryClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SyncDirectory", varargs...)
+	ret0, _ := ret[0].(idl.Agent_SyncDirectoryClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncDirectory indicates an expected call of SyncDirectory
+func (mr *MockAgentClientMockRecorder) SyncDirectory(ctx interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncDirectory", reflect.TypeOf((*MockAgentClient)(nil).SyncDirectory), varargs...)
+}
+
+// VerifyDirectory mocks base method
+func (m *MockAgentClient) VerifyDirectory(ctx context.Context, opts ...grpc.CallOption) (idl.Agent_VerifyDirectoryClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VerifyDirectory", varargs...)
+	ret0, _ := ret[0].(idl.Agent_VerifyDirectoryClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyDirectory indicates an expected call of VerifyDirectory
+func (mr *MockAgentClientMockRecorder) VerifyDirectory(ctx interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyDirectory", reflect.TypeOf((*MockAgentClient)(nil).VerifyDirectory), varargs...)
+}
+
+// CheckPorts mocks base method
+func (m *MockAgentClient) CheckPorts(ctx context.Context, in *idl.CheckPortsRequest, opts ...grpc.CallOption) (*idl.CheckPortsReply, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CheckPorts", varargs...)
+	ret0, _ := ret[0].(*idl.CheckPortsReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckPorts indicates an expected call of CheckPorts
+func (mr *MockAgentClientMockRecorder) CheckPorts(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPorts", reflect.TypeOf((*MockAgentClient)(nil).CheckPorts), varargs...)
+}
+
+// MockAgent_UpgradePrimariesClient is a mock of Agent_UpgradePrimariesClient interface
+type MockAgent_UpgradePrimariesClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgent_UpgradePrimariesClientMockRecorder
+}
+
+// MockAgent_UpgradePrimariesClientMockRecorder is the mock recorder for MockAgent_UpgradePrimariesClient
+type MockAgent_UpgradePrimariesClientMockRecorder struct {
+	mock *MockAgent_UpgradePrimariesClient
+}
+
+// NewMockAgent_UpgradePrimariesClient creates a new mock instance
+func NewMockAgent_UpgradePrimariesClient(ctrl *gomock.Controller) *MockAgent_UpgradePrimariesClient {
+	mock := &MockAgent_UpgradePrimariesClient{ctrl: ctrl}
+	mock.recorder = &MockAgent_UpgradePrimariesClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAgent_UpgradePrimariesClient) EXPECT() *MockAgent_UpgradePrimariesClientMockRecorder {
+	return m.recorder
+}
+
+// Recv mocks base method
+func (m *MockAgent_UpgradePrimariesClient) Recv() (*idl.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*idl.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv
+func (mr *MockAgent_UpgradePrimariesClientMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockAgent_UpgradePrimariesClient)(nil).Recv))
+}
+
+// Header mocks base method
+func (m *MockAgent_UpgradePrimariesClient) Header() (metadata.MD, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header")
+	ret0, _ := ret[0].(metadata.MD)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header
+func (mr *MockAgent_UpgradePrimariesClientMockRecorder) Header() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockAgent_UpgradePrimariesClient)(nil).Header))
+}
+
+// Trailer mocks base method
+func (m *MockAgent_UpgradePrimariesClient) Trailer() metadata.MD {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trailer")
+	ret0, _ := ret[0].(metadata.MD)
+	return ret0
+}
+
+// Trailer indicates an expected call of Trailer
+func (mr *MockAgent_UpgradePrimariesClientMockRecorder) Trailer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockAgent_UpgradePrimariesClient)(nil).Trailer))
+}
+
+// CloseSend mocks base method
+func (m *MockAgent_UpgradePrimariesClient) CloseSend() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseSend")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSend indicates an expected call of CloseSend
+func (mr *MockAgent_UpgradePrimariesClientMockRecorder) CloseSend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSend", reflect.TypeOf((*MockAgent_UpgradePrimariesClient)(nil).CloseSend))
+}
+
+// Context mocks base method
+func (m *MockAgent_UpgradePrimariesClient) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockAgent_UpgradePrimariesClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockAgent_UpgradePrimariesClient)(nil).Context))
+}
+
+// SendMsg mocks base method
+func (m_2 *MockAgent_UpgradePrimariesClient) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg
+func (mr *MockAgent_UpgradePrimariesClientMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockAgent_UpgradePrimariesClient)(nil).SendMsg), m)
+}
+
+// RecvMsg mocks base method
+func (m_2 *MockAgent_UpgradePrimariesClient) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg
+func (mr *MockAgent_UpgradePrimariesClientMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockAgent_UpgradePrimariesClient)(nil).RecvMsg), m)
+}
+
+// MockAgent_SyncDirectoryClient is a mock of Agent_SyncDirectoryClient interface
+type MockAgent_SyncDirectoryClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgent_SyncDirectoryClientMockRecorder
+}
+
+// MockAgent_SyncDirectoryClientMockRecorder is the mock recorder for MockAgent_SyncDirectoryClient
+type MockAgent_SyncDirectoryClientMockRecorder struct {
+	mock *MockAgent_SyncDirectoryClient
+}
+
+// NewMockAgent_SyncDirectoryClient creates a new mock instance
+func NewMockAgent_SyncDirectoryClient(ctrl *gomock.Controller) *MockAgent_SyncDirectoryClient {
+	mock := &MockAgent_SyncDirectoryClient{ctrl: ctrl}
+	mock.recorder = &MockAgent_SyncDirectoryClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAgent_SyncDirectoryClient) EXPECT() *MockAgent_SyncDirectoryClientMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method
+func (m *MockAgent_SyncDirectoryClient) Send(arg0 *idl.SyncDirectoryRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send
+func (mr *MockAgent_SyncDirectoryClientMockRecorder) Send(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockAgent_SyncDirectoryClient)(nil).Send), arg0)
+}
+
+// Recv mocks base method
+func (m *MockAgent_SyncDirectoryClient) Recv() (*idl.SyncDirectoryReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*idl.SyncDirectoryReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv
+func (mr *MockAgent_SyncDirectoryClientMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockAgent_SyncDirectoryClient)(nil).Recv))
+}
+
+// Header mocks base method
+func (m *MockAgent_SyncDirectoryClient) Header() (metadata.MD, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header")
+	ret0, _ := ret[0].(metadata.MD)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header
+func (mr *MockAgent_SyncDirectoryClientMockRecorder) Header() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockAgent_SyncDirectoryClient)(nil).Header))
+}
+
+// Trailer mocks base method
+func (m *MockAgent_SyncDirectoryClient) Trailer() metadata.MD {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trailer")
+	ret0, _ := ret[0].(metadata.MD)
+	return ret0
+}
+
+// Trailer indicates an expected call of Trailer
+func (mr *MockAgent_SyncDirectoryClientMockRecorder) Trailer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockAgent_SyncDirectoryClient)(nil).Trailer))
+}
+
+// CloseSend mocks base method
+func (m *MockAgent_SyncDirectoryClient) CloseSend() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseSend")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSend indicates an expected call of CloseSend
+func (mr *MockAgent_SyncDirectoryClientMockRecorder) CloseSend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSend", reflect.TypeOf((*MockAgent_SyncDirectoryClient)(nil).CloseSend))
+}
+
+// Context mocks base method
+func (m *MockAgent_SyncDirectoryClient) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockAgent_SyncDirectoryClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockAgent_SyncDirectoryClient)(nil).Context))
+}
+
+// SendMsg mocks base method
+func (m_2 *MockAgent_SyncDirectoryClient) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg
+func (mr *MockAgent_SyncDirectoryClientMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockAgent_SyncDirectoryClient)(nil).SendMsg), m)
+}
+
+// RecvMsg mocks base method
+func (m_2 *MockAgent_SyncDirectoryClient) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg
+func (mr *MockAgent_SyncDirectoryClientMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockAgent_SyncDirectoryClient)(nil).RecvMsg), m)
+}
+
+// MockAgent_VerifyDirectoryClient is a mock of Agent_VerifyDirectoryClient interface
+type MockAgent_VerifyDirectoryClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgent_VerifyDirectoryClientMockRecorder
+}
+
+// MockAgent_VerifyDirectoryClientMockRecorder is the mock recorder for MockAgent_VerifyDirectoryClient
+type MockAgent_VerifyDirectoryClientMockRecorder struct {
+	mock *MockAgent_VerifyDirectoryClient
+}
+
+// NewMockAgent_VerifyDirectoryClient creates a new mock instance
+func NewMockAgent_VerifyDirectoryClient(ctrl *gomock.Controller) *MockAgent_VerifyDirectoryClient {
+	mock := &MockAgent_VerifyDirectoryClient{ctrl: ctrl}
+	mock.recorder = &MockAgent_VerifyDirectoryClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAgent_VerifyDirectoryClient) EXPECT() *MockAgent_VerifyDirectoryClientMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method
+func (m *MockAgent_VerifyDirectoryClient) Send(arg0 *idl.VerifyDirectoryRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send
+func (mr *MockAgent_VerifyDirectoryClientMockRecorder) Send(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockAgent_VerifyDirectoryClient)(nil).Send), arg0)
+}
+
+// CloseAndRecv mocks base method
+func (m *MockAgent_VerifyDirectoryClient) CloseAndRecv() (*idl.VerifyDirectoryReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseAndRecv")
+	ret0, _ := ret[0].(*idl.VerifyDirectoryReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseAndRecv indicates an expected call of CloseAndRecv
+func (mr *MockAgent_VerifyDirectoryClientMockRecorder) CloseAndRecv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseAndRecv", reflect.TypeOf((*MockAgent_VerifyDirectoryClient)(nil).CloseAndRecv))
+}
+
+// Header mocks base method
+func (m *MockAgent_VerifyDirectoryClient) Header() (metadata.MD, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header")
+	ret0, _ := ret[0].(metadata.MD)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header
+func (mr *MockAgent_VerifyDirectoryClientMockRecorder) Header() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockAgent_VerifyDirectoryClient)(nil).Header))
+}
+
+// Trailer mocks base method
+func (m *MockAgent_VerifyDirectoryClient) Trailer() metadata.MD {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trailer")
+	ret0, _ := ret[0].(metadata.MD)
+	return ret0
+}
+
+// Trailer indicates an expected call of Trailer
+func (mr *MockAgent_VerifyDirectoryClientMockRecorder) Trailer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trailer", reflect.TypeOf((*MockAgent_VerifyDirectoryClient)(nil).Trailer))
+}
+
+// CloseSend mocks base method
+func (m *MockAgent_VerifyDirectoryClient) CloseSend() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseSend")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSend indicates an expected call of CloseSend
+func (mr *MockAgent_VerifyDirectoryClientMockRecorder) CloseSend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSend", reflect.TypeOf((*MockAgent_VerifyDirectoryClient)(nil).CloseSend))
+}
+
+// Context mocks base method
+func (m *MockAgent_VerifyDirectoryClient) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockAgent_VerifyDirectoryClientMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockAgent_VerifyDirectoryClient)(nil).Context))
+}
+
+// SendMsg mocks base method
+func (m_2 *MockAgent_VerifyDirectoryClient) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg
+func (mr *MockAgent_VerifyDirectoryClientMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockAgent_VerifyDirectoryClient)(nil).SendMsg), m)
+}
+
+// RecvMsg mocks base method
+func (m_2 *MockAgent_VerifyDirectoryClient) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg
+func (mr *MockAgent_VerifyDirectoryClientMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockAgent_VerifyDirectoryClient)(nil).RecvMsg), m)
+}
+
 // MockAgentServer is a mock of AgentServer interface
 type MockAgentServer struct {
 	ctrl     *gomock.Controller
@@ -198,6 +801,21 @@ func (m *MockAgentServer) EXPECT() *MockAgentServerMockRecorder {
 	return m.recorder
 }
 
+// Hello mocks base method
+func (m *MockAgentServer) Hello(arg0 context.Context, arg1 *idl.HelloRequest) (*idl.HelloReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Hello", arg0, arg1)
+	ret0, _ := ret[0].(*idl.HelloReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Hello indicates an expected call of Hello
+func (mr *MockAgentServerMockRecorder) Hello(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hello", reflect.TypeOf((*MockAgentServer)(nil).Hello), arg0, arg1)
+}
+
 // CheckDiskSpace mocks base method
 func (m *MockAgentServer) CheckDiskSpace(arg0 context.Context, arg1 *idl.CheckSegmentDiskSpaceRequest) (*idl.CheckDiskSpaceReply, error) {
 	m.ctrl.T.Helper()
@@ -214,12 +832,11 @@ func (mr *MockAgentServerMockRecorder) CheckDiskSpace(arg0, arg1 interface{}) *g
 }
 
 // UpgradePrimaries mocks base method
-func (m *MockAgentServer) UpgradePrimaries(arg0 context.Context, arg1 *idl.UpgradePrimariesRequest) (*idl.UpgradePrimariesReply, error) {
+func (m *MockAgentServer) UpgradePrimaries(arg0 *idl.UpgradePrimariesRequest, arg1 idl.Agent_UpgradePrimariesServer) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpgradePrimaries", arg0, arg1)
-	ret0, _ := ret[0].(*idl.UpgradePrimariesReply)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // UpgradePrimaries indicates an expected call of UpgradePrimaries
@@ -243,6 +860,21 @@ func (mr *MockAgentServerMockRecorder) RenameDirectories(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameDirectories", reflect.TypeOf((*MockAgentServer)(nil).RenameDirectories), arg0, arg1)
 }
 
+// CheckRenameDirectories mocks base method
+func (m *MockAgentServer) CheckRenameDirectories(arg0 context.Context, arg1 *idl.RenameDirectoriesRequest) (*idl.CheckRenameDirectoriesReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckRenameDirectories", arg0, arg1)
+	ret0, _ := ret[0].(*idl.CheckRenameDirectoriesReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckRenameDirectories indicates an expected call of CheckRenameDirectories
+func (mr *MockAgentServerMockRecorder) CheckRenameDirectories(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRenameDirectories", reflect.TypeOf((*MockAgentServer)(nil).CheckRenameDirectories), arg0, arg1)
+}
+
 // StopAgent mocks base method
 func (m *MockAgentServer) StopAgent(arg0 context.Context, arg1 *idl.StopAgentRequest) (*idl.StopAgentReply, error) {
 	m.ctrl.T.Helper()
@@ -302,3 +934,433 @@ func (mr *MockAgentServerMockRecorder) ArchiveLogDirectory(arg0, arg1 interface{
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveLogDirectory", reflect.TypeOf((*MockAgentServer)(nil).ArchiveLogDirectory), arg0, arg1)
 }
+
+// SyncDirectory mocks base method
+func (m *MockAgentServer) SyncDirectory(arg0 idl.Agent_SyncDirectoryServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncDirectory", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncDirectory indicates an expected call of SyncDirectory
+func (mr *MockAgentServerMockRecorder) SyncDirectory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncDirectory", reflect.TypeOf((*MockAgentServer)(nil).SyncDirectory), arg0)
+}
+
+// VerifyDirectory mocks base method
+func (m *MockAgentServer) VerifyDirectory(arg0 idl.Agent_VerifyDirectoryServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyDirectory", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyDirectory indicates an expected call of VerifyDirectory
+func (mr *MockAgentServerMockRecorder) VerifyDirectory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyDirectory", reflect.TypeOf((*MockAgentServer)(nil).VerifyDirectory), arg0)
+}
+
+// CheckPorts mocks base method
+func (m *MockAgentServer) CheckPorts(arg0 context.Context, arg1 *idl.CheckPortsRequest) (*idl.CheckPortsReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckPorts", arg0, arg1)
+	ret0, _ := ret[0].(*idl.CheckPortsReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckPorts indicates an expected call of CheckPorts
+func (mr *MockAgentServerMockRecorder) CheckPorts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPorts", reflect.TypeOf((*MockAgentServer)(nil).CheckPorts), arg0, arg1)
+}
+
+// MockAgent_UpgradePrimariesServer is a mock of Agent_UpgradePrimariesServer interface
+type MockAgent_UpgradePrimariesServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgent_UpgradePrimariesServerMockRecorder
+}
+
+// MockAgent_UpgradePrimariesServerMockRecorder is the mock recorder for MockAgent_UpgradePrimariesServer
+type MockAgent_UpgradePrimariesServerMockRecorder struct {
+	mock *MockAgent_UpgradePrimariesServer
+}
+
+// NewMockAgent_UpgradePrimariesServer creates a new mock instance
+func NewMockAgent_UpgradePrimariesServer(ctrl *gomock.Controller) *MockAgent_UpgradePrimariesServer {
+	mock := &MockAgent_UpgradePrimariesServer{ctrl: ctrl}
+	mock.recorder = &MockAgent_UpgradePrimariesServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAgent_UpgradePrimariesServer) EXPECT() *MockAgent_UpgradePrimariesServerMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method
+func (m *MockAgent_UpgradePrimariesServer) Send(arg0 *idl.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send
+func (mr *MockAgent_UpgradePrimariesServerMockRecorder) Send(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockAgent_UpgradePrimariesServer)(nil).Send), arg0)
+}
+
+// SetHeader mocks base method
+func (m *MockAgent_UpgradePrimariesServer) SetHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHeader indicates an expected call of SetHeader
+func (mr *MockAgent_UpgradePrimariesServerMockRecorder) SetHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHeader", reflect.TypeOf((*MockAgent_UpgradePrimariesServer)(nil).SetHeader), arg0)
+}
+
+// SendHeader mocks base method
+func (m *MockAgent_UpgradePrimariesServer) SendHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendHeader indicates an expected call of SendHeader
+func (mr *MockAgent_UpgradePrimariesServerMockRecorder) SendHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendHeader", reflect.TypeOf((*MockAgent_UpgradePrimariesServer)(nil).SendHeader), arg0)
+}
+
+// SetTrailer mocks base method
+func (m *MockAgent_UpgradePrimariesServer) SetTrailer(arg0 metadata.MD) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTrailer", arg0)
+}
+
+// SetTrailer indicates an expected call of SetTrailer
+func (mr *MockAgent_UpgradePrimariesServerMockRecorder) SetTrailer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrailer", reflect.TypeOf((*MockAgent_UpgradePrimariesServer)(nil).SetTrailer), arg0)
+}
+
+// Context mocks base method
+func (m *MockAgent_UpgradePrimariesServer) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockAgent_UpgradePrimariesServerMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockAgent_UpgradePrimariesServer)(nil).Context))
+}
+
+// SendMsg mocks base method
+func (m_2 *MockAgent_UpgradePrimariesServer) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg
+func (mr *MockAgent_UpgradePrimariesServerMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockAgent_UpgradePrimariesServer)(nil).SendMsg), m)
+}
+
+// RecvMsg mocks base method
+func (m_2 *MockAgent_UpgradePrimariesServer) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg
+func (mr *MockAgent_UpgradePrimariesServerMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockAgent_UpgradePrimariesServer)(nil).RecvMsg), m)
+}
+
+// MockAgent_SyncDirectoryServer is a mock of Agent_SyncDirectoryServer interface
+type MockAgent_SyncDirectoryServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgent_SyncDirectoryServerMockRecorder
+}
+
+// MockAgent_SyncDirectoryServerMockRecorder is the mock recorder for MockAgent_SyncDirectoryServer
+type MockAgent_SyncDirectoryServerMockRecorder struct {
+	mock *MockAgent_SyncDirectoryServer
+}
+
+// NewMockAgent_SyncDirectoryServer creates a new mock instance
+func NewMockAgent_SyncDirectoryServer(ctrl *gomock.Controller) *MockAgent_SyncDirectoryServer {
+	mock := &MockAgent_SyncDirectoryServer{ctrl: ctrl}
+	mock.recorder = &MockAgent_SyncDirectoryServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAgent_SyncDirectoryServer) EXPECT() *MockAgent_SyncDirectoryServerMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method
+func (m *MockAgent_SyncDirectoryServer) Send(arg0 *idl.SyncDirectoryReply) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send
+func (mr *MockAgent_SyncDirectoryServerMockRecorder) Send(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockAgent_SyncDirectoryServer)(nil).Send), arg0)
+}
+
+// Recv mocks base method
+func (m *MockAgent_SyncDirectoryServer) Recv() (*idl.SyncDirectoryRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*idl.SyncDirectoryRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv
+func (mr *MockAgent_SyncDirectoryServerMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockAgent_SyncDirectoryServer)(nil).Recv))
+}
+
+// SetHeader mocks base method
+func (m *MockAgent_SyncDirectoryServer) SetHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHeader indicates an expected call of SetHeader
+func (mr *MockAgent_SyncDirectoryServerMockRecorder) SetHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHeader", reflect.TypeOf((*MockAgent_SyncDirectoryServer)(nil).SetHeader), arg0)
+}
+
+// SendHeader mocks base method
+func (m *MockAgent_SyncDirectoryServer) SendHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendHeader indicates an expected call of SendHeader
+func (mr *MockAgent_SyncDirectoryServerMockRecorder) SendHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendHeader", reflect.TypeOf((*MockAgent_SyncDirectoryServer)(nil).SendHeader), arg0)
+}
+
+// SetTrailer mocks base method
+func (m *MockAgent_SyncDirectoryServer) SetTrailer(arg0 metadata.MD) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTrailer", arg0)
+}
+
+// SetTrailer indicates an expected call of SetTrailer
+func (mr *MockAgent_SyncDirectoryServerMockRecorder) SetTrailer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrailer", reflect.TypeOf((*MockAgent_SyncDirectoryServer)(nil).SetTrailer), arg0)
+}
+
+// Context mocks base method
+func (m *MockAgent_SyncDirectoryServer) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockAgent_SyncDirectoryServerMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockAgent_SyncDirectoryServer)(nil).Context))
+}
+
+// SendMsg mocks base method
+func (m_2 *MockAgent_SyncDirectoryServer) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg
+func (mr *MockAgent_SyncDirectoryServerMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockAgent_SyncDirectoryServer)(nil).SendMsg), m)
+}
+
+// RecvMsg mocks base method
+func (m_2 *MockAgent_SyncDirectoryServer) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg
+func (mr *MockAgent_SyncDirectoryServerMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockAgent_SyncDirectoryServer)(nil).RecvMsg), m)
+}
+
+// MockAgent_VerifyDirectoryServer is a mock of Agent_VerifyDirectoryServer interface
+type MockAgent_VerifyDirectoryServer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgent_VerifyDirectoryServerMockRecorder
+}
+
+// MockAgent_VerifyDirectoryServerMockRecorder is the mock recorder for MockAgent_VerifyDirectoryServer
+type MockAgent_VerifyDirectoryServerMockRecorder struct {
+	mock *MockAgent_VerifyDirectoryServer
+}
+
+// NewMockAgent_VerifyDirectoryServer creates a new mock instance
+func NewMockAgent_VerifyDirectoryServer(ctrl *gomock.Controller) *MockAgent_VerifyDirectoryServer {
+	mock := &MockAgent_VerifyDirectoryServer{ctrl: ctrl}
+	mock.recorder = &MockAgent_VerifyDirectoryServerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAgent_VerifyDirectoryServer) EXPECT() *MockAgent_VerifyDirectoryServerMockRecorder {
+	return m.recorder
+}
+
+// SendAndClose mocks base method
+func (m *MockAgent_VerifyDirectoryServer) SendAndClose(arg0 *idl.VerifyDirectoryReply) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendAndClose", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendAndClose indicates an expected call of SendAndClose
+func (mr *MockAgent_VerifyDirectoryServerMockRecorder) SendAndClose(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendAndClose", reflect.TypeOf((*MockAgent_VerifyDirectoryServer)(nil).SendAndClose), arg0)
+}
+
+// Recv mocks base method
+func (m *MockAgent_VerifyDirectoryServer) Recv() (*idl.VerifyDirectoryRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recv")
+	ret0, _ := ret[0].(*idl.VerifyDirectoryRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recv indicates an expected call of Recv
+func (mr *MockAgent_VerifyDirectoryServerMockRecorder) Recv() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recv", reflect.TypeOf((*MockAgent_VerifyDirectoryServer)(nil).Recv))
+}
+
+// SetHeader mocks base method
+func (m *MockAgent_VerifyDirectoryServer) SetHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHeader indicates an expected call of SetHeader
+func (mr *MockAgent_VerifyDirectoryServerMockRecorder) SetHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHeader", reflect.TypeOf((*MockAgent_VerifyDirectoryServer)(nil).SetHeader), arg0)
+}
+
+// SendHeader mocks base method
+func (m *MockAgent_VerifyDirectoryServer) SendHeader(arg0 metadata.MD) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendHeader", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendHeader indicates an expected call of SendHeader
+func (mr *MockAgent_VerifyDirectoryServerMockRecorder) SendHeader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendHeader", reflect.TypeOf((*MockAgent_VerifyDirectoryServer)(nil).SendHeader), arg0)
+}
+
+// SetTrailer mocks base method
+func (m *MockAgent_VerifyDirectoryServer) SetTrailer(arg0 metadata.MD) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTrailer", arg0)
+}
+
+// SetTrailer indicates an expected call of SetTrailer
+func (mr *MockAgent_VerifyDirectoryServerMockRecorder) SetTrailer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTrailer", reflect.TypeOf((*MockAgent_VerifyDirectoryServer)(nil).SetTrailer), arg0)
+}
+
+// Context mocks base method
+func (m *MockAgent_VerifyDirectoryServer) Context() context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Context")
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// Context indicates an expected call of Context
+func (mr *MockAgent_VerifyDirectoryServerMockRecorder) Context() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockAgent_VerifyDirectoryServer)(nil).Context))
+}
+
+// SendMsg mocks base method
+func (m_2 *MockAgent_VerifyDirectoryServer) SendMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SendMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMsg indicates an expected call of SendMsg
+func (mr *MockAgent_VerifyDirectoryServerMockRecorder) SendMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMsg", reflect.TypeOf((*MockAgent_VerifyDirectoryServer)(nil).SendMsg), m)
+}
+
+// RecvMsg mocks base method
+func (m_2 *MockAgent_VerifyDirectoryServer) RecvMsg(m interface{}) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "RecvMsg", m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecvMsg indicates an expected call of RecvMsg
+func (mr *MockAgent_VerifyDirectoryServerMockRecorder) RecvMsg(m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecvMsg", reflect.TypeOf((*MockAgent_VerifyDirectoryServer)(nil).RecvMsg), m)
+}
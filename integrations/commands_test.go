@@ -4,11 +4,17 @@
 package integrations_test
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"testing"
 
+	"github.com/greenplum-db/gpupgrade/cli/commanders"
 	"github.com/greenplum-db/gpupgrade/cli/commands"
+	"github.com/greenplum-db/gpupgrade/testutils"
 )
 
 func TestHelpCommands(t *testing.T) {
@@ -56,3 +62,32 @@ func TestHelpCommands(t *testing.T) {
 		}
 	})
 }
+
+func TestJSONFormat(t *testing.T) {
+	t.Run("writes only JSON to stdout when a command fails", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "")
+		defer testutils.MustRemoveAll(t, dir)
+
+		resetEnv := testutils.SetEnv(t, "GPUPGRADE_HOME", filepath.Join(dir, ".gpupgrade"))
+		defer resetEnv()
+
+		// Check fails when initialize has not been run.
+		cmd := exec.Command("gpupgrade", "--format=json", "check")
+		output, err := cmd.Output()
+		if _, ok := err.(*exec.ExitError); !ok {
+			t.Fatalf("got error %#v want an exit error", err)
+		}
+
+		var summary commanders.Summary
+		scanner := bufio.NewScanner(bytes.NewReader(output))
+		for scanner.Scan() {
+			if err := json.Unmarshal(scanner.Bytes(), &summary); err != nil {
+				t.Fatalf("stdout line %q is not JSON: %v", scanner.Text(), err)
+			}
+		}
+
+		if summary.Type != "summary" || summary.Command != "check" || summary.Result != "failure" || summary.Error == "" {
+			t.Errorf("got last line %+v, want a failed summary of check", summary)
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/greenplum-db/gpupgrade/cli/commanders"
+	"github.com/greenplum-db/gpupgrade/hub"
 	"github.com/greenplum-db/gpupgrade/testutils"
 )
 
@@ -26,7 +27,7 @@ func TestHub(t *testing.T) {
 			t.Errorf("unexpected error got %+v", err)
 		}
 
-		err = commanders.CreateInitialClusterConfigs()
+		err = commanders.CreateInitialClusterConfigs(false, "", 0, 0, hub.RemoteShell{})
 		if err != nil {
 			t.Errorf("unexpected error got %+v", err)
 		}
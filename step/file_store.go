@@ -7,9 +7,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"time"
 
 	"github.com/google/renameio"
+	"github.com/greenplum-db/gp-common-go-libs/operating"
 	"github.com/hashicorp/go-multierror"
+	"golang.org/x/xerrors"
 
 	"github.com/greenplum-db/gpupgrade/idl"
 )
@@ -17,6 +20,8 @@ import (
 type Store interface {
 	Read(string, idl.Substep) (idl.Status, error)
 	Write(string, idl.Substep, idl.Status) error
+	// Fail marks the substep as FAILED and records the error that caused it.
+	Fail(string, idl.Substep, error) error
 }
 
 // FileStore implements step.Store by providing persistent storage on disk.
@@ -28,7 +33,57 @@ func NewFileStore(path string) *FileStore {
 	return &FileStore{path}
 }
 
-type prettyMap = map[string]map[string]PrettyStatus
+type prettyMap = map[string]map[string]Entry
+
+// Entry is the persisted record of a single substep within a section. The
+// times, duration and error describe the most recent attempt to run it.
+type Entry struct {
+	Status    PrettyStatus
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  PrettyDuration
+	Attempts  int
+	LastError string
+}
+
+// UnmarshalJSON accepts both the current object representation of an Entry
+// and the older formats written by earlier versions of the FileStore, which
+// are migrated to the current one. See migrate().
+func (e *Entry) UnmarshalJSON(buf []byte) error {
+	if len(buf) > 0 && buf[0] == '"' {
+		// Originally, only the bare status string was stored.
+		var status PrettyStatus
+		if err := json.Unmarshal(buf, &status); err != nil {
+			return err
+		}
+
+		*e = Entry{Status: status}
+		e.migrate()
+		return nil
+	}
+
+	type entry Entry // avoid recursing back into this method
+	if err := json.Unmarshal(buf, (*entry)(e)); err != nil {
+		return err
+	}
+
+	e.migrate()
+	return nil
+}
+
+// migrate fills in the fields that were not recorded by earlier versions of
+// the FileStore. Any substep with a recorded status has been attempted at
+// least once, and the duration of a finished substep can be derived from its
+// start and end times when both are known.
+func (e *Entry) migrate() {
+	if e.Attempts == 0 && e.Status.Status != idl.Status_UNKNOWN_STATUS {
+		e.Attempts = 1
+	}
+
+	if e.Duration.Duration == 0 && !e.StartTime.IsZero() && !e.EndTime.IsZero() {
+		e.Duration.Duration = e.EndTime.Sub(e.StartTime)
+	}
+}
 
 // PrettyStatus exists only to write a string description of idl.Status to
 // the JSON representation, instead of an integer.
@@ -52,6 +107,26 @@ func (p *PrettyStatus) UnmarshalText(buf []byte) error {
 	return nil
 }
 
+// PrettyDuration exists only to write a human-readable time.Duration (such as
+// "1m30s") to the JSON representation, instead of an integer.
+type PrettyDuration struct {
+	time.Duration
+}
+
+func (p PrettyDuration) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *PrettyDuration) UnmarshalText(buf []byte) error {
+	d, err := time.ParseDuration(string(buf))
+	if err != nil {
+		return xerrors.Errorf("invalid duration %q: %w", string(buf), err)
+	}
+
+	p.Duration = d
+	return nil
+}
+
 func (f *FileStore) load() (prettyMap, error) {
 	data, err := ioutil.ReadFile(f.path)
 	if err != nil {
@@ -78,28 +153,89 @@ func (f *FileStore) Read(section string, substep idl.Substep) (idl.Status, error
 		return idl.Status_UNKNOWN_STATUS, nil
 	}
 
-	status, ok := sectionMap[substep.String()]
+	entry, ok := sectionMap[substep.String()]
 	if !ok {
 		return idl.Status_UNKNOWN_STATUS, nil
 	}
 
-	return status.Status, nil
+	return entry.Status.Status, nil
 }
 
-// Write atomically updates the status file.
+// ReadAll returns every persisted Entry, keyed by section and then by substep.
+func (f *FileStore) ReadAll() (map[string]map[idl.Substep]Entry, error) {
+	steps, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make(map[string]map[idl.Substep]Entry)
+	for section, substeps := range steps {
+		sections[section] = make(map[idl.Substep]Entry)
+
+		for name, entry := range substeps {
+			val, ok := idl.Substep_value[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown substep name %q in section %q", name, section)
+			}
+
+			sections[section][idl.Substep(val)] = entry
+		}
+	}
+
+	return sections, nil
+}
+
+// Write atomically updates the status file with the given status.
+func (f *FileStore) Write(section string, substep idl.Substep, status idl.Status) error {
+	return f.update(section, substep, status, nil)
+}
+
+// Fail atomically marks the substep as FAILED in the status file, and records
+// the error that caused the failure.
+func (f *FileStore) Fail(section string, substep idl.Substep, cause error) error {
+	return f.update(section, substep, idl.Status_FAILED, cause)
+}
+
+// update atomically updates the status file.
 // Load the latest values from the filesystem, rather than storing
 // in-memory on a struct to avoid having two sources of truth.
-func (f *FileStore) Write(section string, substep idl.Substep, status idl.Status) (err error) {
+//
+// Each time a substep is marked RUNNING a new attempt begins: the attempt
+// count is incremented, its start time is recorded, and the results of the
+// previous attempt are cleared. The end time and duration are recorded when
+// the substep is marked COMPLETE or FAILED.
+func (f *FileStore) update(section string, substep idl.Substep, status idl.Status, cause error) (err error) {
 	steps, err := f.load()
 	if err != nil {
 		return err
 	}
 
 	if _, ok := steps[section]; !ok {
-		steps[section] = make(map[string]PrettyStatus)
+		steps[section] = make(map[string]Entry)
+	}
+
+	entry := steps[section][substep.String()]
+	entry.Status = PrettyStatus{status}
+
+	switch status {
+	case idl.Status_RUNNING:
+		entry.StartTime = operating.System.Now()
+		entry.EndTime = time.Time{}
+		entry.Duration = PrettyDuration{}
+		entry.Attempts++
+		entry.LastError = ""
+	case idl.Status_COMPLETE, idl.Status_FAILED:
+		entry.EndTime = operating.System.Now()
+		if !entry.StartTime.IsZero() {
+			entry.Duration = PrettyDuration{entry.EndTime.Sub(entry.StartTime)}
+		}
+	}
+
+	if cause != nil {
+		entry.LastError = cause.Error()
 	}
-	steps[section][substep.String()] = PrettyStatus{status}
 
+	steps[section][substep.String()] = entry
 	data, err := json.MarshalIndent(steps, "", "  ") // pretty print JSON
 	if err != nil {
 		return err
@@ -5,10 +5,15 @@ package step_test
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/operating"
 
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/step"
@@ -156,14 +161,279 @@ func TestFileStore(t *testing.T) {
 		defer f.Close()
 
 		dec := json.NewDecoder(f)
-		raw := make(map[string]map[string]string)
+		raw := make(map[string]map[string]struct{ Status string })
 		if err := dec.Decode(&raw); err != nil {
 			t.Fatalf("decoding statuses: %+v", err)
 		}
 
 		key := substep.String()
-		if raw[section][key] != status.String() {
-			t.Errorf("status[%q][%q] = %q, want %q", section, key, raw[section][key], status.String())
+		if raw[section][key].Status != status.String() {
+			t.Errorf("status[%q][%q] = %q, want %q", section, key, raw[section][key].Status, status.String())
+		}
+	})
+
+	t.Run("records start and end times", func(t *testing.T) {
+		clear(t, path)
+
+		start := time.Date(2020, time.March, 1, 10, 0, 0, 0, time.UTC)
+		end := start.Add(5 * time.Minute)
+
+		operating.System.Now = func() time.Time { return start }
+		defer func() { operating.System.Now = time.Now }()
+
+		substep := idl.Substep_UPGRADE_PRIMARIES
+		if err := fs.Write(section, substep, idl.Status_RUNNING); err != nil {
+			t.Fatalf("Write(): %+v", err)
+		}
+
+		operating.System.Now = func() time.Time { return end }
+		if err := fs.Write(section, substep, idl.Status_COMPLETE); err != nil {
+			t.Fatalf("Write(): %+v", err)
+		}
+
+		entries, err := fs.ReadAll()
+		if err != nil {
+			t.Fatalf("ReadAll() returned error %+v", err)
+		}
+
+		entry := entries[section][substep]
+		if entry.Status.Status != idl.Status_COMPLETE {
+			t.Errorf("got status %v want %v", entry.Status.Status, idl.Status_COMPLETE)
+		}
+		if !entry.StartTime.Equal(start) {
+			t.Errorf("got start time %v want %v", entry.StartTime, start)
+		}
+		if !entry.EndTime.Equal(end) {
+			t.Errorf("got end time %v want %v", entry.EndTime, end)
+		}
+	})
+
+	t.Run("clears the end time when a substep is re-run", func(t *testing.T) {
+		clear(t, path)
+
+		substep := idl.Substep_UPGRADE_PRIMARIES
+		for _, status := range []idl.Status{idl.Status_RUNNING, idl.Status_FAILED, idl.Status_RUNNING} {
+			if err := fs.Write(section, substep, status); err != nil {
+				t.Fatalf("Write(): %+v", err)
+			}
+		}
+
+		entries, err := fs.ReadAll()
+		if err != nil {
+			t.Fatalf("ReadAll() returned error %+v", err)
+		}
+
+		entry := entries[section][substep]
+		if entry.StartTime.IsZero() {
+			t.Error("expected start time to be set")
+		}
+		if !entry.EndTime.IsZero() {
+			t.Errorf("got end time %v want zero value", entry.EndTime)
+		}
+	})
+
+	t.Run("reads status files that contain only statuses", func(t *testing.T) {
+		err := ioutil.WriteFile(path, []byte(`{"execute": {"UPGRADE_MASTER": "COMPLETE"}}`), 0600)
+		if err != nil {
+			t.Fatalf("writing status file: %v", err)
+		}
+
+		status, err := fs.Read("execute", idl.Substep_UPGRADE_MASTER)
+		if err != nil {
+			t.Errorf("Read() returned error %#v", err)
+		}
+		if status != idl.Status_COMPLETE {
+			t.Errorf("read %v, want %v", status, idl.Status_COMPLETE)
+		}
+
+		entries, err := fs.ReadAll()
+		if err != nil {
+			t.Fatalf("ReadAll() returned error %+v", err)
+		}
+
+		entry := entries["execute"][idl.Substep_UPGRADE_MASTER]
+		if !entry.StartTime.IsZero() || !entry.EndTime.IsZero() {
+			t.Errorf("got times %v and %v, want zero values", entry.StartTime, entry.EndTime)
+		}
+	})
+
+	t.Run("records the duration, attempt count and last error of each run", func(t *testing.T) {
+		clear(t, path)
+
+		start := time.Date(2020, time.March, 1, 10, 0, 0, 0, time.UTC)
+		now := start
+		operating.System.Now = func() time.Time { return now }
+		defer func() { operating.System.Now = time.Now }()
+
+		substep := idl.Substep_UPGRADE_PRIMARIES
+		if err := fs.Write(section, substep, idl.Status_RUNNING); err != nil {
+			t.Fatalf("Write(): %+v", err)
+		}
+
+		now = start.Add(time.Minute)
+		if err := fs.Fail(section, substep, errors.New("pg_upgrade failed")); err != nil {
+			t.Fatalf("Fail(): %+v", err)
+		}
+
+		entries, err := fs.ReadAll()
+		if err != nil {
+			t.Fatalf("ReadAll() returned error %+v", err)
+		}
+
+		entry := entries[section][substep]
+		expected := step.Entry{
+			Status:    step.PrettyStatus{Status: idl.Status_FAILED},
+			StartTime: start,
+			EndTime:   start.Add(time.Minute),
+			Duration:  step.PrettyDuration{Duration: time.Minute},
+			Attempts:  1,
+			LastError: "pg_upgrade failed",
+		}
+		if !reflect.DeepEqual(entry, expected) {
+			t.Errorf("got entry %+v want %+v", entry, expected)
+		}
+
+		// Retry successfully.
+		start = start.Add(time.Hour)
+		now = start
+		if err := fs.Write(section, substep, idl.Status_RUNNING); err != nil {
+			t.Fatalf("Write(): %+v", err)
+		}
+
+		entries, err = fs.ReadAll()
+		if err != nil {
+			t.Fatalf("ReadAll() returned error %+v", err)
+		}
+
+		entry = entries[section][substep]
+		expected = step.Entry{
+			Status:    step.PrettyStatus{Status: idl.Status_RUNNING},
+			StartTime: start,
+			Attempts:  2,
+		}
+		if !reflect.DeepEqual(entry, expected) {
+			t.Errorf("got entry %+v want %+v", entry, expected)
+		}
+
+		now = start.Add(90 * time.Second)
+		if err := fs.Write(section, substep, idl.Status_COMPLETE); err != nil {
+			t.Fatalf("Write(): %+v", err)
+		}
+
+		entries, err = fs.ReadAll()
+		if err != nil {
+			t.Fatalf("ReadAll() returned error %+v", err)
+		}
+
+		entry = entries[section][substep]
+		expected = step.Entry{
+			Status:    step.PrettyStatus{Status: idl.Status_COMPLETE},
+			StartTime: start,
+			EndTime:   now,
+			Duration:  step.PrettyDuration{Duration: 90 * time.Second},
+			Attempts:  2,
+		}
+		if !reflect.DeepEqual(entry, expected) {
+			t.Errorf("got entry %+v want %+v", entry, expected)
+		}
+	})
+
+	t.Run("uses human-readable durations", func(t *testing.T) {
+		clear(t, path)
+
+		start := time.Date(2020, time.March, 1, 10, 0, 0, 0, time.UTC)
+		operating.System.Now = func() time.Time { return start }
+		defer func() { operating.System.Now = time.Now }()
+
+		substep := idl.Substep_UPGRADE_MASTER
+		if err := fs.Write(section, substep, idl.Status_RUNNING); err != nil {
+			t.Fatalf("Write(): %+v", err)
+		}
+
+		operating.System.Now = func() time.Time { return start.Add(90 * time.Second) }
+		if err := fs.Write(section, substep, idl.Status_COMPLETE); err != nil {
+			t.Fatalf("Write(): %+v", err)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading status file: %+v", err)
+		}
+
+		raw := make(map[string]map[string]struct{ Duration string })
+		if err := json.Unmarshal(data, &raw); err != nil {
+			t.Fatalf("decoding statuses: %+v", err)
+		}
+
+		key := substep.String()
+		if raw[section][key].Duration != "1m30s" {
+			t.Errorf("duration[%q][%q] = %q, want %q", section, key, raw[section][key].Duration, "1m30s")
+		}
+	})
+
+	t.Run("migrates status files written by earlier versions", func(t *testing.T) {
+		start := time.Date(2020, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+		contents := `{
+			"execute": {
+				"UPGRADE_MASTER": "COMPLETE",
+				"UPGRADE_PRIMARIES": {
+					"Status": "COMPLETE",
+					"StartTime": "2020-03-01T10:00:00Z",
+					"EndTime": "2020-03-01T10:05:00Z"
+				}
+			}
+		}`
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatalf("writing status file: %v", err)
+		}
+
+		entries, err := fs.ReadAll()
+		if err != nil {
+			t.Fatalf("ReadAll() returned error %+v", err)
+		}
+
+		expected := map[idl.Substep]step.Entry{
+			idl.Substep_UPGRADE_MASTER: {
+				Status:   step.PrettyStatus{Status: idl.Status_COMPLETE},
+				Attempts: 1,
+			},
+			idl.Substep_UPGRADE_PRIMARIES: {
+				Status:    step.PrettyStatus{Status: idl.Status_COMPLETE},
+				StartTime: start,
+				EndTime:   start.Add(5 * time.Minute),
+				Duration:  step.PrettyDuration{Duration: 5 * time.Minute},
+				Attempts:  1,
+			},
+		}
+		if !reflect.DeepEqual(entries["execute"], expected) {
+			t.Errorf("got entries %+v want %+v", entries["execute"], expected)
+		}
+
+		// Subsequent writes use the current format.
+		if err := fs.Write("execute", idl.Substep_UPGRADE_MASTER, idl.Status_RUNNING); err != nil {
+			t.Fatalf("Write(): %+v", err)
+		}
+
+		entries, err = fs.ReadAll()
+		if err != nil {
+			t.Fatalf("ReadAll() returned error %+v", err)
+		}
+
+		if attempts := entries["execute"][idl.Substep_UPGRADE_MASTER].Attempts; attempts != 2 {
+			t.Errorf("got %d attempts want 2", attempts)
+		}
+	})
+
+	t.Run("ReadAll returns an error for unknown substeps", func(t *testing.T) {
+		err := ioutil.WriteFile(path, []byte(`{"execute": {"NOT_A_SUBSTEP": "COMPLETE"}}`), 0600)
+		if err != nil {
+			t.Fatalf("writing status file: %v", err)
+		}
+
+		_, err = fs.ReadAll()
+		if err == nil {
+			t.Error("expected an error")
 		}
 	})
 }
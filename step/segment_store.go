@@ -0,0 +1,121 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package step
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/google/renameio"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// SegmentStatusFileName is the name of the file, within the state directory,
+// that records which segments have completed a substep.
+const SegmentStatusFileName = "segment_status.json"
+
+// SegmentStore records, per substep, the content IDs of the segments that
+// have completed it. This allows a substep that fails on some segments to be
+// retried on only those segments. It sits alongside the FileStore, which
+// tracks the status of the substep as a whole.
+type SegmentStore struct {
+	path string
+}
+
+func NewSegmentStore(path string) *SegmentStore {
+	return &SegmentStore{path}
+}
+
+// segmentMap is the persisted form of the store: the sorted content IDs that
+// have completed each substep, keyed by the substep name.
+type segmentMap = map[string][]int32
+
+func (s *SegmentStore) load() (segmentMap, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return make(segmentMap), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make(segmentMap)
+	if err := json.Unmarshal(data, &segments); err != nil {
+		return nil, xerrors.Errorf("reading segment status %q: %w", s.path, err)
+	}
+
+	return segments, nil
+}
+
+func (s *SegmentStore) save(segments segmentMap) error {
+	data, err := json.MarshalIndent(segments, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return renameio.WriteFile(s.path, data, 0600)
+}
+
+// Completed returns the content IDs of the segments that have completed the
+// substep. A missing store has no completed segments.
+func (s *SegmentStore) Completed(substep idl.Substep) (map[int32]bool, error) {
+	segments, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[int32]bool)
+	for _, content := range segments[substep.String()] {
+		completed[content] = true
+	}
+
+	return completed, nil
+}
+
+// MarkCompleted atomically records that the given segments have completed the
+// substep.
+func (s *SegmentStore) MarkCompleted(substep idl.Substep, contents ...int32) error {
+	if len(contents) == 0 {
+		return nil
+	}
+
+	segments, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[int32]bool)
+	var merged []int32
+	for _, content := range append(segments[substep.String()], contents...) {
+		if !seen[content] {
+			seen[content] = true
+			merged = append(merged, content)
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool { return merged[i] < merged[j] })
+	segments[substep.String()] = merged
+
+	return s.save(segments)
+}
+
+// Reset atomically forgets which segments have completed the substep, so
+// that it runs on every segment the next time.
+func (s *SegmentStore) Reset(substep idl.Substep) error {
+	segments, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := segments[substep.String()]; !ok {
+		return nil
+	}
+
+	delete(segments, substep.String())
+	return s.save(segments)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package step_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/step"
+)
+
+func TestSegmentStore(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Errorf("removing temp directory: %v", err)
+		}
+	}()
+
+	path := filepath.Join(tmpDir, step.SegmentStatusFileName)
+	store := step.NewSegmentStore(path)
+
+	t.Run("has no completed segments when the file does not exist", func(t *testing.T) {
+		completed, err := store.Completed(idl.Substep_UPGRADE_PRIMARIES)
+		if err != nil {
+			t.Fatalf("Completed() returned error %#v", err)
+		}
+
+		if len(completed) != 0 {
+			t.Errorf("got completed segments %v, want none", completed)
+		}
+	})
+
+	t.Run("returns the segments that were marked as completed", func(t *testing.T) {
+		if err := store.MarkCompleted(idl.Substep_UPGRADE_PRIMARIES, 2, 0); err != nil {
+			t.Fatalf("MarkCompleted() returned error %#v", err)
+		}
+
+		if err := store.MarkCompleted(idl.Substep_UPGRADE_PRIMARIES, 0, 1); err != nil {
+			t.Fatalf("MarkCompleted() returned error %#v", err)
+		}
+
+		if err := store.MarkCompleted(idl.Substep_UPGRADE_MIRRORS, 5); err != nil {
+			t.Fatalf("MarkCompleted() returned error %#v", err)
+		}
+
+		completed, err := store.Completed(idl.Substep_UPGRADE_PRIMARIES)
+		if err != nil {
+			t.Fatalf("Completed() returned error %#v", err)
+		}
+
+		expected := map[int32]bool{0: true, 1: true, 2: true}
+		if !reflect.DeepEqual(completed, expected) {
+			t.Errorf("got completed segments %v want %v", completed, expected)
+		}
+	})
+
+	t.Run("forgets the segments of a substep that is reset", func(t *testing.T) {
+		if err := store.Reset(idl.Substep_UPGRADE_PRIMARIES); err != nil {
+			t.Fatalf("Reset() returned error %#v", err)
+		}
+
+		completed, err := store.Completed(idl.Substep_UPGRADE_PRIMARIES)
+		if err != nil {
+			t.Fatalf("Completed() returned error %#v", err)
+		}
+
+		if len(completed) != 0 {
+			t.Errorf("got completed segments %v, want none", completed)
+		}
+
+		completed, err = store.Completed(idl.Substep_UPGRADE_MIRRORS)
+		if err != nil {
+			t.Fatalf("Completed() returned error %#v", err)
+		}
+
+		expected := map[int32]bool{5: true}
+		if !reflect.DeepEqual(completed, expected) {
+			t.Errorf("got completed segments %v want %v", completed, expected)
+		}
+	})
+
+	t.Run("errors when the file cannot be parsed", func(t *testing.T) {
+		if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := store.Completed(idl.Substep_UPGRADE_PRIMARIES)
+		if err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+}
@@ -4,10 +4,13 @@
 package step
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/greenplum-db/gp-common-go-libs/operating"
 	multierror "github.com/hashicorp/go-multierror"
@@ -15,9 +18,34 @@ import (
 
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
+// StatusFileName is the name of the file, within the state directory, that
+// backs the FileStore used by every step.
+const StatusFileName = "status.json"
+
+var (
+	substepDuration = metrics.Default.NewGauge("gpupgrade_substep_duration_seconds",
+		"How long the most recent run of each substep took.", "step", "substep")
+	substepState = metrics.Default.NewGauge("gpupgrade_substep_state",
+		"The state of each substep that has run; the series for the current state is 1.", "step", "substep", "state")
+
+	statuses = statusNames()
+)
+
+func statusNames() []string {
+	var names []string
+	for _, name := range idl.Status_name {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
 type Step struct {
+	ctx     context.Context // cancels the running substep, and skips the rest
 	name    string
 	sender  idl.MessageSender // sends substep status messages
 	store   Store             // persistent substep status storage
@@ -25,8 +53,9 @@ type Step struct {
 	err     error
 }
 
-func New(name string, sender idl.MessageSender, store Store, streams OutStreamsCloser) *Step {
+func New(ctx context.Context, name string, sender idl.MessageSender, store Store, streams OutStreamsCloser) *Step {
 	return &Step{
+		ctx:     ctx,
 		name:    name,
 		sender:  sender,
 		store:   store,
@@ -34,18 +63,12 @@ func New(name string, sender idl.MessageSender, store Store, streams OutStreamsC
 	}
 }
 
-func Begin(stateDir string, name string, sender idl.MessageSender) (*Step, error) {
-	logdir, err := utils.GetLogDir()
+func Begin(ctx context.Context, stateDir string, name string, sender idl.MessageSender) (*Step, error) {
+	log, err := OpenLog(name)
 	if err != nil {
 		return nil, err
 	}
 
-	path := filepath.Join(logdir, fmt.Sprintf("%s_%s.log", name, operating.System.Now().Format("20060102")))
-	log, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
-	if err != nil {
-		return nil, xerrors.Errorf(`step "%s": %w`, name, err)
-	}
-
 	_, err = fmt.Fprintf(log, "\n%s in progress.\n", strings.Title(name))
 	if err != nil {
 		log.Close()
@@ -59,13 +82,29 @@ func Begin(stateDir string, name string, sender idl.MessageSender) (*Step, error
 
 	streams := newMultiplexedStream(sender, log)
 
-	return New(name, sender, NewFileStore(statusPath), streams), nil
+	return New(ctx, name, sender, NewFileStore(statusPath), streams), nil
+}
+
+// OpenLog opens today's log file for the named step, for appending.
+func OpenLog(name string) (*os.File, error) {
+	logdir, err := utils.GetLogDir()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(logdir, fmt.Sprintf("%s_%s.log", name, operating.System.Now().Format("20060102")))
+	log, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, xerrors.Errorf(`step "%s": %w`, name, err)
+	}
+
+	return log, nil
 }
 
 // Returns path to status file, and if one does not exist it creates an empty
 // JSON file.
 func GetStatusFile(stateDir string) (path string, err error) {
-	path = filepath.Join(stateDir, "status.json")
+	path = filepath.Join(stateDir, StatusFileName)
 
 	f, err := os.OpenFile(path, os.O_EXCL|os.O_CREATE|os.O_WRONLY, 0600)
 	if os.IsExist(err) {
@@ -102,15 +141,20 @@ func (s *Step) Err() error {
 	return s.err
 }
 
-func (s *Step) AlwaysRun(substep idl.Substep, f func(OutStreams) error) {
+// Substep is the work done by a single substep. The context is cancelled when
+// the step is cancelled, and any processes started by the substep should be
+// stopped.
+type Substep func(context.Context, OutStreams) error
+
+func (s *Step) AlwaysRun(substep idl.Substep, f Substep) {
 	s.run(substep, f, true)
 }
 
-func (s *Step) Run(substep idl.Substep, f func(OutStreams) error) {
+func (s *Step) Run(substep idl.Substep, f Substep) {
 	s.run(substep, f, false)
 }
 
-func (s *Step) run(substep idl.Substep, f func(OutStreams) error, alwaysRun bool) {
+func (s *Step) run(substep idl.Substep, f Substep, alwaysRun bool) {
 	var err error
 	defer func() {
 		if err != nil {
@@ -122,14 +166,19 @@ func (s *Step) run(substep idl.Substep, f func(OutStreams) error, alwaysRun bool
 		return
 	}
 
+	// Once cancelled, no further substeps are started.
+	err = s.ctx.Err()
+	if err != nil {
+		return
+	}
+
 	status, err := s.store.Read(s.name, substep)
 	if err != nil {
 		return
 	}
 
 	if status == idl.Status_RUNNING {
-		// TODO: Finalize error wording and recommended action
-		err = fmt.Errorf("Found previous substep %s was running. Manual intervention needed to cleanup. Please contact support.", substep)
+		err = fmt.Errorf(`Found previous substep %s was running. Run "gpupgrade recover" to check whether it can be safely retried.`, substep)
 		s.sendStatus(substep, idl.Status_FAILED)
 		return
 	}
@@ -151,9 +200,11 @@ func (s *Step) run(substep idl.Substep, f func(OutStreams) error, alwaysRun bool
 		return
 	}
 
-	err = f(s.streams)
+	start := time.Now()
+	err = f(s.ctx, s.streams)
+	substepDuration.Set(time.Since(start).Seconds(), s.name, substep.String())
 	if err != nil {
-		if werr := s.write(substep, idl.Status_FAILED); werr != nil {
+		if werr := s.fail(substep, err); werr != nil {
 			err = multierror.Append(err, werr).ErrorOrNil()
 		}
 		return
@@ -168,10 +219,24 @@ func (s *Step) write(substep idl.Substep, status idl.Status) error {
 		return err
 	}
 
+	substepState.SetState(status.String(), statuses, s.name, substep.String())
+
 	s.sendStatus(substep, status)
 	return nil
 }
 
+func (s *Step) fail(substep idl.Substep, cause error) error {
+	err := s.store.Fail(s.name, substep, cause)
+	if err != nil {
+		return err
+	}
+
+	substepState.SetState(idl.Status_FAILED.String(), statuses, s.name, substep.String())
+
+	s.sendStatus(substep, idl.Status_FAILED)
+	return nil
+}
+
 func (s *Step) sendStatus(substep idl.Substep, status idl.Status) {
 	// A stream is not guaranteed to remain connected during execution, so
 	// errors are explicitly ignored.
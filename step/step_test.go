@@ -4,11 +4,14 @@
 package step_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -17,6 +20,7 @@ import (
 	"github.com/greenplum-db/gpupgrade/idl"
 	"github.com/greenplum-db/gpupgrade/idl/mock_idl"
 	"github.com/greenplum-db/gpupgrade/step"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
 func TestStepRun(t *testing.T) {
@@ -36,10 +40,10 @@ func TestStepRun(t *testing.T) {
 				Status: idl.Status_COMPLETE,
 			}}})
 
-		s := step.New("Initialize", server, &TestStore{}, DevNullWithClose)
+		s := step.New(context.Background(), "Initialize", server, &TestStore{}, DevNullWithClose)
 
 		var called bool
-		s.Run(idl.Substep_GENERATING_CONFIG, func(streams step.OutStreams) error {
+		s.Run(idl.Substep_GENERATING_CONFIG, func(_ context.Context, streams step.OutStreams) error {
 			called = true
 			return nil
 		})
@@ -66,10 +70,10 @@ func TestStepRun(t *testing.T) {
 			}}})
 
 		store := &TestStore{}
-		s := step.New("Initialize", server, store, DevNullWithClose)
+		s := step.New(context.Background(), "Initialize", server, store, DevNullWithClose)
 
 		var status idl.Status
-		s.Run(idl.Substep_GENERATING_CONFIG, func(streams step.OutStreams) error {
+		s.Run(idl.Substep_GENERATING_CONFIG, func(_ context.Context, streams step.OutStreams) error {
 			// save off status to verify that it is running
 			status = store.Status
 			return nil
@@ -103,10 +107,10 @@ func TestStepRun(t *testing.T) {
 			}}})
 
 		store := &TestStore{Status: idl.Status_COMPLETE}
-		s := step.New("Initialize", server, store, DevNullWithClose)
+		s := step.New(context.Background(), "Initialize", server, store, DevNullWithClose)
 
 		var called bool
-		s.AlwaysRun(idl.Substep_CHECK_UPGRADE, func(streams step.OutStreams) error {
+		s.AlwaysRun(idl.Substep_CHECK_UPGRADE, func(_ context.Context, streams step.OutStreams) error {
 			called = true
 			return nil
 		})
@@ -132,17 +136,55 @@ func TestStepRun(t *testing.T) {
 				Status: idl.Status_FAILED,
 			}}})
 
-		s := step.New("Initialize", server, &TestStore{}, DevNullWithClose)
+		store := &TestStore{}
+		s := step.New(context.Background(), "Initialize", server, store, DevNullWithClose)
 
 		var called bool
-		s.Run(idl.Substep_GENERATING_CONFIG, func(streams step.OutStreams) error {
+		expected := errors.New("oops")
+		s.Run(idl.Substep_GENERATING_CONFIG, func(_ context.Context, streams step.OutStreams) error {
 			called = true
-			return errors.New("oops")
+			return expected
 		})
 
 		if !called {
 			t.Error("expected substep to be called")
 		}
+
+		if store.Status != idl.Status_FAILED {
+			t.Errorf("got status %v want %v", store.Status, idl.Status_FAILED)
+		}
+
+		if store.LastError != expected {
+			t.Errorf("recorded error %#v want %#v", store.LastError, expected)
+		}
+	})
+
+	t.Run("reports the substep state and duration as metrics", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		server := mock_idl.NewMockCliToHub_ExecuteServer(ctrl)
+		server.EXPECT().Send(gomock.Any()).AnyTimes()
+
+		s := step.New(context.Background(), "metrics", server, &TestStore{}, DevNullWithClose)
+		s.Run(idl.Substep_GENERATING_CONFIG, func(_ context.Context, streams step.OutStreams) error {
+			return errors.New("oops")
+		})
+
+		buf := new(bytes.Buffer)
+		if err := metrics.Default.Write(buf); err != nil {
+			t.Fatalf("Write() returned error %+v", err)
+		}
+
+		for _, expected := range []string{
+			`gpupgrade_substep_duration_seconds{step="metrics",substep="GENERATING_CONFIG"} `,
+			`gpupgrade_substep_state{step="metrics",substep="GENERATING_CONFIG",state="FAILED"} 1`,
+			`gpupgrade_substep_state{step="metrics",substep="GENERATING_CONFIG",state="RUNNING"} 0`,
+		} {
+			if !strings.Contains(buf.String(), expected) {
+				t.Errorf("expected metrics to contain %q, got:\n%s", expected, buf)
+			}
+		}
 	})
 
 	t.Run("returns an error when MarkInProgress fails", func(t *testing.T) {
@@ -152,10 +194,10 @@ func TestStepRun(t *testing.T) {
 		server := mock_idl.NewMockCliToHub_ExecuteServer(ctrl)
 
 		failingStore := &TestStore{WriteErr: errors.New("oops")}
-		s := step.New("Initialize", server, failingStore, DevNullWithClose)
+		s := step.New(context.Background(), "Initialize", server, failingStore, DevNullWithClose)
 
 		var called bool
-		s.Run(idl.Substep_CHECK_UPGRADE, func(streams step.OutStreams) error {
+		s.Run(idl.Substep_CHECK_UPGRADE, func(_ context.Context, streams step.OutStreams) error {
 			called = true
 			return nil
 		})
@@ -181,10 +223,10 @@ func TestStepRun(t *testing.T) {
 			}}})
 
 		store := &TestStore{Status: idl.Status_COMPLETE}
-		s := step.New("Initialize", server, store, DevNullWithClose)
+		s := step.New(context.Background(), "Initialize", server, store, DevNullWithClose)
 
 		var called bool
-		s.Run(idl.Substep_CHECK_UPGRADE, func(streams step.OutStreams) error {
+		s.Run(idl.Substep_CHECK_UPGRADE, func(_ context.Context, streams step.OutStreams) error {
 			called = true
 			return nil
 		})
@@ -201,15 +243,15 @@ func TestStepRun(t *testing.T) {
 		server := mock_idl.NewMockCliToHub_ExecuteServer(ctrl)
 		server.EXPECT().Send(gomock.Any()).AnyTimes()
 
-		s := step.New("Initialize", server, &TestStore{}, DevNullWithClose)
+		s := step.New(context.Background(), "Initialize", server, &TestStore{}, DevNullWithClose)
 
 		expected := errors.New("oops")
-		s.Run(idl.Substep_GENERATING_CONFIG, func(streams step.OutStreams) error {
+		s.Run(idl.Substep_GENERATING_CONFIG, func(_ context.Context, streams step.OutStreams) error {
 			return expected
 		})
 
 		var called bool
-		s.Run(idl.Substep_START_AGENTS, func(streams step.OutStreams) error {
+		s.Run(idl.Substep_START_AGENTS, func(_ context.Context, streams step.OutStreams) error {
 			called = true
 			return nil
 		})
@@ -223,6 +265,80 @@ func TestStepRun(t *testing.T) {
 		}
 	})
 
+	t.Run("marks a cancelled substep as failed and skips subsequent substeps", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		server := mock_idl.NewMockCliToHub_ExecuteServer(ctrl)
+		server.EXPECT().Send(gomock.Any()).AnyTimes()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		store := &TestStore{}
+		s := step.New(ctx, "Initialize", server, store, DevNullWithClose)
+
+		s.Run(idl.Substep_GENERATING_CONFIG, func(ctx context.Context, streams step.OutStreams) error {
+			cancel()
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		if store.Status != idl.Status_FAILED {
+			t.Errorf("got status %v want %v", store.Status, idl.Status_FAILED)
+		}
+
+		if !xerrors.Is(store.LastError, context.Canceled) {
+			t.Errorf("got last error %#v want %#v", store.LastError, context.Canceled)
+		}
+
+		var called bool
+		s.Run(idl.Substep_START_AGENTS, func(_ context.Context, streams step.OutStreams) error {
+			called = true
+			return nil
+		})
+
+		if called {
+			t.Error("expected substep to be skipped")
+		}
+
+		if !xerrors.Is(s.Err(), context.Canceled) {
+			t.Errorf("got error %#v want %#v", s.Err(), context.Canceled)
+		}
+	})
+
+	t.Run("does not start substeps once cancelled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		server := mock_idl.NewMockCliToHub_ExecuteServer(ctrl)
+		// No status is sent for a substep that is not started.
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		store := &TestStore{}
+		s := step.New(ctx, "Initialize", server, store, DevNullWithClose)
+
+		var called bool
+		s.Run(idl.Substep_GENERATING_CONFIG, func(_ context.Context, streams step.OutStreams) error {
+			called = true
+			return nil
+		})
+
+		if called {
+			t.Error("expected substep to be skipped")
+		}
+
+		if store.Status != idl.Status_UNKNOWN_STATUS {
+			t.Errorf("got status %v want %v", store.Status, idl.Status_UNKNOWN_STATUS)
+		}
+
+		if !xerrors.Is(s.Err(), context.Canceled) {
+			t.Errorf("got error %#v want %#v", s.Err(), context.Canceled)
+		}
+	})
+
 	t.Run("for a substep that was running mark it as failed", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -231,10 +347,10 @@ func TestStepRun(t *testing.T) {
 		server.EXPECT().Send(gomock.Any()).AnyTimes()
 
 		store := &TestStore{Status: idl.Status_RUNNING}
-		s := step.New("Initialize", server, store, DevNullWithClose)
+		s := step.New(context.Background(), "Initialize", server, store, DevNullWithClose)
 
 		var called bool
-		s.Run(idl.Substep_GENERATING_CONFIG, func(streams step.OutStreams) error {
+		s.Run(idl.Substep_GENERATING_CONFIG, func(_ context.Context, streams step.OutStreams) error {
 			called = true
 			return nil
 		})
@@ -252,7 +368,7 @@ func TestStepRun(t *testing.T) {
 func TestStepFinish(t *testing.T) {
 	t.Run("closes the output streams", func(t *testing.T) {
 		streams := &devNullWithClose{}
-		s := step.New("Initialize", nil, nil, streams)
+		s := step.New(context.Background(), "Initialize", nil, nil, streams)
 
 		err := s.Finish()
 		if err != nil {
@@ -267,7 +383,7 @@ func TestStepFinish(t *testing.T) {
 	t.Run("returns an error when failing to close the output streams", func(t *testing.T) {
 		expected := errors.New("oops")
 		streams := &devNullWithClose{CloseErr: expected}
-		s := step.New("Initialize", nil, nil, streams)
+		s := step.New(context.Background(), "Initialize", nil, nil, streams)
 
 		err := s.Finish()
 		if !xerrors.Is(err, expected) {
@@ -335,8 +451,9 @@ func TestStatusFile(t *testing.T) {
 }
 
 type TestStore struct {
-	Status   idl.Status
-	WriteErr error
+	Status    idl.Status
+	LastError error
+	WriteErr  error
 }
 
 func (t *TestStore) Read(_ string, substep idl.Substep) (idl.Status, error) {
@@ -348,6 +465,12 @@ func (t *TestStore) Write(_ string, substep idl.Substep, status idl.Status) (err
 	return t.WriteErr
 }
 
+func (t *TestStore) Fail(_ string, substep idl.Substep, cause error) (err error) {
+	t.Status = idl.Status_FAILED
+	t.LastError = cause
+	return t.WriteErr
+}
+
 // DevNullWithClose implements step.OutStreamsCloser as a no-op. It also tracks calls to
 // Close().
 var DevNullWithClose = &devNullWithClose{}
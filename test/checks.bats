@@ -117,3 +117,36 @@ are_equivalent_within_tolerance() {
         fail "the required bytes ($required_bytes) are not within 0.1% of the total disk space ($total_space)"
     fi
 }
+
+@test "initialize saves the results of the checks in the state directory" {
+    run gpupgrade initialize \
+        --disk-free-ratio=1.0 \
+        --source-bindir="$PWD" \
+        --target-bindir="$PWD" \
+        --source-master-port="${PGPORT}" \
+        --only-check=disk_space \
+        --stop-before-cluster-creation 3>&-
+
+    [ "$status" -eq 1 ]
+
+    grep -E '^disk_space +FAILED' "$GPUPGRADE_HOME/check_results.txt" || fail "actual results: $(cat "$GPUPGRADE_HOME/check_results.txt")"
+}
+
+@test "check rejects unknown checks" {
+    run gpupgrade check nonesuch
+    [ "$status" -eq 1 ]
+    [[ $output = *'unknown check "nonesuch"'* ]] || fail "actual output: $output"
+}
+
+@test "initialize rejects --disk-free-ratio with --estimate-disk-space" {
+    run gpupgrade initialize \
+        --disk-free-ratio=0.5 \
+        --estimate-disk-space \
+        --source-bindir="$PWD" \
+        --target-bindir="$PWD" \
+        --source-master-port="${PGPORT}" \
+        --stop-before-cluster-creation 3>&-
+
+    [ "$status" -eq 1 ]
+    [[ $output = *'cannot be used together'* ]] || fail "actual output: $output"
+}
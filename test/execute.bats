@@ -110,7 +110,7 @@ reset_master_and_primary_pg_control_files() {
     PGPORT=6020 ensure_hardlinks_for_relfilenode_on_master_and_segments 'test_linking' 2
 }
 
-@test "gpupgrade execute step to upgrade master should always rsync the master data dir from backup" {
+@test "gpupgrade execute step to upgrade master should always restore the master data dir from backup" {
     require_gnu_stat
     set_master_and_primary_datadirs
 
@@ -135,8 +135,8 @@ reset_master_and_primary_pg_control_files() {
     # target master data directory with the backup.
     rm -rf "${datadir}"/*
     
-    # create an extra file to ensure that its deleted during rsync as we pass
-    # --delete flag
+    # create an extra file to ensure that its deleted during the copy, as we
+    # delete anything not in the backup
     mkdir "${datadir}"/base_extra
     touch "${datadir}"/base_extra/1101
     gpupgrade execute --verbose
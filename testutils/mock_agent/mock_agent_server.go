@@ -6,15 +6,21 @@ package mock_agent
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"sync"
 
 	"github.com/greenplum-db/gpupgrade/hub"
 	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils/handshake"
+	"github.com/greenplum-db/gpupgrade/utils/health"
 
 	"google.golang.org/grpc"
 )
 
+// Version is the version of gpupgrade that the mock agent reports.
+const Version = "mock-agent"
+
 type MockAgentServer struct {
 	addr       net.Addr
 	grpcServer *grpc.Server
@@ -40,13 +46,20 @@ func NewMockAgentServer() (*MockAgentServer, hub.Dialer, int) {
 		panic(err)
 	}
 
+	// Report a version, as a real agent does.
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		health.SendVersion(ctx, Version)
+		return handler(ctx, req)
+	}
+
 	mockServer := &MockAgentServer{
 		addr:       lis.Addr(),
-		grpcServer: grpc.NewServer(),
+		grpcServer: grpc.NewServer(grpc.UnaryInterceptor(interceptor)),
 		Err:        make(chan error, 10000),
 	}
 
 	idl.RegisterAgentServer(mockServer.grpcServer, mockServer)
+	health.Register(mockServer.grpcServer)
 
 	go func() {
 		_ = mockServer.grpcServer.Serve(lis)
@@ -68,7 +81,7 @@ func (m *MockAgentServer) CheckDiskSpace(context.Context, *idl.CheckSegmentDiskS
 	return &idl.CheckDiskSpaceReply{}, nil
 }
 
-func (m *MockAgentServer) UpgradePrimaries(ctx context.Context, in *idl.UpgradePrimariesRequest) (*idl.UpgradePrimariesReply, error) {
+func (m *MockAgentServer) UpgradePrimaries(in *idl.UpgradePrimariesRequest, stream idl.Agent_UpgradePrimariesServer) error {
 	m.increaseCalls()
 
 	m.mu.Lock()
@@ -80,7 +93,7 @@ func (m *MockAgentServer) UpgradePrimaries(ctx context.Context, in *idl.UpgradeP
 		err = <-m.Err
 	}
 
-	return &idl.UpgradePrimariesReply{}, err
+	return err
 }
 
 func (m *MockAgentServer) RenameDirectories(context.Context, *idl.RenameDirectoriesRequest) (*idl.RenameDirectoriesReply, error) {
@@ -88,6 +101,11 @@ func (m *MockAgentServer) RenameDirectories(context.Context, *idl.RenameDirector
 	return &idl.RenameDirectoriesReply{}, nil
 }
 
+func (m *MockAgentServer) CheckRenameDirectories(context.Context, *idl.RenameDirectoriesRequest) (*idl.CheckRenameDirectoriesReply, error) {
+	m.increaseCalls()
+	return &idl.CheckRenameDirectoriesReply{}, nil
+}
+
 func (m *MockAgentServer) DeleteDataDirectories(context.Context, *idl.DeleteDataDirectoriesRequest) (*idl.DeleteDataDirectoriesReply, error) {
 	m.increaseCalls()
 	return &idl.DeleteDataDirectoriesReply{}, nil
@@ -103,6 +121,59 @@ func (m *MockAgentServer) ArchiveLogDirectory(context.Context, *idl.ArchiveLogDi
 	return &idl.ArchiveLogDirectoryReply{}, nil
 }
 
+func (m *MockAgentServer) SyncDirectory(stream idl.Agent_SyncDirectoryServer) error {
+	m.increaseCalls()
+
+	// Consume the entries without writing them, and need none of the files.
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if msg.GetEntriesDone() {
+			break
+		}
+	}
+
+	err := stream.Send(&idl.SyncDirectoryReply{Contents: &idl.SyncDirectoryReply_Needed{
+		Needed: &idl.NeededFiles{Done: true},
+	}})
+	if err != nil {
+		return err
+	}
+
+	return stream.Send(&idl.SyncDirectoryReply{Contents: &idl.SyncDirectoryReply_Stats{
+		Stats: &idl.SyncStats{},
+	}})
+}
+
+func (m *MockAgentServer) VerifyDirectory(stream idl.Agent_VerifyDirectoryServer) error {
+	m.increaseCalls()
+
+	// Consume the manifest without checking it.
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&idl.VerifyDirectoryReply{})
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (m *MockAgentServer) CheckPorts(context.Context, *idl.CheckPortsRequest) (*idl.CheckPortsReply, error) {
+	m.increaseCalls()
+	return &idl.CheckPortsReply{}, nil
+}
+
+// Hello is not counted by NumberOfCalls, since the hub greets every agent it
+// connects to.
+func (m *MockAgentServer) Hello(context.Context, *idl.HelloRequest) (*idl.HelloReply, error) {
+	return handshake.Reply(Version), nil
+}
+
 func (m *MockAgentServer) StopAgent(ctx context.Context, in *idl.StopAgentRequest) (*idl.StopAgentReply, error) {
 	return &idl.StopAgentReply{}, nil
 }
@@ -21,6 +21,18 @@ const OldSuffix = ".old"
 var PostgresFiles = []string{"postgresql.conf", "PG_VERSION"}
 var StateDirectoryFiles = []string{"config.json", "status.json"}
 
+// SegmentBackupExclusions are the files in the upgraded master's backup that
+// are not restored onto the primaries, since each segment keeps its own.
+var SegmentBackupExclusions = []string{
+	"internal.auto.conf",
+	"postgresql.conf",
+	"pg_hba.conf",
+	"postmaster.opts",
+	"gp_dbid",
+	"gpssh.conf",
+	"gpperfmon",
+}
+
 // pgUpgradeDirectory returns a path to a directory underneath the state
 // directory that is to be used for storing pg_upgrade state. It does not ensure
 // the directory exists first.
@@ -109,6 +121,36 @@ func ArchiveSource(source, target string, renameTarget bool) error {
 	return nil
 }
 
+// CheckArchiveSource returns an error describing the manual cleanup needed
+// when ArchiveSource cannot safely be (re-)run with the same arguments, such
+// as after an interrupted rename left the directories in an unexpected state.
+// A rename that was interrupted between archiving the source and renaming the
+// target is completed by ArchiveSource, so it is not reported.
+func CheckArchiveSource(source, target string, renameTarget bool) error {
+	archive := target + OldSuffix
+	if alreadyRenamed(archive, target) {
+		return nil
+	}
+
+	sourceExists, archiveExists := PathExists(source), PathExists(archive)
+
+	switch {
+	case sourceExists && archiveExists:
+		return fmt.Errorf("both the source directory %q and its archive %q exist. "+
+			"Determine which one holds the original source data directory, and move the other out of the way.", source, archive)
+
+	case !sourceExists && !archiveExists:
+		return fmt.Errorf("neither the source directory %q nor its archive %q exist. "+
+			"Restore the source data directory to %q.", source, archive, source)
+
+	case renameTarget && !PathExists(target):
+		return fmt.Errorf("the target directory %q does not exist. "+
+			"Restore the upgraded data directory to %q.", target, target)
+	}
+
+	return nil
+}
+
 func renameDataDirectory(src, dst string) error {
 	if err := verifyDataDirectory(src); err != nil {
 		return err
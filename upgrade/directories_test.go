@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/greenplum-db/gp-common-go-libs/testhelper"
@@ -299,6 +300,97 @@ func TestArchiveSource(t *testing.T) {
 	})
 }
 
+func TestCheckArchiveSource(t *testing.T) {
+	t.Run("succeeds before any renames and after all renames", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		if err := upgrade.CheckArchiveSource(source, target, true); err != nil {
+			t.Errorf("unexpected error before renaming: %#v", err)
+		}
+
+		if err := upgrade.ArchiveSource(source, target, true); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if err := upgrade.CheckArchiveSource(source, target, true); err != nil {
+			t.Errorf("unexpected error after renaming: %#v", err)
+		}
+	})
+
+	t.Run("succeeds when only the source has been archived", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		if err := os.Rename(source, target+upgrade.OldSuffix); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if err := upgrade.CheckArchiveSource(source, target, true); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("reports directories that need manual cleanup", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			setup    func(source, target string) error
+			expected string
+		}{
+			{
+				name: "source and archive both exist",
+				setup: func(source, target string) error {
+					return os.Mkdir(target+upgrade.OldSuffix, 0700)
+				},
+				expected: "both the source directory",
+			},
+			{
+				name: "source and archive are both missing",
+				setup: func(source, target string) error {
+					return os.RemoveAll(source)
+				},
+				expected: "neither the source directory",
+			},
+			{
+				name: "target is missing",
+				setup: func(source, target string) error {
+					return os.RemoveAll(target)
+				},
+				expected: "the target directory",
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				source, target, cleanup := testutils.MustCreateDataDirs(t)
+				defer cleanup(t)
+
+				if err := c.setup(source, target); err != nil {
+					t.Fatalf("setup: %#v", err)
+				}
+
+				err := upgrade.CheckArchiveSource(source, target, true)
+				if err == nil || !strings.Contains(err.Error(), c.expected) {
+					t.Errorf("got error %v, want it to contain %q", err, c.expected)
+				}
+			})
+		}
+	})
+
+	t.Run("does not require a target when renameTarget is false", func(t *testing.T) {
+		source, target, cleanup := testutils.MustCreateDataDirs(t)
+		defer cleanup(t)
+
+		if err := os.RemoveAll(target); err != nil {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+
+		if err := upgrade.CheckArchiveSource(source, target, false); err != nil {
+			t.Errorf("unexpected error: %#v", err)
+		}
+	})
+}
+
 func setup(t *testing.T) (teardown func(), directories []string, requiredPaths []string) {
 	requiredPaths = []string{"pg_file1", "pg_file2"}
 	var dataDirectories = []string{"/data/dbfast_mirror1/seg1", "/data/dbfast_mirror2/seg2"}
@@ -4,6 +4,7 @@
 package upgrade
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +13,9 @@ import (
 	"strconv"
 
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
+
+	"github.com/greenplum-db/gpupgrade/utils"
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
 )
 
 const DefaultAgentPort = 6416
@@ -20,6 +24,9 @@ const DefaultAgentPort = 6416
 // also the WithExecCommand option.
 var execCommand = exec.Command
 
+var runningProcesses = metrics.Default.NewGauge("gpupgrade_pg_upgrade_processes",
+	"Number of pg_upgrade processes currently running on the host.", "host")
+
 // Segment holds the information needed to upgrade a single GPDB segment.
 type Segment struct {
 	BinDir  string
@@ -38,7 +45,51 @@ type SegmentPair struct {
 // Options.
 func Run(p SegmentPair, options ...Option) error {
 	opts := newOptionList(options)
+	path, args := command(p, opts)
+
+	// If the caller specified an explicit Command implementation to use, get
+	// our exec.Cmd using that. Otherwise use our internal execCommand.
+	cmdFunc := execCommand
+	if opts.ExecCommandSet {
+		cmdFunc = opts.ExecCommand
+	}
+	cmd := cmdFunc(path, args...)
+
+	cmd.Dir = opts.Dir
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+
+	// Explicitly clear the child environment. pg_upgrade shouldn't need things
+	// like PATH, and PGPORT et al are explicitly forbidden to be set.
+	cmd.Env = []string{}
+
+	// XXX ...but we make a single exception for now, for LD_LIBRARY_PATH, to
+	// work around pervasive problems with RPATH settings in our Postgres
+	// extension modules.
+	if path, ok := os.LookupEnv("LD_LIBRARY_PATH"); ok {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("LD_LIBRARY_PATH=%s", path))
+	}
+
+	gplog.Info(cmd.String())
+
+	host, err := utils.System.Hostname()
+	if err != nil {
+		gplog.Debug("getting hostname for metrics: %+v", err)
+	}
+
+	runningProcesses.Add(1, host)
+	defer runningProcesses.Add(-1, host)
+
+	return utils.RunContext(opts.Context, cmd)
+}
 
+// Command returns the path to pg_upgrade and the arguments that Run passes to
+// it for the given pair of Segments and Options.
+func Command(p SegmentPair, options ...Option) (string, []string) {
+	return command(p, newOptionList(options))
+}
+
+func command(p SegmentPair, opts *optionList) (string, []string) {
 	mode := "dispatcher"
 	if opts.SegmentMode {
 		mode = "segment"
@@ -70,32 +121,7 @@ func Run(p SegmentPair, options ...Option) error {
 		args = append(args, "--old-tablespaces-file", opts.TablespaceFilePath)
 	}
 
-	// If the caller specified an explicit Command implementation to use, get
-	// our exec.Cmd using that. Otherwise use our internal execCommand.
-	cmdFunc := execCommand
-	if opts.ExecCommandSet {
-		cmdFunc = opts.ExecCommand
-	}
-	cmd := cmdFunc(path, args...)
-
-	cmd.Dir = opts.Dir
-	cmd.Stdout = opts.Stdout
-	cmd.Stderr = opts.Stderr
-
-	// Explicitly clear the child environment. pg_upgrade shouldn't need things
-	// like PATH, and PGPORT et al are explicitly forbidden to be set.
-	cmd.Env = []string{}
-
-	// XXX ...but we make a single exception for now, for LD_LIBRARY_PATH, to
-	// work around pervasive problems with RPATH settings in our Postgres
-	// extension modules.
-	if path, ok := os.LookupEnv("LD_LIBRARY_PATH"); ok {
-		cmd.Env = append(cmd.Env, fmt.Sprintf("LD_LIBRARY_PATH=%s", path))
-	}
-
-	gplog.Info(cmd.String())
-
-	return cmd.Run()
+	return path, args
 }
 
 // Option configures the way Run executes pg_upgrade.
@@ -153,6 +179,14 @@ func WithExecCommand(execCommand func(string, ...string) *exec.Cmd) Option {
 	}
 }
 
+// WithContext configures a context that stops pg_upgrade when it is
+// cancelled. By default pg_upgrade runs to completion.
+func WithContext(ctx context.Context) Option {
+	return func(o *optionList) {
+		o.Context = ctx
+	}
+}
+
 // WithTablespaceFile configures the tablespace mapping file path passed to pg_upgrade
 // to perform the upgrade of the segment tablespaces.
 func WithTablespaceFile(filePath string) Option {
@@ -164,6 +198,7 @@ func WithTablespaceFile(filePath string) Option {
 // optionList holds the combined result of all possible Options. Zero values
 // represent the default settings.
 type optionList struct {
+	Context            context.Context
 	Dir                string
 	CheckOnly          bool
 	UseLinkMode        bool
@@ -176,7 +211,7 @@ type optionList struct {
 
 // newOptionList returns an optionList with all of the provided Options applied.
 func newOptionList(opts []Option) *optionList {
-	options := new(optionList)
+	options := &optionList{Context: context.Background()}
 	for _, opt := range opts {
 		opt(options)
 	}
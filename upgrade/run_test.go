@@ -6,6 +6,7 @@ package upgrade_test
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -13,6 +14,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/xerrors"
 
@@ -47,10 +49,16 @@ func EnvironmentMain() {
 	}
 }
 
+// Sleeps long enough to be cancelled.
+func SleepMain() {
+	time.Sleep(30 * time.Second)
+}
+
 func init() {
 	exectest.RegisterMains(
 		Success,
 		Failure,
+		SleepMain,
 		PrintMain,
 		WorkingDirectoryMain,
 		EnvironmentMain,
@@ -209,6 +217,19 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("stops pg_upgrade when the context is cancelled", func(t *testing.T) {
+		upgrade.SetExecCommand(exectest.NewCommand(SleepMain))
+		defer upgrade.ResetExecCommand()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := upgrade.Run(pair, upgrade.WithContext(ctx))
+		if !xerrors.Is(err, context.Canceled) {
+			t.Errorf("got error %#v want %#v", err, context.Canceled)
+		}
+	})
+
 	t.Run("calls pg_upgrade with the correct arguments for", func(t *testing.T) {
 		argsTest := func(t *testing.T, opts ...upgrade.Option) {
 			t.Helper()
@@ -297,3 +318,37 @@ func TestRun(t *testing.T) {
 		}
 	})
 }
+
+func TestCommand(t *testing.T) {
+	pair := upgrade.SegmentPair{
+		Source: &upgrade.Segment{BinDir: "/old/bin", DataDir: "/old/data", DBID: 10, Port: 15432},
+		Target: &upgrade.Segment{BinDir: "/new/bin", DataDir: "/new/data", DBID: 20, Port: 15433},
+	}
+	options := []upgrade.Option{upgrade.WithSegmentMode(), upgrade.WithLinkMode(), upgrade.WithTablespaceFile("/tablespaces.txt")}
+
+	path, args := upgrade.Command(pair, options...)
+
+	var called bool
+	cmd := exectest.NewCommandWithVerifier(Success, func(actualPath string, actualArgs ...string) {
+		called = true
+
+		if actualPath != path {
+			t.Errorf("Run() executed %q, Command() returned %q", actualPath, path)
+		}
+
+		if strings.Join(actualArgs, " ") != strings.Join(args, " ") {
+			t.Errorf("Run() passed arguments %q, Command() returned %q", actualArgs, args)
+		}
+	})
+
+	upgrade.SetExecCommand(cmd)
+	defer upgrade.ResetExecCommand()
+
+	if err := upgrade.Run(pair, options...); err != nil {
+		t.Fatalf("Run() returned error %+v", err)
+	}
+
+	if !called {
+		t.Errorf("pg_upgrade was not executed")
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+// Package certs provides the optional mutual TLS used between the CLI, hub and
+// agents. A self-signed CA is generated in the state directory during
+// initialize, and used to sign one certificate for the hub and CLI, and another
+// for the agents. Each side only accepts peers whose certificates were signed
+// by that CA.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// DirName is the name of the directory, within the state directory, that
+// holds the certificates.
+const DirName = "certs"
+
+const (
+	caCertName    = "ca.crt"
+	caKeyName     = "ca.key"
+	hubCertName   = "hub.crt"
+	hubKeyName    = "hub.key"
+	agentCertName = "agent.crt"
+	agentKeyName  = "agent.key"
+)
+
+// Validity is how long generated certificates are valid for.
+var Validity = 365 * 24 * time.Hour
+
+// Config holds the paths to the PEM files used for mutual TLS. TLS is
+// disabled when the paths are empty.
+type Config struct {
+	CACert string // the CA that peer certificates must be signed by
+	Cert   string
+	Key    string
+}
+
+func (c Config) Enabled() bool {
+	return c.CACert != ""
+}
+
+// Dir returns the certificate directory within the state directory.
+func Dir(stateDir string) string {
+	return filepath.Join(stateDir, DirName)
+}
+
+// HubConfig returns the certificate paths used by the hub and the CLI.
+func HubConfig(stateDir string) Config {
+	dir := Dir(stateDir)
+	return Config{
+		CACert: filepath.Join(dir, caCertName),
+		Cert:   filepath.Join(dir, hubCertName),
+		Key:    filepath.Join(dir, hubKeyName),
+	}
+}
+
+// AgentConfig returns the certificate paths used by the agents. The agents
+// use the same state directory path as the hub, so these paths are valid on
+// every host.
+func AgentConfig(stateDir string) Config {
+	dir := Dir(stateDir)
+	return Config{
+		CACert: filepath.Join(dir, caCertName),
+		Cert:   filepath.Join(dir, agentCertName),
+		Key:    filepath.Join(dir, agentKeyName),
+	}
+}
+
+// AgentFiles returns the files that must be copied to each agent host. The CA
+// key is deliberately excluded.
+func AgentFiles(stateDir string) []string {
+	c := AgentConfig(stateDir)
+	return []string{c.CACert, c.Cert, c.Key}
+}
+
+// GenerateHub creates a self-signed CA in the state directory, and uses it to
+// sign a certificate for the hub and CLI on this host. Existing certificates
+// are kept, so that initialize can be rerun.
+func GenerateHub(stateDir string) (Config, error) {
+	conf := HubConfig(stateDir)
+	if _, err := os.Stat(conf.Cert); err == nil {
+		return conf, nil
+	}
+
+	if err := os.MkdirAll(Dir(stateDir), 0700); err != nil {
+		return Config{}, xerrors.Errorf("creating certificate directory: %w", err)
+	}
+
+	caTemplate, err := newTemplate("gpupgrade CA")
+	if err != nil {
+		return Config{}, err
+	}
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Config{}, xerrors.Errorf("generating CA key: %w", err)
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return Config{}, xerrors.Errorf("creating CA certificate: %w", err)
+	}
+
+	caKeyPath := filepath.Join(Dir(stateDir), caKeyName)
+	if err := writeCertAndKey(conf.CACert, caDER, caKeyPath, caKey); err != nil {
+		return Config{}, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return Config{}, err
+	}
+
+	hosts := []string{"localhost", "127.0.0.1", "::1", hostname}
+	if err := issue(stateDir, "gpupgrade hub", hosts, conf.Cert, conf.Key); err != nil {
+		return Config{}, err
+	}
+
+	return conf, nil
+}
+
+// GenerateAgent uses the CA in the state directory to sign a certificate for
+// the agents running on the given hosts.
+func GenerateAgent(stateDir string, hosts []string) (Config, error) {
+	conf := AgentConfig(stateDir)
+	if err := issue(stateDir, "gpupgrade agent", hosts, conf.Cert, conf.Key); err != nil {
+		return Config{}, err
+	}
+
+	return conf, nil
+}
+
+func issue(stateDir string, name string, hosts []string, certPath, keyPath string) error {
+	ca, caKey, err := loadCA(stateDir)
+	if err != nil {
+		return err
+	}
+
+	template, err := newTemplate(name)
+	if err != nil {
+		return err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	// The same certificate is used both to serve, and to dial other processes.
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return xerrors.Errorf("generating %s key: %w", name, err)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return xerrors.Errorf("creating %s certificate: %w", name, err)
+	}
+
+	return writeCertAndKey(certPath, der, keyPath, key)
+}
+
+func newTemplate(name string) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, xerrors.Errorf("generating serial number: %w", err)
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    now.Add(-time.Hour), // allow for clock skew between hosts
+		NotAfter:     now.Add(Validity),
+	}, nil
+}
+
+func loadCA(stateDir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPEM, err := ioutil.ReadFile(filepath.Join(Dir(stateDir), caCertName))
+	if err != nil {
+		return nil, nil, xerrors.Errorf("reading CA certificate: %w", err)
+	}
+
+	keyPEM, err := ioutil.ReadFile(filepath.Join(Dir(stateDir), caKeyName))
+	if err != nil {
+		return nil, nil, xerrors.Errorf("reading CA key: %w", err)
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, xerrors.New("CA certificate or key is not PEM encoded")
+	}
+
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("parsing CA certificate: %w", err)
+	}
+
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("parsing CA key: %w", err)
+	}
+
+	return cert, key, nil
+}
+
+func writeCertAndKey(certPath string, der []byte, keyPath string, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return xerrors.Errorf("encoding key: %w", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := ioutil.WriteFile(certPath, certPEM, 0600); err != nil {
+		return xerrors.Errorf("writing certificate: %w", err)
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := ioutil.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return xerrors.Errorf("writing key: %w", err)
+	}
+
+	return nil
+}
+
+// ServerOptions returns the options for a gRPC server that only accepts
+// clients with certificates signed by the CA. No options are returned when
+// TLS is disabled.
+func ServerOptions(c Config) ([]grpc.ServerOption, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+
+	cert, pool, err := load(c)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	return []grpc.ServerOption{grpc.Creds(creds)}, nil
+}
+
+// DialOption returns the option for dialing a gRPC server, presenting our
+// certificate and only trusting servers signed by the CA. When TLS is
+// disabled, the connection is insecure.
+func DialOption(c Config) (grpc.DialOption, error) {
+	if !c.Enabled() {
+		return grpc.WithInsecure(), nil
+	}
+
+	cert, pool, err := load(c)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	return grpc.WithTransportCredentials(creds), nil
+}
+
+func load(c Config) (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+	if err != nil {
+		return tls.Certificate{}, nil, xerrors.Errorf("loading TLS certificate: %w", err)
+	}
+
+	caPEM, err := ioutil.ReadFile(c.CACert)
+	if err != nil {
+		return tls.Certificate{}, nil, xerrors.Errorf("reading CA certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, xerrors.Errorf("no certificates found in %q", c.CACert)
+	}
+
+	return cert, pool, nil
+}
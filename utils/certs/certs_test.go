@@ -0,0 +1,169 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package certs_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/greenplum-db/gpupgrade/utils/certs"
+)
+
+func TestGenerate(t *testing.T) {
+	stateDir := tempDir(t)
+	defer os.RemoveAll(stateDir)
+
+	hubConf, err := certs.GenerateHub(stateDir)
+	if err != nil {
+		t.Fatalf("GenerateHub() returned error %+v", err)
+	}
+
+	if hubConf != certs.HubConfig(stateDir) {
+		t.Errorf("got config %+v want %+v", hubConf, certs.HubConfig(stateDir))
+	}
+
+	agentConf, err := certs.GenerateAgent(stateDir, []string{"localhost", "sdw1"})
+	if err != nil {
+		t.Fatalf("GenerateAgent() returned error %+v", err)
+	}
+
+	t.Run("keys are only readable by the owner", func(t *testing.T) {
+		for _, path := range []string{hubConf.Key, agentConf.Key} {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("stat %q: %+v", path, err)
+			}
+
+			if info.Mode().Perm() != 0600 {
+				t.Errorf("got mode %v for %q want %v", info.Mode().Perm(), path, os.FileMode(0600))
+			}
+		}
+	})
+
+	t.Run("keeps the existing CA when run again", func(t *testing.T) {
+		before, err := ioutil.ReadFile(hubConf.CACert)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := certs.GenerateHub(stateDir); err != nil {
+			t.Fatalf("GenerateHub() returned error %+v", err)
+		}
+
+		after, err := ioutil.ReadFile(hubConf.CACert)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(before) != string(after) {
+			t.Error("expected the CA certificate to be unchanged")
+		}
+	})
+
+	t.Run("the hub can connect to an agent", func(t *testing.T) {
+		addr := serve(t, agentConf)
+
+		if err := dial(addr, hubConf); err != nil {
+			t.Errorf("dial returned error %+v", err)
+		}
+	})
+
+	t.Run("an agent rejects insecure connections", func(t *testing.T) {
+		addr := serve(t, agentConf)
+
+		if err := dial(addr, certs.Config{}); err == nil {
+			t.Error("expected dial to fail")
+		}
+	})
+
+	t.Run("an agent rejects certificates from another CA", func(t *testing.T) {
+		otherDir := tempDir(t)
+		defer os.RemoveAll(otherDir)
+
+		otherConf, err := certs.GenerateHub(otherDir)
+		if err != nil {
+			t.Fatalf("GenerateHub() returned error %+v", err)
+		}
+
+		addr := serve(t, agentConf)
+
+		if err := dial(addr, otherConf); err == nil {
+			t.Error("expected dial to fail")
+		}
+	})
+}
+
+func TestDisabled(t *testing.T) {
+	opts, err := certs.ServerOptions(certs.Config{})
+	if err != nil {
+		t.Errorf("ServerOptions() returned error %+v", err)
+	}
+
+	if len(opts) != 0 {
+		t.Errorf("got %d server options want none", len(opts))
+	}
+
+	addr := serve(t, certs.Config{})
+	if err := dial(addr, certs.Config{}); err != nil {
+		t.Errorf("dial returned error %+v", err)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+// serve starts a gRPC server using conf, which is stopped at the end of the
+// test, and returns its address.
+func serve(t *testing.T, conf certs.Config) string {
+	t.Helper()
+
+	opts, err := certs.ServerOptions(conf)
+	if err != nil {
+		t.Fatalf("ServerOptions() returned error %+v", err)
+	}
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer(opts...)
+	go server.Serve(lis) //nolint
+	t.Cleanup(server.Stop)
+
+	// Dial by name, which must match the certificate.
+	return fmt.Sprintf("localhost:%d", lis.Addr().(*net.TCPAddr).Port)
+}
+
+func dial(addr string, conf certs.Config) error {
+	opt, err := certs.DialOption(conf)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, addr, opt, grpc.WithBlock())
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dirsync mirrors directory trees, in the manner of rsync --archive.
+// The source side is walked into a sequence of Entries, which a Receiver
+// applies to the target directory. The two halves may run in the same process
+// (see Sync) or on different hosts, with the Entries sent between them.
+package dirsync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// ErrChecksum is returned when the contents received for a file do not match
+// the checksum sent with them.
+var ErrChecksum = xerrors.New("checksum mismatch")
+
+type Type int
+
+const (
+	Regular Type = iota
+	Directory
+	Symlink
+	Hardlink
+)
+
+func (t Type) String() string {
+	switch t {
+	case Regular:
+		return "regular file"
+	case Directory:
+		return "directory"
+	case Symlink:
+		return "symbolic link"
+	case Hardlink:
+		return "hard link"
+	default:
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+}
+
+// Entry describes one file of the source tree.
+type Entry struct {
+	// Path is the slash-separated path of the file relative to the target
+	// directory. The target directory itself is ".".
+	Path string
+	Type Type
+
+	// Mode holds the permission bits, along with the setuid, setgid and
+	// sticky bits.
+	Mode    os.FileMode
+	ModTime time.Time
+	Size    int64
+
+	// Link is the target of a Symlink, or the Path of an earlier Entry that a
+	// Hardlink shares its contents with.
+	Link string
+
+	Uid int
+	Gid int
+}
+
+// OpenFunc opens the contents of a Regular Entry. It is nil for the other
+// types.
+type OpenFunc func() (io.ReadCloser, error)
+
+type Options struct {
+	// Delete removes the files in the target directories that are not in the
+	// source. Excluded files are left in place.
+	Delete bool
+
+	// Exclude lists the patterns of the files to leave out. A pattern without
+	// a slash matches the name of a file at any depth. A pattern with a slash
+	// matches the end of a file's path, unless it starts with a slash, in which
+	// case it matches the whole path. Excluding a directory excludes all of its
+	// contents.
+	Exclude []string
+
+	// Checksum compares the contents of files that already exist in the
+	// target, rather than their size and modification time.
+	Checksum bool
+}
+
+// Stats summarises the changes made to the target directory.
+type Stats struct {
+	Files   int64 // the number of files, links and directories updated
+	Bytes   int64 // the size of the file contents written
+	Deleted int64 // the number of files and directories removed
+}
+
+// Sync mirrors the contents of sourceDir into targetDir.
+func Sync(ctx context.Context, sourceDir, targetDir string, opts Options) (Stats, error) {
+	r := NewReceiver(targetDir, opts)
+
+	source := filepath.Clean(sourceDir) + string(os.PathSeparator)
+	if err := Walk(ctx, []string{source}, opts, r.Apply); err != nil {
+		return r.stats, err
+	}
+
+	return r.Finish()
+}
+
+// Walk calls fn for each file of the sources, skipping any that are excluded.
+// As with rsync, a source ending in a slash contributes its contents, whereas
+// any other source contributes itself under its base name. Directories are
+// walked before their contents, in lexical order. Device files, sockets and
+// named pipes are skipped.
+func Walk(ctx context.Context, sources []string, opts Options, fn func(Entry, OpenFunc) error) error {
+	type inode struct{ dev, ino uint64 }
+	links := make(map[inode]string)
+
+	for _, source := range sources {
+		root := filepath.Clean(source)
+
+		prefix := filepath.Base(root)
+		if strings.HasSuffix(source, string(os.PathSeparator)) {
+			prefix = "."
+		}
+
+		err := filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return xerrors.Errorf("walking %q: %w", file, err)
+			}
+
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			rel = path.Join(prefix, filepath.ToSlash(rel))
+
+			if rel != "." && Excluded(opts.Exclude, rel) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			entry := Entry{
+				Path:    rel,
+				Mode:    info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky),
+				ModTime: info.ModTime(),
+			}
+
+			stat, ok := info.Sys().(*syscall.Stat_t)
+			if ok {
+				entry.Uid = int(stat.Uid)
+				entry.Gid = int(stat.Gid)
+			}
+
+			var open OpenFunc
+
+			switch mode := info.Mode(); {
+			case mode.IsDir():
+				entry.Type = Directory
+
+			case mode&os.ModeSymlink != 0:
+				entry.Type = Symlink
+				entry.Link, err = os.Readlink(file)
+				if err != nil {
+					return xerrors.Errorf("reading link %q: %w", file, err)
+				}
+
+			case mode.IsRegular():
+				if ok && uint64(stat.Nlink) > 1 {
+					id := inode{uint64(stat.Dev), uint64(stat.Ino)}
+					if first, seen := links[id]; seen {
+						entry.Type = Hardlink
+						entry.Link = first
+						break
+					}
+					links[id] = rel
+				}
+
+				entry.Type = Regular
+				entry.Size = info.Size()
+				open = func() (io.ReadCloser, error) {
+					return os.Open(file)
+				}
+
+			default:
+				return nil
+			}
+
+			return fn(entry, open)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Excluded returns whether any of the patterns exclude the slash-separated
+// path. See Options.Exclude.
+func Excluded(patterns []string, rel string) bool {
+	parts := strings.Split(rel, "/")
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+
+		if strings.HasPrefix(pattern, "/") {
+			if match(strings.TrimPrefix(pattern, "/"), rel) {
+				return true
+			}
+			continue
+		}
+
+		if !strings.Contains(pattern, "/") {
+			if match(pattern, parts[len(parts)-1]) {
+				return true
+			}
+			continue
+		}
+
+		for i := range parts {
+			if match(pattern, strings.Join(parts[i:], "/")) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func match(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
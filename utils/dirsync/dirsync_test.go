@@ -0,0 +1,494 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package dirsync_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
+)
+
+func TestSync(t *testing.T) {
+	t.Run("copies the contents of the source directory", func(t *testing.T) {
+		source, target := mustCreateDirs(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		mustWriteFile(t, filepath.Join(source, "hello"), "hello", 0640)
+		mustMkdir(t, filepath.Join(source, "base"), 0750)
+		mustWriteFile(t, filepath.Join(source, "base", "1"), "one", 0600)
+		mustSymlink(t, "base/1", filepath.Join(source, "link"))
+
+		modTime := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+		if err := os.Chtimes(filepath.Join(source, "hello"), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+
+		stats, err := dirsync.Sync(context.Background(), source, target, dirsync.Options{})
+		if err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+
+		expected := dirsync.Stats{Files: 4, Bytes: 8}
+		if stats != expected {
+			t.Errorf("got stats %+v want %+v", stats, expected)
+		}
+
+		verifyFile(t, filepath.Join(target, "hello"), "hello", 0640)
+		verifyFile(t, filepath.Join(target, "base", "1"), "one", 0600)
+		verifyMode(t, filepath.Join(target, "base"), os.ModeDir|0750)
+
+		info, err := os.Stat(filepath.Join(target, "hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().Equal(modTime) {
+			t.Errorf("got modification time %v want %v", info.ModTime(), modTime)
+		}
+
+		link, err := os.Readlink(filepath.Join(target, "link"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link != "base/1" {
+			t.Errorf("got link %q want %q", link, "base/1")
+		}
+	})
+
+	t.Run("creates the target directory", func(t *testing.T) {
+		source, parent := mustCreateDirs(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, parent)
+
+		mustWriteFile(t, filepath.Join(source, "hello"), "hello", 0600)
+
+		target := filepath.Join(parent, "target")
+		if _, err := dirsync.Sync(context.Background(), source, target, dirsync.Options{}); err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+
+		verifyFile(t, filepath.Join(target, "hello"), "hello", 0600)
+	})
+
+	t.Run("fails when the parent of the target directory does not exist", func(t *testing.T) {
+		source := testutils.GetTempDir(t, "source")
+		defer testutils.MustRemoveAll(t, source)
+
+		_, err := dirsync.Sync(context.Background(), source, "/tmp/some/invalid/target/dir", dirsync.Options{})
+		if !xerrors.Is(err, os.ErrNotExist) {
+			t.Errorf("got error %#v want a not-exist error", err)
+		}
+	})
+
+	t.Run("skips files that have not changed", func(t *testing.T) {
+		source, target := mustCreateDirs(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		mustWriteFile(t, filepath.Join(source, "hello"), "hello", 0600)
+
+		if _, err := dirsync.Sync(context.Background(), source, target, dirsync.Options{}); err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+
+		stats, err := dirsync.Sync(context.Background(), source, target, dirsync.Options{})
+		if err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+
+		if stats != (dirsync.Stats{}) {
+			t.Errorf("got stats %+v want none", stats)
+		}
+	})
+
+	t.Run("compares the contents of files when checksumming", func(t *testing.T) {
+		source, target := mustCreateDirs(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		// Same size and modification time, different contents.
+		modTime := time.Now().Truncate(time.Second)
+		for dir, contents := range map[string]string{source: "hello", target: "jello"} {
+			path := filepath.Join(dir, "hello")
+			mustWriteFile(t, path, contents, 0600)
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := dirsync.Sync(context.Background(), source, target, dirsync.Options{}); err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+		verifyFile(t, filepath.Join(target, "hello"), "jello", 0600)
+
+		stats, err := dirsync.Sync(context.Background(), source, target, dirsync.Options{Checksum: true})
+		if err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+		verifyFile(t, filepath.Join(target, "hello"), "hello", 0600)
+
+		if stats.Files != 1 {
+			t.Errorf("got %d files copied want 1", stats.Files)
+		}
+	})
+
+	t.Run("deletes files that are not in the source", func(t *testing.T) {
+		source, target := mustCreateDirs(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		mustWriteFile(t, filepath.Join(source, "kept"), "kept", 0600)
+		mustWriteFile(t, filepath.Join(target, "kept"), "old", 0600)
+		mustWriteFile(t, filepath.Join(target, "extra"), "goodbye", 0600)
+		mustMkdir(t, filepath.Join(target, "extra_dir"), 0700)
+		mustWriteFile(t, filepath.Join(target, "extra_dir", "file"), "goodbye", 0600)
+
+		stats, err := dirsync.Sync(context.Background(), source, target, dirsync.Options{Delete: true})
+		if err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+
+		if stats.Deleted != 2 {
+			t.Errorf("got %d deleted want 2", stats.Deleted)
+		}
+
+		verifyContents(t, target, []string{"kept"})
+		verifyFile(t, filepath.Join(target, "kept"), "kept", 0600)
+	})
+
+	t.Run("leaves files that are not in the source without delete", func(t *testing.T) {
+		source, target := mustCreateDirs(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		mustWriteFile(t, filepath.Join(target, "extra"), "hello", 0600)
+
+		if _, err := dirsync.Sync(context.Background(), source, target, dirsync.Options{}); err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+
+		verifyContents(t, target, []string{"extra"})
+	})
+
+	t.Run("does not copy or delete excluded files", func(t *testing.T) {
+		source, target := mustCreateDirs(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		mustWriteFile(t, filepath.Join(source, "postgresql.conf"), "source", 0600)
+		mustWriteFile(t, filepath.Join(source, "copied"), "copied", 0600)
+		mustMkdir(t, filepath.Join(source, "pg_log"), 0700)
+		mustWriteFile(t, filepath.Join(source, "pg_log", "gpdb.csv"), "source log", 0600)
+
+		mustWriteFile(t, filepath.Join(target, "postgresql.conf"), "target", 0600)
+		mustMkdir(t, filepath.Join(target, "pg_log"), 0700)
+		mustWriteFile(t, filepath.Join(target, "pg_log", "old.csv"), "target log", 0600)
+
+		opts := dirsync.Options{Delete: true, Exclude: []string{"postgresql.conf", "pg_log/*"}}
+		if _, err := dirsync.Sync(context.Background(), source, target, opts); err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+
+		verifyContents(t, target, []string{"copied", "pg_log", "pg_log/old.csv", "postgresql.conf"})
+		verifyFile(t, filepath.Join(target, "postgresql.conf"), "target", 0600)
+	})
+
+	t.Run("preserves hard links", func(t *testing.T) {
+		source, target := mustCreateDirs(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		mustWriteFile(t, filepath.Join(source, "a"), "shared", 0600)
+		if err := os.Link(filepath.Join(source, "a"), filepath.Join(source, "b")); err != nil {
+			t.Fatal(err)
+		}
+
+		stats, err := dirsync.Sync(context.Background(), source, target, dirsync.Options{})
+		if err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+
+		if stats.Bytes != int64(len("shared")) {
+			t.Errorf("got %d bytes copied want %d", stats.Bytes, len("shared"))
+		}
+
+		a, err := os.Stat(filepath.Join(target, "a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := os.Stat(filepath.Join(target, "b"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !os.SameFile(a, b) {
+			t.Errorf("expected %q and %q to be hard links", "a", "b")
+		}
+	})
+
+	t.Run("replaces files of a different type", func(t *testing.T) {
+		source, target := mustCreateDirs(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		mustWriteFile(t, filepath.Join(source, "was_dir"), "now a file", 0600)
+		mustMkdir(t, filepath.Join(target, "was_dir"), 0700)
+		mustWriteFile(t, filepath.Join(target, "was_dir", "file"), "gone", 0600)
+
+		if _, err := dirsync.Sync(context.Background(), source, target, dirsync.Options{}); err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+
+		verifyFile(t, filepath.Join(target, "was_dir"), "now a file", 0600)
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		source, target := mustCreateDirs(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := dirsync.Sync(ctx, source, target, dirsync.Options{})
+		if err != context.Canceled {
+			t.Errorf("got error %#v want %#v", err, context.Canceled)
+		}
+	})
+}
+
+func TestWalk(t *testing.T) {
+	t.Run("names sources by their base name unless they end in a slash", func(t *testing.T) {
+		dir := testutils.GetTempDir(t, "walk")
+		defer testutils.MustRemoveAll(t, dir)
+
+		mustWriteFile(t, filepath.Join(dir, "mapping.txt"), "mapping", 0600)
+		mustMkdir(t, filepath.Join(dir, "16386"), 0700)
+		mustWriteFile(t, filepath.Join(dir, "16386", "1"), "one", 0600)
+		mustMkdir(t, filepath.Join(dir, "contents"), 0700)
+		mustWriteFile(t, filepath.Join(dir, "contents", "2"), "two", 0600)
+
+		sources := []string{
+			filepath.Join(dir, "mapping.txt"),
+			filepath.Join(dir, "16386"),
+			filepath.Join(dir, "contents") + "/",
+		}
+
+		var paths []string
+		err := dirsync.Walk(context.Background(), sources, dirsync.Options{}, func(e dirsync.Entry, _ dirsync.OpenFunc) error {
+			paths = append(paths, e.Path)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Walk() returned error %+v", err)
+		}
+
+		expected := []string{"mapping.txt", "16386", "16386/1", ".", "2"}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Errorf("got paths %q want %q", paths, expected)
+		}
+	})
+}
+
+func TestReceiver(t *testing.T) {
+	t.Run("refuses paths outside of the target directory", func(t *testing.T) {
+		target := testutils.GetTempDir(t, "target")
+		defer testutils.MustRemoveAll(t, target)
+
+		for _, path := range []string{"../escape", "/etc/passwd", "a/../../escape"} {
+			r := dirsync.NewReceiver(target, dirsync.Options{})
+			err := r.Apply(dirsync.Entry{Path: path, Type: dirsync.Directory}, nil)
+			if err == nil {
+				t.Errorf("expected an error for path %q", path)
+			}
+		}
+	})
+
+	t.Run("refuses paths under a symbolic link in the target directory", func(t *testing.T) {
+		target, outside := mustCreateDirs(t)
+		defer testutils.MustRemoveAll(t, target)
+		defer testutils.MustRemoveAll(t, outside)
+
+		mustMkdir(t, filepath.Join(target, "pg_tblspc"), 0700)
+		mustSymlink(t, outside, filepath.Join(target, "pg_tblspc", "16386"))
+
+		r := dirsync.NewReceiver(target, dirsync.Options{})
+		for _, e := range []dirsync.Entry{
+			{Path: ".", Type: dirsync.Directory},
+			{Path: "pg_tblspc", Type: dirsync.Directory},
+		} {
+			if err := r.Apply(e, nil); err != nil {
+				t.Fatalf("Apply() returned error %+v", err)
+			}
+		}
+
+		err := r.Apply(dirsync.Entry{Path: "pg_tblspc/16386/escaped", Type: dirsync.Directory}, nil)
+		if err == nil {
+			t.Error("expected an error")
+		}
+
+		if _, err := os.Lstat(filepath.Join(outside, "escaped")); !os.IsNotExist(err) {
+			t.Errorf("expected nothing to be written through the link, got %v", err)
+		}
+	})
+
+	t.Run("needs the contents of only the regular files that differ", func(t *testing.T) {
+		target := testutils.GetTempDir(t, "target")
+		defer testutils.MustRemoveAll(t, target)
+
+		modTime := time.Now().Truncate(time.Second)
+		path := filepath.Join(target, "hello")
+		mustWriteFile(t, path, "hello", 0600)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+
+		cases := []struct {
+			entry    dirsync.Entry
+			expected bool
+		}{
+			{dirsync.Entry{Path: "hello", Type: dirsync.Regular, Size: 5, ModTime: modTime}, false},
+			{dirsync.Entry{Path: "hello", Type: dirsync.Regular, Size: 6, ModTime: modTime}, true},
+			{dirsync.Entry{Path: "missing", Type: dirsync.Regular}, true},
+			{dirsync.Entry{Path: "dir", Type: dirsync.Directory}, false},
+		}
+
+		r := dirsync.NewReceiver(target, dirsync.Options{})
+		for _, c := range cases {
+			if needs := r.Needs(c.entry); needs != c.expected {
+				t.Errorf("Needs(%+v) returned %t want %t", c.entry, needs, c.expected)
+			}
+		}
+
+		checksum := dirsync.NewReceiver(target, dirsync.Options{Checksum: true})
+		if !checksum.Needs(cases[0].entry) {
+			t.Error("expected the contents to be needed when checksumming")
+		}
+	})
+}
+
+func TestExcluded(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		path     string
+		excluded bool
+	}{
+		{"postgresql.conf", "postgresql.conf", true},
+		{"postgresql.conf", "sub/postgresql.conf", true},
+		{"postgresql.conf", "postgresql.conf.bak", false},
+		{"*.conf", "sub/pg_hba.conf", true},
+		{"pg_log/*", "pg_log", false},
+		{"pg_log/*", "pg_log/gpdb.csv", true},
+		{"pg_log/*", "sub/pg_log/gpdb.csv", true},
+		{"/pg_log/*", "sub/pg_log/gpdb.csv", false},
+		{"/pg_log/*", "pg_log/gpdb.csv", true},
+		{"gpperfmon/", "gpperfmon", true},
+	}
+
+	for _, c := range cases {
+		if actual := dirsync.Excluded([]string{c.pattern}, c.path); actual != c.excluded {
+			t.Errorf("Excluded(%q, %q) = %t want %t", c.pattern, c.path, actual, c.excluded)
+		}
+	}
+}
+
+func mustCreateDirs(t *testing.T) (string, string) {
+	t.Helper()
+	return testutils.GetTempDir(t, "source"), testutils.GetTempDir(t, "target")
+}
+
+func mustMkdir(t *testing.T, path string, mode os.FileMode) {
+	t.Helper()
+
+	if err := os.Mkdir(path, mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustWriteFile(t *testing.T, path, contents string, mode os.FileMode) {
+	t.Helper()
+
+	if err := ioutil.WriteFile(path, []byte(contents), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustSymlink(t *testing.T, oldname, newname string) {
+	t.Helper()
+
+	if err := os.Symlink(oldname, newname); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func verifyFile(t *testing.T, path, expected string, mode os.FileMode) {
+	t.Helper()
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %q: %v", path, err)
+	}
+
+	if string(contents) != expected {
+		t.Errorf("%q contains %q want %q", path, contents, expected)
+	}
+
+	verifyMode(t, path, mode)
+}
+
+func verifyMode(t *testing.T, path string, mode os.FileMode) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode() != mode {
+		t.Errorf("%q has mode %v want %v", path, info.Mode(), mode)
+	}
+}
+
+// verifyContents checks the slash-separated paths of everything within dir.
+func verifyContents(t *testing.T, dir string, expected []string) {
+	t.Helper()
+
+	var actual []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != dir {
+			rel, _ := filepath.Rel(dir, path)
+			actual = append(actual, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(actual)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got contents %q want %q", actual, expected)
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package dirsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"syscall"
+
+	"golang.org/x/xerrors"
+)
+
+// ManifestEntry records a file of the source tree, along with a checksum of
+// its contents, so that a copy of it can be verified.
+type ManifestEntry struct {
+	Path string
+	Type Type
+	Size int64
+	Link string
+
+	// Checksum is the SHA-256 checksum of a Regular file.
+	Checksum []byte
+}
+
+// Manifest lists the files of a source tree in the order Walk visits them.
+type Manifest []ManifestEntry
+
+// NewManifest walks the sources as Walk does, checksumming the contents of
+// each regular file.
+func NewManifest(ctx context.Context, sources []string, opts Options) (Manifest, error) {
+	var manifest Manifest
+
+	err := Walk(ctx, sources, opts, func(e Entry, open OpenFunc) error {
+		entry := ManifestEntry{Path: e.Path, Type: e.Type, Size: e.Size, Link: e.Link}
+
+		if open != nil {
+			sum, err := checksumContents(open)
+			if err != nil {
+				return xerrors.Errorf("checksumming %q: %w", e.Path, err)
+			}
+			entry.Checksum = sum
+		}
+
+		manifest = append(manifest, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+type Reason int
+
+const (
+	Missing Reason = iota
+	Changed
+	Extraneous
+)
+
+func (r Reason) String() string {
+	switch r {
+	case Missing:
+		return "missing"
+	case Changed:
+		return "changed"
+	case Extraneous:
+		return "extraneous"
+	default:
+		return fmt.Sprintf("Reason(%d)", int(r))
+	}
+}
+
+// Mismatch describes a file of a copy that differs from the manifest of its
+// source.
+type Mismatch struct {
+	Path   string
+	Reason Reason
+	Detail string
+}
+
+func (m Mismatch) String() string {
+	if m.Detail == "" {
+		return fmt.Sprintf("%s: %s", m.Path, m.Reason)
+	}
+	return fmt.Sprintf("%s: %s (%s)", m.Path, m.Reason, m.Detail)
+}
+
+// Verify compares the manifest against the copy of its source in targetDir,
+// made with the same options, and returns the files that differ. When the
+// options delete, the files in the copied directories that are not in the
+// manifest are reported as extraneous, other than those that are excluded.
+func (m Manifest) Verify(ctx context.Context, targetDir string, opts Options) ([]Mismatch, error) {
+	r := NewReceiver(targetDir, opts)
+
+	listed := make(map[string]bool, len(m))
+	for _, e := range m {
+		listed[path.Clean(e.Path)] = true
+	}
+
+	var mismatches []Mismatch
+	for _, e := range m {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		file, err := r.path(e.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		detail, err := verifyEntry(r, file, e)
+		if os.IsNotExist(err) || xerrors.Is(err, syscall.ENOTDIR) {
+			mismatches = append(mismatches, Mismatch{Path: e.Path, Reason: Missing})
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("verifying %q: %w", file, err)
+		}
+
+		if detail != "" {
+			mismatches = append(mismatches, Mismatch{Path: e.Path, Reason: Changed, Detail: detail})
+			continue
+		}
+
+		if e.Type != Directory || !opts.Delete {
+			continue
+		}
+
+		names, err := readDirNames(file)
+		if err != nil {
+			return nil, xerrors.Errorf("reading %q: %w", file, err)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			rel := path.Join(e.Path, name)
+			if listed[rel] || Excluded(opts.Exclude, rel) {
+				continue
+			}
+			mismatches = append(mismatches, Mismatch{Path: rel, Reason: Extraneous})
+		}
+	}
+
+	sort.SliceStable(mismatches, func(i, j int) bool {
+		return mismatches[i].Path < mismatches[j].Path
+	})
+
+	return mismatches, nil
+}
+
+// verifyEntry returns how the file differs from the entry, or an empty string
+// when it does not.
+func verifyEntry(r *Receiver, file string, e ManifestEntry) (string, error) {
+	info, err := os.Lstat(file)
+	if err != nil {
+		return "", err
+	}
+
+	if !sameType(info, e.Type) {
+		return fmt.Sprintf("is a %s, expected a %s", typeOf(info), e.Type), nil
+	}
+
+	switch e.Type {
+	case Symlink:
+		link, err := os.Readlink(file)
+		if err != nil {
+			return "", err
+		}
+		if link != e.Link {
+			return fmt.Sprintf("links to %q, expected %q", link, e.Link), nil
+		}
+
+	case Hardlink:
+		first, err := r.path(e.Link)
+		if err != nil {
+			return "", err
+		}
+		firstInfo, err := os.Lstat(first)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if firstInfo == nil || !os.SameFile(info, firstInfo) {
+			return fmt.Sprintf("is not linked to %q", e.Link), nil
+		}
+
+	case Regular:
+		if info.Size() != e.Size {
+			return fmt.Sprintf("size is %d bytes, expected %d", info.Size(), e.Size), nil
+		}
+
+		sum, err := checksum(file)
+		if err != nil {
+			return "", err
+		}
+		if !bytes.Equal(sum, e.Checksum) {
+			return "checksum differs", nil
+		}
+	}
+
+	return "", nil
+}
+
+func typeOf(info os.FileInfo) Type {
+	switch {
+	case info.IsDir():
+		return Directory
+	case info.Mode()&os.ModeSymlink != 0:
+		return Symlink
+	default:
+		return Regular
+	}
+}
+
+func checksumContents(open OpenFunc) ([]byte, error) {
+	f, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
+}
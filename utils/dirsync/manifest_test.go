@@ -0,0 +1,175 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package dirsync_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/utils/dirsync"
+)
+
+func TestManifest(t *testing.T) {
+	opts := dirsync.Options{Delete: true, Exclude: []string{"pg_log"}}
+
+	// copy creates a source tree and a copy of it, returning the manifest of
+	// the source.
+	copy := func(t *testing.T) (dirsync.Manifest, string, string) {
+		t.Helper()
+
+		source, target := mustCreateDirs(t)
+
+		mustWriteFile(t, filepath.Join(source, "hello"), "hello", 0600)
+		mustMkdir(t, filepath.Join(source, "base"), 0700)
+		mustWriteFile(t, filepath.Join(source, "base", "1"), "one", 0600)
+		mustSymlink(t, "base/1", filepath.Join(source, "link"))
+		if err := os.Link(filepath.Join(source, "hello"), filepath.Join(source, "hardlink")); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := dirsync.Sync(context.Background(), source, target, opts); err != nil {
+			t.Fatalf("Sync() returned error %+v", err)
+		}
+
+		manifest, err := dirsync.NewManifest(context.Background(), []string{source + "/"}, opts)
+		if err != nil {
+			t.Fatalf("NewManifest() returned error %+v", err)
+		}
+
+		return manifest, source, target
+	}
+
+	t.Run("lists the files of the sources with checksums of their contents", func(t *testing.T) {
+		manifest, source, target := copy(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		var paths []string
+		for _, e := range manifest {
+			paths = append(paths, e.Path)
+
+			if (e.Type == dirsync.Regular) != (e.Checksum != nil) {
+				t.Errorf("%s %q has checksum %x", e.Type, e.Path, e.Checksum)
+			}
+		}
+
+		expected := []string{".", "base", "base/1", "hardlink", "hello", "link"}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Errorf("got paths %q want %q", paths, expected)
+		}
+	})
+
+	t.Run("finds no mismatches in an intact copy", func(t *testing.T) {
+		manifest, source, target := copy(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		// Excluded files are not extraneous.
+		mustMkdir(t, filepath.Join(target, "pg_log"), 0700)
+
+		mismatches, err := manifest.Verify(context.Background(), target, opts)
+		if err != nil {
+			t.Fatalf("Verify() returned error %+v", err)
+		}
+
+		if len(mismatches) != 0 {
+			t.Errorf("got mismatches %v, want none", mismatches)
+		}
+	})
+
+	t.Run("reports the files that differ from the manifest", func(t *testing.T) {
+		manifest, source, target := copy(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		// Same size, different contents.
+		mustWriteFile(t, filepath.Join(target, "base", "1"), "two", 0600)
+		if err := os.Remove(filepath.Join(target, "hello")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Remove(filepath.Join(target, "link")); err != nil {
+			t.Fatal(err)
+		}
+		mustSymlink(t, "hello", filepath.Join(target, "link"))
+		mustWriteFile(t, filepath.Join(target, "base", "2"), "extra", 0600)
+
+		mismatches, err := manifest.Verify(context.Background(), target, opts)
+		if err != nil {
+			t.Fatalf("Verify() returned error %+v", err)
+		}
+
+		// "hardlink" sorts first, so "hello" is the entry recorded as the hard
+		// link, and removing it leaves "hardlink" intact.
+		expected := []dirsync.Mismatch{
+			{Path: "base/1", Reason: dirsync.Changed, Detail: "checksum differs"},
+			{Path: "base/2", Reason: dirsync.Extraneous},
+			{Path: "hello", Reason: dirsync.Missing},
+			{Path: "link", Reason: dirsync.Changed, Detail: `links to "hello", expected "base/1"`},
+		}
+		if !reflect.DeepEqual(mismatches, expected) {
+			t.Errorf("got mismatches %v want %v", mismatches, expected)
+		}
+	})
+
+	t.Run("reports files of the wrong type or size", func(t *testing.T) {
+		manifest, source, target := copy(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		testutils.MustRemoveAll(t, filepath.Join(target, "base"))
+		mustWriteFile(t, filepath.Join(target, "base"), "base", 0600)
+		mustWriteFile(t, filepath.Join(target, "hardlink"), "hello, world", 0600)
+
+		mismatches, err := manifest.Verify(context.Background(), target, opts)
+		if err != nil {
+			t.Fatalf("Verify() returned error %+v", err)
+		}
+
+		expected := []dirsync.Mismatch{
+			{Path: "base", Reason: dirsync.Changed, Detail: "is a regular file, expected a directory"},
+			{Path: "base/1", Reason: dirsync.Missing},
+			{Path: "hardlink", Reason: dirsync.Changed, Detail: "size is 12 bytes, expected 5"},
+		}
+		if !reflect.DeepEqual(mismatches, expected) {
+			t.Errorf("got mismatches %v want %v", mismatches, expected)
+		}
+	})
+
+	t.Run("leaves extraneous files alone without delete", func(t *testing.T) {
+		manifest, source, target := copy(t)
+		defer testutils.MustRemoveAll(t, source)
+		defer testutils.MustRemoveAll(t, target)
+
+		mustWriteFile(t, filepath.Join(target, "extra"), "extra", 0600)
+
+		mismatches, err := manifest.Verify(context.Background(), target, dirsync.Options{})
+		if err != nil {
+			t.Fatalf("Verify() returned error %+v", err)
+		}
+
+		if len(mismatches) != 0 {
+			t.Errorf("got mismatches %v, want none", mismatches)
+		}
+	})
+}
+
+func TestMismatch(t *testing.T) {
+	cases := []struct {
+		mismatch dirsync.Mismatch
+		expected string
+	}{
+		{dirsync.Mismatch{Path: "base/1", Reason: dirsync.Missing}, "base/1: missing"},
+		{dirsync.Mismatch{Path: "base/1", Reason: dirsync.Changed, Detail: "checksum differs"}, "base/1: changed (checksum differs)"},
+	}
+
+	for _, c := range cases {
+		if actual := c.mismatch.String(); actual != c.expected {
+			t.Errorf("got %q want %q", actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package dirsync
+
+import (
+	"os"
+	"time"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// ToProto converts an Entry to be sent to an agent.
+func ToProto(e Entry) *idl.FileEntry {
+	return &idl.FileEntry{
+		Path:    e.Path,
+		Type:    idl.FileEntry_Type(e.Type),
+		Mode:    uint32(e.Mode),
+		ModTime: e.ModTime.UnixNano(),
+		Size:    e.Size,
+		Link:    e.Link,
+		Uid:     uint32(e.Uid),
+		Gid:     uint32(e.Gid),
+	}
+}
+
+// FromProto converts an Entry received from the hub.
+func FromProto(e *idl.FileEntry) Entry {
+	return Entry{
+		Path:    e.Path,
+		Type:    Type(e.Type),
+		Mode:    os.FileMode(e.Mode),
+		ModTime: time.Unix(0, e.ModTime),
+		Size:    e.Size,
+		Link:    e.Link,
+		Uid:     int(e.Uid),
+		Gid:     int(e.Gid),
+	}
+}
+
+// OptionsToProto converts Options to be sent to an agent.
+func OptionsToProto(targetDir string, opts Options) *idl.SyncOptions {
+	return &idl.SyncOptions{
+		TargetDir: targetDir,
+		Delete:    opts.Delete,
+		Exclude:   opts.Exclude,
+		Checksum:  opts.Checksum,
+	}
+}
+
+// OptionsFromProto converts Options received from the hub.
+func OptionsFromProto(opts *idl.SyncOptions) Options {
+	return Options{
+		Delete:   opts.Delete,
+		Exclude:  opts.Exclude,
+		Checksum: opts.Checksum,
+	}
+}
+
+// ManifestEntryToProto converts a ManifestEntry to be sent to an agent.
+func ManifestEntryToProto(e ManifestEntry) *idl.ManifestEntry {
+	return &idl.ManifestEntry{
+		Path:     e.Path,
+		Type:     idl.FileEntry_Type(e.Type),
+		Size:     e.Size,
+		Link:     e.Link,
+		Checksum: e.Checksum,
+	}
+}
+
+// ManifestEntryFromProto converts a ManifestEntry received from the hub.
+func ManifestEntryFromProto(e *idl.ManifestEntry) ManifestEntry {
+	return ManifestEntry{
+		Path:     e.Path,
+		Type:     Type(e.Type),
+		Size:     e.Size,
+		Link:     e.Link,
+		Checksum: e.Checksum,
+	}
+}
+
+// MismatchToProto converts a Mismatch to be returned to the hub.
+func MismatchToProto(m Mismatch) *idl.Mismatch {
+	return &idl.Mismatch{
+		Path:   m.Path,
+		Reason: idl.Mismatch_Reason(m.Reason),
+		Detail: m.Detail,
+	}
+}
+
+// MismatchFromProto converts a Mismatch received from an agent.
+func MismatchFromProto(m *idl.Mismatch) Mismatch {
+	return Mismatch{
+		Path:   m.Path,
+		Reason: Reason(m.Reason),
+		Detail: m.Detail,
+	}
+}
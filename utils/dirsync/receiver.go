@@ -0,0 +1,363 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package dirsync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// Receiver applies Entries to a target directory. Each Entry must be applied
+// after the directory containing it, as Walk does, and Finish must be called
+// once they have all been applied.
+type Receiver struct {
+	target string
+	opts   Options
+	stats  Stats
+
+	seen map[string]bool
+	dirs []Entry // in the order they were applied
+
+	// realDirs holds the directories applied so far, which are known not to
+	// be symbolic links. See path().
+	realDirs map[string]bool
+}
+
+func NewReceiver(targetDir string, opts Options) *Receiver {
+	return &Receiver{
+		target:   filepath.Clean(targetDir),
+		opts:     opts,
+		seen:     make(map[string]bool),
+		realDirs: make(map[string]bool),
+	}
+}
+
+// Needs returns whether Apply will read the contents of the Entry, so that a
+// sender may leave out the contents of the files that are unchanged. Errors
+// are left for Apply to report.
+func (r *Receiver) Needs(e Entry) bool {
+	if e.Type != Regular {
+		return false
+	}
+
+	file, err := r.path(e.Path)
+	if err != nil {
+		return true
+	}
+
+	existing, err := os.Lstat(file)
+	return err != nil || !existing.Mode().IsRegular() || !r.upToDate(existing, e)
+}
+
+// Apply brings the file of the Entry in the target directory up to date. The
+// contents of a Regular Entry are only read when they differ from the existing
+// file.
+func (r *Receiver) Apply(e Entry, open OpenFunc) error {
+	file, err := r.path(e.Path)
+	if err != nil {
+		return err
+	}
+
+	if len(r.seen) == 0 {
+		// Like rsync, create the target directory but not its parents.
+		if err := os.Mkdir(r.target, 0700); err != nil && !os.IsExist(err) {
+			return xerrors.Errorf("creating target directory: %w", err)
+		}
+	}
+	r.seen[path.Clean(e.Path)] = true
+
+	existing, err := os.Lstat(file)
+	if err != nil && !os.IsNotExist(err) {
+		return xerrors.Errorf("stat'ing %q: %w", file, err)
+	}
+
+	if existing != nil && !sameType(existing, e.Type) {
+		if err := os.RemoveAll(file); err != nil {
+			return xerrors.Errorf("replacing %q: %w", file, err)
+		}
+		existing = nil
+
+		// A directory may have been replaced; check them all again.
+		r.realDirs = make(map[string]bool)
+	}
+
+	switch e.Type {
+	case Directory:
+		err = r.applyDirectory(file, existing, e)
+	case Symlink:
+		err = r.applySymlink(file, existing, e)
+	case Hardlink:
+		err = r.applyHardlink(file, existing, e)
+	default:
+		err = r.applyRegular(file, existing, e, open)
+	}
+
+	if err != nil {
+		return xerrors.Errorf("syncing %q: %w", file, err)
+	}
+
+	return nil
+}
+
+// Finish removes the extraneous files, when deleting, and sets the attributes
+// of the directories now that their contents are no longer changing.
+func (r *Receiver) Finish() (Stats, error) {
+	if r.opts.Delete {
+		for _, dir := range r.dirs {
+			if err := r.deleteExtraneous(dir.Path); err != nil {
+				return r.stats, err
+			}
+		}
+	}
+
+	// Setting the modification time of a directory must come after its
+	// contents have been changed, so work from the deepest directories up.
+	for i := len(r.dirs) - 1; i >= 0; i-- {
+		dir := r.dirs[i]
+
+		file, err := r.path(dir.Path)
+		if err != nil {
+			return r.stats, err
+		}
+
+		if err := setAttributes(file, dir); err != nil {
+			return r.stats, xerrors.Errorf("syncing %q: %w", file, err)
+		}
+	}
+
+	return r.stats, nil
+}
+
+// path returns the location in the target directory of a relative path,
+// refusing any that would fall outside of it. That includes paths under a
+// symbolic link in the target directory, such as pg_tblspc/<oid>, which would
+// otherwise be followed out of it.
+func (r *Receiver) path(rel string) (string, error) {
+	clean := path.Clean(rel)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", xerrors.Errorf("path %q is outside of the target directory", rel)
+	}
+
+	// The parents of an applied directory were checked when it was applied.
+	for dir := path.Dir(clean); dir != "." && !r.realDirs[dir]; dir = path.Dir(dir) {
+		info, err := os.Lstat(filepath.Join(r.target, filepath.FromSlash(dir)))
+		if err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", xerrors.Errorf("path %q is under the symbolic link %q in the target directory", rel, dir)
+		}
+	}
+
+	return filepath.Join(r.target, filepath.FromSlash(clean)), nil
+}
+
+func (r *Receiver) applyDirectory(file string, existing os.FileInfo, e Entry) error {
+	r.dirs = append(r.dirs, e)
+
+	if existing == nil {
+		// Keep the directory writable until its contents have been applied;
+		// its mode is set by Finish.
+		if err := os.Mkdir(file, 0700); err != nil {
+			return err
+		}
+
+		r.stats.Files++
+	}
+
+	r.realDirs[path.Clean(e.Path)] = true
+	return nil
+}
+
+func (r *Receiver) applySymlink(file string, existing os.FileInfo, e Entry) error {
+	if existing != nil {
+		link, err := os.Readlink(file)
+		if err == nil && link == e.Link {
+			return nil
+		}
+
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Symlink(e.Link, file); err != nil {
+		return err
+	}
+
+	r.stats.Files++
+	return chown(file, e)
+}
+
+func (r *Receiver) applyHardlink(file string, existing os.FileInfo, e Entry) error {
+	first, err := r.path(e.Link)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		if info, err := os.Lstat(first); err == nil && os.SameFile(existing, info) {
+			return nil
+		}
+
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Link(first, file); err != nil {
+		return err
+	}
+
+	r.stats.Files++
+	return nil
+}
+
+func (r *Receiver) applyRegular(file string, existing os.FileInfo, e Entry, open OpenFunc) error {
+	if existing != nil && r.upToDate(existing, e) {
+		return setAttributes(file, e)
+	}
+
+	if open == nil {
+		return xerrors.New("no contents to copy")
+	}
+
+	source, err := open()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	// Write to a temporary file that replaces the existing one only when it is
+	// complete, so that an interrupted copy does not leave a partial file.
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), source)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	sum := hash.Sum(nil)
+
+	if r.opts.Checksum && existing != nil {
+		existingSum, err := checksum(file)
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(existingSum, sum) {
+			return setAttributes(file, e)
+		}
+	}
+
+	if err := setAttributes(tmp.Name(), e); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return err
+	}
+
+	r.stats.Files++
+	r.stats.Bytes += written
+	return nil
+}
+
+// upToDate returns whether an existing regular file can be kept without
+// reading the contents of the Entry.
+func (r *Receiver) upToDate(existing os.FileInfo, e Entry) bool {
+	return !r.opts.Checksum && existing.Size() == e.Size && existing.ModTime().Equal(e.ModTime)
+}
+
+// deleteExtraneous removes the contents of a target directory that were not
+// applied, other than those that are excluded.
+func (r *Receiver) deleteExtraneous(dir string) error {
+	file, err := r.path(dir)
+	if err != nil {
+		return err
+	}
+
+	names, err := readDirNames(file)
+	if err != nil {
+		return xerrors.Errorf("reading %q: %w", file, err)
+	}
+
+	for _, name := range names {
+		rel := path.Join(dir, name)
+		if r.seen[rel] || Excluded(r.opts.Exclude, rel) {
+			continue
+		}
+
+		extraneous := filepath.Join(file, name)
+		if err := os.RemoveAll(extraneous); err != nil {
+			return xerrors.Errorf("deleting %q: %w", extraneous, err)
+		}
+
+		r.stats.Deleted++
+	}
+
+	return nil
+}
+
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.Readdirnames(-1)
+}
+
+func sameType(info os.FileInfo, t Type) bool {
+	switch t {
+	case Directory:
+		return info.IsDir()
+	case Symlink:
+		return info.Mode()&os.ModeSymlink != 0
+	default:
+		return info.Mode().IsRegular()
+	}
+}
+
+func setAttributes(file string, e Entry) error {
+	if err := chown(file, e); err != nil {
+		return err
+	}
+
+	// chown clears the setuid and setgid bits, so set the mode afterwards.
+	if err := os.Chmod(file, e.Mode); err != nil {
+		return err
+	}
+
+	return os.Chtimes(file, e.ModTime, e.ModTime)
+}
+
+// chown sets the owner of a file when running as root. As with rsync,
+// ownership is otherwise left to the user that makes the copy.
+func chown(file string, e Entry) error {
+	if os.Geteuid() != 0 {
+		return nil
+	}
+
+	return os.Lchown(file, e.Uid, e.Gid)
+}
+
+func checksum(file string) ([]byte, error) {
+	return checksumContents(func() (io.ReadCloser, error) {
+		return os.Open(file)
+	})
+}
@@ -4,6 +4,8 @@
 package disk
 
 import (
+	"path/filepath"
+
 	"golang.org/x/sys/unix"
 	"golang.org/x/xerrors"
 
@@ -14,7 +16,9 @@ import (
 )
 
 // SpaceFailures maps a unique filesystem identifier to its disk usage. One
-// entry is created for each filesystem that doesn't have enough available disk.
+// entry is created for each filesystem that doesn't have enough available disk
+// or inodes. The inode counts of an entry are left zero unless there are not
+// enough inodes, and likewise for its disk space.
 //
 // This type is assignable to idl.CheckDiskSpaceReply.Failed.
 type SpaceFailures = map[string]*idl.CheckDiskSpaceReply_DiskUsage
@@ -26,6 +30,15 @@ type Disk interface {
 	Filesystems() (sigar.FileSystemList, error)
 	Usage(string) (sigar.FileSystemUsage, error)
 	Stat(string) (*unix.Stat_t, error)
+	Walk(string, filepath.WalkFunc) error
+	Inodes(string) (InodeUsage, error)
+}
+
+// InodeUsage is the number of inodes of a filesystem, and how many are free.
+// Filesystems that allocate inodes as needed, such as btrfs, have none.
+type InodeUsage struct {
+	Total uint64
+	Free  uint64
 }
 
 // CheckUsage uses the given Disk to look up filesystem usage for each path, and
@@ -33,6 +46,10 @@ type Disk interface {
 // don't have enough space will be given an entry in the returned SpaceFailures
 // map. Note that this is one entry per filesystem, not one entry per path.
 //
+// Inodes are not checked, since projecting the files that the upgrade adds
+// means walking every data directory. CheckNeeds checks them when the space
+// needed is estimated.
+//
 // This function ignores space that has been reserved for the superuser (i.e.
 // the difference between "free" and "avail" in statfs(2)). It does not consider
 // that space to be free for use, nor does it count that space against the total
@@ -41,21 +58,9 @@ type Disk interface {
 func CheckUsage(d Disk, requiredRatio float64, paths ...string) (SpaceFailures, error) {
 	failures := make(SpaceFailures)
 
-	// Find the device ID for every filesystem. We'll use these to map data
-	// directories to filesystems later.
-	fs, err := d.Filesystems()
+	fsByID, err := filesystemsByID(d)
 	if err != nil {
-		return nil, xerrors.Errorf("enumerating filesystems: %w", err)
-	}
-
-	fsByID := make(map[uint64]string)
-	for _, f := range fs.List {
-		stat, err := d.Stat(f.DirName)
-		if err != nil {
-			return nil, xerrors.Errorf("stat'ing %s: %w", f.DirName, err)
-		}
-
-		fsByID[uint64(stat.Dev)] = f.DirName
+		return nil, err
 	}
 
 	for _, path := range paths {
@@ -73,16 +78,9 @@ func CheckUsage(d Disk, requiredRatio float64, paths ...string) (SpaceFailures,
 
 		if usage.Avail < required {
 			// Get the filesystem that this path belongs to.
-			stat, err := d.Stat(path)
+			f, _, err := filesystemOf(d, fsByID, path)
 			if err != nil {
-				return nil, xerrors.Errorf("stat'ing %s: %w", path, err)
-			}
-
-			f, ok := fsByID[uint64(stat.Dev)]
-			if !ok {
-				// Rather than blow up if we can't associate a path with a
-				// filesystem, just use the path itself.
-				f = path
+				return nil, err
 			}
 
 			failures[f] = &idl.CheckDiskSpaceReply_DiskUsage{
@@ -95,8 +93,85 @@ func CheckUsage(d Disk, requiredRatio float64, paths ...string) (SpaceFailures,
 	return failures, nil
 }
 
-// Local is a standard implementation of the Disk interface that uses gosigar
-// and unix.Stat to obtain statistics for the local machine.
+// inodeNeed is the number of files that the upgrade adds to a filesystem.
+type inodeNeed struct {
+	filesystem string
+	path       string // any path on the filesystem
+	files      uint64
+}
+
+// checkInodes compares the files that the upgrade adds to each filesystem with
+// the inodes free there, and adds an entry to failures for each filesystem
+// that has too few.
+func checkInodes(d Disk, needs map[uint64]*inodeNeed, failures SpaceFailures) error {
+	for _, need := range needs {
+		inodes, err := d.Inodes(need.path)
+		if err != nil {
+			return xerrors.Errorf("getting inode usage for %s: %w", need.path, err)
+		}
+
+		gplog.Debug("%s: %d inodes free of %d required (%d total)",
+			need.filesystem, inodes.Free, need.files, inodes.Total)
+
+		if inodes.Total == 0 || inodes.Free >= need.files {
+			continue
+		}
+
+		usage, ok := failures[need.filesystem]
+		if !ok {
+			usage = new(idl.CheckDiskSpaceReply_DiskUsage)
+			failures[need.filesystem] = usage
+		}
+
+		usage.InodesRequired = need.files
+		usage.InodesAvailable = inodes.Free
+	}
+
+	return nil
+}
+
+// filesystemsByID finds the device ID for every filesystem. These are used to
+// map data directories to filesystems.
+func filesystemsByID(d Disk) (map[uint64]string, error) {
+	fs, err := d.Filesystems()
+	if err != nil {
+		return nil, xerrors.Errorf("enumerating filesystems: %w", err)
+	}
+
+	fsByID := make(map[uint64]string)
+	for _, f := range fs.List {
+		stat, err := d.Stat(f.DirName)
+		if err != nil {
+			return nil, xerrors.Errorf("stat'ing %s: %w", f.DirName, err)
+		}
+
+		fsByID[uint64(stat.Dev)] = f.DirName
+	}
+
+	return fsByID, nil
+}
+
+// filesystemOf returns the name and device ID of the filesystem that path
+// belongs to.
+func filesystemOf(d Disk, fsByID map[uint64]string, path string) (string, uint64, error) {
+	stat, err := d.Stat(path)
+	if err != nil {
+		return "", 0, xerrors.Errorf("stat'ing %s: %w", path, err)
+	}
+
+	f, ok := fsByID[uint64(stat.Dev)]
+	if !ok {
+		// Rather than blow up if we can't associate a path with a
+		// filesystem, just use the path itself.
+		f = path
+	}
+
+	return f, uint64(stat.Dev), nil
+}
+
+// Local is a standard implementation of the Disk interface that uses gosigar,
+// unix.Stat, unix.Statfs and filepath.Walk to obtain statistics for the local
+// machine.
 var Local = local{}
 
 type local struct{}
@@ -118,3 +193,23 @@ func (_ local) Stat(path string) (*unix.Stat_t, error) {
 	err := unix.Stat(path, stat)
 	return stat, err
 }
+
+func (_ local) Inodes(path string) (InodeUsage, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return InodeUsage{}, err
+	}
+
+	return InodeUsage{Total: stat.Files, Free: stat.Ffree}, nil
+}
+
+// Walk follows root when it is a symlink, such as a tablespace link in
+// pg_tblspc, but not any of the symlinks beneath it.
+func (_ local) Walk(root string, fn filepath.WalkFunc) error {
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(resolved, fn)
+}
@@ -5,6 +5,7 @@ package disk_test
 
 import (
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -127,6 +128,21 @@ func TestCheckUsage(t *testing.T) {
 		})
 	}
 
+	t.Run("does not check inodes", func(t *testing.T) {
+		d := d
+		d.inodes = func(path string) (disk.InodeUsage, error) {
+			return disk.InodeUsage{Total: 1000, Free: 0}, nil
+		}
+
+		actual, err := disk.CheckUsage(d, 0.1, "/test/path")
+		if err != nil {
+			t.Errorf("returned error %#v", err)
+		}
+		if len(actual) > 0 {
+			t.Errorf("returned %v; want no failures", actual)
+		}
+	})
+
 	t.Run("excludes superuser-reserved space from total disk size", func(t *testing.T) {
 		// Use the previous test disk setup; just tweak the usage.
 		d.usage = func(path string) (sigar.FileSystemUsage, error) {
@@ -290,6 +306,16 @@ func TestLocal(t *testing.T) {
 	if (stat.Mode & unix.S_IFDIR) == 0 {
 		t.Errorf("Local.Stat(%q) did not stat a directory: %+v", dir, stat)
 	}
+
+	err = disk.Local.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		t.Errorf("Local.Walk(%q) returned error %#v", dir, err)
+	}
 }
 
 // testDisk is a stub implementation of disk.Disk.
@@ -299,6 +325,8 @@ type testDisk struct {
 	filesystems func() (sigar.FileSystemList, error)
 	usage       func(string) (sigar.FileSystemUsage, error)
 	stat        func(string) (*unix.Stat_t, error)
+	walk        func(string, filepath.WalkFunc) error
+	inodes      func(string) (disk.InodeUsage, error)
 }
 
 func (t testDisk) Filesystems() (sigar.FileSystemList, error) {
@@ -322,6 +350,20 @@ func (t testDisk) Stat(path string) (*unix.Stat_t, error) {
 	return t.stat(path)
 }
 
+func (t testDisk) Walk(root string, fn filepath.WalkFunc) error {
+	if t.walk == nil {
+		return t.err
+	}
+	return t.walk(root, fn)
+}
+
+func (t testDisk) Inodes(path string) (disk.InodeUsage, error) {
+	if t.inodes == nil {
+		return disk.InodeUsage{}, t.err
+	}
+	return t.inodes(path)
+}
+
 func scale(n uint64, f float64) uint64 {
 	return uint64(float64(n) * f)
 }
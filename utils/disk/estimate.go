@@ -0,0 +1,252 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// firstNormalObjectID is the lowest OID given to user objects. Relation files
+// named with a lower relfilenode belong to the system catalog.
+const firstNormalObjectID = 16384
+
+// DataSize is the size of the files of a data directory or tablespace, and
+// how many there are.
+type DataSize struct {
+	Relations     uint64 // bytes of user relation files, which link mode does not copy
+	Other         uint64 // bytes of the catalog, WAL, and every other file
+	RelationFiles uint64
+	OtherFiles    uint64 // including directories
+}
+
+func (s DataSize) Total() uint64 {
+	return s.Relations + s.Other
+}
+
+func (s DataSize) TotalFiles() uint64 {
+	return s.RelationFiles + s.OtherFiles
+}
+
+// Need is the space, in bytes, and the number of inodes that the upgrade
+// needs on the filesystem of Path.
+type Need struct {
+	Path  string
+	Bytes uint64
+	Files uint64
+}
+
+// Measure returns the size of the files under path. Symlinks beneath path,
+// such as those to tablespaces, are not followed.
+func Measure(d Disk, path string) (DataSize, error) {
+	var size DataSize
+
+	err := d.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			size.OtherFiles++
+			return nil
+		}
+
+		if isUserRelation(file) {
+			size.Relations += uint64(info.Size())
+			size.RelationFiles++
+		} else {
+			size.Other += uint64(info.Size())
+			size.OtherFiles++
+		}
+		return nil
+	})
+	if err != nil {
+		return DataSize{}, xerrors.Errorf("measuring %s: %w", path, err)
+	}
+
+	return size, nil
+}
+
+// isUserRelation returns whether file is a segment, free space map or
+// visibility map of a user relation. These live in a directory named for the
+// OID of their database, and are named for their relfilenode.
+func isUserRelation(file string) bool {
+	if _, err := strconv.ParseUint(filepath.Base(filepath.Dir(file)), 10, 32); err != nil {
+		return false
+	}
+
+	name := filepath.Base(file)
+	if i := strings.IndexAny(name, "._"); i >= 0 {
+		name = name[:i]
+	}
+
+	relfilenode, err := strconv.ParseUint(name, 10, 32)
+	return err == nil && relfilenode >= firstNormalObjectID
+}
+
+// Tablespaces returns the links to the tablespaces of a data directory.
+func Tablespaces(d Disk, datadir string) ([]string, error) {
+	dir := filepath.Join(datadir, "pg_tblspc")
+
+	var links []string
+	err := d.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == dir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			links = append(links, filepath.Join(dir, info.Name()))
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, xerrors.Errorf("finding the tablespaces of %s: %w", datadir, err)
+	}
+
+	return links, nil
+}
+
+// MeasureAll returns the combined size of the given paths.
+func MeasureAll(d Disk, paths ...string) (DataSize, error) {
+	var total DataSize
+	for _, path := range paths {
+		size, err := Measure(d, path)
+		if err != nil {
+			return DataSize{}, err
+		}
+
+		total.Relations += size.Relations
+		total.Other += size.Other
+		total.RelationFiles += size.RelationFiles
+		total.OtherFiles += size.OtherFiles
+	}
+
+	return total, nil
+}
+
+// ExistingDir returns dir if it exists, or else the nearest of its parents
+// that does. A directory the upgrade has yet to create takes its space from
+// the filesystem of that parent.
+func ExistingDir(d Disk, dir string) (string, error) {
+	for {
+		_, err := d.Stat(dir)
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", xerrors.Errorf("stat %s: %w", dir, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", xerrors.Errorf("stat %s: %w", dir, err)
+		}
+		dir = parent
+	}
+}
+
+// UpgradeNeeds returns the space needed to upgrade a data directory and its
+// tablespaces. The target cluster is created beside the source, on the same
+// filesystems, and needs as much space and as many inodes as the files it
+// copies. When linked is set the user relation files are linked rather than
+// copied, as link mode does for the master and primaries. The target standby
+// and mirrors are always full copies.
+func UpgradeNeeds(d Disk, datadir string, linked bool) ([]Need, error) {
+	paths, err := Tablespaces(d, datadir)
+	if err != nil {
+		return nil, err
+	}
+	paths = append([]string{datadir}, paths...)
+
+	var needs []Need
+	for _, path := range paths {
+		size, err := Measure(d, path)
+		if err != nil {
+			return nil, err
+		}
+
+		need := Need{Path: path, Bytes: size.Total(), Files: size.TotalFiles()}
+		if linked {
+			need.Bytes, need.Files = size.Other, size.OtherFiles
+		}
+
+		needs = append(needs, need)
+	}
+
+	return needs, nil
+}
+
+// CheckNeeds adds up the needs on each filesystem, and compares the totals to
+// the space and inodes available there. Any filesystems that don't have enough
+// will be given an entry in the returned SpaceFailures map, showing the total
+// that is required. As with CheckUsage, the space is given in KiB.
+//
+// As with CheckUsage, space reserved for the superuser is not considered to be
+// available.
+func CheckNeeds(d Disk, needs ...Need) (SpaceFailures, error) {
+	fsByID, err := filesystemsByID(d)
+	if err != nil {
+		return nil, err
+	}
+
+	type filesystem struct {
+		name      string
+		required  uint64 // in bytes
+		available uint64 // in KiB
+	}
+
+	byID := make(map[uint64]*filesystem)
+	inodes := make(map[uint64]*inodeNeed)
+	for _, need := range needs {
+		name, id, err := filesystemOf(d, fsByID, need.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		fs, ok := byID[id]
+		if !ok {
+			usage, err := d.Usage(need.Path)
+			if err != nil {
+				return nil, xerrors.Errorf("getting fs usage for %s: %w", need.Path, err)
+			}
+
+			fs = &filesystem{name: name, available: usage.Avail}
+			byID[id] = fs
+			inodes[id] = &inodeNeed{filesystem: name, path: need.Path}
+		}
+
+		fs.required += need.Bytes
+		inodes[id].files += need.Files
+	}
+
+	failures := make(SpaceFailures)
+	for _, fs := range byID {
+		required := (fs.required + 1023) / 1024 // round up to KiB
+
+		gplog.Debug("%s: %d KiB avail of %d KiB required", fs.name, fs.available, required)
+
+		if fs.available < required {
+			failures[fs.name] = &idl.CheckDiskSpaceReply_DiskUsage{
+				Required:  required,
+				Available: fs.available,
+			}
+		}
+	}
+
+	if err := checkInodes(d, inodes, failures); err != nil {
+		return nil, err
+	}
+
+	return failures, nil
+}
@@ -0,0 +1,287 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package disk_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	sigar "github.com/cloudfoundry/gosigar"
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+	"golang.org/x/xerrors"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/testutils"
+	"github.com/greenplum-db/gpupgrade/utils/disk"
+)
+
+func TestEstimate(t *testing.T) {
+	testhelper.SetupTestLogger()
+
+	// Lay out a data directory with a tablespace on a second filesystem:
+	//
+	//   fs1/datadir/base/16384/{16390,16390_fsm,1259}
+	//   fs1/datadir/global/1262
+	//   fs1/datadir/pg_tblspc/16400 -> fs2/tablespace/2
+	//   fs2/tablespace/2/GPDB_6_301908232/16384/16401
+	root := testutils.GetTempDir(t, "")
+	defer testutils.MustRemoveAll(t, root)
+
+	fs1 := filepath.Join(root, "fs1")
+	fs2 := filepath.Join(root, "fs2")
+	datadir := filepath.Join(fs1, "datadir")
+	tablespace := filepath.Join(fs2, "tablespace", "2")
+	link := filepath.Join(datadir, "pg_tblspc", "16400")
+
+	mustWriteFile(t, filepath.Join(datadir, "base", "16384", "16390"), 1000)
+	mustWriteFile(t, filepath.Join(datadir, "base", "16384", "16390_fsm"), 100)
+	mustWriteFile(t, filepath.Join(datadir, "base", "16384", "1259"), 200)
+	mustWriteFile(t, filepath.Join(datadir, "global", "1262"), 300)
+	mustWriteFile(t, filepath.Join(tablespace, "GPDB_6_301908232", "16384", "16401"), 2000)
+
+	if err := os.MkdirAll(filepath.Dir(link), 0700); err != nil {
+		t.Fatalf("creating pg_tblspc: %+v", err)
+	}
+	if err := os.Symlink(tablespace, link); err != nil {
+		t.Fatalf("linking tablespace: %+v", err)
+	}
+
+	// fs1 has 1 KiB available, and fs2 has 5 KiB.
+	d := testDisk{
+		err: errors.New("should never happen"),
+
+		filesystems: func() (sigar.FileSystemList, error) {
+			return sigar.FileSystemList{List: []sigar.FileSystem{
+				{DirName: fs1},
+				{DirName: fs2},
+			}}, nil
+		},
+
+		usage: func(path string) (sigar.FileSystemUsage, error) {
+			u := sigar.FileSystemUsage{Total: 10, Avail: 1}
+			if onFilesystem(path, fs2) {
+				u.Avail = 5
+			}
+			return u, nil
+		},
+
+		stat: func(path string) (*unix.Stat_t, error) {
+			if onFilesystem(path, fs2) {
+				return &unix.Stat_t{Dev: 2}, nil
+			}
+			return &unix.Stat_t{Dev: 1}, nil
+		},
+
+		walk: disk.Local.Walk,
+
+		// fs1 has 5 inodes free, and fs2 has 100.
+		inodes: func(path string) (disk.InodeUsage, error) {
+			if onFilesystem(path, fs2) {
+				return disk.InodeUsage{Total: 1000, Free: 100}, nil
+			}
+			return disk.InodeUsage{Total: 1000, Free: 5}, nil
+		},
+	}
+
+	t.Run("measures user relations apart from other files", func(t *testing.T) {
+		size, err := disk.Measure(d, datadir)
+		if err != nil {
+			t.Fatalf("Measure() returned error %+v", err)
+		}
+
+		// The other files are the four regular files, the five directories
+		// and the tablespace link.
+		expected := disk.DataSize{Relations: 1100, Other: 500, RelationFiles: 2, OtherFiles: 8}
+		if size != expected {
+			t.Errorf("got size %+v want %+v", size, expected)
+		}
+	})
+
+	t.Run("finds the tablespaces of a data directory", func(t *testing.T) {
+		links, err := disk.Tablespaces(d, datadir)
+		if err != nil {
+			t.Fatalf("Tablespaces() returned error %+v", err)
+		}
+
+		if !reflect.DeepEqual(links, []string{link}) {
+			t.Errorf("got tablespaces %q want %q", links, []string{link})
+		}
+	})
+
+	t.Run("finds no tablespaces without pg_tblspc", func(t *testing.T) {
+		links, err := disk.Tablespaces(d, tablespace)
+		if err != nil {
+			t.Fatalf("Tablespaces() returned error %+v", err)
+		}
+
+		if len(links) != 0 {
+			t.Errorf("got tablespaces %q, want none", links)
+		}
+	})
+
+	t.Run("measures a data directory with its tablespace", func(t *testing.T) {
+		size, err := disk.MeasureAll(d, datadir, tablespace)
+		if err != nil {
+			t.Fatalf("MeasureAll() returned error %+v", err)
+		}
+
+		expected := disk.DataSize{Relations: 3100, Other: 500, RelationFiles: 3, OtherFiles: 11}
+		if size != expected {
+			t.Errorf("got size %+v want %+v", size, expected)
+		}
+	})
+
+	t.Run("needs the whole size of the data when it is copied", func(t *testing.T) {
+		needs, err := disk.UpgradeNeeds(d, datadir, false)
+		if err != nil {
+			t.Fatalf("UpgradeNeeds() returned error %+v", err)
+		}
+
+		expected := []disk.Need{
+			{Path: datadir, Bytes: 1600, Files: 10},
+			{Path: link, Bytes: 2000, Files: 4},
+		}
+		if !reflect.DeepEqual(needs, expected) {
+			t.Errorf("got needs %+v want %+v", needs, expected)
+		}
+	})
+
+	t.Run("needs the size of all but the user relations when they are linked", func(t *testing.T) {
+		needs, err := disk.UpgradeNeeds(d, datadir, true)
+		if err != nil {
+			t.Fatalf("UpgradeNeeds() returned error %+v", err)
+		}
+
+		expected := []disk.Need{
+			{Path: datadir, Bytes: 500, Files: 8},
+			{Path: link, Bytes: 0, Files: 3},
+		}
+		if !reflect.DeepEqual(needs, expected) {
+			t.Errorf("got needs %+v want %+v", needs, expected)
+		}
+	})
+
+	t.Run("adds up the needs of each filesystem", func(t *testing.T) {
+		failures, err := disk.CheckNeeds(d,
+			disk.Need{Path: filepath.Join(fs1, "primary1"), Bytes: 600},
+			disk.Need{Path: filepath.Join(fs1, "primary2"), Bytes: 600},
+			disk.Need{Path: link, Bytes: 2000},
+		)
+		if err != nil {
+			t.Fatalf("CheckNeeds() returned error %+v", err)
+		}
+
+		expected := disk.SpaceFailures{
+			fs1: &idl.CheckDiskSpaceReply_DiskUsage{Required: 2, Available: 1},
+		}
+		if !reflect.DeepEqual(failures, expected) {
+			t.Errorf("got failures %v want %v", failures, expected)
+		}
+	})
+
+	t.Run("reports filesystems without enough free inodes", func(t *testing.T) {
+		failures, err := disk.CheckNeeds(d,
+			disk.Need{Path: filepath.Join(fs1, "primary1"), Files: 3},
+			disk.Need{Path: filepath.Join(fs1, "primary2"), Files: 3},
+			disk.Need{Path: link, Files: 50},
+		)
+		if err != nil {
+			t.Fatalf("CheckNeeds() returned error %+v", err)
+		}
+
+		expected := disk.SpaceFailures{
+			fs1: &idl.CheckDiskSpaceReply_DiskUsage{InodesRequired: 6, InodesAvailable: 5},
+		}
+		if !reflect.DeepEqual(failures, expected) {
+			t.Errorf("got failures %v want %v", failures, expected)
+		}
+	})
+
+	t.Run("finds the nearest existing directory", func(t *testing.T) {
+		d := d
+		d.stat = func(path string) (*unix.Stat_t, error) {
+			stat := new(unix.Stat_t)
+			return stat, unix.Stat(path, stat)
+		}
+
+		dir, err := disk.ExistingDir(d, filepath.Join(fs1, "state", "tablespaces"))
+		if err != nil {
+			t.Fatalf("ExistingDir() returned error %+v", err)
+		}
+		if dir != fs1 {
+			t.Errorf("got dir %q want %q", dir, fs1)
+		}
+
+		dir, err = disk.ExistingDir(d, datadir)
+		if err != nil {
+			t.Fatalf("ExistingDir() returned error %+v", err)
+		}
+		if dir != datadir {
+			t.Errorf("got dir %q want %q", dir, datadir)
+		}
+	})
+
+	t.Run("bubbles up any errors", func(t *testing.T) {
+		d := d
+		d.walk = func(string, filepath.WalkFunc) error {
+			return d.err
+		}
+
+		_, err := disk.UpgradeNeeds(d, datadir, false)
+		if !xerrors.Is(err, d.err) {
+			t.Errorf("UpgradeNeeds() returned %#v want %#v", err, d.err)
+		}
+
+		d.inodes = nil
+		_, err = disk.CheckNeeds(d, disk.Need{Path: datadir, Bytes: 1})
+		if !xerrors.Is(err, d.err) {
+			t.Errorf("CheckNeeds() returned %#v want %#v", err, d.err)
+		}
+
+		d.usage = nil
+		_, err = disk.CheckNeeds(d, disk.Need{Path: datadir, Bytes: 1})
+		if !xerrors.Is(err, d.err) {
+			t.Errorf("CheckNeeds() returned %#v want %#v", err, d.err)
+		}
+
+		d.stat = nil
+		_, err = disk.ExistingDir(d, datadir)
+		if !xerrors.Is(err, d.err) {
+			t.Errorf("ExistingDir() returned %#v want %#v", err, d.err)
+		}
+	})
+}
+
+// onFilesystem returns whether path, with its symlinks resolved, is under the
+// directory of the given filesystem.
+func onFilesystem(path string, fs string) bool {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	resolvedFS, err := filepath.EvalSymlinks(fs)
+	if err != nil {
+		resolvedFS = fs
+	}
+
+	return strings.HasPrefix(path, resolvedFS)
+}
+
+func mustWriteFile(t *testing.T, path string, size int) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("creating %s: %+v", filepath.Dir(path), err)
+	}
+
+	if err := ioutil.WriteFile(path, make([]byte, size), 0600); err != nil {
+		t.Fatalf("writing %s: %+v", path, err)
+	}
+}
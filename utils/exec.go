@@ -0,0 +1,74 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"golang.org/x/xerrors"
+)
+
+// TerminateGracePeriod is how long RunContext waits for a cancelled command to
+// exit after asking it to terminate, before killing it.
+var TerminateGracePeriod = 10 * time.Second
+
+// RunContext starts cmd and waits for it to complete, like cmd.Run(). If ctx
+// is cancelled first, the command and any children it has started are sent
+// SIGTERM, followed by SIGKILL if they have not exited after
+// TerminateGracePeriod. The returned error then wraps ctx.Err().
+//
+// Unlike exec.CommandContext, this gives processes such as pg_upgrade a chance
+// to shut down the postgres instances they have started.
+func RunContext(ctx context.Context, cmd *exec.Cmd) error {
+	if ctx.Done() == nil {
+		return cmd.Run()
+	}
+
+	// Put the command in its own process group, so that it can be signalled
+	// along with its children.
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	name := filepath.Base(cmd.Path)
+	gplog.Info("terminating %s (pid %d)", name, cmd.Process.Pid)
+	signalProcessGroup(cmd, syscall.SIGTERM)
+
+	select {
+	case <-done:
+	case <-time.After(TerminateGracePeriod):
+		gplog.Warn("%s (pid %d) did not exit after %s; killing it", name, cmd.Process.Pid, TerminateGracePeriod)
+		signalProcessGroup(cmd, syscall.SIGKILL)
+		<-done
+	}
+
+	return xerrors.Errorf("%s: %w", name, ctx.Err())
+}
+
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) {
+	// A negative pid signals the whole process group. Errors are ignored since
+	// the processes may have already exited.
+	_ = syscall.Kill(-cmd.Process.Pid, sig)
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"golang.org/x/xerrors"
+)
+
+func TestRunContext(t *testing.T) {
+	testhelper.SetupTestLogger()
+
+	t.Run("runs the command to completion", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var out bytes.Buffer
+		cmd := exec.Command("echo", "hello")
+		cmd.Stdout = &out
+
+		if err := RunContext(ctx, cmd); err != nil {
+			t.Errorf("RunContext() returned error %+v", err)
+		}
+
+		if out.String() != "hello\n" {
+			t.Errorf("got stdout %q want %q", out.String(), "hello\n")
+		}
+	})
+
+	t.Run("returns the command's error", func(t *testing.T) {
+		err := RunContext(context.Background(), exec.Command("false"))
+
+		var exitErr *exec.ExitError
+		if !xerrors.As(err, &exitErr) {
+			t.Errorf("got error %#v want type %T", err, exitErr)
+		}
+	})
+
+	t.Run("terminates the command and its children when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		// The child sleep keeps the stdout pipe open, so Wait only returns
+		// once it has been terminated as well.
+		var out bytes.Buffer
+		cmd := exec.Command("sh", "-c", "sleep 30 & wait")
+		cmd.Stdout = &out
+
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		start := time.Now()
+		err := RunContext(ctx, cmd)
+		if !xerrors.Is(err, context.Canceled) {
+			t.Errorf("got error %#v want %#v", err, context.Canceled)
+		}
+
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("command took %s to terminate", elapsed)
+		}
+	})
+
+	t.Run("kills the command if it does not exit after the grace period", func(t *testing.T) {
+		defer func(period time.Duration) { TerminateGracePeriod = period }(TerminateGracePeriod)
+		TerminateGracePeriod = 100 * time.Millisecond
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var out bytes.Buffer
+		cmd := exec.Command("sh", "-c", `trap "" TERM; echo ready; sleep 30`)
+		cmd.Stdout = &out
+
+		time.AfterFunc(200*time.Millisecond, cancel)
+
+		start := time.Now()
+		err := RunContext(ctx, cmd)
+		if !xerrors.Is(err, context.Canceled) {
+			t.Errorf("got error %#v want %#v", err, context.Canceled)
+		}
+
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("command took %s to be killed", elapsed)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+// Package handshake lets the CLI, hub and agents check that they were built
+// from the same version of gpupgrade before talking to each other, since the
+// messages they exchange change from version to version.
+package handshake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcStatus "google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+)
+
+// Capabilities lists the optional features of this build that a peer may want
+// to know about. Names are only ever added.
+var Capabilities = []string{
+	"agent-health",
+	"cancel",
+	"check-ports",
+	"disk-space-estimate",
+	"metrics",
+	"plan",
+	"redeploy-agents",
+	"remote-shell",
+	"tls",
+}
+
+// Reply returns the HelloReply of this build, which is at version.
+func Reply(version string) *idl.HelloReply {
+	return &idl.HelloReply{
+		Version:      version,
+		Capabilities: Capabilities,
+	}
+}
+
+// HelloFunc is the Hello method of an idl.CliToHubClient or idl.AgentClient.
+type HelloFunc func(context.Context, *idl.HelloRequest, ...grpc.CallOption) (*idl.HelloReply, error)
+
+// Hello greets a peer, such as "the hub", and checks that it is at the same
+// version as this build. A peer that predates the handshake is reported as
+// mismatched. Callers skip the handshake when their own version is unknown,
+// as it is for development builds.
+func Hello(ctx context.Context, peer, version string, hello HelloFunc) (*idl.HelloReply, error) {
+	reply, err := hello(ctx, &idl.HelloRequest{
+		Version:      version,
+		Capabilities: Capabilities,
+	})
+	if grpcStatus.Code(err) == codes.Unimplemented {
+		return nil, &VersionMismatchError{Peer: peer, Want: version}
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("greeting %s: %w", peer, err)
+	}
+
+	if reply.Version != version {
+		return reply, &VersionMismatchError{Peer: peer, Version: reply.Version, Want: version}
+	}
+
+	return reply, nil
+}
+
+// ErrVersionMismatch is matched by a *VersionMismatchError.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// VersionMismatchError is returned by Hello when a peer is at a different
+// version.
+type VersionMismatchError struct {
+	Peer    string
+	Version string // empty when the peer predates the handshake
+	Want    string
+}
+
+func (v *VersionMismatchError) Error() string {
+	if v.Version == "" {
+		return fmt.Sprintf("%s is an older version of gpupgrade, want version %s", v.Peer, v.Want)
+	}
+
+	return fmt.Sprintf("%s is gpupgrade version %s, want version %s", v.Peer, v.Version, v.Want)
+}
+
+func (v *VersionMismatchError) Is(err error) bool {
+	return err == ErrVersionMismatch
+}
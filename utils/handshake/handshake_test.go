@@ -0,0 +1,107 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package handshake_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang.org/x/xerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcStatus "google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/idl"
+	"github.com/greenplum-db/gpupgrade/utils/handshake"
+)
+
+func TestHello(t *testing.T) {
+	// replying returns a HelloFunc that replies with the given version, and
+	// records the request it was sent.
+	replying := func(version string, request **idl.HelloRequest) handshake.HelloFunc {
+		return func(_ context.Context, in *idl.HelloRequest, _ ...grpc.CallOption) (*idl.HelloReply, error) {
+			*request = in
+			return handshake.Reply(version), nil
+		}
+	}
+
+	t.Run("greets a peer at the same version", func(t *testing.T) {
+		var request *idl.HelloRequest
+		reply, err := handshake.Hello(context.Background(), "the hub", "1.2.3", replying("1.2.3", &request))
+		if err != nil {
+			t.Fatalf("Hello() returned error %+v", err)
+		}
+
+		expected := &idl.HelloRequest{Version: "1.2.3", Capabilities: handshake.Capabilities}
+		if !reflect.DeepEqual(request, expected) {
+			t.Errorf("sent %+v want %+v", request, expected)
+		}
+
+		if !reflect.DeepEqual(reply, handshake.Reply("1.2.3")) {
+			t.Errorf("got reply %+v want %+v", reply, handshake.Reply("1.2.3"))
+		}
+	})
+
+	t.Run("returns a mismatch for a peer at a different version", func(t *testing.T) {
+		var request *idl.HelloRequest
+		reply, err := handshake.Hello(context.Background(), "the hub", "1.2.3", replying("1.0.0", &request))
+
+		var mismatch *handshake.VersionMismatchError
+		if !xerrors.As(err, &mismatch) {
+			t.Fatalf("got error %#v want type %T", err, mismatch)
+		}
+
+		expected := &handshake.VersionMismatchError{Peer: "the hub", Version: "1.0.0", Want: "1.2.3"}
+		if !reflect.DeepEqual(mismatch, expected) {
+			t.Errorf("got %+v want %+v", mismatch, expected)
+		}
+
+		if !xerrors.Is(err, handshake.ErrVersionMismatch) {
+			t.Errorf("expected error %#v to match %#v", err, handshake.ErrVersionMismatch)
+		}
+
+		if reply.GetVersion() != "1.0.0" {
+			t.Errorf("got reply %+v want version 1.0.0", reply)
+		}
+
+		msg := "the hub is gpupgrade version 1.0.0, want version 1.2.3"
+		if err.Error() != msg {
+			t.Errorf("got message %q want %q", err.Error(), msg)
+		}
+	})
+
+	t.Run("returns a mismatch for a peer that predates the handshake", func(t *testing.T) {
+		unimplemented := func(context.Context, *idl.HelloRequest, ...grpc.CallOption) (*idl.HelloReply, error) {
+			return nil, grpcStatus.Error(codes.Unimplemented, "unknown method Hello")
+		}
+
+		_, err := handshake.Hello(context.Background(), "the agent on host sdw1", "1.2.3", unimplemented)
+		if !xerrors.Is(err, handshake.ErrVersionMismatch) {
+			t.Errorf("got error %#v want %#v", err, handshake.ErrVersionMismatch)
+		}
+
+		msg := "the agent on host sdw1 is an older version of gpupgrade, want version 1.2.3"
+		if err.Error() != msg {
+			t.Errorf("got message %q want %q", err.Error(), msg)
+		}
+	})
+
+	t.Run("returns other errors", func(t *testing.T) {
+		expected := errors.New("ahh!")
+		failing := func(context.Context, *idl.HelloRequest, ...grpc.CallOption) (*idl.HelloReply, error) {
+			return nil, expected
+		}
+
+		_, err := handshake.Hello(context.Background(), "the hub", "1.2.3", failing)
+		if !xerrors.Is(err, expected) {
+			t.Errorf("got error %#v want %#v", err, expected)
+		}
+
+		if xerrors.Is(err, handshake.ErrVersionMismatch) {
+			t.Errorf("expected error %#v not to be a mismatch", err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+// Package health serves the standard gRPC health service from the hub and
+// agents, and lets them report the version of gpupgrade they are running.
+package health
+
+import (
+	"context"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// VersionKey is the response header in which the hub and agents report their
+// version of gpupgrade.
+const VersionKey = "gpupgrade-version"
+
+// Register serves the standard gRPC health service on server. The server is
+// reported as serving until the returned health server is shut down.
+func Register(server *grpc.Server) *health.Server {
+	s := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, s)
+	return s
+}
+
+// SendVersion adds version to the response header of a unary call. It is
+// called from the unary interceptors of the hub and agents.
+func SendVersion(ctx context.Context, version string) {
+	err := grpc.SetHeader(ctx, metadata.Pairs(VersionKey, version))
+	if err != nil {
+		gplog.Debug("failed to send version header: %+v", err)
+	}
+}
+
+// Check asks the health service of a server whether it is serving, and returns
+// the version of gpupgrade that it reported. The version is empty if the
+// server did not report one.
+func Check(ctx context.Context, client grpc_health_v1.HealthClient) (grpc_health_v1.HealthCheckResponse_ServingStatus, string, error) {
+	var header metadata.MD
+	reply, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{}, grpc.Header(&header))
+	if err != nil {
+		return grpc_health_v1.HealthCheckResponse_UNKNOWN, "", err
+	}
+
+	var version string
+	if values := header.Get(VersionKey); len(values) > 0 {
+		version = values[0]
+	}
+
+	return reply.Status, version, nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package health_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/greenplum-db/gpupgrade/utils/health"
+)
+
+func TestCheck(t *testing.T) {
+	testhelper.SetupTestLogger()
+
+	// serve starts a server with the health service, that reports the given
+	// version, and returns a connection to it.
+	serve := func(t *testing.T, version string) (*grpc.ClientConn, func()) {
+		t.Helper()
+
+		interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if version != "" {
+				health.SendVersion(ctx, version)
+			}
+			return handler(ctx, req)
+		}
+
+		server := grpc.NewServer(grpc.UnaryInterceptor(interceptor))
+		healthServer := health.Register(server)
+
+		listener := bufconn.Listen(1024 * 1024)
+		go server.Serve(listener)
+
+		dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.Dial()
+		}
+		conn, err := grpc.DialContext(context.Background(), "bufnet",
+			grpc.WithContextDialer(dialer), grpc.WithInsecure())
+		if err != nil {
+			t.Fatalf("dialing server: %+v", err)
+		}
+
+		return conn, func() {
+			healthServer.Shutdown()
+			conn.Close()
+			server.Stop()
+		}
+	}
+
+	t.Run("reports the status and version of the server", func(t *testing.T) {
+		conn, stop := serve(t, "1.2.3")
+		defer stop()
+
+		status, version, err := health.Check(context.Background(), grpc_health_v1.NewHealthClient(conn))
+		if err != nil {
+			t.Fatalf("Check() returned error %+v", err)
+		}
+
+		if status != grpc_health_v1.HealthCheckResponse_SERVING {
+			t.Errorf("got status %s want %s", status, grpc_health_v1.HealthCheckResponse_SERVING)
+		}
+		if version != "1.2.3" {
+			t.Errorf("got version %q want %q", version, "1.2.3")
+		}
+	})
+
+	t.Run("reports an empty version when the server sends none", func(t *testing.T) {
+		conn, stop := serve(t, "")
+		defer stop()
+
+		_, version, err := health.Check(context.Background(), grpc_health_v1.NewHealthClient(conn))
+		if err != nil {
+			t.Fatalf("Check() returned error %+v", err)
+		}
+
+		if version != "" {
+			t.Errorf("got version %q, want none", version)
+		}
+	})
+
+	t.Run("returns an error when the server is unreachable", func(t *testing.T) {
+		conn, stop := serve(t, "1.2.3")
+		stop()
+
+		_, _, err := health.Check(context.Background(), grpc_health_v1.NewHealthClient(conn))
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var requestDuration = Default.NewHistogram("gpupgrade_grpc_request_duration_seconds",
+	"Time taken to handle gRPC requests, including streaming requests.",
+	DefaultBuckets, "method", "code")
+
+// UnaryServerInterceptor records the latency of unary gRPC requests.
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observe(info.FullMethod, start, err)
+
+	return resp, err
+}
+
+// StreamServerInterceptor records the latency of streaming gRPC requests.
+func StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observe(info.FullMethod, start, err)
+
+	return err
+}
+
+func observe(method string, start time.Time, err error) {
+	requestDuration.Observe(time.Since(start).Seconds(), method, status.Code(err).String())
+}
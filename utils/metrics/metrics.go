@@ -0,0 +1,328 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+// Package metrics provides a minimal registry of counters, gauges and
+// histograms that can be scraped over HTTP in the Prometheus text exposition
+// format. The hub and agents each serve the Default registry when a metrics
+// port is configured.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// Path is the HTTP path that metrics are served on.
+const Path = "/metrics"
+
+// Default is the registry that all gpupgrade metrics are registered with.
+var Default = NewRegistry()
+
+// DefaultBuckets are the histogram bucket upper bounds, in seconds, used for
+// request latencies.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300, 1800}
+
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+type family struct {
+	mu      sync.Mutex
+	name    string
+	help    string
+	kind    string // counter, gauge or histogram
+	labels  []string
+	buckets []float64 // histograms only
+	series  map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64  // counters and gauges
+	counts      []uint64 // histograms only; non-cumulative count per bucket
+	sum         float64  // histograms only
+	count       uint64   // histograms only
+}
+
+func (r *Registry) register(f *family) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.families[f.name]; ok {
+		panic(fmt.Sprintf("metric %q is already registered", f.name))
+	}
+
+	f.series = make(map[string]*series)
+	r.families[f.name] = f
+	return f
+}
+
+// get returns the series for the given label values, creating it if needed.
+// Callers must hold the family's mutex.
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metric %q has labels %q but got values %q", f.name, f.labels, labelValues))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.kind == "histogram" {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+// Counter is a value that only increases, such as a number of bytes copied.
+type Counter struct{ f *family }
+
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(&family{name: name, help: help, kind: "counter", labels: labels})}
+}
+
+// Add increases the counter for the given label values. Negative values are
+// ignored.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+
+	c.f.get(labelValues).value += v
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Gauge is a value that can go up and down, such as a number of running
+// processes.
+type Gauge struct{ f *family }
+
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(&family{name: name, help: help, kind: "gauge", labels: labels})}
+}
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	g.f.get(labelValues).value = v
+}
+
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	g.f.get(labelValues).value += v
+}
+
+// SetState reports an enumerated state in the usual Prometheus style: the
+// series whose final label is the current state is set to 1, and those for
+// every other state are set to 0. The final label of the gauge must be the
+// state.
+func (g *Gauge) SetState(current string, states []string, labelValues ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	for _, state := range states {
+		v := 0.0
+		if state == current {
+			v = 1
+		}
+
+		g.f.get(append(labelValues[:len(labelValues):len(labelValues)], state)).value = v
+	}
+}
+
+// Histogram counts observations, such as request latencies, into buckets.
+type Histogram struct{ f *family }
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &Histogram{r.register(&family{name: name, help: help, kind: "histogram", labels: labels, buckets: buckets})}
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+
+	s := h.f.get(labelValues)
+	s.sum += v
+	s.count++
+
+	i := sort.SearchFloat64s(h.f.buckets, v)
+	if i < len(s.counts) {
+		s.counts[i]++
+	}
+}
+
+// Write renders every registered metric in the Prometheus text exposition
+// format. Metrics and their series are sorted so that the output is stable.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	var families []*family
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	buf := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(buf)
+	}
+
+	return buf.Flush()
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.series) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+
+	var keys []string
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+
+		if f.kind != "histogram" {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, f.labelString(s.labelValues, "", ""), formatFloat(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, upper := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.labelString(s.labelValues, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.labelString(s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, f.labelString(s.labelValues, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, f.labelString(s.labelValues, "", ""), s.count)
+	}
+}
+
+// labelString renders the label set, with an optional extra label used for
+// histogram buckets.
+func (f *family) labelString(values []string, extraName, extraValue string) string {
+	var pairs []string
+	for i, name := range f.labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabel(values[i])))
+	}
+
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extraName, extraValue))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escapeLabel escapes a label value. The exposition format only allows
+// backslashes, double quotes and newlines to be escaped.
+func escapeLabel(s string) string {
+	s = escapeHelp(s)
+	return strings.Replace(s, `"`, `\"`, -1)
+}
+
+func escapeHelp(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return strings.Replace(s, "\n", `\n`, -1)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := r.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Server serves a Registry over HTTP.
+type Server struct {
+	lis    net.Listener
+	server *http.Server
+}
+
+// DefaultAddress is the loopback address that metrics are served on unless
+// another is given. Metrics are served without authentication, so they are
+// only exposed to other hosts when asked for.
+const DefaultAddress = "127.0.0.1"
+
+// Listen starts serving the registry at Path on the given address and port, in
+// the background. An empty address is DefaultAddress, and a port of zero picks
+// a free port; see Addr().
+func Listen(address string, port int, r *Registry) (*Server, error) {
+	if address == "" {
+		address = DefaultAddress
+	}
+
+	lis, err := net.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+	if err != nil {
+		return nil, xerrors.Errorf("listening for metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(Path, r)
+
+	s := &Server{lis: lis, server: &http.Server{Handler: mux}}
+	go s.server.Serve(lis) //nolint
+
+	return s, nil
+}
+
+// Addr returns the address that metrics are served on.
+func (s *Server) Addr() net.Addr {
+	return s.lis.Addr()
+}
+
+func (s *Server) Close() error {
+	return s.server.Close()
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/greenplum-db/gpupgrade/utils/metrics"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Run("renders metrics in the text exposition format", func(t *testing.T) {
+		r := metrics.NewRegistry()
+
+		copied := r.NewCounter("copied_bytes_total", "Bytes copied.", "host")
+		copied.Add(10, "sdw2")
+		copied.Add(5, "sdw1")
+		copied.Add(7, "sdw1")
+		copied.Add(-3, "sdw1") // counters never decrease
+
+		running := r.NewGauge("processes", "Running processes.")
+		running.Add(1)
+		running.Add(1)
+		running.Add(-1)
+
+		state := r.NewGauge("state", "The current state.", "substep", "state")
+		state.SetState("RUNNING", []string{"COMPLETE", "RUNNING"}, "COPY")
+		state.SetState("COMPLETE", []string{"COMPLETE", "RUNNING"}, "COPY")
+
+		latency := r.NewHistogram("latency_seconds", "Request latency.", []float64{1, 0.1}, "method")
+		latency.Observe(0.05, "Get")
+		latency.Observe(0.5, "Get")
+		latency.Observe(2, "Get")
+
+		r.NewGauge("unused", "Never set, so not rendered.")
+
+		buf := new(bytes.Buffer)
+		if err := r.Write(buf); err != nil {
+			t.Fatalf("Write() returned error %+v", err)
+		}
+
+		expected := `# HELP copied_bytes_total Bytes copied.
+# TYPE copied_bytes_total counter
+copied_bytes_total{host="sdw1"} 12
+copied_bytes_total{host="sdw2"} 10
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{method="Get",le="0.1"} 1
+latency_seconds_bucket{method="Get",le="1"} 2
+latency_seconds_bucket{method="Get",le="+Inf"} 3
+latency_seconds_sum{method="Get"} 2.55
+latency_seconds_count{method="Get"} 3
+# HELP processes Running processes.
+# TYPE processes gauge
+processes 1
+# HELP state The current state.
+# TYPE state gauge
+state{substep="COPY",state="COMPLETE"} 1
+state{substep="COPY",state="RUNNING"} 0
+`
+		if buf.String() != expected {
+			t.Errorf("got\n%s\nwant\n%s", buf.String(), expected)
+		}
+	})
+
+	t.Run("escapes label values", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		r.NewGauge("g", "help", "dir").Set(1, "a\\b\"c\nd")
+
+		buf := new(bytes.Buffer)
+		if err := r.Write(buf); err != nil {
+			t.Fatalf("Write() returned error %+v", err)
+		}
+
+		expected := `g{dir="a\\b\"c\nd"} 1`
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("got %q, want it to contain %q", buf.String(), expected)
+		}
+	})
+
+	t.Run("panics when a metric is registered twice", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		r.NewCounter("c", "help")
+
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic")
+			}
+		}()
+
+		r.NewGauge("c", "help")
+	})
+
+	t.Run("panics when given the wrong number of label values", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		c := r.NewCounter("c", "help", "host")
+
+		defer func() {
+			if recover() == nil {
+				t.Error("expected a panic")
+			}
+		}()
+
+		c.Inc()
+	})
+}
+
+func TestListen(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.NewCounter("requests_total", "Requests.").Inc()
+
+	server, err := metrics.Listen("", 0, r)
+	if err != nil {
+		t.Fatalf("Listen() returned error %+v", err)
+	}
+	defer server.Close()
+
+	body := scrape(t, server.Addr())
+
+	expected := "requests_total 1\n"
+	if !strings.Contains(body, expected) {
+		t.Errorf("got %q, want it to contain %q", body, expected)
+	}
+
+	addr, ok := server.Addr().(*net.TCPAddr)
+	if !ok || !addr.IP.IsLoopback() {
+		t.Errorf("got address %v, want metrics to be served on loopback by default", server.Addr())
+	}
+}
+
+func TestServerInterceptors(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return status.Error(codes.NotFound, "no such thing")
+	}
+
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(metrics.UnaryServerInterceptor),
+		grpc.StreamInterceptor(metrics.StreamServerInterceptor),
+		grpc.UnknownServiceHandler(handler),
+	)
+	go server.Serve(lis) //nolint
+	defer server.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	err = conn.Invoke(context.Background(), "/test.Service/Missing", &empty{}, &empty{})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("got error %+v, want code %s", err, codes.NotFound)
+	}
+
+	metricsServer, err := metrics.Listen("", 0, metrics.Default)
+	if err != nil {
+		t.Fatalf("Listen() returned error %+v", err)
+	}
+	defer metricsServer.Close()
+
+	body := scrape(t, metricsServer.Addr())
+
+	expected := `gpupgrade_grpc_request_duration_seconds_count{method="/test.Service/Missing",code="NotFound"} 1`
+	if !strings.Contains(body, expected) {
+		t.Errorf("got %q, want it to contain %q", body, expected)
+	}
+}
+
+func scrape(t *testing.T, addr net.Addr) string {
+	t.Helper()
+
+	port := addr.(*net.TCPAddr).Port
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d%s", port, metrics.Path))
+	if err != nil {
+		t.Fatalf("scraping metrics: %+v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading metrics: %+v", err)
+	}
+
+	return string(body)
+}
+
+// empty is a message with no fields; it is encoded as an empty protobuf.
+type empty struct{}
+
+func (*empty) Reset()         {}
+func (*empty) String() string { return "" }
+func (*empty) ProtoMessage()  {}
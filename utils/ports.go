@@ -0,0 +1,25 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"net"
+	"strconv"
+)
+
+// PortsInUse returns the ports that cannot be listened on, on any address of
+// this host, because they are already taken.
+func PortsInUse(ports []int) []int {
+	var inUse []int
+	for _, port := range ports {
+		listener, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(port)))
+		if err != nil {
+			inUse = append(inUse, port)
+			continue
+		}
+		listener.Close()
+	}
+
+	return inUse
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2017-2020 VMware, Inc. or its affiliates
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestPortsInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listening: %+v", err)
+	}
+	defer listener.Close()
+	busy := listener.Addr().(*net.TCPAddr).Port
+
+	// Find a free port by closing a listener.
+	free, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listening: %+v", err)
+	}
+	freePort := free.Addr().(*net.TCPAddr).Port
+	free.Close()
+
+	inUse := PortsInUse([]int{freePort, busy})
+
+	expected := []int{busy}
+	if !reflect.DeepEqual(inUse, expected) {
+		t.Errorf("got ports in use %v want %v", inUse, expected)
+	}
+}